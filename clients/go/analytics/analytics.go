@@ -0,0 +1,431 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+// Package analytics aggregates usage across many CXDB contexts.
+//
+// A run lists contexts, reads each one's turns, and folds every canonical
+// ConversationItem inside the time range into a Report: turns per client tag,
+// token and cost totals, tool usage, and error rates. Reports are written to
+// one or more Sinks (CSV, JSON, or a Prometheus pushgateway), which makes the
+// package suitable for a periodic cron job; see cmd/cxdb-analytics.
+//
+// # Basic Usage
+//
+//	src := &analytics.ClientSource{API: api, Client: client}
+//	report, err := analytics.Run(ctx, src,
+//	    analytics.WithRange(time.Now().Add(-24*time.Hour), time.Now()),
+//	    analytics.WithPricing(pricing),
+//	)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	_ = analytics.NewCSVSink(os.Stdout).Write(ctx, report)
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	cxdb "github.com/strongdm/ai-cxdb/clients/go"
+	"github.com/strongdm/ai-cxdb/clients/go/httpclient"
+	"github.com/strongdm/ai-cxdb/clients/go/types"
+)
+
+// UntaggedClient is the client tag reported for contexts without one.
+const UntaggedClient = "(none)"
+
+// ContextInfo describes a context to scan.
+type ContextInfo struct {
+	ContextID      uint64
+	ClientTag      string
+	CreatedAt      time.Time
+	LastActivityAt time.Time
+}
+
+// Source lists contexts and reads their turns.
+type Source interface {
+	// Contexts returns the contexts to consider.
+	Contexts(ctx context.Context) ([]ContextInfo, error)
+
+	// Turns returns up to limit of the newest turns of a context, oldest
+	// first, with payloads.
+	Turns(ctx context.Context, contextID uint64, limit uint32) ([]cxdb.TurnRecord, error)
+}
+
+// ErrContextListTruncated means more contexts exist than a ClientSource
+// lists. The context listing cannot be paged, so raise ListLimit rather
+// than report on part of the org.
+var ErrContextListTruncated = errors.New("analytics: context list truncated")
+
+// ClientSource lists contexts through the HTTP API and reads turns over the
+// binary protocol.
+type ClientSource struct {
+	API    *httpclient.Client
+	Client *cxdb.Client
+
+	// ListLimit caps how many contexts are listed (default 1000). Contexts
+	// fails with ErrContextListTruncated if there are more.
+	ListLimit int
+
+	// Tag restricts listing to one client tag.
+	Tag string
+}
+
+// Contexts implements Source.
+func (s *ClientSource) Contexts(ctx context.Context) ([]ContextInfo, error) {
+	limit := s.ListLimit
+	if limit == 0 {
+		limit = 1000
+	}
+	// Ask for one more than the limit to tell a full listing from a cut one.
+	list, err := s.API.ListContexts(ctx, &httpclient.ListContextsParams{Limit: limit + 1, Tag: s.Tag})
+	if err != nil {
+		return nil, fmt.Errorf("list contexts: %w", err)
+	}
+	if len(list.Contexts) > limit {
+		return nil, fmt.Errorf("list contexts: %w: more than %d contexts", ErrContextListTruncated, limit)
+	}
+	out := make([]ContextInfo, 0, len(list.Contexts))
+	for _, c := range list.Contexts {
+		id, err := strconv.ParseUint(c.ContextID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("list contexts: invalid context id %q", c.ContextID)
+		}
+		info := ContextInfo{ContextID: id, ClientTag: c.ClientTag}
+		if c.CreatedAtUnixMs > 0 {
+			info.CreatedAt = time.UnixMilli(c.CreatedAtUnixMs)
+		}
+		if c.LastActivityAt > 0 {
+			info.LastActivityAt = time.UnixMilli(c.LastActivityAt)
+		}
+		out = append(out, info)
+	}
+	return out, nil
+}
+
+// Turns implements Source.
+func (s *ClientSource) Turns(ctx context.Context, contextID uint64, limit uint32) ([]cxdb.TurnRecord, error) {
+	return s.Client.GetLast(ctx, contextID, cxdb.GetLastOptions{Limit: limit, IncludePayload: true})
+}
+
+// =============================================================================
+// Options
+// =============================================================================
+
+// Option configures aggregation.
+type Option func(*options)
+
+type options struct {
+	from, to time.Time
+	pricing  Pricing
+	maxTurns uint32
+}
+
+func defaultOptions() options {
+	return options{maxTurns: 10000}
+}
+
+// WithRange restricts aggregation to items timestamped in [from, to).
+// A zero bound is open. Items without a timestamp are counted whenever their
+// context falls in the range.
+func WithRange(from, to time.Time) Option {
+	return func(o *options) {
+		o.from, o.to = from, to
+	}
+}
+
+// WithPricing sets the per-model prices used to compute CostUSD.
+func WithPricing(p Pricing) Option {
+	return func(o *options) {
+		o.pricing = p
+	}
+}
+
+// WithMaxTurnsPerContext bounds how many of a context's newest turns are
+// read (default 10000).
+func WithMaxTurnsPerContext(n uint32) Option {
+	return func(o *options) {
+		o.maxTurns = n
+	}
+}
+
+// =============================================================================
+// Report
+// =============================================================================
+
+// Stats are usage totals for one slice of the report.
+type Stats struct {
+	Contexts       int64   `json:"contexts"`
+	Turns          int64   `json:"turns"`
+	AssistantTurns int64   `json:"assistant_turns"`
+	ToolCalls      int64   `json:"tool_calls"`
+	InputTokens    int64   `json:"input_tokens"`
+	OutputTokens   int64   `json:"output_tokens"`
+	CachedTokens   int64   `json:"cached_tokens"`
+	CostUSD        float64 `json:"cost_usd"`
+	Errors         int64   `json:"errors"`
+}
+
+// ErrorRate is the fraction of turns that recorded an error.
+func (s *Stats) ErrorRate() float64 {
+	if s.Turns == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Turns)
+}
+
+// ToolStats are usage totals for one tool.
+type ToolStats struct {
+	Calls  int64 `json:"calls"`
+	Errors int64 `json:"errors"`
+}
+
+// ErrorRate is the fraction of calls that failed.
+func (s *ToolStats) ErrorRate() float64 {
+	if s.Calls == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Calls)
+}
+
+// Report is the result of an aggregation run.
+type Report struct {
+	From        time.Time `json:"from,omitempty"`
+	To          time.Time `json:"to,omitempty"`
+	GeneratedAt time.Time `json:"generated_at"`
+
+	// Totals covers every scanned context.
+	Totals Stats `json:"totals"`
+
+	// ByClientTag splits totals by the context's client tag.
+	ByClientTag map[string]*Stats `json:"by_client_tag"`
+
+	// ByModel splits token and cost totals by the model in turn metrics.
+	ByModel map[string]*Stats `json:"by_model"`
+
+	// Tools counts tool invocations by tool name.
+	Tools map[string]*ToolStats `json:"tools"`
+
+	// ContextErrors lists contexts that could not be read.
+	ContextErrors map[uint64]string `json:"context_errors,omitempty"`
+}
+
+// =============================================================================
+// Aggregation
+// =============================================================================
+
+// Run scans every context from src and aggregates it into a report. A
+// context that fails to read is recorded in Report.ContextErrors rather than
+// aborting the run; a cancelled ctx aborts it.
+func Run(ctx context.Context, src Source, opts ...Option) (*Report, error) {
+	a := NewAggregator(opts...)
+	infos, err := src.Contexts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("analytics: %w", err)
+	}
+	for _, info := range infos {
+		if !a.inRange(info) {
+			continue
+		}
+		turns, err := src.Turns(ctx, info.ContextID, a.opts.maxTurns)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("analytics: %w", ctx.Err())
+			}
+			a.report.ContextErrors[info.ContextID] = err.Error()
+			continue
+		}
+		a.Add(info, turns)
+	}
+	return a.Report(), nil
+}
+
+// Aggregator folds contexts into a Report. It is not safe for concurrent use.
+type Aggregator struct {
+	opts   options
+	report *Report
+}
+
+// NewAggregator creates an empty aggregator.
+func NewAggregator(opts ...Option) *Aggregator {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Aggregator{
+		opts: o,
+		report: &Report{
+			From:          o.from,
+			To:            o.to,
+			ByClientTag:   make(map[string]*Stats),
+			ByModel:       make(map[string]*Stats),
+			Tools:         make(map[string]*ToolStats),
+			ContextErrors: make(map[uint64]string),
+		},
+	}
+}
+
+// inRange reports whether a context may have activity in the range.
+func (a *Aggregator) inRange(info ContextInfo) bool {
+	if !a.opts.from.IsZero() && !info.LastActivityAt.IsZero() && info.LastActivityAt.Before(a.opts.from) {
+		return false
+	}
+	if !a.opts.to.IsZero() && !info.CreatedAt.IsZero() && !info.CreatedAt.Before(a.opts.to) {
+		return false
+	}
+	return true
+}
+
+// Add folds one context's turns into the report. Non-canonical and
+// undecodable turns are skipped.
+func (a *Aggregator) Add(info ContextInfo, turns []cxdb.TurnRecord) {
+	tag := info.ClientTag
+	if tag == "" {
+		tag = UntaggedClient
+	}
+	byTag := a.report.ByClientTag[tag]
+	if byTag == nil {
+		byTag = &Stats{}
+		a.report.ByClientTag[tag] = byTag
+	}
+
+	counted := false
+	for _, t := range turns {
+		if t.TypeID != types.TypeIDConversationItem && t.TypeID != types.TypeIDConversationItemLegacy {
+			continue
+		}
+		var item types.ConversationItem
+		if err := cxdb.DecodeMsgpackInto(t.Payload, &item); err != nil {
+			continue
+		}
+		if !a.itemInRange(&item) {
+			continue
+		}
+		if !counted {
+			counted = true
+			a.report.Totals.Contexts++
+			byTag.Contexts++
+		}
+		a.addItem(&item, &a.report.Totals, byTag)
+	}
+}
+
+func (a *Aggregator) itemInRange(item *types.ConversationItem) bool {
+	if item.Timestamp == 0 {
+		return true
+	}
+	ts := time.UnixMilli(item.Timestamp)
+	if !a.opts.from.IsZero() && ts.Before(a.opts.from) {
+		return false
+	}
+	if !a.opts.to.IsZero() && !ts.Before(a.opts.to) {
+		return false
+	}
+	return true
+}
+
+func (a *Aggregator) addItem(item *types.ConversationItem, slices ...*Stats) {
+	failed := item.Status == types.ItemStatusError ||
+		(item.System != nil && item.System.Kind == types.SystemKindError) ||
+		(item.ToolResult != nil && item.ToolResult.IsError)
+
+	var calls int64
+	var m *types.TurnMetrics
+	if turn := item.Turn; turn != nil {
+		m = turn.Metrics
+		for _, tc := range turn.ToolCalls {
+			calls++
+			callFailed := tc.Status == types.ToolCallStatusError || tc.Error != nil
+			a.tool(tc.Name).Calls++
+			if callFailed {
+				a.tool(tc.Name).Errors++
+				failed = true
+			}
+		}
+	}
+	if item.ToolCall != nil {
+		calls++
+		a.tool(item.ToolCall.Name).Calls++
+	}
+
+	var cost float64
+	if m != nil {
+		cost = a.opts.pricing.Cost(m)
+		model := m.Model
+		if model == "" {
+			model = UntaggedClient
+		}
+		byModel := a.report.ByModel[model]
+		if byModel == nil {
+			byModel = &Stats{}
+			a.report.ByModel[model] = byModel
+		}
+		slices = append(slices, byModel)
+	}
+
+	for _, s := range slices {
+		s.Turns++
+		s.ToolCalls += calls
+		if item.Turn != nil || item.Assistant != nil {
+			s.AssistantTurns++
+		}
+		if failed {
+			s.Errors++
+		}
+		if m != nil {
+			s.InputTokens += m.InputTokens
+			s.OutputTokens += m.OutputTokens
+			if m.CachedTokens != nil {
+				s.CachedTokens += *m.CachedTokens
+			}
+			s.CostUSD += cost
+		}
+	}
+}
+
+func (a *Aggregator) tool(name string) *ToolStats {
+	s := a.report.Tools[name]
+	if s == nil {
+		s = &ToolStats{}
+		a.report.Tools[name] = s
+	}
+	return s
+}
+
+// Report returns the aggregated report, stamped with the current time.
+func (a *Aggregator) Report() *Report {
+	a.report.GeneratedAt = time.Now().UTC()
+	return a.report
+}
+
+// =============================================================================
+// Pricing
+// =============================================================================
+
+// Price is the USD cost per million tokens for a model.
+type Price struct {
+	Input       float64 `json:"input"`
+	Output      float64 `json:"output"`
+	CachedInput float64 `json:"cached_input,omitempty"`
+}
+
+// Pricing maps model names to prices. Models without an entry cost nothing.
+type Pricing map[string]Price
+
+// Cost returns the USD cost of one turn's metrics. Cached input tokens are
+// billed at CachedInput when it is set, and at Input otherwise.
+func (p Pricing) Cost(m *types.TurnMetrics) float64 {
+	price, ok := p[m.Model]
+	if !ok {
+		return 0
+	}
+	input := float64(m.InputTokens) * price.Input
+	if m.CachedTokens != nil && price.CachedInput > 0 {
+		cached := float64(*m.CachedTokens)
+		input += cached * (price.CachedInput - price.Input)
+	}
+	return (input + float64(m.OutputTokens)*price.Output) / 1e6
+}
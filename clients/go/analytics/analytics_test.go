@@ -0,0 +1,195 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	cxdb "github.com/strongdm/ai-cxdb/clients/go"
+	"github.com/strongdm/ai-cxdb/clients/go/httpclient"
+	"github.com/strongdm/ai-cxdb/clients/go/types"
+)
+
+type fakeSource struct {
+	contexts []ContextInfo
+	turns    map[uint64][]*types.ConversationItem
+	fail     map[uint64]bool
+}
+
+func (f *fakeSource) Contexts(context.Context) ([]ContextInfo, error) {
+	return f.contexts, nil
+}
+
+func (f *fakeSource) Turns(_ context.Context, contextID uint64, _ uint32) ([]cxdb.TurnRecord, error) {
+	if f.fail[contextID] {
+		return nil, errors.New("boom")
+	}
+	var out []cxdb.TurnRecord
+	for i, item := range f.turns[contextID] {
+		payload, err := cxdb.EncodeMsgpack(item)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, cxdb.TurnRecord{
+			TurnID:  contextID*100 + uint64(i),
+			TypeID:  types.TypeIDConversationItem,
+			Payload: payload,
+		})
+	}
+	return out, nil
+}
+
+func at(item *types.ConversationItem, ts time.Time) *types.ConversationItem {
+	item.Timestamp = ts.UnixMilli()
+	return item
+}
+
+func TestRunAggregates(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	cached := int64(400)
+	turn := types.BuildAssistantTurn("done").
+		WithToolCall(types.BuildToolCallItem("c1", "shell", "{}").WithResult("ok", nil).Build()).
+		WithToolCall(types.BuildToolCallItem("c2", "read", "{}").WithError("ENOENT", nil).Build()).
+		WithFullMetrics(&types.TurnMetrics{InputTokens: 1000, OutputTokens: 200, CachedTokens: &cached, Model: "m1"}).
+		Build()
+
+	src := &fakeSource{
+		contexts: []ContextInfo{
+			{ContextID: 1, ClientTag: "cli"},
+			{ContextID: 2},
+			{ContextID: 3, ClientTag: "old", LastActivityAt: now.Add(-48 * time.Hour)},
+			{ContextID: 4, ClientTag: "cli"},
+		},
+		turns: map[uint64][]*types.ConversationItem{
+			1: {at(types.NewUserInput("hi"), now), at(turn, now)},
+			2: {at(types.NewUserInput("too old"), now.Add(-48*time.Hour)), at(types.NewSystemError("bad"), now)},
+			3: {at(types.NewUserInput("skipped"), now)},
+		},
+		fail: map[uint64]bool{4: true},
+	}
+
+	r, err := Run(context.Background(), src,
+		WithRange(now.Add(-time.Hour), now.Add(time.Hour)),
+		WithPricing(Pricing{"m1": {Input: 3, Output: 15, CachedInput: 0.3}}),
+	)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if r.Totals.Contexts != 2 || r.Totals.Turns != 3 || r.Totals.Errors != 2 {
+		t.Errorf("totals = %+v", r.Totals)
+	}
+	cli := r.ByClientTag["cli"]
+	if cli == nil || cli.Turns != 2 || cli.InputTokens != 1000 || cli.ToolCalls != 2 || cli.AssistantTurns != 1 {
+		t.Fatalf("cli = %+v", cli)
+	}
+	// 600 uncached input at $3, 400 cached at $0.30, 200 output at $15.
+	wantCost := (600*3 + 400*0.3 + 200*15) / 1e6
+	if math.Abs(cli.CostUSD-wantCost) > 1e-12 {
+		t.Errorf("cost = %v, want %v", cli.CostUSD, wantCost)
+	}
+	if r.ByClientTag[UntaggedClient].Errors != 1 {
+		t.Errorf("untagged = %+v", r.ByClientTag[UntaggedClient])
+	}
+	if _, ok := r.ByClientTag["old"]; ok {
+		t.Error("context outside range was scanned")
+	}
+	if r.Tools["read"].Errors != 1 || r.Tools["shell"].Calls != 1 || r.Tools["shell"].Errors != 0 {
+		t.Errorf("tools = read %+v shell %+v", r.Tools["read"], r.Tools["shell"])
+	}
+	if r.ContextErrors[4] == "" {
+		t.Error("expected context 4 error to be recorded")
+	}
+	if r.ByModel["m1"].OutputTokens != 200 {
+		t.Errorf("by model = %+v", r.ByModel["m1"])
+	}
+}
+
+// =============================================================================
+// Sinks
+// =============================================================================
+
+func sampleReport() *Report {
+	a := NewAggregator()
+	r := a.Report()
+	r.Totals = Stats{Contexts: 1, Turns: 4, Errors: 1}
+	r.ByClientTag["a\"b"] = &Stats{Turns: 4, Errors: 1}
+	r.Tools["shell"] = &ToolStats{Calls: 2, Errors: 1}
+	return r
+}
+
+func TestCSVSink(t *testing.T) {
+	var b bytes.Buffer
+	if err := NewCSVSink(&b).Write(context.Background(), sampleReport()); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines:\n%s", len(lines), b.String())
+	}
+	if !strings.HasPrefix(lines[1], "total,,1,4,") || !strings.HasSuffix(lines[1], ",1,0.25") {
+		t.Errorf("total row = %q", lines[1])
+	}
+	if lines[3] != "tool,shell,,,,2,,,,,1,0.5" {
+		t.Errorf("tool row = %q", lines[3])
+	}
+}
+
+func TestPushgatewaySink(t *testing.T) {
+	var gotPath, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var b bytes.Buffer
+		_, _ = b.ReadFrom(r.Body)
+		gotPath, gotBody = r.Method+" "+r.URL.Path, b.String()
+	}))
+	defer srv.Close()
+
+	if err := NewPushgatewaySink(srv.URL+"/", "cxdb analytics", nil).Write(context.Background(), sampleReport()); err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "PUT /metrics/job/cxdb analytics" {
+		t.Errorf("request = %q", gotPath)
+	}
+	for _, want := range []string{
+		`cxdb_analytics_turns{client_tag="a\"b"} 4`,
+		`cxdb_analytics_error_rate{client_tag="a\"b"} 0.25`,
+		`cxdb_analytics_tool_errors{tool="shell"} 1`,
+	} {
+		if !strings.Contains(gotBody, want) {
+			t.Errorf("body missing %q:\n%s", want, gotBody)
+		}
+	}
+}
+
+func TestClientSourceReportsTruncatedListing(t *testing.T) {
+	var gotLimit string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotLimit = r.URL.Query().Get("limit")
+		_, _ = io.WriteString(w, `{"contexts":[{"context_id":"1"},{"context_id":"2"},{"context_id":"3"}],"count":3}`)
+	}))
+	defer srv.Close()
+
+	src := &ClientSource{API: httpclient.New(srv.URL), ListLimit: 2}
+	if _, err := src.Contexts(context.Background()); !errors.Is(err, ErrContextListTruncated) {
+		t.Fatalf("err = %v, want ErrContextListTruncated", err)
+	}
+	if gotLimit != "3" {
+		t.Errorf("limit = %s, want one more than ListLimit", gotLimit)
+	}
+
+	src.ListLimit = 3
+	infos, err := src.Contexts(context.Background())
+	if err != nil || len(infos) != 3 {
+		t.Fatalf("Contexts = %v, %v", infos, err)
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Sink writes a report somewhere.
+type Sink interface {
+	Write(ctx context.Context, r *Report) error
+}
+
+// =============================================================================
+// JSON
+// =============================================================================
+
+type jsonSink struct {
+	w io.Writer
+}
+
+// NewJSONSink writes the report as indented JSON.
+func NewJSONSink(w io.Writer) Sink {
+	return &jsonSink{w: w}
+}
+
+func (s *jsonSink) Write(_ context.Context, r *Report) error {
+	enc := json.NewEncoder(s.w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		return fmt.Errorf("analytics: write json: %w", err)
+	}
+	return nil
+}
+
+// =============================================================================
+// CSV
+// =============================================================================
+
+type csvSink struct {
+	w io.Writer
+}
+
+// NewCSVSink writes one row per report slice: the totals, each client tag,
+// each model, and each tool. Tool rows only fill tool_calls and errors.
+func NewCSVSink(w io.Writer) Sink {
+	return &csvSink{w: w}
+}
+
+var csvHeader = []string{
+	"scope", "name", "contexts", "turns", "assistant_turns", "tool_calls",
+	"input_tokens", "output_tokens", "cached_tokens", "cost_usd", "errors", "error_rate",
+}
+
+func (s *csvSink) Write(_ context.Context, r *Report) error {
+	cw := csv.NewWriter(s.w)
+	_ = cw.Write(csvHeader)
+
+	row := func(scope, name string, st *Stats) {
+		_ = cw.Write([]string{
+			scope, name,
+			itoa(st.Contexts), itoa(st.Turns), itoa(st.AssistantTurns), itoa(st.ToolCalls),
+			itoa(st.InputTokens), itoa(st.OutputTokens), itoa(st.CachedTokens),
+			ftoa(st.CostUSD), itoa(st.Errors), ftoa(st.ErrorRate()),
+		})
+	}
+	row("total", "", &r.Totals)
+	for _, k := range sortedKeys(r.ByClientTag) {
+		row("client_tag", k, r.ByClientTag[k])
+	}
+	for _, k := range sortedKeys(r.ByModel) {
+		row("model", k, r.ByModel[k])
+	}
+	for _, k := range sortedKeys(r.Tools) {
+		t := r.Tools[k]
+		_ = cw.Write([]string{"tool", k, "", "", "", itoa(t.Calls), "", "", "", "", itoa(t.Errors), ftoa(t.ErrorRate())})
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("analytics: write csv: %w", err)
+	}
+	return nil
+}
+
+// =============================================================================
+// Prometheus
+// =============================================================================
+
+// WritePrometheus writes the report in the Prometheus text exposition format.
+func WritePrometheus(w io.Writer, r *Report) error {
+	var b bytes.Buffer
+	gauge := func(name, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	}
+
+	statMetrics := []struct {
+		name, help string
+		value      func(*Stats) string
+	}{
+		{"cxdb_analytics_contexts", "Contexts with activity in the report range.", func(s *Stats) string { return itoa(s.Contexts) }},
+		{"cxdb_analytics_turns", "Conversation turns in the report range.", func(s *Stats) string { return itoa(s.Turns) }},
+		{"cxdb_analytics_input_tokens", "Input tokens reported by assistant turns.", func(s *Stats) string { return itoa(s.InputTokens) }},
+		{"cxdb_analytics_output_tokens", "Output tokens reported by assistant turns.", func(s *Stats) string { return itoa(s.OutputTokens) }},
+		{"cxdb_analytics_cost_usd", "Estimated cost in USD.", func(s *Stats) string { return ftoa(s.CostUSD) }},
+		{"cxdb_analytics_error_rate", "Fraction of turns that recorded an error.", func(s *Stats) string { return ftoa(s.ErrorRate()) }},
+	}
+	for _, m := range statMetrics {
+		gauge(m.name, m.help)
+		for _, k := range sortedKeys(r.ByClientTag) {
+			fmt.Fprintf(&b, "%s{client_tag=\"%s\"} %s\n", m.name, escapeLabel(k), m.value(r.ByClientTag[k]))
+		}
+	}
+
+	gauge("cxdb_analytics_tool_calls", "Tool invocations by tool name.")
+	for _, k := range sortedKeys(r.Tools) {
+		fmt.Fprintf(&b, "cxdb_analytics_tool_calls{tool=\"%s\"} %d\n", escapeLabel(k), r.Tools[k].Calls)
+	}
+	gauge("cxdb_analytics_tool_errors", "Failed tool invocations by tool name.")
+	for _, k := range sortedKeys(r.Tools) {
+		fmt.Fprintf(&b, "cxdb_analytics_tool_errors{tool=\"%s\"} %d\n", escapeLabel(k), r.Tools[k].Errors)
+	}
+	gauge("cxdb_analytics_generated_timestamp_seconds", "When the report was generated.")
+	fmt.Fprintf(&b, "cxdb_analytics_generated_timestamp_seconds %d\n", r.GeneratedAt.Unix())
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+type pushgatewaySink struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewPushgatewaySink pushes the report to a Prometheus pushgateway under
+// the given job, replacing the job's previous metrics. If hc is nil,
+// http.DefaultClient is used.
+func NewPushgatewaySink(baseURL, job string, hc *http.Client) Sink {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return &pushgatewaySink{
+		endpoint: strings.TrimRight(baseURL, "/") + "/metrics/job/" + url.PathEscape(job),
+		client:   hc,
+	}
+}
+
+func (s *pushgatewaySink) Write(ctx context.Context, r *Report) error {
+	var b bytes.Buffer
+	if err := WritePrometheus(&b, r); err != nil {
+		return fmt.Errorf("analytics: push metrics: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.endpoint, &b)
+	if err != nil {
+		return fmt.Errorf("analytics: push metrics: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("analytics: push metrics: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("analytics: push metrics: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// =============================================================================
+// Helpers
+// =============================================================================
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func itoa(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
+
+func ftoa(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,289 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
+	"github.com/zeebo/blake3"
+)
+
+// DefaultBlobChunkSize is the chunk size ReadBlob requests by default.
+const DefaultBlobChunkSize = 4 << 20
+
+// ErrBlobRangeUnsupported is returned by GetBlobRange when the server does
+// not list CapBlobRanges in its HELLO response. ReadBlob and DownloadBlob
+// fall back to a whole-blob fetch on their own.
+var ErrBlobRangeUnsupported = errors.New("cxdb: server does not support ranged blob reads")
+
+// BlobChunk is one range of a blob returned by GetBlobRange.
+type BlobChunk struct {
+	// Offset is the position of Data within the blob.
+	Offset uint64
+
+	// Total is the length of the whole blob.
+	Total uint64
+
+	// Data holds at most the requested number of bytes; it is empty when
+	// Offset equals Total.
+	Data []byte
+
+	// Flags are the response frame flags.
+	Flags ResponseFlags
+}
+
+// GetBlobRange fetches up to maxLen bytes of a blob starting at offset. The
+// chunk cannot be verified on its own; ReadBlob checks the hash of the
+// assembled blob.
+func (c *Client) GetBlobRange(ctx context.Context, hash [32]byte, offset uint64, maxLen uint32) (*BlobChunk, error) {
+	// Servers without range support reject the longer payload by dropping
+	// the connection, so only ask servers that advertised it.
+	if !c.blobRanges {
+		return nil, fmt.Errorf("get blob range: %w", ErrBlobRangeUnsupported)
+	}
+
+	payload := make([]byte, 0, 44)
+	payload = append(payload, hash[:]...)
+	payload = binary.LittleEndian.AppendUint64(payload, offset)
+	payload = binary.LittleEndian.AppendUint32(payload, maxLen)
+
+	resp, err := c.sendRequestWithFlags(ctx, wire.MsgGetBlob, RequestFlagBlobRange, payload)
+	if err != nil {
+		return nil, fmt.Errorf("get blob range: %w", err)
+	}
+	c.usage.record(usageContextID(ctx), ContextUsage{BytesRead: uint64(frameHeaderSize + len(resp.payload))})
+
+	if len(resp.payload) < 20 {
+		return nil, fmt.Errorf("%w: get blob range response too short (%d bytes)", ErrInvalidResponse, len(resp.payload))
+	}
+	chunk := &BlobChunk{
+		Total:  binary.LittleEndian.Uint64(resp.payload[0:8]),
+		Offset: binary.LittleEndian.Uint64(resp.payload[8:16]),
+		Data:   resp.payload[20:],
+		Flags:  ResponseFlags(resp.flags),
+	}
+	n := binary.LittleEndian.Uint32(resp.payload[16:20])
+	switch {
+	case uint64(n) != uint64(len(chunk.Data)):
+		return nil, fmt.Errorf("%w: get blob range length %d, payload has %d bytes", ErrInvalidResponse, n, len(chunk.Data))
+	case chunk.Offset != offset || n > maxLen || chunk.Offset+uint64(n) > chunk.Total:
+		return nil, fmt.Errorf("%w: get blob range returned [%d, +%d) of %d for [%d, +%d)",
+			ErrInvalidResponse, chunk.Offset, n, chunk.Total, offset, maxLen)
+	}
+	return chunk, nil
+}
+
+// BlobProgress reports how much of a blob has been read.
+type BlobProgress struct {
+	Hash [32]byte
+
+	// Offset is the number of bytes of the blob delivered so far, including
+	// any resumed prefix.
+	Offset uint64
+
+	// Total is the blob length.
+	Total uint64
+}
+
+// BlobReadOption configures ReadBlob and DownloadBlob.
+type BlobReadOption func(*blobReadOptions)
+
+type blobReadOptions struct {
+	chunkSize    uint32
+	chunkTimeout time.Duration
+	progress     func(BlobProgress)
+	offset       uint64
+}
+
+// WithChunkSize sets the number of bytes requested per chunk.
+func WithChunkSize(n uint32) BlobReadOption {
+	return func(o *blobReadOptions) {
+		if n > 0 {
+			o.chunkSize = n
+		}
+	}
+}
+
+// WithChunkTimeout bounds each chunk request. A stalled transfer fails
+// after d instead of after the whole context deadline. The client request
+// timeout still applies if it is shorter.
+func WithChunkTimeout(d time.Duration) BlobReadOption {
+	return func(o *blobReadOptions) {
+		o.chunkTimeout = d
+	}
+}
+
+// WithProgress calls fn after every chunk is written.
+func WithProgress(fn func(BlobProgress)) BlobReadOption {
+	return func(o *blobReadOptions) {
+		o.progress = fn
+	}
+}
+
+// WithResumeOffset starts reading at offset, for resuming a read that
+// failed part way. Bytes before offset are not fetched, so ReadBlob cannot
+// verify the blob hash; DownloadBlob re-reads the prefix from disk instead.
+func WithResumeOffset(offset uint64) BlobReadOption {
+	return func(o *blobReadOptions) {
+		o.offset = offset
+	}
+}
+
+// ReadBlob streams a blob to w in chunks. It returns the offset reached:
+// on error, pass it to WithResumeOffset to continue where the read stopped.
+//
+// Chunks are written as they arrive. When reading from offset 0 the hash of
+// the whole blob is checked at the end and a mismatch returns
+// ErrInvalidResponse after w has received the data.
+func (c *Client) ReadBlob(ctx context.Context, hash [32]byte, w io.Writer, opts ...BlobReadOption) (uint64, error) {
+	return readBlobChunks(ctx, hash, w, nil, blobReader{c.GetBlobRange, c.GetBlob}, opts)
+}
+
+// DownloadBlob saves a blob to path. Data is written to path+".part" and
+// renamed once the hash checks out. If a previous download left a .part
+// file, DownloadBlob hashes what is there and fetches only the rest.
+func (c *Client) DownloadBlob(ctx context.Context, hash [32]byte, path string, opts ...BlobReadOption) (uint64, error) {
+	return downloadBlob(ctx, hash, path, blobReader{c.GetBlobRange, c.GetBlob}, opts)
+}
+
+// blobReader is the pair of fetches the chunked reader needs, so the same
+// loop serves Client and ReconnectingClient.
+type blobReader struct {
+	getRange func(ctx context.Context, hash [32]byte, offset uint64, maxLen uint32) (*BlobChunk, error)
+	getWhole func(ctx context.Context, hash [32]byte) ([]byte, error)
+}
+
+// readBlobChunks reads hash from the configured offset into w. If h is
+// non-nil it already holds the hash of the bytes before the offset;
+// otherwise the hash is checked only when reading from 0.
+func readBlobChunks(ctx context.Context, hash [32]byte, w io.Writer, h *blake3.Hasher, r blobReader, opts []BlobReadOption) (uint64, error) {
+	o := blobReadOptions{chunkSize: DefaultBlobChunkSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if h == nil && o.offset == 0 {
+		h = blake3.New()
+	}
+
+	off := o.offset
+	for {
+		chunkCtx, cancel := ctx, context.CancelFunc(func() {})
+		if o.chunkTimeout > 0 {
+			chunkCtx, cancel = context.WithTimeout(ctx, o.chunkTimeout)
+		}
+		chunk, err := r.getRange(chunkCtx, hash, off, o.chunkSize)
+		cancel()
+		if errors.Is(err, ErrBlobRangeUnsupported) {
+			return readBlobWhole(ctx, hash, w, h, r, off, o)
+		}
+		if err != nil {
+			return off, fmt.Errorf("read blob at offset %d: %w", off, err)
+		}
+
+		if _, err := w.Write(chunk.Data); err != nil {
+			return off, fmt.Errorf("read blob: write: %w", err)
+		}
+		if h != nil {
+			_, _ = h.Write(chunk.Data)
+		}
+		off += uint64(len(chunk.Data))
+		if o.progress != nil {
+			o.progress(BlobProgress{Hash: hash, Offset: off, Total: chunk.Total})
+		}
+
+		if off >= chunk.Total {
+			break
+		}
+		if len(chunk.Data) == 0 {
+			return off, fmt.Errorf("%w: empty blob chunk at offset %d of %d", ErrInvalidResponse, off, chunk.Total)
+		}
+	}
+
+	if h != nil {
+		var sum [32]byte
+		copy(sum[:], h.Sum(nil))
+		if sum != hash {
+			return off, fmt.Errorf("%w: blob content does not match hash", ErrInvalidResponse)
+		}
+	}
+	return off, nil
+}
+
+// readBlobWhole serves a chunked read from a single GET_BLOB for servers
+// without range support. The transfer itself cannot resume, but progress
+// is still reported per chunk as the data is written out.
+func readBlobWhole(ctx context.Context, hash [32]byte, w io.Writer, h *blake3.Hasher, r blobReader, off uint64, o blobReadOptions) (uint64, error) {
+	data, err := r.getWhole(ctx, hash)
+	if err != nil {
+		return off, fmt.Errorf("read blob: %w", err)
+	}
+	total := uint64(len(data))
+	if off > total {
+		return off, fmt.Errorf("%w: resume offset %d beyond blob length %d", ErrInvalidResponse, off, total)
+	}
+	// GetBlob verified data; make sure what the caller already has matches.
+	if h != nil && off > 0 {
+		var prefix [32]byte
+		copy(prefix[:], h.Sum(nil))
+		if prefix != blake3.Sum256(data[:off]) {
+			return off, fmt.Errorf("%w: partial blob does not match the first %d bytes", ErrInvalidResponse, off)
+		}
+	}
+	for off < total {
+		end := min(off+uint64(o.chunkSize), total)
+		if _, err := w.Write(data[off:end]); err != nil {
+			return off, fmt.Errorf("read blob: write: %w", err)
+		}
+		off = end
+		if o.progress != nil {
+			o.progress(BlobProgress{Hash: hash, Offset: off, Total: total})
+		}
+	}
+	return off, nil
+}
+
+func downloadBlob(ctx context.Context, hash [32]byte, path string, r blobReader, opts []BlobReadOption) (uint64, error) {
+	part := path + ".part"
+	f, err := os.OpenFile(part, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return 0, fmt.Errorf("download blob: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	// Hash the partial download; this also leaves f positioned at its end.
+	h := blake3.New()
+	have, err := io.Copy(h, f)
+	if err != nil {
+		return 0, fmt.Errorf("download blob: read %s: %w", part, err)
+	}
+
+	opts = append(opts, WithResumeOffset(uint64(have)))
+	n, err := readBlobChunks(ctx, hash, f, h, r, opts)
+	if errors.Is(err, ErrInvalidResponse) {
+		// The partial file cannot be trusted; start over next time.
+		_ = f.Close()
+		_ = os.Remove(part)
+		return 0, fmt.Errorf("download blob: %w", err)
+	}
+	if err != nil {
+		return n, fmt.Errorf("download blob: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return n, fmt.Errorf("download blob: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return n, fmt.Errorf("download blob: %w", err)
+	}
+	if err := os.Rename(part, path); err != nil {
+		return n, fmt.Errorf("download blob: %w", err)
+	}
+	return n, nil
+}
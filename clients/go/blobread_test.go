@@ -0,0 +1,288 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
+	"github.com/zeebo/blake3"
+)
+
+// blobServer serves one blob over GET_BLOB. It advertises ranged reads
+// unless noRanges is set, in which case it drops the connection on a ranged
+// request like the server does.
+type blobServer struct {
+	data     []byte
+	noRanges bool
+	failAt   int64 // fail the ranged read at this offset once; -1 for never
+	stall    time.Duration
+	ranged   atomic.Int32
+	whole    atomic.Int32
+}
+
+func newBlobServer(data []byte) *blobServer {
+	return &blobServer{data: data, failAt: -1}
+}
+
+func (s *blobServer) handle(msgType uint16, p []byte) (uint16, uint16, []byte) {
+	le := binary.LittleEndian
+	switch {
+	case msgType == wire.MsgHello && s.noRanges:
+		return msgType, 0, helloWithIdentity(`{}`)
+	case msgType == wire.MsgHello:
+		return msgType, 0, helloWithCapabilities(`{"capabilities":["blob_ranges"]}`)
+	case msgType != wire.MsgGetBlob:
+		return wire.MsgError, 0, wire.AppendError(nil, 400, "unexpected message")
+	case len(p) == 32:
+		s.whole.Add(1)
+		return msgType, 0, append(le.AppendUint32(nil, uint32(len(s.data))), s.data...)
+	case len(p) != 44 || s.noRanges:
+		return dropConn, 0, nil
+	}
+
+	s.ranged.Add(1)
+	if s.stall > 0 {
+		time.Sleep(s.stall)
+	}
+	off := le.Uint64(p[32:40])
+	if int64(off) == s.failAt {
+		s.failAt = -1
+		return wire.MsgError, 0, wire.AppendError(nil, wire.CodeInternal, "read failed")
+	}
+	start := min(off, uint64(len(s.data)))
+	end := min(off+uint64(le.Uint32(p[40:44])), uint64(len(s.data)))
+	chunk := s.data[start:max(start, end)]
+	resp := le.AppendUint64(nil, uint64(len(s.data)))
+	resp = le.AppendUint64(resp, off)
+	resp = le.AppendUint32(resp, uint32(len(chunk)))
+	return msgType, 0, append(resp, chunk...)
+}
+
+// helloClient returns a client connected to handler that has completed the
+// HELLO handshake, so it knows the server's capabilities.
+func helloClient(t *testing.T, handler fakeHandler) *Client {
+	t.Helper()
+	c := pipeClient(t, handler)
+	if err := c.sendHello(""); err != nil {
+		t.Fatalf("hello: %v", err)
+	}
+	return c
+}
+
+func testBlob(n int) ([]byte, [32]byte) {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	return data, blake3.Sum256(data)
+}
+
+func TestReadBlobChunked(t *testing.T) {
+	data, hash := testBlob(1000)
+	srv := newBlobServer(data)
+	c := helloClient(t, srv.handle)
+
+	var buf bytes.Buffer
+	var progress []uint64
+	n, err := c.ReadBlob(context.Background(), hash, &buf,
+		WithChunkSize(300),
+		WithProgress(func(p BlobProgress) {
+			if p.Total != 1000 {
+				t.Errorf("progress total = %d", p.Total)
+			}
+			progress = append(progress, p.Offset)
+		}))
+	if err != nil {
+		t.Fatalf("ReadBlob: %v", err)
+	}
+	if n != 1000 || !bytes.Equal(buf.Bytes(), data) {
+		t.Fatalf("read %d bytes, content match %v", n, bytes.Equal(buf.Bytes(), data))
+	}
+	if want := []uint64{300, 600, 900, 1000}; !slices.Equal(progress, want) {
+		t.Fatalf("progress = %v, want %v", progress, want)
+	}
+	if srv.ranged.Load() != 4 || srv.whole.Load() != 0 {
+		t.Fatalf("ranged=%d whole=%d", srv.ranged.Load(), srv.whole.Load())
+	}
+}
+
+func TestReadBlobFallsBackWithoutRanges(t *testing.T) {
+	data, hash := testBlob(1000)
+	srv := newBlobServer(data)
+	srv.noRanges = true
+	c := helloClient(t, srv.handle)
+
+	var buf bytes.Buffer
+	var progress []uint64
+	n, err := c.ReadBlob(context.Background(), hash, &buf,
+		WithChunkSize(400),
+		WithProgress(func(p BlobProgress) { progress = append(progress, p.Offset) }))
+	if err != nil {
+		t.Fatalf("ReadBlob: %v", err)
+	}
+	if n != 1000 || !bytes.Equal(buf.Bytes(), data) {
+		t.Fatalf("fallback read %d bytes", n)
+	}
+	if want := []uint64{400, 800, 1000}; !slices.Equal(progress, want) {
+		t.Fatalf("progress = %v, want %v", progress, want)
+	}
+
+	if _, err := c.GetBlobRange(context.Background(), hash, 0, 10); !errors.Is(err, ErrBlobRangeUnsupported) {
+		t.Fatalf("GetBlobRange err = %v, want ErrBlobRangeUnsupported", err)
+	}
+
+	// No ranged request reached the server, so the connection is still up.
+	if _, err := c.ReadBlob(context.Background(), hash, &bytes.Buffer{}); err != nil {
+		t.Fatalf("second ReadBlob: %v", err)
+	}
+	if srv.whole.Load() != 2 || srv.ranged.Load() != 0 {
+		t.Fatalf("whole=%d ranged=%d, want 2, 0", srv.whole.Load(), srv.ranged.Load())
+	}
+}
+
+func TestReadBlobResume(t *testing.T) {
+	data, hash := testBlob(1000)
+	srv := newBlobServer(data)
+	srv.failAt = 600
+	c := helloClient(t, srv.handle)
+
+	var buf bytes.Buffer
+	off, err := c.ReadBlob(context.Background(), hash, &buf, WithChunkSize(300))
+	if !IsServerError(err, 500) {
+		t.Fatalf("err = %v, want server error 500", err)
+	}
+	if off != 600 || buf.Len() != 600 {
+		t.Fatalf("stopped at %d with %d bytes written", off, buf.Len())
+	}
+
+	off, err = c.ReadBlob(context.Background(), hash, &buf, WithChunkSize(300), WithResumeOffset(off))
+	if err != nil {
+		t.Fatalf("resumed ReadBlob: %v", err)
+	}
+	if off != 1000 || !bytes.Equal(buf.Bytes(), data) {
+		t.Fatalf("resumed read ended at %d", off)
+	}
+}
+
+func TestReadBlobHashMismatch(t *testing.T) {
+	data, hash := testBlob(100)
+	srv := newBlobServer(append([]byte(nil), data...))
+	srv.data[50] ^= 0xff
+	c := helloClient(t, srv.handle)
+
+	_, err := c.ReadBlob(context.Background(), hash, &bytes.Buffer{}, WithChunkSize(30))
+	if !errors.Is(err, ErrInvalidResponse) {
+		t.Fatalf("err = %v, want ErrInvalidResponse", err)
+	}
+}
+
+func TestReadBlobChunkTimeout(t *testing.T) {
+	data, hash := testBlob(100)
+	srv := newBlobServer(data)
+	srv.stall = 500 * time.Millisecond
+	c := helloClient(t, srv.handle)
+
+	start := time.Now()
+	_, err := c.ReadBlob(context.Background(), hash, &bytes.Buffer{}, WithChunkTimeout(50*time.Millisecond))
+	var ne net.Error
+	if !errors.As(err, &ne) || !ne.Timeout() {
+		t.Fatalf("err = %v, want timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Fatalf("chunk timeout took %v", elapsed)
+	}
+}
+
+func TestDownloadBlobResumesPartFile(t *testing.T) {
+	data, hash := testBlob(1000)
+	srv := newBlobServer(data)
+	c := helloClient(t, srv.handle)
+
+	path := filepath.Join(t.TempDir(), "blob.bin")
+	if err := os.WriteFile(path+".part", data[:700], 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var first uint64
+	n, err := c.DownloadBlob(context.Background(), hash, path, WithChunkSize(200),
+		WithProgress(func(p BlobProgress) {
+			if first == 0 {
+				first = p.Offset
+			}
+		}))
+	if err != nil {
+		t.Fatalf("DownloadBlob: %v", err)
+	}
+	if n != 1000 || first != 900 {
+		t.Fatalf("n = %d, first progress = %d; want 1000, 900", n, first)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("downloaded file differs: %v", err)
+	}
+	if _, err := os.Stat(path + ".part"); !os.IsNotExist(err) {
+		t.Fatalf(".part file left behind: %v", err)
+	}
+}
+
+func TestDownloadBlobDiscardsCorruptPartFile(t *testing.T) {
+	data, hash := testBlob(1000)
+	srv := newBlobServer(data)
+	c := helloClient(t, srv.handle)
+
+	path := filepath.Join(t.TempDir(), "blob.bin")
+	bad := append([]byte(nil), data[:500]...)
+	bad[10] ^= 0xff
+	if err := os.WriteFile(path+".part", bad, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.DownloadBlob(context.Background(), hash, path); !errors.Is(err, ErrInvalidResponse) {
+		t.Fatalf("err = %v, want ErrInvalidResponse", err)
+	}
+	if _, err := os.Stat(path + ".part"); !os.IsNotExist(err) {
+		t.Fatalf("corrupt .part file kept: %v", err)
+	}
+
+	// The retry starts from scratch and succeeds.
+	if _, err := c.DownloadBlob(context.Background(), hash, path); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+}
+
+func TestDownloadBlobDiscardsOversizedPartFile(t *testing.T) {
+	data, hash := testBlob(1000)
+	for _, noRanges := range []bool{false, true} {
+		srv := newBlobServer(data)
+		srv.noRanges = noRanges
+		c := helloClient(t, srv.handle)
+
+		// A .part file longer than the blob, e.g. left by another blob
+		// downloaded to the same path.
+		path := filepath.Join(t.TempDir(), "blob.bin")
+		if err := os.WriteFile(path+".part", append(append([]byte(nil), data...), "extra"...), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.DownloadBlob(context.Background(), hash, path); !errors.Is(err, ErrInvalidResponse) {
+			t.Fatalf("noRanges=%v: err = %v, want ErrInvalidResponse", noRanges, err)
+		}
+		if _, err := os.Stat(path + ".part"); !os.IsNotExist(err) {
+			t.Fatalf("noRanges=%v: oversized .part file kept: %v", noRanges, err)
+		}
+		if _, err := c.DownloadBlob(context.Background(), hash, path); err != nil {
+			t.Fatalf("noRanges=%v: retry: %v", noRanges, err)
+		}
+	}
+}
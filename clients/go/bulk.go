@@ -0,0 +1,86 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/strongdm/ai-cxdb/clients/go/types"
+)
+
+// ContextTemplate is the starting content of each context made by
+// CreateContexts.
+type ContextTemplate struct {
+	// BaseTurnID is the turn each context starts from, as for
+	// CreateContext. Zero starts empty contexts.
+	BaseTurnID uint64
+
+	// Metadata is sent with the first item, e.g. labels that mark the
+	// contexts as seed data. It requires at least one item.
+	Metadata *types.ContextMetadata
+
+	// Items are appended to each context in order.
+	Items []*types.ConversationItem
+}
+
+// CreateContexts creates n contexts from template, one after another, and
+// returns their heads after the template items are appended. A nil
+// template creates n empty contexts. It is meant for seeding test and
+// development servers; on error it returns the heads of the contexts
+// completed so far.
+func (c *Client) CreateContexts(ctx context.Context, n int, template *ContextTemplate) ([]*ContextHead, error) {
+	return createContexts(ctx, c, n, template)
+}
+
+// contextSeeder is the subset of Client and ReconnectingClient that
+// createContexts needs.
+type contextSeeder interface {
+	CreateContext(ctx context.Context, baseTurnID uint64) (*ContextHead, error)
+	AppendConversationItem(ctx context.Context, contextID, parentTurnID uint64, item *types.ConversationItem) (*AppendResult, error)
+}
+
+func createContexts(ctx context.Context, c contextSeeder, n int, template *ContextTemplate) ([]*ContextHead, error) {
+	if template == nil {
+		template = &ContextTemplate{}
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("create contexts: negative count %d", n)
+	}
+	if template.Metadata != nil && len(template.Items) == 0 {
+		return nil, errors.New("create contexts: template metadata needs an item to travel with")
+	}
+
+	heads := make([]*ContextHead, 0, n)
+	for i := 0; i < n; i++ {
+		head, err := createFromTemplate(ctx, c, template)
+		if err != nil {
+			return heads, fmt.Errorf("create contexts: context %d of %d: %w", i+1, n, err)
+		}
+		heads = append(heads, head)
+	}
+	return heads, nil
+}
+
+func createFromTemplate(ctx context.Context, c contextSeeder, template *ContextTemplate) (*ContextHead, error) {
+	head, err := c.CreateContext(ctx, template.BaseTurnID)
+	if err != nil {
+		return nil, err
+	}
+	for i, item := range template.Items {
+		if i == 0 && template.Metadata != nil {
+			// Copied so the template stays reusable.
+			first := *item
+			item = first.WithContextMetadata(template.Metadata)
+		}
+		res, err := c.AppendConversationItem(ctx, head.ContextID, head.HeadTurnID, item)
+		if err != nil {
+			return nil, fmt.Errorf("append item %d to context %d: %w", i+1, head.ContextID, err)
+		}
+		head.HeadTurnID = res.TurnID
+		head.HeadDepth = res.Depth
+	}
+	return head, nil
+}
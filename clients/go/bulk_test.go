@@ -0,0 +1,69 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/strongdm/ai-cxdb/clients/go/types"
+)
+
+type fakeSeeder struct {
+	contexts uint64
+	turns    uint64
+	items    map[uint64][]*types.ConversationItem
+	failAt   uint64 // context whose second append fails
+}
+
+func (f *fakeSeeder) CreateContext(_ context.Context, baseTurnID uint64) (*ContextHead, error) {
+	f.contexts++
+	return &ContextHead{ContextID: f.contexts, HeadTurnID: baseTurnID}, nil
+}
+
+func (f *fakeSeeder) AppendConversationItem(_ context.Context, contextID, parentTurnID uint64, item *types.ConversationItem) (*AppendResult, error) {
+	if contextID == f.failAt && len(f.items[contextID]) == 1 {
+		return nil, errors.New("boom")
+	}
+	f.turns++
+	f.items[contextID] = append(f.items[contextID], item)
+	return &AppendResult{ContextID: contextID, TurnID: f.turns, Depth: uint32(len(f.items[contextID]))}, nil
+}
+
+func TestCreateContexts(t *testing.T) {
+	f := &fakeSeeder{items: map[uint64][]*types.ConversationItem{}, failAt: 3}
+	template := &ContextTemplate{
+		Metadata: &types.ContextMetadata{Labels: []string{"seed"}},
+		Items:    []*types.ConversationItem{types.NewUserInput("hi"), types.NewAssistantTurn("hello")},
+	}
+
+	heads, err := createContexts(context.Background(), f, 2, template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(heads) != 2 || heads[1].ContextID != 2 || heads[1].HeadTurnID != 4 || heads[1].HeadDepth != 2 {
+		t.Fatalf("heads = %+v %+v", heads[0], heads[1])
+	}
+	for id, items := range f.items {
+		if items[0].ContextMetadata == nil || items[1].ContextMetadata != nil {
+			t.Errorf("context %d: metadata must be on the first item only", id)
+		}
+	}
+	if template.Items[0].ContextMetadata != nil {
+		t.Error("template item was modified")
+	}
+
+	heads, err = createContexts(context.Background(), f, 2, template)
+	if err == nil || len(heads) != 0 {
+		t.Errorf("failing batch = %v, %v", heads, err)
+	}
+
+	if heads, err := createContexts(context.Background(), f, 3, nil); err != nil || len(heads) != 3 || heads[0].HeadTurnID != 0 {
+		t.Errorf("empty contexts = %v, %v", heads, err)
+	}
+	if _, err := createContexts(context.Background(), f, 1, &ContextTemplate{Metadata: &types.ContextMetadata{}}); err == nil {
+		t.Error("metadata without items was accepted")
+	}
+}
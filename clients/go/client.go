@@ -41,38 +41,39 @@
 // For local development, use plain TCP:
 //
 //	client, err := cxdb.Dial("localhost:9009")
+//
+// # WebAssembly
+//
+// The package and its subpackages build for GOOS=js and GOOS=wasip1 with
+// GOARCH=wasm. Browsers cannot open TCP connections, so use DialWebSocket
+// against the gateway's /v1/binary tunnel there; the httpclient package
+// works unchanged.
 package cxdb
 
 import (
-	"bytes"
 	"context"
 	"crypto/tls"
 	"encoding/binary"
+	"errors"
 	"fmt"
-	"io"
+	"log/slog"
 	"net"
+	"net/http"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
-)
 
-// Binary protocol message types
-const (
-	msgHello     uint16 = 1
-	msgCtxCreate uint16 = 2
-	msgCtxFork   uint16 = 3
-	msgGetHead   uint16 = 4
-	msgAppend    uint16 = 5
-	msgGetLast   uint16 = 6
-	msgGetBlob   uint16 = 9
-	msgError     uint16 = 255
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
 )
 
-// Encoding and compression constants
+// Encoding and compression constants. See package wire for the full set of
+// protocol constants.
 const (
-	EncodingMsgpack   uint32 = 1
-	CompressionNone   uint32 = 0
-	CompressionZstd   uint32 = 1
+	EncodingMsgpack = wire.EncodingMsgpack
+	EncodingBlobRef = wire.EncodingBlobRef // payload is a PayloadRef stub
+	CompressionNone = wire.CompressionNone
+	CompressionZstd = wire.CompressionZstd
 )
 
 // Default timeouts
@@ -83,13 +84,40 @@ const (
 
 // Client handles binary protocol communication with the CXDB server.
 type Client struct {
-	conn      net.Conn
-	mu        sync.Mutex
-	reqID     atomic.Uint64
-	timeout   time.Duration
-	closed    bool
-	sessionID uint64    // Assigned by server on HELLO
-	clientTag string    // Client's identifying tag
+	transport   Transport
+	mu          sync.Mutex
+	reqID       atomic.Uint64
+	timeout     time.Duration
+	closed      bool
+	sessionID   uint64          // Assigned by server on HELLO
+	clientTag   string          // Client's identifying tag
+	notices     []ServerNotice  // Sent by server on HELLO
+	identity    NetworkIdentity // Sent by server on HELLO
+	routingKeys bool            // server accepts routing hints, from HELLO
+	blobRanges  bool            // server serves ranged GET_BLOB, from HELLO
+	blobProbes  bool            // server answers hash-first PUT_BLOB, from HELLO
+	blobRefs    bool            // server understands blob-ref turn payloads, from HELLO
+	treeFormats bool            // server validates ATTACH_FS tree formats, from HELLO
+
+	payloadBlobThreshold int            // externalize larger payloads; 0 disables
+	verifyFsAttach       bool           // check fs attachments, see WithFsAttachVerify
+	hashFirstMin         int            // probe PUT_BLOB for blobs this large; 0 disables
+	serverProvenance     bool           // see WithServerProvenance
+	titleGenerator       TitleGenerator // see WithTitleGenerator
+	titleTimeout         time.Duration
+	routingKey           string       // sent in HELLO, see WithRoutingKey
+	schemaPolicy         SchemaPolicy // see WithSchemaPolicy
+	spill                spiller      // see WithMemoryBudget
+
+	usage  *usageTracker // per-context traffic counters
+	leases *leaseSet     // held single-writer leases
+
+	rejectedFlags atomic.Uint32 // request flags the server rejected, see UnsupportedFlags
+	payloadLimit  atomic.Uint64 // append frame limit learnt from the server; 0 if unknown
+
+	onConnect    func(ConnectEvent)
+	onDisconnect func(DisconnectEvent)
+	downOnce     sync.Once // onDisconnect fires once
 }
 
 // Option configures client behavior.
@@ -99,6 +127,23 @@ type clientOptions struct {
 	dialTimeout    time.Duration
 	requestTimeout time.Duration
 	clientTag      string
+
+	payloadBlobThreshold int
+	leaseMode            LeaseMode
+	schemaPolicy         SchemaPolicy
+	verifyFsAttach       bool
+	hashFirstMin         int
+	serverProvenance     bool
+	titleGenerator       TitleGenerator
+	titleTimeout         time.Duration
+	routingKey           string
+	memoryBudget         *memoryBudget
+	spillDir             string
+
+	wsHeader http.Header // extra WebSocket handshake headers, see DialWebSocket
+
+	onConnect    func(ConnectEvent)
+	onDisconnect func(DisconnectEvent)
 }
 
 // WithDialTimeout sets the connection timeout.
@@ -123,13 +168,21 @@ func WithClientTag(tag string) Option {
 	}
 }
 
+// WithPayloadBlobThreshold stores turn payloads larger than n bytes as blobs
+// and appends a small EncodingBlobRef stub in their place. GetLast resolves
+// stubs transparently. Stubs are written only to servers that list
+// CapBlobRefPayloads in their HELLO response; others get every payload
+// inline. Zero (the default) disables externalization.
+func WithPayloadBlobThreshold(n int) Option {
+	return func(o *clientOptions) {
+		o.payloadBlobThreshold = n
+	}
+}
+
 // Dial connects to a CXDB server at the given address using plain TCP.
 // For production use with TLS, use DialTLS instead.
 func Dial(addr string, opts ...Option) (*Client, error) {
-	options := clientOptions{
-		dialTimeout:    DefaultDialTimeout,
-		requestTimeout: DefaultRequestTimeout,
-	}
+	options := defaultClientOptions()
 	for _, opt := range opts {
 		opt(&options)
 	}
@@ -139,28 +192,13 @@ func Dial(addr string, opts ...Option) (*Client, error) {
 		return nil, fmt.Errorf("cxdb dial: %w", err)
 	}
 
-	client := &Client{
-		conn:      conn,
-		timeout:   options.requestTimeout,
-		clientTag: options.clientTag,
-	}
-
-	// Send HELLO to establish session
-	if err := client.sendHello(options.clientTag); err != nil {
-		_ = conn.Close()
-		return nil, fmt.Errorf("cxdb hello: %w", err)
-	}
-
-	return client, nil
+	return newClient(NewStreamTransport(conn), options)
 }
 
 // DialTLS connects to a CXDB server using TLS.
 // This is the recommended method for production deployments.
 func DialTLS(addr string, opts ...Option) (*Client, error) {
-	options := clientOptions{
-		dialTimeout:    DefaultDialTimeout,
-		requestTimeout: DefaultRequestTimeout,
-	}
+	options := defaultClientOptions()
 	for _, opt := range opts {
 		opt(&options)
 	}
@@ -171,30 +209,22 @@ func DialTLS(addr string, opts ...Option) (*Client, error) {
 		return nil, fmt.Errorf("cxdb dial tls: %w", err)
 	}
 
-	client := &Client{
-		conn:      conn,
-		timeout:   options.requestTimeout,
-		clientTag: options.clientTag,
-	}
-
-	// Send HELLO to establish session
-	if err := client.sendHello(options.clientTag); err != nil {
-		_ = conn.Close()
-		return nil, fmt.Errorf("cxdb hello: %w", err)
-	}
-
-	return client, nil
+	return newClient(NewStreamTransport(conn), options)
 }
 
 // Close closes the connection to the server.
 func (c *Client) Close() error {
 	c.mu.Lock()
-	defer c.mu.Unlock()
 	if c.closed {
+		c.mu.Unlock()
 		return nil
 	}
 	c.closed = true
-	return c.conn.Close()
+	err := c.transport.Close()
+	c.mu.Unlock()
+
+	c.disconnected(DisconnectClosed, nil)
+	return err
 }
 
 // SessionID returns the session ID assigned by the server during the HELLO handshake.
@@ -210,25 +240,20 @@ func (c *Client) ClientTag() string {
 // sendHello sends the HELLO message to establish a session with the server.
 // This is called automatically during Dial/DialTLS.
 func (c *Client) sendHello(clientTag string) error {
-	// Build HELLO payload:
-	// protocol_version: u16 (1)
-	// client_tag_len: u16
-	// client_tag: [bytes]
-	// client_meta_json_len: u32 (0)
-	payload := &bytes.Buffer{}
-	_ = binary.Write(payload, binary.LittleEndian, uint16(1)) // protocol version
-	_ = binary.Write(payload, binary.LittleEndian, uint16(len(clientTag)))
-	payload.WriteString(clientTag)
-	_ = binary.Write(payload, binary.LittleEndian, uint32(0)) // no JSON metadata
+	meta, err := c.helloMetaJSON()
+	if err != nil {
+		return err
+	}
+	payload := wire.AppendHello(nil, clientTag, meta)
 
 	// Set deadline for handshake
-	if err := c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+	if err := c.transport.SetDeadline(time.Now().Add(c.timeout)); err != nil {
 		return fmt.Errorf("set deadline: %w", err)
 	}
-	defer func() { _ = c.conn.SetDeadline(time.Time{}) }()
+	defer func() { _ = c.transport.SetDeadline(time.Time{}) }()
 
 	reqID := c.reqID.Add(1)
-	if err := c.writeFrame(msgHello, reqID, payload.Bytes()); err != nil {
+	if err := c.writeFrame(wire.MsgHello, reqID, payload); err != nil {
 		return err
 	}
 
@@ -237,18 +262,46 @@ func (c *Client) sendHello(clientTag string) error {
 		return err
 	}
 
-	if resp.msgType == msgError {
-		return parseServerError(resp.payload)
+	if resp.msgType == wire.MsgError {
+		return parseServerError(resp.payload, 0)
 	}
 
-	if resp.msgType != msgHello {
+	if resp.msgType != wire.MsgHello {
 		return fmt.Errorf("unexpected response type: %d", resp.msgType)
 	}
 
-	// Parse response: session_id (u64) + protocol_version (u16)
+	// Parse response: session_id (u64) + protocol_version (u16), then
+	// optionally notices_json_len (u32) + notices_json,
+	// identity_json_len (u32) + identity_json and
+	// capabilities_json_len (u32) + capabilities_json
 	if len(resp.payload) >= 8 {
 		c.sessionID = binary.LittleEndian.Uint64(resp.payload[0:8])
 	}
+	if len(resp.payload) > 10 {
+		notices, err := parseHelloNotices(resp.payload[10:])
+		if err != nil {
+			// Notices are advisory; a malformed section must not fail the handshake.
+			slog.Warn("[cxdb] ignoring malformed server notices", "error", err)
+		}
+		c.notices = notices
+		logNotices(notices)
+
+		identity, err := parseHelloIdentity(resp.payload[10:])
+		if err != nil {
+			slog.Warn("[cxdb] ignoring malformed network identity", "error", err)
+		}
+		c.identity = identity
+
+		caps, err := parseHelloCapabilities(resp.payload[10:])
+		if err != nil {
+			slog.Warn("[cxdb] ignoring malformed server capabilities", "error", err)
+		}
+		c.routingKeys = slices.Contains(caps, wire.CapRoutingKeys)
+		c.blobRanges = slices.Contains(caps, wire.CapBlobRanges)
+		c.blobProbes = slices.Contains(caps, wire.CapBlobProbe)
+		c.blobRefs = slices.Contains(caps, wire.CapBlobRefPayloads)
+		c.treeFormats = slices.Contains(caps, wire.CapTreeFormat)
+	}
 
 	return nil
 }
@@ -256,11 +309,18 @@ func (c *Client) sendHello(clientTag string) error {
 // frame represents a binary protocol frame.
 type frame struct {
 	msgType uint16
+	flags   uint16
 	reqID   uint64
 	payload []byte
 }
 
-func (c *Client) sendRequest(ctx context.Context, msgType uint16, payload []byte) (*frame, error) {
+func (c *Client) sendRequest(ctx context.Context, msgType uint16, payload []byte) (resp *frame, err error) {
+	// Registered first so it runs after the unlock below.
+	defer func() {
+		if isConnectionError(err) {
+			c.disconnected(DisconnectConnectionError, err)
+		}
+	}()
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -273,67 +333,73 @@ func (c *Client) sendRequest(ctx context.Context, msgType uint16, payload []byte
 	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
 		deadline = d
 	}
-	if err := c.conn.SetDeadline(deadline); err != nil {
+	if err := c.transport.SetDeadline(deadline); err != nil {
 		return nil, fmt.Errorf("set deadline: %w", err)
 	}
-	defer func() { _ = c.conn.SetDeadline(time.Time{}) }() // Clear deadline
+	defer func() { _ = c.transport.SetDeadline(time.Time{}) }() // Clear deadline
 
+	flags, payload, err := c.routeRequest(ctx, 0, payload)
+	if err != nil {
+		return nil, err
+	}
 	reqID := c.reqID.Add(1)
 
-	if err := c.writeFrame(msgType, reqID, payload); err != nil {
+	if err := c.writeFrameWithFlags(msgType, flags, reqID, payload); err != nil {
 		return nil, err
 	}
 
-	resp, err := c.readFrame()
+	resp, err = c.readFrame()
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.msgType == msgError {
-		return nil, parseServerError(resp.payload)
+	if resp.msgType == wire.MsgError {
+		return nil, c.serverError(resp.payload, flags)
 	}
 
 	return resp, nil
 }
 
 func (c *Client) writeFrame(msgType uint16, reqID uint64, payload []byte) error {
-	header := &bytes.Buffer{}
-	_ = binary.Write(header, binary.LittleEndian, uint32(len(payload)))
-	_ = binary.Write(header, binary.LittleEndian, msgType)
-	_ = binary.Write(header, binary.LittleEndian, uint16(0)) // flags
-	_ = binary.Write(header, binary.LittleEndian, reqID)
-
-	_, err := c.conn.Write(append(header.Bytes(), payload...))
-	return err
+	return c.writeFrameWithFlags(msgType, 0, reqID, payload)
 }
 
 func (c *Client) readFrame() (*frame, error) {
-	header := make([]byte, 16)
-	if _, err := io.ReadFull(c.conn, header); err != nil {
-		return nil, fmt.Errorf("read header: %w", err)
+	h, payload, err := c.transport.ReadFrame()
+	if err != nil {
+		return nil, err
 	}
+	return &frame{msgType: h.MsgType, flags: h.Flags, reqID: h.ReqID, payload: payload}, nil
+}
 
-	length := binary.LittleEndian.Uint32(header[0:4])
-	msgType := binary.LittleEndian.Uint16(header[4:6])
-	reqID := binary.LittleEndian.Uint64(header[8:16])
-
-	payload := make([]byte, length)
-	if _, err := io.ReadFull(c.conn, payload); err != nil {
-		return nil, fmt.Errorf("read payload: %w", err)
+// serverError is parseServerError that also remembers flags the server
+// rejected. Callers hold c.mu.
+func (c *Client) serverError(payload []byte, flags uint16) error {
+	err := parseServerError(payload, flags)
+	var uf *UnsupportedFlagsError
+	if errors.As(err, &uf) {
+		c.rejectedFlags.Store(c.rejectedFlags.Load() | uint32(uf.Flags))
 	}
+	return err
+}
 
-	return &frame{msgType: msgType, reqID: reqID, payload: payload}, nil
+// UnsupportedFlags returns the request flags the server has rejected on
+// this connection. Features behind them fall back or fail fast.
+func (c *Client) UnsupportedFlags() uint16 {
+	return uint16(c.rejectedFlags.Load())
 }
 
-func parseServerError(payload []byte) error {
-	if len(payload) < 8 {
+// flagRejected reports whether the server has rejected flag.
+func (c *Client) flagRejected(flag uint16) bool {
+	return uint16(c.rejectedFlags.Load())&flag != 0
+}
+
+// parseServerError decodes an ERROR payload sent in reply to a request with
+// the given flags.
+func parseServerError(payload []byte, flags uint16) error {
+	code, detail, ok := wire.ParseError(payload)
+	if !ok {
 		return &ServerError{Code: 0, Detail: "unknown error"}
 	}
-	code := binary.LittleEndian.Uint32(payload[0:4])
-	detailLen := binary.LittleEndian.Uint32(payload[4:8])
-	detail := ""
-	if int(detailLen) <= len(payload)-8 {
-		detail = string(payload[8 : 8+detailLen])
-	}
-	return &ServerError{Code: code, Detail: detail}
+	return classifyServerError(&ServerError{Code: code, Detail: detail}, flags)
 }
@@ -0,0 +1,178 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/strongdm/ai-cxdb/clients/go/types"
+)
+
+// ErrSkipTurn is returned by a CloneRewrite to leave a turn out of the clone.
+var ErrSkipTurn = errors.New("cxdb: skip turn")
+
+// ErrCloneIncomplete is returned by CloneContext when the server returns
+// fewer turns than the source context holds, so the clone would silently
+// drop its oldest history.
+var ErrCloneIncomplete = errors.New("cxdb: clone source history incomplete")
+
+// CloneRewrite returns the payload to store for turn in the clone. It sees
+// the stored payload with blob references already resolved; the returned
+// bytes are written with the turn's TypeID, TypeVersion, Encoding, and
+// Compression. Return ErrSkipTurn to drop the turn.
+type CloneRewrite func(turn *TurnRecord) ([]byte, error)
+
+// CloneOption configures CloneContext.
+type CloneOption func(*cloneOptions)
+
+type cloneOptions struct {
+	rewrite CloneRewrite
+}
+
+// WithCloneRewrite applies fn to every turn before it is copied.
+func WithCloneRewrite(fn CloneRewrite) CloneOption {
+	return func(o *cloneOptions) {
+		o.rewrite = fn
+	}
+}
+
+// CloneResult describes a completed clone.
+type CloneResult struct {
+	ContextID  uint64
+	HeadTurnID uint64
+
+	// Copied and Skipped count source turns.
+	Copied  int
+	Skipped int
+
+	// TurnIDs maps each copied source turn ID to its ID in the clone.
+	TurnIDs map[uint64]uint64
+}
+
+// CloneContext copies the turns of contextID, oldest first, into a new
+// context. Unlike ForkContext the copy shares no turns with the source, so
+// a rewrite can sanitize it (redact secrets, strip reasoning) before it is
+// shared outside the security boundary.
+//
+// The clone keeps the order, types, and encodings of the source turns, and
+// timestamps stored in payloads are untouched unless the rewrite changes
+// them. Turns from a forked context's base are copied too. Filesystem
+// attachments are not.
+func (c *Client) CloneContext(ctx context.Context, contextID uint64, opts ...CloneOption) (*CloneResult, error) {
+	return cloneContext(ctx, c, contextID, opts)
+}
+
+// cloneSource is the subset of Client and ReconnectingClient that
+// cloneContext needs.
+type cloneSource interface {
+	GetHead(ctx context.Context, contextID uint64) (*ContextHead, error)
+	GetLastPage(ctx context.Context, contextID uint64, opts GetLastOptions) (*TurnPage, error)
+	CreateContext(ctx context.Context, baseTurnID uint64) (*ContextHead, error)
+	AppendTurn(ctx context.Context, req *AppendRequest) (*AppendResult, error)
+}
+
+func cloneContext(ctx context.Context, c cloneSource, contextID uint64, opts []CloneOption) (*CloneResult, error) {
+	var o cloneOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	head, err := c.GetHead(ctx, contextID)
+	if err != nil {
+		return nil, fmt.Errorf("clone context: %w", err)
+	}
+	var turns []TurnRecord
+	if head.HeadTurnID != 0 {
+		// The first turn is at depth 0, so the head's chain has one more
+		// turn than its depth.
+		n := head.HeadDepth + 1
+		page, err := c.GetLastPage(ctx, contextID, GetLastOptions{Limit: n, IncludePayload: true})
+		if err != nil {
+			return nil, fmt.Errorf("clone context: %w", err)
+		}
+		if page.Flags.Truncated() || len(page.Turns) < int(n) {
+			return nil, fmt.Errorf("clone context %d: %w: got %d of %d turns",
+				contextID, ErrCloneIncomplete, len(page.Turns), n)
+		}
+		turns = page.Turns
+	}
+
+	dst, err := c.CreateContext(ctx, 0)
+	if err != nil {
+		return nil, fmt.Errorf("clone context: %w", err)
+	}
+	result := &CloneResult{ContextID: dst.ContextID, TurnIDs: make(map[uint64]uint64, len(turns))}
+
+	for i := range turns {
+		t := &turns[i]
+		payload := t.Payload
+		if o.rewrite != nil {
+			payload, err = o.rewrite(t)
+			if errors.Is(err, ErrSkipTurn) {
+				result.Skipped++
+				continue
+			}
+			if err != nil {
+				return result, fmt.Errorf("clone context: rewrite turn %d: %w", t.TurnID, err)
+			}
+		}
+		res, err := c.AppendTurn(ctx, &AppendRequest{
+			ContextID:      dst.ContextID,
+			ParentTurnID:   result.HeadTurnID,
+			TypeID:         t.TypeID,
+			TypeVersion:    t.TypeVersion,
+			Payload:        payload,
+			Encoding:       t.Encoding,
+			Compression:    t.Compression,
+			IdempotencyKey: fmt.Sprintf("clone:%d:%d:%d", contextID, dst.ContextID, t.TurnID),
+		})
+		if err != nil {
+			return result, fmt.Errorf("clone context: copy turn %d: %w", t.TurnID, err)
+		}
+		result.TurnIDs[t.TurnID] = res.TurnID
+		result.HeadTurnID = res.TurnID
+		result.Copied++
+	}
+	return result, nil
+}
+
+// RewriteConversationItems returns a CloneRewrite that decodes each
+// ConversationItem turn, applies fn, and re-encodes it. Turns of other
+// types are copied unchanged. The item's Timestamp is restored after fn
+// runs. Tags the types package does not know are dropped from rewritten
+// turns, which is usually what a sanitized copy wants.
+func RewriteConversationItems(fn func(item *types.ConversationItem) error) CloneRewrite {
+	return func(turn *TurnRecord) ([]byte, error) {
+		if turn.TypeID != types.TypeIDConversationItem && turn.TypeID != types.TypeIDConversationItemLegacy {
+			return turn.Payload, nil
+		}
+		if turn.Encoding != EncodingMsgpack || turn.Compression != CompressionNone {
+			return nil, fmt.Errorf("conversation item turn %d has encoding %d, compression %d", turn.TurnID, turn.Encoding, turn.Compression)
+		}
+		var item types.ConversationItem
+		if err := DecodeMsgpackInto(turn.Payload, &item); err != nil {
+			return nil, fmt.Errorf("decode conversation item: %w", err)
+		}
+		ts := item.Timestamp
+		if err := fn(&item); err != nil {
+			return nil, err
+		}
+		item.Timestamp = ts
+		return EncodeMsgpack(&item)
+	}
+}
+
+// StripReasoning clears extended thinking output from assistant items. Use
+// it with RewriteConversationItems.
+func StripReasoning(item *types.ConversationItem) error {
+	if item.Turn != nil {
+		item.Turn.Reasoning = ""
+	}
+	if item.Assistant != nil {
+		item.Assistant.Reasoning = ""
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/strongdm/ai-cxdb/clients/go/types"
+)
+
+// contextStore is a cloneSource holding whole contexts in memory.
+type contextStore struct {
+	contexts  map[uint64][]TurnRecord
+	nextCtx   uint64
+	nextTurn  uint64
+	truncated bool
+}
+
+func newContextStore() *contextStore {
+	return &contextStore{contexts: make(map[uint64][]TurnRecord), nextCtx: 1, nextTurn: 1}
+}
+
+func (s *contextStore) GetHead(_ context.Context, contextID uint64) (*ContextHead, error) {
+	turns, ok := s.contexts[contextID]
+	if !ok {
+		return nil, &ServerError{Code: 404}
+	}
+	// Like the server, the first turn is at depth 0.
+	head := &ContextHead{ContextID: contextID}
+	if len(turns) > 0 {
+		head.HeadTurnID = turns[len(turns)-1].TurnID
+		head.HeadDepth = turns[len(turns)-1].Depth
+	}
+	return head, nil
+}
+
+func (s *contextStore) GetLastPage(_ context.Context, contextID uint64, opts GetLastOptions) (*TurnPage, error) {
+	turns := s.contexts[contextID]
+	if s.truncated && len(turns) > 1 {
+		return &TurnPage{Turns: turns[1:], Flags: ResponseFlagTruncated}, nil
+	}
+	if n := int(opts.Limit); n < len(turns) {
+		turns = turns[len(turns)-n:]
+	}
+	return &TurnPage{Turns: append([]TurnRecord(nil), turns...)}, nil
+}
+
+func (s *contextStore) CreateContext(context.Context, uint64) (*ContextHead, error) {
+	id := s.nextCtx
+	s.nextCtx++
+	s.contexts[id] = nil
+	return &ContextHead{ContextID: id}, nil
+}
+
+func (s *contextStore) AppendTurn(_ context.Context, req *AppendRequest) (*AppendResult, error) {
+	turns := s.contexts[req.ContextID]
+	var parent uint64
+	if len(turns) > 0 {
+		parent = turns[len(turns)-1].TurnID
+	}
+	if req.ParentTurnID != 0 && req.ParentTurnID != parent {
+		return nil, &ServerError{Code: 409}
+	}
+	rec := TurnRecord{
+		TurnID:      s.nextTurn,
+		ParentID:    parent,
+		Depth:       uint32(len(turns)),
+		TypeID:      req.TypeID,
+		TypeVersion: req.TypeVersion,
+		Encoding:    req.Encoding,
+		Compression: req.Compression,
+		Payload:     req.Payload,
+	}
+	s.nextTurn++
+	s.contexts[req.ContextID] = append(turns, rec)
+	return &AppendResult{ContextID: req.ContextID, TurnID: rec.TurnID, Depth: rec.Depth}, nil
+}
+
+func (s *contextStore) seed(t *testing.T, items ...*types.ConversationItem) uint64 {
+	t.Helper()
+	head, _ := s.CreateContext(context.Background(), 0)
+	for _, item := range items {
+		_, err := s.AppendTurn(context.Background(), &AppendRequest{
+			ContextID:   head.ContextID,
+			TypeID:      types.TypeIDConversationItem,
+			TypeVersion: types.TypeVersionConversationItem,
+			Payload:     mustEncode(t, item),
+			Encoding:    EncodingMsgpack,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return head.ContextID
+}
+
+func decodeItems(t *testing.T, turns []TurnRecord) []types.ConversationItem {
+	t.Helper()
+	items := make([]types.ConversationItem, len(turns))
+	for i, turn := range turns {
+		if err := DecodeMsgpackInto(turn.Payload, &items[i]); err != nil {
+			t.Fatalf("turn %d: %v", turn.TurnID, err)
+		}
+	}
+	return items
+}
+
+func TestCloneContextRewrite(t *testing.T) {
+	store := newContextStore()
+	user := types.NewUserInput("my key is sk-secret")
+	user.Timestamp = 1706615000000
+	reply := types.NewAssistantTurn("noted")
+	reply.Timestamp = 1706615001000
+	reply.Turn.Reasoning = "the user shared sk-secret"
+	src := store.seed(t, user, reply)
+
+	redact := func(item *types.ConversationItem) error {
+		if item.UserInput != nil {
+			item.UserInput.Text = strings.ReplaceAll(item.UserInput.Text, "sk-secret", "[redacted]")
+		}
+		item.Timestamp = 0 // must not leak into the clone
+		return StripReasoning(item)
+	}
+	res, err := cloneContext(context.Background(), store, src, []CloneOption{WithCloneRewrite(RewriteConversationItems(redact))})
+	if err != nil {
+		t.Fatalf("clone: %v", err)
+	}
+	if res.ContextID == src || res.Copied != 2 || res.Skipped != 0 {
+		t.Fatalf("result = %+v", res)
+	}
+
+	srcTurns, dstTurns := store.contexts[src], store.contexts[res.ContextID]
+	if res.HeadTurnID != dstTurns[1].TurnID || res.TurnIDs[srcTurns[1].TurnID] != dstTurns[1].TurnID {
+		t.Fatalf("turn mapping %v, head %d", res.TurnIDs, res.HeadTurnID)
+	}
+	if dstTurns[1].ParentID != dstTurns[0].TurnID || dstTurns[0].TypeID != types.TypeIDConversationItem {
+		t.Fatalf("clone structure wrong: %+v", dstTurns)
+	}
+
+	items := decodeItems(t, dstTurns)
+	if items[0].UserInput.Text != "my key is [redacted]" || items[1].Turn.Reasoning != "" || items[1].Turn.Text != "noted" {
+		t.Fatalf("clone not sanitized: %+v %+v", items[0].UserInput, items[1].Turn)
+	}
+	if items[0].Timestamp != 1706615000000 || items[1].Timestamp != 1706615001000 {
+		t.Fatalf("timestamps = %d, %d", items[0].Timestamp, items[1].Timestamp)
+	}
+
+	// The source is untouched.
+	if orig := decodeItems(t, srcTurns); orig[1].Turn.Reasoning == "" {
+		t.Fatal("source context was modified")
+	}
+}
+
+func TestCloneContextSkipAndPassThrough(t *testing.T) {
+	store := newContextStore()
+	src := store.seed(t, types.NewUserInput("a"), types.NewSystemInfo("internal"), types.NewUserInput("b"))
+	store.contexts[src] = append(store.contexts[src], TurnRecord{TurnID: 99, Depth: 3, TypeID: "com.example.Other", Payload: []byte{0xc0}})
+
+	rewrite := RewriteConversationItems(func(item *types.ConversationItem) error {
+		if item.System != nil {
+			return ErrSkipTurn
+		}
+		return nil
+	})
+	res, err := cloneContext(context.Background(), store, src, []CloneOption{WithCloneRewrite(rewrite)})
+	if err != nil {
+		t.Fatalf("clone: %v", err)
+	}
+	if res.Copied != 3 || res.Skipped != 1 {
+		t.Fatalf("copied %d, skipped %d", res.Copied, res.Skipped)
+	}
+	dst := store.contexts[res.ContextID]
+	if dst[2].TypeID != "com.example.Other" || string(dst[2].Payload) != "\xc0" {
+		t.Fatalf("other type not copied verbatim: %+v", dst[2])
+	}
+}
+
+func TestCloneContextEmptyAndIncomplete(t *testing.T) {
+	store := newContextStore()
+	empty := store.seed(t)
+	res, err := cloneContext(context.Background(), store, empty, nil)
+	if err != nil || res.Copied != 0 || res.ContextID == empty {
+		t.Fatalf("empty clone = %+v, %v", res, err)
+	}
+
+	// A single turn sits at depth 0 and is still copied.
+	one := store.seed(t, types.NewSystemInfo("system prompt"))
+	res, err = cloneContext(context.Background(), store, one, nil)
+	if err != nil || res.Copied != 1 || len(store.contexts[res.ContextID]) != 1 {
+		t.Fatalf("one-turn clone = %+v, %v", res, err)
+	}
+	if items := decodeItems(t, store.contexts[res.ContextID]); items[0].System == nil || items[0].System.Content != "system prompt" {
+		t.Fatalf("one-turn clone copied %+v", items[0])
+	}
+
+	src := store.seed(t, types.NewUserInput("a"), types.NewUserInput("b"))
+	store.truncated = true
+	before := len(store.contexts)
+	if _, err := cloneContext(context.Background(), store, src, nil); !errors.Is(err, ErrCloneIncomplete) {
+		t.Fatalf("err = %v, want ErrCloneIncomplete", err)
+	}
+	if len(store.contexts) != before {
+		t.Fatal("incomplete clone created a context")
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+// Command cxdb-analytics aggregates usage across contexts and writes a
+// report. It is intended to run periodically, e.g. from cron:
+//
+//	cxdb-analytics -since 24h -format csv -out /var/reports/cxdb-$(date +%F).csv
+//	cxdb-analytics -since 1h -pushgateway http://pushgateway:9091
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	cxdb "github.com/strongdm/ai-cxdb/clients/go"
+	"github.com/strongdm/ai-cxdb/clients/go/analytics"
+	"github.com/strongdm/ai-cxdb/clients/go/credentials"
+	"github.com/strongdm/ai-cxdb/clients/go/httpclient"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:9009", "binary protocol address")
+	api := flag.String("api", "http://127.0.0.1:9010", "HTTP API base URL")
+	token := flag.String("token", os.Getenv("CXDB_TOKEN"), "bearer token for the HTTP API")
+	profile := flag.String("profile", os.Getenv(credentials.EnvProfile), "stored credential profile to use when -token is empty")
+	since := flag.Duration("since", 24*time.Hour, "report on activity within this duration before now")
+	tag := flag.String("tag", "", "only scan contexts with this client tag")
+	limit := flag.Int("contexts", 1000, "maximum contexts to scan; the run fails if there are more")
+	pricingPath := flag.String("pricing", "", "JSON file mapping model to {input, output, cached_input} USD per million tokens")
+	format := flag.String("format", "json", "output format: json, csv, or prometheus")
+	out := flag.String("out", "-", "output file (- for stdout)")
+	pushgateway := flag.String("pushgateway", "", "Prometheus pushgateway URL to push metrics to")
+	job := flag.String("job", "cxdb_analytics", "pushgateway job name")
+	timeout := flag.Duration("timeout", 10*time.Minute, "overall timeout")
+	flag.Parse()
+
+	if *token == "" && *profile != "" {
+		cred, err := loadCredential(*profile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cxdb-analytics: %v\n", err)
+			os.Exit(1)
+		}
+		*token = cred.Token
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	if err := run(ctx, *addr, *api, *token, *since, *tag, *limit, *pricingPath, *format, *out, *pushgateway, *job); err != nil {
+		fmt.Fprintf(os.Stderr, "cxdb-analytics: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func loadCredential(profile string) (*credentials.Credential, error) {
+	store, err := credentials.Default()
+	if err != nil {
+		return nil, err
+	}
+	return credentials.Resolve(store, profile)
+}
+
+func run(ctx context.Context, addr, api, token string, since time.Duration, tag string, limit int,
+	pricingPath, format, out, pushgateway, job string) error {
+	var pricing analytics.Pricing
+	if pricingPath != "" {
+		data, err := os.ReadFile(pricingPath)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &pricing); err != nil {
+			return fmt.Errorf("parse pricing: %w", err)
+		}
+	}
+
+	client, err := cxdb.Dial(addr, cxdb.WithClientTag("cxdb-analytics"))
+	if err != nil {
+		return fmt.Errorf("dial: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	var hopts []httpclient.Option
+	if token != "" {
+		hopts = append(hopts, httpclient.WithBearerToken(token))
+	}
+	src := &analytics.ClientSource{
+		API:       httpclient.New(api, hopts...),
+		Client:    client,
+		ListLimit: limit,
+		Tag:       tag,
+	}
+
+	now := time.Now()
+	report, err := analytics.Run(ctx, src,
+		analytics.WithRange(now.Add(-since), now),
+		analytics.WithPricing(pricing),
+	)
+	if errors.Is(err, analytics.ErrContextListTruncated) {
+		return fmt.Errorf("%w; raise -contexts", err)
+	}
+	if err != nil {
+		return err
+	}
+	for id, msg := range report.ContextErrors {
+		fmt.Fprintf(os.Stderr, "context %d: %s\n", id, msg)
+	}
+
+	var w io.Writer = os.Stdout
+	if out != "-" {
+		f, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+
+	var sinks []analytics.Sink
+	switch format {
+	case "json":
+		sinks = append(sinks, analytics.NewJSONSink(w))
+	case "csv":
+		sinks = append(sinks, analytics.NewCSVSink(w))
+	case "prometheus":
+		sinks = append(sinks, promSink{w})
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+	if pushgateway != "" {
+		sinks = append(sinks, analytics.NewPushgatewaySink(pushgateway, job, nil))
+	}
+	for _, s := range sinks {
+		if err := s.Write(ctx, report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type promSink struct {
+	w io.Writer
+}
+
+func (s promSink) Write(_ context.Context, r *analytics.Report) error {
+	return analytics.WritePrometheus(s.w, r)
+}
@@ -0,0 +1,118 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+// Command cxdb-credentials manages stored gateway tokens for CLI tools.
+//
+//	cxdb-credentials set -profile prod -gateway https://cxdb.example.com < token.txt
+//	cxdb-credentials get -profile prod
+//	cxdb-credentials delete -profile prod
+//
+// Tokens go to the OS keychain when available, otherwise to an encrypted
+// file unlocked with CXDB_CREDENTIALS_PASSPHRASE. Tools that accept
+// -profile (or CXDB_PROFILE) read them from there.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/strongdm/ai-cxdb/clients/go/credentials"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cmd, args := os.Args[1], os.Args[2:]
+
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	profile := fs.String("profile", credentials.ProfileFromEnv(), "credential profile (e.g. dev, staging, prod)")
+	gateway := fs.String("gateway", "", "gateway base URL the token was issued by (set)")
+	expiresIn := fs.Duration("expires-in", 0, "token lifetime from now; 0 if unknown (set)")
+	asJSON := fs.Bool("json", false, "print the full credential as JSON (get)")
+	_ = fs.Parse(args)
+
+	store, err := credentials.Default()
+	if err != nil {
+		fail(err)
+	}
+
+	switch cmd {
+	case "set":
+		err = set(store, *profile, *gateway, *expiresIn, os.Stdin)
+	case "get":
+		err = get(store, *profile, *asJSON, os.Stdout)
+	case "delete":
+		err = store.Delete(*profile)
+	default:
+		usage()
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+// set reads a token, or a JSON token exchange response, from r.
+func set(store credentials.Store, profile, gateway string, expiresIn time.Duration, r io.Reader) error {
+	data, err := io.ReadAll(bufio.NewReader(r))
+	if err != nil {
+		return err
+	}
+	input := strings.TrimSpace(string(data))
+	if input == "" {
+		return errors.New("no token on stdin")
+	}
+
+	cred := &credentials.Credential{Token: input, TokenType: "Bearer"}
+	if strings.HasPrefix(input, "{") {
+		// Output of POST /auth/aws/token.
+		var resp struct {
+			Token     string    `json:"token"`
+			ExpiresAt time.Time `json:"expires_at"`
+			TokenType string    `json:"token_type"`
+		}
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return fmt.Errorf("parse token response: %w", err)
+		}
+		cred = &credentials.Credential{Token: resp.Token, TokenType: resp.TokenType, ExpiresAt: resp.ExpiresAt}
+	}
+	if expiresIn > 0 {
+		cred.ExpiresAt = time.Now().Add(expiresIn).UTC()
+	}
+	cred.Gateway = gateway
+	return store.Set(profile, cred)
+}
+
+func get(store credentials.Store, profile string, asJSON bool, w io.Writer) error {
+	cred, err := store.Get(profile)
+	if err != nil {
+		return err
+	}
+	if cred.Expired(time.Now()) {
+		fmt.Fprintf(os.Stderr, "cxdb-credentials: warning: token for %s expired at %s\n", profile, cred.ExpiresAt.Format(time.RFC3339))
+	}
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(cred)
+	}
+	_, err = fmt.Fprintln(w, cred.Token)
+	return err
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: cxdb-credentials set|get|delete [-profile name] [flags]")
+	os.Exit(2)
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "cxdb-credentials: %v\n", err)
+	os.Exit(1)
+}
@@ -13,6 +13,8 @@ import (
 	"path/filepath"
 
 	"github.com/zeebo/blake3"
+
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
 )
 
 type Fixture struct {
@@ -27,21 +29,7 @@ func main() {
 	outDir := flag.String("out", "clients/rust/cxdb/tests/fixtures", "output directory for fixtures")
 	flag.Parse()
 
-	fixtures := []Fixture{
-		helloFixture("hello_empty", ""),
-		helloFixture("hello_tag", "test-client"),
-		ctxCreateFixture("ctx_create_base0", 0),
-		ctxForkFixture("ctx_fork_base123", 123),
-		getHeadFixture("get_head_ctx42", 42),
-		appendFixture("append_parent0", 1, 0, "cxdb.ConversationItem", 3, []byte{0x91, 0x01}, ""),
-		appendFixture("append_parent7", 1, 7, "cxdb.ConversationItem", 3, []byte{0x91, 0x02}, ""),
-		appendFixture("append_idempotent", 1, 0, "cxdb.ConversationItem", 3, []byte{0x91, 0x03}, "idem-1"),
-		getLastFixture("get_last_default", 1, 10, false),
-		getLastFixture("get_last_payload", 1, 5, true),
-		attachFsFixture("attach_fs", 99, testHash(0xAA)),
-		putBlobFixture("put_blob", []byte("hello blob")),
-		appendWithFsFixture("append_with_fs", 1, 0, "cxdb.ConversationItem", 3, []byte{0x91, 0x04}, "", testHash(0xBB)),
-	}
+	fixtures := protocolFixtures()
 
 	if err := os.MkdirAll(*outDir, 0o755); err != nil {
 		fmt.Fprintf(os.Stderr, "mkdir: %v\n", err)
@@ -62,31 +50,69 @@ func main() {
 	}
 }
 
+// protocolFixtures returns the request fixtures in generation order.
+func protocolFixtures() []Fixture {
+	return []Fixture{
+		helloFixture("hello_empty", ""),
+		helloFixture("hello_tag", "test-client"),
+		helloRoutingKeyFixture("hello_routing_key", "test-client", "tenant-7"),
+		ctxCreateFixture("ctx_create_base0", 0),
+		ctxForkFixture("ctx_fork_base123", 123),
+		getHeadFixture("get_head_ctx42", 42),
+		routedFixture(getHeadFixture("get_head_routed", 42), "42"),
+		appendFixture("append_parent0", 1, 0, "cxdb.ConversationItem", 3, []byte{0x91, 0x01}, ""),
+		appendFixture("append_parent7", 1, 7, "cxdb.ConversationItem", 3, []byte{0x91, 0x02}, ""),
+		appendFixture("append_idempotent", 1, 0, "cxdb.ConversationItem", 3, []byte{0x91, 0x03}, "idem-1"),
+		getLastFixture("get_last_default", 1, 10, false),
+		getLastFixture("get_last_payload", 1, 5, true),
+		getLastIfChangedFixture("get_last_if_changed", 1, 10, 42),
+		attachFsFixture("attach_fs", 99, testHash(0xAA)),
+		attachFsTreeFormatFixture("attach_fs_tree_v2", 99, testHash(0xAA), wire.TreeFormatV2),
+		putBlobFixture("put_blob", []byte("hello blob")),
+		putBlobProbeFixture("put_blob_probe", []byte("hello blob")),
+		appendWithFsFixture("append_with_fs", 1, 0, "cxdb.ConversationItem", 3, []byte{0x91, 0x04}, "", testHash(0xBB)),
+	}
+}
+
 func helloFixture(name, tag string) Fixture {
-	payload := make([]byte, 0, 2+2+len(tag)+4)
-	payload = appendU16(payload, 1)
-	payload = appendU16(payload, uint16(len(tag)))
-	payload = append(payload, []byte(tag)...)
-	payload = appendU32(payload, 0)
-	return Fixture{Name: name, MsgType: 1, Flags: 0, PayloadHex: hex.EncodeToString(payload)}
+	payload := wire.AppendHello(nil, tag, nil)
+	return Fixture{Name: name, MsgType: wire.MsgHello, Flags: 0, PayloadHex: hex.EncodeToString(payload)}
+}
+
+func helloRoutingKeyFixture(name, tag, routingKey string) Fixture {
+	meta, _ := json.Marshal(map[string]string{"routing_key": routingKey})
+	payload := wire.AppendHello(nil, tag, meta)
+	return Fixture{
+		Name: name, MsgType: wire.MsgHello, Flags: 0, PayloadHex: hex.EncodeToString(payload),
+		Notes: "HELLO with a connection routing key in client_meta_json.",
+	}
+}
+
+// routedFixture prefixes a request fixture with a routing hint.
+func routedFixture(fixture Fixture, key string) Fixture {
+	payload, _ := hex.DecodeString(fixture.PayloadHex)
+	fixture.Flags |= wire.FlagRoutingKey
+	fixture.PayloadHex = hex.EncodeToString(append(wire.AppendRoutingKey(nil, key), payload...))
+	fixture.Notes = "Routed request: starts with key_len:u16 and the routing key; sent only to servers advertising routing_keys."
+	return fixture
 }
 
 func ctxCreateFixture(name string, baseTurn uint64) Fixture {
 	payload := make([]byte, 8)
 	binary.LittleEndian.PutUint64(payload, baseTurn)
-	return Fixture{Name: name, MsgType: 2, Flags: 0, PayloadHex: hex.EncodeToString(payload)}
+	return Fixture{Name: name, MsgType: wire.MsgCtxCreate, Flags: 0, PayloadHex: hex.EncodeToString(payload)}
 }
 
 func ctxForkFixture(name string, baseTurn uint64) Fixture {
 	payload := make([]byte, 8)
 	binary.LittleEndian.PutUint64(payload, baseTurn)
-	return Fixture{Name: name, MsgType: 3, Flags: 0, PayloadHex: hex.EncodeToString(payload)}
+	return Fixture{Name: name, MsgType: wire.MsgCtxFork, Flags: 0, PayloadHex: hex.EncodeToString(payload)}
 }
 
 func getHeadFixture(name string, contextID uint64) Fixture {
 	payload := make([]byte, 8)
 	binary.LittleEndian.PutUint64(payload, contextID)
-	return Fixture{Name: name, MsgType: 4, Flags: 0, PayloadHex: hex.EncodeToString(payload)}
+	return Fixture{Name: name, MsgType: wire.MsgGetHead, Flags: 0, PayloadHex: hex.EncodeToString(payload)}
 }
 
 func appendFixture(name string, ctxID, parentID uint64, typeID string, typeVersion uint32, payloadBytes []byte, idem string) Fixture {
@@ -96,8 +122,8 @@ func appendFixture(name string, ctxID, parentID uint64, typeID string, typeVersi
 	payload = appendU32(payload, uint32(len(typeID)))
 	payload = append(payload, []byte(typeID)...)
 	payload = appendU32(payload, typeVersion)
-	payload = appendU32(payload, 1) // EncodingMsgpack
-	payload = appendU32(payload, 0) // CompressionNone
+	payload = appendU32(payload, wire.EncodingMsgpack)
+	payload = appendU32(payload, wire.CompressionNone)
 	payload = appendU32(payload, uint32(len(payloadBytes)))
 	hash := blake3.Sum256(payloadBytes)
 	payload = append(payload, hash[:]...)
@@ -107,14 +133,14 @@ func appendFixture(name string, ctxID, parentID uint64, typeID string, typeVersi
 	if len(idem) > 0 {
 		payload = append(payload, []byte(idem)...)
 	}
-	return Fixture{Name: name, MsgType: 5, Flags: 0, PayloadHex: hex.EncodeToString(payload)}
+	return Fixture{Name: name, MsgType: wire.MsgAppend, Flags: 0, PayloadHex: hex.EncodeToString(payload)}
 }
 
 func appendWithFsFixture(name string, ctxID, parentID uint64, typeID string, typeVersion uint32, payloadBytes []byte, idem string, fsHash [32]byte) Fixture {
 	fixture := appendFixture(name, ctxID, parentID, typeID, typeVersion, payloadBytes, idem)
 	payload, _ := hex.DecodeString(fixture.PayloadHex)
 	payload = append(payload, fsHash[:]...)
-	fixture.Flags = 1
+	fixture.Flags = wire.FlagHasFsRoot
 	fixture.PayloadHex = hex.EncodeToString(payload)
 	return fixture
 }
@@ -128,14 +154,34 @@ func getLastFixture(name string, contextID uint64, limit uint32, includePayload
 	} else {
 		payload = appendU32(payload, 0)
 	}
-	return Fixture{Name: name, MsgType: 6, Flags: 0, PayloadHex: hex.EncodeToString(payload)}
+	return Fixture{Name: name, MsgType: wire.MsgGetLast, Flags: 0, PayloadHex: hex.EncodeToString(payload)}
+}
+
+func getLastIfChangedFixture(name string, contextID uint64, limit uint32, knownHead uint64) Fixture {
+	fixture := getLastFixture(name, contextID, limit, false)
+	payload, _ := hex.DecodeString(fixture.PayloadHex)
+	payload = appendU64(payload, knownHead)
+	fixture.Flags = wire.FlagIfHeadChanged
+	fixture.PayloadHex = hex.EncodeToString(payload)
+	fixture.Notes = "Conditional GET_LAST: ends with the head turn ID last seen; the server answers with no turns and not_modified if the head is unchanged."
+	return fixture
 }
 
 func attachFsFixture(name string, turnID uint64, fsHash [32]byte) Fixture {
 	payload := make([]byte, 0, 40)
 	payload = appendU64(payload, turnID)
 	payload = append(payload, fsHash[:]...)
-	return Fixture{Name: name, MsgType: 10, Flags: 0, PayloadHex: hex.EncodeToString(payload)}
+	return Fixture{Name: name, MsgType: wire.MsgAttachFs, Flags: 0, PayloadHex: hex.EncodeToString(payload)}
+}
+
+func attachFsTreeFormatFixture(name string, turnID uint64, fsHash [32]byte, format uint8) Fixture {
+	fixture := attachFsFixture(name, turnID, fsHash)
+	payload, _ := hex.DecodeString(fixture.PayloadHex)
+	payload = append(payload, format)
+	fixture.Flags = wire.FlagTreeFormat
+	fixture.PayloadHex = hex.EncodeToString(payload)
+	fixture.Notes = "ATTACH_FS ending with the tree format version of the snapshot."
+	return fixture
 }
 
 func putBlobFixture(name string, data []byte) Fixture {
@@ -144,13 +190,21 @@ func putBlobFixture(name string, data []byte) Fixture {
 	payload = append(payload, hash[:]...)
 	payload = appendU32(payload, uint32(len(data)))
 	payload = append(payload, data...)
-	return Fixture{Name: name, MsgType: 11, Flags: 0, PayloadHex: hex.EncodeToString(payload)}
+	return Fixture{Name: name, MsgType: wire.MsgPutBlob, Flags: 0, PayloadHex: hex.EncodeToString(payload)}
 }
 
-func appendU16(buf []byte, val uint16) []byte {
-	b := make([]byte, 2)
-	binary.LittleEndian.PutUint16(b, val)
-	return append(buf, b...)
+func putBlobProbeFixture(name string, data []byte) Fixture {
+	hash := blake3.Sum256(data)
+	payload := make([]byte, 0, 36)
+	payload = append(payload, hash[:]...)
+	payload = appendU32(payload, uint32(len(data)))
+	return Fixture{
+		Name:       name,
+		MsgType:    wire.MsgPutBlob,
+		Flags:      wire.FlagBlobProbe,
+		PayloadHex: hex.EncodeToString(payload),
+		Notes:      "Hash-first PUT_BLOB: hash and length only; content follows in a plain PUT_BLOB if the server answers 404.",
+	}
 }
 
 func appendU32(buf []byte, val uint32) []byte {
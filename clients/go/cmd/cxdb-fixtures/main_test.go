@@ -0,0 +1,32 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestFixturesUpToDate fails when the checked-in fixtures no longer match
+// the generator, e.g. after a wire constant changed without regenerating.
+func TestFixturesUpToDate(t *testing.T) {
+	for _, dir := range []string{"../../../../fixtures/protocol", "../../../rust/tests/fixtures"} {
+		for _, want := range protocolFixtures() {
+			data, err := os.ReadFile(filepath.Join(dir, want.Name+".json"))
+			if err != nil {
+				t.Errorf("%s: %v", dir, err)
+				continue
+			}
+			var got Fixture
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("%s/%s: %v", dir, want.Name, err)
+			}
+			if got != want {
+				t.Errorf("%s/%s is stale:\n%+v\nregenerate to get\n%+v", dir, want.Name, got, want)
+			}
+		}
+	}
+}
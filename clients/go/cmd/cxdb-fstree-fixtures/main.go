@@ -40,12 +40,45 @@ func main() {
 		os.Exit(1)
 	}
 
-	snap, err := fstree.Capture(tmpDir)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "capture: %v\n", err)
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "mkdir: %v\n", err)
 		os.Exit(1)
 	}
 
+	formats := []struct {
+		name   string
+		format uint8
+		notes  string
+	}{
+		{"fstree_basic", fstree.TreeFormatV1, "Generated from a deterministic synthetic workspace."},
+		{"fstree_basic_v2", fstree.TreeFormatV2, "The fstree_basic workspace in tree format v2: each tree object starts with the version byte 0x02."},
+	}
+	for _, f := range formats {
+		fixture, err := buildFixture(tmpDir, f.name, f.format, f.notes)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", f.name, err)
+			os.Exit(1)
+		}
+
+		path := filepath.Join(*outDir, fixture.Name+".json")
+		data, err := json.MarshalIndent(fixture, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "marshal %s: %v\n", fixture.Name, err)
+			os.Exit(1)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "write %s: %v\n", path, err)
+			os.Exit(1)
+		}
+	}
+}
+
+func buildFixture(root, name string, format uint8, notes string) (Fixture, error) {
+	snap, err := fstree.Capture(root, fstree.WithTreeFormat(format))
+	if err != nil {
+		return Fixture{}, fmt.Errorf("capture: %w", err)
+	}
+
 	trees := make(map[string]string)
 	for hash, data := range snap.Trees {
 		trees[hex.EncodeToString(hash[:])] = hex.EncodeToString(data)
@@ -53,7 +86,7 @@ func main() {
 
 	files := make(map[string]string)
 	for hash, ref := range snap.Files {
-		rel, err := filepath.Rel(tmpDir, ref.Path)
+		rel, err := filepath.Rel(root, ref.Path)
 		if err != nil {
 			rel = ref.Path
 		}
@@ -67,30 +100,14 @@ func main() {
 		"hello": hex.EncodeToString(helloHash[:]),
 	}
 
-	fixture := Fixture{
-		Name:        "fstree_basic",
+	return Fixture{
+		Name:        name,
 		RootHashHex: hex.EncodeToString(snap.RootHash[:]),
 		Trees:       trees,
 		Files:       files,
 		Blake3:      blake3Fixtures,
-		Notes:       "Generated from a deterministic synthetic workspace.",
-	}
-
-	if err := os.MkdirAll(*outDir, 0o755); err != nil {
-		fmt.Fprintf(os.Stderr, "mkdir: %v\n", err)
-		os.Exit(1)
-	}
-
-	path := filepath.Join(*outDir, fixture.Name+".json")
-	data, err := json.MarshalIndent(fixture, "", "  ")
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "marshal %s: %v\n", fixture.Name, err)
-		os.Exit(1)
-	}
-	if err := os.WriteFile(path, data, 0o644); err != nil {
-		fmt.Fprintf(os.Stderr, "write %s: %v\n", path, err)
-		os.Exit(1)
-	}
+		Notes:       notes,
+	}, nil
 }
 
 func seedWorkspace(root string) error {
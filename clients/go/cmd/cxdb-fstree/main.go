@@ -0,0 +1,110 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+// Command cxdb-fstree previews what a filesystem snapshot would capture.
+//
+//	cxdb-fstree stats   -exclude 'node_modules/**' ./workspace
+//	cxdb-fstree explain -exclude '*.log' -include 'keep.log' ./workspace logs/keep.log
+//
+// stats captures the directory and prints counts and warnings (files over
+// the size limit, unreadable entries). explain reports, for each path,
+// which rule includes or excludes it.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/strongdm/ai-cxdb/clients/go/fstree"
+)
+
+// listFlag collects a repeatable string flag.
+type listFlag []string
+
+func (l *listFlag) String() string     { return strings.Join(*l, ",") }
+func (l *listFlag) Set(v string) error { *l = append(*l, v); return nil }
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cmd, args := os.Args[1], os.Args[2:]
+
+	var includes, excludes listFlag
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	fs.Var(&includes, "include", "glob to capture even if an -exclude matches (repeatable)")
+	fs.Var(&excludes, "exclude", "glob to exclude (repeatable)")
+	maxSize := fs.Int64("max-size", 100<<20, "maximum file size in bytes")
+	follow := fs.Bool("follow-symlinks", false, "capture symlink targets instead of links")
+	_ = fs.Parse(args)
+
+	// Includes are exceptions, so they are evaluated first.
+	var rules []fstree.Rule
+	for _, p := range includes {
+		rules = append(rules, fstree.Rule{Pattern: p, Include: true})
+	}
+	for _, p := range excludes {
+		rules = append(rules, fstree.Rule{Pattern: p})
+	}
+	opts := []fstree.Option{fstree.WithRules(rules...), fstree.WithMaxFileSize(*maxSize)}
+	if *follow {
+		opts = append(opts, fstree.WithFollowSymlinks())
+	}
+
+	var err error
+	switch cmd {
+	case "stats":
+		if fs.NArg() != 1 {
+			usage()
+		}
+		err = stats(fs.Arg(0), opts)
+	case "explain":
+		if fs.NArg() < 2 {
+			usage()
+		}
+		err = explain(fs.Arg(0), fs.Args()[1:], opts)
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cxdb-fstree: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func stats(root string, opts []fstree.Option) error {
+	snap, err := fstree.Capture(root, opts...)
+	if err != nil {
+		return err
+	}
+	s := snap.Stats
+	fmt.Printf("root:     %x\n", snap.RootHash)
+	fmt.Printf("files:    %d (%d bytes)\n", s.FileCount, s.TotalBytes)
+	fmt.Printf("dirs:     %d\n", s.DirCount)
+	fmt.Printf("symlinks: %d\n", s.SymlinkCount)
+	fmt.Printf("excluded: %d\n", s.ExcludedCount)
+	fmt.Printf("duration: %s\n", s.Duration)
+	for _, w := range s.Warnings {
+		fmt.Printf("warning:  %s\n", w)
+	}
+	return nil
+}
+
+func explain(root string, paths []string, opts []fstree.Option) error {
+	for _, p := range paths {
+		e, err := fstree.Explain(root, p, opts...)
+		if err != nil {
+			return err
+		}
+		fmt.Println(e)
+	}
+	return nil
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: cxdb-fstree stats [flags] DIR")
+	fmt.Fprintln(os.Stderr, "       cxdb-fstree explain [flags] DIR PATH...")
+	os.Exit(2)
+}
@@ -0,0 +1,171 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+// Command cxdb-gc-report walks contexts and reports where storage goes: bytes
+// per context and client tag, the largest filesystem snapshots, and the
+// content stored most often. It only reads, and is meant to guide retention
+// and fstree exclude-pattern decisions:
+//
+//	cxdb-gc-report -top 20
+//	cxdb-gc-report -query 'label = "ci"' -format json -out gc.json
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	cxdb "github.com/strongdm/ai-cxdb/clients/go"
+	"github.com/strongdm/ai-cxdb/clients/go/credentials"
+	"github.com/strongdm/ai-cxdb/clients/go/httpclient"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:9009", "binary protocol address")
+	api := flag.String("api", "http://127.0.0.1:9010", "HTTP API base URL")
+	token := flag.String("token", os.Getenv("CXDB_TOKEN"), "bearer token for the HTTP API")
+	profile := flag.String("profile", os.Getenv(credentials.EnvProfile), "stored credential profile to use when -token is empty")
+	tag := flag.String("tag", "", "only scan contexts with this client tag")
+	query := flag.String("query", "", `only scan contexts matching this CQL query, e.g. 'label = "ci"'`)
+	limit := flag.Int("contexts", 1000, "maximum contexts to scan")
+	maxTurns := flag.Uint("turns", 10000, "maximum turns to read per context")
+	snapshots := flag.Bool("snapshots", true, "walk the filesystem snapshot of each context head")
+	top := flag.Int("top", 10, "entries in the largest-snapshot and duplicate lists")
+	format := flag.String("format", "text", "output format: text or json")
+	out := flag.String("out", "-", "output file (- for stdout)")
+	timeout := flag.Duration("timeout", 30*time.Minute, "overall timeout")
+	flag.Parse()
+
+	if *tag != "" && *query != "" {
+		fmt.Fprintln(os.Stderr, "cxdb-gc-report: -tag and -query are exclusive; use tag = \"...\" in the query")
+		os.Exit(2)
+	}
+	if *token == "" && *profile != "" {
+		cred, err := loadCredential(*profile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cxdb-gc-report: %v\n", err)
+			os.Exit(1)
+		}
+		*token = cred.Token
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	client, err := cxdb.Dial(*addr, cxdb.WithClientTag("cxdb-gc-report"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cxdb-gc-report: dial: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = client.Close() }()
+
+	var hopts []httpclient.Option
+	if *token != "" {
+		hopts = append(hopts, httpclient.WithBearerToken(*token))
+	}
+	src := &clientSource{
+		api:    httpclient.New(*api, hopts...),
+		client: client,
+		tag:    *tag,
+		query:  *query,
+		limit:  *limit,
+	}
+	opts := scanOptions{maxTurns: uint32(*maxTurns), snapshots: *snapshots, top: *top}
+	if err := run(ctx, src, opts, *format, *out); err != nil {
+		fmt.Fprintf(os.Stderr, "cxdb-gc-report: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func loadCredential(profile string) (*credentials.Credential, error) {
+	store, err := credentials.Default()
+	if err != nil {
+		return nil, err
+	}
+	return credentials.Resolve(store, profile)
+}
+
+func run(ctx context.Context, src source, opts scanOptions, format, out string) error {
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unknown format %q", format)
+	}
+	report, err := scan(ctx, src, opts)
+	if err != nil {
+		return err
+	}
+	for id, msg := range report.Errors {
+		fmt.Fprintf(os.Stderr, "context %s: %s\n", id, msg)
+	}
+
+	var w io.Writer = os.Stdout
+	if out != "-" {
+		f, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+	if format == "json" {
+		return writeJSON(w, report)
+	}
+	return writeText(w, report)
+}
+
+// source lists contexts and reads what they reference.
+type source interface {
+	// contexts returns the contexts to scan.
+	contexts(ctx context.Context) ([]httpclient.ContextSummary, error)
+
+	// turns returns up to limit of the newest turns of a context with
+	// payloads, blob references left as stubs.
+	turns(ctx context.Context, contextID uint64, limit uint32) ([]cxdb.TurnRecord, error)
+
+	// listFs lists a directory of a turn's filesystem snapshot. It returns
+	// errNoSnapshot if the turn has none.
+	listFs(ctx context.Context, turnID uint64, path string) (*httpclient.FsListing, error)
+}
+
+var errNoSnapshot = errors.New("no filesystem snapshot")
+
+// clientSource lists contexts and snapshots through the HTTP API and reads
+// turns over the binary protocol.
+type clientSource struct {
+	api    *httpclient.Client
+	client *cxdb.Client
+	tag    string
+	query  string
+	limit  int
+}
+
+func (s *clientSource) contexts(ctx context.Context) ([]httpclient.ContextSummary, error) {
+	if s.query != "" {
+		res, err := s.api.SearchContexts(ctx, s.query, &httpclient.SearchContextsParams{Limit: s.limit})
+		if err != nil {
+			return nil, fmt.Errorf("search contexts: %w", err)
+		}
+		return res.Contexts, nil
+	}
+	list, err := s.api.ListContexts(ctx, &httpclient.ListContextsParams{Limit: s.limit, Tag: s.tag})
+	if err != nil {
+		return nil, fmt.Errorf("list contexts: %w", err)
+	}
+	return list.Contexts, nil
+}
+
+func (s *clientSource) turns(ctx context.Context, contextID uint64, limit uint32) ([]cxdb.TurnRecord, error) {
+	return s.client.GetLast(ctx, contextID, cxdb.GetLastOptions{Limit: limit, IncludePayload: true, KeepPayloadRefs: true})
+}
+
+func (s *clientSource) listFs(ctx context.Context, turnID uint64, path string) (*httpclient.FsListing, error) {
+	listing, err := s.api.ListFs(ctx, turnID, &httpclient.ListFsParams{Path: path})
+	if path == "" && cxdb.IsServerError(err, http.StatusNotFound) {
+		return nil, fmt.Errorf("turn %d: %w", turnID, errNoSnapshot)
+	}
+	return listing, err
+}
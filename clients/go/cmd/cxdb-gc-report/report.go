@@ -0,0 +1,432 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	cxdb "github.com/strongdm/ai-cxdb/clients/go"
+	"github.com/strongdm/ai-cxdb/clients/go/analytics"
+)
+
+// Content kinds in Duplicate.Kind.
+const (
+	kindPayload = "payload" // inline turn payload
+	kindBlob    = "blob"    // turn payload stored as a blob
+	kindFile    = "file"    // file in a filesystem snapshot
+)
+
+type scanOptions struct {
+	maxTurns  uint32
+	snapshots bool
+	top       int
+}
+
+// Report is the result of one cxdb-gc-report run. Per-context and per-tag
+// figures count everything a context references, so contexts that share
+// turns through forks or share a snapshot each count it; Totals count each
+// turn, blob, and file once.
+type Report struct {
+	GeneratedAt      time.Time         `json:"generated_at"`
+	Totals           Totals            `json:"totals"`
+	Contexts         []ContextUsage    `json:"contexts"`
+	ClientTags       []TagUsage        `json:"client_tags"`
+	LargestSnapshots []SnapshotUsage   `json:"largest_snapshots"`
+	Duplicates       []Duplicate       `json:"duplicates"`
+	Errors           map[string]string `json:"errors,omitempty"` // by context ID
+}
+
+// Usage is what a context or client tag references, in bytes.
+type Usage struct {
+	Turns         int   `json:"turns"`
+	PayloadBytes  int64 `json:"payload_bytes"`
+	BlobBytes     int64 `json:"blob_bytes"`
+	SnapshotBytes int64 `json:"snapshot_bytes"` // head snapshot files
+}
+
+// Bytes is the sum of all byte counts.
+func (u Usage) Bytes() int64 {
+	return u.PayloadBytes + u.BlobBytes + u.SnapshotBytes
+}
+
+func (u *Usage) add(o Usage) {
+	u.Turns += o.Turns
+	u.PayloadBytes += o.PayloadBytes
+	u.BlobBytes += o.BlobBytes
+	u.SnapshotBytes += o.SnapshotBytes
+}
+
+// Totals count distinct content across all scanned contexts.
+type Totals struct {
+	Contexts  int `json:"contexts"`
+	Snapshots int `json:"snapshots"`
+	Usage
+
+	// ReferencedBytes is the sum of Bytes over contexts: what storage
+	// would take without content addressing and shared turns.
+	ReferencedBytes int64 `json:"referenced_bytes"`
+}
+
+// ContextUsage is the storage referenced by one context.
+type ContextUsage struct {
+	ContextID string `json:"context_id"`
+	ClientTag string `json:"client_tag"`
+	Usage
+
+	// SharedTurns counts turns already reached from a context listed
+	// earlier, typically the base of a fork.
+	SharedTurns int `json:"shared_turns"`
+
+	// Truncated means the context has more turns than were read.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// TagUsage is the storage referenced by the contexts of one client tag.
+type TagUsage struct {
+	ClientTag string `json:"client_tag"`
+	Contexts  int    `json:"contexts"`
+	Usage
+}
+
+// SnapshotUsage describes one head filesystem snapshot.
+type SnapshotUsage struct {
+	RootHash string `json:"root_hash"`
+	TurnID   string `json:"turn_id"`
+	Files    int    `json:"files"`
+	Bytes    int64  `json:"bytes"`
+
+	// Contexts lists the contexts whose head has this snapshot.
+	Contexts []string `json:"contexts"`
+}
+
+// Duplicate is content referenced more than once. The server stores it
+// once; many references usually mean the same large file or payload is
+// captured over and over, a candidate for an exclude pattern or a blob.
+type Duplicate struct {
+	Hash    string `json:"hash"`
+	Kind    string `json:"kind"`
+	Size    int64  `json:"size"`
+	Refs    int    `json:"refs"`
+	Example string `json:"example"` // type ID or file path
+}
+
+// repeatedBytes is what the extra references would take if stored again.
+func (d Duplicate) repeatedBytes() int64 {
+	return d.Size * int64(d.Refs-1)
+}
+
+// scanner accumulates a Report.
+type scanner struct {
+	src    source
+	opts   scanOptions
+	report *Report
+
+	turns     map[uint64]bool
+	content   map[[32]byte]*Duplicate
+	snapshots map[string]*SnapshotUsage
+	tags      map[string]*TagUsage
+}
+
+func scan(ctx context.Context, src source, opts scanOptions) (*Report, error) {
+	list, err := src.contexts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s := &scanner{
+		src:       src,
+		opts:      opts,
+		report:    &Report{GeneratedAt: time.Now().UTC(), Errors: map[string]string{}},
+		turns:     map[uint64]bool{},
+		content:   map[[32]byte]*Duplicate{},
+		snapshots: map[string]*SnapshotUsage{},
+		tags:      map[string]*TagUsage{},
+	}
+	for _, c := range list {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		id, err := strconv.ParseUint(c.ContextID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("list contexts: invalid context id %q", c.ContextID)
+		}
+		tag := c.ClientTag
+		if tag == "" {
+			tag = analytics.UntaggedClient
+		}
+		usage, err := s.scanContext(ctx, id, tag)
+		if err != nil {
+			s.report.Errors[c.ContextID] = err.Error()
+			continue
+		}
+		s.report.Contexts = append(s.report.Contexts, usage)
+	}
+	return s.finish(), nil
+}
+
+func (s *scanner) scanContext(ctx context.Context, contextID uint64, tag string) (ContextUsage, error) {
+	usage := ContextUsage{ContextID: strconv.FormatUint(contextID, 10), ClientTag: tag}
+	turns, err := s.src.turns(ctx, contextID, s.opts.maxTurns)
+	if err != nil {
+		return usage, fmt.Errorf("read turns: %w", err)
+	}
+	usage.Truncated = s.opts.maxTurns > 0 && uint32(len(turns)) >= s.opts.maxTurns
+	for _, t := range turns {
+		usage.Turns++
+		shared := s.turns[t.TurnID]
+		s.turns[t.TurnID] = true
+		if shared {
+			usage.SharedTurns++
+		} else {
+			s.report.Totals.Turns++
+		}
+
+		if ref := payloadRef(t); ref != nil {
+			usage.BlobBytes += int64(ref.Length)
+			if !shared {
+				s.reference(ref.Hash, kindBlob, int64(ref.Length), t.TypeID)
+			}
+			continue
+		}
+		usage.PayloadBytes += int64(len(t.Payload))
+		if !shared {
+			s.reference(t.PayloadHash, kindPayload, int64(len(t.Payload)), t.TypeID)
+		}
+	}
+
+	if s.opts.snapshots && len(turns) > 0 {
+		head := turns[len(turns)-1].TurnID
+		snap, err := s.scanSnapshot(ctx, head)
+		switch {
+		case errors.Is(err, errNoSnapshot):
+		case err != nil:
+			return usage, fmt.Errorf("walk snapshot of turn %d: %w", head, err)
+		default:
+			snap.Contexts = append(snap.Contexts, usage.ContextID)
+			usage.SnapshotBytes = snap.Bytes
+		}
+	}
+	return usage, nil
+}
+
+// payloadRef returns the blob reference of a turn stored as a blob.
+func payloadRef(t cxdb.TurnRecord) *cxdb.PayloadRef {
+	if t.PayloadRef != nil {
+		return t.PayloadRef
+	}
+	if t.Encoding != cxdb.EncodingBlobRef {
+		return nil
+	}
+	ref, err := cxdb.ParsePayloadRef(t.Payload)
+	if err != nil {
+		return nil
+	}
+	return ref
+}
+
+// scanSnapshot walks the snapshot of a turn once per root hash.
+func (s *scanner) scanSnapshot(ctx context.Context, turnID uint64) (*SnapshotUsage, error) {
+	root, err := s.src.listFs(ctx, turnID, "")
+	if err != nil {
+		return nil, err
+	}
+	if snap, ok := s.snapshots[root.FsRootHash]; ok {
+		return snap, nil
+	}
+	snap := &SnapshotUsage{RootHash: root.FsRootHash, TurnID: strconv.FormatUint(turnID, 10)}
+	dirs := []string{""}
+	for len(dirs) > 0 {
+		dir := dirs[len(dirs)-1]
+		dirs = dirs[:len(dirs)-1]
+		listing := root
+		if dir != "" {
+			if listing, err = s.src.listFs(ctx, turnID, dir); err != nil {
+				return nil, fmt.Errorf("%s: %w", dir, err)
+			}
+		}
+		for _, e := range listing.Entries {
+			p := path.Join(dir, e.Name)
+			switch e.Kind {
+			case "dir":
+				dirs = append(dirs, p)
+			case "file":
+				snap.Files++
+				snap.Bytes += e.Size
+				hash, err := parseHash(e.Hash)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", p, err)
+				}
+				s.reference(hash, kindFile, e.Size, p)
+			}
+		}
+	}
+	s.snapshots[root.FsRootHash] = snap
+	return snap, nil
+}
+
+// reference records one reference to content, adding it to the distinct
+// totals the first time.
+func (s *scanner) reference(hash [32]byte, kind string, size int64, example string) {
+	if d, ok := s.content[hash]; ok {
+		d.Refs++
+		return
+	}
+	s.content[hash] = &Duplicate{Hash: hex.EncodeToString(hash[:]), Kind: kind, Size: size, Refs: 1, Example: example}
+	switch kind {
+	case kindPayload:
+		s.report.Totals.PayloadBytes += size
+	case kindBlob:
+		s.report.Totals.BlobBytes += size
+	case kindFile:
+		s.report.Totals.SnapshotBytes += size
+	}
+}
+
+func parseHash(s string) ([32]byte, error) {
+	var h [32]byte
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != len(h) {
+		return h, fmt.Errorf("invalid hash %q", s)
+	}
+	copy(h[:], b)
+	return h, nil
+}
+
+func (s *scanner) finish() *Report {
+	r := s.report
+	r.Totals.Contexts = len(r.Contexts)
+	r.Totals.Snapshots = len(s.snapshots)
+	for _, c := range r.Contexts {
+		r.Totals.ReferencedBytes += c.Bytes()
+		t, ok := s.tags[c.ClientTag]
+		if !ok {
+			t = &TagUsage{ClientTag: c.ClientTag}
+			s.tags[c.ClientTag] = t
+		}
+		t.Contexts++
+		t.add(c.Usage)
+	}
+	sort.SliceStable(r.Contexts, func(i, j int) bool { return r.Contexts[i].Bytes() > r.Contexts[j].Bytes() })
+
+	for _, t := range s.tags {
+		r.ClientTags = append(r.ClientTags, *t)
+	}
+	sort.Slice(r.ClientTags, func(i, j int) bool {
+		a, b := r.ClientTags[i], r.ClientTags[j]
+		if a.Bytes() != b.Bytes() {
+			return a.Bytes() > b.Bytes()
+		}
+		return a.ClientTag < b.ClientTag
+	})
+
+	for _, snap := range s.snapshots {
+		r.LargestSnapshots = append(r.LargestSnapshots, *snap)
+	}
+	sort.Slice(r.LargestSnapshots, func(i, j int) bool {
+		a, b := r.LargestSnapshots[i], r.LargestSnapshots[j]
+		if a.Bytes != b.Bytes {
+			return a.Bytes > b.Bytes
+		}
+		return a.RootHash < b.RootHash
+	})
+	r.LargestSnapshots = truncate(r.LargestSnapshots, s.opts.top)
+
+	for _, d := range s.content {
+		if d.Refs > 1 {
+			r.Duplicates = append(r.Duplicates, *d)
+		}
+	}
+	sort.Slice(r.Duplicates, func(i, j int) bool {
+		a, b := r.Duplicates[i], r.Duplicates[j]
+		if a.repeatedBytes() != b.repeatedBytes() {
+			return a.repeatedBytes() > b.repeatedBytes()
+		}
+		return a.Hash < b.Hash
+	})
+	r.Duplicates = truncate(r.Duplicates, s.opts.top)
+	return r
+}
+
+func truncate[T any](s []T, n int) []T {
+	if n > 0 && len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+func writeJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func writeText(w io.Writer, r *Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	t := r.Totals
+	fmt.Fprintf(tw, "%d contexts, %d distinct turns, %d snapshots\n", t.Contexts, t.Turns, t.Snapshots)
+	fmt.Fprintf(tw, "distinct: payloads %s, blobs %s, snapshot files %s\n",
+		formatBytes(t.PayloadBytes), formatBytes(t.BlobBytes), formatBytes(t.SnapshotBytes))
+	fmt.Fprintf(tw, "referenced by contexts: %s\n", formatBytes(t.ReferencedBytes))
+
+	fmt.Fprintf(tw, "\nCLIENT TAG\tCONTEXTS\tTURNS\tPAYLOADS\tBLOBS\tSNAPSHOTS\t\n")
+	for _, u := range r.ClientTags {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\t\n", u.ClientTag, u.Contexts, u.Turns,
+			formatBytes(u.PayloadBytes), formatBytes(u.BlobBytes), formatBytes(u.SnapshotBytes))
+	}
+
+	fmt.Fprintf(tw, "\nCONTEXT\tCLIENT TAG\tTURNS\tSHARED\tPAYLOADS\tBLOBS\tSNAPSHOT\t\n")
+	for _, u := range r.Contexts {
+		id := u.ContextID
+		if u.Truncated {
+			id += "+"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\t%s\t%s\t\n", id, u.ClientTag, u.Turns, u.SharedTurns,
+			formatBytes(u.PayloadBytes), formatBytes(u.BlobBytes), formatBytes(u.SnapshotBytes))
+	}
+
+	if len(r.LargestSnapshots) > 0 {
+		fmt.Fprintf(tw, "\nSNAPSHOT\tTURN\tFILES\tBYTES\tCONTEXTS\t\n")
+		for _, snap := range r.LargestSnapshots {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%d\t\n", shortHash(snap.RootHash), snap.TurnID, snap.Files,
+				formatBytes(snap.Bytes), len(snap.Contexts))
+		}
+	}
+
+	if len(r.Duplicates) > 0 {
+		fmt.Fprintf(tw, "\nDUPLICATE\tKIND\tSIZE\tREFS\tEXAMPLE\t\n")
+		for _, d := range r.Duplicates {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t\n", shortHash(d.Hash), d.Kind, formatBytes(d.Size), d.Refs, d.Example)
+		}
+	}
+	return tw.Flush()
+}
+
+func shortHash(h string) string {
+	if len(h) > 12 {
+		return h[:12]
+	}
+	return h
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,155 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	cxdb "github.com/strongdm/ai-cxdb/clients/go"
+	"github.com/strongdm/ai-cxdb/clients/go/httpclient"
+)
+
+type fakeSource struct {
+	list    []httpclient.ContextSummary
+	history map[uint64][]cxdb.TurnRecord
+	fs      map[uint64]map[string]*httpclient.FsListing // by head turn, then path
+}
+
+func (f *fakeSource) contexts(context.Context) ([]httpclient.ContextSummary, error) {
+	return f.list, nil
+}
+
+func (f *fakeSource) turns(_ context.Context, contextID uint64, _ uint32) ([]cxdb.TurnRecord, error) {
+	turns, ok := f.history[contextID]
+	if !ok {
+		return nil, &cxdb.ServerError{Code: 404, Detail: "context not found"}
+	}
+	return turns, nil
+}
+
+func (f *fakeSource) listFs(_ context.Context, turnID uint64, path string) (*httpclient.FsListing, error) {
+	listing, ok := f.fs[turnID][path]
+	if !ok {
+		return nil, errNoSnapshot
+	}
+	return listing, nil
+}
+
+func hashOf(b byte) [32]byte {
+	var h [32]byte
+	h[0] = b
+	return h
+}
+
+func hexOf(b byte) string {
+	h := hashOf(b)
+	return hex.EncodeToString(h[:])
+}
+
+func TestScan(t *testing.T) {
+	base := []cxdb.TurnRecord{
+		{TurnID: 1, TypeID: "cxdb.ConversationItem", PayloadHash: hashOf(1), Payload: make([]byte, 100)},
+		{TurnID: 2, TypeID: "cxdb.ConversationItem", PayloadHash: hashOf(2), Payload: make([]byte, 50)},
+	}
+	blob := cxdb.PayloadRef{Hash: hashOf(3), Encoding: 1, Length: 5000}
+	fork := append(base[:2:2],
+		cxdb.TurnRecord{TurnID: 3, TypeID: "cxdb.ConversationItem", Encoding: cxdb.EncodingBlobRef, PayloadHash: hashOf(4), Payload: blob.Encode()},
+		cxdb.TurnRecord{TurnID: 4, TypeID: "cxdb.ConversationItem", PayloadHash: hashOf(1), Payload: make([]byte, 100)},
+	)
+	snapshot := map[string]*httpclient.FsListing{
+		"": {FsRootHash: "root", Entries: []httpclient.FsEntry{
+			{Name: "a.bin", Kind: "file", Size: 700, Hash: hexOf(9)},
+			{Name: "src", Kind: "dir", Hash: hexOf(8)},
+		}},
+		"src": {Entries: []httpclient.FsEntry{
+			{Name: "copy.bin", Kind: "file", Size: 700, Hash: hexOf(9)},
+			{Name: "link", Kind: "symlink", Hash: hexOf(7)},
+		}},
+	}
+	src := &fakeSource{
+		list: []httpclient.ContextSummary{
+			{ContextID: "1", ClientTag: "agent"},
+			{ContextID: "2", ClientTag: "agent"},
+			{ContextID: "3"},
+			{ContextID: "4", ClientTag: "agent"},
+		},
+		history: map[uint64][]cxdb.TurnRecord{1: base, 2: fork, 3: base[:1]},
+		fs:      map[uint64]map[string]*httpclient.FsListing{2: snapshot, 4: snapshot},
+	}
+
+	r, err := scan(context.Background(), src, scanOptions{maxTurns: 100, snapshots: true, top: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Totals{
+		Contexts:        3,
+		Snapshots:       1,
+		Usage:           Usage{Turns: 4, PayloadBytes: 150, BlobBytes: 5000, SnapshotBytes: 700},
+		ReferencedBytes: (150 + 1400) + (250 + 5000 + 1400) + 100,
+	}
+	if r.Totals != want {
+		t.Errorf("totals = %+v, want %+v", r.Totals, want)
+	}
+	if len(r.Errors) != 1 || r.Errors["4"] == "" {
+		t.Errorf("errors = %v, want one for context 4", r.Errors)
+	}
+
+	if top := r.Contexts[0]; top.ContextID != "2" || top.SharedTurns != 2 || top.SnapshotBytes != 1400 {
+		t.Errorf("largest context = %+v", top)
+	}
+	if len(r.ClientTags) != 2 || r.ClientTags[0].ClientTag != "agent" || r.ClientTags[0].Contexts != 2 ||
+		r.ClientTags[1].ClientTag != "(none)" {
+		t.Errorf("client tags = %+v", r.ClientTags)
+	}
+	if len(r.LargestSnapshots) != 1 || r.LargestSnapshots[0].Files != 2 || len(r.LargestSnapshots[0].Contexts) != 2 {
+		t.Errorf("snapshots = %+v", r.LargestSnapshots)
+	}
+
+	// The file is listed twice in the snapshot; payload 1 is appended again
+	// as turn 4. Turns shared through the fork are not duplicates.
+	if len(r.Duplicates) != 2 {
+		t.Fatalf("duplicates = %+v", r.Duplicates)
+	}
+	if d := r.Duplicates[0]; d.Kind != kindFile || d.Refs != 2 || d.Example != "a.bin" {
+		t.Errorf("first duplicate = %+v", d)
+	}
+	if d := r.Duplicates[1]; d.Kind != kindPayload || d.Refs != 2 || d.Size != 100 {
+		t.Errorf("second duplicate = %+v", d)
+	}
+
+	var text bytes.Buffer
+	if err := writeText(&text, r); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text.String(), "3 contexts, 4 distinct turns, 1 snapshots") {
+		t.Errorf("text report:\n%s", text.String())
+	}
+}
+
+func TestScanNoSnapshot(t *testing.T) {
+	src := &fakeSource{
+		list:    []httpclient.ContextSummary{{ContextID: "1"}},
+		history: map[uint64][]cxdb.TurnRecord{1: {{TurnID: 1, PayloadHash: hashOf(1), Payload: []byte{0xc0}}}},
+	}
+	r, err := scan(context.Background(), src, scanOptions{maxTurns: 1, snapshots: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Errors) != 0 || r.Totals.Snapshots != 0 || !r.Contexts[0].Truncated {
+		t.Errorf("report = %+v", r)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	for n, want := range map[int64]string{0: "0 B", 1023: "1023 B", 1536: "1.5 KiB", 5 << 30: "5.0 GiB"} {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+// Command cxdb-init scaffolds a minimal Go service that records its
+// conversations in CXDB: a ReconnectingClient, process provenance, canonical
+// ConversationItem builders, filesystem snapshots after each turn, and
+// graceful shutdown. New integrations start from it instead of from the
+// integration tests:
+//
+//	cxdb-init -module github.com/acme/support-agent
+//	cd support-agent && go mod tidy && go run .
+package main
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+// Config is the data the templates are rendered with.
+type Config struct {
+	Module      string // module path of the new service
+	Name        string // service name, used as the client tag
+	CXDBVersion string // required version of the Go client
+	Replace     string // local path of the Go client for development; optional
+}
+
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+func main() {
+	module := flag.String("module", "", "module path of the new service (required)")
+	name := flag.String("name", "", "service name and client tag (default: last element of -module)")
+	dir := flag.String("dir", "", "output directory (default: the service name)")
+	version := flag.String("cxdb-version", "v0.0.8", "version of github.com/strongdm/ai-cxdb/clients/go to require")
+	replace := flag.String("replace", "", "local path of clients/go, for developing against a checkout")
+	force := flag.Bool("force", false, "overwrite existing files")
+	flag.Parse()
+
+	cfg := Config{Module: *module, Name: *name, CXDBVersion: *version, Replace: *replace}
+	if cfg.Name == "" {
+		cfg.Name = path.Base(cfg.Module)
+	}
+	if *dir == "" {
+		*dir = cfg.Name
+	}
+	files, err := render(cfg)
+	if err == nil {
+		err = write(*dir, files, *force)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cxdb-init: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Created %s. Next:\n\n\tcd %s && go mod tidy && go run .\n", *dir, *dir)
+}
+
+// render returns the scaffold's files by name.
+func render(cfg Config) (map[string][]byte, error) {
+	if cfg.Module == "" {
+		return nil, errors.New("-module is required")
+	}
+	if !namePattern.MatchString(cfg.Name) {
+		return nil, fmt.Errorf("service name %q: use lowercase letters, digits, and dashes", cfg.Name)
+	}
+	tmpl, err := template.ParseFS(templates, "templates/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{}
+	for _, t := range tmpl.Templates() {
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, cfg); err != nil {
+			return nil, fmt.Errorf("render %s: %w", t.Name(), err)
+		}
+		name := strings.TrimSuffix(t.Name(), ".tmpl")
+		out := buf.Bytes()
+		if strings.HasSuffix(name, ".go") {
+			if out, err = format.Source(out); err != nil {
+				return nil, fmt.Errorf("format %s: %w", name, err)
+			}
+		}
+		files[name] = out
+	}
+	return files, nil
+}
+
+// write creates the files under dir. Existing files are an error unless
+// force is set, so a typo cannot clobber a project.
+func write(dir string, files map[string][]byte, force bool) error {
+	if !force {
+		for name := range files {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return fmt.Errorf("%s exists; use -force to overwrite", filepath.Join(dir, name))
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
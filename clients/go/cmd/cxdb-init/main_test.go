@@ -0,0 +1,70 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	files, err := render(Config{Module: "example.com/acme/support-agent", Name: "support-agent", CXDBVersion: "v0.0.8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"go.mod", "main.go", "conversation.go", "README.md"} {
+		if len(files[name]) == 0 {
+			t.Errorf("%s missing", name)
+		}
+	}
+	for name, data := range files {
+		if strings.HasSuffix(name, ".go") {
+			if _, err := parser.ParseFile(token.NewFileSet(), name, data, 0); err != nil {
+				t.Errorf("%s: %v", name, err)
+			}
+		}
+	}
+	mod := string(files["go.mod"])
+	if !strings.HasPrefix(mod, "module example.com/acme/support-agent\n") || strings.Contains(mod, "replace") {
+		t.Errorf("go.mod:\n%s", mod)
+	}
+	if !strings.Contains(string(files["main.go"]), `serviceName    = "support-agent"`) {
+		t.Errorf("main.go does not name the service:\n%s", files["main.go"])
+	}
+
+	files, err = render(Config{Module: "example.com/x", Name: "x", CXDBVersion: "v0.0.8", Replace: "../cxdb/clients/go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(files["go.mod"]), "replace github.com/strongdm/ai-cxdb/clients/go => ../cxdb/clients/go") {
+		t.Errorf("go.mod without replace:\n%s", files["go.mod"])
+	}
+
+	for _, cfg := range []Config{{Name: "x"}, {Module: "example.com/Bad_Name", Name: "Bad_Name"}} {
+		if _, err := render(cfg); err == nil {
+			t.Errorf("render(%+v) succeeded", cfg)
+		}
+	}
+}
+
+func TestWriteRefusesOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{"main.go": []byte("package main\n")}
+	if err := write(dir, files, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := write(dir, map[string][]byte{"main.go": []byte("changed")}, false); err == nil {
+		t.Fatal("overwrote main.go without -force")
+	}
+	if err := write(dir, map[string][]byte{"main.go": []byte("changed")}, true); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "main.go")); string(data) != "changed" {
+		t.Errorf("main.go = %q", data)
+	}
+}
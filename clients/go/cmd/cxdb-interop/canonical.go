@@ -0,0 +1,130 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/zeebo/blake3"
+
+	cxdb "github.com/strongdm/ai-cxdb/clients/go"
+	"github.com/strongdm/ai-cxdb/clients/go/types"
+)
+
+// messageTurnTypeID is the type written by the interop writers of every
+// client: a map of 1 => role, 2 => text.
+const messageTurnTypeID = "com.yourorg.ai.MessageTurn"
+
+// tagStats describes the top-level keys of a payload map.
+type tagStats struct {
+	numeric     int
+	digitString int
+}
+
+// scanTags reads the top-level map of a msgpack payload and classifies its
+// keys. Per CLIENT_SPEC.md §3.1 tags must be positive integers; digit-string
+// tags are tolerated for interop and anything else is an error.
+func scanTags(payload []byte) (tagStats, error) {
+	var stats tagStats
+	dec := msgpack.NewDecoder(bytes.NewReader(payload))
+	n, err := dec.DecodeMapLen()
+	if err != nil {
+		return stats, fmt.Errorf("payload is not a map: %w", err)
+	}
+	seen := make(map[uint64]bool, n)
+	for i := 0; i < n; i++ {
+		key, err := dec.DecodeInterface()
+		if err != nil {
+			return stats, fmt.Errorf("key %d: %w", i, err)
+		}
+		var tag uint64
+		switch v := reflect.ValueOf(key); v.Kind() {
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if v.Int() < 0 {
+				return stats, fmt.Errorf("negative tag %d", v.Int())
+			}
+			tag = uint64(v.Int())
+			stats.numeric++
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			tag = v.Uint()
+			stats.numeric++
+		case reflect.String:
+			t, err := strconv.ParseUint(v.String(), 10, 64)
+			if err != nil {
+				return stats, fmt.Errorf("non-numeric tag %q", v.String())
+			}
+			tag = t
+			stats.digitString++
+		default:
+			return stats, fmt.Errorf("tag of type %T", key)
+		}
+		if seen[tag] {
+			return stats, fmt.Errorf("duplicate tag %d", tag)
+		}
+		seen[tag] = true
+		if err := dec.Skip(); err != nil {
+			return stats, fmt.Errorf("value for tag %d: %w", tag, err)
+		}
+	}
+	return stats, nil
+}
+
+// verifyTurn checks that a turn read back from the server is canonical and
+// decodes as its declared type. It returns a short description of the
+// decoded value.
+func verifyTurn(turn cxdb.TurnRecord) (string, error) {
+	if turn.Payload == nil {
+		return "", errors.New("payload not returned")
+	}
+	// Resolved blob-ref payloads carry the hash of the stub, not the body.
+	if turn.PayloadRef == nil {
+		if sum := blake3.Sum256(turn.Payload); sum != turn.PayloadHash {
+			return "", fmt.Errorf("payload hash %x does not match content %x", turn.PayloadHash[:8], sum[:8])
+		}
+	}
+	stats, err := scanTags(turn.Payload)
+	if err != nil {
+		return "", err
+	}
+
+	var detail string
+	switch turn.TypeID {
+	case messageTurnTypeID:
+		fields, err := cxdb.DecodeMsgpack(turn.Payload)
+		if err != nil {
+			return "", err
+		}
+		role, ok := fields[1].(string)
+		if !ok {
+			return "", errors.New("tag 1 (role) missing or not a string")
+		}
+		text, ok := fields[2].(string)
+		if !ok {
+			return "", errors.New("tag 2 (text) missing or not a string")
+		}
+		detail = fmt.Sprintf("role=%s text=%s", role, text)
+	case types.TypeIDConversationItem, types.TypeIDConversationItemLegacy:
+		// Strict: unknown tags mean the writer is on a newer schema than
+		// this client, which cross-client CI should catch.
+		var item types.ConversationItem
+		if err := cxdb.DecodeMsgpackStrict(turn.Payload, &item); err != nil {
+			return "", err
+		}
+		detail = "item_type=" + string(item.ItemType)
+	default:
+		if _, err := cxdb.DecodeMsgpack(turn.Payload); err != nil {
+			return "", err
+		}
+		detail = "type=" + turn.TypeID
+	}
+	if stats.digitString > 0 {
+		detail += fmt.Sprintf(" (%d digit-string tags)", stats.digitString)
+	}
+	return detail, nil
+}
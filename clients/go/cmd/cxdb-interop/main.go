@@ -0,0 +1,115 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+// Command cxdb-interop verifies that the Go client and a running server
+// agree on the wire protocol, and that items written by other clients
+// decode canonically.
+//
+//	cxdb-interop run   -addr 127.0.0.1:9009              # every protocol message
+//	cxdb-interop write -addr 127.0.0.1:9009              # seed a context for another client
+//	cxdb-interop read  -addr 127.0.0.1:9009 -context 42  # verify turns another client wrote
+//
+// Each subcommand emits a report; -format json produces the machine-readable
+// form used by cross-client CI. The exit status is 1 if any check failed.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	cxdb "github.com/strongdm/ai-cxdb/clients/go"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cmd, args := os.Args[1], os.Args[2:]
+
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:9009", "server address")
+	format := fs.String("format", "text", "report format: text or json")
+	out := fs.String("out", "", "write the report to this file instead of stdout")
+	timeout := fs.Duration("timeout", 30*time.Second, "overall deadline")
+	contextID := fs.Uint64("context", 0, "context id to verify (read)")
+	limit := fs.Int("limit", 10, "number of most recent turns to verify (read)")
+	role := fs.String("role", "user", "message role to write or expect (write, read)")
+	text := fs.String("text", "hello", "message text to write or expect (write, read)")
+	expect := fs.Bool("expect", false, "require the newest message turn to match -role and -text (read)")
+	_ = fs.Parse(args)
+
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "unknown format %q\n", *format)
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	report := newReport(cmd, *addr)
+	var client *cxdb.Client
+	connected := report.step("hello", func() (string, error) {
+		var err error
+		client, err = cxdb.Dial(*addr, cxdb.WithClientTag("cxdb-interop"))
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("session_id=%d", client.SessionID()), nil
+	})
+	if connected {
+		defer func() { _ = client.Close() }()
+	}
+
+	switch cmd {
+	case "run":
+		runSuite(ctx, report, client)
+	case "write":
+		writeSuite(ctx, report, client, *role, *text)
+	case "read":
+		if *contextID == 0 {
+			fmt.Fprintln(os.Stderr, "read: -context is required")
+			os.Exit(2)
+		}
+		var want *message
+		if *expect {
+			want = &message{role: *role, text: *text}
+		}
+		readSuite(ctx, report, client, *contextID, *limit, want)
+	default:
+		usage()
+	}
+	report.finish()
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "create report: %v\n", err)
+			os.Exit(1)
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+	var err error
+	if *format == "json" {
+		err = report.writeJSON(w)
+	} else {
+		err = report.writeText(w)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "write report: %v\n", err)
+		os.Exit(1)
+	}
+	if !report.Passed {
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: cxdb-interop run|write|read [-addr host:port] [-format text|json] [-out file] [flags]")
+	os.Exit(2)
+}
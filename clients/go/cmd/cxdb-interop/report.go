@@ -0,0 +1,131 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Check statuses.
+const (
+	statusPass = "pass"
+	statusFail = "fail"
+	statusSkip = "skip"
+)
+
+// errSkip marks a check that could not run, usually because an earlier
+// check it depends on failed.
+var errSkip = errors.New("skipped")
+
+// Report is the machine-readable result of one cxdb-interop invocation.
+// Cross-client CI compares reports from every client against the same
+// server, so field names are part of the contract.
+type Report struct {
+	Suite      string    `json:"suite"`
+	Client     string    `json:"client"`
+	Addr       string    `json:"addr"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMS int64     `json:"duration_ms"`
+	Passed     bool      `json:"passed"`
+	Summary    Summary   `json:"summary"`
+	Checks     []Check   `json:"checks"`
+}
+
+// Summary counts checks by status.
+type Summary struct {
+	Pass int `json:"pass"`
+	Fail int `json:"fail"`
+	Skip int `json:"skip"`
+}
+
+// Check is the outcome of a single protocol or decoding check.
+type Check struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	DurationMS int64  `json:"duration_ms"`
+	Detail     string `json:"detail,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+func newReport(suite, addr string) *Report {
+	return &Report{
+		Suite:     suite,
+		Client:    "go",
+		Addr:      addr,
+		StartedAt: time.Now().UTC(),
+		Passed:    true,
+		Checks:    []Check{},
+	}
+}
+
+// step runs fn and records its outcome. It returns true if the check passed.
+func (r *Report) step(name string, fn func() (string, error)) bool {
+	start := time.Now()
+	detail, err := fn()
+	c := Check{
+		Name:       name,
+		Status:     statusPass,
+		DurationMS: time.Since(start).Milliseconds(),
+		Detail:     detail,
+	}
+	switch {
+	case errors.Is(err, errSkip):
+		c.Status = statusSkip
+		c.Error = err.Error()
+		r.Summary.Skip++
+	case err != nil:
+		c.Status = statusFail
+		c.Error = err.Error()
+		r.Summary.Fail++
+		r.Passed = false
+	default:
+		r.Summary.Pass++
+	}
+	r.Checks = append(r.Checks, c)
+	return c.Status == statusPass
+}
+
+// skipUnless returns errSkip naming the missing prerequisite if ok is false.
+func skipUnless(ok bool, prerequisite string) error {
+	if ok {
+		return nil
+	}
+	return fmt.Errorf("%w: requires %s", errSkip, prerequisite)
+}
+
+func (r *Report) finish() {
+	r.DurationMS = time.Since(r.StartedAt).Milliseconds()
+}
+
+func (r *Report) writeJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func (r *Report) writeText(w io.Writer) error {
+	for _, c := range r.Checks {
+		line := fmt.Sprintf("%-4s %-28s %5dms", c.Status, c.Name, c.DurationMS)
+		if c.Detail != "" {
+			line += "  " + c.Detail
+		}
+		if c.Error != "" {
+			line += "  error: " + c.Error
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	result := "PASS"
+	if !r.Passed {
+		result = "FAIL"
+	}
+	_, err := fmt.Fprintf(w, "%s %s: %d passed, %d failed, %d skipped (%dms)\n",
+		result, r.Suite, r.Summary.Pass, r.Summary.Fail, r.Summary.Skip, r.DurationMS)
+	return err
+}
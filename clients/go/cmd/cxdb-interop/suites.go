@@ -0,0 +1,431 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/zeebo/blake3"
+
+	cxdb "github.com/strongdm/ai-cxdb/clients/go"
+	"github.com/strongdm/ai-cxdb/clients/go/fstree"
+	"github.com/strongdm/ai-cxdb/clients/go/types"
+)
+
+type message struct {
+	role string
+	text string
+}
+
+func (m message) encode() ([]byte, error) {
+	return cxdb.EncodeMsgpack(map[uint64]any{1: m.role, 2: m.text})
+}
+
+func appendMessage(ctx context.Context, client *cxdb.Client, contextID, parentTurnID uint64, m message) (*cxdb.AppendResult, error) {
+	payload, err := m.encode()
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.AppendTurn(ctx, &cxdb.AppendRequest{
+		ContextID:    contextID,
+		ParentTurnID: parentTurnID,
+		TypeID:       messageTurnTypeID,
+		TypeVersion:  1,
+		Payload:      payload,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.PayloadHash != blake3.Sum256(payload) {
+		return nil, errors.New("append returned a payload hash that does not match the payload")
+	}
+	return res, nil
+}
+
+func appendItem(ctx context.Context, client *cxdb.Client, contextID uint64, item *types.ConversationItem) (*cxdb.AppendResult, error) {
+	payload, err := cxdb.EncodeMsgpack(item)
+	if err != nil {
+		return nil, err
+	}
+	return client.AppendTurn(ctx, &cxdb.AppendRequest{
+		ContextID:   contextID,
+		TypeID:      types.TypeIDConversationItem,
+		TypeVersion: types.TypeVersionConversationItem,
+		Payload:     payload,
+	})
+}
+
+// runSuite exercises every protocol message the Go client sends.
+func runSuite(ctx context.Context, r *Report, client *cxdb.Client) {
+	connected := client != nil
+
+	var head *cxdb.ContextHead
+	created := r.step("create_context", func() (string, error) {
+		if err := skipUnless(connected, "hello"); err != nil {
+			return "", err
+		}
+		var err error
+		head, err = client.CreateContext(ctx, 0)
+		if err != nil {
+			return "", err
+		}
+		if head.HeadTurnID != 0 || head.HeadDepth != 0 {
+			return "", fmt.Errorf("new context has head turn %d at depth %d", head.HeadTurnID, head.HeadDepth)
+		}
+		return fmt.Sprintf("context_id=%d", head.ContextID), nil
+	})
+
+	msgs := []message{{"user", "ping"}, {"assistant", "pong"}}
+	var appended []*cxdb.AppendResult
+	didAppend := r.step("append_turn", func() (string, error) {
+		if err := skipUnless(created, "create_context"); err != nil {
+			return "", err
+		}
+		for i, m := range msgs {
+			res, err := appendMessage(ctx, client, head.ContextID, 0, m)
+			if err != nil {
+				return "", err
+			}
+			// The server puts the first turn of a context at depth 0.
+			if res.ContextID != head.ContextID || res.Depth != uint32(i) {
+				return "", fmt.Errorf("append %d: got context %d depth %d", i, res.ContextID, res.Depth)
+			}
+			appended = append(appended, res)
+		}
+		return fmt.Sprintf("turn_ids=%d,%d", appended[0].TurnID, appended[1].TurnID), nil
+	})
+
+	r.step("get_head", func() (string, error) {
+		if err := skipUnless(didAppend, "append_turn"); err != nil {
+			return "", err
+		}
+		h, err := client.GetHead(ctx, head.ContextID)
+		if err != nil {
+			return "", err
+		}
+		last := appended[len(appended)-1]
+		if h.HeadTurnID != last.TurnID || h.HeadDepth != last.Depth {
+			return "", fmt.Errorf("head is turn %d depth %d, want turn %d depth %d", h.HeadTurnID, h.HeadDepth, last.TurnID, last.Depth)
+		}
+		return fmt.Sprintf("head_turn_id=%d", h.HeadTurnID), nil
+	})
+
+	r.step("get_last", func() (string, error) {
+		if err := skipUnless(didAppend, "append_turn"); err != nil {
+			return "", err
+		}
+		turns, err := client.GetLast(ctx, head.ContextID, cxdb.GetLastOptions{Limit: 10, IncludePayload: true})
+		if err != nil {
+			return "", err
+		}
+		if len(turns) != len(msgs) {
+			return "", fmt.Errorf("got %d turns, want %d", len(turns), len(msgs))
+		}
+		for i, turn := range turns {
+			if turn.TurnID != appended[i].TurnID {
+				return "", fmt.Errorf("turn %d has id %d, want %d (oldest first)", i, turn.TurnID, appended[i].TurnID)
+			}
+			detail, err := verifyTurn(turn)
+			if err != nil {
+				return "", fmt.Errorf("turn %d: %w", turn.TurnID, err)
+			}
+			if want := fmt.Sprintf("role=%s text=%s", msgs[i].role, msgs[i].text); detail != want {
+				return "", fmt.Errorf("turn %d decoded as %q, want %q", turn.TurnID, detail, want)
+			}
+		}
+		return fmt.Sprintf("%d turns", len(turns)), nil
+	})
+
+	var fork *cxdb.ContextHead
+	forked := r.step("fork_context", func() (string, error) {
+		if err := skipUnless(didAppend, "append_turn"); err != nil {
+			return "", err
+		}
+		base := appended[0]
+		var err error
+		fork, err = client.ForkContext(ctx, base.TurnID)
+		if err != nil {
+			return "", err
+		}
+		if fork.ContextID == head.ContextID {
+			return "", errors.New("fork reused the source context id")
+		}
+		if fork.HeadTurnID != base.TurnID || fork.HeadDepth != base.Depth {
+			return "", fmt.Errorf("fork head is turn %d depth %d, want turn %d depth %d", fork.HeadTurnID, fork.HeadDepth, base.TurnID, base.Depth)
+		}
+		return fmt.Sprintf("context_id=%d", fork.ContextID), nil
+	})
+
+	r.step("fork_isolation", func() (string, error) {
+		if err := skipUnless(forked, "fork_context"); err != nil {
+			return "", err
+		}
+		res, err := appendMessage(ctx, client, fork.ContextID, 0, message{"assistant", "branch"})
+		if err != nil {
+			return "", err
+		}
+		turns, err := client.GetLast(ctx, fork.ContextID, cxdb.GetLastOptions{Limit: 10})
+		if err != nil {
+			return "", err
+		}
+		if len(turns) != 2 || turns[0].TurnID != appended[0].TurnID || turns[1].TurnID != res.TurnID {
+			return "", fmt.Errorf("fork history has %d turns, want shared base then branch turn", len(turns))
+		}
+		h, err := client.GetHead(ctx, head.ContextID)
+		if err != nil {
+			return "", err
+		}
+		if h.HeadTurnID != appended[len(appended)-1].TurnID {
+			return "", fmt.Errorf("appending to the fork moved the source head to turn %d", h.HeadTurnID)
+		}
+		return fmt.Sprintf("branch_turn_id=%d", res.TurnID), nil
+	})
+
+	blob := make([]byte, 4096)
+	_, _ = rand.Read(blob)
+	var blobHash [32]byte
+	stored := r.step("put_blob", func() (string, error) {
+		if err := skipUnless(connected, "hello"); err != nil {
+			return "", err
+		}
+		res, err := client.PutBlob(ctx, &cxdb.PutBlobRequest{Data: blob})
+		if err != nil {
+			return "", err
+		}
+		if res.Hash != blake3.Sum256(blob) {
+			return "", errors.New("server hash does not match BLAKE3 of the content")
+		}
+		if !res.WasNew {
+			return "", errors.New("random blob reported as already present")
+		}
+		blobHash = res.Hash
+		return fmt.Sprintf("hash=%x", blobHash[:8]), nil
+	})
+
+	r.step("put_blob_dedup", func() (string, error) {
+		if err := skipUnless(stored, "put_blob"); err != nil {
+			return "", err
+		}
+		res, err := client.PutBlob(ctx, &cxdb.PutBlobRequest{Data: blob})
+		if err != nil {
+			return "", err
+		}
+		if res.WasNew {
+			return "", errors.New("second put of the same blob reported as new")
+		}
+		return "", nil
+	})
+
+	r.step("get_blob", func() (string, error) {
+		if err := skipUnless(stored, "put_blob"); err != nil {
+			return "", err
+		}
+		data, err := client.GetBlob(ctx, blobHash)
+		if err != nil {
+			return "", err
+		}
+		if !bytes.Equal(data, blob) {
+			return "", fmt.Errorf("got %d bytes that differ from the %d stored", len(data), len(blob))
+		}
+		return fmt.Sprintf("%d bytes", len(data)), nil
+	})
+
+	var snap *fstree.Snapshot
+	uploaded := r.step("fs_upload", func() (string, error) {
+		if err := skipUnless(connected, "hello"); err != nil {
+			return "", err
+		}
+		dir, err := os.MkdirTemp("", "cxdb-interop-fs-")
+		if err != nil {
+			return "", err
+		}
+		defer func() { _ = os.RemoveAll(dir) }()
+		if err := writeFixtureTree(dir); err != nil {
+			return "", err
+		}
+		var res *fstree.UploadResult
+		snap, res, err = fstree.CaptureAndUpload(ctx, client, dir)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("root=%x trees=%d files=%d", snap.RootHash[:8], res.TreesUploaded+res.TreesSkipped, res.FilesUploaded+res.FilesSkipped), nil
+	})
+
+	r.step("fs_attach", func() (string, error) {
+		if err := skipUnless(uploaded && didAppend, "fs_upload and append_turn"); err != nil {
+			return "", err
+		}
+		turnID := appended[len(appended)-1].TurnID
+		res, err := client.AttachFs(ctx, &cxdb.AttachFsRequest{TurnID: turnID, FsRootHash: snap.RootHash, TreeFormat: snap.TreeFormat})
+		if err != nil {
+			return "", err
+		}
+		if res.TurnID != turnID || res.FsRootHash != snap.RootHash {
+			return "", fmt.Errorf("attach echoed turn %d root %x", res.TurnID, res.FsRootHash[:8])
+		}
+		return fmt.Sprintf("turn_id=%d", turnID), nil
+	})
+
+	r.step("append_with_fs", func() (string, error) {
+		if err := skipUnless(uploaded && created, "fs_upload and create_context"); err != nil {
+			return "", err
+		}
+		payload, err := message{"user", "with fs"}.encode()
+		if err != nil {
+			return "", err
+		}
+		res, err := client.AppendTurnWithFs(ctx, &cxdb.AppendRequest{
+			ContextID:   head.ContextID,
+			TypeID:      messageTurnTypeID,
+			TypeVersion: 1,
+			Payload:     payload,
+		}, &snap.RootHash)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("turn_id=%d", res.TurnID), nil
+	})
+
+	r.step("canonical_item", func() (string, error) {
+		if err := skipUnless(created, "create_context"); err != nil {
+			return "", err
+		}
+		item := types.NewUserInput("interop canonical item", "README.md")
+		res, err := appendItem(ctx, client, head.ContextID, item)
+		if err != nil {
+			return "", err
+		}
+		turns, err := client.GetLast(ctx, head.ContextID, cxdb.GetLastOptions{Limit: 1, IncludePayload: true})
+		if err != nil {
+			return "", err
+		}
+		if len(turns) != 1 || turns[0].TurnID != res.TurnID {
+			return "", errors.New("conversation item is not the context head")
+		}
+		detail, err := verifyTurn(turns[0])
+		if err != nil {
+			return "", err
+		}
+		var got types.ConversationItem
+		if err := cxdb.DecodeMsgpackInto(turns[0].Payload, &got); err != nil {
+			return "", err
+		}
+		if got.UserInput == nil || got.UserInput.Text != item.UserInput.Text {
+			return "", errors.New("user input text did not round-trip")
+		}
+		return detail, nil
+	})
+}
+
+// writeSuite seeds a context for another client's reader: a message turn
+// followed by a canonical conversation item.
+func writeSuite(ctx context.Context, r *Report, client *cxdb.Client, role, text string) {
+	var head *cxdb.ContextHead
+	created := r.step("create_context", func() (string, error) {
+		if err := skipUnless(client != nil, "hello"); err != nil {
+			return "", err
+		}
+		var err error
+		head, err = client.CreateContext(ctx, 0)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("context_id=%d", head.ContextID), nil
+	})
+
+	r.step("write_item", func() (string, error) {
+		if err := skipUnless(created, "create_context"); err != nil {
+			return "", err
+		}
+		res, err := appendItem(ctx, client, head.ContextID, types.NewUserInput(text))
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("turn_id=%d", res.TurnID), nil
+	})
+
+	// The message turn goes last so readers that only look at the head,
+	// such as the Rust interop example, find it.
+	r.step("write_message", func() (string, error) {
+		if err := skipUnless(created, "create_context"); err != nil {
+			return "", err
+		}
+		res, err := appendMessage(ctx, client, head.ContextID, 0, message{role, text})
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("context_id=%d turn_id=%d", head.ContextID, res.TurnID), nil
+	})
+}
+
+// readSuite verifies the most recent turns of a context written by another
+// client. If want is set, the newest message turn must match it.
+func readSuite(ctx context.Context, r *Report, client *cxdb.Client, contextID uint64, limit int, want *message) {
+	var turns []cxdb.TurnRecord
+	fetched := r.step("get_last", func() (string, error) {
+		if err := skipUnless(client != nil, "hello"); err != nil {
+			return "", err
+		}
+		var err error
+		turns, err = client.GetLast(ctx, contextID, cxdb.GetLastOptions{Limit: uint32(limit), IncludePayload: true})
+		if err != nil {
+			return "", err
+		}
+		if len(turns) == 0 {
+			return "", errors.New("no turns")
+		}
+		return fmt.Sprintf("%d turns", len(turns)), nil
+	})
+	if !fetched {
+		return
+	}
+
+	var newest string
+	for _, turn := range turns {
+		r.step(fmt.Sprintf("decode_turn_%d", turn.TurnID), func() (string, error) {
+			detail, err := verifyTurn(turn)
+			if err == nil && turn.TypeID == messageTurnTypeID {
+				newest = detail
+			}
+			return detail, err
+		})
+	}
+
+	if want != nil {
+		r.step("expect_message", func() (string, error) {
+			expected := fmt.Sprintf("role=%s text=%s", want.role, want.text)
+			if newest != expected {
+				return "", fmt.Errorf("newest message is %q, want %q", newest, expected)
+			}
+			return expected, nil
+		})
+	}
+}
+
+// writeFixtureTree creates a small directory tree with a nested file and an
+// empty file.
+func writeFixtureTree(dir string) error {
+	files := map[string]string{
+		"README.md":        "# interop\n",
+		"src/main.go":      "package main\n\nfunc main() {}\n",
+		"src/empty.txt":    "",
+		"docs/notes/a.txt": "nested\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
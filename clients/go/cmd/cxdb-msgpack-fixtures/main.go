@@ -28,6 +28,7 @@ func main() {
 	fixtures := []Fixture{
 		conversationFixture(),
 		numericMapFixture(),
+		scalarsFixture(),
 	}
 
 	if err := os.MkdirAll(*outDir, 0o755); err != nil {
@@ -86,3 +87,19 @@ func numericMapFixture() Fixture {
 		Notes:      "Map with numeric keys for ordering test.",
 	}
 }
+
+func scalarsFixture() Fixture {
+	payload, err := cxdb.EncodeMsgpack(map[uint64]any{
+		1: "user",
+		3: types.Millis(1706615000000),
+		4: types.Bytes{0x89, 0x50, 0x4e, 0x47},
+	})
+	if err != nil {
+		panic(err)
+	}
+	return Fixture{
+		Name:       "msgpack_scalars",
+		PayloadHex: hex.EncodeToString(payload),
+		Notes:      "types.Millis as a compact uint (tag 3, 2024-01-30T11:43:20Z) and types.Bytes as bin (tag 4).",
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+// Command cxdb-seed fills a development or test server with synthetic
+// conversations, for load tests and UI work:
+//
+//	cxdb-seed -contexts 500 -tool-ratio 0.5 -snapshot-ratio 0.1
+//
+// Seeded contexts are labelled "seed". Servers other than loopback ones are
+// refused unless -allow-remote is set, so a stray command cannot pollute
+// production.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	cxdb "github.com/strongdm/ai-cxdb/clients/go"
+	"github.com/strongdm/ai-cxdb/clients/go/seed"
+)
+
+func main() {
+	def := seed.DefaultOptions()
+	addr := flag.String("addr", "127.0.0.1:9009", "binary protocol address")
+	allowRemote := flag.Bool("allow-remote", false, "allow a server that is not on a loopback address")
+	contexts := flag.Int("contexts", def.Contexts, "conversations to create")
+	minTurns := flag.Int("min-turns", def.MinTurns, "minimum exchanges per conversation")
+	maxTurns := flag.Int("max-turns", def.MaxTurns, "maximum exchanges per conversation")
+	toolRatio := flag.Float64("tool-ratio", def.ToolCallRatio, "fraction of assistant answers that call tools")
+	snapshotRatio := flag.Float64("snapshot-ratio", def.SnapshotRatio, "fraction of assistant answers followed by a filesystem snapshot")
+	files := flag.Int("files", def.Files, "files in each generated workspace")
+	labels := flag.String("labels", "", "comma-separated labels to add to \""+seed.Label+"\"")
+	tag := flag.String("tag", def.ClientTag, "client tag of the seeded contexts")
+	seedValue := flag.Int64("seed", def.Seed, "random seed; the same seed generates the same conversations")
+	timeout := flag.Duration("timeout", 30*time.Minute, "overall timeout")
+	flag.Parse()
+
+	if !*allowRemote && !isLoopback(*addr) {
+		fmt.Fprintf(os.Stderr, "cxdb-seed: %s is not a loopback address; pass -allow-remote to seed it anyway\n", *addr)
+		os.Exit(2)
+	}
+
+	opts := seed.Options{
+		Contexts:      *contexts,
+		MinTurns:      *minTurns,
+		MaxTurns:      *maxTurns,
+		ToolCallRatio: *toolRatio,
+		SnapshotRatio: *snapshotRatio,
+		Files:         *files,
+		ClientTag:     *tag,
+		Seed:          *seedValue,
+		Progress: func(done, total int) {
+			if done%50 == 0 || done == total {
+				fmt.Fprintf(os.Stderr, "%d/%d contexts\n", done, total)
+			}
+		},
+	}
+	for _, l := range strings.Split(*labels, ",") {
+		if l = strings.TrimSpace(l); l != "" {
+			opts.Labels = append(opts.Labels, l)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	client, err := cxdb.Dial(*addr, cxdb.WithClientTag("cxdb-seed"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cxdb-seed: dial: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = client.Close() }()
+
+	start := time.Now()
+	res, err := seed.Run(ctx, client, opts)
+	if res != nil {
+		fmt.Printf("seeded %d contexts: %d turns, %d tool calls, %d snapshots in %s\n",
+			len(res.ContextIDs), res.Turns, res.ToolCalls, res.Snapshots, time.Since(start).Round(time.Millisecond))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cxdb-seed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// isLoopback reports whether addr names this machine.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
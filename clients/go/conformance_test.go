@@ -0,0 +1,157 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
+)
+
+// protocolFixtureDir holds the golden request fixtures written by
+// cmd/cxdb-fixtures.
+const protocolFixtureDir = "../../fixtures/protocol"
+
+// capturedFrame is a request as written to the wire.
+type capturedFrame struct {
+	header  wire.Header
+	payload []byte
+}
+
+// captureClient returns a Client whose requests are recorded and answered
+// with a zeroed payload of the request's type.
+func captureClient(t *testing.T) (*Client, <-chan capturedFrame) {
+	t.Helper()
+	clientConn, serverConn := net.Pipe()
+	frames := make(chan capturedFrame, 1)
+	go func() {
+		for {
+			buf := make([]byte, wire.HeaderSize)
+			if _, err := io.ReadFull(serverConn, buf); err != nil {
+				return
+			}
+			h, _ := wire.ParseHeader(buf)
+			payload := make([]byte, h.Length)
+			if _, err := io.ReadFull(serverConn, payload); err != nil {
+				return
+			}
+			frames <- capturedFrame{h, payload}
+			resp := wire.AppendFrame(nil, h.MsgType, 0, h.ReqID, make([]byte, 64))
+			if _, err := serverConn.Write(resp); err != nil {
+				return
+			}
+		}
+	}()
+	c := &Client{transport: NewStreamTransport(clientConn), timeout: 5 * time.Second, usage: newUsageTracker()}
+	t.Cleanup(func() {
+		_ = c.Close()
+		_ = serverConn.Close()
+	})
+	return c, frames
+}
+
+func TestRequestsMatchProtocolFixtures(t *testing.T) {
+	ctx := context.Background()
+	seeded := func(b byte) (h [32]byte) {
+		for i := range h {
+			h[i] = b
+		}
+		return h
+	}
+	appendReq := func(parent uint64, payload byte, idem string) *AppendRequest {
+		return &AppendRequest{
+			ContextID:      1,
+			ParentTurnID:   parent,
+			TypeID:         "cxdb.ConversationItem",
+			TypeVersion:    3,
+			Payload:        []byte{0x91, payload},
+			IdempotencyKey: idem,
+		}
+	}
+	fsRoot := seeded(0xBB)
+
+	// Response parsing may reject the zeroed replies; only the requests
+	// matter here.
+	requests := map[string]func(c *Client){
+		"hello_empty":       func(c *Client) { _ = c.sendHello("") },
+		"hello_tag":         func(c *Client) { _ = c.sendHello("test-client") },
+		"ctx_create_base0":  func(c *Client) { _, _ = c.CreateContext(ctx, 0) },
+		"ctx_fork_base123":  func(c *Client) { _, _ = c.ForkContext(ctx, 123) },
+		"get_head_ctx42":    func(c *Client) { _, _ = c.GetHead(ctx, 42) },
+		"append_parent0":    func(c *Client) { _, _ = c.AppendTurn(ctx, appendReq(0, 0x01, "")) },
+		"append_parent7":    func(c *Client) { _, _ = c.AppendTurn(ctx, appendReq(7, 0x02, "")) },
+		"append_idempotent": func(c *Client) { _, _ = c.AppendTurn(ctx, appendReq(0, 0x03, "idem-1")) },
+		"append_with_fs":    func(c *Client) { _, _ = c.AppendTurnWithFs(ctx, appendReq(0, 0x04, ""), &fsRoot) },
+		"get_last_default":  func(c *Client) { _, _ = c.GetLast(ctx, 1, GetLastOptions{}) },
+		"get_last_payload": func(c *Client) {
+			_, _ = c.GetLast(ctx, 1, GetLastOptions{Limit: 5, IncludePayload: true, KeepPayloadRefs: true})
+		},
+		"get_last_if_changed": func(c *Client) {
+			_, _ = c.GetLast(ctx, 1, GetLastOptions{IfNoneMatch: 42})
+		},
+		"attach_fs": func(c *Client) { _, _ = c.AttachFs(ctx, &AttachFsRequest{TurnID: 99, FsRootHash: seeded(0xAA)}) },
+		"put_blob":  func(c *Client) { _, _ = c.PutBlob(ctx, &PutBlobRequest{Data: []byte("hello blob")}) },
+		"attach_fs_tree_v2": func(c *Client) {
+			c.treeFormats = true
+			_, _ = c.AttachFs(ctx, &AttachFsRequest{TurnID: 99, FsRootHash: seeded(0xAA), TreeFormat: wire.TreeFormatV2})
+		},
+		"hello_routing_key": func(c *Client) {
+			c.routingKey = "tenant-7"
+			_ = c.sendHello("test-client")
+		},
+		"get_head_routed": func(c *Client) {
+			c.routingKeys = true
+			_, _ = c.GetHead(WithRoutingHint(ctx, "42"), 42)
+		},
+		"put_blob_probe": func(c *Client) {
+			c.hashFirstMin = 1
+			c.blobProbes = true
+			_, _ = c.PutBlob(ctx, &PutBlobRequest{Data: []byte("hello blob")})
+		},
+	}
+
+	paths, err := filepath.Glob(filepath.Join(protocolFixtureDir, "*.json"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no fixtures in %s: %v", protocolFixtureDir, err)
+	}
+	for _, path := range paths {
+		var fixture struct {
+			Name       string `json:"name"`
+			MsgType    uint16 `json:"msg_type"`
+			Flags      uint16 `json:"flags"`
+			PayloadHex string `json:"payload_hex"`
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(data, &fixture); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		send, ok := requests[fixture.Name]
+		if !ok {
+			t.Errorf("%s: no client request for fixture", fixture.Name)
+			continue
+		}
+
+		c, frames := captureClient(t)
+		send(c)
+		got := <-frames
+		if got.header.MsgType != fixture.MsgType || got.header.Flags != fixture.Flags {
+			t.Errorf("%s: msg_type %d flags %d, fixture has %d and %d",
+				fixture.Name, got.header.MsgType, got.header.Flags, fixture.MsgType, fixture.Flags)
+		}
+		if hex.EncodeToString(got.payload) != fixture.PayloadHex {
+			t.Errorf("%s: payload\n%x\nfixture\n%s", fixture.Name, got.payload, fixture.PayloadHex)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+// DisconnectReason says why a connection went down.
+type DisconnectReason string
+
+const (
+	// DisconnectClosed means the application called Close.
+	DisconnectClosed DisconnectReason = "closed"
+
+	// DisconnectConnectionError means a request failed with a connection
+	// error (see IsConnectionError): reset, EOF, timeout, and so on.
+	DisconnectConnectionError DisconnectReason = "connection_error"
+
+	// DisconnectRecycled means a ReconnectingClient replaced a healthy
+	// connection that reached its maximum lifetime or idle timeout.
+	DisconnectRecycled DisconnectReason = "recycled"
+)
+
+// ConnectEvent describes an established connection.
+type ConnectEvent struct {
+	SessionID uint64 // assigned by the server in the HELLO handshake
+	ClientTag string
+	Reconnect bool // true when a ReconnectingClient restored or recycled a link
+}
+
+// DisconnectEvent describes a lost or closed connection.
+type DisconnectEvent struct {
+	SessionID uint64 // session of the connection that went down
+	Reason    DisconnectReason
+	Err       error // the connection error; nil for DisconnectClosed and DisconnectRecycled
+}
+
+// WithOnConnect sets a callback invoked once the HELLO handshake succeeds.
+//
+// With DialReconnecting the callback reports the logical link instead of
+// each socket: it fires for the initial connection and again, with
+// Reconnect set, each time the link is restored.
+func WithOnConnect(fn func(ConnectEvent)) Option {
+	return func(o *clientOptions) {
+		o.onConnect = fn
+	}
+}
+
+// WithOnDisconnect sets a callback invoked when the connection goes down,
+// at most once per connection. A Client reports the first request that
+// fails with a connection error; such a Client should be discarded.
+//
+// With DialReconnecting the callback fires when a request hits a connection
+// error, before reconnecting, so applications can pause optional work until
+// the next OnConnect. Callbacks run synchronously and must not block.
+func WithOnDisconnect(fn func(DisconnectEvent)) Option {
+	return func(o *clientOptions) {
+		o.onDisconnect = fn
+	}
+}
+
+// connected reports a completed handshake.
+func (c *Client) connected() {
+	if c.onConnect != nil {
+		c.onConnect(ConnectEvent{SessionID: c.sessionID, ClientTag: c.clientTag})
+	}
+}
+
+// disconnected reports the connection going down; only the first call
+// has an effect. It must not be called with c.mu held.
+func (c *Client) disconnected(reason DisconnectReason, err error) {
+	c.downOnce.Do(func() {
+		if c.onDisconnect != nil {
+			c.onDisconnect(DisconnectEvent{SessionID: c.sessionID, Reason: reason, Err: err})
+		}
+	})
+}
@@ -0,0 +1,135 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"sync"
+	"syscall"
+	"testing"
+)
+
+func TestClientConnectionStateCallbacks(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ln.Close() }()
+	serverConns := make(chan net.Conn, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		serveFrames(conn, func(msgType uint16, _ []byte) (uint16, uint16, []byte) {
+			hello := make([]byte, 10)
+			binary.LittleEndian.PutUint64(hello, 42)
+			return msgType, 0, hello
+		})
+		serverConns <- conn
+	}()
+
+	var mu sync.Mutex
+	var connects []ConnectEvent
+	var disconnects []DisconnectEvent
+	c, err := Dial(ln.Addr().String(),
+		WithClientTag("banner"),
+		WithOnConnect(func(e ConnectEvent) {
+			mu.Lock()
+			connects = append(connects, e)
+			mu.Unlock()
+		}),
+		WithOnDisconnect(func(e DisconnectEvent) {
+			mu.Lock()
+			disconnects = append(disconnects, e)
+			mu.Unlock()
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(connects) != 1 || connects[0] != (ConnectEvent{SessionID: 42, ClientTag: "banner"}) {
+		t.Fatalf("connects = %+v", connects)
+	}
+
+	// The server going away is reported once, by the first failed request.
+	_ = (<-serverConns).Close()
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetHead(context.Background(), 1); !IsConnectionError(err) {
+			t.Fatalf("GetHead after hangup: %v", err)
+		}
+	}
+	_ = c.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(disconnects) != 1 {
+		t.Fatalf("disconnects = %+v", disconnects)
+	}
+	if d := disconnects[0]; d.SessionID != 42 || d.Reason != DisconnectConnectionError || d.Err == nil {
+		t.Errorf("disconnect = %+v", d)
+	}
+}
+
+func TestClientCloseReportsDisconnect(t *testing.T) {
+	c := pipeClient(t, func(msgType uint16, _ []byte) (uint16, uint16, []byte) {
+		return msgType, 0, nil
+	})
+	var got []DisconnectEvent
+	c.onDisconnect = func(e DisconnectEvent) { got = append(got, e) }
+
+	_ = c.Close()
+	_ = c.Close()
+	if len(got) != 1 || got[0].Reason != DisconnectClosed || got[0].Err != nil {
+		t.Errorf("disconnects = %+v", got)
+	}
+}
+
+func TestReconnectingClientConnectionStateCallbacks(t *testing.T) {
+	dialer := newMockDialer()
+	rc, err := createTestReconnectingClient(dialer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Callbacks run on the sender goroutine and in Close, never concurrently.
+	var events []string
+	rc.onConnect = func(e ConnectEvent) {
+		if !e.Reconnect {
+			t.Errorf("connect event %+v lacks Reconnect", e)
+		}
+		events = append(events, "connect")
+	}
+	rc.onDisconnect = func(e DisconnectEvent) {
+		events = append(events, string(e.Reason))
+	}
+
+	calls := 0
+	err = rc.enqueue(context.Background(), "test", func(context.Context, *Client) error {
+		if calls++; calls == 1 {
+			return syscall.ECONNRESET
+		}
+		// The link is reported restored before the retry runs.
+		if len(events) != 2 {
+			t.Errorf("events before retry = %v", events)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = rc.Close()
+
+	want := []string{"connection_error", "connect", "closed"}
+	if len(events) != len(want) {
+		t.Fatalf("events = %v, want %v", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("events = %v, want %v", events, want)
+		}
+	}
+}
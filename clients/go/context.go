@@ -7,6 +7,8 @@ import (
 	"context"
 	"encoding/binary"
 	"fmt"
+
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
 )
 
 // ContextHead represents the head of a context (branch).
@@ -14,6 +16,9 @@ type ContextHead struct {
 	ContextID  uint64
 	HeadTurnID uint64
 	HeadDepth  uint32
+
+	// Flags are the response frame flags.
+	Flags ResponseFlags
 }
 
 // CreateContext creates a new context in CXDB.
@@ -23,12 +28,12 @@ func (c *Client) CreateContext(ctx context.Context, baseTurnID uint64) (*Context
 	payload := make([]byte, 8)
 	binary.LittleEndian.PutUint64(payload, baseTurnID)
 
-	resp, err := c.sendRequest(ctx, msgCtxCreate, payload)
+	resp, err := c.sendRequest(ctx, wire.MsgCtxCreate, payload)
 	if err != nil {
 		return nil, fmt.Errorf("create context: %w", err)
 	}
 
-	return parseContextHead(resp.payload)
+	return parseContextHead(resp)
 }
 
 // ForkContext creates a new context branching from a specific turn.
@@ -37,12 +42,12 @@ func (c *Client) ForkContext(ctx context.Context, baseTurnID uint64) (*ContextHe
 	payload := make([]byte, 8)
 	binary.LittleEndian.PutUint64(payload, baseTurnID)
 
-	resp, err := c.sendRequest(ctx, msgCtxFork, payload)
+	resp, err := c.sendRequest(ctx, wire.MsgCtxFork, payload)
 	if err != nil {
 		return nil, fmt.Errorf("fork context: %w", err)
 	}
 
-	return parseContextHead(resp.payload)
+	return parseContextHead(resp)
 }
 
 // GetHead retrieves the current head of a context.
@@ -50,15 +55,16 @@ func (c *Client) GetHead(ctx context.Context, contextID uint64) (*ContextHead, e
 	payload := make([]byte, 8)
 	binary.LittleEndian.PutUint64(payload, contextID)
 
-	resp, err := c.sendRequest(ctx, msgGetHead, payload)
+	resp, err := c.sendRequest(ctx, wire.MsgGetHead, payload)
 	if err != nil {
 		return nil, fmt.Errorf("get head: %w", err)
 	}
 
-	return parseContextHead(resp.payload)
+	return parseContextHead(resp)
 }
 
-func parseContextHead(payload []byte) (*ContextHead, error) {
+func parseContextHead(resp *frame) (*ContextHead, error) {
+	payload := resp.payload
 	if len(payload) < 20 {
 		return nil, fmt.Errorf("%w: context head too short (%d bytes)", ErrInvalidResponse, len(payload))
 	}
@@ -66,5 +72,6 @@ func parseContextHead(payload []byte) (*ContextHead, error) {
 		ContextID:  binary.LittleEndian.Uint64(payload[0:8]),
 		HeadTurnID: binary.LittleEndian.Uint64(payload[8:16]),
 		HeadDepth:  binary.LittleEndian.Uint32(payload[16:20]),
+		Flags:      ResponseFlags(resp.flags),
 	}, nil
 }
@@ -0,0 +1,178 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+// Package credentials stores gateway-issued bearer tokens for CLI tools.
+//
+// Tokens are kept per profile (e.g. "dev", "staging", "prod") in the OS
+// keychain when one is available, and otherwise in a passphrase-encrypted
+// file under the user config directory. Tools resolve a token with Resolve,
+// which honors CXDB_TOKEN so scripts and CI keep working unchanged.
+//
+// # Basic Usage
+//
+//	store, err := credentials.Default()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	cred, err := credentials.Resolve(store, credentials.ProfileFromEnv())
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	api := httpclient.New(cred.Gateway, httpclient.WithBearerToken(cred.Token))
+package credentials
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// Environment variables consulted by ProfileFromEnv, Resolve, and Default.
+const (
+	EnvProfile    = "CXDB_PROFILE"
+	EnvToken      = "CXDB_TOKEN"
+	EnvGateway    = "CXDB_GATEWAY"
+	EnvPassphrase = "CXDB_CREDENTIALS_PASSPHRASE"
+)
+
+// DefaultProfile is used when no profile is named.
+const DefaultProfile = "default"
+
+// Errors returned by stores and Resolve.
+var (
+	// ErrNotFound is returned when a profile has no stored credential.
+	ErrNotFound = errors.New("credentials: not found")
+
+	// ErrExpired is returned by Resolve when the stored token has expired.
+	ErrExpired = errors.New("credentials: token expired")
+
+	// ErrInvalidProfile is returned for profile names outside [A-Za-z0-9._-].
+	ErrInvalidProfile = errors.New("credentials: invalid profile name")
+
+	// ErrNoBackend is returned by Default when there is no keychain and no
+	// passphrase for the encrypted file.
+	ErrNoBackend = errors.New("credentials: no keychain available and " + EnvPassphrase + " is not set")
+)
+
+// Credential is a stored bearer token.
+type Credential struct {
+	// Token is the bearer token sent to the gateway.
+	Token string `json:"token"`
+
+	// TokenType is the token type reported by the gateway, usually "Bearer".
+	TokenType string `json:"token_type,omitempty"`
+
+	// ExpiresAt is when the gateway stops accepting the token. Zero means
+	// unknown.
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+
+	// Gateway is the base URL the token was issued by.
+	Gateway string `json:"gateway,omitempty"`
+}
+
+// Expired reports whether the token is past its expiry.
+func (c *Credential) Expired(now time.Time) bool {
+	return !c.ExpiresAt.IsZero() && !now.Before(c.ExpiresAt)
+}
+
+// Store persists credentials by profile.
+type Store interface {
+	// Get returns the credential for profile, or ErrNotFound.
+	Get(profile string) (*Credential, error)
+
+	// Set stores cred under profile, replacing any existing credential.
+	Set(profile string, cred *Credential) error
+
+	// Delete removes profile. Deleting a missing profile is not an error.
+	Delete(profile string) error
+}
+
+var profilePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// ValidateProfile checks that a profile name is safe to use as a keychain
+// account and file key.
+func ValidateProfile(profile string) error {
+	if !profilePattern.MatchString(profile) {
+		return fmt.Errorf("%w: %q", ErrInvalidProfile, profile)
+	}
+	return nil
+}
+
+// ProfileFromEnv returns CXDB_PROFILE, or DefaultProfile when it is unset.
+func ProfileFromEnv() string {
+	if p := os.Getenv(EnvProfile); p != "" {
+		return p
+	}
+	return DefaultProfile
+}
+
+// Resolve returns the credential a tool should use for profile.
+// CXDB_TOKEN, when set, takes precedence over the store; CXDB_GATEWAY
+// overrides the stored gateway URL. Stored tokens past their expiry return
+// ErrExpired.
+func Resolve(store Store, profile string) (*Credential, error) {
+	if tok := os.Getenv(EnvToken); tok != "" {
+		return &Credential{Token: tok, TokenType: "Bearer", Gateway: os.Getenv(EnvGateway)}, nil
+	}
+	if store == nil {
+		return nil, fmt.Errorf("resolve %s: %w", profile, ErrNotFound)
+	}
+	cred, err := store.Get(profile)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", profile, err)
+	}
+	if cred.Expired(time.Now()) {
+		return nil, fmt.Errorf("resolve %s: %w at %s", profile, ErrExpired, cred.ExpiresAt.Format(time.RFC3339))
+	}
+	if gw := os.Getenv(EnvGateway); gw != "" {
+		cred.Gateway = gw
+	}
+	return cred, nil
+}
+
+// Default returns the OS keychain store when one is available, and
+// otherwise the encrypted file at DefaultFilePath unlocked with
+// CXDB_CREDENTIALS_PASSPHRASE.
+func Default() (Store, error) {
+	if kc, err := NewKeychain(DefaultService); err == nil {
+		return kc, nil
+	}
+	pass := os.Getenv(EnvPassphrase)
+	if pass == "" {
+		return nil, ErrNoBackend
+	}
+	path, err := DefaultFilePath()
+	if err != nil {
+		return nil, err
+	}
+	return NewFileStore(path, pass), nil
+}
+
+// DefaultFilePath is the encrypted credential file location, under the
+// user config directory (e.g. ~/.config/cxdb/credentials.enc).
+func DefaultFilePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("credentials: %w", err)
+	}
+	return filepath.Join(dir, "cxdb", "credentials.enc"), nil
+}
+
+func encodeCredential(cred *Credential) ([]byte, error) {
+	if cred == nil || cred.Token == "" {
+		return nil, errors.New("credentials: empty token")
+	}
+	return json.Marshal(cred)
+}
+
+func decodeCredential(data []byte) (*Credential, error) {
+	var cred Credential
+	if err := json.Unmarshal(data, &cred); err != nil {
+		return nil, fmt.Errorf("credentials: decode: %w", err)
+	}
+	return &cred, nil
+}
@@ -0,0 +1,233 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package credentials
+
+import (
+	"encoding/hex"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPBKDF2Vectors(t *testing.T) {
+	tests := []struct {
+		password, salt string
+		iterations     int
+		keyLen         int
+		want           string
+	}{
+		{"passwd", "salt", 1, 64, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+		{"password", "salt", 4096, 32, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+	}
+	for _, tt := range tests {
+		got := hex.EncodeToString(pbkdf2SHA256([]byte(tt.password), []byte(tt.salt), tt.iterations, tt.keyLen))
+		if got != tt.want {
+			t.Errorf("pbkdf2(%q, %q, %d) = %s, want %s", tt.password, tt.salt, tt.iterations, got, tt.want)
+		}
+	}
+}
+
+func newTestFileStore(t *testing.T, pass string) *FileStore {
+	t.Helper()
+	f := NewFileStore(filepath.Join(t.TempDir(), "cxdb", "credentials.enc"), pass)
+	f.iterations = 1000
+	return f
+}
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	f := newTestFileStore(t, "hunter2")
+
+	if _, err := f.Get("prod"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get before Set: err = %v, want ErrNotFound", err)
+	}
+
+	exp := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := f.Set("prod", &Credential{Token: "tok-prod", TokenType: "Bearer", ExpiresAt: exp, Gateway: "https://cxdb.example.com"}); err != nil {
+		t.Fatalf("Set prod: %v", err)
+	}
+	if err := f.Set("dev", &Credential{Token: "tok-dev"}); err != nil {
+		t.Fatalf("Set dev: %v", err)
+	}
+
+	got, err := f.Get("prod")
+	if err != nil {
+		t.Fatalf("Get prod: %v", err)
+	}
+	if got.Token != "tok-prod" || !got.ExpiresAt.Equal(exp) || got.Gateway != "https://cxdb.example.com" {
+		t.Fatalf("Get prod = %+v", got)
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "tok-prod") {
+		t.Fatal("token stored in plaintext")
+	}
+	info, err := os.Stat(f.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("file mode = %o, want 600", perm)
+	}
+
+	if err := f.Delete("prod"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := f.Get("prod"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete: err = %v, want ErrNotFound", err)
+	}
+	names, err := f.Profiles()
+	if err != nil || len(names) != 1 || names[0] != "dev" {
+		t.Fatalf("Profiles = %v, %v", names, err)
+	}
+}
+
+func TestFileStoreWrongPassphrase(t *testing.T) {
+	f := newTestFileStore(t, "right")
+	if err := f.Set("dev", &Credential{Token: "tok"}); err != nil {
+		t.Fatal(err)
+	}
+	wrong := NewFileStore(f.path, "wrong")
+	if _, err := wrong.Get("dev"); !errors.Is(err, ErrBadPassphrase) {
+		t.Fatalf("Get with wrong passphrase: err = %v, want ErrBadPassphrase", err)
+	}
+	if err := wrong.Set("dev", &Credential{Token: "other"}); !errors.Is(err, ErrBadPassphrase) {
+		t.Fatalf("Set with wrong passphrase: err = %v, want ErrBadPassphrase", err)
+	}
+}
+
+func TestValidateProfile(t *testing.T) {
+	for _, p := range []string{"dev", "prod-us.east_1"} {
+		if err := ValidateProfile(p); err != nil {
+			t.Errorf("ValidateProfile(%q) = %v", p, err)
+		}
+	}
+	for _, p := range []string{"", "../etc", "a b", strings.Repeat("x", 65)} {
+		if err := ValidateProfile(p); !errors.Is(err, ErrInvalidProfile) {
+			t.Errorf("ValidateProfile(%q) = %v, want ErrInvalidProfile", p, err)
+		}
+	}
+}
+
+func TestKeychainCommands(t *testing.T) {
+	secrets := make(map[string]string)
+	var calls []string
+	fake := func(stdin []byte, name string, args ...string) ([]byte, error) {
+		calls = append(calls, name+" "+args[0])
+		switch args[0] {
+		case "store":
+			secrets[args[len(args)-1]] = string(stdin)
+		case "lookup":
+			return []byte(secrets[args[len(args)-1]] + "\n"), nil
+		case "clear":
+			delete(secrets, args[len(args)-1])
+		}
+		return nil, nil
+	}
+	k := &Keychain{service: DefaultService, tool: "secret-tool", run: fake}
+
+	if err := k.Set("staging", &Credential{Token: "tok-staging"}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	got, err := k.Get("staging")
+	if err != nil || got.Token != "tok-staging" {
+		t.Fatalf("Get = %+v, %v", got, err)
+	}
+	if err := k.Delete("staging"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := k.Get("staging"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete: err = %v, want ErrNotFound", err)
+	}
+	if len(calls) != 4 {
+		t.Fatalf("calls = %v", calls)
+	}
+}
+
+func TestKeychainGetErrors(t *testing.T) {
+	boom := errors.New("exit status")
+	for _, tt := range []struct {
+		tool     string
+		err      error
+		notFound bool
+	}{
+		{"security", &toolError{name: "security", code: 44, stderr: "The specified item could not be found in the keychain.", err: boom}, true},
+		{"security", &toolError{name: "security", code: 36, stderr: "User interaction is not allowed.", err: boom}, false},
+		{"secret-tool", &toolError{name: "secret-tool", code: 1, err: boom}, true},
+		{"secret-tool", &toolError{name: "secret-tool", code: 1, stderr: "Cannot autolaunch D-Bus without X11 $DISPLAY", err: boom}, false},
+		{"secret-tool", &toolError{name: "secret-tool", code: -1, err: exec.ErrNotFound}, false},
+	} {
+		fake := func([]byte, string, ...string) ([]byte, error) { return nil, tt.err }
+		k := &Keychain{service: DefaultService, tool: tt.tool, run: fake}
+		_, err := k.Get("prod")
+		if tt.notFound && !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: %v: err = %v, want ErrNotFound", tt.tool, tt.err, err)
+		}
+		if !tt.notFound && (errors.Is(err, ErrNotFound) || !errors.Is(err, tt.err)) {
+			t.Errorf("%s: %v: err = %v, want the tool error", tt.tool, tt.err, err)
+		}
+	}
+}
+
+func TestKeychainSecurityKeepsSecretOffCommandLine(t *testing.T) {
+	var args []string
+	var stdin string
+	fake := func(in []byte, name string, a ...string) ([]byte, error) {
+		args, stdin = append([]string{name}, a...), string(in)
+		return nil, nil
+	}
+	k := &Keychain{service: DefaultService, tool: "security", run: fake}
+
+	if err := k.Set("prod", &Credential{Token: `tok"\secret`}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if strings.Join(args, " ") != "security -i" {
+		t.Fatalf("args = %q, want security -i", args)
+	}
+	want := `add-generic-password -U -s "cxdb" -a "prod" -w "{\"token\":\"tok\\\"\\\\secret\",\"expires_at\":\"0001-01-01T00:00:00Z\"}"` + "\n"
+	if stdin != want {
+		t.Fatalf("stdin = %s, want %s", stdin, want)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	f := newTestFileStore(t, "pw")
+	if err := f.Set("prod", &Credential{Token: "stored", Gateway: "https://prod"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Set("old", &Credential{Token: "stale", ExpiresAt: time.Now().Add(-time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(EnvToken, "")
+	t.Setenv(EnvGateway, "")
+	got, err := Resolve(f, "prod")
+	if err != nil || got.Token != "stored" || got.Gateway != "https://prod" {
+		t.Fatalf("Resolve(prod) = %+v, %v", got, err)
+	}
+	if _, err := Resolve(f, "old"); !errors.Is(err, ErrExpired) {
+		t.Fatalf("Resolve(old): err = %v, want ErrExpired", err)
+	}
+	if _, err := Resolve(f, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Resolve(missing): err = %v, want ErrNotFound", err)
+	}
+
+	t.Setenv(EnvGateway, "https://override")
+	got, err = Resolve(f, "prod")
+	if err != nil || got.Gateway != "https://override" {
+		t.Fatalf("Resolve with gateway override = %+v, %v", got, err)
+	}
+
+	t.Setenv(EnvToken, "from-env")
+	got, err = Resolve(nil, "missing")
+	if err != nil || got.Token != "from-env" {
+		t.Fatalf("Resolve with env token = %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrBadPassphrase is returned when the credential file cannot be decrypted.
+var ErrBadPassphrase = errors.New("credentials: wrong passphrase or corrupted file")
+
+const (
+	fileVersion = 1
+	kdfPBKDF2   = "pbkdf2-sha256"
+
+	// defaultIterations follows current OWASP guidance for PBKDF2-SHA256.
+	defaultIterations = 600_000
+	saltSize          = 16
+	keySize           = 32
+)
+
+// fileEnvelope is the on-disk format. The ciphertext is AES-256-GCM over the
+// JSON-encoded profiles, with the envelope header as additional data.
+type fileEnvelope struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func (e *fileEnvelope) additionalData() []byte {
+	return []byte(fmt.Sprintf("cxdb-credentials/v%d/%s/%d", e.Version, e.KDF, e.Iterations))
+}
+
+// FileStore keeps all profiles in one passphrase-encrypted file. Writes
+// replace the file atomically with mode 0600.
+type FileStore struct {
+	path       string
+	passphrase string
+	iterations int
+
+	mu sync.Mutex
+}
+
+// NewFileStore returns a store backed by the file at path. The file is
+// created on the first Set.
+func NewFileStore(path, passphrase string) *FileStore {
+	return &FileStore{path: path, passphrase: passphrase, iterations: defaultIterations}
+}
+
+// Get implements Store.
+func (f *FileStore) Get(profile string) (*Credential, error) {
+	if err := ValidateProfile(profile); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	profiles, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	cred, ok := profiles[profile]
+	if !ok {
+		return nil, fmt.Errorf("%w: profile %s", ErrNotFound, profile)
+	}
+	return cred, nil
+}
+
+// Set implements Store.
+func (f *FileStore) Set(profile string, cred *Credential) error {
+	if err := ValidateProfile(profile); err != nil {
+		return err
+	}
+	if _, err := encodeCredential(cred); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	profiles, err := f.load()
+	if err != nil {
+		return err
+	}
+	profiles[profile] = cred
+	return f.save(profiles)
+}
+
+// Delete implements Store.
+func (f *FileStore) Delete(profile string) error {
+	if err := ValidateProfile(profile); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	profiles, err := f.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := profiles[profile]; !ok {
+		return nil
+	}
+	delete(profiles, profile)
+	return f.save(profiles)
+}
+
+// Profiles returns the stored profile names.
+func (f *FileStore) Profiles() ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	profiles, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (f *FileStore) load() (map[string]*Credential, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return make(map[string]*Credential), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("credentials: read %s: %w", f.path, err)
+	}
+
+	var env fileEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("credentials: parse %s: %w", f.path, err)
+	}
+	if env.Version != fileVersion || env.KDF != kdfPBKDF2 || env.Iterations <= 0 {
+		return nil, fmt.Errorf("credentials: unsupported file format v%d/%s", env.Version, env.KDF)
+	}
+	gcm, err := newGCM(f.passphrase, env.Salt, env.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Nonce) != gcm.NonceSize() {
+		return nil, ErrBadPassphrase
+	}
+	plain, err := gcm.Open(nil, env.Nonce, env.Ciphertext, env.additionalData())
+	if err != nil {
+		return nil, ErrBadPassphrase
+	}
+
+	profiles := make(map[string]*Credential)
+	if err := json.Unmarshal(plain, &profiles); err != nil {
+		return nil, fmt.Errorf("credentials: decode %s: %w", f.path, err)
+	}
+	return profiles, nil
+}
+
+func (f *FileStore) save(profiles map[string]*Credential) error {
+	plain, err := json.Marshal(profiles)
+	if err != nil {
+		return fmt.Errorf("credentials: encode: %w", err)
+	}
+
+	env := fileEnvelope{Version: fileVersion, KDF: kdfPBKDF2, Iterations: f.iterations, Salt: make([]byte, saltSize)}
+	if _, err := rand.Read(env.Salt); err != nil {
+		return fmt.Errorf("credentials: %w", err)
+	}
+	gcm, err := newGCM(f.passphrase, env.Salt, env.Iterations)
+	if err != nil {
+		return err
+	}
+	env.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(env.Nonce); err != nil {
+		return fmt.Errorf("credentials: %w", err)
+	}
+	env.Ciphertext = gcm.Seal(nil, env.Nonce, plain, env.additionalData())
+
+	data, err := json.MarshalIndent(&env, "", "  ")
+	if err != nil {
+		return fmt.Errorf("credentials: encode: %w", err)
+	}
+	return writeFileAtomic(f.path, data)
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("credentials: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".credentials-*")
+	if err != nil {
+		return fmt.Errorf("credentials: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if err := tmp.Chmod(0o600); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("credentials: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("credentials: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("credentials: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("credentials: %w", err)
+	}
+	return nil
+}
+
+func newGCM(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	if passphrase == "" {
+		return nil, errors.New("credentials: empty passphrase")
+	}
+	block, err := aes.NewCipher(pbkdf2SHA256([]byte(passphrase), salt, iterations, keySize))
+	if err != nil {
+		return nil, fmt.Errorf("credentials: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// pbkdf2SHA256 implements PBKDF2 (RFC 8018) with HMAC-SHA256.
+func pbkdf2SHA256(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	hashLen := prf.Size()
+	blocks := (keyLen + hashLen - 1) / hashLen
+
+	out := make([]byte, 0, blocks*hashLen)
+	var counter [4]byte
+	u := make([]byte, hashLen)
+	t := make([]byte, hashLen)
+	for block := 1; block <= blocks; block++ {
+		binary.BigEndian.PutUint32(counter[:], uint32(block))
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(counter[:])
+		u = prf.Sum(u[:0])
+		copy(t, u)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		out = append(out, t...)
+	}
+	return out[:keyLen]
+}
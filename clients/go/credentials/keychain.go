@@ -0,0 +1,168 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package credentials
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// DefaultService is the keychain service name credentials are filed under.
+const DefaultService = "cxdb"
+
+// ErrNoKeychain is returned by NewKeychain on platforms without a supported
+// keychain tool.
+var ErrNoKeychain = errors.New("credentials: no supported keychain")
+
+// runner runs a keychain tool with optional stdin and returns stdout.
+type runner func(stdin []byte, name string, args ...string) ([]byte, error)
+
+func execRunner(stdin []byte, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		te := &toolError{name: name, code: -1, stderr: strings.TrimSpace(stderr.String()), err: err}
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			te.code = exit.ExitCode()
+		}
+		return nil, te
+	}
+	return out, nil
+}
+
+// toolError is a keychain tool that failed to run or exited non-zero.
+type toolError struct {
+	name   string
+	code   int // exit status, or -1 if the tool did not exit
+	stderr string
+	err    error
+}
+
+func (e *toolError) Error() string {
+	if e.stderr != "" {
+		return fmt.Sprintf("%s: %v: %s", e.name, e.err, e.stderr)
+	}
+	return fmt.Sprintf("%s: %v", e.name, e.err)
+}
+
+func (e *toolError) Unwrap() error { return e.err }
+
+// Keychain stores credentials in the OS keychain: the login keychain via
+// security(1) on macOS, or the Secret Service via secret-tool(1) on Linux.
+type Keychain struct {
+	service string
+	tool    string
+	run     runner
+}
+
+// NewKeychain returns a keychain store for service, or ErrNoKeychain when
+// the platform tool is not installed.
+func NewKeychain(service string) (*Keychain, error) {
+	var tool string
+	switch runtime.GOOS {
+	case "darwin":
+		tool = "security"
+	case "linux", "freebsd", "openbsd":
+		tool = "secret-tool"
+	default:
+		return nil, ErrNoKeychain
+	}
+	if _, err := exec.LookPath(tool); err != nil {
+		return nil, fmt.Errorf("%w: %s not found", ErrNoKeychain, tool)
+	}
+	return &Keychain{service: service, tool: tool, run: execRunner}, nil
+}
+
+// Get implements Store.
+func (k *Keychain) Get(profile string) (*Credential, error) {
+	if err := ValidateProfile(profile); err != nil {
+		return nil, err
+	}
+	var out []byte
+	var err error
+	if k.tool == "security" {
+		out, err = k.run(nil, "security", "find-generic-password", "-s", k.service, "-a", profile, "-w")
+	} else {
+		out, err = k.run(nil, "secret-tool", "lookup", "service", k.service, "profile", profile)
+	}
+	out = bytes.TrimSpace(out)
+	if k.missing(err) || (err == nil && len(out) == 0) {
+		return nil, fmt.Errorf("%w: profile %s", ErrNotFound, profile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("credentials: read %s: %w", profile, err)
+	}
+	return decodeCredential(out)
+}
+
+// missing reports whether err is the tool's answer for an item that does
+// not exist: security(1) exits 44 (errSecItemNotFound), and secret-tool(1)
+// exits 1 without a message, or 0 with no output. Other failures, such as
+// a locked keychain or no Secret Service, are not.
+func (k *Keychain) missing(err error) bool {
+	var te *toolError
+	if !errors.As(err, &te) {
+		return false
+	}
+	if k.tool == "security" {
+		return te.code == 44
+	}
+	return te.code == 1 && te.stderr == ""
+}
+
+// Set implements Store.
+func (k *Keychain) Set(profile string, cred *Credential) error {
+	if err := ValidateProfile(profile); err != nil {
+		return err
+	}
+	data, err := encodeCredential(cred)
+	if err != nil {
+		return err
+	}
+	if k.tool == "security" {
+		// Interactive mode reads the command from stdin, keeping the secret
+		// out of the process list.
+		cmd := "add-generic-password -U -s " + securityQuote(k.service) + " -a " + securityQuote(profile) + " -w " + securityQuote(string(data)) + "\n"
+		_, err = k.run([]byte(cmd), "security", "-i")
+	} else {
+		_, err = k.run(data, "secret-tool", "store", "--label", k.service+" "+profile, "service", k.service, "profile", profile)
+	}
+	if err != nil {
+		return fmt.Errorf("credentials: store %s: %w", profile, err)
+	}
+	return nil
+}
+
+// Delete implements Store.
+func (k *Keychain) Delete(profile string) error {
+	if err := ValidateProfile(profile); err != nil {
+		return err
+	}
+	if k.tool == "security" {
+		// Exits non-zero when the item does not exist.
+		_, _ = k.run(nil, "security", "delete-generic-password", "-s", k.service, "-a", profile)
+		return nil
+	}
+	if _, err := k.run(nil, "secret-tool", "clear", "service", k.service, "profile", profile); err != nil {
+		return fmt.Errorf("credentials: delete %s: %w", profile, err)
+	}
+	return nil
+}
+
+// securityQuote quotes s as one argument of a security(1) interactive-mode
+// command line. encodeCredential's JSON never contains a raw newline.
+func securityQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
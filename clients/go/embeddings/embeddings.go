@@ -0,0 +1,318 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+// Package embeddings attaches embedding vectors to CXDB turns.
+//
+// Embeddings are stored as annotation items (types.ItemTypeAnnotation) that
+// reference the embedded turn by ID. They can be appended to the same context
+// or to a sidecar context, which keeps the conversation itself free of
+// derived data.
+//
+// # Basic Usage
+//
+//	d := embeddings.NewDriver(client, myEmbedder,
+//	    embeddings.WithBatchSize(32),
+//	)
+//	res, err := d.Run(ctx, contextID)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println("embedded", res.Embedded, "turns")
+package embeddings
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/zeebo/blake3"
+
+	cxdb "github.com/strongdm/ai-cxdb/clients/go"
+	"github.com/strongdm/ai-cxdb/clients/go/types"
+	"github.com/strongdm/ai-cxdb/clients/go/window"
+)
+
+// Embedder turns text into vectors. Implementations wrap a provider API.
+type Embedder interface {
+	// Model identifies the embedding model; it is recorded on each vector.
+	Model() string
+
+	// Embed returns one vector per input text, in order.
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// ErrVectorCount is returned when an Embedder returns the wrong number of vectors.
+var ErrVectorCount = errors.New("embeddings: embedder returned wrong number of vectors")
+
+// Option configures attachment and the batch driver.
+type Option func(*options)
+
+type options struct {
+	batchSize     int
+	minChars      int
+	maxTurns      uint32
+	sidecar       uint64
+	blobThreshold int
+}
+
+func defaultOptions() options {
+	return options{
+		batchSize: 16,
+		minChars:  1,
+		maxTurns:  4096,
+	}
+}
+
+// WithBatchSize sets how many texts are sent to the Embedder per call (default 16).
+func WithBatchSize(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.batchSize = n
+		}
+	}
+}
+
+// WithMinChars skips turns whose extracted text is shorter than n (default 1).
+func WithMinChars(n int) Option {
+	return func(o *options) {
+		o.minChars = n
+	}
+}
+
+// WithMaxTurns bounds how many turns Run reads from the context (default 4096).
+func WithMaxTurns(n uint32) Option {
+	return func(o *options) {
+		o.maxTurns = n
+	}
+}
+
+// WithAnnotationContext appends annotations to a sidecar context instead of
+// the embedded context. The sidecar is also scanned for existing embeddings.
+func WithAnnotationContext(contextID uint64) Option {
+	return func(o *options) {
+		o.sidecar = contextID
+	}
+}
+
+// WithBlobThreshold stores vectors with at least n dimensions as blobs
+// instead of inline. Zero (the default) always stores inline.
+func WithBlobThreshold(dims int) Option {
+	return func(o *options) {
+		o.blobThreshold = dims
+	}
+}
+
+// =============================================================================
+// Attach
+// =============================================================================
+
+// Attach appends a single embedding annotation for targetTurnID. The
+// annotation goes to contextID, or to the WithAnnotationContext sidecar.
+func Attach(ctx context.Context, client *cxdb.Client, contextID, targetTurnID uint64, emb types.Embedding, opts ...Option) (*cxdb.AppendResult, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return attach(ctx, client, contextID, targetTurnID, emb, o)
+}
+
+func attach(ctx context.Context, client *cxdb.Client, contextID, targetTurnID uint64, emb types.Embedding, o options) (*cxdb.AppendResult, error) {
+	if emb.Dims == 0 {
+		emb.Dims = len(emb.Vector)
+	}
+	if o.blobThreshold > 0 && len(emb.Vector) >= o.blobThreshold {
+		res, err := client.PutBlob(ctx, &cxdb.PutBlobRequest{Data: types.EncodeVector(emb.Vector)})
+		if err != nil {
+			return nil, fmt.Errorf("attach embedding: %w", err)
+		}
+		emb.BlobHash = hex.EncodeToString(res.Hash[:])
+		emb.Encoding = types.VectorEncodingF32LE
+		emb.Vector = nil
+	}
+
+	b := types.BuildAnnotation(targetTurnID).WithEmbedding(emb)
+	target := contextID
+	if o.sidecar != 0 {
+		b.WithTargetContext(contextID)
+		target = o.sidecar
+	}
+	payload, err := cxdb.EncodeMsgpack(b.Build())
+	if err != nil {
+		return nil, fmt.Errorf("attach embedding: %w", err)
+	}
+	res, err := client.AppendTurn(ctx, &cxdb.AppendRequest{
+		ContextID:      target,
+		TypeID:         types.TypeIDConversationItem,
+		TypeVersion:    types.TypeVersionConversationItem,
+		Payload:        payload,
+		IdempotencyKey: fmt.Sprintf("embedding:%d:%d:%s", contextID, targetTurnID, emb.Model),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("attach embedding: %w", err)
+	}
+	return res, nil
+}
+
+// =============================================================================
+// Batch Driver
+// =============================================================================
+
+// Result summarizes a Driver run.
+type Result struct {
+	// Scanned is the number of canonical turns read.
+	Scanned int
+
+	// Skipped counts turns with no embeddable text or an existing embedding.
+	Skipped int
+
+	// Embedded counts annotations appended.
+	Embedded int
+}
+
+// Driver embeds every turn of a context that lacks an embedding for the
+// Embedder's model.
+type Driver struct {
+	client   *cxdb.Client
+	embedder Embedder
+	opts     options
+}
+
+// NewDriver creates a batch embedding driver.
+func NewDriver(client *cxdb.Client, embedder Embedder, opts ...Option) *Driver {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Driver{client: client, embedder: embedder, opts: o}
+}
+
+// Run embeds pending turns of contextID. It is safe to re-run: turns that
+// already carry an embedding for the model are skipped, and appends use
+// idempotency keys.
+func (d *Driver) Run(ctx context.Context, contextID uint64) (*Result, error) {
+	entries, err := d.read(ctx, contextID)
+	if err != nil {
+		return nil, fmt.Errorf("embed context %d: %w", contextID, err)
+	}
+	existing := entries
+	if d.opts.sidecar != 0 {
+		if existing, err = d.read(ctx, d.opts.sidecar); err != nil {
+			return nil, fmt.Errorf("embed context %d: %w", contextID, err)
+		}
+	}
+
+	pending := Pending(entries, existing, d.embedder.Model(), d.opts.minChars)
+	res := &Result{Scanned: len(entries) - countAnnotations(entries)}
+	res.Skipped = res.Scanned - len(pending)
+
+	for start := 0; start < len(pending); start += d.opts.batchSize {
+		end := start + d.opts.batchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+		batch := pending[start:end]
+		texts := make([]string, len(batch))
+		for i, p := range batch {
+			texts[i] = p.Text
+		}
+		vectors, err := d.embedder.Embed(ctx, texts)
+		if err != nil {
+			return res, fmt.Errorf("embed context %d: %w", contextID, err)
+		}
+		if len(vectors) != len(batch) {
+			return res, fmt.Errorf("%w: got %d, want %d", ErrVectorCount, len(vectors), len(batch))
+		}
+		for i, p := range batch {
+			emb := types.Embedding{
+				Model:      d.embedder.Model(),
+				Dims:       len(vectors[i]),
+				Vector:     vectors[i],
+				SourceHash: SourceHash(p.Text),
+			}
+			if _, err := attach(ctx, d.client, contextID, p.TurnID, emb, d.opts); err != nil {
+				return res, fmt.Errorf("embed turn %d: %w", p.TurnID, err)
+			}
+			res.Embedded++
+		}
+	}
+	return res, nil
+}
+
+func (d *Driver) read(ctx context.Context, contextID uint64) ([]window.Entry, error) {
+	turns, err := d.client.GetLast(ctx, contextID, cxdb.GetLastOptions{Limit: d.opts.maxTurns, IncludePayload: true})
+	if err != nil {
+		return nil, err
+	}
+	return window.Decode(turns)
+}
+
+// PendingTurn is a turn that still needs an embedding.
+type PendingTurn struct {
+	TurnID uint64
+	Text   string
+}
+
+// Pending returns the turns in entries that have embeddable text of at least
+// minChars and no embedding for model among the annotations in existing.
+// existing is usually entries itself, or the sidecar context's entries.
+func Pending(entries, existing []window.Entry, model string, minChars int) []PendingTurn {
+	done := make(map[uint64]bool)
+	for _, e := range existing {
+		a := e.Item.Annotation
+		if e.Item.ItemType != types.ItemTypeAnnotation || a == nil {
+			continue
+		}
+		for _, emb := range a.Embeddings {
+			if emb.Model == model {
+				done[a.TargetTurnID] = true
+			}
+		}
+	}
+
+	var out []PendingTurn
+	for _, e := range entries {
+		if e.Item.ItemType == types.ItemTypeAnnotation || done[e.TurnID] {
+			continue
+		}
+		text := Text(e.Item)
+		if text == "" || len(text) < minChars {
+			continue
+		}
+		out = append(out, PendingTurn{TurnID: e.TurnID, Text: text})
+	}
+	return out
+}
+
+// Text extracts the embeddable text of an item: user input, assistant
+// text, or system content. Tool output is not embedded.
+func Text(item *types.ConversationItem) string {
+	switch {
+	case item.UserInput != nil:
+		return strings.TrimSpace(item.UserInput.Text)
+	case item.Turn != nil:
+		return strings.TrimSpace(item.Turn.Text)
+	case item.Assistant != nil:
+		return strings.TrimSpace(item.Assistant.Text)
+	case item.System != nil:
+		return strings.TrimSpace(item.System.Content)
+	}
+	return ""
+}
+
+// SourceHash is the hex BLAKE3-256 hash recorded as Embedding.SourceHash.
+func SourceHash(text string) string {
+	sum := blake3.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+func countAnnotations(entries []window.Entry) int {
+	n := 0
+	for _, e := range entries {
+		if e.Item.ItemType == types.ItemTypeAnnotation {
+			n++
+		}
+	}
+	return n
+}
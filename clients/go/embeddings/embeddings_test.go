@@ -0,0 +1,91 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package embeddings
+
+import (
+	"testing"
+
+	cxdb "github.com/strongdm/ai-cxdb/clients/go"
+	"github.com/strongdm/ai-cxdb/clients/go/types"
+	"github.com/strongdm/ai-cxdb/clients/go/window"
+)
+
+func entry(turnID uint64, item *types.ConversationItem) window.Entry {
+	return window.Entry{TurnID: turnID, Depth: uint32(turnID), Item: item}
+}
+
+func TestPendingSkipsEmbeddedAndEmpty(t *testing.T) {
+	entries := []window.Entry{
+		entry(1, types.NewUserInput("what is cxdb?")),
+		entry(2, types.NewAssistantTurn("a context store")),
+		entry(3, types.NewUserInput("   ")),
+		entry(4, types.NewEmbeddingAnnotation(1, "m1", []float32{1, 2})),
+		entry(5, types.NewEmbeddingAnnotation(2, "other", []float32{1, 2})),
+	}
+
+	got := Pending(entries, entries, "m1", 1)
+	if len(got) != 1 || got[0].TurnID != 2 || got[0].Text != "a context store" {
+		t.Fatalf("Pending = %+v, want only turn 2", got)
+	}
+
+	if got := Pending(entries, entries, "m1", 100); len(got) != 0 {
+		t.Errorf("Pending with minChars=100 = %+v, want none", got)
+	}
+}
+
+func TestPendingUsesSidecar(t *testing.T) {
+	entries := []window.Entry{
+		entry(1, types.NewUserInput("hello")),
+		entry(2, types.NewUserInput("world")),
+	}
+	sidecar := []window.Entry{
+		entry(10, types.BuildAnnotation(2).WithTargetContext(7).
+			WithEmbedding(types.Embedding{Model: "m1", Dims: 2, Vector: []float32{0, 1}}).Build()),
+	}
+	got := Pending(entries, sidecar, "m1", 1)
+	if len(got) != 1 || got[0].TurnID != 1 {
+		t.Fatalf("Pending = %+v, want only turn 1", got)
+	}
+}
+
+func TestAnnotationRoundTrip(t *testing.T) {
+	item := types.BuildAnnotation(42).
+		WithNote("reviewed").
+		WithEmbedding(types.Embedding{Model: "m1", Dims: 3, Vector: []float32{0.5, -1, 3.25}, SourceHash: SourceHash("x")}).
+		Build()
+	data, err := cxdb.EncodeMsgpack(item)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	var got types.ConversationItem
+	if err := cxdb.DecodeMsgpackInto(data, &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.ItemType != types.ItemTypeAnnotation || got.Annotation == nil {
+		t.Fatalf("decoded item = %+v", got)
+	}
+	a := got.Annotation
+	if a.TargetTurnID != 42 || a.Note != "reviewed" || len(a.Embeddings) != 1 {
+		t.Fatalf("annotation = %+v", a)
+	}
+	if v := a.Embeddings[0].Vector; len(v) != 3 || v[1] != -1 || v[2] != 3.25 {
+		t.Errorf("vector = %v", v)
+	}
+}
+
+func TestVectorEncoding(t *testing.T) {
+	in := []float32{0, 1.5, -2.25, 1e-7}
+	out, err := types.DecodeVector(types.EncodeVector(in))
+	if err != nil {
+		t.Fatalf("DecodeVector: %v", err)
+	}
+	for i := range in {
+		if out[i] != in[i] {
+			t.Errorf("out[%d] = %v, want %v", i, out[i], in[i])
+		}
+	}
+	if _, err := types.DecodeVector([]byte{1, 2, 3}); err == nil {
+		t.Error("expected error for truncated vector")
+	}
+}
@@ -31,7 +31,12 @@ func DecodeMsgpack(data []byte) (map[uint64]any, error) {
 	return result, nil
 }
 
-// DecodeMsgpackInto decodes msgpack data into the provided value.
+// DecodeMsgpackInto decodes msgpack data into the provided value. Unknown
+// tags are ignored unless CXDB_STRICT_DECODE is set: "warn" logs what
+// DecodeMsgpackStrict would report and "error" returns it.
 func DecodeMsgpackInto(data []byte, v any) error {
-	return msgpack.Unmarshal(data, v)
+	if err := msgpack.Unmarshal(data, v); err != nil {
+		return err
+	}
+	return decodeStrictByEnv(data, v)
 }
@@ -4,8 +4,14 @@
 package cxdb
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
 )
 
 // Common errors
@@ -21,6 +27,10 @@ var (
 
 	// ErrInvalidResponse is returned when the server response is malformed.
 	ErrInvalidResponse = errors.New("cxdb: invalid response")
+
+	// ErrNotModified is returned by conditional reads when the context head
+	// is still the turn given in GetLastOptions.IfNoneMatch.
+	ErrNotModified = errors.New("cxdb: not modified")
 )
 
 // ServerError represents an error returned by the CXDB server.
@@ -41,3 +51,154 @@ func IsServerError(err error, code uint32) bool {
 	}
 	return false
 }
+
+// Capability errors. The server reports these as ServerErrors; the client
+// wraps them in the typed errors below so callers can fall back without
+// parsing the detail text. errors.Is matches the sentinel and IsServerError
+// still matches the server code.
+var (
+	// ErrPayloadTooLarge means a request exceeded a server size limit.
+	ErrPayloadTooLarge = errors.New("cxdb: payload too large")
+
+	// ErrUnsupportedEncoding means the server does not know a payload
+	// encoding or compression.
+	ErrUnsupportedEncoding = errors.New("cxdb: unsupported encoding")
+
+	// ErrUnsupportedFlags means the server rejected request flag bits it
+	// does not implement.
+	ErrUnsupportedFlags = errors.New("cxdb: unsupported request flags")
+)
+
+// PayloadTooLargeError reports a request over a server size limit. Size and
+// Limit are in bytes and zero when the server did not say.
+type PayloadTooLargeError struct {
+	Size   uint64
+	Limit  uint64
+	Server *ServerError
+}
+
+func (e *PayloadTooLargeError) Error() string {
+	if e.Limit == 0 {
+		return fmt.Sprintf("%v: %s", ErrPayloadTooLarge, e.Server.Detail)
+	}
+	return fmt.Sprintf("%v: %d bytes exceeds the limit of %d", ErrPayloadTooLarge, e.Size, e.Limit)
+}
+
+func (e *PayloadTooLargeError) Unwrap() []error { return []error{ErrPayloadTooLarge, e.Server} }
+
+// UnsupportedEncodingError reports an encoding or compression the server
+// does not know. The field the server named is set; the other is empty.
+type UnsupportedEncodingError struct {
+	Encoding    string
+	Compression string
+	Server      *ServerError
+}
+
+func (e *UnsupportedEncodingError) Error() string {
+	switch {
+	case e.Compression != "":
+		return fmt.Sprintf("%v: compression %s", ErrUnsupportedEncoding, e.Compression)
+	case e.Encoding != "":
+		return fmt.Sprintf("%v: encoding %s", ErrUnsupportedEncoding, e.Encoding)
+	}
+	return fmt.Sprintf("%v: %s", ErrUnsupportedEncoding, e.Server.Detail)
+}
+
+func (e *UnsupportedEncodingError) Unwrap() []error {
+	return []error{ErrUnsupportedEncoding, e.Server}
+}
+
+// UnsupportedFlagsError reports request flag bits the server rejected.
+// Supported is the set the server implements, zero when it did not say.
+type UnsupportedFlagsError struct {
+	Flags     uint16
+	Supported uint16
+	Server    *ServerError
+}
+
+func (e *UnsupportedFlagsError) Error() string {
+	if e.Flags == 0 {
+		return fmt.Sprintf("%v: %s", ErrUnsupportedFlags, e.Server.Detail)
+	}
+	return fmt.Sprintf("%v: 0x%04x", ErrUnsupportedFlags, e.Flags)
+}
+
+func (e *UnsupportedFlagsError) Unwrap() []error { return []error{ErrUnsupportedFlags, e.Server} }
+
+// Servers that predate structured details say the same things in plain text.
+var (
+	tooLargeText    = regexp.MustCompile(`(\d+)(?: bytes)? exceeds (?:the )?(?:maximum|max|limit)(?: of)? (\d+)`)
+	encodingText    = regexp.MustCompile(`unsupported (encoding|compression):? ([\w.-]+)`)
+	flagsText       = regexp.MustCompile(`unsupported (?:request )?flags?:? (0x[0-9a-fA-F]+|\d+)`)
+	structuredCodes = map[string]bool{"PAYLOAD_TOO_LARGE": true, "UNSUPPORTED_ENCODING": true, "UNSUPPORTED_FLAGS": true}
+)
+
+// errorDetail is the structured form of an ERROR detail, see
+// docs/protocol.md.
+type errorDetail struct {
+	Code    string `json:"code"`
+	Details struct {
+		Size        uint64 `json:"size"`
+		Limit       uint64 `json:"limit"`
+		Encoding    any    `json:"encoding"`
+		Compression any    `json:"compression"`
+		Flags       uint16 `json:"flags"`
+		Supported   uint16 `json:"supported"`
+	} `json:"details"`
+}
+
+// classifyServerError returns the typed error for se, or se itself. sent
+// are the flags of the rejected request, blamed when the server rejects
+// flags without naming them.
+func classifyServerError(se *ServerError, sent uint16) error {
+	var d errorDetail
+	if strings.HasPrefix(strings.TrimSpace(se.Detail), "{") &&
+		json.Unmarshal([]byte(se.Detail), &d) == nil && structuredCodes[d.Code] {
+		switch d.Code {
+		case "PAYLOAD_TOO_LARGE":
+			return &PayloadTooLargeError{Size: d.Details.Size, Limit: d.Details.Limit, Server: se}
+		case "UNSUPPORTED_ENCODING":
+			return &UnsupportedEncodingError{Encoding: detailString(d.Details.Encoding), Compression: detailString(d.Details.Compression), Server: se}
+		default:
+			flags := d.Details.Flags
+			if flags == 0 {
+				flags = sent
+			}
+			return &UnsupportedFlagsError{Flags: flags, Supported: d.Details.Supported, Server: se}
+		}
+	}
+
+	if se.Code < 400 || se.Code >= 500 || se.Code == wire.CodeNotFound {
+		return se
+	}
+	detail := strings.ToLower(se.Detail)
+	if m := tooLargeText.FindStringSubmatch(detail); m != nil || se.Code == wire.CodePayloadTooLarge {
+		e := &PayloadTooLargeError{Server: se}
+		if m != nil {
+			e.Size, _ = strconv.ParseUint(m[1], 10, 64)
+			e.Limit, _ = strconv.ParseUint(m[2], 10, 64)
+		}
+		return e
+	}
+	if m := encodingText.FindStringSubmatch(detail); m != nil {
+		if m[1] == "compression" {
+			return &UnsupportedEncodingError{Compression: m[2], Server: se}
+		}
+		return &UnsupportedEncodingError{Encoding: m[2], Server: se}
+	}
+	if m := flagsText.FindStringSubmatch(detail); m != nil {
+		flags, err := strconv.ParseUint(m[1], 0, 16)
+		if err != nil || flags == 0 {
+			flags = uint64(sent)
+		}
+		return &UnsupportedFlagsError{Flags: uint16(flags), Server: se}
+	}
+	return se
+}
+
+func detailString(v any) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,129 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
+)
+
+func TestClassifyServerError(t *testing.T) {
+	tests := []struct {
+		name   string
+		code   uint32
+		detail string
+		sent   uint16
+		want   error
+	}{
+		{"frame size", 422, "frame size 70000000 exceeds maximum 67108864", 0,
+			&PayloadTooLargeError{Size: 70000000, Limit: 67108864}},
+		{"bare 413", 413, "too big", 0, &PayloadTooLargeError{}},
+		{"structured size", 422, `{"code":"PAYLOAD_TOO_LARGE","message":"payload too large","details":{"size":10,"limit":4}}`, 0,
+			&PayloadTooLargeError{Size: 10, Limit: 4}},
+		{"compression", 422, "unsupported compression: 7", 0, &UnsupportedEncodingError{Compression: "7"}},
+		{"structured encoding", 422, `{"code":"UNSUPPORTED_ENCODING","details":{"encoding":9}}`, 0,
+			&UnsupportedEncodingError{Encoding: "9"}},
+		{"flags", 400, "unsupported request flags 0x8", 0x18, &UnsupportedFlagsError{Flags: 0x8}},
+		{"structured flags", 422, `{"code":"UNSUPPORTED_FLAGS","details":{"supported":7}}`, 0x10,
+			&UnsupportedFlagsError{Flags: 0x10, Supported: 7}},
+		{"not found", 404, "blob 12 exceeds maximum 3", 0, nil},
+		{"other", 422, "invalid type_id", 0, nil},
+		{"other structured", 409, `{"code":"HASH_MISMATCH"}`, 0, nil},
+	}
+	for _, tt := range tests {
+		se := &ServerError{Code: tt.code, Detail: tt.detail}
+		got := classifyServerError(se, tt.sent)
+		if !IsServerError(got, tt.code) {
+			t.Errorf("%s: %v does not match code %d", tt.name, got, tt.code)
+		}
+		switch want := tt.want.(type) {
+		case nil:
+			if got != se {
+				t.Errorf("%s: got %#v, want the plain ServerError", tt.name, got)
+			}
+		case *PayloadTooLargeError:
+			var e *PayloadTooLargeError
+			if !errors.As(got, &e) || !errors.Is(got, ErrPayloadTooLarge) || e.Size != want.Size || e.Limit != want.Limit {
+				t.Errorf("%s: got %#v, want %+v", tt.name, got, want)
+			}
+		case *UnsupportedEncodingError:
+			var e *UnsupportedEncodingError
+			if !errors.As(got, &e) || !errors.Is(got, ErrUnsupportedEncoding) || e.Encoding != want.Encoding || e.Compression != want.Compression {
+				t.Errorf("%s: got %#v, want %+v", tt.name, got, want)
+			}
+		case *UnsupportedFlagsError:
+			var e *UnsupportedFlagsError
+			if !errors.As(got, &e) || !errors.Is(got, ErrUnsupportedFlags) || e.Flags != want.Flags || e.Supported != want.Supported {
+				t.Errorf("%s: got %#v, want %+v", tt.name, got, want)
+			}
+		}
+	}
+}
+
+func TestAppendTooLargeFallsBackToBlob(t *testing.T) {
+	const limit = 512
+	store := &memStore{blobs: make(map[[32]byte][]byte)}
+	var rejected int
+	c := helloClient(t, func(msgType uint16, p []byte) (uint16, uint16, []byte) {
+		if msgType == wire.MsgAppend && len(p) > limit {
+			rejected++
+			return wire.MsgError, 0, wire.AppendError(nil, wire.CodeInvalidInput, "frame size 1100 exceeds maximum 512")
+		}
+		return store.handle(msgType, p)
+	})
+	ctx := context.Background()
+
+	big := bytes.Repeat([]byte("x"), 1000)
+	for i := 0; i < 2; i++ {
+		if _, err := c.AppendTurn(ctx, &AppendRequest{ContextID: 1, TypeID: "t", TypeVersion: 1, Payload: big}); err != nil {
+			t.Fatalf("AppendTurn %d: %v", i, err)
+		}
+	}
+	if rejected != 1 {
+		t.Errorf("server rejected %d appends, want only the first before the limit was learnt", rejected)
+	}
+	for i, turn := range store.turns {
+		if turn.Encoding != EncodingBlobRef {
+			t.Errorf("turn %d stored inline", i)
+		}
+	}
+
+	small := []byte("fits")
+	if _, err := c.AppendTurn(ctx, &AppendRequest{ContextID: 1, TypeID: "t", TypeVersion: 1, Payload: small}); err != nil {
+		t.Fatal(err)
+	}
+	if got := store.turns[2]; got.Encoding != EncodingMsgpack || !bytes.Equal(got.Payload, small) {
+		t.Errorf("small payload stored as encoding %d", got.Encoding)
+	}
+}
+
+func TestGetLastRejectedFlag(t *testing.T) {
+	le := binary.LittleEndian
+	var conditional int
+	c := pipeClient(t, func(msgType uint16, p []byte) (uint16, uint16, []byte) {
+		if len(p) > 16 {
+			conditional++
+			return wire.MsgError, 0, wire.AppendError(nil, wire.CodeInvalidInput, "unsupported flags: 0x8")
+		}
+		return msgType, 0, le.AppendUint32(nil, 0)
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetLast(ctx, 1, GetLastOptions{IfNoneMatch: 3}); err != nil {
+			t.Fatalf("GetLast %d: %v", i, err)
+		}
+	}
+	if conditional != 1 {
+		t.Errorf("sent %d conditional reads, want 1", conditional)
+	}
+	if c.UnsupportedFlags() != RequestFlagIfHeadChanged {
+		t.Errorf("UnsupportedFlags() = %#x", c.UnsupportedFlags())
+	}
+}
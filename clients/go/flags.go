@@ -0,0 +1,122 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
+)
+
+// Frame flags. Every frame header carries a 16-bit flags field; requests and
+// responses use separate bit assignments. New bits are appended, never
+// reused, so older peers can ignore bits they do not know.
+
+// Request flags.
+const (
+	// RequestFlagHasFsRoot marks an append payload that ends with a 32-byte
+	// filesystem root hash.
+	RequestFlagHasFsRoot = wire.FlagHasFsRoot
+
+	// RequestFlagBlobRange marks a GET_BLOB payload that carries a byte
+	// range after the hash.
+	RequestFlagBlobRange = wire.FlagBlobRange
+
+	// RequestFlagBlobProbe marks a PUT_BLOB payload that carries only the
+	// hash and length, asking whether the server already has the blob.
+	RequestFlagBlobProbe = wire.FlagBlobProbe
+
+	// RequestFlagIfHeadChanged marks a GET_LAST payload that ends with the
+	// head turn ID the client last saw.
+	RequestFlagIfHeadChanged = wire.FlagIfHeadChanged
+
+	// RequestFlagTreeFormat marks an ATTACH_FS payload that ends with the
+	// tree format version.
+	RequestFlagTreeFormat = wire.FlagTreeFormat
+
+	// RequestFlagRoutingKey marks a request payload that starts with a
+	// routing key, see WithRoutingHint.
+	RequestFlagRoutingKey = wire.FlagRoutingKey
+)
+
+// ResponseFlags are the flag bits of a server response frame.
+type ResponseFlags uint16
+
+const (
+	// ResponseFlagTruncated means the result set was cut short by a server
+	// limit; fewer records were returned than requested or available.
+	ResponseFlagTruncated = ResponseFlags(wire.FlagTruncated)
+
+	// ResponseFlagInheritedFs means the turn's filesystem snapshot was
+	// inherited from an ancestor rather than attached to the turn itself.
+	ResponseFlagInheritedFs = ResponseFlags(wire.FlagInheritedFs)
+
+	// ResponseFlagDeprecated means the request used a deprecated message
+	// form that a future server may reject.
+	ResponseFlagDeprecated = ResponseFlags(wire.FlagDeprecated)
+
+	// ResponseFlagNotModified means a conditional GET_LAST found the head
+	// unchanged and returned no turns.
+	ResponseFlagNotModified = ResponseFlags(wire.FlagNotModified)
+
+	responseFlagsKnown = ResponseFlagTruncated | ResponseFlagInheritedFs | ResponseFlagDeprecated | ResponseFlagNotModified
+)
+
+var responseFlagNames = []struct {
+	flag ResponseFlags
+	name string
+}{
+	{ResponseFlagTruncated, "truncated"},
+	{ResponseFlagInheritedFs, "inherited_fs"},
+	{ResponseFlagDeprecated, "deprecated"},
+	{ResponseFlagNotModified, "not_modified"},
+}
+
+// Has reports whether every bit of flag is set.
+func (f ResponseFlags) Has(flag ResponseFlags) bool {
+	return f&flag == flag
+}
+
+// Truncated reports whether ResponseFlagTruncated is set.
+func (f ResponseFlags) Truncated() bool {
+	return f.Has(ResponseFlagTruncated)
+}
+
+// InheritedFs reports whether ResponseFlagInheritedFs is set.
+func (f ResponseFlags) InheritedFs() bool {
+	return f.Has(ResponseFlagInheritedFs)
+}
+
+// Deprecated reports whether ResponseFlagDeprecated is set.
+func (f ResponseFlags) Deprecated() bool {
+	return f.Has(ResponseFlagDeprecated)
+}
+
+// NotModified reports whether ResponseFlagNotModified is set.
+func (f ResponseFlags) NotModified() bool {
+	return f.Has(ResponseFlagNotModified)
+}
+
+// Unknown returns the bits this client does not recognize.
+func (f ResponseFlags) Unknown() ResponseFlags {
+	return f &^ responseFlagsKnown
+}
+
+// String lists the set flags, e.g. "truncated|deprecated|0x100".
+func (f ResponseFlags) String() string {
+	if f == 0 {
+		return "none"
+	}
+	var parts []string
+	for _, n := range responseFlagNames {
+		if f.Has(n.flag) {
+			parts = append(parts, n.name)
+		}
+	}
+	if u := f.Unknown(); u != 0 {
+		parts = append(parts, fmt.Sprintf("%#x", uint16(u)))
+	}
+	return strings.Join(parts, "|")
+}
@@ -0,0 +1,163 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
+)
+
+func TestResponseFlags(t *testing.T) {
+	tests := []struct {
+		flags ResponseFlags
+		want  string
+	}{
+		{0, "none"},
+		{ResponseFlagTruncated, "truncated"},
+		{ResponseFlagInheritedFs | ResponseFlagDeprecated, "inherited_fs|deprecated"},
+		{ResponseFlagTruncated | 1<<8, "truncated|0x100"},
+		{ResponseFlagNotModified, "not_modified"},
+	}
+	for _, tt := range tests {
+		if got := tt.flags.String(); got != tt.want {
+			t.Errorf("ResponseFlags(%#x).String() = %q, want %q", uint16(tt.flags), got, tt.want)
+		}
+	}
+
+	f := ResponseFlagTruncated | 1<<8
+	if !f.Truncated() || f.InheritedFs() || f.Deprecated() {
+		t.Errorf("accessors wrong for %v", f)
+	}
+	if f.Unknown() != 1<<8 {
+		t.Errorf("Unknown() = %#x, want 0x100", uint16(f.Unknown()))
+	}
+}
+
+// fakeHandler answers one request frame.
+type fakeHandler func(msgType uint16, payload []byte) (respType, flags uint16, resp []byte)
+
+// dropConn, returned as the response type, makes serveFrames close the
+// connection without answering, as the server does when it cannot parse a
+// request payload.
+const dropConn uint16 = 0xffff
+
+// serveFrames answers requests on conn with handler until conn closes.
+func serveFrames(conn net.Conn, handler fakeHandler) {
+	go func() {
+		for {
+			header := make([]byte, 16)
+			if _, err := io.ReadFull(conn, header); err != nil {
+				return
+			}
+			req := make([]byte, binary.LittleEndian.Uint32(header[0:4]))
+			if _, err := io.ReadFull(conn, req); err != nil {
+				return
+			}
+			respType, flags, payload := handler(binary.LittleEndian.Uint16(header[4:6]), req)
+			if respType == dropConn {
+				_ = conn.Close()
+				return
+			}
+			resp := make([]byte, 16, 16+len(payload))
+			binary.LittleEndian.PutUint32(resp[0:4], uint32(len(payload)))
+			binary.LittleEndian.PutUint16(resp[4:6], respType)
+			binary.LittleEndian.PutUint16(resp[6:8], flags)
+			copy(resp[8:16], header[8:16])
+			if _, err := conn.Write(append(resp, payload...)); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+// pipeClient returns a client connected to handler.
+func pipeClient(t *testing.T, handler fakeHandler) *Client {
+	t.Helper()
+	clientConn, serverConn := net.Pipe()
+	serveFrames(serverConn, handler)
+	c := &Client{transport: NewStreamTransport(clientConn), timeout: 5 * time.Second, usage: newUsageTracker()}
+	t.Cleanup(func() {
+		_ = c.Close()
+		_ = serverConn.Close()
+	})
+	return c
+}
+
+func TestResponseFlagsSurfaced(t *testing.T) {
+	c := pipeClient(t, func(msgType uint16, _ []byte) (uint16, uint16, []byte) {
+		if msgType == wire.MsgGetHead {
+			head := make([]byte, 20)
+			binary.LittleEndian.PutUint64(head[0:8], 7)
+			return msgType, uint16(ResponseFlagDeprecated), head
+		}
+		return msgType, uint16(ResponseFlagTruncated), make([]byte, 4)
+	})
+
+	got, err := c.GetHead(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("GetHead: %v", err)
+	}
+	if got.ContextID != 7 || !got.Flags.Deprecated() {
+		t.Errorf("head = %+v", got)
+	}
+
+	page, err := c.GetLastPage(context.Background(), 7, GetLastOptions{Limit: 100})
+	if err != nil {
+		t.Fatalf("GetLastPage: %v", err)
+	}
+	if len(page.Turns) != 0 || !page.Flags.Truncated() {
+		t.Errorf("page = %+v", page)
+	}
+}
+
+func TestGetLastIfNoneMatch(t *testing.T) {
+	le := binary.LittleEndian
+	head := uint64(5)
+	turns := func() []byte {
+		rec := le.AppendUint32(nil, 1)
+		rec = le.AppendUint64(rec, head)
+		rec = le.AppendUint64(rec, head-1)
+		rec = le.AppendUint32(rec, uint32(head))
+		rec = le.AppendUint32(rec, 0) // type id
+		rec = le.AppendUint32(rec, 1)
+		rec = le.AppendUint32(rec, wire.EncodingMsgpack)
+		rec = le.AppendUint32(rec, wire.CompressionNone)
+		rec = le.AppendUint32(rec, 1)
+		rec = append(rec, make([]byte, 32)...)
+		return append(le.AppendUint32(rec, 1), 0xc0) // msgpack nil
+	}
+	c := pipeClient(t, func(msgType uint16, p []byte) (uint16, uint16, []byte) {
+		if len(p) == 24 && le.Uint64(p[16:]) == head {
+			return msgType, uint16(ResponseFlagNotModified), le.AppendUint32(nil, 0)
+		}
+		return msgType, 0, turns()
+	})
+	ctx := context.Background()
+
+	got, err := c.GetLast(ctx, 1, GetLastOptions{IfNoneMatch: 4})
+	if err != nil || len(got) != 1 || got[0].TurnID != 5 {
+		t.Fatalf("changed head: %v, %+v", err, got)
+	}
+	if _, err := c.GetLastPage(ctx, 1, GetLastOptions{IfNoneMatch: 5}); !errors.Is(err, ErrNotModified) {
+		t.Errorf("unchanged head: err = %v, want ErrNotModified", err)
+	}
+	if usage := c.UsageStats().Contexts[1]; usage.Reads != 2 {
+		t.Errorf("Reads = %d, want 2", usage.Reads)
+	}
+
+	// Servers that ignore the flag send the turns anyway.
+	legacy := pipeClient(t, func(msgType uint16, _ []byte) (uint16, uint16, []byte) {
+		return msgType, 0, turns()
+	})
+	if _, err := legacy.GetLast(ctx, 1, GetLastOptions{IfNoneMatch: 5}); !errors.Is(err, ErrNotModified) {
+		t.Errorf("legacy server: err = %v, want ErrNotModified", err)
+	}
+}
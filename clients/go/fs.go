@@ -7,18 +7,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
 	"github.com/zeebo/blake3"
 )
 
-// Protocol message types for filesystem operations
-const (
-	msgAttachFs uint16 = 10
-	msgPutBlob  uint16 = 11
-)
-
 // AttachFsRequest contains parameters for attaching a filesystem snapshot to a turn.
 type AttachFsRequest struct {
 	// TurnID is the turn to attach the snapshot to.
@@ -26,22 +22,39 @@ type AttachFsRequest struct {
 
 	// FsRootHash is the BLAKE3-256 hash of the root tree object.
 	FsRootHash [32]byte
+
+	// TreeFormat is the format version of the snapshot's tree objects
+	// (fstree.Snapshot.TreeFormat), sent so the server can validate the
+	// trees it is given. It is sent only for formats after
+	// wire.TreeFormatV1 and only to servers that list wire.CapTreeFormat;
+	// otherwise the original payload is sent, which servers read as v1.
+	TreeFormat uint8
 }
 
 // AttachFsResult contains the result of an attach operation.
 type AttachFsResult struct {
 	TurnID     uint64
 	FsRootHash [32]byte
+
+	// Flags are the response frame flags.
+	Flags ResponseFlags
 }
 
 // AttachFs attaches a filesystem snapshot to an existing turn.
 // The tree objects and file blobs must already exist in the blob store.
+// With WithFsAttachVerify, a snapshot that was not recorded returns the
+// acknowledgement together with an *FsAttachError.
 func (c *Client) AttachFs(ctx context.Context, req *AttachFsRequest) (*AttachFsResult, error) {
 	payload := &bytes.Buffer{}
 	_ = binary.Write(payload, binary.LittleEndian, req.TurnID)
 	payload.Write(req.FsRootHash[:])
+	var flags uint16
+	if req.TreeFormat > wire.TreeFormatV1 && c.treeFormats {
+		flags = RequestFlagTreeFormat
+		payload.WriteByte(req.TreeFormat)
+	}
 
-	resp, err := c.sendRequest(ctx, msgAttachFs, payload.Bytes())
+	resp, err := c.sendRequestWithFlags(ctx, wire.MsgAttachFs, flags, payload.Bytes())
 	if err != nil {
 		return nil, fmt.Errorf("attach fs: %w", err)
 	}
@@ -52,9 +65,16 @@ func (c *Client) AttachFs(ctx context.Context, req *AttachFsRequest) (*AttachFsR
 
 	result := &AttachFsResult{
 		TurnID: binary.LittleEndian.Uint64(resp.payload[0:8]),
+		Flags:  ResponseFlags(resp.flags),
 	}
 	copy(result.FsRootHash[:], resp.payload[8:40])
 
+	if c.verifyFsAttach {
+		if err := c.checkFsAttach(ctx, req.TurnID, req.FsRootHash, result.TurnID, result.FsRootHash, result.Flags); err != nil {
+			return result, fmt.Errorf("attach fs: %w", err)
+		}
+	}
+
 	return result, nil
 }
 
@@ -71,6 +91,13 @@ type PutBlobResult struct {
 
 	// WasNew indicates whether this was a new blob (true) or already existed (false).
 	WasNew bool
+
+	// ContentSent is false when a hash-first probe (WithPutBlobHashFirst)
+	// found the blob on the server and the data was never sent.
+	ContentSent bool
+
+	// Flags are the response frame flags.
+	Flags ResponseFlags
 }
 
 // PutBlob stores a blob in the content-addressed store.
@@ -79,28 +106,72 @@ func (c *Client) PutBlob(ctx context.Context, req *PutBlobRequest) (*PutBlobResu
 	// Compute hash
 	hash := blake3.Sum256(req.Data)
 
+	if c.hashFirstMin > 0 && len(req.Data) >= c.hashFirstMin {
+		result, err := c.probePutBlob(ctx, hash, len(req.Data))
+		if err == nil {
+			return result, nil
+		}
+		if !errors.Is(err, errBlobAbsent) {
+			return nil, fmt.Errorf("put blob: %w", err)
+		}
+	}
+
 	payload := &bytes.Buffer{}
 	payload.Write(hash[:])
 	_ = binary.Write(payload, binary.LittleEndian, uint32(len(req.Data)))
 	payload.Write(req.Data)
 
-	resp, err := c.sendRequest(ctx, msgPutBlob, payload.Bytes())
+	resp, err := c.sendRequest(ctx, wire.MsgPutBlob, payload.Bytes())
 	if err != nil {
 		return nil, fmt.Errorf("put blob: %w", err)
 	}
+	c.usage.record(usageContextID(ctx), ContextUsage{BlobsUploaded: 1, BlobBytesUploaded: uint64(frameHeaderSize + payload.Len())})
+
+	result, err := parsePutBlobResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	result.ContentSent = true
+	return result, nil
+}
 
+func parsePutBlobResponse(resp *frame) (*PutBlobResult, error) {
 	if len(resp.payload) < 33 {
 		return nil, fmt.Errorf("%w: put blob response too short (%d bytes)", ErrInvalidResponse, len(resp.payload))
 	}
 
 	result := &PutBlobResult{
 		WasNew: resp.payload[32] == 1,
+		Flags:  ResponseFlags(resp.flags),
 	}
 	copy(result.Hash[:], resp.payload[0:32])
 
 	return result, nil
 }
 
+// GetBlob fetches a blob by its BLAKE3-256 hash. The content is verified
+// against the hash.
+func (c *Client) GetBlob(ctx context.Context, hash [32]byte) ([]byte, error) {
+	resp, err := c.sendRequest(ctx, wire.MsgGetBlob, hash[:])
+	if err != nil {
+		return nil, fmt.Errorf("get blob: %w", err)
+	}
+	c.usage.record(usageContextID(ctx), ContextUsage{BytesRead: uint64(frameHeaderSize + len(resp.payload))})
+
+	if len(resp.payload) < 4 {
+		return nil, fmt.Errorf("%w: get blob response too short (%d bytes)", ErrInvalidResponse, len(resp.payload))
+	}
+	n := binary.LittleEndian.Uint32(resp.payload[0:4])
+	if uint64(n) != uint64(len(resp.payload)-4) {
+		return nil, fmt.Errorf("%w: get blob length %d, payload has %d bytes", ErrInvalidResponse, n, len(resp.payload)-4)
+	}
+	data := resp.payload[4:]
+	if blake3.Sum256(data) != hash {
+		return nil, fmt.Errorf("%w: get blob content does not match hash", ErrInvalidResponse)
+	}
+	return data, nil
+}
+
 // PutBlobIfAbsent stores a blob only if it doesn't already exist.
 // Returns the hash and whether the blob was stored.
 func (c *Client) PutBlobIfAbsent(ctx context.Context, data []byte) ([32]byte, bool, error) {
@@ -113,7 +184,27 @@ func (c *Client) PutBlobIfAbsent(ctx context.Context, data []byte) ([32]byte, bo
 
 // AppendTurnWithFs appends a new turn with an optional filesystem snapshot.
 // If fsRootHash is non-nil, the filesystem snapshot will be attached to the turn.
+// With WithFsAttachVerify, a snapshot that was not recorded returns the
+// appended turn together with an *FsAttachError.
 func (c *Client) AppendTurnWithFs(ctx context.Context, req *AppendRequest, fsRootHash *[32]byte) (*AppendResult, error) {
+	if err := c.leases.check(req.ContextID, req.TypeID); err != nil {
+		return nil, fmt.Errorf("append turn: %w", err)
+	}
+	sent, err := c.externalizePayload(ctx, req, false)
+	if err != nil {
+		return nil, fmt.Errorf("append turn: %w", err)
+	}
+	result, err := c.appendTurnWithFs(ctx, sent, fsRootHash)
+	if retry, rerr := c.blobFallback(ctx, req, sent, err); rerr != nil {
+		return nil, rerr
+	} else if retry != nil {
+		result, err = c.appendTurnWithFs(ctx, retry, fsRootHash)
+	}
+	return result, err
+}
+
+// appendTurnWithFs sends one APPEND_TURN, flagged if fsRootHash is set.
+func (c *Client) appendTurnWithFs(ctx context.Context, req *AppendRequest, fsRootHash *[32]byte) (*AppendResult, error) {
 	encoding := req.Encoding
 	if encoding == 0 {
 		encoding = EncodingMsgpack
@@ -147,14 +238,15 @@ func (c *Client) AppendTurnWithFs(ctx context.Context, req *AppendRequest, fsRoo
 	// If fsRootHash is provided, append it and set flags
 	var flags uint16
 	if fsRootHash != nil {
-		flags = 1 // bit 0 = has_fs_root
+		flags = RequestFlagHasFsRoot
 		payload.Write(fsRootHash[:])
 	}
 
-	resp, err := c.sendRequestWithFlags(ctx, msgAppend, flags, payload.Bytes())
+	resp, err := c.sendRequestWithFlags(ctx, wire.MsgAppend, flags, payload.Bytes())
 	if err != nil {
 		return nil, fmt.Errorf("append turn: %w", err)
 	}
+	c.usage.record(req.ContextID, ContextUsage{Appends: 1, BytesAppended: uint64(frameHeaderSize + payload.Len())})
 
 	if len(resp.payload) < 52 {
 		return nil, fmt.Errorf("%w: append response too short (%d bytes)", ErrInvalidResponse, len(resp.payload))
@@ -164,14 +256,26 @@ func (c *Client) AppendTurnWithFs(ctx context.Context, req *AppendRequest, fsRoo
 		ContextID: binary.LittleEndian.Uint64(resp.payload[0:8]),
 		TurnID:    binary.LittleEndian.Uint64(resp.payload[8:16]),
 		Depth:     binary.LittleEndian.Uint32(resp.payload[16:20]),
+		Flags:     ResponseFlags(resp.flags),
 	}
 	copy(result.PayloadHash[:], resp.payload[20:52])
 
+	if c.verifyFsAttach && fsRootHash != nil {
+		if err := c.checkFsAttach(ctx, result.TurnID, *fsRootHash, result.TurnID, *fsRootHash, result.Flags); err != nil {
+			return result, fmt.Errorf("append turn: %w", err)
+		}
+	}
+
 	return result, nil
 }
 
 // sendRequestWithFlags is like sendRequest but allows setting custom flags.
-func (c *Client) sendRequestWithFlags(ctx context.Context, msgType uint16, flags uint16, payload []byte) (*frame, error) {
+func (c *Client) sendRequestWithFlags(ctx context.Context, msgType uint16, flags uint16, payload []byte) (resp *frame, err error) {
+	defer func() {
+		if isConnectionError(err) {
+			c.disconnected(DisconnectConnectionError, err)
+		}
+	}()
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -184,36 +288,33 @@ func (c *Client) sendRequestWithFlags(ctx context.Context, msgType uint16, flags
 	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
 		deadline = d
 	}
-	if err := c.conn.SetDeadline(deadline); err != nil {
+	if err := c.transport.SetDeadline(deadline); err != nil {
 		return nil, fmt.Errorf("set deadline: %w", err)
 	}
-	defer func() { _ = c.conn.SetDeadline(time.Time{}) }() // Clear deadline
+	defer func() { _ = c.transport.SetDeadline(time.Time{}) }() // Clear deadline
 
+	flags, payload, err = c.routeRequest(ctx, flags, payload)
+	if err != nil {
+		return nil, err
+	}
 	reqID := c.reqID.Add(1)
 
 	if err := c.writeFrameWithFlags(msgType, flags, reqID, payload); err != nil {
 		return nil, err
 	}
 
-	resp, err := c.readFrame()
+	resp, err = c.readFrame()
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.msgType == msgError {
-		return nil, parseServerError(resp.payload)
+	if resp.msgType == wire.MsgError {
+		return nil, c.serverError(resp.payload, flags)
 	}
 
 	return resp, nil
 }
 
 func (c *Client) writeFrameWithFlags(msgType uint16, flags uint16, reqID uint64, payload []byte) error {
-	header := &bytes.Buffer{}
-	_ = binary.Write(header, binary.LittleEndian, uint32(len(payload)))
-	_ = binary.Write(header, binary.LittleEndian, msgType)
-	_ = binary.Write(header, binary.LittleEndian, flags)
-	_ = binary.Write(header, binary.LittleEndian, reqID)
-
-	_, err := c.conn.Write(append(header.Bytes(), payload...))
-	return err
+	return c.transport.WriteFrame(wire.Header{Length: uint32(len(payload)), MsgType: msgType, Flags: flags, ReqID: reqID}, payload)
 }
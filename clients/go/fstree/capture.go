@@ -5,6 +5,7 @@ package fstree
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -12,6 +13,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/vmihailenco/msgpack/v5"
@@ -23,6 +25,10 @@ var (
 	ErrTooManyFiles = errors.New("fstree: too many files")
 	ErrFileTooLarge = errors.New("fstree: file too large")
 	ErrCyclicLink   = errors.New("fstree: cyclic symbolic link detected")
+
+	// ErrTreeFormat is returned for tree objects in an unknown format and
+	// for v2 trees that are not in canonical order.
+	ErrTreeFormat = errors.New("fstree: invalid tree object")
 )
 
 // Capture takes a snapshot of the filesystem at the given root path.
@@ -35,56 +41,133 @@ var (
 func Capture(root string, opts ...Option) (*Snapshot, error) {
 	start := time.Now()
 
-	// Resolve to absolute path
-	absRoot, err := filepath.Abs(root)
+	absRoot, _, err := statDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	b := newBuilder(absRoot, opts)
+	rootHash, err := b.buildTree(absRoot, "")
+	if err != nil {
+		return nil, err
+	}
+	return b.snapshot(rootHash, start), nil
+}
+
+// CaptureMulti snapshots several directories into one tree. The root of
+// the snapshot is a synthetic directory with one entry per mount, named by
+// the map key, so a single root hash covers e.g. a repository and a
+// scratch directory:
+//
+//	snap, err := fstree.CaptureMulti(map[string]string{
+//	    "repo":    "/home/agent/repo",
+//	    "scratch": "/tmp/agent-scratch",
+//	})
+//
+// Paths in the snapshot, and those matched by exclusion rules, start with
+// the mount name ("repo/src/main.go"). Options and limits such as
+// WithMaxFiles apply across all mounts. Mount names must be non-empty and
+// contain no path separators.
+func CaptureMulti(mounts map[string]string, opts ...Option) (*Snapshot, error) {
+	start := time.Now()
+	if len(mounts) == 0 {
+		return nil, errors.New("fstree: no mounts")
+	}
+
+	names := make([]string, 0, len(mounts))
+	for name := range mounts {
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+			return nil, fmt.Errorf("%w: mount %q", ErrUnsafeName, name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	b := newBuilder("", opts)
+	entries := make([]TreeEntry, 0, len(names))
+	for _, name := range names {
+		absPath, info, err := statDir(mounts[name])
+		if err != nil {
+			return nil, fmt.Errorf("mount %s: %w", name, err)
+		}
+		hash, err := b.buildTree(absPath, name)
+		if err != nil {
+			return nil, fmt.Errorf("mount %s: %w", name, err)
+		}
+		entries = append(entries, TreeEntry{
+			Name:    name,
+			Kind:    EntryKindDirectory,
+			Mode:    uint32(info.Mode().Perm()),
+			Hash:    hash,
+			ModTime: b.modTime(info),
+		})
+	}
+
+	treeBytes, err := serializeTree(entries, b.opts.treeFormat)
 	if err != nil {
-		return nil, fmt.Errorf("resolve root: %w", err)
+		return nil, fmt.Errorf("serialize mount root: %w", err)
 	}
+	rootHash := blake3.Sum256(treeBytes)
+	b.trees[rootHash] = treeBytes
+	b.dirCount++
 
-	// Check root exists and is a directory
+	return b.snapshot(rootHash, start), nil
+}
+
+// statDir resolves root to an absolute path and checks it is a directory.
+func statDir(root string) (string, fs.FileInfo, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", nil, fmt.Errorf("resolve root: %w", err)
+	}
 	info, err := os.Stat(absRoot)
 	if err != nil {
-		return nil, fmt.Errorf("stat root: %w", err)
+		return "", nil, fmt.Errorf("stat root: %w", err)
 	}
 	if !info.IsDir() {
-		return nil, fmt.Errorf("root is not a directory: %s", absRoot)
+		return "", nil, fmt.Errorf("root is not a directory: %s", absRoot)
 	}
+	return absRoot, info, nil
+}
 
-	// Apply options
+func newBuilder(root string, opts []Option) *builder {
 	o := defaultOptions()
 	for _, opt := range opts {
 		opt(o)
 	}
-
-	// Build the tree
-	b := &builder{
-		root:     absRoot,
+	return &builder{
+		root:     root,
 		opts:     o,
 		trees:    make(map[[32]byte][]byte),
 		files:    make(map[[32]byte]*FileRef),
 		symlinks: make(map[[32]byte]string),
 		visited:  make(map[string]bool), // for cycle detection with symlinks
+		io:       newThrottle(o),
 	}
+}
 
-	rootHash, err := b.buildTree(absRoot, "")
-	if err != nil {
-		return nil, err
+// snapshot assembles the result once the tree is built.
+func (b *builder) snapshot(rootHash [32]byte, start time.Time) *Snapshot {
+	if b.moreWarnings > 0 {
+		b.warnings = append(b.warnings, fmt.Sprintf("... and %d more", b.moreWarnings))
 	}
-
 	return &Snapshot{
 		RootHash:   rootHash,
+		TreeFormat: b.opts.treeFormat,
 		Trees:      b.trees,
 		Files:      b.files,
 		Symlinks:   b.symlinks,
 		CapturedAt: start,
 		Stats: SnapshotStats{
-			FileCount:    b.fileCount,
-			DirCount:     b.dirCount,
-			SymlinkCount: b.symlinkCount,
-			TotalBytes:   b.totalBytes,
-			Duration:     time.Since(start),
+			FileCount:     b.fileCount,
+			DirCount:      b.dirCount,
+			SymlinkCount:  b.symlinkCount,
+			TotalBytes:    b.totalBytes,
+			ExcludedCount: b.excludedCount,
+			Warnings:      b.warnings,
+			Duration:      time.Since(start),
 		},
-	}, nil
+	}
 }
 
 // builder accumulates state during tree construction.
@@ -95,11 +178,27 @@ type builder struct {
 	files    map[[32]byte]*FileRef
 	symlinks map[[32]byte]string // target path for symlinks
 	visited  map[string]bool     // resolved paths for cycle detection
+	io       *throttle
+
+	fileCount     int
+	dirCount      int
+	symlinkCount  int
+	totalBytes    uint64
+	excludedCount int
+	warnings      []string
+	moreWarnings  int
+}
+
+// maxWarnings bounds SnapshotStats.Warnings.
+const maxWarnings = 100
 
-	fileCount    int
-	dirCount     int
-	symlinkCount int
-	totalBytes   uint64
+// warn records a SnapshotStats warning.
+func (b *builder) warn(format string, args ...any) {
+	if len(b.warnings) >= maxWarnings {
+		b.moreWarnings++
+		return
+	}
+	b.warnings = append(b.warnings, fmt.Sprintf(format, args...))
 }
 
 // buildTree recursively builds the tree for a directory.
@@ -129,20 +228,20 @@ func (b *builder) buildTree(absPath, relPath string) ([32]byte, error) {
 		childRelPath := filepath.Join(relPath, name)
 		childAbsPath := filepath.Join(absPath, name)
 
-		// Check exclusions
-		if b.opts.shouldExclude(childRelPath, de.IsDir()) {
-			continue
-		}
-
 		// Get file info (follows symlinks if needed)
-		var info fs.FileInfo
-		if b.opts.followSymlinks {
-			info, err = os.Stat(childAbsPath)
-		} else {
-			info, err = os.Lstat(childAbsPath)
-		}
+		info, err := b.opts.stat(childAbsPath)
 		if err != nil {
 			// Skip files we can't stat (permission errors, etc.)
+			b.warn("skipped %s: %v", childRelPath, err)
+			continue
+		}
+
+		// Check exclusions
+		if e := b.opts.explain(childRelPath, entryKind(info), info.Size()); e.Excluded {
+			b.excludedCount++
+			if e.sizeLimited {
+				b.warn("skipped %s: %v: %s", childRelPath, ErrFileTooLarge, e.Rule)
+			}
 			continue
 		}
 
@@ -152,19 +251,21 @@ func (b *builder) buildTree(absPath, relPath string) ([32]byte, error) {
 				return [32]byte{}, err
 			}
 			// Skip individual files on error
+			b.warn("skipped %s: %v", childRelPath, err)
 			continue
 		}
 
 		entries = append(entries, entry)
 	}
 
-	// Sort entries by name for deterministic hashing
+	// Sort entries by name for deterministic hashing. Names are compared
+	// as bytes, independent of locale and Unicode normalization.
 	sort.Slice(entries, func(i, j int) bool {
 		return entries[i].Name < entries[j].Name
 	})
 
 	// Serialize and hash the tree object
-	treeBytes, err := serializeTree(entries)
+	treeBytes, err := serializeTree(entries, b.opts.treeFormat)
 	if err != nil {
 		return [32]byte{}, fmt.Errorf("serialize tree %s: %w", relPath, err)
 	}
@@ -210,11 +311,12 @@ func (b *builder) buildEntry(absPath, relPath, name string, info fs.FileInfo) (T
 		}
 
 		return TreeEntry{
-			Name: name,
-			Kind: EntryKindDirectory,
-			Mode: mode,
-			Size: 0,
-			Hash: dirHash,
+			Name:    name,
+			Kind:    EntryKindDirectory,
+			Mode:    mode,
+			Size:    0,
+			Hash:    dirHash,
+			ModTime: b.modTime(info),
 		}, nil
 
 	default:
@@ -224,11 +326,8 @@ func (b *builder) buildEntry(absPath, relPath, name string, info fs.FileInfo) (T
 		}
 
 		size := info.Size()
-		if size > b.opts.maxFileSize {
-			return TreeEntry{}, fmt.Errorf("%w: %s (%d bytes)", ErrFileTooLarge, relPath, size)
-		}
 
-		hash, err := hashFile(absPath)
+		hash, err := b.hashFile(absPath)
 		if err != nil {
 			return TreeEntry{}, fmt.Errorf("hash file %s: %w", relPath, err)
 		}
@@ -242,37 +341,57 @@ func (b *builder) buildEntry(absPath, relPath, name string, info fs.FileInfo) (T
 		b.totalBytes += uint64(size)
 
 		return TreeEntry{
-			Name: name,
-			Kind: EntryKindFile,
-			Mode: mode,
-			Size: uint64(size),
-			Hash: hash,
+			Name:    name,
+			Kind:    EntryKindFile,
+			Mode:    mode,
+			Size:    uint64(size),
+			Hash:    hash,
+			ModTime: b.modTime(info),
 		}, nil
 	}
 }
 
-// hashFile computes the BLAKE3-256 hash of a file's contents.
-func hashFile(path string) ([32]byte, error) {
+// modTime returns the ModTime to record for info, or 0 unless times are
+// preserved.
+func (b *builder) modTime(info fs.FileInfo) int64 {
+	if !b.opts.preserveTimes {
+		return 0
+	}
+	return info.ModTime().UnixNano()
+}
+
+// hashFile computes the BLAKE3-256 hash of a file's contents, paced by
+// the builder's throttle.
+func (b *builder) hashFile(path string) ([32]byte, error) {
+	start := time.Now()
 	f, err := os.Open(path)
 	if err != nil {
 		return [32]byte{}, err
 	}
 	defer func() { _ = f.Close() }()
 
+	ctx := context.Background()
 	h := blake3.New()
-	if _, err := io.Copy(h, f); err != nil {
+	if _, err := io.Copy(h, b.io.reader(ctx, f)); err != nil {
 		return [32]byte{}, err
 	}
 
 	var hash [32]byte
 	copy(hash[:], h.Sum(nil))
-	return hash, nil
+	return hash, b.io.fileDone(ctx, time.Since(start))
 }
 
-// serializeTree serializes a list of TreeEntry to msgpack.
-// Uses numeric field tags matching the TreeEntry struct tags.
-func serializeTree(entries []TreeEntry) ([]byte, error) {
+// serializeTree serializes a list of TreeEntry, sorted by name, in the
+// given format. Uses numeric field tags matching the TreeEntry struct tags.
+func serializeTree(entries []TreeEntry, format uint8) ([]byte, error) {
 	buf := &bytes.Buffer{}
+	switch format {
+	case TreeFormatV1:
+	case TreeFormatV2:
+		buf.WriteByte(TreeFormatV2)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %d", ErrTreeFormat, format)
+	}
 	enc := msgpack.NewEncoder(buf)
 	enc.SetSortMapKeys(true)
 
@@ -283,11 +402,42 @@ func serializeTree(entries []TreeEntry) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-// DeserializeTree deserializes msgpack bytes to TreeEntry slice.
+// TreeFormatOf returns the format version of a serialized tree object.
+func TreeFormatOf(data []byte) (uint8, error) {
+	if len(data) == 0 {
+		return 0, fmt.Errorf("%w: empty", ErrTreeFormat)
+	}
+	switch c := data[0]; {
+	case c&0xf0 == 0x90 || c == 0xdc || c == 0xdd || c == 0xc0:
+		// msgpack fixarray, array16, array32, or nil for an empty v1 tree
+		return TreeFormatV1, nil
+	case c == TreeFormatV2:
+		return TreeFormatV2, nil
+	default:
+		return 0, fmt.Errorf("%w: unknown format byte %#x", ErrTreeFormat, c)
+	}
+}
+
+// DeserializeTree deserializes a tree object in any supported format to
+// its entries.
 func DeserializeTree(data []byte) ([]TreeEntry, error) {
+	format, err := TreeFormatOf(data)
+	if err != nil {
+		return nil, err
+	}
+	if format == TreeFormatV2 {
+		data = data[1:]
+	}
 	var entries []TreeEntry
 	if err := msgpack.Unmarshal(data, &entries); err != nil {
 		return nil, err
 	}
+	if format == TreeFormatV2 {
+		for i := 1; i < len(entries); i++ {
+			if entries[i-1].Name >= entries[i].Name {
+				return nil, fmt.Errorf("%w: entry %q out of order", ErrTreeFormat, entries[i].Name)
+			}
+		}
+	}
 	return entries, nil
 }
@@ -4,8 +4,12 @@
 package fstree
 
 import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -295,3 +299,196 @@ func TestCapture_MaxFileSize(t *testing.T) {
 		t.Errorf("expected 1 file (small only), got %d", snap.Stats.FileCount)
 	}
 }
+
+func TestCaptureMulti(t *testing.T) {
+	repo := t.TempDir()
+	scratch := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(repo, "src"), 0755)
+	_ = os.WriteFile(filepath.Join(repo, "src", "main.go"), []byte("package main"), 0644)
+	_ = os.WriteFile(filepath.Join(repo, "debug.log"), []byte("log"), 0644)
+	_ = os.WriteFile(filepath.Join(scratch, "notes.txt"), []byte("notes"), 0644)
+
+	snap, err := CaptureMulti(map[string]string{"scratch": scratch, "repo": repo}, WithExclude("*.log"))
+	if err != nil {
+		t.Fatalf("CaptureMulti failed: %v", err)
+	}
+
+	files, err := snap.ListFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(files, ",") != "repo/src/main.go,scratch/notes.txt" {
+		t.Errorf("files = %v", files)
+	}
+	if snap.Stats.FileCount != 2 || snap.Stats.DirCount != 4 { // root, repo, repo/src, scratch
+		t.Errorf("stats = %+v", snap.Stats)
+	}
+
+	// Each mount's subtree hashes the same as capturing it alone.
+	single, err := Capture(repo, WithExclude("*.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := snap.GetRootEntries()
+	if len(entries) != 2 || entries[0].Name != "repo" || entries[0].Hash != single.RootHash {
+		t.Errorf("root entries = %+v", entries)
+	}
+
+	// The root hash is independent of map iteration order.
+	again, err := CaptureMulti(map[string]string{"repo": repo, "scratch": scratch}, WithExclude("*.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.RootHash != snap.RootHash {
+		t.Error("root hash changed between identical captures")
+	}
+}
+
+func TestCaptureMulti_InvalidMounts(t *testing.T) {
+	dir := t.TempDir()
+	for _, mounts := range []map[string]string{
+		{},
+		{"": dir},
+		{"a/b": dir},
+		{"..": dir},
+		{"missing": filepath.Join(dir, "nope")},
+	} {
+		if _, err := CaptureMulti(mounts); err == nil {
+			t.Errorf("CaptureMulti(%v) succeeded", mounts)
+		}
+	}
+}
+
+func TestCapture_OrderIndependent(t *testing.T) {
+	// Names whose byte order differs from case-insensitive and locale order.
+	names := []string{"b.txt", "B.txt", "a.txt", "_x", "é.txt", "10", "9"}
+	write := func(dir string, names []string) {
+		for _, name := range names {
+			_ = os.WriteFile(filepath.Join(dir, name), []byte(name), 0644)
+		}
+	}
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	write(dir1, names)
+	reversed := make([]string, len(names))
+	for i, name := range names {
+		reversed[len(names)-1-i] = name
+	}
+	write(dir2, reversed)
+
+	for _, format := range []uint8{TreeFormatV1, TreeFormatV2} {
+		snap1, err := Capture(dir1, WithTreeFormat(format))
+		if err != nil {
+			t.Fatal(err)
+		}
+		snap2, err := Capture(dir2, WithTreeFormat(format))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if snap1.RootHash != snap2.RootHash {
+			t.Errorf("v%d: root hash depends on creation order", format)
+		}
+		entries, err := snap1.GetRootEntries()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, e := range entries {
+			got = append(got, e.Name)
+		}
+		if want := "10 9 B.txt _x a.txt b.txt é.txt"; strings.Join(got, " ") != want {
+			t.Errorf("v%d: entries %q, want byte order %q", format, got, want)
+		}
+	}
+}
+
+func TestCapture_TreeFormatV2(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(tmpDir, "src", "empty"), 0755)
+	_ = os.WriteFile(filepath.Join(tmpDir, "src", "main.go"), []byte("package main"), 0644)
+
+	v1, err := Capture(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, err := Capture(tmpDir, WithTreeFormat(TreeFormatV2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v1.TreeFormat != TreeFormatV1 || v2.TreeFormat != TreeFormatV2 || v1.RootHash == v2.RootHash {
+		t.Errorf("formats %d/%d, roots %x/%x", v1.TreeFormat, v2.TreeFormat, v1.RootHash, v2.RootHash)
+	}
+	for _, snap := range []*Snapshot{v1, v2} {
+		for hash, data := range snap.Trees {
+			if format, err := TreeFormatOf(data); err != nil || format != snap.TreeFormat {
+				t.Errorf("tree %x: format %d, %v; want %d", hash[:4], format, err, snap.TreeFormat)
+			}
+		}
+	}
+
+	files, err := v2.ListFiles()
+	if err != nil || len(files) != 1 || files[0] != "src/main.go" {
+		t.Errorf("ListFiles = %v, %v", files, err)
+	}
+	dest := filepath.Join(t.TempDir(), "out")
+	if _, err := v2.Restore(dest); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(dest, "src", "empty")); err != nil || !info.IsDir() {
+		t.Errorf("empty directory not restored: %v", err)
+	}
+
+	if _, err := Capture(tmpDir, WithTreeFormat(3)); !errors.Is(err, ErrTreeFormat) {
+		t.Errorf("unknown format: err = %v", err)
+	}
+}
+
+func TestDeserializeTree_RejectsNonCanonicalV2(t *testing.T) {
+	entries := []TreeEntry{{Name: "b"}, {Name: "a"}}
+	v1, _ := serializeTree(entries, TreeFormatV1)
+	if _, err := DeserializeTree(v1); err != nil {
+		t.Errorf("v1 trees are read as written: %v", err)
+	}
+	v2, _ := serializeTree(entries, TreeFormatV2)
+	if _, err := DeserializeTree(v2); !errors.Is(err, ErrTreeFormat) {
+		t.Errorf("unsorted v2 tree: err = %v", err)
+	}
+	dup, _ := serializeTree([]TreeEntry{{Name: "a"}, {Name: "a"}}, TreeFormatV2)
+	if _, err := DeserializeTree(dup); !errors.Is(err, ErrTreeFormat) {
+		t.Errorf("duplicate v2 entries: err = %v", err)
+	}
+	if _, err := DeserializeTree([]byte{0x07}); !errors.Is(err, ErrTreeFormat) {
+		t.Errorf("unknown format byte: err = %v", err)
+	}
+}
+
+// TestCapture_MatchesFixtures recaptures the workspace of the fstree
+// fixtures written by cmd/cxdb-fstree-fixtures, so a change to tree
+// encoding or ordering cannot go unnoticed by other implementations.
+func TestCapture_MatchesFixtures(t *testing.T) {
+	root := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(root, "src"), 0o755)
+	_ = os.WriteFile(filepath.Join(root, "README.md"), []byte("# Test"), 0o644)
+	_ = os.WriteFile(filepath.Join(root, "src", "main.go"), []byte("package main"), 0o644)
+	_ = os.WriteFile(filepath.Join(root, "src", "lib.go"), []byte("package main\n\nfunc foo() {}"), 0o644)
+	_ = os.WriteFile(filepath.Join(root, "script.sh"), []byte("#!/bin/bash\necho hi"), 0o755)
+
+	for name, format := range map[string]uint8{"fstree_basic": TreeFormatV1, "fstree_basic_v2": TreeFormatV2} {
+		data, err := os.ReadFile(filepath.Join("..", "..", "rust", "tests", "fixtures", name+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fixture struct {
+			RootHashHex string `json:"root_hash_hex"`
+		}
+		if err := json.Unmarshal(data, &fixture); err != nil {
+			t.Fatal(err)
+		}
+		snap, err := Capture(root, WithTreeFormat(format))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(snap.RootHash[:]); got != fixture.RootHashHex {
+			t.Errorf("%s: root %s, fixture has %s", name, got, fixture.RootHashHex)
+		}
+	}
+}
@@ -3,39 +3,55 @@
 
 package fstree
 
-import "path/filepath"
-
 // Option configures snapshot behavior.
 type Option func(*options)
 
 type options struct {
-	excludePatterns []string
-	excludeFn       func(path string, isDir bool) bool
-	followSymlinks  bool
-	maxFileSize     int64
-	maxFiles        int
+	rules          []Rule
+	excludeFn      func(path string, isDir bool) bool
+	followSymlinks bool
+	maxFileSize    int64
+	maxFiles       int
+	preserveTimes  bool
+	treeFormat     uint8
+	ioRate         int64 // bytes per second, see WithIOThrottle
+	nice           bool  // see WithNice
 }
 
 func defaultOptions() *options {
 	return &options{
-		excludePatterns: nil,
-		followSymlinks:  false,
-		maxFileSize:     100 * 1024 * 1024, // 100MB default max file size
-		maxFiles:        100000,            // 100k files max
+		followSymlinks: false,
+		maxFileSize:    100 * 1024 * 1024, // 100MB default max file size
+		maxFiles:       100000,            // 100k files max
+		treeFormat:     TreeFormatV1,
 	}
 }
 
 // WithExclude adds glob patterns for paths to exclude.
 // Patterns are matched against the relative path from the root.
 // Examples: "*.log", ".git/**", "node_modules/**"
+//
+// Each pattern becomes an exclude Rule, evaluated in order with rules
+// added by WithRules.
 func WithExclude(patterns ...string) Option {
 	return func(o *options) {
-		o.excludePatterns = append(o.excludePatterns, patterns...)
+		for _, p := range patterns {
+			o.rules = append(o.rules, Rule{Pattern: p})
+		}
+	}
+}
+
+// WithRules appends ordered include/exclude rules. The first rule that
+// matches a path decides it; see Rule.
+func WithRules(rules ...Rule) Option {
+	return func(o *options) {
+		o.rules = append(o.rules, rules...)
 	}
 }
 
 // WithExcludeFunc sets a custom exclusion function.
-// Return true to exclude the path. Called for every file and directory.
+// Return true to exclude the path. Called for every file and directory,
+// before any rules.
 func WithExcludeFunc(fn func(path string, isDir bool) bool) Option {
 	return func(o *options) {
 		o.excludeFn = fn
@@ -54,6 +70,8 @@ func WithFollowSymlinks() Option {
 
 // WithMaxFileSize sets the maximum file size to include.
 // Files larger than this are skipped. Default is 100MB.
+// The limit is applied after all rules, so an include rule can admit a
+// larger file. Skipped files are listed in SnapshotStats.Warnings.
 func WithMaxFileSize(bytes int64) Option {
 	return func(o *options) {
 		o.maxFileSize = bytes
@@ -68,31 +86,23 @@ func WithMaxFiles(n int) Option {
 	}
 }
 
-// shouldExclude checks if a path should be excluded based on options.
-func (o *options) shouldExclude(relPath string, isDir bool) bool {
-	// Check custom function first
-	if o.excludeFn != nil && o.excludeFn(relPath, isDir) {
-		return true
+// WithPreserveTimes records file and directory modification times in the
+// snapshot so Restore and Download can reproduce them. Times are part of
+// the tree hashes, so touching a file changes the snapshot even when its
+// content does not, and unchanged subtrees dedup only if their times also
+// match. Symlink times are not recorded.
+func WithPreserveTimes() Option {
+	return func(o *options) {
+		o.preserveTimes = true
 	}
+}
 
-	// Check glob patterns
-	for _, pattern := range o.excludePatterns {
-		// Try direct match
-		if matched, _ := filepath.Match(pattern, relPath); matched {
-			return true
-		}
-		// Try matching just the base name
-		if matched, _ := filepath.Match(pattern, filepath.Base(relPath)); matched {
-			return true
-		}
-		// For ** patterns, do prefix matching on directories
-		if isDir && len(pattern) > 3 && pattern[len(pattern)-3:] == "/**" {
-			prefix := pattern[:len(pattern)-3]
-			if matched, _ := filepath.Match(prefix, relPath); matched {
-				return true
-			}
-		}
+// WithTreeFormat selects the format of the tree objects written, TreeFormatV1
+// (the default) or TreeFormatV2. The format is part of every tree hash, so
+// snapshots in different formats share file blobs but no trees. Use v2 once
+// every server and reader of the snapshots understands it.
+func WithTreeFormat(version uint8) Option {
+	return func(o *options) {
+		o.treeFormat = version
 	}
-
-	return false
 }
@@ -0,0 +1,262 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package fstree
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	cxdb "github.com/strongdm/ai-cxdb/clients/go"
+	"github.com/zeebo/blake3"
+)
+
+// Restore errors
+var (
+	ErrDestNotEmpty = errors.New("fstree: restore destination is not empty")
+	ErrUnsafeName   = errors.New("fstree: unsafe entry name")
+	ErrHashMismatch = errors.New("fstree: content does not match hash")
+)
+
+// BlobGetter fetches blobs by hash. *cxdb.Client and
+// *cxdb.ReconnectingClient implement it.
+type BlobGetter interface {
+	GetBlob(ctx context.Context, hash [32]byte) ([]byte, error)
+}
+
+// blobStreamer is implemented by clients that can stream large blobs.
+type blobStreamer interface {
+	ReadBlob(ctx context.Context, hash [32]byte, w io.Writer, opts ...cxdb.BlobReadOption) (uint64, error)
+}
+
+// RestoreResult contains the result of restoring a snapshot.
+type RestoreResult struct {
+	// FileCount is the number of regular files written.
+	FileCount int
+
+	// DirCount is the number of directories created, excluding the root.
+	DirCount int
+
+	// SymlinkCount is the number of symbolic links created.
+	SymlinkCount int
+
+	// TotalBytes is the total size of all files written.
+	TotalBytes uint64
+}
+
+// Restore writes the snapshot to dest, reading file contents from the
+// captured paths. Each file is checked against its hash, so files changed
+// since capture fail with ErrHashMismatch.
+//
+// dest must be empty or not exist. Empty directories, permission bits, and
+// symlinks are restored; modification times are restored for entries
+// captured with WithPreserveTimes. The root directory's own mode and time
+// are not part of a snapshot and are left alone. Re-capturing dest with
+// the same options yields the same root hash.
+func (s *Snapshot) Restore(dest string) (*RestoreResult, error) {
+	return restore(context.Background(), snapshotSource{s}, s.RootHash, dest)
+}
+
+// Download writes the snapshot with the given root hash to dest, fetching
+// tree objects and contents from the server. It behaves like Restore.
+// Files are streamed when the client supports ranged blob reads.
+func Download(ctx context.Context, client BlobGetter, rootHash [32]byte, dest string) (*RestoreResult, error) {
+	return restore(ctx, blobSource{client}, rootHash, dest)
+}
+
+// restoreSource supplies tree objects and contents by hash.
+type restoreSource interface {
+	tree(ctx context.Context, hash [32]byte) ([]byte, error)
+	file(ctx context.Context, hash [32]byte, w io.Writer) error
+	symlink(ctx context.Context, hash [32]byte) (string, error)
+}
+
+type snapshotSource struct{ s *Snapshot }
+
+func (src snapshotSource) tree(_ context.Context, hash [32]byte) ([]byte, error) {
+	data, ok := src.s.Trees[hash]
+	if !ok {
+		return nil, fmt.Errorf("tree not found: %x", hash[:8])
+	}
+	return data, nil
+}
+
+func (src snapshotSource) file(_ context.Context, hash [32]byte, w io.Writer) error {
+	r, err := src.s.GetFile(hash)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = r.Close() }()
+	_, err = io.Copy(w, r)
+	return err
+}
+
+func (src snapshotSource) symlink(_ context.Context, hash [32]byte) (string, error) {
+	target, ok := src.s.Symlinks[hash]
+	if !ok {
+		return "", fmt.Errorf("symlink not found: %x", hash[:8])
+	}
+	return target, nil
+}
+
+type blobSource struct{ client BlobGetter }
+
+func (src blobSource) tree(ctx context.Context, hash [32]byte) ([]byte, error) {
+	return src.client.GetBlob(ctx, hash)
+}
+
+func (src blobSource) file(ctx context.Context, hash [32]byte, w io.Writer) error {
+	if s, ok := src.client.(blobStreamer); ok {
+		_, err := s.ReadBlob(ctx, hash, w)
+		return err
+	}
+	data, err := src.client.GetBlob(ctx, hash)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func (src blobSource) symlink(ctx context.Context, hash [32]byte) (string, error) {
+	data, err := src.client.GetBlob(ctx, hash)
+	return string(data), err
+}
+
+func restore(ctx context.Context, src restoreSource, rootHash [32]byte, dest string) (*RestoreResult, error) {
+	entries, err := os.ReadDir(dest)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if err := os.MkdirAll(dest, 0o755); err != nil {
+			return nil, fmt.Errorf("create dest: %w", err)
+		}
+	case err != nil:
+		return nil, fmt.Errorf("read dest: %w", err)
+	case len(entries) > 0:
+		return nil, fmt.Errorf("%w: %s", ErrDestNotEmpty, dest)
+	}
+
+	r := &restorer{src: src, result: &RestoreResult{}}
+	if err := r.restoreTree(ctx, rootHash, dest, ""); err != nil {
+		return r.result, err
+	}
+	return r.result, nil
+}
+
+type restorer struct {
+	src    restoreSource
+	result *RestoreResult
+}
+
+// restoreTree writes the entries of the tree object hash into dir, which
+// must already exist.
+func (r *restorer) restoreTree(ctx context.Context, hash [32]byte, dir, relPath string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	data, err := r.src.tree(ctx, hash)
+	if err != nil {
+		return fmt.Errorf("fetch tree %s: %w", relPath, err)
+	}
+	if blake3.Sum256(data) != hash {
+		return fmt.Errorf("%w: tree %s", ErrHashMismatch, relPath)
+	}
+	entries, err := DeserializeTree(data)
+	if err != nil {
+		return fmt.Errorf("decode tree %s: %w", relPath, err)
+	}
+
+	for _, entry := range entries {
+		if entry.Name == "" || entry.Name == "." || entry.Name == ".." || strings.ContainsAny(entry.Name, `/\`) {
+			return fmt.Errorf("%w: %q in %s", ErrUnsafeName, entry.Name, relPath)
+		}
+		childRel := filepath.Join(relPath, entry.Name)
+		childPath := filepath.Join(dir, entry.Name)
+
+		switch entry.Kind {
+		case EntryKindDirectory:
+			// Owner-writable until the children are in place.
+			if err := os.Mkdir(childPath, 0o700); err != nil {
+				return fmt.Errorf("create dir %s: %w", childRel, err)
+			}
+			if err := r.restoreTree(ctx, entry.Hash, childPath, childRel); err != nil {
+				return err
+			}
+			r.result.DirCount++
+		case EntryKindFile:
+			if err := r.restoreFile(ctx, entry, childPath, childRel); err != nil {
+				return err
+			}
+			r.result.FileCount++
+			r.result.TotalBytes += entry.Size
+			continue
+		case EntryKindSymlink:
+			target, err := r.src.symlink(ctx, entry.Hash)
+			if err != nil {
+				return fmt.Errorf("fetch symlink %s: %w", childRel, err)
+			}
+			if blake3.Sum256([]byte(target)) != entry.Hash {
+				return fmt.Errorf("%w: symlink %s", ErrHashMismatch, childRel)
+			}
+			if err := os.Symlink(target, childPath); err != nil {
+				return fmt.Errorf("create symlink %s: %w", childRel, err)
+			}
+			r.result.SymlinkCount++
+			continue
+		default:
+			return fmt.Errorf("unknown entry kind %d for %s", entry.Kind, childRel)
+		}
+
+		// Directories get their mode and time after their children, which
+		// would otherwise bump the time again.
+		if err := applyAttrs(childPath, entry); err != nil {
+			return fmt.Errorf("set attributes %s: %w", childRel, err)
+		}
+	}
+	return nil
+}
+
+func (r *restorer) restoreFile(ctx context.Context, entry TreeEntry, path, relPath string) error {
+	// O_EXCL refuses to write through anything already at path.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("create file %s: %w", relPath, err)
+	}
+	h := blake3.New()
+	err = r.src.file(ctx, entry.Hash, io.MultiWriter(f, h))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("write file %s: %w", relPath, err)
+	}
+	if !bytes.Equal(h.Sum(nil), entry.Hash[:]) {
+		return fmt.Errorf("%w: file %s", ErrHashMismatch, relPath)
+	}
+	if err := applyAttrs(path, entry); err != nil {
+		return fmt.Errorf("set attributes %s: %w", relPath, err)
+	}
+	return nil
+}
+
+// applyAttrs sets the permission bits and, if recorded, the modification
+// time of a restored file or directory.
+func applyAttrs(path string, entry TreeEntry) error {
+	if err := os.Chmod(path, os.FileMode(entry.Mode).Perm()); err != nil {
+		return err
+	}
+	if entry.ModTime != 0 {
+		t := time.Unix(0, entry.ModTime)
+		if err := os.Chtimes(path, t, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
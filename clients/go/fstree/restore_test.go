@@ -0,0 +1,212 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package fstree
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// buildWorkspace creates files, empty directories, a symlink, and fixed
+// modification times under a new temp dir.
+func buildWorkspace(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(os.MkdirAll(filepath.Join(root, "src", "internal"), 0o755))
+	must(os.MkdirAll(filepath.Join(root, "empty", "nested-empty"), 0o750))
+	must(os.WriteFile(filepath.Join(root, "README.md"), []byte("# Test\n"), 0o644))
+	must(os.WriteFile(filepath.Join(root, "src", "main.go"), []byte("package main\n"), 0o600))
+	must(os.WriteFile(filepath.Join(root, "src", "internal", "run.sh"), []byte("#!/bin/sh\n"), 0o755))
+	must(os.Symlink("src/main.go", filepath.Join(root, "main-link")))
+
+	// Files first, then directories deepest first, so setting a time does
+	// not disturb one already set.
+	base := time.Date(2024, 3, 1, 12, 0, 0, 123456789, time.UTC)
+	paths := []string{
+		"README.md", "src/main.go", "src/internal/run.sh",
+		"empty/nested-empty", "empty", "src/internal", "src",
+	}
+	for i, p := range paths {
+		ts := base.Add(time.Duration(i) * time.Hour)
+		must(os.Chtimes(filepath.Join(root, p), ts, ts))
+	}
+	return root
+}
+
+func TestCapture_PreserveTimesOptIn(t *testing.T) {
+	root := buildWorkspace(t)
+
+	plain, err := Capture(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = plain.Walk(func(path string, e TreeEntry) error {
+		if e.ModTime != 0 {
+			t.Errorf("%s: ModTime recorded without WithPreserveTimes", path)
+		}
+		return nil
+	})
+
+	// Without the option, times do not affect the hash.
+	later := time.Now()
+	if err := os.Chtimes(filepath.Join(root, "README.md"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	again, err := Capture(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.RootHash != plain.RootHash {
+		t.Error("touching a file changed the root hash without WithPreserveTimes")
+	}
+
+	timed, err := Capture(root, WithPreserveTimes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timed.RootHash == plain.RootHash {
+		t.Error("WithPreserveTimes did not change the root hash")
+	}
+	entry, rc, err := timed.GetFileAtPath("README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = rc.Close()
+	if entry.ModTime != later.UnixNano() {
+		t.Errorf("README.md ModTime = %d, want %d", entry.ModTime, later.UnixNano())
+	}
+}
+
+func TestRestore_RoundTrip(t *testing.T) {
+	root := buildWorkspace(t)
+
+	for _, tc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"default", nil},
+		{"preserve times", []Option{WithPreserveTimes()}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			snap, err := Capture(root, tc.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dest := filepath.Join(t.TempDir(), "restored")
+			res, err := snap.Restore(dest)
+			if err != nil {
+				t.Fatalf("Restore: %v", err)
+			}
+			if res.FileCount != 3 || res.DirCount != 4 || res.SymlinkCount != 1 {
+				t.Errorf("result = %+v", res)
+			}
+
+			back, err := Capture(dest, tc.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if back.RootHash != snap.RootHash {
+				t.Fatalf("restored tree hash %x, want %x", back.RootHash[:8], snap.RootHash[:8])
+			}
+
+			info, err := os.Stat(filepath.Join(dest, "empty", "nested-empty"))
+			if err != nil || !info.IsDir() || info.Mode().Perm() != 0o750 {
+				t.Errorf("empty dir not restored: %v %v", info, err)
+			}
+			if target, err := os.Readlink(filepath.Join(dest, "main-link")); err != nil || target != "src/main.go" {
+				t.Errorf("symlink = %q, %v", target, err)
+			}
+		})
+	}
+}
+
+func TestRestore_DestMustBeEmpty(t *testing.T) {
+	snap, err := Capture(buildWorkspace(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dest := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dest, "existing"), nil, 0o644)
+	if _, err := snap.Restore(dest); !errors.Is(err, ErrDestNotEmpty) {
+		t.Fatalf("err = %v, want ErrDestNotEmpty", err)
+	}
+}
+
+func TestRestore_ChangedSourceFile(t *testing.T) {
+	root := buildWorkspace(t)
+	snap, err := Capture(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = os.WriteFile(filepath.Join(root, "README.md"), []byte("changed"), 0o644)
+	if _, err := snap.Restore(filepath.Join(t.TempDir(), "out")); !errors.Is(err, ErrHashMismatch) {
+		t.Fatalf("err = %v, want ErrHashMismatch", err)
+	}
+}
+
+// mapBlobs is a BlobGetter over uploaded snapshot blobs.
+type mapBlobs map[[32]byte][]byte
+
+func (m mapBlobs) GetBlob(_ context.Context, hash [32]byte) ([]byte, error) {
+	data, ok := m[hash]
+	if !ok {
+		return nil, errors.New("blob not found")
+	}
+	return data, nil
+}
+
+func TestDownload_RoundTrip(t *testing.T) {
+	snap, err := Capture(buildWorkspace(t), WithPreserveTimes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	blobs := mapBlobs{}
+	for hash, data := range snap.Trees {
+		blobs[hash] = data
+	}
+	for hash, ref := range snap.Files {
+		data, err := os.ReadFile(ref.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		blobs[hash] = data
+	}
+	for hash, target := range snap.Symlinks {
+		blobs[hash] = []byte(target)
+	}
+
+	dest := filepath.Join(t.TempDir(), "downloaded")
+	if _, err := Download(context.Background(), blobs, snap.RootHash, dest); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	back, err := Capture(dest, WithPreserveTimes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back.RootHash != snap.RootHash {
+		t.Fatal("downloaded tree differs from the snapshot")
+	}
+
+	// A tampered tree object is rejected.
+	for hash := range snap.Trees {
+		if hash != snap.RootHash {
+			blobs[hash] = append([]byte(nil), blobs[hash]...)
+			blobs[hash][0] ^= 0xff
+		}
+	}
+	_, err = Download(context.Background(), blobs, snap.RootHash, filepath.Join(t.TempDir(), "bad"))
+	if !errors.Is(err, ErrHashMismatch) {
+		t.Fatalf("err = %v, want ErrHashMismatch", err)
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package fstree
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Rule decides whether matching paths are captured. Rules are evaluated in
+// order and the first match wins, so exceptions go before the broader rule
+// they carve out of:
+//
+//	fstree.WithRules(
+//	    fstree.Rule{Pattern: "fixtures/*.bin", Include: true},
+//	    fstree.Rule{Pattern: "*.bin"},
+//	    fstree.Rule{Name: "huge", LargerThan: 10 << 20},
+//	)
+//
+// A rule matches when every condition it sets holds. Paths no rule matches
+// are included, subject to WithMaxFileSize.
+type Rule struct {
+	// Name identifies the rule in explanations. Defaults to a description
+	// of its conditions.
+	Name string
+
+	// Pattern is a glob matched against the path relative to the root and
+	// against its base name. A trailing "/**" also matches the directory
+	// itself. Empty matches every path.
+	Pattern string
+
+	// Kinds limits the rule to these entry kinds. Empty matches all kinds.
+	Kinds []EntryKind
+
+	// LargerThan limits the rule to regular files bigger than this many
+	// bytes. Zero disables the condition.
+	LargerThan int64
+
+	// Include makes matching paths captured instead of excluded.
+	Include bool
+}
+
+// String returns the rule's name, or a description of its conditions.
+func (r Rule) String() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	var parts []string
+	if r.Include {
+		parts = append(parts, "include")
+	} else {
+		parts = append(parts, "exclude")
+	}
+	if r.Pattern != "" {
+		parts = append(parts, fmt.Sprintf("%q", r.Pattern))
+	}
+	if len(r.Kinds) > 0 {
+		kinds := make([]string, len(r.Kinds))
+		for i, k := range r.Kinds {
+			kinds[i] = k.String()
+		}
+		parts = append(parts, strings.Join(kinds, "|"))
+	}
+	if r.LargerThan > 0 {
+		parts = append(parts, fmt.Sprintf("larger than %d bytes", r.LargerThan))
+	}
+	if len(parts) == 1 {
+		parts = append(parts, "all")
+	}
+	return strings.Join(parts, " ")
+}
+
+func (r Rule) matches(relPath string, kind EntryKind, size int64) bool {
+	if len(r.Kinds) > 0 {
+		found := false
+		for _, k := range r.Kinds {
+			found = found || k == kind
+		}
+		if !found {
+			return false
+		}
+	}
+	if r.LargerThan > 0 && (kind != EntryKindFile || size <= r.LargerThan) {
+		return false
+	}
+	return r.Pattern == "" || matchPattern(r.Pattern, relPath, kind == EntryKindDirectory)
+}
+
+// matchPattern matches a glob against relPath and its base name.
+func matchPattern(pattern, relPath string, isDir bool) bool {
+	if matched, _ := filepath.Match(pattern, relPath); matched {
+		return true
+	}
+	if matched, _ := filepath.Match(pattern, filepath.Base(relPath)); matched {
+		return true
+	}
+	// For ** patterns, do prefix matching on directories
+	if isDir && strings.HasSuffix(pattern, "/**") && len(pattern) > 3 {
+		if matched, _ := filepath.Match(strings.TrimSuffix(pattern, "/**"), relPath); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns "file", "directory", or "symlink".
+func (k EntryKind) String() string {
+	switch k {
+	case EntryKindFile:
+		return "file"
+	case EntryKindDirectory:
+		return "directory"
+	case EntryKindSymlink:
+		return "symlink"
+	}
+	return fmt.Sprintf("kind(%d)", uint8(k))
+}
+
+// Explanation reports why a path is or is not captured.
+type Explanation struct {
+	// Path is the path relative to the capture root.
+	Path string
+
+	// Excluded reports whether Capture leaves the path out.
+	Excluded bool
+
+	// Rule is the deciding rule's String, or "" if no rule matched.
+	Rule string
+
+	// RuleIndex is the deciding rule's position among the rules, or -1 for
+	// WithExcludeFunc, WithMaxFileSize, or no match.
+	RuleIndex int
+
+	// Ancestor is set when the path is excluded because this enclosing
+	// directory is.
+	Ancestor string
+
+	sizeLimited bool // excluded for its size, which Capture warns about
+}
+
+// String describes the decision in one line.
+func (e *Explanation) String() string {
+	switch {
+	case e.Ancestor != "":
+		return fmt.Sprintf("%s: excluded because directory %s is excluded by %s", e.Path, e.Ancestor, e.Rule)
+	case e.Rule == "":
+		return fmt.Sprintf("%s: included (no rule matched)", e.Path)
+	case e.Excluded:
+		return fmt.Sprintf("%s: excluded by %s", e.Path, e.Rule)
+	}
+	return fmt.Sprintf("%s: included by %s", e.Path, e.Rule)
+}
+
+// explain decides a single path without looking at its ancestors.
+func (o *options) explain(relPath string, kind EntryKind, size int64) *Explanation {
+	e := &Explanation{Path: relPath, RuleIndex: -1}
+	if o.excludeFn != nil && o.excludeFn(relPath, kind == EntryKindDirectory) {
+		e.Excluded, e.Rule = true, "exclude func"
+		return e
+	}
+	for i, r := range o.rules {
+		if r.matches(relPath, kind, size) {
+			e.Excluded, e.Rule, e.RuleIndex = !r.Include, r.String(), i
+			e.sizeLimited = e.Excluded && r.LargerThan > 0
+			return e
+		}
+	}
+	if kind == EntryKindFile && size > o.maxFileSize {
+		e.Excluded, e.sizeLimited = true, true
+		e.Rule = fmt.Sprintf("max file size (%d > %d bytes)", size, o.maxFileSize)
+	}
+	return e
+}
+
+// Explain reports whether Capture(root, opts...) would capture path and
+// which rule decided it. path may be relative to root or absolute. Paths
+// inside an excluded directory report that directory as Ancestor.
+func Explain(root, path string, opts ...Option) (*Explanation, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("resolve root: %w", err)
+	}
+	relPath := path
+	if filepath.IsAbs(path) {
+		if relPath, err = filepath.Rel(absRoot, path); err != nil {
+			return nil, fmt.Errorf("resolve path: %w", err)
+		}
+	}
+	relPath = filepath.Clean(relPath)
+	if relPath == "." || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("path %s is not inside %s", path, root)
+	}
+
+	parts := strings.Split(relPath, string(filepath.Separator))
+	for i := 1; i < len(parts); i++ {
+		dir := filepath.Join(parts[:i]...)
+		if e := o.explain(dir, EntryKindDirectory, 0); e.Excluded {
+			e.Path, e.Ancestor = relPath, dir
+			return e, nil
+		}
+	}
+
+	info, err := o.stat(filepath.Join(absRoot, relPath))
+	if err != nil {
+		return nil, err
+	}
+	return o.explain(relPath, entryKind(info), info.Size()), nil
+}
+
+func (o *options) stat(path string) (fs.FileInfo, error) {
+	if o.followSymlinks {
+		return os.Stat(path)
+	}
+	return os.Lstat(path)
+}
+
+func entryKind(info fs.FileInfo) EntryKind {
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		return EntryKindSymlink
+	case info.IsDir():
+		return EntryKindDirectory
+	}
+	return EntryKindFile
+}
@@ -0,0 +1,117 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package fstree
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func rulesWorkspace(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(root, "fixtures"), 0o755)
+	_ = os.MkdirAll(filepath.Join(root, "node_modules", "pkg"), 0o755)
+	_ = os.WriteFile(filepath.Join(root, "main.go"), []byte("package main"), 0o644)
+	_ = os.WriteFile(filepath.Join(root, "data.bin"), make([]byte, 10), 0o644)
+	_ = os.WriteFile(filepath.Join(root, "fixtures", "golden.bin"), make([]byte, 10), 0o644)
+	_ = os.WriteFile(filepath.Join(root, "fixtures", "big.json"), make([]byte, 500), 0o644)
+	_ = os.WriteFile(filepath.Join(root, "node_modules", "pkg", "index.js"), []byte("x"), 0o644)
+	return root
+}
+
+func TestRules_FirstMatchWins(t *testing.T) {
+	root := rulesWorkspace(t)
+	opts := []Option{
+		WithRules(
+			Rule{Pattern: "fixtures/*.bin", Include: true},
+			Rule{Pattern: "*.bin"},
+			Rule{Name: "no big json", Pattern: "*.json", LargerThan: 100},
+		),
+		WithExclude("node_modules/**"),
+	}
+
+	snap, err := Capture(root, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _ := snap.ListFiles()
+	if strings.Join(files, ",") != "fixtures/golden.bin,main.go" {
+		t.Fatalf("captured %v", files)
+	}
+	if snap.Stats.ExcludedCount != 3 {
+		t.Errorf("ExcludedCount = %d, want 3", snap.Stats.ExcludedCount)
+	}
+	// Only the size-based exclusion is surprising enough to warn about.
+	if len(snap.Stats.Warnings) != 1 || !strings.Contains(snap.Stats.Warnings[0], "fixtures/big.json") {
+		t.Errorf("Warnings = %q", snap.Stats.Warnings)
+	}
+
+	tests := []struct {
+		path     string
+		excluded bool
+		rule     string
+		index    int
+		ancestor string
+	}{
+		{"fixtures/golden.bin", false, `include "fixtures/*.bin"`, 0, ""},
+		{"data.bin", true, `exclude "*.bin"`, 1, ""},
+		{"fixtures/big.json", true, "no big json", 2, ""},
+		{"main.go", false, "", -1, ""},
+		{"node_modules", true, `exclude "node_modules/**"`, 3, ""},
+		{"node_modules/pkg/index.js", true, `exclude "node_modules/**"`, 3, "node_modules"},
+	}
+	for _, tt := range tests {
+		e, err := Explain(root, tt.path, opts...)
+		if err != nil {
+			t.Fatalf("Explain(%s): %v", tt.path, err)
+		}
+		if e.Excluded != tt.excluded || e.Rule != tt.rule || e.RuleIndex != tt.index || e.Ancestor != tt.ancestor {
+			t.Errorf("Explain(%s) = %+v", tt.path, e)
+		}
+	}
+}
+
+func TestRules_MaxFileSizeExplainedAndOverridable(t *testing.T) {
+	root := rulesWorkspace(t)
+
+	e, err := Explain(root, filepath.Join(root, "fixtures", "big.json"), WithMaxFileSize(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.Excluded || e.RuleIndex != -1 || !strings.HasPrefix(e.Rule, "max file size") {
+		t.Fatalf("Explain = %+v", e)
+	}
+	if got := e.String(); got != "fixtures/big.json: excluded by max file size (500 > 100 bytes)" {
+		t.Errorf("String() = %q", got)
+	}
+
+	snap, err := Capture(root, WithMaxFileSize(100), WithRules(Rule{Pattern: "*.json", Include: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := snap.GetFileAtPath("fixtures/big.json"); err != nil {
+		t.Errorf("include rule did not override the size limit: %v", err)
+	}
+}
+
+func TestRules_Kinds(t *testing.T) {
+	root := rulesWorkspace(t)
+	snap, err := Capture(root, WithRules(Rule{Pattern: "fixtures", Kinds: []EntryKind{EntryKindFile}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := snap.GetFileAtPath("fixtures/golden.bin"); err != nil {
+		t.Error("a file-only rule excluded a directory")
+	}
+
+	if got := (Rule{Kinds: []EntryKind{EntryKindSymlink}}).String(); got != "exclude symlink" {
+		t.Errorf("String() = %q", got)
+	}
+	if _, err := Explain(root, "../outside"); err == nil {
+		t.Error("Explain accepted a path outside the root")
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package fstree
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"time"
+)
+
+// maxNicePause bounds the pause WithNice takes after one file.
+const maxNicePause = 100 * time.Millisecond
+
+// WithIOThrottle limits file reads during Capture and Upload to about
+// bytesPerSec, so a snapshot of a large workspace does not saturate the
+// disk the agent is working on. Zero (the default) reads at full speed.
+// Reads are paced in chunks; short bursts may exceed the rate.
+func WithIOThrottle(bytesPerSec int64) Option {
+	return func(o *options) {
+		o.ioRate = bytesPerSec
+	}
+}
+
+// WithNice makes Capture and Upload yield after each file: they pause for
+// as long as the file took to read, up to 100ms, which keeps the snapshot
+// to at most about half of the disk time. Combine it with WithIOThrottle
+// for a hard rate.
+func WithNice() Option {
+	return func(o *options) {
+		o.nice = true
+	}
+}
+
+// throttle paces the file reads of one Capture or Upload.
+type throttle struct {
+	rate int64 // bytes per second; 0 is unlimited
+	nice bool
+
+	start time.Time
+	read  int64
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func newThrottle(o *options) *throttle {
+	return &throttle{rate: o.ioRate, nice: o.nice, sleep: sleepContext}
+}
+
+// account records n bytes read and sleeps until the running total is back
+// under the rate.
+func (t *throttle) account(ctx context.Context, n int) error {
+	if t.rate <= 0 || n == 0 {
+		return nil
+	}
+	if t.start.IsZero() {
+		t.start = time.Now()
+	}
+	t.read += int64(n)
+	due := t.start.Add(time.Duration(float64(t.read) / float64(t.rate) * float64(time.Second)))
+	if wait := time.Until(due); wait > 0 {
+		return t.sleep(ctx, wait)
+	}
+	return nil
+}
+
+// fileDone pauses under WithNice after a file; took is how long reading it
+// took.
+func (t *throttle) fileDone(ctx context.Context, took time.Duration) error {
+	if !t.nice {
+		return nil
+	}
+	runtime.Gosched()
+	return t.sleep(ctx, min(took, maxNicePause))
+}
+
+// reader wraps r so reads are accounted against the rate.
+func (t *throttle) reader(ctx context.Context, r io.Reader) io.Reader {
+	if t.rate <= 0 {
+		return r
+	}
+	return &throttledReader{ctx: ctx, r: r, t: t}
+}
+
+// throttleChunk is the largest single read of a throttled reader, so the
+// pacing stays smooth for large files.
+const throttleChunk = 64 * 1024
+
+type throttledReader struct {
+	ctx context.Context
+	r   io.Reader
+	t   *throttle
+}
+
+func (tr *throttledReader) Read(p []byte) (int, error) {
+	if len(p) > throttleChunk {
+		p = p[:throttleChunk]
+	}
+	n, err := tr.r.Read(p)
+	if terr := tr.t.account(tr.ctx, n); terr != nil && err == nil {
+		err = terr
+	}
+	return n, err
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package fstree
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zeebo/blake3"
+)
+
+type memPutter map[[32]byte][]byte
+
+func (m memPutter) PutBlobIfAbsent(_ context.Context, data []byte) ([32]byte, bool, error) {
+	h := blake3.Sum256(data)
+	_, ok := m[h]
+	m[h] = data
+	return h, !ok, nil
+}
+
+func TestThrottlePacesReads(t *testing.T) {
+	var slept time.Duration
+	th := &throttle{rate: 1 << 20, sleep: func(_ context.Context, d time.Duration) error {
+		slept = d // time does not pass, so the last wait is the total
+		return nil
+	}}
+	data := bytes.Repeat([]byte("x"), 1<<20)
+	n, err := io.Copy(io.Discard, th.reader(context.Background(), bytes.NewReader(data)))
+	if err != nil || n != int64(len(data)) {
+		t.Fatalf("copy = %d, %v", n, err)
+	}
+	// One second's budget read at memory speed: nearly all of it is spent
+	// waiting.
+	if slept < 900*time.Millisecond || slept > time.Second {
+		t.Errorf("slept %v for 1 MiB at 1 MiB/s", slept)
+	}
+
+	nice := &throttle{nice: true, sleep: func(_ context.Context, d time.Duration) error {
+		slept = d
+		return nil
+	}}
+	if err := nice.fileDone(context.Background(), 5*time.Second); err != nil || slept != maxNicePause {
+		t.Errorf("nice pause = %v, want %v", slept, maxNicePause)
+	}
+}
+
+func TestThrottledCaptureAndUpload(t *testing.T) {
+	dir := t.TempDir()
+	for i, size := range []int{64 << 10, 64 << 10, 1} {
+		name := filepath.Join(dir, string(rune('a'+i)))
+		if err := os.WriteFile(name, bytes.Repeat([]byte{byte(i)}, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	plain, err := Capture(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := []Option{WithIOThrottle(1 << 20), WithNice()}
+	start := time.Now()
+	snap, res, err := CaptureAndUpload(context.Background(), memPutter{}, dir, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.RootHash != plain.RootHash || res.FilesUploaded != 3 {
+		t.Errorf("throttled snapshot differs: %x vs %x, %+v", snap.RootHash, plain.RootHash, res)
+	}
+	// 128 KiB read twice, by Capture and by Upload, at 1 MiB/s.
+	if took := time.Since(start); took < 200*time.Millisecond {
+		t.Errorf("throttled capture and upload took %v", took)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := plain.Upload(ctx, memPutter{}, WithIOThrottle(1)); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled throttled upload = %v", err)
+	}
+}
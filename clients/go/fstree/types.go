@@ -17,6 +17,13 @@
 //	fmt.Printf("Root hash: %x\n", snapshot.RootHash)
 //	fmt.Printf("Trees: %d, Files: %d\n", len(snapshot.Trees), len(snapshot.Files))
 //
+// To materialize a snapshot again, use Snapshot.Restore for a local capture
+// or Download for one stored on the server:
+//
+//	result, err := fstree.Download(ctx, client, rootHash, "/tmp/restored")
+//
+// Capture with WithPreserveTimes to have modification times restored too.
+//
 // # Design
 //
 // The filesystem is represented as a Merkle tree:
@@ -27,11 +34,30 @@
 //
 // # Wire Format
 //
-// Tree objects are msgpack-encoded arrays of TreeEntry, sorted by name.
-// This ensures deterministic hashing regardless of filesystem enumeration order.
+// Tree objects are msgpack-encoded arrays of TreeEntry, sorted by name
+// byte-wise. This ensures deterministic hashing regardless of filesystem
+// enumeration order.
+//
+// Format v1, the default, is the bare array. Format v2 (WithTreeFormat)
+// prefixes it with a version byte so later changes to the tree layout can
+// be told apart; v2 readers also reject entries that are out of order or
+// duplicated. DeserializeTree reads both.
 package fstree
 
-import "time"
+import (
+	"time"
+
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
+)
+
+// Tree object format versions.
+const (
+	// TreeFormatV1 is a bare msgpack array of entries.
+	TreeFormatV1 = wire.TreeFormatV1
+
+	// TreeFormatV2 is the version byte 0x02 followed by the v1 array.
+	TreeFormatV2 = wire.TreeFormatV2
+)
 
 // EntryKind indicates the type of filesystem entry.
 type EntryKind uint8
@@ -68,6 +94,12 @@ type TreeEntry struct {
 	//   - For directories: hash of serialized TreeObject
 	//   - For symlinks: hash of target path bytes
 	Hash [32]byte `msgpack:"5" json:"hash"`
+
+	// ModTime is the modification time in Unix nanoseconds, recorded for
+	// files and directories only when captured with WithPreserveTimes.
+	// Zero means not recorded; it is then omitted from the encoding so
+	// tree hashes are unchanged.
+	ModTime int64 `msgpack:"6,omitempty" json:"mtime,omitempty"`
 }
 
 // TreeObject is a directory listing - a collection of entries.
@@ -81,6 +113,9 @@ type Snapshot struct {
 	// RootHash is the BLAKE3-256 hash of the root TreeObject.
 	RootHash [32]byte
 
+	// TreeFormat is the format version of the tree objects in Trees.
+	TreeFormat uint8
+
 	// Trees maps tree hashes to their serialized TreeObject bytes.
 	// Includes all directory tree objects in the snapshot.
 	Trees map[[32]byte][]byte
@@ -126,6 +161,15 @@ type SnapshotStats struct {
 	// TotalBytes is the total size of all files.
 	TotalBytes uint64
 
+	// ExcludedCount is the number of entries left out by rules or size
+	// limits. Contents of an excluded directory are not counted.
+	ExcludedCount int
+
+	// Warnings describe entries that were skipped unexpectedly: files over
+	// a size limit and entries that could not be read. At most
+	// maxWarnings are kept.
+	Warnings []string
+
 	// Duration is how long the snapshot took.
 	Duration time.Duration
 }
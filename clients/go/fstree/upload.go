@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	cxdb "github.com/strongdm/ai-cxdb/clients/go"
 )
@@ -33,12 +34,24 @@ type UploadResult struct {
 	BytesUploaded int64
 }
 
+// BlobPutter stores blobs. *cxdb.Client and *cxdb.ReconnectingClient
+// implement it.
+type BlobPutter interface {
+	PutBlobIfAbsent(ctx context.Context, data []byte) ([32]byte, bool, error)
+}
+
 // Upload uploads all tree objects and file blobs from a snapshot to the server.
 // Returns the root hash which can be used to attach the snapshot to a turn.
-func (s *Snapshot) Upload(ctx context.Context, client *cxdb.Client) (*UploadResult, error) {
+// Of opts, only WithIOThrottle and WithNice apply; they pace file reads.
+func (s *Snapshot) Upload(ctx context.Context, client BlobPutter, opts ...Option) (*UploadResult, error) {
 	result := &UploadResult{
 		RootHash: s.RootHash,
 	}
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	throttle := newThrottle(o)
 
 	// Upload all tree objects first (they're already serialized)
 	for hash, data := range s.Trees {
@@ -57,7 +70,7 @@ func (s *Snapshot) Upload(ctx context.Context, client *cxdb.Client) (*UploadResu
 	// Upload all file blobs
 	for hash, ref := range s.Files {
 		// Read file content
-		content, err := readFile(ref.Path)
+		content, err := readFile(ctx, throttle, ref.Path)
 		if err != nil {
 			return nil, fmt.Errorf("read file %s: %w", ref.Path, err)
 		}
@@ -92,19 +105,24 @@ func (s *Snapshot) Upload(ctx context.Context, client *cxdb.Client) (*UploadResu
 }
 
 // uploadBlob uploads a single blob to the server.
-func uploadBlob(ctx context.Context, client *cxdb.Client, hash [32]byte, data []byte) (bool, error) {
+func uploadBlob(ctx context.Context, client BlobPutter, hash [32]byte, data []byte) (bool, error) {
 	_, wasNew, err := client.PutBlobIfAbsent(ctx, data)
 	return wasNew, err
 }
 
-// readFile reads the entire contents of a file.
-func readFile(path string) ([]byte, error) {
+// readFile reads the entire contents of a file, paced by t.
+func readFile(ctx context.Context, t *throttle, path string) ([]byte, error) {
+	start := time.Now()
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer func() { _ = f.Close() }()
-	return io.ReadAll(f)
+	data, err := io.ReadAll(t.reader(ctx, f))
+	if err != nil {
+		return nil, err
+	}
+	return data, t.fileDone(ctx, time.Since(start))
 }
 
 // UploadAndAttach captures a filesystem snapshot, uploads it, and attaches it to a turn.
@@ -117,7 +135,7 @@ func UploadAndAttach(ctx context.Context, client *cxdb.Client, root string, turn
 	}
 
 	// Upload all blobs
-	result, err := snap.Upload(ctx, client)
+	result, err := snap.Upload(ctx, client, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("upload: %w", err)
 	}
@@ -126,6 +144,7 @@ func UploadAndAttach(ctx context.Context, client *cxdb.Client, root string, turn
 	_, err = client.AttachFs(ctx, &cxdb.AttachFsRequest{
 		TurnID:     turnID,
 		FsRootHash: snap.RootHash,
+		TreeFormat: snap.TreeFormat,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("attach: %w", err)
@@ -136,7 +155,7 @@ func UploadAndAttach(ctx context.Context, client *cxdb.Client, root string, turn
 
 // CaptureAndUpload captures a filesystem snapshot and uploads it to the server.
 // Returns the snapshot and upload result. The snapshot can be attached to a turn later.
-func CaptureAndUpload(ctx context.Context, client *cxdb.Client, root string, opts ...Option) (*Snapshot, *UploadResult, error) {
+func CaptureAndUpload(ctx context.Context, client BlobPutter, root string, opts ...Option) (*Snapshot, *UploadResult, error) {
 	// Capture snapshot
 	snap, err := Capture(root, opts...)
 	if err != nil {
@@ -144,7 +163,7 @@ func CaptureAndUpload(ctx context.Context, client *cxdb.Client, root string, opt
 	}
 
 	// Upload all blobs
-	result, err := snap.Upload(ctx, client)
+	result, err := snap.Upload(ctx, client, opts...)
 	if err != nil {
 		return nil, nil, fmt.Errorf("upload: %w", err)
 	}
@@ -0,0 +1,107 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ErrFsNotAttached is wrapped by the *FsAttachError returned when attach
+// verification is enabled and a snapshot was not recorded as requested.
+var ErrFsNotAttached = errors.New("cxdb: fs snapshot not attached")
+
+// Reasons for an FsAttachError.
+const (
+	// FsAttachMismatch means the server acknowledged a different turn or
+	// root hash than the one sent.
+	FsAttachMismatch = "mismatch"
+
+	// FsAttachInherited means the server reports the turn's snapshot as
+	// inherited from an ancestor, so the attachment was dropped.
+	FsAttachInherited = "inherited"
+
+	// FsAttachUnresolvable means the root tree object is not in the blob
+	// store, so the snapshot cannot be read back.
+	FsAttachUnresolvable = "unresolvable"
+)
+
+// FsAttachError describes a filesystem snapshot that the server did not
+// record for a turn, or recorded but cannot resolve.
+type FsAttachError struct {
+	TurnID     uint64
+	FsRootHash [32]byte // the root that was sent
+	Reason     string   // FsAttachMismatch, FsAttachInherited, or FsAttachUnresolvable
+
+	// Got is the acknowledged turn and root for FsAttachMismatch.
+	GotTurnID     uint64
+	GotFsRootHash [32]byte
+
+	// Err is the failed root lookup for FsAttachUnresolvable.
+	Err error
+}
+
+func (e *FsAttachError) Error() string {
+	msg := fmt.Sprintf("%s: turn %d root %s: %s", ErrFsNotAttached, e.TurnID, hex.EncodeToString(e.FsRootHash[:]), e.Reason)
+	switch {
+	case e.Reason == FsAttachMismatch:
+		msg += fmt.Sprintf(" (server recorded turn %d root %s)", e.GotTurnID, hex.EncodeToString(e.GotFsRootHash[:]))
+	case e.Err != nil:
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *FsAttachError) Unwrap() []error {
+	if e.Err != nil {
+		return []error{ErrFsNotAttached, e.Err}
+	}
+	return []error{ErrFsNotAttached}
+}
+
+// WithFsAttachVerify makes AttachFs and AppendTurnWithFs check that the
+// snapshot was recorded: the acknowledgement must echo the turn and root,
+// must not report an inherited snapshot, and the root tree object must be
+// readable from the blob store. Failures return an *FsAttachError. The
+// check costs one extra round trip per attachment.
+func WithFsAttachVerify() Option {
+	return func(o *clientOptions) {
+		o.verifyFsAttach = true
+	}
+}
+
+// checkFsAttach checks an attach acknowledgement for turnID and root.
+func (c *Client) checkFsAttach(ctx context.Context, turnID uint64, root [32]byte, gotTurnID uint64, gotRoot [32]byte, flags ResponseFlags) error {
+	fail := &FsAttachError{TurnID: turnID, FsRootHash: root}
+	switch {
+	case gotTurnID != turnID || gotRoot != root:
+		fail.Reason = FsAttachMismatch
+		fail.GotTurnID, fail.GotFsRootHash = gotTurnID, gotRoot
+		return fail
+	case flags.InheritedFs():
+		fail.Reason = FsAttachInherited
+		return fail
+	}
+	if err := c.probeBlob(ctx, root); err != nil {
+		if IsConnectionError(err) {
+			return err
+		}
+		fail.Reason = FsAttachUnresolvable
+		fail.Err = err
+		return fail
+	}
+	return nil
+}
+
+// probeBlob checks that a blob exists, reading as little of it as the
+// server allows.
+func (c *Client) probeBlob(ctx context.Context, hash [32]byte) error {
+	_, err := c.GetBlobRange(ctx, hash, 0, 0)
+	if errors.Is(err, ErrBlobRangeUnsupported) {
+		_, err = c.GetBlob(ctx, hash)
+	}
+	return err
+}
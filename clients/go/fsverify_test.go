@@ -0,0 +1,110 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
+	"github.com/zeebo/blake3"
+)
+
+func TestFsAttachVerify(t *testing.T) {
+	le := binary.LittleEndian
+	tree := []byte("tree object")
+	root := blake3.Sum256(tree)
+	other := [32]byte{0xBB}
+
+	tests := []struct {
+		name    string
+		echo    [32]byte // root acknowledged by ATTACH_FS
+		flags   ResponseFlags
+		noRoot  bool // GET_BLOB of the root fails with 404
+		reason  string
+		noRange bool
+	}{
+		{name: "recorded", echo: root},
+		{name: "recorded, whole-blob probe", echo: root, noRange: true},
+		{name: "other root", echo: other, reason: FsAttachMismatch},
+		{name: "inherited", echo: root, flags: ResponseFlagInheritedFs, reason: FsAttachInherited},
+		{name: "missing tree", echo: root, noRoot: true, reason: FsAttachUnresolvable},
+	}
+	for _, tt := range tests {
+		blobs := newBlobServer(tree)
+		blobs.noRanges = tt.noRange
+		c := helloClient(t, func(msgType uint16, p []byte) (uint16, uint16, []byte) {
+			switch msgType {
+			case wire.MsgAttachFs:
+				return msgType, uint16(tt.flags), append(p[:8:8], tt.echo[:]...)
+			case wire.MsgAppend:
+				resp := le.AppendUint64(nil, 1)
+				resp = le.AppendUint64(resp, 8)
+				return msgType, uint16(tt.flags), append(resp, make([]byte, 36)...)
+			case wire.MsgGetBlob:
+				if tt.noRoot {
+					return wire.MsgError, 0, wire.AppendError(nil, wire.CodeNotFound, "blob not found")
+				}
+			}
+			return blobs.handle(msgType, p)
+		})
+		c.verifyFsAttach = true
+
+		res, err := c.AttachFs(context.Background(), &AttachFsRequest{TurnID: 7, FsRootHash: root})
+		if res == nil {
+			t.Fatalf("%s: no result: %v", tt.name, err)
+		}
+		if tt.reason == "" {
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			}
+			continue
+		}
+		var fae *FsAttachError
+		if !errors.As(err, &fae) || !errors.Is(err, ErrFsNotAttached) || fae.Reason != tt.reason || fae.TurnID != 7 {
+			t.Errorf("%s: err = %v", tt.name, err)
+		}
+		if tt.noRoot && !IsServerError(err, wire.CodeNotFound) {
+			t.Errorf("%s: lookup error not wrapped: %v", tt.name, err)
+		}
+
+		// Appends only get the flag and lookup checks; the root is not echoed.
+		if tt.reason == FsAttachMismatch {
+			continue
+		}
+		appended, err := c.AppendTurnWithFs(context.Background(), &AppendRequest{ContextID: 1, TypeID: "t"}, &root)
+		if appended == nil || appended.TurnID != 8 || !errors.As(err, &fae) || fae.Reason != tt.reason || fae.TurnID != 8 {
+			t.Errorf("%s: append = %+v, %v", tt.name, appended, err)
+		}
+	}
+}
+
+func TestAttachFsTreeFormatNeedsCapability(t *testing.T) {
+	for _, tt := range []struct {
+		caps    string
+		format  uint8
+		wantLen int
+	}{
+		{`{"capabilities":[]}`, wire.TreeFormatV2, 40},
+		{`{"capabilities":["tree_format"]}`, wire.TreeFormatV1, 40},
+		{`{"capabilities":["tree_format"]}`, wire.TreeFormatV2, 41},
+	} {
+		var got []byte
+		c := helloClient(t, func(msgType uint16, p []byte) (uint16, uint16, []byte) {
+			if msgType == wire.MsgHello {
+				return msgType, 0, helloWithCapabilities(tt.caps)
+			}
+			got = append([]byte(nil), p...)
+			return msgType, 0, append(p[:8:8], make([]byte, 32)...)
+		})
+		if _, err := c.AttachFs(context.Background(), &AttachFsRequest{TurnID: 7, TreeFormat: tt.format}); err != nil {
+			t.Fatalf("%s v%d: %v", tt.caps, tt.format, err)
+		}
+		if len(got) != tt.wantLen || (tt.wantLen == 41 && got[40] != tt.format) {
+			t.Errorf("%s v%d: sent % x", tt.caps, tt.format, got)
+		}
+	}
+}
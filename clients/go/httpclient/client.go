@@ -0,0 +1,231 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+// Package httpclient is a Go client for the CXDB HTTP API served by the
+// gateway (or directly by the server on :9010).
+//
+// The request methods and response types in zz_generated.go are generated
+// from the gateway's OpenAPI document; see gateway/pkg/openapi. Regenerate
+// them with `make openapi` after changing the route table.
+//
+// # Basic Usage
+//
+//	c := httpclient.New("https://cxdb.example.com", httpclient.WithBearerToken(token))
+//	list, err := c.ListContexts(ctx, &httpclient.ListContextsParams{Limit: 10})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, cx := range list.Contexts {
+//	    fmt.Println(cx.ContextID, cx.HeadDepth)
+//	}
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	cxdb "github.com/strongdm/ai-cxdb/clients/go"
+)
+
+// DefaultTimeout is the overall timeout of the default HTTP client.
+const DefaultTimeout = 30 * time.Second
+
+// Client issues requests against the CXDB HTTP API.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	token      string
+	userAgent  string
+}
+
+// Option configures client behavior.
+type Option func(*Client)
+
+// WithHTTPClient replaces the underlying *http.Client.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithBearerToken sends the token in an Authorization header on every request.
+// Use it with tokens from the AWS IAM exchange or a Kubernetes service account.
+func WithBearerToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithUserAgent sets the User-Agent header.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) {
+		c.userAgent = ua
+	}
+}
+
+// New creates a client for the API rooted at baseURL (e.g. "http://localhost:9010").
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+		userAgent:  "cxdb-go-httpclient",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// HTTPError is returned for non-2xx responses. It unwraps to the
+// equivalent *cxdb.ServerError, so callers can handle errors from this
+// client and the binary client alike:
+//
+//	if cxdb.IsServerError(err, 404) { ... }
+type HTTPError struct {
+	StatusCode int
+
+	// Code is the error code from the body, which is the HTTP status for
+	// gateway and backend errors.
+	Code int
+
+	Message string
+
+	// RequestID identifies the request in gateway and backend logs.
+	RequestID string
+
+	// Reason is a stable cause for sign-in and token exchange failures,
+	// e.g. "role_not_allowed".
+	Reason string
+}
+
+func (e *HTTPError) Error() string {
+	msg := fmt.Sprintf("cxdb http error %d", e.StatusCode)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.RequestID != "" {
+		msg += " (request " + e.RequestID + ")"
+	}
+	return msg
+}
+
+// Unwrap returns the error as a *cxdb.ServerError.
+func (e *HTTPError) Unwrap() error {
+	code := e.Code
+	if code <= 0 {
+		code = e.StatusCode
+	}
+	return &cxdb.ServerError{Code: uint32(code), Detail: e.Message}
+}
+
+// do performs a request and decodes the response into out. out may be nil
+// (body discarded), *[]byte (raw body), *json.RawMessage, or a pointer to a
+// JSON-decodable value. A 304 answer to a conditional request returns
+// cxdb.ErrNotModified.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, header http.Header, body, out any) error {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("%s %s: encode body: %w", method, path, err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	for k, vs := range header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s %s: read body: %w", method, path, err)
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		return cxdb.ErrNotModified
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newHTTPError(resp, data)
+	}
+
+	switch v := out.(type) {
+	case nil:
+		return nil
+	case *[]byte:
+		*v = data
+		return nil
+	case *json.RawMessage:
+		*v = json.RawMessage(data)
+		return nil
+	default:
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("%s %s: decode response: %w", method, path, err)
+		}
+		return nil
+	}
+}
+
+// newHTTPError parses a gateway or backend error body, falling back to the
+// trimmed body text as the message.
+func newHTTPError(resp *http.Response, data []byte) *HTTPError {
+	e := &HTTPError{StatusCode: resp.StatusCode, RequestID: resp.Header.Get("X-Request-Id")}
+	var body Error
+	if err := json.Unmarshal(data, &body); err == nil && body.Error.Message != "" {
+		e.Code, e.Message, e.Reason = body.Error.Code, body.Error.Message, body.Error.Reason
+		if body.Error.RequestID != "" {
+			e.RequestID = body.Error.RequestID
+		}
+		return e
+	}
+	e.Message = strings.TrimSpace(string(data))
+	return e
+}
+
+// escapePathRest escapes each segment of a slash-separated path.
+func escapePathRest(p string) string {
+	segs := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	for i, s := range segs {
+		segs[i] = url.PathEscape(s)
+	}
+	return strings.Join(segs, "/")
+}
+
+func formatBool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
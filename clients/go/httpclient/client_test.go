@@ -0,0 +1,129 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cxdb "github.com/strongdm/ai-cxdb/clients/go"
+)
+
+func TestListContextsQueryAndDecode(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/contexts" {
+			t.Errorf("path = %q, want /v1/contexts", r.URL.Path)
+		}
+		q := r.URL.Query()
+		if q.Get("limit") != "5" || q.Get("include_provenance") != "1" || q.Has("tag") {
+			t.Errorf("unexpected query %q", r.URL.RawQuery)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer tok" {
+			t.Errorf("Authorization = %q", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"contexts":[{"context_id":"7","head_turn_id":"9","head_depth":2,"created_at_unix_ms":1,"is_live":true}],"count":1,"active_sessions":[],"active_tags":[]}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL+"/", WithBearerToken("tok"))
+	list, err := c.ListContexts(context.Background(), &ListContextsParams{Limit: 5, IncludeProvenance: true})
+	if err != nil {
+		t.Fatalf("ListContexts: %v", err)
+	}
+	if list.Count != 1 || len(list.Contexts) != 1 {
+		t.Fatalf("unexpected list %+v", list)
+	}
+	if cx := list.Contexts[0]; cx.ContextID != "7" || cx.HeadDepth != 2 || !cx.IsLive {
+		t.Errorf("unexpected context %+v", cx)
+	}
+}
+
+func TestGetFsFileEscapesPath(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/v1/turns/42/fs/src/a%20b.go" {
+			t.Errorf("path = %q", r.URL.EscapedPath())
+		}
+		_, _ = w.Write([]byte("package a"))
+	}))
+	defer srv.Close()
+
+	data, err := New(srv.URL).GetFsFile(context.Background(), 42, "src/a b.go")
+	if err != nil {
+		t.Fatalf("GetFsFile: %v", err)
+	}
+	if string(data) != "package a" {
+		t.Errorf("content = %q", data)
+	}
+}
+
+func TestHTTPErrorParsesBackendBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":{"code":404,"message":"context not found"}}`))
+	}))
+	defer srv.Close()
+
+	_, err := New(srv.URL).GetContextProvenance(context.Background(), 1)
+	var he *HTTPError
+	if !errors.As(err, &he) {
+		t.Fatalf("expected *HTTPError, got %v", err)
+	}
+	if he.StatusCode != http.StatusNotFound || he.Message != "context not found" {
+		t.Errorf("unexpected error %+v", he)
+	}
+}
+
+func TestHTTPErrorMatchesServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/contexts/1/provenance":
+			w.Header().Set("X-Request-Id", "hdr-id")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":404,"message":"context not found","request_id":"req-1"}}`))
+		default:
+			w.Header().Set("X-Request-Id", "req-2")
+			http.Error(w, "upstream down", http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+	c := New(srv.URL)
+
+	_, err := c.GetContextProvenance(context.Background(), 1)
+	if !cxdb.IsServerError(err, 404) {
+		t.Fatalf("IsServerError(err, 404) = false for %v", err)
+	}
+	var se *cxdb.ServerError
+	if !errors.As(err, &se) || se.Detail != "context not found" {
+		t.Errorf("ServerError = %+v", se)
+	}
+	if got := err.Error(); got != "cxdb http error 404: context not found (request req-1)" {
+		t.Errorf("Error() = %q", got)
+	}
+
+	// Plain-text bodies keep the status as the code and the header's ID.
+	_, err = c.GetContextProvenance(context.Background(), 2)
+	var he *HTTPError
+	if !errors.As(err, &he) || he.Message != "upstream down" || he.RequestID != "req-2" || !cxdb.IsServerError(err, 502) {
+		t.Errorf("unexpected error %+v", he)
+	}
+}
+
+func TestListTurnsNotModified(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("If-None-Match"); got != `"9"` {
+			t.Errorf("If-None-Match = %q", got)
+		}
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer srv.Close()
+
+	_, err := New(srv.URL).ListTurns(context.Background(), 7, &ListTurnsParams{IfNoneMatch: `"9"`})
+	if !errors.Is(err, cxdb.ErrNotModified) {
+		t.Errorf("err = %v, want cxdb.ErrNotModified", err)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"github.com/strongdm/ai-cxdb/clients/go/types"
+)
+
+// NetworkIdentity is the connection as the server observed it. Behind a
+// proxy or the gateway's WebSocket tunnel it is the proxy's address.
+type NetworkIdentity struct {
+	ClientAddress string
+	ClientPort    int
+}
+
+// NetworkIdentity returns the address and port the server reported in the
+// HELLO handshake; zero for servers that do not report them.
+func (c *Client) NetworkIdentity() NetworkIdentity {
+	return c.identity
+}
+
+// WithServerProvenance makes AppendConversationItem fill the network
+// identity fields of a first turn's Provenance from the HELLO handshake.
+// Fields the caller already set are kept.
+func WithServerProvenance() Option {
+	return func(o *clientOptions) {
+		o.serverProvenance = true
+	}
+}
+
+// EnrichProvenance returns a copy of p with ClientAddress and ClientPort
+// filled from NetworkIdentity where p leaves them blank. p is not modified,
+// so a process-wide Provenance from types.CaptureProcessProvenance can be
+// shared between goroutines and connections. A nil p yields nil.
+func (c *Client) EnrichProvenance(p *types.Provenance) *types.Provenance {
+	if p == nil {
+		return nil
+	}
+	enriched := *p
+	if enriched.ClientAddress == "" {
+		enriched.ClientAddress = c.identity.ClientAddress
+	}
+	if enriched.ClientPort == 0 {
+		enriched.ClientPort = c.identity.ClientPort
+	}
+	return &enriched
+}
+
+// AppendConversationItem encodes item and appends it as a
+// types.TypeIDConversationItem turn. A parentTurnID of 0 appends at the
+// context head.
+//
+// With WithServerProvenance, an item carrying context metadata (by
+// convention only the first turn of a context does) is appended with its
+// Provenance passed through EnrichProvenance. With WithTitleGenerator, the
+// first user input of a context is appended with a generated title. item
+// itself is not modified. With WithSchemaPolicy, the item is validated
+// before it is sent.
+func (c *Client) AppendConversationItem(ctx context.Context, contextID, parentTurnID uint64, item *types.ConversationItem) (*AppendResult, error) {
+	return c.appendConversationItem(ctx, contextID, parentTurnID, item, nil)
+}
+
+func (c *Client) appendConversationItem(ctx context.Context, contextID, parentTurnID uint64, item *types.ConversationItem, policy *SchemaPolicy) (*AppendResult, error) {
+	if policy == nil {
+		policy = &c.schemaPolicy
+	}
+	if err := checkSchema(*policy, contextID, item); err != nil {
+		return nil, fmt.Errorf("append conversation item: %w", err)
+	}
+	item = c.withGeneratedTitle(ctx, contextID, parentTurnID, item)
+	if c.serverProvenance && item.ContextMetadata != nil && item.ContextMetadata.Provenance != nil {
+		meta := *item.ContextMetadata
+		meta.Provenance = c.EnrichProvenance(meta.Provenance)
+		enriched := *item
+		enriched.ContextMetadata = &meta
+		item = &enriched
+	}
+
+	payload, err := types.CanonicalEncode(item)
+	if err != nil {
+		return nil, fmt.Errorf("append conversation item: %w", err)
+	}
+	return c.AppendTurn(ctx, &AppendRequest{
+		ContextID:    contextID,
+		ParentTurnID: parentTurnID,
+		TypeID:       types.TypeIDConversationItem,
+		TypeVersion:  types.TypeVersionConversationItem,
+		Payload:      payload,
+	})
+}
+
+// helloIdentity is the JSON section that may follow the notices section of
+// a HELLO response, prefixed by its u32 length.
+type helloIdentity struct {
+	ClientAddress string `json:"client_address"`
+	ClientPort    int    `json:"client_port"`
+}
+
+// parseHelloIdentity decodes the network identity section of a HELLO
+// response, given the bytes after protocol_version. Servers that send it
+// always send the notices section first, empty if need be.
+func parseHelloIdentity(data []byte) (NetworkIdentity, error) {
+	if len(data) < 4 {
+		return NetworkIdentity{}, nil
+	}
+	skip := uint64(binary.LittleEndian.Uint32(data[0:4]))
+	if skip > uint64(len(data)-4) {
+		return NetworkIdentity{}, nil // reported by parseHelloNotices
+	}
+	data = data[4+skip:]
+	if len(data) < 4 {
+		return NetworkIdentity{}, nil
+	}
+	n := binary.LittleEndian.Uint32(data[0:4])
+	if uint64(n) > uint64(len(data)-4) {
+		return NetworkIdentity{}, fmt.Errorf("network identity truncated: %d of %d bytes", len(data)-4, n)
+	}
+	if n == 0 {
+		return NetworkIdentity{}, nil
+	}
+	var raw helloIdentity
+	if err := json.Unmarshal(data[4:4+n], &raw); err != nil {
+		return NetworkIdentity{}, fmt.Errorf("network identity: %w", err)
+	}
+	return NetworkIdentity(raw), nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/strongdm/ai-cxdb/clients/go/types"
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
+)
+
+func helloWithIdentity(identity string) []byte {
+	b := helloWithNotices(`{"notices":[]}`)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(identity)))
+	return append(b, identity...)
+}
+
+func TestParseHelloIdentity(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+		want    NetworkIdentity
+		wantErr bool
+	}{
+		{"old server", make([]byte, 10), NetworkIdentity{}, false},
+		{"notices only", helloWithNotices(`{"notices":[]}`), NetworkIdentity{}, false},
+		{"identity", helloWithIdentity(`{"client_address":"203.0.113.7","client_port":51234}`),
+			NetworkIdentity{ClientAddress: "203.0.113.7", ClientPort: 51234}, false},
+		{"malformed", helloWithIdentity(`{"client_port":"x"}`), NetworkIdentity{}, true},
+		{"truncated", helloWithIdentity(`{}`)[:len(helloWithIdentity(`{}`))-1], NetworkIdentity{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseHelloIdentity(tt.payload[10:])
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s: got %+v, %v", tt.name, got, err)
+		}
+	}
+}
+
+func TestAppendConversationItemServerProvenance(t *testing.T) {
+	var appended []byte
+	c := pipeClient(t, func(msgType uint16, p []byte) (uint16, uint16, []byte) {
+		switch msgType {
+		case wire.MsgHello:
+			return msgType, 0, helloWithIdentity(`{"client_address":"203.0.113.7","client_port":51234}`)
+		case wire.MsgAppend:
+			appended = p
+			return msgType, 0, make([]byte, 52)
+		}
+		return wire.MsgError, 0, wire.AppendError(nil, wire.CodeInvalidInput, "unexpected message")
+	})
+	if err := c.sendHello(""); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.NetworkIdentity(); got.ClientAddress != "203.0.113.7" || got.ClientPort != 51234 {
+		t.Fatalf("NetworkIdentity() = %+v", got)
+	}
+	c.serverProvenance = true
+
+	shared := &types.Provenance{ServiceName: "agent"}
+	item := types.NewUserInput("hi").WithContextMetadata(&types.ContextMetadata{Provenance: shared})
+	if _, err := c.AppendConversationItem(context.Background(), 1, 0, item); err != nil {
+		t.Fatal(err)
+	}
+	if shared.ClientAddress != "" || item.ContextMetadata.Provenance != shared {
+		t.Error("caller's item or provenance was modified")
+	}
+
+	want := *item
+	want.ContextMetadata = &types.ContextMetadata{Provenance: &types.Provenance{
+		ServiceName: "agent", ClientAddress: "203.0.113.7", ClientPort: 51234,
+	}}
+	encoded, err := EncodeMsgpack(&want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(appended, encoded) {
+		t.Error("appended payload lacks the enriched provenance")
+	}
+
+	// Values set by the caller win.
+	p := c.EnrichProvenance(&types.Provenance{ClientAddress: "10.0.0.1"})
+	if p.ClientAddress != "10.0.0.1" || p.ClientPort != 51234 {
+		t.Errorf("EnrichProvenance = %+v", p)
+	}
+}
+
+func TestAppendConversationItemCanonicalHash(t *testing.T) {
+	store := &memStore{blobs: make(map[[32]byte][]byte)}
+	c := pipeClient(t, store.handle)
+
+	item := types.NewUserInput("hash me", "a.go", "b.go")
+	want, err := types.CanonicalHash(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.AppendConversationItem(context.Background(), 1, 0, item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.PayloadHash != want || store.turns[0].PayloadHash != want {
+		t.Errorf("PayloadHash = %x, stored %x, want %x", res.PayloadHash[:8], store.turns[0].PayloadHash[:8], want[:8])
+	}
+}
@@ -0,0 +1,587 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+)
+
+// Lease errors.
+var (
+	// ErrLeaseHeld is returned by AcquireLease when another writer holds an
+	// unexpired lease on the context.
+	ErrLeaseHeld = errors.New("cxdb: lease held by another writer")
+
+	// ErrLeaseLost is returned when a lease could not be renewed before it
+	// expired, or was taken over by another writer.
+	ErrLeaseLost = errors.New("cxdb: lease lost")
+
+	// ErrNoLease is returned by appends under LeaseRequire when the client
+	// holds no live lease on the context.
+	ErrNoLease = errors.New("cxdb: no lease held for context")
+)
+
+// DefaultLeaseTTL is the lease duration used when WithLeaseTTL is not given.
+// Leases are renewed every third of their TTL.
+const DefaultLeaseTTL = 15 * time.Second
+
+// LeaseMode controls how appends behave on contexts the client holds no
+// lease for.
+type LeaseMode int
+
+const (
+	// LeaseOff performs no lease checks. This is the default.
+	LeaseOff LeaseMode = iota
+
+	// LeaseWarn logs a warning and appends anyway.
+	LeaseWarn
+
+	// LeaseRequire refuses the append with ErrNoLease.
+	LeaseRequire
+)
+
+// WithLeaseMode sets how appends without a held lease are treated.
+func WithLeaseMode(mode LeaseMode) Option {
+	return func(o *clientOptions) {
+		o.leaseMode = mode
+	}
+}
+
+// LeaseGrant is a lease as recorded by a LeaseStore.
+type LeaseGrant struct {
+	ContextID uint64
+	Holder    string
+
+	// Token identifies one acquisition. Renewals and releases must present
+	// the token that was granted.
+	Token string
+
+	ExpiresAt time.Time
+}
+
+// LeaseStore arbitrates single-writer leases. Implementations must be safe
+// for concurrent use and must grant at most one unexpired lease per context.
+type LeaseStore interface {
+	// Acquire grants a lease on g.ContextID to g.Holder for ttl, or returns
+	// ErrLeaseHeld. g.ExpiresAt is ignored.
+	Acquire(ctx context.Context, g LeaseGrant, ttl time.Duration) (LeaseGrant, error)
+
+	// Renew extends a granted lease by ttl, or returns ErrLeaseLost.
+	Renew(ctx context.Context, g LeaseGrant, ttl time.Duration) (LeaseGrant, error)
+
+	// Release gives up a granted lease. Releasing a lost lease is not an
+	// error.
+	Release(ctx context.Context, g LeaseGrant) error
+}
+
+// LeaseOption configures AcquireLease.
+type LeaseOption func(*leaseOptions)
+
+type leaseOptions struct {
+	ttl    time.Duration
+	holder string
+}
+
+// WithLeaseTTL sets the lease duration. Default: DefaultLeaseTTL.
+func WithLeaseTTL(d time.Duration) LeaseOption {
+	return func(o *leaseOptions) {
+		o.ttl = d
+	}
+}
+
+// WithLeaseHolder names the lease holder in ErrLeaseHeld messages and lease
+// records. Default: the client tag.
+func WithLeaseHolder(name string) LeaseOption {
+	return func(o *leaseOptions) {
+		o.holder = name
+	}
+}
+
+// Lease is a held single-writer lease. It is renewed in the background until
+// Release is called or renewal fails.
+type Lease struct {
+	set       *leaseSet
+	store     LeaseStore
+	ttl       time.Duration
+	contextID uint64
+	holder    string
+
+	mu    sync.Mutex
+	grant LeaseGrant
+	err   error
+
+	lost chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// ContextID returns the leased context.
+func (l *Lease) ContextID() uint64 {
+	return l.contextID
+}
+
+// Holder returns the holder name recorded with the lease.
+func (l *Lease) Holder() string {
+	return l.holder
+}
+
+// ExpiresAt returns the expiry of the last successful renewal.
+func (l *Lease) ExpiresAt() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.grant.ExpiresAt
+}
+
+// Lost is closed when the lease is lost. Writers should stop appending to
+// the context once it fires.
+func (l *Lease) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Err returns why the lease was lost, or nil while it is held.
+func (l *Lease) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// Valid reports whether the lease is held and unexpired.
+func (l *Lease) Valid() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err == nil && time.Now().Before(l.grant.ExpiresAt)
+}
+
+// Release stops renewal and gives up the lease.
+func (l *Lease) Release(ctx context.Context) error {
+	l.mu.Lock()
+	select {
+	case <-l.stop:
+		l.mu.Unlock()
+		return nil
+	default:
+	}
+	close(l.stop)
+	l.mu.Unlock()
+	<-l.done
+
+	l.set.remove(l)
+	l.mu.Lock()
+	grant, lost := l.grant, l.err != nil
+	l.mu.Unlock()
+	if lost {
+		return nil
+	}
+	if err := l.store.Release(ctx, grant); err != nil {
+		return fmt.Errorf("release lease: %w", err)
+	}
+	return nil
+}
+
+// heartbeat renews the lease every third of its TTL. Transient renewal
+// errors are retried until the lease expires.
+func (l *Lease) heartbeat() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+
+		l.mu.Lock()
+		grant := l.grant
+		l.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), l.ttl/3)
+		renewed, err := l.store.Renew(ctx, grant, l.ttl)
+		cancel()
+
+		switch {
+		case err == nil:
+			l.mu.Lock()
+			l.grant = renewed
+			l.mu.Unlock()
+		case errors.Is(err, ErrLeaseLost):
+			l.markLost(fmt.Errorf("context %d: %w", l.contextID, err))
+			return
+		case !time.Now().Before(grant.ExpiresAt):
+			l.markLost(fmt.Errorf("%w: context %d: renewal failed: %v", ErrLeaseLost, l.contextID, err))
+			return
+		default:
+			slog.Warn("[cxdb] lease renewal failed, retrying",
+				"context_id", l.contextID,
+				"expires_at", grant.ExpiresAt,
+				"error", err)
+		}
+	}
+}
+
+func (l *Lease) markLost(err error) {
+	l.mu.Lock()
+	l.err = err
+	l.mu.Unlock()
+	close(l.lost)
+	l.set.remove(l)
+	slog.Warn("[cxdb] lease lost", "context_id", l.contextID, "error", err)
+}
+
+// leaseSet is the lease state shared by a client and its reconnects. A nil
+// set performs no checks.
+type leaseSet struct {
+	mode LeaseMode
+
+	mu   sync.Mutex
+	held map[uint64]*Lease
+}
+
+func newLeaseSet(mode LeaseMode) *leaseSet {
+	return &leaseSet{mode: mode, held: make(map[uint64]*Lease)}
+}
+
+func (s *leaseSet) remove(l *Lease) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.held[l.contextID] == l {
+		delete(s.held, l.contextID)
+	}
+}
+
+// check applies the lease mode to an append on contextID. Lease claim turns
+// are exempt; they are how ContextLeaseStore takes leases.
+func (s *leaseSet) check(contextID uint64, typeID string) error {
+	if s == nil || s.mode == LeaseOff || typeID == LeaseTypeID {
+		return nil
+	}
+	s.mu.Lock()
+	l := s.held[contextID]
+	s.mu.Unlock()
+	if l != nil && l.Valid() {
+		return nil
+	}
+	if s.mode == LeaseRequire {
+		return fmt.Errorf("%w %d", ErrNoLease, contextID)
+	}
+	slog.Warn("[cxdb] append without lease", "context_id", contextID)
+	return nil
+}
+
+// AcquireLease takes a single-writer lease on contextID from store and
+// renews it in the background until released or lost. It returns
+// ErrLeaseHeld if another writer holds the lease. While the lease is valid,
+// appends to contextID pass the client's LeaseMode check.
+func (c *Client) AcquireLease(ctx context.Context, store LeaseStore, contextID uint64, opts ...LeaseOption) (*Lease, error) {
+	return c.leases.acquire(ctx, store, contextID, c.clientTag, opts)
+}
+
+func (s *leaseSet) acquire(ctx context.Context, store LeaseStore, contextID uint64, defaultHolder string, opts []LeaseOption) (*Lease, error) {
+	if s == nil {
+		s = newLeaseSet(LeaseOff)
+	}
+	o := leaseOptions{ttl: DefaultLeaseTTL, holder: defaultHolder}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	token, err := newLeaseToken()
+	if err != nil {
+		return nil, fmt.Errorf("acquire lease: %w", err)
+	}
+	grant, err := store.Acquire(ctx, LeaseGrant{ContextID: contextID, Holder: o.holder, Token: token}, o.ttl)
+	if err != nil {
+		return nil, fmt.Errorf("acquire lease: %w", err)
+	}
+
+	l := &Lease{
+		set:       s,
+		store:     store,
+		ttl:       o.ttl,
+		contextID: contextID,
+		holder:    o.holder,
+		grant:     grant,
+		lost:      make(chan struct{}),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	s.mu.Lock()
+	s.held[contextID] = l
+	s.mu.Unlock()
+	go l.heartbeat()
+	return l, nil
+}
+
+func newLeaseToken() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+// =============================================================================
+// In-memory store
+// =============================================================================
+
+// MemoryLeaseStore is a LeaseStore for writers in a single process.
+type MemoryLeaseStore struct {
+	mu     sync.Mutex
+	grants map[uint64]LeaseGrant
+	now    func() time.Time
+}
+
+// NewMemoryLeaseStore returns an empty in-memory lease store.
+func NewMemoryLeaseStore() *MemoryLeaseStore {
+	return &MemoryLeaseStore{grants: make(map[uint64]LeaseGrant), now: time.Now}
+}
+
+// Acquire implements LeaseStore.
+func (m *MemoryLeaseStore) Acquire(_ context.Context, g LeaseGrant, ttl time.Duration) (LeaseGrant, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	if cur, ok := m.grants[g.ContextID]; ok && cur.Token != g.Token && now.Before(cur.ExpiresAt) {
+		return LeaseGrant{}, fmt.Errorf("%w: %s until %s", ErrLeaseHeld, cur.Holder, cur.ExpiresAt.Format(time.RFC3339))
+	}
+	g.ExpiresAt = now.Add(ttl)
+	m.grants[g.ContextID] = g
+	return g, nil
+}
+
+// Renew implements LeaseStore.
+func (m *MemoryLeaseStore) Renew(_ context.Context, g LeaseGrant, ttl time.Duration) (LeaseGrant, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	cur, ok := m.grants[g.ContextID]
+	if !ok || cur.Token != g.Token || !now.Before(cur.ExpiresAt) {
+		return LeaseGrant{}, ErrLeaseLost
+	}
+	cur.ExpiresAt = now.Add(ttl)
+	m.grants[g.ContextID] = cur
+	return cur, nil
+}
+
+// Release implements LeaseStore.
+func (m *MemoryLeaseStore) Release(_ context.Context, g LeaseGrant) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if cur, ok := m.grants[g.ContextID]; ok && cur.Token == g.Token {
+		delete(m.grants, g.ContextID)
+	}
+	return nil
+}
+
+// =============================================================================
+// CXDB-backed store
+// =============================================================================
+
+// LeaseTypeID is the type of the claim turns written by ContextLeaseStore.
+// Appends of this type are never subject to lease checks.
+const LeaseTypeID = "cxdb.Lease"
+
+// leaseLogPage is how many recent claim turns ContextLeaseStore reads
+// first. The read doubles until it covers every claim that may still hold a
+// lease.
+const leaseLogPage = 256
+
+// LeaseLog is the subset of Client and ReconnectingClient used by
+// ContextLeaseStore.
+type LeaseLog interface {
+	AppendTurn(ctx context.Context, req *AppendRequest) (*AppendResult, error)
+	GetLast(ctx context.Context, contextID uint64, opts GetLastOptions) ([]TurnRecord, error)
+}
+
+// leaseClaim is the payload of a LeaseTypeID turn.
+type leaseClaim struct {
+	ContextID      uint64 `msgpack:"1"`
+	Holder         string `msgpack:"2"`
+	Token          string `msgpack:"3"`
+	Op             string `msgpack:"4"` // acquire, renew, release
+	IssuedAtUnixMs int64  `msgpack:"5"`
+	TTLMs          int64  `msgpack:"6"`
+
+	// PrevExpiresAtUnixMs is, on a renew, the expiry of the grant being
+	// renewed. It lets replay tell a stale acquire from a lost holder when
+	// the holder's earlier claims have left the window.
+	PrevExpiresAtUnixMs int64 `msgpack:"7"`
+}
+
+// ContextLeaseStore keeps leases as claim turns in a dedicated CXDB context
+// shared by all competing writers, so replicas on different hosts can
+// coordinate through the server they already use.
+//
+// The server serializes appends, so every writer sees claims in the same
+// order. Each operation appends a claim and then replays the claims issued
+// within twice the longest TTL seen: the first acquire made while no
+// unexpired lease exists wins, and only its token may renew or release it.
+// A live holder renews within its TTL, so its latest claim is always
+// replayed however many other claims follow it. Renewals carry the expiry
+// they extend, so a holder keeps its lease even when its acquire and a
+// competitor's rejected acquire fall at the edge of the replayed claims.
+// Expiry uses the writers' clocks, so the TTL should comfortably exceed
+// clock skew between replicas, and writers sharing a lease context should
+// use the same TTL.
+//
+// Every operation appends a turn, and CXDB never deletes turns, so the
+// lease context grows by one turn per acquire, renewal and release. Reads
+// stay bounded by the TTL, but long-running deployments should move to a
+// fresh lease context from time to time, for example at each deploy, once
+// no writer uses the old one.
+type ContextLeaseStore struct {
+	log       LeaseLog
+	contextID uint64
+	now       func() time.Time
+}
+
+// NewContextLeaseStore returns a store that records claims in
+// leaseContextID. Create the context once and share its ID between writers.
+func NewContextLeaseStore(log LeaseLog, leaseContextID uint64) *ContextLeaseStore {
+	return &ContextLeaseStore{log: log, contextID: leaseContextID, now: time.Now}
+}
+
+// Acquire implements LeaseStore.
+func (s *ContextLeaseStore) Acquire(ctx context.Context, g LeaseGrant, ttl time.Duration) (LeaseGrant, error) {
+	cur, err := s.claim(ctx, "acquire", g, ttl)
+	if err != nil {
+		return LeaseGrant{}, err
+	}
+	if cur.Token != g.Token {
+		return LeaseGrant{}, fmt.Errorf("%w: %s until %s", ErrLeaseHeld, cur.Holder, cur.ExpiresAt.Format(time.RFC3339))
+	}
+	return cur, nil
+}
+
+// Renew implements LeaseStore.
+func (s *ContextLeaseStore) Renew(ctx context.Context, g LeaseGrant, ttl time.Duration) (LeaseGrant, error) {
+	cur, err := s.claim(ctx, "renew", g, ttl)
+	if err != nil {
+		return LeaseGrant{}, err
+	}
+	if cur.Token != g.Token {
+		return LeaseGrant{}, ErrLeaseLost
+	}
+	return cur, nil
+}
+
+// Release implements LeaseStore.
+func (s *ContextLeaseStore) Release(ctx context.Context, g LeaseGrant) error {
+	_, err := s.claim(ctx, "release", g, 0)
+	return err
+}
+
+// claim appends a claim and returns the resulting lease holder for
+// g.ContextID. The returned grant is zero when no lease is held.
+func (s *ContextLeaseStore) claim(ctx context.Context, op string, g LeaseGrant, ttl time.Duration) (LeaseGrant, error) {
+	c := &leaseClaim{
+		ContextID:      g.ContextID,
+		Holder:         g.Holder,
+		Token:          g.Token,
+		Op:             op,
+		IssuedAtUnixMs: s.now().UnixMilli(),
+		TTLMs:          ttl.Milliseconds(),
+	}
+	if op == "renew" && !g.ExpiresAt.IsZero() {
+		c.PrevExpiresAtUnixMs = g.ExpiresAt.UnixMilli()
+	}
+	payload, err := EncodeMsgpack(c)
+	if err != nil {
+		return LeaseGrant{}, fmt.Errorf("encode lease claim: %w", err)
+	}
+	if _, err := s.log.AppendTurn(ctx, &AppendRequest{
+		ContextID:   s.contextID,
+		TypeID:      LeaseTypeID,
+		TypeVersion: 1,
+		Payload:     payload,
+	}); err != nil {
+		return LeaseGrant{}, fmt.Errorf("append lease claim: %w", err)
+	}
+
+	turns, err := s.readClaims(ctx, ttl)
+	if err != nil {
+		return LeaseGrant{}, fmt.Errorf("read lease claims: %w", err)
+	}
+	return replayLeaseClaims(turns, g.ContextID), nil
+}
+
+// readClaims returns the recent end of the lease log, reaching back until
+// the oldest claim read was issued at least twice the longest TTL ago. The
+// doubled TTL leaves room for clock skew between writers.
+func (s *ContextLeaseStore) readClaims(ctx context.Context, ttl time.Duration) ([]TurnRecord, error) {
+	now := s.now().UnixMilli()
+	for limit := uint32(leaseLogPage); ; limit *= 2 {
+		turns, err := s.log.GetLast(ctx, s.contextID, GetLastOptions{Limit: limit, IncludePayload: true})
+		if err != nil {
+			return nil, err
+		}
+		if uint32(len(turns)) < limit || limit > math.MaxUint32/2 {
+			return turns, nil
+		}
+		maxTTL, oldest := ttl.Milliseconds(), int64(math.MaxInt64)
+		for _, t := range turns {
+			var c leaseClaim
+			if t.TypeID != LeaseTypeID || DecodeMsgpackInto(t.Payload, &c) != nil {
+				continue
+			}
+			maxTTL = max(maxTTL, c.TTLMs)
+			oldest = min(oldest, c.IssuedAtUnixMs)
+		}
+		if oldest <= now-2*maxTTL {
+			return turns, nil
+		}
+	}
+}
+
+// replayLeaseClaims returns the lease holder after applying turns, oldest
+// first. A renew is accepted as an acquire when the turns hold no live
+// lease, so holders survive their acquire claim falling out of the read.
+//
+// The read may also start just before a competitor's acquire that was
+// rejected against claims no longer visible, which then looks like a win.
+// A renew whose previous expiry falls after the current holder took over
+// shows the renewer was live at that moment, so the takeover could not have
+// happened and the renewer keeps the lease.
+func replayLeaseClaims(turns []TurnRecord, contextID uint64) LeaseGrant {
+	var cur LeaseGrant
+	var curExpiry, curSince int64
+	for _, t := range turns {
+		if t.TypeID != LeaseTypeID {
+			continue
+		}
+		var c leaseClaim
+		if err := DecodeMsgpackInto(t.Payload, &c); err != nil || c.ContextID != contextID {
+			continue
+		}
+		free := cur.Token == "" || c.IssuedAtUnixMs >= curExpiry
+		mine := cur.Token == c.Token
+		live := c.Op == "renew" && c.PrevExpiresAtUnixMs > curSince
+		switch c.Op {
+		case "acquire", "renew":
+			if mine {
+				curExpiry = c.IssuedAtUnixMs + c.TTLMs
+			} else if free || live {
+				cur = LeaseGrant{ContextID: contextID, Holder: c.Holder, Token: c.Token}
+				curExpiry = c.IssuedAtUnixMs + c.TTLMs
+				curSince = c.IssuedAtUnixMs
+			}
+		case "release":
+			if mine {
+				cur, curExpiry, curSince = LeaseGrant{}, 0, 0
+			}
+		}
+	}
+	if cur.Token != "" {
+		cur.ExpiresAt = time.UnixMilli(curExpiry)
+	}
+	return cur
+}
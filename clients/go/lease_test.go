@@ -0,0 +1,223 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
+)
+
+// skewClock is a time source that can be moved forward concurrently.
+type skewClock struct{ offset atomic.Int64 }
+
+func (c *skewClock) now() time.Time          { return time.Now().Add(time.Duration(c.offset.Load())) }
+func (c *skewClock) advance(d time.Duration) { c.offset.Add(int64(d)) }
+
+func TestMemoryLeaseStore(t *testing.T) {
+	ctx := context.Background()
+	clock := &skewClock{}
+	store := NewMemoryLeaseStore()
+	store.now = clock.now
+
+	a, err := store.Acquire(ctx, LeaseGrant{ContextID: 1, Holder: "a", Token: "ta"}, time.Minute)
+	if err != nil {
+		t.Fatalf("acquire a: %v", err)
+	}
+	if _, err := store.Acquire(ctx, LeaseGrant{ContextID: 1, Holder: "b", Token: "tb"}, time.Minute); !errors.Is(err, ErrLeaseHeld) {
+		t.Fatalf("acquire b: err = %v, want ErrLeaseHeld", err)
+	}
+	if _, err := store.Acquire(ctx, LeaseGrant{ContextID: 2, Holder: "b", Token: "tb"}, time.Minute); err != nil {
+		t.Fatalf("acquire other context: %v", err)
+	}
+	if _, err := store.Renew(ctx, a, time.Minute); err != nil {
+		t.Fatalf("renew a: %v", err)
+	}
+
+	clock.advance(2 * time.Minute)
+	if _, err := store.Acquire(ctx, LeaseGrant{ContextID: 1, Holder: "b", Token: "tb"}, time.Minute); err != nil {
+		t.Fatalf("acquire after expiry: %v", err)
+	}
+	if _, err := store.Renew(ctx, a, time.Minute); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("renew stale lease: err = %v, want ErrLeaseLost", err)
+	}
+	if err := store.Release(ctx, a); err != nil {
+		t.Fatalf("release stale lease: %v", err)
+	}
+	if _, err := store.Acquire(ctx, LeaseGrant{ContextID: 1, Holder: "a", Token: "ta2"}, time.Minute); !errors.Is(err, ErrLeaseHeld) {
+		t.Fatalf("stale release freed the lease: err = %v", err)
+	}
+}
+
+func TestContextLeaseStore(t *testing.T) {
+	ctx := context.Background()
+	srv := &memStore{blobs: make(map[[32]byte][]byte)}
+	c := pipeClient(t, srv.handle)
+	clock := &skewClock{}
+	store := NewContextLeaseStore(c, 1)
+	store.now = clock.now
+
+	a, err := store.Acquire(ctx, LeaseGrant{ContextID: 7, Holder: "a", Token: "ta"}, time.Minute)
+	if err != nil {
+		t.Fatalf("acquire a: %v", err)
+	}
+	if a.Holder != "a" || a.ExpiresAt.Before(clock.now()) {
+		t.Fatalf("grant = %+v", a)
+	}
+	if _, err := store.Acquire(ctx, LeaseGrant{ContextID: 7, Holder: "b", Token: "tb"}, time.Minute); !errors.Is(err, ErrLeaseHeld) {
+		t.Fatalf("acquire b: err = %v, want ErrLeaseHeld", err)
+	}
+	if _, err := store.Renew(ctx, LeaseGrant{ContextID: 7, Holder: "b", Token: "tb"}, time.Minute); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("renew by non-holder: err = %v, want ErrLeaseLost", err)
+	}
+	if _, err := store.Renew(ctx, a, time.Minute); err != nil {
+		t.Fatalf("renew a: %v", err)
+	}
+
+	if err := store.Release(ctx, a); err != nil {
+		t.Fatalf("release a: %v", err)
+	}
+	b, err := store.Acquire(ctx, LeaseGrant{ContextID: 7, Holder: "b", Token: "tb"}, time.Minute)
+	if err != nil {
+		t.Fatalf("acquire b after release: %v", err)
+	}
+
+	clock.advance(2 * time.Minute)
+	if _, err := store.Acquire(ctx, LeaseGrant{ContextID: 7, Holder: "a", Token: "ta2"}, time.Minute); err != nil {
+		t.Fatalf("acquire after expiry: %v", err)
+	}
+	if _, err := store.Renew(ctx, b, time.Minute); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("renew expired lease: err = %v, want ErrLeaseLost", err)
+	}
+
+	for _, turn := range srv.turns {
+		if turn.TypeID != LeaseTypeID {
+			t.Fatalf("unexpected turn type %q in lease context", turn.TypeID)
+		}
+	}
+}
+
+func TestContextLeaseStoreBeyondWindow(t *testing.T) {
+	ctx := context.Background()
+	srv := &memStore{blobs: make(map[[32]byte][]byte)}
+	c := pipeClient(t, srv.handle)
+	store := NewContextLeaseStore(c, 1)
+
+	// Another lease in the same log pushes older claims past the first page.
+	x, err := store.Acquire(ctx, LeaseGrant{ContextID: 8, Holder: "x", Token: "tx"}, time.Minute)
+	if err != nil {
+		t.Fatalf("acquire x: %v", err)
+	}
+	filler := func(n int) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			if x, err = store.Renew(ctx, x, time.Minute); err != nil {
+				t.Fatalf("renew x: %v", err)
+			}
+		}
+	}
+
+	a, err := store.Acquire(ctx, LeaseGrant{ContextID: 7, Holder: "a", Token: "ta"}, time.Minute)
+	if err != nil {
+		t.Fatalf("acquire a: %v", err)
+	}
+	filler(leaseLogPage)
+	if _, err := store.Acquire(ctx, LeaseGrant{ContextID: 7, Holder: "c", Token: "tc"}, time.Minute); !errors.Is(err, ErrLeaseHeld) {
+		t.Fatalf("acquire c while a's only claim is past the first page: err = %v, want ErrLeaseHeld", err)
+	}
+	if a, err = store.Renew(ctx, a, time.Minute); err != nil {
+		t.Fatalf("renew a after its acquire left the window: %v", err)
+	}
+	if _, err := store.Acquire(ctx, LeaseGrant{ContextID: 7, Holder: "b", Token: "tb"}, time.Minute); !errors.Is(err, ErrLeaseHeld) {
+		t.Fatalf("acquire b: err = %v, want ErrLeaseHeld", err)
+	}
+
+	// Leave b's rejected acquire as the oldest claim for context 7 in the
+	// first page; a must still hold the lease.
+	filler(leaseLogPage - 2)
+	if a, err = store.Renew(ctx, a, time.Minute); err != nil {
+		t.Fatalf("renew a after b's rejected acquire: %v", err)
+	}
+	if _, err := store.Renew(ctx, LeaseGrant{ContextID: 7, Holder: "b", Token: "tb"}, time.Minute); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("renew by b: err = %v, want ErrLeaseLost", err)
+	}
+	if len(srv.turns) <= 2*leaseLogPage {
+		t.Fatalf("only %d claims written", len(srv.turns))
+	}
+}
+
+func TestLeaseRequireBlocksAppends(t *testing.T) {
+	ctx := context.Background()
+	srv := &memStore{blobs: make(map[[32]byte][]byte)}
+	c := pipeClient(t, srv.handle)
+	c.leases = newLeaseSet(LeaseRequire)
+	req := &AppendRequest{ContextID: 1, TypeID: "com.example.Message", TypeVersion: 1, Payload: []byte{0x80}}
+
+	if _, err := c.AppendTurn(ctx, req); !errors.Is(err, ErrNoLease) {
+		t.Fatalf("append without lease: err = %v, want ErrNoLease", err)
+	}
+
+	lease, err := c.AcquireLease(ctx, NewMemoryLeaseStore(), 1, WithLeaseHolder("replica-a"))
+	if err != nil {
+		t.Fatalf("AcquireLease: %v", err)
+	}
+	if lease.Holder() != "replica-a" || !lease.Valid() {
+		t.Fatalf("lease holder=%q valid=%v", lease.Holder(), lease.Valid())
+	}
+	if _, err := c.AppendTurn(ctx, req); err != nil {
+		t.Fatalf("append with lease: %v", err)
+	}
+
+	if err := lease.Release(ctx); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if _, err := c.AppendTurn(ctx, req); !errors.Is(err, ErrNoLease) {
+		t.Fatalf("append after release: err = %v, want ErrNoLease", err)
+	}
+}
+
+func TestLeaseHeartbeatDetectsTakeover(t *testing.T) {
+	ctx := context.Background()
+	clock := &skewClock{}
+	store := NewMemoryLeaseStore()
+	store.now = clock.now
+	c := pipeClient(t, func(uint16, []byte) (uint16, uint16, []byte) { return wire.MsgError, 0, nil })
+	c.leases = newLeaseSet(LeaseRequire)
+
+	lease, err := c.AcquireLease(ctx, store, 3, WithLeaseTTL(30*time.Millisecond))
+	if err != nil {
+		t.Fatalf("AcquireLease: %v", err)
+	}
+
+	// Renewals keep the lease alive well past its TTL.
+	time.Sleep(100 * time.Millisecond)
+	if !lease.Valid() {
+		t.Fatalf("lease not renewed: %v", lease.Err())
+	}
+
+	// Another writer takes over once the store sees the lease as expired.
+	clock.advance(time.Minute)
+	if _, err := store.Acquire(ctx, LeaseGrant{ContextID: 3, Holder: "other", Token: "other"}, time.Hour); err != nil {
+		t.Fatalf("takeover: %v", err)
+	}
+
+	select {
+	case <-lease.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("lease loss not detected")
+	}
+	if !errors.Is(lease.Err(), ErrLeaseLost) {
+		t.Fatalf("Err() = %v, want ErrLeaseLost", lease.Err())
+	}
+	if err := c.leases.check(3, "com.example.Message"); !errors.Is(err, ErrNoLease) {
+		t.Fatalf("check after loss: err = %v, want ErrNoLease", err)
+	}
+	if err := lease.Release(ctx); err != nil {
+		t.Fatalf("Release after loss: %v", err)
+	}
+}
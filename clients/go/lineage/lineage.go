@@ -0,0 +1,223 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+// Package lineage fetches context lineage trees from the gateway.
+//
+// Every context that was spawned from another records ParentContextID and
+// SpawnReason in its provenance. The gateway links those records into a
+// forest of roots, forks, quests, delegations, and sub-agents suitable for
+// rendering agent activity maps, and serves it at GET /v1/contexts/tree.
+//
+// # Basic Usage
+//
+//	tree, err := lineage.Fetch(ctx, api, lineage.WithLimit(1000))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, root := range tree.Roots {
+//	    root.Walk(func(n *lineage.Node) {
+//	        fmt.Printf("%*s%d %s %q\n", n.Depth*2, "", n.ContextID, n.Kind, n.Title)
+//	    })
+//	}
+package lineage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/strongdm/ai-cxdb/clients/go/httpclient"
+)
+
+// Node kinds. Contexts with a parent take their kind from the provenance
+// SpawnReason, or KindFork if none was recorded; other reasons are passed
+// through unchanged.
+const (
+	KindRoot       = "root"
+	KindFork       = "fork"
+	KindQuest      = "quest"
+	KindDelegation = "delegation"
+	KindSubAgent   = "sub_agent"
+)
+
+// Node is one context in a lineage tree.
+type Node struct {
+	ContextID       uint64
+	ParentContextID uint64
+	Kind            string
+	Title           string
+	ClientTag       string
+	OnBehalfOf      string
+	IsLive          bool
+	HeadDepth       int
+	CreatedAtUnixMs int64
+	LastActivityAt  int64
+
+	// Depth is the distance from the node's root.
+	Depth int
+
+	// Descendants counts every node below this one.
+	Descendants int
+
+	// Detached is set on roots whose parent is outside the listing or part
+	// of a parent cycle.
+	Detached bool
+
+	// Children are ordered oldest first.
+	Children []*Node
+}
+
+// Walk calls fn for n and its descendants, depth first.
+func (n *Node) Walk(fn func(*Node)) {
+	fn(n)
+	for _, c := range n.Children {
+		c.Walk(fn)
+	}
+}
+
+// Tree is a lineage forest.
+type Tree struct {
+	// Roots are ordered oldest first.
+	Roots []*Node
+
+	// Count is the number of nodes in the forest.
+	Count int
+
+	// ByKind counts nodes per kind.
+	ByKind map[string]int
+}
+
+// Find returns the node for contextID, or nil.
+func (t *Tree) Find(contextID uint64) *Node {
+	var found *Node
+	for _, root := range t.Roots {
+		root.Walk(func(n *Node) {
+			if n.ContextID == contextID {
+				found = n
+			}
+		})
+		if found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// Subtree returns the tree whose root holds contextID, or nil.
+func (t *Tree) Subtree(contextID uint64) *Tree {
+	for _, root := range t.Roots {
+		sub := &Tree{Roots: []*Node{root}, ByKind: make(map[string]int)}
+		var found bool
+		root.Walk(func(n *Node) {
+			sub.Count++
+			sub.ByKind[n.Kind]++
+			found = found || n.ContextID == contextID
+		})
+		if found {
+			return sub
+		}
+	}
+	return nil
+}
+
+// =============================================================================
+// Fetching
+// =============================================================================
+
+type fetchOptions struct {
+	limit int
+	tag   string
+	root  uint64
+}
+
+// FetchOption configures Fetch.
+type FetchOption func(*fetchOptions)
+
+// WithLimit sets how many recently active contexts to consider. Default: 500.
+func WithLimit(n int) FetchOption {
+	return func(o *fetchOptions) {
+		o.limit = n
+	}
+}
+
+// WithTag restricts the tree to contexts with the given client tag.
+func WithTag(tag string) FetchOption {
+	return func(o *fetchOptions) {
+		o.tag = tag
+	}
+}
+
+// WithRoot returns only the tree that contains contextID.
+func WithRoot(contextID uint64) FetchOption {
+	return func(o *fetchOptions) {
+		o.root = contextID
+	}
+}
+
+// Fetch returns the lineage tree of recently active contexts from the
+// gateway.
+func Fetch(ctx context.Context, api *httpclient.Client, opts ...FetchOption) (*Tree, error) {
+	o := fetchOptions{limit: 500}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	params := &httpclient.GetContextTreeParams{Limit: o.limit, Tag: o.tag}
+	if o.root != 0 {
+		params.Root = strconv.FormatUint(o.root, 10)
+	}
+	resp, err := api.GetContextTree(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("lineage: get context tree: %w", err)
+	}
+	tree := &Tree{Roots: make([]*Node, 0, len(resp.Roots)), Count: resp.Count, ByKind: make(map[string]int)}
+	if len(resp.ByKind) > 0 {
+		if err := json.Unmarshal(resp.ByKind, &tree.ByKind); err != nil {
+			return nil, fmt.Errorf("lineage: decode by_kind: %w", err)
+		}
+	}
+	for _, r := range resp.Roots {
+		n, err := newNode(r)
+		if err != nil {
+			return nil, err
+		}
+		tree.Roots = append(tree.Roots, n)
+	}
+	return tree, nil
+}
+
+// newNode converts a gateway tree node and its children.
+func newNode(r httpclient.ContextTreeNode) (*Node, error) {
+	id, err := strconv.ParseUint(r.ContextID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("lineage: invalid context_id %q", r.ContextID)
+	}
+	n := &Node{
+		ContextID:       id,
+		Kind:            r.Kind,
+		Title:           r.Title,
+		ClientTag:       r.ClientTag,
+		OnBehalfOf:      r.OnBehalfOf,
+		IsLive:          r.IsLive,
+		HeadDepth:       r.HeadDepth,
+		CreatedAtUnixMs: r.CreatedAtUnixMs,
+		LastActivityAt:  r.LastActivityAt,
+		Depth:           r.Depth,
+		Descendants:     r.Descendants,
+		Detached:        r.Detached,
+		Children:        make([]*Node, 0, len(r.Children)),
+	}
+	if r.ParentContextID != "" {
+		if n.ParentContextID, err = strconv.ParseUint(r.ParentContextID, 10, 64); err != nil {
+			return nil, fmt.Errorf("lineage: invalid parent_context_id %q", r.ParentContextID)
+		}
+	}
+	for _, c := range r.Children {
+		child, err := newNode(c)
+		if err != nil {
+			return nil, err
+		}
+		n.Children = append(n.Children, child)
+	}
+	return n, nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package lineage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/strongdm/ai-cxdb/clients/go/httpclient"
+)
+
+const treeJSON = `{
+	"roots": [
+		{"context_id": "1", "kind": "root", "on_behalf_of": "alice", "created_at_unix_ms": 10, "depth": 0, "descendants": 2, "children": [
+			{"context_id": "2", "parent_context_id": "1", "kind": "fork", "created_at_unix_ms": 20, "depth": 1, "descendants": 1, "children": [
+				{"context_id": "3", "parent_context_id": "2", "kind": "quest", "created_at_unix_ms": 30, "depth": 2, "descendants": 0, "children": []}
+			]}
+		]},
+		{"context_id": "7", "parent_context_id": "99", "kind": "delegation", "detached": true, "created_at_unix_ms": 70, "depth": 0, "descendants": 0, "children": []}
+	],
+	"count": 4,
+	"by_kind": {"root": 1, "fork": 1, "quest": 1, "delegation": 1}
+}`
+
+func treeServer(t *testing.T, body string, query *string) *httpclient.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/contexts/tree" {
+			http.NotFound(w, r)
+			return
+		}
+		*query = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return httpclient.New(srv.URL)
+}
+
+func TestFetch(t *testing.T) {
+	var query string
+	api := treeServer(t, treeJSON, &query)
+
+	tree, err := Fetch(context.Background(), api, WithLimit(1000), WithTag("agents"), WithRoot(3))
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if query != "limit=1000&root=3&tag=agents" {
+		t.Errorf("query = %q", query)
+	}
+	if len(tree.Roots) != 2 || tree.Count != 4 || tree.ByKind[KindFork] != 1 || tree.ByKind[KindDelegation] != 1 {
+		t.Fatalf("tree = %+v", tree)
+	}
+	root := tree.Roots[0]
+	if root.ContextID != 1 || root.Kind != KindRoot || root.OnBehalfOf != "alice" || root.Descendants != 2 {
+		t.Fatalf("root = %+v", root)
+	}
+	quest := tree.Find(3)
+	if quest == nil || quest.Kind != KindQuest || quest.Depth != 2 || quest.ParentContextID != 2 {
+		t.Fatalf("quest node = %+v", quest)
+	}
+	if detached := tree.Find(7); detached == nil || !detached.Detached || detached.ParentContextID != 99 {
+		t.Fatalf("detached node = %+v", detached)
+	}
+
+	var seen int
+	for _, root := range tree.Roots {
+		root.Walk(func(*Node) { seen++ })
+	}
+	if seen != tree.Count {
+		t.Fatalf("walked %d nodes, want %d", seen, tree.Count)
+	}
+}
+
+func TestFetchInvalidID(t *testing.T) {
+	var query string
+	api := treeServer(t, `{"roots":[{"context_id":"x","kind":"root","children":[]}],"count":1,"by_kind":{}}`, &query)
+	if _, err := Fetch(context.Background(), api); err == nil {
+		t.Fatal("Fetch accepted an invalid context_id")
+	}
+	if query != "limit=500" {
+		t.Errorf("query = %q", query)
+	}
+}
+
+func TestSubtree(t *testing.T) {
+	var query string
+	tree, err := Fetch(context.Background(), treeServer(t, treeJSON, &query))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := tree.Subtree(3)
+	if sub == nil || len(sub.Roots) != 1 || sub.Roots[0].ContextID != 1 || sub.Count != 3 {
+		t.Fatalf("subtree = %+v", sub)
+	}
+	if tree.Subtree(42) != nil {
+		t.Fatal("subtree of unknown context should be nil")
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SDKVersion is the version of this client library, compared against the
+// minimum client version in server notices.
+const SDKVersion = "0.1.0"
+
+// ServerNotice is an advisory the server attaches to the HELLO response,
+// such as the deprecation of a protocol version or of old client releases.
+// Notices give embedded agents warning before a server upgrade breaks them.
+type ServerNotice struct {
+	// Kind classifies the notice, e.g. "deprecation" or "upgrade".
+	Kind string
+
+	// Message is a human-readable description.
+	Message string
+
+	// MinClientVersion is the oldest SDK release the server supports, or
+	// will support after Sunset. Empty if the notice does not say.
+	MinClientVersion string
+
+	// Sunset is when the deprecated behavior stops working; zero if unset.
+	Sunset time.Time
+
+	// URL points at upgrade instructions.
+	URL string
+}
+
+// Unsupported reports whether this SDK is older than MinClientVersion.
+func (n ServerNotice) Unsupported() bool {
+	return n.MinClientVersion != "" && compareVersions(SDKVersion, n.MinClientVersion) < 0
+}
+
+// ServerNotices returns the notices the server sent in the HELLO handshake.
+// Servers that predate notices send none.
+func (c *Client) ServerNotices() []ServerNotice {
+	return append([]ServerNotice(nil), c.notices...)
+}
+
+// helloNotices is the JSON that may follow session_id and protocol_version
+// in a HELLO response, prefixed by its u32 length.
+type helloNotices struct {
+	Notices []struct {
+		Kind             string `json:"kind"`
+		Message          string `json:"message"`
+		MinClientVersion string `json:"min_client_version"`
+		Sunset           string `json:"sunset"` // RFC 3339 date or timestamp
+		URL              string `json:"url"`
+	} `json:"notices"`
+}
+
+// parseHelloNotices decodes the notices section of a HELLO response, given
+// the bytes after protocol_version. An absent section yields no notices.
+func parseHelloNotices(data []byte) ([]ServerNotice, error) {
+	if len(data) < 4 {
+		return nil, nil
+	}
+	n := binary.LittleEndian.Uint32(data[0:4])
+	if uint64(n) > uint64(len(data)-4) {
+		return nil, fmt.Errorf("notices truncated: %d of %d bytes", len(data)-4, n)
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	var raw helloNotices
+	if err := json.Unmarshal(data[4:4+n], &raw); err != nil {
+		return nil, fmt.Errorf("notices: %w", err)
+	}
+	notices := make([]ServerNotice, 0, len(raw.Notices))
+	for _, r := range raw.Notices {
+		notice := ServerNotice{
+			Kind:             r.Kind,
+			Message:          r.Message,
+			MinClientVersion: r.MinClientVersion,
+			URL:              r.URL,
+		}
+		if r.Sunset != "" {
+			notice.Sunset = parseSunset(r.Sunset)
+		}
+		notices = append(notices, notice)
+	}
+	return notices, nil
+}
+
+func parseSunset(s string) time.Time {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t
+	}
+	t, _ := time.Parse(time.DateOnly, s)
+	return t
+}
+
+// loggedNotices remembers notices already logged by this process, so a
+// fleet of reconnecting clients warns once rather than on every handshake.
+var loggedNotices sync.Map
+
+// logNotices logs each notice the first time this process sees it.
+func logNotices(notices []ServerNotice) {
+	for _, n := range notices {
+		key := n.Kind + "\x00" + n.Message + "\x00" + n.MinClientVersion
+		if _, seen := loggedNotices.LoadOrStore(key, true); seen {
+			continue
+		}
+		attrs := []any{
+			"kind", n.Kind,
+			"message", n.Message,
+			"sdk_version", SDKVersion,
+		}
+		if n.MinClientVersion != "" {
+			attrs = append(attrs, "min_client_version", n.MinClientVersion)
+		}
+		if !n.Sunset.IsZero() {
+			attrs = append(attrs, "sunset", n.Sunset.Format(time.DateOnly))
+		}
+		if n.URL != "" {
+			attrs = append(attrs, "url", n.URL)
+		}
+		if n.Unsupported() {
+			slog.Error("[cxdb] server notice: client version no longer supported", attrs...)
+		} else {
+			slog.Warn("[cxdb] server notice", attrs...)
+		}
+	}
+}
+
+// compareVersions compares dotted numeric versions such as "1.4.0" or
+// "v1.4", ignoring any pre-release or build suffix. Missing components
+// count as zero.
+func compareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(v string) []int {
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	var parts []int
+	for _, s := range strings.Split(v, ".") {
+		n, _ := strconv.Atoi(s)
+		parts = append(parts, n)
+	}
+	return parts
+}
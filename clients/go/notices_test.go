@@ -0,0 +1,83 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func helloWithNotices(notices string) []byte {
+	resp := binary.LittleEndian.AppendUint64(nil, 42)
+	resp = binary.LittleEndian.AppendUint16(resp, 1)
+	if notices != "" {
+		resp = binary.LittleEndian.AppendUint32(resp, uint32(len(notices)))
+		resp = append(resp, notices...)
+	}
+	return resp
+}
+
+func TestHelloServerNotices(t *testing.T) {
+	tests := []struct {
+		name  string
+		hello []byte
+		want  []ServerNotice
+	}{
+		{"legacy server", helloWithNotices(""), nil},
+		{
+			"deprecation",
+			helloWithNotices(`{"notices":[
+				{"kind":"deprecation","message":"protocol v1 ends soon","min_client_version":"99.0","sunset":"2026-03-01","url":"https://example.com/upgrade"},
+				{"kind":"upgrade","message":"new release","sunset":"2026-04-01T12:00:00Z"}]}`),
+			[]ServerNotice{
+				{
+					Kind:             "deprecation",
+					Message:          "protocol v1 ends soon",
+					MinClientVersion: "99.0",
+					Sunset:           time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+					URL:              "https://example.com/upgrade",
+				},
+				{Kind: "upgrade", Message: "new release", Sunset: time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)},
+			},
+		},
+		{"malformed", helloWithNotices(`{"notices":`), nil},
+	}
+	for _, tt := range tests {
+		c := pipeClient(t, func(msgType uint16, _ []byte) (uint16, uint16, []byte) {
+			return msgType, 0, tt.hello
+		})
+		if err := c.sendHello("agent"); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if c.SessionID() != 42 {
+			t.Errorf("%s: session %d", tt.name, c.SessionID())
+		}
+		got := c.ServerNotices()
+		if len(got) != len(tt.want) {
+			t.Fatalf("%s: notices = %+v", tt.name, got)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: notice %d = %+v, want %+v", tt.name, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestServerNoticeUnsupported(t *testing.T) {
+	for minVersion, want := range map[string]bool{
+		"":             false,
+		SDKVersion:     false,
+		"0.0.9":        false,
+		"v0.1":         false,
+		"0.1.1":        true,
+		"0.10.0":       true,
+		"1.0.0-beta.1": true,
+	} {
+		if got := (ServerNotice{MinClientVersion: minVersion}).Unsupported(); got != want {
+			t.Errorf("MinClientVersion %q: Unsupported() = %v, want %v", minVersion, got, want)
+		}
+	}
+}
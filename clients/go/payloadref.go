@@ -0,0 +1,159 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// PayloadRefSize is the size of an encoded PayloadRef stub.
+const PayloadRefSize = 44
+
+// PayloadRef is the payload of an EncodingBlobRef turn: it points at a blob
+// holding the real payload and records how that payload is encoded.
+//
+// Stub layout (little-endian):
+//
+//	hash:        [32]u8  BLAKE3-256 of the blob
+//	encoding:    u32     encoding of the blob contents
+//	compression: u32     compression of the blob contents
+//	length:      u32     blob length in bytes
+type PayloadRef struct {
+	Hash        [32]byte
+	Encoding    uint32
+	Compression uint32
+	Length      uint32
+}
+
+// Encode returns the stub bytes.
+func (r *PayloadRef) Encode() []byte {
+	buf := make([]byte, PayloadRefSize)
+	copy(buf[0:32], r.Hash[:])
+	binary.LittleEndian.PutUint32(buf[32:36], r.Encoding)
+	binary.LittleEndian.PutUint32(buf[36:40], r.Compression)
+	binary.LittleEndian.PutUint32(buf[40:44], r.Length)
+	return buf
+}
+
+// ParsePayloadRef decodes a stub.
+func ParsePayloadRef(data []byte) (*PayloadRef, error) {
+	if len(data) != PayloadRefSize {
+		return nil, fmt.Errorf("%w: payload ref is %d bytes, want %d", ErrInvalidResponse, len(data), PayloadRefSize)
+	}
+	r := &PayloadRef{
+		Encoding:    binary.LittleEndian.Uint32(data[32:36]),
+		Compression: binary.LittleEndian.Uint32(data[36:40]),
+		Length:      binary.LittleEndian.Uint32(data[40:44]),
+	}
+	copy(r.Hash[:], data[0:32])
+	return r, nil
+}
+
+// externalizePayload stores a payload above the client's threshold, or too
+// large for the server's learnt append limit, as a blob and returns a copy
+// of req whose payload is the stub. force externalizes any payload. Other
+// requests are returned unchanged.
+func (c *Client) externalizePayload(ctx context.Context, req *AppendRequest, force bool) (*AppendRequest, error) {
+	// Servers without blob-ref support project the stub as if it were the
+	// item, which breaks turn listings, so only send stubs to servers that
+	// advertised it.
+	if !c.blobRefs {
+		return req, nil
+	}
+	if !force && !c.payloadOversized(req) {
+		return req, nil
+	}
+	encoding := req.Encoding
+	if encoding == 0 {
+		encoding = EncodingMsgpack
+	}
+	if encoding == EncodingBlobRef {
+		return req, nil
+	}
+
+	blob, err := c.PutBlob(WithUsageContext(ctx, req.ContextID), &PutBlobRequest{Data: req.Payload})
+	if err != nil {
+		return nil, fmt.Errorf("externalize payload: %w", err)
+	}
+	ref := &PayloadRef{
+		Hash:        blob.Hash,
+		Encoding:    encoding,
+		Compression: req.Compression,
+		Length:      uint32(len(req.Payload)),
+	}
+	stub := *req
+	stub.Payload = ref.Encode()
+	stub.Encoding = EncodingBlobRef
+	stub.Compression = CompressionNone
+	return &stub, nil
+}
+
+// blobFallback handles an append of req, sent as sent, that failed with
+// err. If the server rejected it as too large and the payload was inline,
+// it remembers the server's limit and returns req with the payload stored
+// as a blob, to be sent again, if the server accepts blob refs. Otherwise
+// it returns nil.
+func (c *Client) blobFallback(ctx context.Context, req, sent *AppendRequest, err error) (*AppendRequest, error) {
+	var tooLarge *PayloadTooLargeError
+	if !errors.As(err, &tooLarge) || sent.Encoding == EncodingBlobRef {
+		return nil, nil
+	}
+	if tooLarge.Limit > 0 {
+		c.payloadLimit.Store(tooLarge.Limit)
+	}
+	if !c.blobRefs {
+		return nil, nil
+	}
+	slog.Warn("[cxdb] server rejected an oversized append, retrying with the payload as a blob",
+		"context_id", req.ContextID, "size", len(req.Payload), "limit", tooLarge.Limit)
+	retry, xerr := c.externalizePayload(ctx, req, true)
+	if xerr != nil {
+		return nil, fmt.Errorf("append turn: %w", errors.Join(err, xerr))
+	}
+	return retry, nil
+}
+
+// payloadOversized reports whether req's payload is above the blob
+// threshold or would push the append over the server's limit.
+func (c *Client) payloadOversized(req *AppendRequest) bool {
+	if c.payloadBlobThreshold > 0 && len(req.Payload) > c.payloadBlobThreshold {
+		return true
+	}
+	limit := c.payloadLimit.Load()
+	return limit > 0 && uint64(appendOverhead(req)+len(req.Payload)) > limit
+}
+
+// appendOverhead is the size of an APPEND_TURN payload less the turn
+// payload itself, with room for a filesystem root.
+func appendOverhead(req *AppendRequest) int {
+	return 8 + 8 + 4 + len(req.TypeID) + 4 + 4 + 4 + 4 + 32 + 4 + 4 + len(req.IdempotencyKey) + 32
+}
+
+// resolvePayloads replaces EncodingBlobRef payloads with the referenced
+// blobs, restoring the original encoding and compression.
+func (c *Client) resolvePayloads(ctx context.Context, turns []TurnRecord) error {
+	for i := range turns {
+		t := &turns[i]
+		if t.Encoding != EncodingBlobRef || t.Payload == nil {
+			continue
+		}
+		ref, err := ParsePayloadRef(t.Payload)
+		if err != nil {
+			return fmt.Errorf("resolve turn %d payload: %w", t.TurnID, err)
+		}
+		data, err := c.GetBlob(ctx, ref.Hash)
+		if err != nil {
+			return fmt.Errorf("resolve turn %d payload: %w", t.TurnID, err)
+		}
+		t.PayloadRef = ref
+		t.Payload = data
+		t.Encoding = ref.Encoding
+		t.Compression = ref.Compression
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"sync"
+	"testing"
+
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
+	"github.com/zeebo/blake3"
+)
+
+// memStore is an in-memory server for PUT_BLOB, GET_BLOB, APPEND_TURN and
+// GET_LAST. It keeps a single context. Its HELLO lists blob-ref support
+// unless legacy is set.
+type memStore struct {
+	mu     sync.Mutex
+	blobs  map[[32]byte][]byte
+	turns  []TurnRecord
+	legacy bool
+}
+
+func (m *memStore) handle(msgType uint16, p []byte) (uint16, uint16, []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	le := binary.LittleEndian
+	switch msgType {
+	case wire.MsgHello:
+		if m.legacy {
+			return msgType, 0, helloWithIdentity(`{}`)
+		}
+		return msgType, 0, helloWithCapabilities(`{"capabilities":["blob_ref_payloads"]}`)
+
+	case wire.MsgPutBlob:
+		var hash [32]byte
+		copy(hash[:], p[0:32])
+		_, existed := m.blobs[hash]
+		m.blobs[hash] = append([]byte(nil), p[36:]...)
+		resp := append(hash[:], 1)
+		if existed {
+			resp[32] = 0
+		}
+		return msgType, 0, resp
+
+	case wire.MsgGetBlob:
+		var hash [32]byte
+		copy(hash[:], p)
+		data, ok := m.blobs[hash]
+		if !ok {
+			resp := le.AppendUint32(le.AppendUint32(nil, 404), 0)
+			return wire.MsgError, 0, resp
+		}
+		return msgType, 0, append(le.AppendUint32(nil, uint32(len(data))), data...)
+
+	case wire.MsgAppend:
+		typeLen := le.Uint32(p[16:20])
+		off := 20 + int(typeLen)
+		rec := TurnRecord{
+			TurnID:      uint64(len(m.turns) + 1),
+			Depth:       uint32(len(m.turns) + 1),
+			TypeID:      string(p[20:off]),
+			TypeVersion: le.Uint32(p[off:]),
+			Encoding:    le.Uint32(p[off+4:]),
+			Compression: le.Uint32(p[off+8:]),
+		}
+		copy(rec.PayloadHash[:], p[off+16:off+48])
+		n := le.Uint32(p[off+48:])
+		rec.Payload = append([]byte(nil), p[off+52:off+52+int(n)]...)
+		m.turns = append(m.turns, rec)
+
+		resp := le.AppendUint64(nil, 1)
+		resp = le.AppendUint64(resp, rec.TurnID)
+		resp = le.AppendUint32(resp, rec.Depth)
+		return msgType, 0, append(resp, rec.PayloadHash[:]...)
+
+	case wire.MsgGetLast:
+		turns := m.turns
+		if limit := int(le.Uint32(p[8:12])); len(turns) > limit {
+			turns = turns[len(turns)-limit:]
+		}
+		var b bytes.Buffer
+		_ = binary.Write(&b, le, uint32(len(turns)))
+		for _, t := range turns {
+			_ = binary.Write(&b, le, t.TurnID)
+			_ = binary.Write(&b, le, t.ParentID)
+			_ = binary.Write(&b, le, t.Depth)
+			_ = binary.Write(&b, le, uint32(len(t.TypeID)))
+			b.WriteString(t.TypeID)
+			_ = binary.Write(&b, le, t.TypeVersion)
+			_ = binary.Write(&b, le, t.Encoding)
+			_ = binary.Write(&b, le, t.Compression)
+			_ = binary.Write(&b, le, uint32(len(t.Payload)))
+			b.Write(t.PayloadHash[:])
+			_ = binary.Write(&b, le, uint32(len(t.Payload)))
+			b.Write(t.Payload)
+		}
+		return msgType, 0, b.Bytes()
+	}
+	return wire.MsgError, 0, le.AppendUint32(le.AppendUint32(nil, 400), 0)
+}
+
+func TestPayloadBlobThreshold(t *testing.T) {
+	store := &memStore{blobs: make(map[[32]byte][]byte)}
+	c := helloClient(t, store.handle)
+	c.payloadBlobThreshold = 64
+	ctx := context.Background()
+
+	small := []byte("small payload")
+	big := bytes.Repeat([]byte("x"), 1000)
+	for _, p := range [][]byte{small, big} {
+		if _, err := c.AppendTurn(ctx, &AppendRequest{ContextID: 1, TypeID: "t", TypeVersion: 1, Payload: p}); err != nil {
+			t.Fatalf("AppendTurn: %v", err)
+		}
+	}
+
+	if got := store.turns[0]; got.Encoding != EncodingMsgpack || !bytes.Equal(got.Payload, small) {
+		t.Errorf("small payload stored as encoding %d, %d bytes", got.Encoding, len(got.Payload))
+	}
+	stored := store.turns[1]
+	if stored.Encoding != EncodingBlobRef || len(stored.Payload) != PayloadRefSize {
+		t.Fatalf("big payload stored as encoding %d, %d bytes", stored.Encoding, len(stored.Payload))
+	}
+	if _, ok := store.blobs[blake3.Sum256(big)]; !ok {
+		t.Fatal("big payload was not stored as a blob")
+	}
+
+	turns, err := c.GetLast(ctx, 1, GetLastOptions{IncludePayload: true})
+	if err != nil {
+		t.Fatalf("GetLast: %v", err)
+	}
+	resolved := turns[1]
+	if !bytes.Equal(resolved.Payload, big) || resolved.Encoding != EncodingMsgpack || resolved.PayloadRef == nil {
+		t.Errorf("resolved turn: encoding %d, %d bytes, ref %v", resolved.Encoding, len(resolved.Payload), resolved.PayloadRef)
+	}
+	if turns[0].PayloadRef != nil {
+		t.Error("inline turn has a PayloadRef")
+	}
+
+	raw, err := c.GetLast(ctx, 1, GetLastOptions{IncludePayload: true, KeepPayloadRefs: true})
+	if err != nil {
+		t.Fatalf("GetLast raw: %v", err)
+	}
+	ref, err := ParsePayloadRef(raw[1].Payload)
+	if err != nil {
+		t.Fatalf("ParsePayloadRef: %v", err)
+	}
+	if ref.Length != uint32(len(big)) || ref.Encoding != EncodingMsgpack {
+		t.Errorf("ref = %+v", ref)
+	}
+}
+
+func TestPayloadBlobThresholdNeedsServerSupport(t *testing.T) {
+	store := &memStore{blobs: make(map[[32]byte][]byte), legacy: true}
+	c := helloClient(t, store.handle)
+	c.payloadBlobThreshold = 64
+
+	big := bytes.Repeat([]byte("x"), 1000)
+	if _, err := c.AppendTurn(context.Background(), &AppendRequest{ContextID: 1, TypeID: "t", TypeVersion: 1, Payload: big}); err != nil {
+		t.Fatalf("AppendTurn: %v", err)
+	}
+	if got := store.turns[0]; got.Encoding != EncodingMsgpack || !bytes.Equal(got.Payload, big) {
+		t.Errorf("payload stored as encoding %d, %d bytes; want it inline", got.Encoding, len(got.Payload))
+	}
+	if len(store.blobs) != 0 {
+		t.Errorf("%d blobs stored for a server without blob-ref support", len(store.blobs))
+	}
+}
+
+func TestGetBlobVerifiesHash(t *testing.T) {
+	c := pipeClient(t, func(msgType uint16, _ []byte) (uint16, uint16, []byte) {
+		return msgType, 0, append(binary.LittleEndian.AppendUint32(nil, 3), "bad"...)
+	})
+	if _, err := c.GetBlob(context.Background(), blake3.Sum256([]byte("good"))); err == nil {
+		t.Fatal("expected hash mismatch error")
+	}
+}
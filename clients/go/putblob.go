@@ -0,0 +1,64 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
+)
+
+// errBlobAbsent means a hash-first probe found no blob, so the content must
+// be sent.
+var errBlobAbsent = errors.New("cxdb: blob absent")
+
+// WithPutBlobHashFirst makes PutBlob send the hash and size of blobs of at
+// least minSize bytes before their content, and skip the content if the
+// server already has it. For snapshot uploads of mostly unchanged
+// workspaces this trades one small round trip per file for not resending
+// the bytes. Probes are sent only to servers that list CapBlobProbe in
+// their HELLO response; others always get the content. Zero (the default)
+// disables probing.
+func WithPutBlobHashFirst(minSize int) Option {
+	return func(o *clientOptions) {
+		o.hashFirstMin = minSize
+	}
+}
+
+// probePutBlob sends a PUT_BLOB carrying only hash and size. It returns the
+// result if the server has the blob and errBlobAbsent if the content must
+// be sent.
+func (c *Client) probePutBlob(ctx context.Context, hash [32]byte, size int) (*PutBlobResult, error) {
+	// Servers without probe support fail to parse the short payload and
+	// drop the connection, so only probe servers that advertised it.
+	if !c.blobProbes {
+		return nil, errBlobAbsent
+	}
+
+	payload := make([]byte, 0, 36)
+	payload = append(payload, hash[:]...)
+	payload = binary.LittleEndian.AppendUint32(payload, uint32(size))
+
+	resp, err := c.sendRequestWithFlags(ctx, wire.MsgPutBlob, RequestFlagBlobProbe, payload)
+	if err != nil {
+		if IsServerError(err, wire.CodeNotFound) {
+			c.usage.record(usageContextID(ctx), ContextUsage{BlobBytesUploaded: uint64(frameHeaderSize + len(payload))})
+			return nil, errBlobAbsent
+		}
+		return nil, err
+	}
+	c.usage.record(usageContextID(ctx), ContextUsage{BlobsUploaded: 1, BlobBytesUploaded: uint64(frameHeaderSize + len(payload))})
+
+	result, err := parsePutBlobResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	if result.Hash != hash || result.WasNew {
+		return nil, fmt.Errorf("%w: put blob probe acknowledged %x (was_new=%v)", ErrInvalidResponse, result.Hash, result.WasNew)
+	}
+	return result, nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
+)
+
+// blobStoreServer answers PUT_BLOB from an in-memory store. Payloads of
+// exactly hash+length with a nonzero length are probes; without probe
+// support the server drops the connection on them, like the server does.
+type blobStoreServer struct {
+	probes  bool // understands hash-first probes
+	stored  map[[32]byte]bool
+	puts    int
+	probesN int
+}
+
+func (s *blobStoreServer) handle(msgType uint16, p []byte) (uint16, uint16, []byte) {
+	if msgType == wire.MsgHello && s.probes {
+		return msgType, 0, helloWithCapabilities(`{"capabilities":["blob_probe"]}`)
+	}
+	if msgType == wire.MsgHello {
+		return msgType, 0, helloWithIdentity(`{}`)
+	}
+	if msgType != wire.MsgPutBlob || len(p) < 36 {
+		return wire.MsgError, 0, wire.AppendError(nil, wire.CodeInvalidInput, "unexpected message")
+	}
+	var hash [32]byte
+	copy(hash[:], p[:32])
+	n := binary.LittleEndian.Uint32(p[32:36])
+	if len(p) == 36 && n > 0 {
+		s.probesN++
+		switch {
+		case !s.probes:
+			return dropConn, 0, nil
+		case !s.stored[hash]:
+			return wire.MsgError, 0, wire.AppendError(nil, wire.CodeNotFound, "blob not found")
+		}
+		return msgType, 0, append(hash[:], 0)
+	}
+	s.puts++
+	wasNew := byte(0)
+	if !s.stored[hash] {
+		s.stored[hash] = true
+		wasNew = 1
+	}
+	return msgType, 0, append(hash[:], wasNew)
+}
+
+func TestPutBlobHashFirst(t *testing.T) {
+	ctx := context.Background()
+	big := bytes.Repeat([]byte("workspace file\n"), 100)
+
+	srv := &blobStoreServer{probes: true, stored: map[[32]byte]bool{}}
+	c := helloClient(t, srv.handle)
+	c.hashFirstMin = 1024
+
+	first, err := c.PutBlob(ctx, &PutBlobRequest{Data: big})
+	if err != nil || !first.WasNew || !first.ContentSent {
+		t.Fatalf("first put = %+v, %v", first, err)
+	}
+	again, err := c.PutBlob(ctx, &PutBlobRequest{Data: big})
+	if err != nil || again.WasNew || again.ContentSent || again.Hash != first.Hash {
+		t.Fatalf("second put = %+v, %v", again, err)
+	}
+	if _, err := c.PutBlob(ctx, &PutBlobRequest{Data: []byte("small")}); err != nil {
+		t.Fatal(err)
+	}
+	if srv.probesN != 2 || srv.puts != 2 {
+		t.Errorf("server saw %d probes and %d puts, want 2 and 2", srv.probesN, srv.puts)
+	}
+	if sent := c.usage.snapshot(false).Total().BlobBytesUploaded; sent >= uint64(2*len(big)) {
+		t.Errorf("uploaded %d bytes; the second copy should not have been sent", sent)
+	}
+
+	// A server without probe support is never asked.
+	legacy := &blobStoreServer{stored: map[[32]byte]bool{}}
+	c = helloClient(t, legacy.handle)
+	c.hashFirstMin = 1024
+	for i := 0; i < 2; i++ {
+		res, err := c.PutBlob(ctx, &PutBlobRequest{Data: big})
+		if err != nil || !res.ContentSent {
+			t.Fatalf("legacy put %d = %+v, %v", i, res, err)
+		}
+	}
+	if legacy.probesN != 0 || legacy.puts != 2 {
+		t.Errorf("legacy server saw %d probes and %d puts, want 0 and 2", legacy.probesN, legacy.puts)
+	}
+}
@@ -14,6 +14,8 @@ import (
 	"sync"
 	"syscall"
 	"time"
+
+	"github.com/strongdm/ai-cxdb/clients/go/types"
 )
 
 // Default reconnection settings
@@ -49,6 +51,30 @@ type ReconnectingClient struct {
 	maxRetryDelay time.Duration
 	onReconnect   func(sessionID uint64)
 
+	// Link state callbacks from WithOnConnect/WithOnDisconnect
+	onConnect    func(ConnectEvent)
+	onDisconnect func(DisconnectEvent)
+
+	// Per-attempt execution bound, applied once a request leaves the queue
+	execTimeout time.Duration
+
+	// Memory budget and spill directory for OpenBlob, from WithMemoryBudget
+	spill spiller
+
+	// Connection recycling; see WithMaxConnLifetime and WithIdleTimeout.
+	// The tracked fields are guarded by mu.
+	maxConnLifetime time.Duration
+	idleTimeout     time.Duration
+	tracked         *Client
+	trackedSince    time.Time
+	lastUsed        time.Time
+	recycleFailures int
+	recycleRetryAt  time.Time
+
+	// Usage counters and held leases, shared by every underlying connection
+	usage  *usageTracker
+	leases *leaseSet
+
 	// Request queue
 	queue     chan *queuedRequest
 	queueSize int
@@ -64,7 +90,7 @@ type ReconnectingClient struct {
 // queuedRequest represents a queued operation waiting to be sent.
 type queuedRequest struct {
 	ctx      context.Context
-	op       func(*Client) error
+	op       func(context.Context, *Client) error
 	resultCh chan error
 	desc     string // For logging
 }
@@ -108,6 +134,18 @@ func WithOnReconnect(fn func(sessionID uint64)) ReconnectOption {
 	}
 }
 
+// WithExecutionTimeout bounds each execution attempt of a request, measured
+// from when it leaves the queue rather than when it was submitted (default:
+// none). The caller's ctx still bounds the whole call, including time spent
+// queued during an outage; the execution timeout keeps a request that finally
+// runs from holding the connection for the rest of that budget. A retry after
+// reconnecting gets a fresh timeout.
+func WithExecutionTimeout(d time.Duration) ReconnectOption {
+	return func(rc *ReconnectingClient) {
+		rc.execTimeout = d
+	}
+}
+
 // DialReconnecting creates a client with automatic reconnection and request queuing.
 // Operations that fail due to connection errors are automatically retried after reconnection.
 func DialReconnecting(addr string, ropts []ReconnectOption, opts ...Option) (*ReconnectingClient, error) {
@@ -135,12 +173,22 @@ func dialReconnecting(addr string, useTLS bool, ropts []ReconnectOption, opts ..
 		cancel:        cancel,
 	}
 
+	// The link state callbacks are reported here rather than by each
+	// underlying Client, so a reconnect reads as one outage.
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	rc.onConnect, rc.onDisconnect = o.onConnect, o.onDisconnect
+	rc.spill = spiller{budget: o.memoryBudget, dir: o.spillDir}
+	dialOpts := append(opts[:len(opts):len(opts)], WithOnConnect(nil), WithOnDisconnect(nil))
+
 	// Set up default dial function
 	rc.dialFunc = func() (*Client, error) {
 		if useTLS {
-			return DialTLS(addr, opts...)
+			return DialTLS(addr, dialOpts...)
 		}
-		return Dial(addr, opts...)
+		return Dial(addr, dialOpts...)
 	}
 
 	// Apply options
@@ -158,6 +206,9 @@ func dialReconnecting(addr string, useTLS bool, ropts []ReconnectOption, opts ..
 		return nil, fmt.Errorf("initial connection failed: %w", err)
 	}
 	rc.client = client
+	rc.usage = client.usage
+	rc.leases = client.leases
+	rc.track(client)
 
 	// Start background sender
 	rc.wg.Add(1)
@@ -169,6 +220,7 @@ func dialReconnecting(addr string, useTLS bool, ropts []ReconnectOption, opts ..
 		"queue_size", rc.queueSize,
 		"session_id", client.SessionID(),
 	)
+	rc.connected(client, false)
 
 	return rc, nil
 }
@@ -200,10 +252,22 @@ func (rc *ReconnectingClient) processRequest(req *queuedRequest) {
 
 	rc.mu.Lock()
 	client := rc.client
+	reason := rc.recycleReason(client, time.Now())
 	rc.mu.Unlock()
 
+	// Replace a connection that NATs and load balancers may have dropped
+	// silently, rather than let this request discover it. A failed
+	// recycle keeps the current connection, so it never fails the request.
+	if reason != "" {
+		rc.recycle(client, reason)
+		rc.mu.Lock()
+		client = rc.client
+		rc.mu.Unlock()
+	}
+
 	// Try the operation
-	err := req.op(client)
+	err := rc.execute(req, client)
+	rc.touch()
 
 	// If connection error, attempt reconnect and retry
 	if err != nil && isConnectionError(err) {
@@ -211,6 +275,13 @@ func (rc *ReconnectingClient) processRequest(req *queuedRequest) {
 			"error", err,
 			"operation", req.desc,
 		)
+		if client != nil && rc.onDisconnect != nil {
+			rc.onDisconnect(DisconnectEvent{
+				SessionID: client.SessionID(),
+				Reason:    DisconnectConnectionError,
+				Err:       err,
+			})
+		}
 
 		if reconnErr := rc.reconnect(req.ctx); reconnErr != nil {
 			slog.Error("[cxdb] reconnection failed",
@@ -227,7 +298,7 @@ func (rc *ReconnectingClient) processRequest(req *queuedRequest) {
 		client = rc.client
 		rc.mu.Unlock()
 
-		err = req.op(client)
+		err = rc.execute(req, client)
 		if err != nil {
 			slog.Error("[cxdb] operation failed after reconnect",
 				"error", err,
@@ -239,8 +310,26 @@ func (rc *ReconnectingClient) processRequest(req *queuedRequest) {
 	req.resultCh <- err
 }
 
+// execute runs one attempt of a request, bounded by the execution timeout.
+func (rc *ReconnectingClient) execute(req *queuedRequest, client *Client) error {
+	ctx := req.ctx
+	if rc.execTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rc.execTimeout)
+		defer cancel()
+	}
+	return req.op(ctx, client)
+}
+
 // reconnect attempts to re-establish the connection with exponential backoff.
 func (rc *ReconnectingClient) reconnect(ctx context.Context) error {
+	// Registered first so OnConnect runs after the unlock below.
+	var restored *Client
+	defer func() {
+		if restored != nil {
+			rc.connected(restored, true)
+		}
+	}()
 	rc.mu.Lock()
 	defer rc.mu.Unlock()
 
@@ -284,7 +373,14 @@ func (rc *ReconnectingClient) reconnect(ctx context.Context) error {
 			continue
 		}
 
+		if rc.usage != nil {
+			newClient.usage = rc.usage
+		}
+		if rc.leases != nil {
+			newClient.leases = rc.leases
+		}
 		rc.client = newClient
+		rc.track(newClient)
 		slog.Info("[cxdb] reconnected successfully",
 			"attempt", attempt,
 			"new_session_id", newClient.SessionID(),
@@ -293,6 +389,7 @@ func (rc *ReconnectingClient) reconnect(ctx context.Context) error {
 		if rc.onReconnect != nil {
 			rc.onReconnect(newClient.SessionID())
 		}
+		restored = newClient
 
 		return nil
 	}
@@ -300,6 +397,17 @@ func (rc *ReconnectingClient) reconnect(ctx context.Context) error {
 	return fmt.Errorf("reconnect failed after %d attempts: %w", rc.maxRetries, lastErr)
 }
 
+// connected reports an established link to the OnConnect callback.
+func (rc *ReconnectingClient) connected(client *Client, reconnect bool) {
+	if rc.onConnect != nil {
+		rc.onConnect(ConnectEvent{
+			SessionID: client.SessionID(),
+			ClientTag: client.ClientTag(),
+			Reconnect: reconnect,
+		})
+	}
+}
+
 // drainQueue empties the queue, sending the given error to all waiting requests.
 func (rc *ReconnectingClient) drainQueue(err error) {
 	for {
@@ -313,7 +421,9 @@ func (rc *ReconnectingClient) drainQueue(err error) {
 }
 
 // enqueue adds an operation to the queue and waits for the result.
-func (rc *ReconnectingClient) enqueue(ctx context.Context, desc string, op func(*Client) error) error {
+// op receives the per-attempt execution context, which carries the
+// WithExecutionTimeout deadline; it must use that rather than ctx.
+func (rc *ReconnectingClient) enqueue(ctx context.Context, desc string, op func(context.Context, *Client) error) error {
 	rc.mu.Lock()
 	if rc.closed {
 		rc.mu.Unlock()
@@ -363,11 +473,16 @@ func (rc *ReconnectingClient) Close() error {
 		rc.wg.Wait()
 
 		rc.mu.Lock()
-		if rc.client != nil {
-			err = rc.client.Close()
+		client := rc.client
+		if client != nil {
+			err = client.Close()
 		}
 		rc.mu.Unlock()
 
+		// A nil client means the link was already reported down.
+		if client != nil && rc.onDisconnect != nil {
+			rc.onDisconnect(DisconnectEvent{SessionID: client.SessionID(), Reason: DisconnectClosed})
+		}
 		slog.Info("[cxdb] reconnecting client closed")
 	})
 	return err
@@ -394,6 +509,45 @@ func (rc *ReconnectingClient) ClientTag() string {
 	return rc.client.ClientTag()
 }
 
+// ServerNotices returns the notices sent in the current connection's HELLO
+// handshake. See Client.ServerNotices.
+func (rc *ReconnectingClient) ServerNotices() []ServerNotice {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.client == nil {
+		return nil
+	}
+	return rc.client.ServerNotices()
+}
+
+// NetworkIdentity returns the current connection's address as the server
+// observed it. See Client.NetworkIdentity.
+func (rc *ReconnectingClient) NetworkIdentity() NetworkIdentity {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.client == nil {
+		return NetworkIdentity{}
+	}
+	return rc.client.NetworkIdentity()
+}
+
+// UsageStats returns a snapshot of per-context traffic counters. Counters
+// accumulate across reconnects.
+func (rc *ReconnectingClient) UsageStats() UsageStats {
+	return rc.usage.snapshot(false)
+}
+
+// ResetUsageStats returns the counters and zeroes them.
+func (rc *ReconnectingClient) ResetUsageStats() UsageStats {
+	return rc.usage.snapshot(true)
+}
+
+// AcquireLease takes a single-writer lease on contextID. Held leases and
+// their renewal survive reconnects.
+func (rc *ReconnectingClient) AcquireLease(ctx context.Context, store LeaseStore, contextID uint64, opts ...LeaseOption) (*Lease, error) {
+	return rc.leases.acquire(ctx, store, contextID, rc.ClientTag(), opts)
+}
+
 // QueueLength returns the current number of queued requests.
 func (rc *ReconnectingClient) QueueLength() int {
 	return len(rc.queue)
@@ -404,7 +558,7 @@ func (rc *ReconnectingClient) QueueLength() int {
 // CreateContext creates a new context, optionally based on an existing turn.
 func (rc *ReconnectingClient) CreateContext(ctx context.Context, baseTurnID uint64) (*ContextHead, error) {
 	var result *ContextHead
-	err := rc.enqueue(ctx, "CreateContext", func(c *Client) error {
+	err := rc.enqueue(ctx, "CreateContext", func(ctx context.Context, c *Client) error {
 		var opErr error
 		result, opErr = c.CreateContext(ctx, baseTurnID)
 		return opErr
@@ -415,7 +569,7 @@ func (rc *ReconnectingClient) CreateContext(ctx context.Context, baseTurnID uint
 // ForkContext creates a new context forked from an existing turn.
 func (rc *ReconnectingClient) ForkContext(ctx context.Context, baseTurnID uint64) (*ContextHead, error) {
 	var result *ContextHead
-	err := rc.enqueue(ctx, "ForkContext", func(c *Client) error {
+	err := rc.enqueue(ctx, "ForkContext", func(ctx context.Context, c *Client) error {
 		var opErr error
 		result, opErr = c.ForkContext(ctx, baseTurnID)
 		return opErr
@@ -423,10 +577,20 @@ func (rc *ReconnectingClient) ForkContext(ctx context.Context, baseTurnID uint64
 	return result, err
 }
 
+// CloneContext copies a context into a new one. See Client.CloneContext.
+func (rc *ReconnectingClient) CloneContext(ctx context.Context, contextID uint64, opts ...CloneOption) (*CloneResult, error) {
+	return cloneContext(ctx, rc, contextID, opts)
+}
+
+// CreateContexts creates n contexts from template. See Client.CreateContexts.
+func (rc *ReconnectingClient) CreateContexts(ctx context.Context, n int, template *ContextTemplate) ([]*ContextHead, error) {
+	return createContexts(ctx, rc, n, template)
+}
+
 // GetHead retrieves the current head turn for a context.
 func (rc *ReconnectingClient) GetHead(ctx context.Context, contextID uint64) (*ContextHead, error) {
 	var result *ContextHead
-	err := rc.enqueue(ctx, "GetHead", func(c *Client) error {
+	err := rc.enqueue(ctx, "GetHead", func(ctx context.Context, c *Client) error {
 		var opErr error
 		result, opErr = c.GetHead(ctx, contextID)
 		return opErr
@@ -434,10 +598,16 @@ func (rc *ReconnectingClient) GetHead(ctx context.Context, contextID uint64) (*C
 	return result, err
 }
 
+// WatchHead watches a context's head by polling, reconnecting as needed.
+// See Client.WatchHead.
+func (rc *ReconnectingClient) WatchHead(ctx context.Context, contextID uint64, interval time.Duration, opts ...WatchOption) (HeadWatch, error) {
+	return watchHead(ctx, rc.GetHead, contextID, interval, opts)
+}
+
 // AppendTurn appends a new turn to a context.
 func (rc *ReconnectingClient) AppendTurn(ctx context.Context, req *AppendRequest) (*AppendResult, error) {
 	var result *AppendResult
-	err := rc.enqueue(ctx, "AppendTurn", func(c *Client) error {
+	err := rc.enqueue(ctx, "AppendTurn", func(ctx context.Context, c *Client) error {
 		var opErr error
 		result, opErr = c.AppendTurn(ctx, req)
 		return opErr
@@ -445,10 +615,17 @@ func (rc *ReconnectingClient) AppendTurn(ctx context.Context, req *AppendRequest
 	return result, err
 }
 
+// AppendConversationItem appends a typed conversation item. With
+// WithServerProvenance the identity of the connection that carries the
+// append is used, so it stays current across reconnects.
+func (rc *ReconnectingClient) AppendConversationItem(ctx context.Context, contextID, parentTurnID uint64, item *types.ConversationItem) (*AppendResult, error) {
+	return rc.appendConversationItem(ctx, contextID, parentTurnID, item, nil)
+}
+
 // GetLast retrieves the last N turns from a context.
 func (rc *ReconnectingClient) GetLast(ctx context.Context, contextID uint64, opts GetLastOptions) ([]TurnRecord, error) {
 	var result []TurnRecord
-	err := rc.enqueue(ctx, "GetLast", func(c *Client) error {
+	err := rc.enqueue(ctx, "GetLast", func(ctx context.Context, c *Client) error {
 		var opErr error
 		result, opErr = c.GetLast(ctx, contextID, opts)
 		return opErr
@@ -456,10 +633,21 @@ func (rc *ReconnectingClient) GetLast(ctx context.Context, contextID uint64, opt
 	return result, err
 }
 
+// GetLastPage retrieves the last N turns from a context with the response flags.
+func (rc *ReconnectingClient) GetLastPage(ctx context.Context, contextID uint64, opts GetLastOptions) (*TurnPage, error) {
+	var result *TurnPage
+	err := rc.enqueue(ctx, "GetLastPage", func(ctx context.Context, c *Client) error {
+		var opErr error
+		result, opErr = c.GetLastPage(ctx, contextID, opts)
+		return opErr
+	})
+	return result, err
+}
+
 // AttachFs attaches a filesystem tree to a context.
 func (rc *ReconnectingClient) AttachFs(ctx context.Context, req *AttachFsRequest) (*AttachFsResult, error) {
 	var result *AttachFsResult
-	err := rc.enqueue(ctx, "AttachFs", func(c *Client) error {
+	err := rc.enqueue(ctx, "AttachFs", func(ctx context.Context, c *Client) error {
 		var opErr error
 		result, opErr = c.AttachFs(ctx, req)
 		return opErr
@@ -470,7 +658,7 @@ func (rc *ReconnectingClient) AttachFs(ctx context.Context, req *AttachFsRequest
 // PutBlob stores a blob and returns its hash.
 func (rc *ReconnectingClient) PutBlob(ctx context.Context, req *PutBlobRequest) (*PutBlobResult, error) {
 	var result *PutBlobResult
-	err := rc.enqueue(ctx, "PutBlob", func(c *Client) error {
+	err := rc.enqueue(ctx, "PutBlob", func(ctx context.Context, c *Client) error {
 		var opErr error
 		result, opErr = c.PutBlob(ctx, req)
 		return opErr
@@ -478,11 +666,55 @@ func (rc *ReconnectingClient) PutBlob(ctx context.Context, req *PutBlobRequest)
 	return result, err
 }
 
+// GetBlob fetches a blob by hash.
+func (rc *ReconnectingClient) GetBlob(ctx context.Context, hash [32]byte) ([]byte, error) {
+	var result []byte
+	err := rc.enqueue(ctx, "GetBlob", func(ctx context.Context, c *Client) error {
+		var opErr error
+		result, opErr = c.GetBlob(ctx, hash)
+		return opErr
+	})
+	return result, err
+}
+
+// GetBlobRange fetches part of a blob with automatic reconnection.
+func (rc *ReconnectingClient) GetBlobRange(ctx context.Context, hash [32]byte, offset uint64, maxLen uint32) (*BlobChunk, error) {
+	var result *BlobChunk
+	err := rc.enqueue(ctx, "GetBlobRange", func(ctx context.Context, c *Client) error {
+		var opErr error
+		result, opErr = c.GetBlobRange(ctx, hash, offset, maxLen)
+		return opErr
+	})
+	return result, err
+}
+
+// ReadBlob streams a blob to w in chunks. Each chunk is a separate request,
+// so a reconnect mid-transfer resumes at the next chunk instead of
+// starting over.
+func (rc *ReconnectingClient) ReadBlob(ctx context.Context, hash [32]byte, w io.Writer, opts ...BlobReadOption) (uint64, error) {
+	return readBlobChunks(ctx, hash, w, nil, blobReader{rc.GetBlobRange, rc.GetBlob}, opts)
+}
+
+// OpenBlob fetches a blob into a PayloadReader, resuming across reconnects
+// as ReadBlob does. See Client.OpenBlob.
+func (rc *ReconnectingClient) OpenBlob(ctx context.Context, hash [32]byte, opts ...BlobReadOption) (*PayloadReader, error) {
+	rc.mu.Lock()
+	ranges := rc.client != nil && rc.client.blobRanges
+	rc.mu.Unlock()
+	return openBlob(ctx, hash, rc.spill, ranges, blobReader{rc.GetBlobRange, rc.GetBlob}, opts)
+}
+
+// DownloadBlob saves a blob to path, resuming across reconnects as ReadBlob
+// does. See Client.DownloadBlob.
+func (rc *ReconnectingClient) DownloadBlob(ctx context.Context, hash [32]byte, path string, opts ...BlobReadOption) (uint64, error) {
+	return downloadBlob(ctx, hash, path, blobReader{rc.GetBlobRange, rc.GetBlob}, opts)
+}
+
 // PutBlobIfAbsent stores a blob only if it doesn't already exist.
 func (rc *ReconnectingClient) PutBlobIfAbsent(ctx context.Context, data []byte) ([32]byte, bool, error) {
 	var hash [32]byte
 	var existed bool
-	err := rc.enqueue(ctx, "PutBlobIfAbsent", func(c *Client) error {
+	err := rc.enqueue(ctx, "PutBlobIfAbsent", func(ctx context.Context, c *Client) error {
 		var opErr error
 		hash, existed, opErr = c.PutBlobIfAbsent(ctx, data)
 		return opErr
@@ -493,7 +725,7 @@ func (rc *ReconnectingClient) PutBlobIfAbsent(ctx context.Context, data []byte)
 // AppendTurnWithFs appends a turn with an attached filesystem snapshot.
 func (rc *ReconnectingClient) AppendTurnWithFs(ctx context.Context, req *AppendRequest, fsRootHash *[32]byte) (*AppendResult, error) {
 	var result *AppendResult
-	err := rc.enqueue(ctx, "AppendTurnWithFs", func(c *Client) error {
+	err := rc.enqueue(ctx, "AppendTurnWithFs", func(ctx context.Context, c *Client) error {
 		var opErr error
 		result, opErr = c.AppendTurnWithFs(ctx, req, fsRootHash)
 		return opErr
@@ -246,7 +246,7 @@ func (d *mockDialer) dial() (*Client, error) {
 
 	d.sessionIDSeq++
 	client := &Client{
-		conn:      conn,
+		transport: NewStreamTransport(conn),
 		timeout:   30 * time.Second,
 		sessionID: d.sessionIDSeq,
 		clientTag: "test",
@@ -406,9 +406,10 @@ func TestReconnectingClient_QueueFull(t *testing.T) {
 	rc.mu.Unlock()
 
 	// Make the client operations block by setting a connection error
-	client.conn.(*mockConn).mu.Lock()
-	client.conn.(*mockConn).writeErr = io.EOF
-	client.conn.(*mockConn).mu.Unlock()
+	conn := client.transport.(*streamTransport).conn.(*mockConn)
+	conn.mu.Lock()
+	conn.writeErr = io.EOF
+	conn.mu.Unlock()
 
 	// First request will be queued
 	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
@@ -464,7 +465,7 @@ func TestReconnectingClient_ReconnectOnFailure(t *testing.T) {
 	// then succeeds on retry (after reconnect)
 	var callCount atomic.Int32
 	ctx := context.Background()
-	err = rc.enqueue(ctx, "test", func(c *Client) error {
+	err = rc.enqueue(ctx, "test", func(_ context.Context, c *Client) error {
 		if callCount.Add(1) == 1 {
 			// First call: simulate connection error
 			return syscall.ECONNRESET
@@ -655,6 +656,14 @@ func TestWithQueueSize(t *testing.T) {
 	}
 }
 
+func TestWithExecutionTimeout(t *testing.T) {
+	rc := &ReconnectingClient{}
+	WithExecutionTimeout(2 * time.Second)(rc)
+	if rc.execTimeout != 2*time.Second {
+		t.Errorf("Expected execTimeout=2s, got %v", rc.execTimeout)
+	}
+}
+
 func TestWithOnReconnect(t *testing.T) {
 	rc := &ReconnectingClient{}
 	called := false
@@ -709,7 +718,7 @@ func TestReconnectingClient_EnqueueAndProcess(t *testing.T) {
 	// Test successful operation
 	var opCalled atomic.Bool
 	ctx := context.Background()
-	err = rc.enqueue(ctx, "test-op", func(c *Client) error {
+	err = rc.enqueue(ctx, "test-op", func(_ context.Context, c *Client) error {
 		opCalled.Store(true)
 		return nil
 	})
@@ -733,7 +742,7 @@ func TestReconnectingClient_EnqueueWithError(t *testing.T) {
 
 	expectedErr := errors.New("operation failed")
 	ctx := context.Background()
-	err = rc.enqueue(ctx, "test-op", func(c *Client) error {
+	err = rc.enqueue(ctx, "test-op", func(_ context.Context, c *Client) error {
 		return expectedErr
 	})
 
@@ -742,6 +751,58 @@ func TestReconnectingClient_EnqueueWithError(t *testing.T) {
 	}
 }
 
+func TestReconnectingClient_ExecutionTimeoutStartsAtDequeue(t *testing.T) {
+	dialer := newMockDialer()
+	const execTimeout = 50 * time.Millisecond
+	rc, err := createTestReconnectingClient(dialer, WithExecutionTimeout(execTimeout))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	// Occupy the sender so the next request waits in the queue.
+	release := make(chan struct{})
+	blockerDone := make(chan error, 1)
+	go func() {
+		blockerDone <- rc.enqueue(context.Background(), "blocker", func(_ context.Context, c *Client) error {
+			<-release
+			return nil
+		})
+	}()
+
+	submitted := time.Now()
+	var started time.Time
+	var deadline time.Time
+	var hasDeadline bool
+	done := make(chan error, 1)
+	go func() {
+		done <- rc.enqueue(context.Background(), "queued", func(ctx context.Context, c *Client) error {
+			started = time.Now()
+			deadline, hasDeadline = ctx.Deadline()
+			return nil
+		})
+	}()
+
+	time.Sleep(3 * execTimeout)
+	close(release)
+	if err := <-blockerDone; err != nil {
+		t.Fatalf("blocker failed: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("queued request failed after waiting longer than the execution timeout: %v", err)
+	}
+
+	if !hasDeadline {
+		t.Fatal("execution context has no deadline")
+	}
+	if !deadline.After(submitted.Add(execTimeout)) {
+		t.Errorf("deadline %v measured from submission, not dequeue", deadline.Sub(submitted))
+	}
+	if d := deadline.Sub(started); d > execTimeout || d <= 0 {
+		t.Errorf("deadline is %v after execution start, want (0, %v]", d, execTimeout)
+	}
+}
+
 func TestReconnectingClient_ConcurrentOperations(t *testing.T) {
 	dialer := newMockDialer()
 	rc, err := createTestReconnectingClient(dialer)
@@ -759,7 +820,7 @@ func TestReconnectingClient_ConcurrentOperations(t *testing.T) {
 		go func() {
 			defer wg.Done()
 			ctx := context.Background()
-			err := rc.enqueue(ctx, "concurrent-op", func(c *Client) error {
+			err := rc.enqueue(ctx, "concurrent-op", func(_ context.Context, c *Client) error {
 				return nil
 			})
 			if err == nil {
@@ -801,7 +862,7 @@ func TestReconnectingClient_DrainOnClose(t *testing.T) {
 			defer wg.Done()
 			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
 			defer cancel()
-			err := rc.enqueue(ctx, "pending-op", func(c *Client) error {
+			err := rc.enqueue(ctx, "pending-op", func(_ context.Context, c *Client) error {
 				// Return a connection error to trigger reconnect (which will fail)
 				return io.EOF
 			})
@@ -916,3 +977,130 @@ func TestReconnectingClient_NilClientAfterFailedReconnect(t *testing.T) {
 		t.Errorf("Expected ClientTag() = '' when client is nil, got '%s'", rc.ClientTag())
 	}
 }
+
+func TestReconnectingClient_IdleTimeoutRecycles(t *testing.T) {
+	dialer := newMockDialer()
+	var mu sync.Mutex
+	var reasons []DisconnectReason
+	rc, err := createTestReconnectingClient(dialer, WithIdleTimeout(30*time.Millisecond),
+		func(rc *ReconnectingClient) {
+			rc.onDisconnect = func(e DisconnectEvent) {
+				mu.Lock()
+				reasons = append(reasons, e.Reason)
+				mu.Unlock()
+			}
+		})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	sessionOf := func() uint64 {
+		var id uint64
+		if err := rc.enqueue(context.Background(), "op", func(_ context.Context, c *Client) error {
+			id = c.SessionID()
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	// Busy connections are kept.
+	for i := 0; i < 3; i++ {
+		if id := sessionOf(); id != 1 {
+			t.Fatalf("request %d ran on session %d, want 1", i, id)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if id := sessionOf(); id != 2 {
+		t.Fatalf("request after idle ran on session %d, want 2", id)
+	}
+	if dialer.getDialCount() != 2 {
+		t.Errorf("dial count = %d, want 2", dialer.getDialCount())
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reasons) != 1 || reasons[0] != DisconnectRecycled {
+		t.Errorf("disconnect reasons = %v, want [recycled]", reasons)
+	}
+}
+
+func TestReconnectingClient_MaxConnLifetimeRecycles(t *testing.T) {
+	dialer := newMockDialer()
+	rc, err := createTestReconnectingClient(dialer, WithMaxConnLifetime(25*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	// Steady traffic does not extend the lifetime.
+	deadline := time.Now().Add(100 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		if err := rc.enqueue(context.Background(), "op", func(context.Context, *Client) error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := dialer.getDialCount(); n < 3 {
+		t.Errorf("dial count = %d after four lifetimes of traffic, want at least 3", n)
+	}
+}
+
+func TestReconnectingClient_RecycleDialFailureKeepsConnection(t *testing.T) {
+	dialer := newMockDialer()
+	rc, err := createTestReconnectingClient(dialer, WithMaxConnLifetime(20*time.Millisecond),
+		func(rc *ReconnectingClient) {
+			rc.retryDelay = 50 * time.Millisecond
+			rc.maxRetryDelay = 50 * time.Millisecond
+		})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	// The next dial, the first recycle attempt, fails.
+	dialer.mu.Lock()
+	dialer.failUntil = 2
+	dialer.mu.Unlock()
+
+	sessionOf := func() uint64 {
+		var id uint64
+		if err := rc.enqueue(context.Background(), "op", func(_ context.Context, c *Client) error {
+			id = c.SessionID()
+			return nil
+		}); err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		return id
+	}
+
+	if id := sessionOf(); id != 1 {
+		t.Fatalf("first request ran on session %d, want 1", id)
+	}
+	time.Sleep(25 * time.Millisecond)
+	if id := sessionOf(); id != 1 {
+		t.Fatalf("request after failed recycle ran on session %d, want 1", id)
+	}
+	if n := dialer.getDialCount(); n != 2 {
+		t.Fatalf("dial count = %d after failed recycle, want 2", n)
+	}
+
+	// Within the backoff the old connection is used without redialing.
+	if id := sessionOf(); id != 1 {
+		t.Fatalf("request during backoff ran on session %d, want 1", id)
+	}
+	if n := dialer.getDialCount(); n != 2 {
+		t.Fatalf("dial count = %d during backoff, want 2", n)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if id := sessionOf(); id != 2 {
+		t.Fatalf("request after backoff ran on session %d, want 2", id)
+	}
+	if n := dialer.getDialCount(); n != 3 {
+		t.Errorf("dial count = %d after retried recycle, want 3", n)
+	}
+}
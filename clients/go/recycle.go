@@ -0,0 +1,130 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"log/slog"
+	"time"
+)
+
+// WithMaxConnLifetime replaces the connection once it is older than d. The
+// check runs before each request, so the replacement happens on the
+// request's time rather than as a failure. Zero (the default) disables it.
+func WithMaxConnLifetime(d time.Duration) ReconnectOption {
+	return func(rc *ReconnectingClient) {
+		rc.maxConnLifetime = d
+	}
+}
+
+// WithIdleTimeout replaces the connection before the next request once it
+// has carried no requests for d. NATs and load balancers drop idle TCP
+// flows without telling either end; set d below their idle limit so the
+// first request after a quiet spell does not fail on a dead connection.
+// Zero (the default) disables it.
+func WithIdleTimeout(d time.Duration) ReconnectOption {
+	return func(rc *ReconnectingClient) {
+		rc.idleTimeout = d
+	}
+}
+
+// track starts the lifetime and idle clocks for a new connection. The
+// caller holds rc.mu.
+func (rc *ReconnectingClient) track(client *Client) {
+	now := time.Now()
+	rc.tracked, rc.trackedSince, rc.lastUsed = client, now, now
+	rc.recycleFailures, rc.recycleRetryAt = 0, time.Time{}
+}
+
+// touch records that the current connection carried a request.
+func (rc *ReconnectingClient) touch() {
+	rc.mu.Lock()
+	rc.lastUsed = time.Now()
+	rc.mu.Unlock()
+}
+
+// recycleReason says why client is due for replacement, or "" if it is
+// not. The caller holds rc.mu.
+func (rc *ReconnectingClient) recycleReason(client *Client, now time.Time) string {
+	if client == nil {
+		return ""
+	}
+	if client != rc.tracked {
+		rc.track(client)
+		return ""
+	}
+	if now.Before(rc.recycleRetryAt) {
+		return ""
+	}
+	switch {
+	case rc.maxConnLifetime > 0 && now.Sub(rc.trackedSince) >= rc.maxConnLifetime:
+		return "max_lifetime"
+	case rc.idleTimeout > 0 && now.Sub(rc.lastUsed) >= rc.idleTimeout:
+		return "idle"
+	}
+	return ""
+}
+
+// recycle replaces a healthy connection with a fresh one. The new
+// connection is dialed before the old one is closed, so a failed dial
+// leaves the current connection in place; the recycle is retried on a
+// later request once the backoff in recycleRetryAt has passed.
+func (rc *ReconnectingClient) recycle(client *Client, reason string) {
+	rc.mu.Lock()
+	age, idle := time.Since(rc.trackedSince), time.Since(rc.lastUsed)
+	rc.mu.Unlock()
+	slog.Info("[cxdb] recycling connection",
+		"reason", reason,
+		"session_id", client.SessionID(),
+		"age", age.Round(time.Millisecond),
+		"idle", idle.Round(time.Millisecond),
+	)
+
+	newClient, err := rc.dialFunc()
+	if err != nil {
+		rc.mu.Lock()
+		rc.recycleFailures++
+		delay := rc.retryDelay
+		for i := 1; i < rc.recycleFailures; i++ {
+			delay = min(delay*2, rc.maxRetryDelay)
+		}
+		rc.recycleRetryAt = time.Now().Add(delay)
+		rc.mu.Unlock()
+		slog.Warn("[cxdb] recycle dial failed, keeping current connection",
+			"reason", reason,
+			"session_id", client.SessionID(),
+			"error", err,
+			"retry_in", delay,
+		)
+		return
+	}
+
+	rc.mu.Lock()
+	if rc.client != client {
+		// A reconnect replaced the connection while we were dialing.
+		rc.mu.Unlock()
+		_ = newClient.Close()
+		return
+	}
+	if rc.usage != nil {
+		newClient.usage = rc.usage
+	}
+	if rc.leases != nil {
+		newClient.leases = rc.leases
+	}
+	rc.client = newClient
+	rc.track(newClient)
+	rc.mu.Unlock()
+
+	_ = client.Close()
+	if rc.onDisconnect != nil {
+		rc.onDisconnect(DisconnectEvent{
+			SessionID: client.SessionID(),
+			Reason:    DisconnectRecycled,
+		})
+	}
+	if rc.onReconnect != nil {
+		rc.onReconnect(newClient.SessionID())
+	}
+	rc.connected(newClient, true)
+}
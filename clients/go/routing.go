@@ -0,0 +1,108 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
+)
+
+// Routing keys let a sharded or proxied server tier place a connection or a
+// request without parsing its payload. A single server ignores them, so
+// callers can set them today and keep working unchanged when sharding
+// arrives.
+
+// WithRoutingKey sets the routing key sent in the HELLO handshake, which a
+// routing tier uses to place the whole connection. Typically a tenant or
+// context ID; at most wire.MaxRoutingKeyLen bytes.
+func WithRoutingKey(key string) Option {
+	return func(o *clientOptions) {
+		o.routingKey = key
+	}
+}
+
+type routingHintKey struct{}
+
+// WithRoutingHint returns a context whose requests carry key as a routing
+// hint, typically the ID of the context they touch, so a routing tier can
+// send them to the shard that owns it. Hints are sent only to servers that
+// advertise routing keys in HELLO and are dropped otherwise; requests
+// without a hint are routed by the connection's WithRoutingKey.
+func WithRoutingHint(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, routingHintKey{}, key)
+}
+
+func routingHint(ctx context.Context) string {
+	key, _ := ctx.Value(routingHintKey{}).(string)
+	return key
+}
+
+// routeRequest prefixes payload with the routing hint of ctx if the server
+// accepts one.
+func (c *Client) routeRequest(ctx context.Context, flags uint16, payload []byte) (uint16, []byte, error) {
+	key := routingHint(ctx)
+	if key == "" || !c.routingKeys {
+		return flags, payload, nil
+	}
+	if len(key) > wire.MaxRoutingKeyLen {
+		return 0, nil, fmt.Errorf("routing hint: %d bytes, limit %d", len(key), wire.MaxRoutingKeyLen)
+	}
+	routed := wire.AppendRoutingKey(make([]byte, 0, 2+len(key)+len(payload)), key)
+	return flags | wire.FlagRoutingKey, append(routed, payload...), nil
+}
+
+// helloMeta is the client_meta_json of HELLO.
+type helloMeta struct {
+	RoutingKey string `json:"routing_key,omitempty"`
+}
+
+// helloMetaJSON returns the HELLO client metadata, or nil if there is none.
+func (c *Client) helloMetaJSON() ([]byte, error) {
+	if c.routingKey == "" {
+		return nil, nil
+	}
+	if len(c.routingKey) > wire.MaxRoutingKeyLen {
+		return nil, fmt.Errorf("routing key: %d bytes, limit %d", len(c.routingKey), wire.MaxRoutingKeyLen)
+	}
+	return json.Marshal(helloMeta{RoutingKey: c.routingKey})
+}
+
+type helloCapabilities struct {
+	Capabilities []string `json:"capabilities"`
+}
+
+// parseHelloCapabilities decodes the capabilities section of a HELLO
+// response, given the bytes after protocol_version. It follows the notices
+// and identity sections and is absent from older servers.
+func parseHelloCapabilities(data []byte) ([]string, error) {
+	for range 2 {
+		if len(data) < 4 {
+			return nil, nil
+		}
+		skip := uint64(binary.LittleEndian.Uint32(data[0:4]))
+		if skip > uint64(len(data)-4) {
+			return nil, nil // reported by the section's own parser
+		}
+		data = data[4+skip:]
+	}
+	if len(data) < 4 {
+		return nil, nil
+	}
+	n := binary.LittleEndian.Uint32(data[0:4])
+	if uint64(n) > uint64(len(data)-4) {
+		return nil, fmt.Errorf("capabilities truncated: %d of %d bytes", len(data)-4, n)
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	var raw helloCapabilities
+	if err := json.Unmarshal(data[4:4+n], &raw); err != nil {
+		return nil, fmt.Errorf("capabilities: %w", err)
+	}
+	return raw.Capabilities, nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
+)
+
+func helloWithCapabilities(caps string) []byte {
+	b := helloWithIdentity(`{}`)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(caps)))
+	return append(b, caps...)
+}
+
+func TestParseHelloCapabilities(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+		want    []string
+		wantErr bool
+	}{
+		{"old server", make([]byte, 10), nil, false},
+		{"identity only", helloWithIdentity(`{}`), nil, false},
+		{"capabilities", helloWithCapabilities(`{"capabilities":["routing_keys","x"]}`), []string{"routing_keys", "x"}, false},
+		{"malformed", helloWithCapabilities(`{"capabilities":7}`), nil, true},
+		{"truncated", helloWithCapabilities(`{}`)[:len(helloWithCapabilities(`{}`))-1], nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseHelloCapabilities(tt.payload[10:])
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, %v", tt.name, got, err)
+		}
+	}
+}
+
+func TestRoutingHint(t *testing.T) {
+	for _, advertised := range []bool{false, true} {
+		var hello, head []byte
+		c := pipeClient(t, func(msgType uint16, p []byte) (uint16, uint16, []byte) {
+			switch msgType {
+			case wire.MsgHello:
+				hello = p
+				if advertised {
+					return msgType, 0, helloWithCapabilities(`{"capabilities":["routing_keys"]}`)
+				}
+				return msgType, 0, helloWithIdentity(`{}`)
+			case wire.MsgGetHead:
+				head = p
+				return msgType, 0, make([]byte, 20)
+			}
+			return wire.MsgError, 0, wire.AppendError(nil, wire.CodeInvalidInput, "unexpected message")
+		})
+		c.routingKey = "tenant-7"
+		if err := c.sendHello(""); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasSuffix(hello, []byte(`{"routing_key":"tenant-7"}`)) {
+			t.Errorf("HELLO payload %q lacks the routing key", hello)
+		}
+
+		ctx := WithRoutingHint(context.Background(), "42")
+		if _, err := c.GetHead(ctx, 42); err != nil {
+			t.Fatal(err)
+		}
+		plain := binary.LittleEndian.AppendUint64(nil, 42)
+		want := plain
+		if advertised {
+			want = append(wire.AppendRoutingKey(nil, "42"), plain...)
+		}
+		if !bytes.Equal(head, want) {
+			t.Errorf("advertised=%v: GET_HEAD payload %x, want %x", advertised, head, want)
+		}
+
+		_, err := c.GetHead(WithRoutingHint(ctx, strings.Repeat("k", wire.MaxRoutingKeyLen+1)), 42)
+		if advertised == (err == nil) {
+			t.Errorf("advertised=%v: over-long hint: %v", advertised, err)
+		}
+	}
+}
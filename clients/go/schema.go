@@ -0,0 +1,109 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/strongdm/ai-cxdb/clients/go/types"
+)
+
+// SchemaPolicy controls how AppendConversationItem treats items that fail
+// types.ConversationItem.Validate. Organizations moving many producers onto
+// the canonical schema can start with SchemaLenient to find offenders and
+// switch to SchemaStrict once the warnings stop.
+type SchemaPolicy int
+
+const (
+	// SchemaOff appends items without validating them. This is the default.
+	SchemaOff SchemaPolicy = iota
+
+	// SchemaLenient logs a warning for an invalid item and appends it anyway.
+	SchemaLenient
+
+	// SchemaStrict refuses an invalid item with an error wrapping
+	// types.ErrInvalidItem; nothing is sent.
+	SchemaStrict
+)
+
+// WithSchemaPolicy sets how AppendConversationItem treats items that fail
+// validation. ContextHandle.WithSchemaPolicy overrides it for one context.
+func WithSchemaPolicy(policy SchemaPolicy) Option {
+	return func(o *clientOptions) {
+		o.schemaPolicy = policy
+	}
+}
+
+// checkSchema applies policy to item, which is about to be appended to
+// contextID.
+func checkSchema(policy SchemaPolicy, contextID uint64, item *types.ConversationItem) error {
+	if policy == SchemaOff {
+		return nil
+	}
+	err := item.Validate()
+	if err == nil {
+		return nil
+	}
+	if policy == SchemaStrict {
+		return err
+	}
+	slog.Warn("[cxdb] appending invalid conversation item", "context_id", contextID, "item_type", item.ItemType, "error", err)
+	return nil
+}
+
+// conversationAppender appends conversation items under an explicit schema
+// policy; nil means the appender's own.
+type conversationAppender interface {
+	appendConversationItem(ctx context.Context, contextID, parentTurnID uint64, item *types.ConversationItem, policy *SchemaPolicy) (*AppendResult, error)
+}
+
+// ContextHandle appends conversation items to one context, optionally under
+// its own schema policy. Handles are cheap and safe for concurrent use.
+type ContextHandle struct {
+	appender  conversationAppender
+	contextID uint64
+	policy    *SchemaPolicy
+}
+
+// ContextHandle returns a handle for contextID that follows the client's
+// schema policy until WithSchemaPolicy is called.
+func (c *Client) ContextHandle(contextID uint64) *ContextHandle {
+	return &ContextHandle{appender: c, contextID: contextID}
+}
+
+// ContextHandle returns a handle for contextID that follows the client's
+// schema policy until WithSchemaPolicy is called.
+func (rc *ReconnectingClient) ContextHandle(contextID uint64) *ContextHandle {
+	return &ContextHandle{appender: rc, contextID: contextID}
+}
+
+// ContextID returns the handle's context.
+func (h *ContextHandle) ContextID() uint64 {
+	return h.contextID
+}
+
+// WithSchemaPolicy returns a copy of h that applies policy instead of the
+// client's. h itself is not modified.
+func (h *ContextHandle) WithSchemaPolicy(policy SchemaPolicy) *ContextHandle {
+	copied := *h
+	copied.policy = &policy
+	return &copied
+}
+
+// AppendConversationItem is Client.AppendConversationItem on the handle's
+// context and under its schema policy.
+func (h *ContextHandle) AppendConversationItem(ctx context.Context, parentTurnID uint64, item *types.ConversationItem) (*AppendResult, error) {
+	return h.appender.appendConversationItem(ctx, h.contextID, parentTurnID, item, h.policy)
+}
+
+func (rc *ReconnectingClient) appendConversationItem(ctx context.Context, contextID, parentTurnID uint64, item *types.ConversationItem, policy *SchemaPolicy) (*AppendResult, error) {
+	var result *AppendResult
+	err := rc.enqueue(ctx, "AppendConversationItem", func(ctx context.Context, c *Client) error {
+		var opErr error
+		result, opErr = c.appendConversationItem(ctx, contextID, parentTurnID, item, policy)
+		return opErr
+	})
+	return result, err
+}
@@ -0,0 +1,43 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/strongdm/ai-cxdb/clients/go/types"
+)
+
+func TestSchemaPolicy(t *testing.T) {
+	store := &memStore{blobs: make(map[[32]byte][]byte)}
+	c := pipeClient(t, store.handle)
+	ctx := context.Background()
+	invalid := &types.ConversationItem{ItemType: types.ItemTypeSystem}
+
+	if _, err := c.AppendConversationItem(ctx, 1, 0, invalid); err != nil {
+		t.Fatalf("SchemaOff: %v", err)
+	}
+
+	c.schemaPolicy = SchemaStrict
+	if _, err := c.AppendConversationItem(ctx, 1, 0, invalid); !errors.Is(err, types.ErrInvalidItem) {
+		t.Fatalf("SchemaStrict: %v, want ErrInvalidItem", err)
+	}
+	if _, err := c.AppendConversationItem(ctx, 1, 0, types.NewUserInput("hi")); err != nil {
+		t.Fatalf("SchemaStrict valid item: %v", err)
+	}
+
+	lenient := c.ContextHandle(1).WithSchemaPolicy(SchemaLenient)
+	if _, err := lenient.AppendConversationItem(ctx, 0, invalid); err != nil {
+		t.Fatalf("lenient handle: %v", err)
+	}
+	if _, err := c.ContextHandle(1).AppendConversationItem(ctx, 0, invalid); !errors.Is(err, types.ErrInvalidItem) {
+		t.Fatalf("default handle: %v, want the client's strict policy", err)
+	}
+
+	if len(store.turns) != 3 {
+		t.Errorf("%d turns appended, want 3", len(store.turns))
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package seed
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/strongdm/ai-cxdb/clients/go/types"
+)
+
+// conversation is one generated session, ready to be written.
+type conversation struct {
+	title string
+	files map[string]string // workspace by slash-separated path
+	steps []step
+}
+
+// step is an item and, for snapshot points, the edit made to the
+// workspace before the snapshot is taken.
+type step struct {
+	item  *types.ConversationItem
+	edit  string
+	patch string
+}
+
+func (c *conversation) snapshots() bool {
+	for _, s := range c.steps {
+		if s.edit != "" {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	services = []string{"billing", "auth", "search", "ingest", "notifier", "scheduler", "reports", "gateway"}
+	tasks    = []string{
+		"Fix the flaky test in the %s package",
+		"Add pagination to the %s list endpoint",
+		"Why is %s slow under load?",
+		"Refactor %s to use the new config loader",
+		"Add retries with backoff to the %s client",
+		"Write a migration for the %s schema change",
+		"Review the error handling in %s",
+	}
+	followUps = []string{
+		"Can you also cover the error path?",
+		"That broke the build, please take another look.",
+		"Looks good. Now update the docs.",
+		"Run the tests again with -race.",
+		"Can we avoid the extra allocation there?",
+		"Please split that into two functions.",
+		"What happens if the context is cancelled?",
+	}
+	answers = []string{
+		"I traced the problem to %s: the handler reuses a buffer across requests. I changed it to allocate per request and added a regression test.",
+		"The %s code path retried without a limit. It now gives up after five attempts with exponential backoff, and the tests cover both outcomes.",
+		"I split the %s change into a parsing step and a validation step, which keeps each function under forty lines and makes the error messages specific.",
+		"Done. The %s tests pass, including the new case for an empty page token.",
+		"Cancellation now propagates through %s; the worker checks ctx.Err() between batches and returns it wrapped.",
+	}
+	tools = []string{"read_file", "grep", "run_tests", "edit_file", "list_dir"}
+	words = []string{"handler", "store", "client", "config", "worker", "cache", "metrics", "retry", "schema", "queue"}
+)
+
+// generate builds conversation index from rng.
+func generate(rng *rand.Rand, opts Options, index int) *conversation {
+	service := pick(rng, services)
+	conv := &conversation{
+		title: fmt.Sprintf(pick(rng, tasks), service),
+		files: map[string]string{},
+	}
+	if opts.SnapshotRatio > 0 {
+		conv.files = workspace(rng, service, opts.Files)
+	}
+	names := sortedKeys(conv.files)
+
+	// Sessions start within the last three days and advance by seconds
+	// to minutes per item.
+	at := time.Now().Add(-time.Duration(rng.Int63n(int64(72 * time.Hour))))
+	next := func() int64 {
+		at = at.Add(time.Duration(5+rng.Intn(240)) * time.Second)
+		return at.UnixMilli()
+	}
+
+	turns := opts.MinTurns + rng.Intn(opts.MaxTurns-opts.MinTurns+1)
+	var inputTokens int64 = 800
+	for t := 1; t <= turns; t++ {
+		text := conv.title
+		if t > 1 {
+			text = pick(rng, followUps)
+		}
+		user := types.NewUserInput(text)
+		user.ID = fmt.Sprintf("seed-%d-%d-user", index, t)
+		user.Timestamp = next()
+		conv.steps = append(conv.steps, step{item: user})
+
+		output := int64(150 + rng.Intn(900))
+		inputTokens += output + int64(200+rng.Intn(2000))
+		b := types.BuildAssistantTurn(fmt.Sprintf(pick(rng, answers), service)).
+			WithID(fmt.Sprintf("seed-%d-%d-assistant", index, t)).
+			WithAgent(opts.ClientTag).
+			WithTurnNumber(t, opts.MaxTurns).
+			WithMetrics(inputTokens, output).
+			WithFinishReason("stop")
+		if rng.Float64() < opts.ToolCallRatio {
+			calls := 1 + rng.Intn(4)
+			for c := 0; c < calls; c++ {
+				b.WithToolCall(toolCall(rng, fmt.Sprintf("call-%d-%d-%d", index, t, c), service, names))
+			}
+		}
+		answer := b.Build()
+		answer.Timestamp = next()
+
+		s := step{item: answer}
+		if len(names) > 0 && rng.Float64() < opts.SnapshotRatio {
+			s.edit = pick(rng, names)
+			s.patch = fmt.Sprintf("\n// Turn %d: %s\n", t, strings.ToLower(text))
+		}
+		conv.steps = append(conv.steps, s)
+	}
+	return conv
+}
+
+func toolCall(rng *rand.Rand, id, service string, files []string) types.ToolCallItem {
+	name := pick(rng, tools)
+	path := "internal/" + service
+	if len(files) > 0 {
+		path = pick(rng, files)
+	}
+	var args, result string
+	switch name {
+	case "read_file", "edit_file":
+		args = fmt.Sprintf(`{"path":%q}`, path)
+		result = fmt.Sprintf("%s: %d lines", path, 20+rng.Intn(400))
+	case "grep":
+		w := pick(rng, words)
+		args = fmt.Sprintf(`{"pattern":%q,"path":"internal/%s"}`, w, service)
+		result = fmt.Sprintf("%s:%d: func new%s()", path, 1+rng.Intn(200), upper(w))
+	case "run_tests":
+		args = fmt.Sprintf(`{"packages":["./internal/%s/..."]}`, service)
+		result = fmt.Sprintf("ok  \texample.com/%s/internal/%s\t%.3fs", service, service, rng.Float64()*4)
+	default:
+		args = fmt.Sprintf(`{"path":"internal/%s"}`, service)
+		result = strings.Join(files[:min(len(files), 5)], "\n")
+	}
+	b := types.BuildToolCallItem(id, name, args).WithDuration(int64(20 + rng.Intn(5000)))
+	if name == "run_tests" && rng.Intn(6) == 0 {
+		exit := 1
+		b.WithError(fmt.Sprintf("--- FAIL: Test%s (0.%02ds)", upper(service), rng.Intn(100)), &exit)
+	} else {
+		exit := 0
+		b.WithResult(result, &exit)
+	}
+	return b.Build()
+}
+
+// workspace generates n small Go source files for service.
+func workspace(rng *rand.Rand, service string, n int) map[string]string {
+	files := map[string]string{
+		"go.mod":    fmt.Sprintf("module example.com/%s\n\ngo 1.22\n", service),
+		"README.md": fmt.Sprintf("# %s\n\nSynthetic workspace generated by cxdb seed.\n", service),
+	}
+	for i := 0; len(files) < n; i++ {
+		w := pick(rng, words)
+		path := fmt.Sprintf("internal/%s/%s_%d.go", service, w, i)
+		var src strings.Builder
+		fmt.Fprintf(&src, "package %s\n", service)
+		funcs := 1 + rng.Intn(6)
+		for f := 0; f < funcs; f++ {
+			fmt.Fprintf(&src, "\n// %s%d handles one step of the %s %s.\nfunc %s%d(n int) int {\n\treturn n * %d\n}\n",
+				w, f, service, w, w, f, 1+rng.Intn(9))
+		}
+		files[path] = src.String()
+	}
+	return files
+}
+
+// upper capitalizes an ASCII word.
+func upper(w string) string {
+	return strings.ToUpper(w[:1]) + w[1:]
+}
+
+func pick(rng *rand.Rand, from []string) string {
+	return from[rng.Intn(len(from))]
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,219 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+// Package seed fills a CXDB server with synthetic conversations for load
+// tests and UI development. Conversations look like coding-agent sessions:
+// user requests, assistant answers with token metrics, tool calls with
+// results and the occasional failure, and filesystem snapshots of a
+// generated workspace.
+//
+// Seeded contexts carry the "seed" label, so they can be found with
+// label = "seed" and kept away from real data. Use it against development
+// and test servers only.
+//
+// # Basic Usage
+//
+//	opts := seed.DefaultOptions()
+//	opts.Contexts = 200
+//	opts.ToolCallRatio = 0.5
+//	res, err := seed.Run(ctx, client, opts)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("seeded %d contexts with %d turns\n", len(res.ContextIDs), res.Turns)
+//
+// The same Seed generates the same conversations, so load tests are
+// repeatable.
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	cxdb "github.com/strongdm/ai-cxdb/clients/go"
+	"github.com/strongdm/ai-cxdb/clients/go/fstree"
+	"github.com/strongdm/ai-cxdb/clients/go/types"
+)
+
+// Label marks every seeded context.
+const Label = "seed"
+
+// Options configures Run.
+type Options struct {
+	// Contexts is the number of conversations to create.
+	Contexts int
+
+	// MinTurns and MaxTurns bound the exchanges per conversation. Each
+	// exchange is a user message and an assistant answer.
+	MinTurns int
+	MaxTurns int
+
+	// ToolCallRatio is the fraction of assistant answers that call tools.
+	ToolCallRatio float64
+
+	// SnapshotRatio is the fraction of assistant answers followed by a
+	// filesystem snapshot. Zero creates no workspace.
+	SnapshotRatio float64
+
+	// Files is the number of files in the generated workspace, which
+	// always has at least a go.mod and a README.
+	Files int
+
+	// ClientTag is recorded as the client tag of each context.
+	ClientTag string
+
+	// Labels are added to Label on each context.
+	Labels []string
+
+	// Seed makes generation repeatable.
+	Seed int64
+
+	// Progress, if set, is called after each context is complete.
+	Progress func(done, total int)
+}
+
+// DefaultOptions returns options for ten mid-sized conversations with
+// some tool use and snapshots.
+func DefaultOptions() Options {
+	return Options{
+		Contexts:      10,
+		MinTurns:      3,
+		MaxTurns:      12,
+		ToolCallRatio: 0.4,
+		SnapshotRatio: 0.2,
+		Files:         20,
+		ClientTag:     "cxdb-seed",
+		Seed:          1,
+	}
+}
+
+func (o Options) validate() error {
+	switch {
+	case o.Contexts < 0:
+		return errors.New("contexts must not be negative")
+	case o.MinTurns < 1 || o.MaxTurns < o.MinTurns:
+		return fmt.Errorf("turns must satisfy 1 <= min (%d) <= max (%d)", o.MinTurns, o.MaxTurns)
+	case o.ToolCallRatio < 0 || o.ToolCallRatio > 1:
+		return fmt.Errorf("tool call ratio %v is not between 0 and 1", o.ToolCallRatio)
+	case o.SnapshotRatio < 0 || o.SnapshotRatio > 1:
+		return fmt.Errorf("snapshot ratio %v is not between 0 and 1", o.SnapshotRatio)
+	case o.SnapshotRatio > 0 && o.Files < 1:
+		return errors.New("snapshots need at least one workspace file")
+	}
+	return nil
+}
+
+// Writer is the subset of cxdb.Client and cxdb.ReconnectingClient that Run
+// needs.
+type Writer interface {
+	fstree.BlobPutter
+	CreateContext(ctx context.Context, baseTurnID uint64) (*cxdb.ContextHead, error)
+	AppendConversationItem(ctx context.Context, contextID, parentTurnID uint64, item *types.ConversationItem) (*cxdb.AppendResult, error)
+	AttachFs(ctx context.Context, req *cxdb.AttachFsRequest) (*cxdb.AttachFsResult, error)
+}
+
+// Result summarizes what Run wrote.
+type Result struct {
+	ContextIDs []uint64
+	Turns      int
+	ToolCalls  int
+	Snapshots  int
+}
+
+// Run creates opts.Contexts synthetic conversations. On error the result
+// covers the contexts written so far, including a partial last one.
+func Run(ctx context.Context, w Writer, opts Options) (*Result, error) {
+	if err := opts.validate(); err != nil {
+		return nil, fmt.Errorf("seed: %w", err)
+	}
+	rng := rand.New(rand.NewSource(opts.Seed))
+	res := &Result{}
+	for i := 0; i < opts.Contexts; i++ {
+		conv := generate(rng, opts, i)
+		if err := write(ctx, w, conv, opts, res); err != nil {
+			return res, fmt.Errorf("seed: context %d of %d: %w", i+1, opts.Contexts, err)
+		}
+		if opts.Progress != nil {
+			opts.Progress(i+1, opts.Contexts)
+		}
+	}
+	return res, nil
+}
+
+// write appends one conversation, building its workspace in a temporary
+// directory when it has snapshots.
+func write(ctx context.Context, w Writer, conv *conversation, opts Options, res *Result) error {
+	head, err := w.CreateContext(ctx, 0)
+	if err != nil {
+		return fmt.Errorf("create context: %w", err)
+	}
+	res.ContextIDs = append(res.ContextIDs, head.ContextID)
+
+	var workdir string
+	if conv.snapshots() {
+		if workdir, err = os.MkdirTemp("", "cxdb-seed-"); err != nil {
+			return err
+		}
+		defer func() { _ = os.RemoveAll(workdir) }()
+		for name, content := range conv.files {
+			if err := writeFile(workdir, name, content); err != nil {
+				return err
+			}
+		}
+	}
+
+	parent := head.HeadTurnID
+	for i, s := range conv.steps {
+		if i == 0 {
+			s.item.WithContextMetadata(&types.ContextMetadata{
+				ClientTag: opts.ClientTag,
+				Title:     conv.title,
+				Labels:    append([]string{Label}, opts.Labels...),
+				Custom:    map[string]string{"seed": strconv.FormatInt(opts.Seed, 10)},
+			})
+		}
+		appended, err := w.AppendConversationItem(ctx, head.ContextID, parent, s.item)
+		if err != nil {
+			return fmt.Errorf("append %s: %w", s.item.ItemType, err)
+		}
+		parent = appended.TurnID
+		res.Turns++
+		if s.item.Turn != nil {
+			res.ToolCalls += len(s.item.Turn.ToolCalls)
+		}
+
+		if s.edit == "" {
+			continue
+		}
+		if err := writeFile(workdir, s.edit, conv.files[s.edit]+s.patch); err != nil {
+			return err
+		}
+		conv.files[s.edit] += s.patch
+		snap, _, err := fstree.CaptureAndUpload(ctx, w, workdir)
+		if err != nil {
+			return fmt.Errorf("snapshot: %w", err)
+		}
+		if _, err := w.AttachFs(ctx, &cxdb.AttachFsRequest{
+			TurnID:     parent,
+			FsRootHash: snap.RootHash,
+			TreeFormat: snap.TreeFormat,
+		}); err != nil {
+			return fmt.Errorf("attach snapshot: %w", err)
+		}
+		res.Snapshots++
+	}
+	return nil
+}
+
+func writeFile(dir, name, content string) error {
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0o644)
+}
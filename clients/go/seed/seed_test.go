@@ -0,0 +1,186 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package seed
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/zeebo/blake3"
+
+	cxdb "github.com/strongdm/ai-cxdb/clients/go"
+	"github.com/strongdm/ai-cxdb/clients/go/types"
+)
+
+// fakeWriter records what Run writes, failing the append numbered failAt
+// if it is set.
+type fakeWriter struct {
+	contexts uint64
+	turns    uint64
+	items    map[uint64][]*types.ConversationItem
+	parents  map[uint64][]uint64
+	attached map[uint64][32]byte
+	blobs    map[[32]byte][]byte
+	failAt   int
+}
+
+func newFakeWriter() *fakeWriter {
+	return &fakeWriter{
+		items:    map[uint64][]*types.ConversationItem{},
+		parents:  map[uint64][]uint64{},
+		attached: map[uint64][32]byte{},
+		blobs:    map[[32]byte][]byte{},
+	}
+}
+
+func (f *fakeWriter) CreateContext(context.Context, uint64) (*cxdb.ContextHead, error) {
+	f.contexts++
+	return &cxdb.ContextHead{ContextID: f.contexts}, nil
+}
+
+func (f *fakeWriter) AppendConversationItem(_ context.Context, contextID, parent uint64, item *types.ConversationItem) (*cxdb.AppendResult, error) {
+	if f.failAt > 0 && int(f.turns)+1 == f.failAt {
+		return nil, errors.New("server went away")
+	}
+	f.turns++
+	f.items[contextID] = append(f.items[contextID], item)
+	f.parents[contextID] = append(f.parents[contextID], parent)
+	return &cxdb.AppendResult{ContextID: contextID, TurnID: f.turns}, nil
+}
+
+func (f *fakeWriter) AttachFs(_ context.Context, req *cxdb.AttachFsRequest) (*cxdb.AttachFsResult, error) {
+	if _, ok := f.blobs[req.FsRootHash]; !ok {
+		return nil, errors.New("root tree not uploaded")
+	}
+	f.attached[req.TurnID] = req.FsRootHash
+	return &cxdb.AttachFsResult{TurnID: req.TurnID, FsRootHash: req.FsRootHash}, nil
+}
+
+func (f *fakeWriter) PutBlobIfAbsent(_ context.Context, data []byte) ([32]byte, bool, error) {
+	h := blake3.Sum256(data)
+	_, exists := f.blobs[h]
+	f.blobs[h] = data
+	return h, !exists, nil
+}
+
+func TestRun(t *testing.T) {
+	w := newFakeWriter()
+	opts := DefaultOptions()
+	opts.Contexts = 5
+	opts.MinTurns, opts.MaxTurns = 2, 4
+	opts.ToolCallRatio = 1
+	opts.SnapshotRatio = 1
+	opts.Files = 6
+	opts.Labels = []string{"ui-dev"}
+	var progress []int
+	opts.Progress = func(done, total int) { progress = append(progress, done) }
+
+	res, err := Run(context.Background(), w, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.ContextIDs) != 5 || !reflect.DeepEqual(progress, []int{1, 2, 3, 4, 5}) {
+		t.Fatalf("contexts = %v, progress = %v", res.ContextIDs, progress)
+	}
+	if res.Turns != int(w.turns) || res.Snapshots != res.Turns/2 || res.ToolCalls < res.Turns/2 {
+		t.Errorf("result = %+v", res)
+	}
+
+	for _, id := range res.ContextIDs {
+		items := w.items[id]
+		if n := len(items); n < 4 || n > 8 || n%2 != 0 {
+			t.Errorf("context %d has %d items, want 2-4 exchanges", id, n)
+		}
+		meta := items[0].ContextMetadata
+		if meta == nil || meta.ClientTag != "cxdb-seed" || meta.Title == "" || !reflect.DeepEqual(meta.Labels, []string{Label, "ui-dev"}) {
+			t.Errorf("context %d metadata = %+v", id, meta)
+		}
+		for i, item := range items {
+			if i > 0 && item.ContextMetadata != nil {
+				t.Errorf("context %d item %d repeats the metadata", id, i)
+			}
+			if i > 0 && (w.parents[id][i] == 0 || item.Timestamp < items[i-1].Timestamp) {
+				t.Errorf("context %d item %d is out of order", id, i)
+			}
+			if i%2 == 1 && (item.Turn == nil || len(item.Turn.ToolCalls) == 0) {
+				t.Errorf("context %d item %d: assistant turn without tool calls at ratio 1", id, i)
+			}
+		}
+	}
+	if len(w.attached) != res.Snapshots {
+		t.Errorf("%d snapshots attached, result says %d", len(w.attached), res.Snapshots)
+	}
+}
+
+func TestRunRepeatable(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Contexts = 3
+	opts.SnapshotRatio = 0
+	summarize := func() []string {
+		w := newFakeWriter()
+		if _, err := Run(context.Background(), w, opts); err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for id := uint64(1); id <= w.contexts; id++ {
+			for _, item := range w.items[id] {
+				if item.UserInput != nil {
+					out = append(out, item.UserInput.Text)
+				} else {
+					out = append(out, item.Turn.Text)
+				}
+			}
+		}
+		return out
+	}
+	first, second := summarize(), summarize()
+	if !reflect.DeepEqual(first, second) {
+		t.Error("the same seed generated different conversations")
+	}
+	opts.Seed++
+	if reflect.DeepEqual(first, summarize()) {
+		t.Error("a different seed generated the same conversations")
+	}
+}
+
+func TestRunNoToolsOrSnapshots(t *testing.T) {
+	w := newFakeWriter()
+	opts := DefaultOptions()
+	opts.ToolCallRatio, opts.SnapshotRatio = 0, 0
+	res, err := Run(context.Background(), w, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ToolCalls != 0 || res.Snapshots != 0 || len(w.blobs) != 0 {
+		t.Errorf("result = %+v, %d blobs", res, len(w.blobs))
+	}
+}
+
+func TestRunPartial(t *testing.T) {
+	w := newFakeWriter()
+	w.failAt = 1
+	opts := DefaultOptions()
+	res, err := Run(context.Background(), w, opts)
+	if err == nil || len(res.ContextIDs) != 1 || res.Turns != 0 {
+		t.Errorf("Run = %+v, %v; want the failed context reported", res, err)
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	for name, mutate := range map[string]func(*Options){
+		"turns":    func(o *Options) { o.MinTurns, o.MaxTurns = 5, 2 },
+		"zero":     func(o *Options) { o.MinTurns = 0 },
+		"tools":    func(o *Options) { o.ToolCallRatio = 1.5 },
+		"snapshot": func(o *Options) { o.SnapshotRatio = -1 },
+		"files":    func(o *Options) { o.Files = 0 },
+	} {
+		opts := DefaultOptions()
+		mutate(&opts)
+		if _, err := Run(context.Background(), newFakeWriter(), opts); err == nil {
+			t.Errorf("%s: Run accepted %+v", name, opts)
+		}
+	}
+}
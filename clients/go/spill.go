@@ -0,0 +1,323 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// WithMemoryBudget caps the bytes of payloads held in memory by open
+// PayloadReaders, from OpenBlob and from GetLast with
+// GetLastOptions.PayloadReaders. Payloads beyond the budget are spilled to
+// temporary files (see WithSpillDir), so a process fetching many large
+// contexts at once stays within a fixed footprint. Zero or less (the
+// default) keeps every payload in memory.
+//
+// The budget is shared by every client dialed with the same Option value,
+// including the connections of a ReconnectingClient. A response frame being
+// read is not counted: each connection holds at most one in memory, and
+// OpenBlob reads in chunks where the server supports it.
+func WithMemoryBudget(bytes int64) Option {
+	budget := &memoryBudget{limit: bytes}
+	return func(o *clientOptions) {
+		o.memoryBudget = budget
+	}
+}
+
+// WithSpillDir sets the directory for payloads spilled beyond the memory
+// budget. The default is os.TempDir(). Spill files are removed when their
+// PayloadReader is closed.
+func WithSpillDir(dir string) Option {
+	return func(o *clientOptions) {
+		o.spillDir = dir
+	}
+}
+
+// memoryBudget counts the bytes held by in-memory PayloadReaders.
+type memoryBudget struct {
+	limit int64 // <= 0 is unlimited
+	used  atomic.Int64
+}
+
+// reserve claims n bytes and reports whether they fit.
+func (b *memoryBudget) reserve(n int64) bool {
+	if b == nil || b.limit <= 0 {
+		return true
+	}
+	for {
+		used := b.used.Load()
+		if used+n > b.limit {
+			return false
+		}
+		if b.used.CompareAndSwap(used, used+n) {
+			return true
+		}
+	}
+}
+
+func (b *memoryBudget) release(n int64) {
+	if b != nil && b.limit > 0 {
+		b.used.Add(-n)
+	}
+}
+
+// spiller places payloads in memory or, past the budget, in spill files.
+type spiller struct {
+	budget *memoryBudget
+	dir    string
+}
+
+// hold returns a reader over data, copying it to a spill file if it does
+// not fit the budget.
+func (s spiller) hold(data []byte) (*PayloadReader, error) {
+	n := int64(len(data))
+	if s.budget.reserve(n) {
+		return &PayloadReader{r: bytes.NewReader(data), size: n, budget: s.budget, reserved: n}, nil
+	}
+	w := s.writer()
+	if err := w.spill(); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.abort()
+		return nil, err
+	}
+	return w.finish()
+}
+
+// writer returns a spillWriter that buffers in memory until the budget
+// runs out.
+func (s spiller) writer() *spillWriter {
+	return &spillWriter{s: s}
+}
+
+// spillWriter collects a payload of unknown size, moving it to a spill file
+// as soon as the budget cannot cover the next write.
+type spillWriter struct {
+	s        spiller
+	buf      bytes.Buffer
+	reserved int64
+	file     *os.File
+	size     int64
+}
+
+func (w *spillWriter) Write(p []byte) (int, error) {
+	if w.file == nil && !w.s.budget.reserve(int64(len(p))) {
+		if err := w.spill(); err != nil {
+			return 0, err
+		}
+	}
+	if w.file == nil {
+		w.reserved += int64(len(p))
+		w.size += int64(len(p))
+		return w.buf.Write(p)
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// spill moves the buffered bytes to a new spill file and returns their
+// reservation.
+func (w *spillWriter) spill() error {
+	f, err := os.CreateTemp(w.s.dir, "cxdb-spill-*")
+	if err != nil {
+		return fmt.Errorf("spill payload: %w", err)
+	}
+	if _, err := f.Write(w.buf.Bytes()); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("spill payload: %w", err)
+	}
+	w.file = f
+	w.buf = bytes.Buffer{}
+	w.s.budget.release(w.reserved)
+	w.reserved = 0
+	return nil
+}
+
+// finish returns the reader; the writer must not be used afterwards.
+func (w *spillWriter) finish() (*PayloadReader, error) {
+	if w.file == nil {
+		return &PayloadReader{
+			r:        bytes.NewReader(w.buf.Bytes()),
+			size:     w.size,
+			budget:   w.s.budget,
+			reserved: w.reserved,
+		}, nil
+	}
+	return &PayloadReader{
+		r:    io.NewSectionReader(w.file, 0, w.size),
+		size: w.size,
+		file: w.file,
+	}, nil
+}
+
+// abort discards what was written.
+func (w *spillWriter) abort() {
+	w.s.budget.release(w.reserved)
+	w.reserved = 0
+	if w.file != nil {
+		_ = w.file.Close()
+		_ = os.Remove(w.file.Name())
+		w.file = nil
+	}
+}
+
+// ErrPayloadClosed is returned by reads from a closed PayloadReader.
+var ErrPayloadClosed = errors.New("cxdb: payload reader closed")
+
+// PayloadReader reads a payload held in memory or, once the client's memory
+// budget is used up, in a temporary file. Close it to return its memory to
+// the budget or remove its file. A PayloadReader is safe for concurrent
+// ReadAt calls only.
+type PayloadReader struct {
+	r interface {
+		io.ReadSeeker
+		io.ReaderAt
+	}
+	size int64
+
+	budget   *memoryBudget // in memory: reserved bytes are returned on Close
+	reserved int64
+	file     *os.File // spilled: removed on Close
+
+	closeOnce sync.Once
+	closed    atomic.Bool
+	closeErr  error
+}
+
+// Size returns the payload length.
+func (p *PayloadReader) Size() int64 { return p.size }
+
+// Spilled reports whether the payload is held in a temporary file.
+func (p *PayloadReader) Spilled() bool { return p.file != nil }
+
+func (p *PayloadReader) Read(b []byte) (int, error) {
+	if p.closed.Load() {
+		return 0, ErrPayloadClosed
+	}
+	return p.r.Read(b)
+}
+
+// ReadAt implements io.ReaderAt.
+func (p *PayloadReader) ReadAt(b []byte, off int64) (int, error) {
+	if p.closed.Load() {
+		return 0, ErrPayloadClosed
+	}
+	return p.r.ReadAt(b, off)
+}
+
+// Seek implements io.Seeker.
+func (p *PayloadReader) Seek(offset int64, whence int) (int64, error) {
+	if p.closed.Load() {
+		return 0, ErrPayloadClosed
+	}
+	return p.r.Seek(offset, whence)
+}
+
+// Bytes reads the whole payload into a new slice, outside the budget.
+func (p *PayloadReader) Bytes() ([]byte, error) {
+	if p.closed.Load() {
+		return nil, ErrPayloadClosed
+	}
+	data := make([]byte, p.size)
+	if _, err := p.r.ReadAt(data, 0); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Close releases the payload. It is safe to call more than once.
+func (p *PayloadReader) Close() error {
+	p.closeOnce.Do(func() {
+		p.closed.Store(true)
+		if p.file != nil {
+			p.closeErr = errors.Join(p.file.Close(), os.Remove(p.file.Name()))
+			return
+		}
+		p.budget.release(p.reserved)
+	})
+	return p.closeErr
+}
+
+// Close closes the PayloadReaders of the page's turns.
+func (p *TurnPage) Close() error {
+	return closeTurnReaders(p.Turns)
+}
+
+// CloseTurns closes the PayloadReaders of turns from GetLast.
+func CloseTurns(turns []TurnRecord) error {
+	return closeTurnReaders(turns)
+}
+
+func closeTurnReaders(turns []TurnRecord) error {
+	var errs []error
+	for i := range turns {
+		if r := turns[i].PayloadReader; r != nil {
+			errs = append(errs, r.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// holdPayloads moves each turn's Payload into a PayloadReader.
+func (s spiller) holdPayloads(turns []TurnRecord) error {
+	for i := range turns {
+		r, err := s.hold(turns[i].Payload)
+		if err != nil {
+			_ = closeTurnReaders(turns[:i])
+			return err
+		}
+		turns[i].PayloadReader = r
+		turns[i].Payload = nil
+	}
+	return nil
+}
+
+// OpenBlob fetches a blob into a PayloadReader, in memory while the
+// client's memory budget allows and in a spill file beyond it. If the
+// server supports ranged reads the blob is read in chunks as ReadBlob does;
+// otherwise it is fetched whole. Either way its hash is verified. Close the
+// reader when done.
+func (c *Client) OpenBlob(ctx context.Context, hash [32]byte, opts ...BlobReadOption) (*PayloadReader, error) {
+	return openBlob(ctx, hash, c.spill, c.blobRanges, blobReader{c.GetBlobRange, c.GetBlob}, opts)
+}
+
+func openBlob(ctx context.Context, hash [32]byte, s spiller, ranges bool, r blobReader, opts []BlobReadOption) (*PayloadReader, error) {
+	if !ranges {
+		// The whole blob arrives in one response; hand that buffer to the
+		// budget rather than copying it through a spillWriter.
+		data, err := r.getWhole(ctx, hash)
+		if err != nil {
+			return nil, fmt.Errorf("open blob: %w", err)
+		}
+		o := blobReadOptions{}
+		for _, opt := range opts {
+			opt(&o)
+		}
+		if o.progress != nil {
+			o.progress(BlobProgress{Hash: hash, Offset: uint64(len(data)), Total: uint64(len(data))})
+		}
+		pr, err := s.hold(data)
+		if err != nil {
+			return nil, fmt.Errorf("open blob: %w", err)
+		}
+		return pr, nil
+	}
+
+	w := s.writer()
+	if _, err := readBlobChunks(ctx, hash, w, nil, r, append(opts[:len(opts):len(opts)], WithResumeOffset(0))); err != nil {
+		w.abort()
+		return nil, fmt.Errorf("open blob: %w", err)
+	}
+	return w.finish()
+}
@@ -0,0 +1,152 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+func spillFiles(t *testing.T, dir string) int {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(entries)
+}
+
+func TestOpenBlobSpillsBeyondBudget(t *testing.T) {
+	data, hash := testBlob(1000)
+	dir := t.TempDir()
+	c := helloClient(t, newBlobServer(data).handle)
+	o := clientOptions{}
+	WithMemoryBudget(1500)(&o)
+	c.spill = spiller{budget: o.memoryBudget, dir: dir}
+	ctx := context.Background()
+
+	first, err := c.OpenBlob(ctx, hash, WithChunkSize(300))
+	if err != nil {
+		t.Fatalf("OpenBlob: %v", err)
+	}
+	if first.Spilled() || first.Size() != 1000 {
+		t.Errorf("first blob: spilled %t, size %d", first.Spilled(), first.Size())
+	}
+
+	// Only 500 bytes are left: the second copy moves to disk mid-read.
+	second, err := c.OpenBlob(ctx, hash, WithChunkSize(300))
+	if err != nil {
+		t.Fatalf("OpenBlob: %v", err)
+	}
+	if !second.Spilled() || spillFiles(t, dir) != 1 {
+		t.Errorf("second blob: spilled %t, %d spill files", second.Spilled(), spillFiles(t, dir))
+	}
+	if used := o.memoryBudget.used.Load(); used != 1000 {
+		t.Errorf("budget used = %d, want 1000", used)
+	}
+	for _, r := range []*PayloadReader{first, second} {
+		got, err := io.ReadAll(r)
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("read %d bytes, %v", len(got), err)
+		}
+	}
+
+	if err := second.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if spillFiles(t, dir) != 0 || o.memoryBudget.used.Load() != 0 {
+		t.Errorf("after close: %d spill files, %d bytes used", spillFiles(t, dir), o.memoryBudget.used.Load())
+	}
+	if _, err := first.Read(make([]byte, 1)); !errors.Is(err, ErrPayloadClosed) {
+		t.Errorf("read after close = %v", err)
+	}
+}
+
+func TestOpenBlobWithoutRanges(t *testing.T) {
+	data, hash := testBlob(1000)
+	srv := newBlobServer(data)
+	srv.noRanges = true
+	dir := t.TempDir()
+	c := helloClient(t, srv.handle)
+	c.spill = spiller{budget: &memoryBudget{limit: 500}, dir: dir}
+
+	r, err := c.OpenBlob(context.Background(), hash, WithChunkSize(300))
+	if err != nil {
+		t.Fatalf("OpenBlob: %v", err)
+	}
+	if srv.whole.Load() != 1 || srv.ranged.Load() != 0 {
+		t.Errorf("whole=%d ranged=%d, want 1, 0", srv.whole.Load(), srv.ranged.Load())
+	}
+	if !r.Spilled() || spillFiles(t, dir) != 1 {
+		t.Errorf("spilled %t, %d spill files", r.Spilled(), spillFiles(t, dir))
+	}
+	if got, err := r.Bytes(); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("read %d bytes, %v", len(got), err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if spillFiles(t, dir) != 0 {
+		t.Errorf("after close: %d spill files", spillFiles(t, dir))
+	}
+}
+
+func TestOpenBlobHashMismatchLeavesNothing(t *testing.T) {
+	data, _ := testBlob(1000)
+	dir := t.TempDir()
+	c := helloClient(t, newBlobServer(data).handle)
+	c.spill = spiller{budget: &memoryBudget{limit: 100}, dir: dir}
+
+	if _, err := c.OpenBlob(context.Background(), [32]byte{1}); !errors.Is(err, ErrInvalidResponse) {
+		t.Fatalf("OpenBlob = %v, want ErrInvalidResponse", err)
+	}
+	if spillFiles(t, dir) != 0 || c.spill.budget.used.Load() != 0 {
+		t.Errorf("%d spill files, %d bytes used", spillFiles(t, dir), c.spill.budget.used.Load())
+	}
+}
+
+func TestGetLastPayloadReaders(t *testing.T) {
+	store := &memStore{blobs: make(map[[32]byte][]byte)}
+	c := pipeClient(t, store.handle)
+	dir := t.TempDir()
+	c.spill = spiller{budget: &memoryBudget{limit: 150}, dir: dir}
+	ctx := context.Background()
+
+	payloads := [][]byte{bytes.Repeat([]byte("a"), 100), bytes.Repeat([]byte("b"), 100)}
+	for _, p := range payloads {
+		if _, err := c.AppendTurn(ctx, &AppendRequest{ContextID: 1, TypeID: "t", TypeVersion: 1, Payload: p}); err != nil {
+			t.Fatalf("AppendTurn: %v", err)
+		}
+	}
+
+	page, err := c.GetLastPage(ctx, 1, GetLastOptions{IncludePayload: true, PayloadReaders: true})
+	if err != nil {
+		t.Fatalf("GetLastPage: %v", err)
+	}
+	for i, turn := range page.Turns {
+		if turn.Payload != nil || turn.PayloadReader == nil {
+			t.Fatalf("turn %d: payload %d bytes, reader %v", i, len(turn.Payload), turn.PayloadReader)
+		}
+		if got, err := turn.PayloadReader.Bytes(); err != nil || !bytes.Equal(got, payloads[i]) {
+			t.Errorf("turn %d: %q, %v", i, got, err)
+		}
+	}
+	if page.Turns[0].PayloadReader.Spilled() || !page.Turns[1].PayloadReader.Spilled() {
+		t.Error("want the first payload in memory and the second spilled")
+	}
+
+	if err := page.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if spillFiles(t, dir) != 0 || c.spill.budget.used.Load() != 0 {
+		t.Errorf("after close: %d spill files, %d bytes used", spillFiles(t, dir), c.spill.budget.used.Load())
+	}
+}
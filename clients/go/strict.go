@@ -0,0 +1,263 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// ErrStrictDecode is wrapped by the *StrictDecodeError returned from
+// DecodeMsgpackStrict.
+var ErrStrictDecode = errors.New("cxdb: strict decode failed")
+
+// StrictDecodeError lists the fields that make a payload disagree with the
+// Go type it was decoded into. Paths are dotted field tags from the payload
+// root; array elements are written as [i] and map values as [key], e.g.
+// "11.2[0].12".
+type StrictDecodeError struct {
+	// Unknown are tags present in the payload with no matching struct field.
+	// They usually mean the producer is on a newer schema version.
+	Unknown []string
+
+	// Missing are fields the type requires but the payload did not set,
+	// as reported by RequiredFieldChecker.
+	Missing []string
+}
+
+func (e *StrictDecodeError) Error() string {
+	var parts []string
+	if len(e.Unknown) > 0 {
+		parts = append(parts, "unknown tags "+strings.Join(e.Unknown, ", "))
+	}
+	if len(e.Missing) > 0 {
+		parts = append(parts, "missing required "+strings.Join(e.Missing, ", "))
+	}
+	return ErrStrictDecode.Error() + ": " + strings.Join(parts, "; ")
+}
+
+func (e *StrictDecodeError) Unwrap() error {
+	return ErrStrictDecode
+}
+
+// RequiredFieldChecker is implemented by payload types whose required
+// fields depend on their contents, such as a discriminated union.
+// MissingFields returns the tag paths of required fields that are unset.
+type RequiredFieldChecker interface {
+	MissingFields() []string
+}
+
+// DecodeMsgpackStrict decodes data into v like DecodeMsgpackInto, then
+// reports payload tags that v has no field for and, if v implements
+// RequiredFieldChecker, required fields that are missing. v is populated
+// even when a *StrictDecodeError is returned.
+func DecodeMsgpackStrict(data []byte, v any) error {
+	if err := msgpack.Unmarshal(data, v); err != nil {
+		return err
+	}
+	return strictCheck(data, v)
+}
+
+func strictCheck(data []byte, v any) error {
+	serr := &StrictDecodeError{}
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	if err := collectUnknown(dec, reflect.TypeOf(v), "", &serr.Unknown); err != nil {
+		return fmt.Errorf("strict decode: %w", err)
+	}
+	if rc, ok := v.(RequiredFieldChecker); ok {
+		serr.Missing = rc.MissingFields()
+	}
+	if len(serr.Unknown) == 0 && len(serr.Missing) == 0 {
+		return nil
+	}
+	return serr
+}
+
+// Strict decode modes for CXDB_STRICT_DECODE.
+const (
+	strictDecodeOff   = ""
+	strictDecodeWarn  = "warn"
+	strictDecodeError = "error"
+)
+
+// strictDecodeMode reads CXDB_STRICT_DECODE once. "warn" makes
+// DecodeMsgpackInto log strict decode problems; "error" makes it return
+// them.
+var strictDecodeMode = sync.OnceValue(func() string {
+	switch mode := strings.ToLower(strings.TrimSpace(os.Getenv("CXDB_STRICT_DECODE"))); mode {
+	case strictDecodeWarn, strictDecodeError:
+		return mode
+	case "1", "true":
+		return strictDecodeWarn
+	default:
+		return strictDecodeOff
+	}
+})
+
+var (
+	timeType               = reflect.TypeOf(time.Time{})
+	customDecoderType      = reflect.TypeOf((*msgpack.CustomDecoder)(nil)).Elem()
+	msgpackUnmarshalerType = reflect.TypeOf((*msgpack.Unmarshaler)(nil)).Elem()
+)
+
+// opaque reports whether t decodes itself, so its encoded form cannot be
+// compared against struct fields.
+func opaque(t reflect.Type) bool {
+	if t == timeType {
+		return true
+	}
+	pt := reflect.PointerTo(t)
+	return t.Implements(customDecoderType) || pt.Implements(customDecoderType) ||
+		t.Implements(msgpackUnmarshalerType) || pt.Implements(msgpackUnmarshalerType)
+}
+
+// collectUnknown walks the next value in dec alongside type t and appends
+// the paths of map keys that t has no field for.
+func collectUnknown(dec *msgpack.Decoder, t reflect.Type, path string, unknown *[]string) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if opaque(t) {
+		return dec.Skip()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		n, err := dec.DecodeMapLen()
+		if err != nil {
+			return fmt.Errorf("%s: %w", pathOrRoot(path), err)
+		}
+		fields := structFields(t)
+		for i := 0; i < n; i++ {
+			key, err := dec.DecodeInterface()
+			if err != nil {
+				return fmt.Errorf("%s: key %d: %w", pathOrRoot(path), i, err)
+			}
+			name := fmt.Sprint(key)
+			ft, ok := fields[name]
+			if !ok {
+				*unknown = append(*unknown, joinPath(path, name))
+				if err := dec.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := collectUnknown(dec, ft, joinPath(path, name), unknown); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return dec.Skip()
+		}
+		n, err := dec.DecodeArrayLen()
+		if err != nil {
+			return fmt.Errorf("%s: %w", pathOrRoot(path), err)
+		}
+		for i := 0; i < n; i++ {
+			if err := collectUnknown(dec, t.Elem(), fmt.Sprintf("%s[%d]", path, i), unknown); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Map:
+		n, err := dec.DecodeMapLen()
+		if err != nil {
+			return fmt.Errorf("%s: %w", pathOrRoot(path), err)
+		}
+		for i := 0; i < n; i++ {
+			key, err := dec.DecodeInterface()
+			if err != nil {
+				return fmt.Errorf("%s: key %d: %w", pathOrRoot(path), i, err)
+			}
+			if err := collectUnknown(dec, t.Elem(), fmt.Sprintf("%s[%v]", path, key), unknown); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		return dec.Skip()
+	}
+}
+
+// structFields maps msgpack field names (the tag, or the Go field name when
+// untagged) to field types, flattening embedded structs as msgpack does.
+func structFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("msgpack")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for k, v := range structFields(ft) {
+					if _, ok := fields[k]; !ok {
+						fields[k] = v
+					}
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "payload"
+	}
+	return path
+}
+
+// decodeStrictByEnv applies CXDB_STRICT_DECODE to a successful
+// DecodeMsgpackInto.
+func decodeStrictByEnv(data []byte, v any) error {
+	mode := strictDecodeMode()
+	if mode == strictDecodeOff {
+		return nil
+	}
+	err := strictCheck(data, v)
+	if err == nil {
+		return nil
+	}
+	if mode == strictDecodeError {
+		return err
+	}
+	slog.Warn("[cxdb] strict decode", "type", reflect.TypeOf(v).String(), "error", err)
+	return nil
+}
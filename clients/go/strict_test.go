@@ -0,0 +1,124 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/strongdm/ai-cxdb/clients/go/types"
+)
+
+func mustEncode(t *testing.T, v any) []byte {
+	t.Helper()
+	data, err := EncodeMsgpack(v)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	return data
+}
+
+func TestDecodeMsgpackStrictCanonicalItem(t *testing.T) {
+	item := types.NewAssistantTurn("hello")
+	item.Turn.ToolCalls = []types.ToolCallItem{{ID: "call-1", Name: "ls", Args: "{}"}}
+
+	var got types.ConversationItem
+	if err := DecodeMsgpackStrict(mustEncode(t, item), &got); err != nil {
+		t.Fatalf("DecodeMsgpackStrict: %v", err)
+	}
+	if got.Turn == nil || got.Turn.Text != "hello" {
+		t.Fatalf("decoded %+v", got)
+	}
+}
+
+func TestDecodeMsgpackStrictUnknownTags(t *testing.T) {
+	// A producer on a newer schema: tag 40 on the item, tag 9 on the
+	// assistant turn, and tag 99 on a nested tool call.
+	payload := mustEncode(t, map[string]any{
+		"1": "assistant_turn",
+		"11": map[string]any{
+			"1": "hi",
+			"2": []any{
+				map[string]any{"1": "call-1", "2": "ls"},
+				map[string]any{"1": "call-2", "2": "cat", "99": "new"},
+			},
+			"9": "new",
+		},
+		"40": true,
+	})
+
+	var got types.ConversationItem
+	err := DecodeMsgpackStrict(payload, &got)
+	if !errors.Is(err, ErrStrictDecode) {
+		t.Fatalf("err = %v, want ErrStrictDecode", err)
+	}
+	var serr *StrictDecodeError
+	if !errors.As(err, &serr) {
+		t.Fatalf("err = %T, want *StrictDecodeError", err)
+	}
+	want := []string{"11.2[1].99", "11.9", "40"}
+	if !reflect.DeepEqual(serr.Unknown, want) {
+		t.Fatalf("Unknown = %v, want %v", serr.Unknown, want)
+	}
+	if len(serr.Missing) != 0 {
+		t.Fatalf("Missing = %v, want none", serr.Missing)
+	}
+	// Known fields are still decoded.
+	if got.Turn == nil || got.Turn.Text != "hi" || len(got.Turn.ToolCalls) != 2 {
+		t.Fatalf("decoded %+v", got.Turn)
+	}
+
+	// The lenient decoder accepts the same payload.
+	if err := DecodeMsgpackInto(payload, &types.ConversationItem{}); err != nil {
+		t.Fatalf("DecodeMsgpackInto: %v", err)
+	}
+}
+
+func TestDecodeMsgpackStrictMissingRequired(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload map[string]any
+		want    []string
+	}{
+		{"no item type", map[string]any{"4": "id"}, []string{"1"}},
+		{"variant absent", map[string]any{"1": "user_input"}, []string{"10"}},
+		{"system without kind", map[string]any{"1": "system", "12": map[string]any{"3": "text"}}, []string{"12.1"}},
+		{"handoff without agents", map[string]any{"1": "handoff", "13": map[string]any{"3": "transfer"}}, []string{"13.1", "13.2"}},
+		{"tool call without name", map[string]any{
+			"1":  "assistant_turn",
+			"11": map[string]any{"2": []any{map[string]any{"1": "call-1"}}},
+		}, []string{"11.2[0].2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got types.ConversationItem
+			err := DecodeMsgpackStrict(mustEncode(t, tt.payload), &got)
+			var serr *StrictDecodeError
+			if !errors.As(err, &serr) {
+				t.Fatalf("err = %v, want *StrictDecodeError", err)
+			}
+			if !reflect.DeepEqual(serr.Missing, tt.want) {
+				t.Fatalf("Missing = %v, want %v", serr.Missing, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeMsgpackIntoStrictMode(t *testing.T) {
+	orig := strictDecodeMode
+	t.Cleanup(func() { strictDecodeMode = orig })
+
+	payload := mustEncode(t, map[string]any{"1": "user_input", "10": map[string]any{"1": "hi"}, "40": 1})
+
+	strictDecodeMode = func() string { return strictDecodeWarn }
+	if err := DecodeMsgpackInto(payload, &types.ConversationItem{}); err != nil {
+		t.Fatalf("warn mode returned %v", err)
+	}
+
+	strictDecodeMode = func() string { return strictDecodeError }
+	if err := DecodeMsgpackInto(payload, &types.ConversationItem{}); !errors.Is(err, ErrStrictDecode) {
+		t.Fatalf("error mode returned %v, want ErrStrictDecode", err)
+	}
+}
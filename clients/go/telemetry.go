@@ -0,0 +1,345 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Telemetry defaults.
+const (
+	DefaultTelemetryRate          = 10 // appends per second
+	DefaultTelemetryQueueSize     = 1000
+	DefaultTelemetryFlushInterval = time.Second
+	DefaultTelemetryAppendTimeout = 10 * time.Second
+)
+
+// ErrTelemetryClosed is returned when enqueueing on a closed TelemetryWriter.
+var ErrTelemetryClosed = errors.New("cxdb: telemetry writer closed")
+
+// TurnAppender appends turns. Client and ReconnectingClient implement it.
+type TurnAppender interface {
+	AppendTurn(ctx context.Context, req *AppendRequest) (*AppendResult, error)
+}
+
+// TelemetryOption configures a TelemetryWriter.
+type TelemetryOption func(*telemetryOptions)
+
+type telemetryOptions struct {
+	rate          float64
+	bandwidth     int
+	queueSize     int
+	flushInterval time.Duration
+	appendTimeout time.Duration
+}
+
+// WithTelemetryRate caps appends per second. Default: DefaultTelemetryRate.
+func WithTelemetryRate(perSecond float64) TelemetryOption {
+	return func(o *telemetryOptions) {
+		if perSecond > 0 {
+			o.rate = perSecond
+		}
+	}
+}
+
+// WithTelemetryBandwidth caps payload bytes appended per second. Default:
+// unlimited.
+func WithTelemetryBandwidth(bytesPerSecond int) TelemetryOption {
+	return func(o *telemetryOptions) {
+		o.bandwidth = bytesPerSecond
+	}
+}
+
+// WithTelemetryQueueSize bounds the number of pending items. When the queue
+// is full the oldest pending item is dropped. Default:
+// DefaultTelemetryQueueSize.
+func WithTelemetryQueueSize(n int) TelemetryOption {
+	return func(o *telemetryOptions) {
+		if n > 0 {
+			o.queueSize = n
+		}
+	}
+}
+
+// WithTelemetryFlushInterval sets how long items accumulate, and can be
+// coalesced, before a batch is sent. Default: DefaultTelemetryFlushInterval.
+func WithTelemetryFlushInterval(d time.Duration) TelemetryOption {
+	return func(o *telemetryOptions) {
+		if d > 0 {
+			o.flushInterval = d
+		}
+	}
+}
+
+// WithTelemetryAppendTimeout bounds each append. Default:
+// DefaultTelemetryAppendTimeout.
+func WithTelemetryAppendTimeout(d time.Duration) TelemetryOption {
+	return func(o *telemetryOptions) {
+		if d > 0 {
+			o.appendTimeout = d
+		}
+	}
+}
+
+// TelemetryStats counts what a TelemetryWriter did with its items.
+type TelemetryStats struct {
+	Enqueued  uint64
+	Sent      uint64
+	Coalesced uint64 // replaced by a newer item with the same key
+	Dropped   uint64 // evicted from a full queue or left over at Close
+	Failed    uint64 // the append returned an error
+	Pending   int
+}
+
+// TelemetryWriter appends low-priority turns (metrics snapshots, debug
+// traces) in the background at a bounded rate, so verbose instrumentation
+// cannot crowd out interactive appends.
+//
+// Items are collected for a flush interval and then sent one at a time,
+// paced by the rate and bandwidth limits. Items enqueued with the same
+// coalescing key replace each other until sent, so only the latest
+// snapshot goes out. Delivery is best effort: failed appends are logged
+// and counted, not retried.
+//
+// Requests on one Client share a connection, so give the writer its own
+// Client (or ReconnectingClient) to keep it off the conversation path.
+type TelemetryWriter struct {
+	appender TurnAppender
+	opts     telemetryOptions
+
+	mu       sync.Mutex
+	pending  []*telemetryItem
+	byKey    map[string]*telemetryItem
+	stats    TelemetryStats
+	closed   bool
+	closeCtx context.Context
+
+	// sendCtx bounds paced sends; Close cancels it once its own context
+	// is done, so an append in flight cannot outlive the Close deadline.
+	sendCtx    context.Context
+	cancelSend context.CancelFunc
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type telemetryItem struct {
+	key string
+	req AppendRequest
+}
+
+// NewTelemetryWriter starts a writer that appends through appender. Call
+// Close to flush and stop it.
+func NewTelemetryWriter(appender TurnAppender, opts ...TelemetryOption) *TelemetryWriter {
+	o := telemetryOptions{
+		rate:          DefaultTelemetryRate,
+		queueSize:     DefaultTelemetryQueueSize,
+		flushInterval: DefaultTelemetryFlushInterval,
+		appendTimeout: DefaultTelemetryAppendTimeout,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	w := &TelemetryWriter{
+		appender: appender,
+		opts:     o,
+		byKey:    make(map[string]*telemetryItem),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	w.sendCtx, w.cancelSend = context.WithCancel(context.Background())
+	go w.run()
+	return w
+}
+
+// Enqueue queues req for appending. The request is copied but its Payload
+// is not; do not modify it afterwards.
+func (w *TelemetryWriter) Enqueue(req *AppendRequest) error {
+	return w.enqueue("", req)
+}
+
+// EnqueueCoalesced queues req under key. If an item with the same key is
+// still pending, req replaces it in place.
+func (w *TelemetryWriter) EnqueueCoalesced(key string, req *AppendRequest) error {
+	return w.enqueue(key, req)
+}
+
+func (w *TelemetryWriter) enqueue(key string, req *AppendRequest) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return ErrTelemetryClosed
+	}
+	w.stats.Enqueued++
+
+	if key != "" {
+		if item, ok := w.byKey[key]; ok {
+			item.req = *req
+			w.stats.Coalesced++
+			return nil
+		}
+	}
+	if len(w.pending) >= w.opts.queueSize {
+		w.forget(w.pending[0])
+		w.pending = w.pending[1:]
+		w.stats.Dropped++
+	}
+	item := &telemetryItem{key: key, req: *req}
+	w.pending = append(w.pending, item)
+	if key != "" {
+		w.byKey[key] = item
+	}
+	return nil
+}
+
+// forget removes item from the coalescing index. Callers hold w.mu.
+func (w *TelemetryWriter) forget(item *telemetryItem) {
+	if item.key != "" && w.byKey[item.key] == item {
+		delete(w.byKey, item.key)
+	}
+}
+
+// next pops the oldest pending item, or returns nil.
+func (w *TelemetryWriter) next() *telemetryItem {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.pending) == 0 {
+		return nil
+	}
+	item := w.pending[0]
+	w.pending[0] = nil
+	w.pending = w.pending[1:]
+	w.forget(item)
+	return item
+}
+
+// Stats returns a snapshot of the writer's counters.
+func (w *TelemetryWriter) Stats() TelemetryStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	s := w.stats
+	s.Pending = len(w.pending)
+	return s
+}
+
+// Close stops accepting items and sends what is pending, without pacing,
+// until ctx is done. Items still pending then are dropped and Close returns
+// an error wrapping ctx.Err(); an append in flight is canceled.
+func (w *TelemetryWriter) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		<-w.done
+		return nil
+	}
+	w.closed = true
+	w.closeCtx = ctx
+	w.mu.Unlock()
+
+	stopCancel := context.AfterFunc(ctx, w.cancelSend)
+	defer stopCancel()
+	defer w.cancelSend()
+	close(w.stop)
+	<-w.done
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if n := len(w.pending); n > 0 {
+		w.stats.Dropped += uint64(n)
+		w.pending = nil
+		w.byKey = make(map[string]*telemetryItem)
+		return fmt.Errorf("telemetry: %d items not flushed: %w", n, ctx.Err())
+	}
+	return nil
+}
+
+func (w *TelemetryWriter) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.opts.flushInterval)
+	defer ticker.Stop()
+
+	var next time.Time // earliest time the next append may start
+	for {
+		select {
+		case <-w.stop:
+			w.drain()
+			return
+		case <-ticker.C:
+		}
+
+		// Send only what was pending at the tick; later items wait for the
+		// next batch and can still be coalesced.
+		w.mu.Lock()
+		batch := len(w.pending)
+		w.mu.Unlock()
+
+		for ; batch > 0; batch-- {
+			if wait := time.Until(next); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-w.stop:
+					timer.Stop()
+					w.drain()
+					return
+				case <-timer.C:
+				}
+			}
+			item := w.next()
+			if item == nil {
+				break
+			}
+			start := time.Now()
+			w.send(w.sendCtx, item)
+			next = start.Add(w.pace(len(item.req.Payload)))
+		}
+	}
+}
+
+// pace is the minimum spacing after an append of n payload bytes.
+func (w *TelemetryWriter) pace(n int) time.Duration {
+	d := time.Duration(float64(time.Second) / w.opts.rate)
+	if w.opts.bandwidth > 0 {
+		if b := time.Duration(n) * time.Second / time.Duration(w.opts.bandwidth); b > d {
+			d = b
+		}
+	}
+	return d
+}
+
+// drain sends everything pending until the Close context is done.
+func (w *TelemetryWriter) drain() {
+	w.mu.Lock()
+	ctx := w.closeCtx
+	w.mu.Unlock()
+	for ctx.Err() == nil {
+		item := w.next()
+		if item == nil {
+			return
+		}
+		w.send(ctx, item)
+	}
+}
+
+func (w *TelemetryWriter) send(ctx context.Context, item *telemetryItem) {
+	ctx, cancel := context.WithTimeout(ctx, w.opts.appendTimeout)
+	defer cancel()
+	_, err := w.appender.AppendTurn(ctx, &item.req)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err != nil {
+		w.stats.Failed++
+		slog.Warn("[cxdb] telemetry append failed",
+			"context_id", item.req.ContextID,
+			"type_id", item.req.TypeID,
+			"error", err)
+		return
+	}
+	w.stats.Sent++
+}
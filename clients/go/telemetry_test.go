@@ -0,0 +1,199 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingAppender records appends and when they happened.
+type recordingAppender struct {
+	mu    sync.Mutex
+	reqs  []AppendRequest
+	times []time.Time
+	err   error
+}
+
+func (a *recordingAppender) AppendTurn(_ context.Context, req *AppendRequest) (*AppendResult, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.err != nil {
+		return nil, a.err
+	}
+	a.reqs = append(a.reqs, *req)
+	a.times = append(a.times, time.Now())
+	return &AppendResult{ContextID: req.ContextID, TurnID: uint64(len(a.reqs))}, nil
+}
+
+func (a *recordingAppender) payloads() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	out := make([]string, len(a.reqs))
+	for i, r := range a.reqs {
+		out[i] = string(r.Payload)
+	}
+	return out
+}
+
+func telemetryReq(payload string) *AppendRequest {
+	return &AppendRequest{ContextID: 1, TypeID: "com.example.Metrics", Payload: []byte(payload)}
+}
+
+func TestTelemetryWriterCoalesces(t *testing.T) {
+	app := &recordingAppender{}
+	w := NewTelemetryWriter(app, WithTelemetryFlushInterval(time.Hour), WithTelemetryRate(1000))
+
+	for _, p := range []string{"m1", "m2", "m3"} {
+		if err := w.EnqueueCoalesced("metrics:1", telemetryReq(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = w.Enqueue(telemetryReq("trace"))
+	_ = w.EnqueueCoalesced("metrics:1", telemetryReq("m4"))
+
+	if err := w.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	got := app.payloads()
+	if len(got) != 2 || got[0] != "m4" || got[1] != "trace" {
+		t.Fatalf("appended %v, want [m4 trace]", got)
+	}
+	s := w.Stats()
+	if s.Enqueued != 5 || s.Coalesced != 3 || s.Sent != 2 || s.Pending != 0 {
+		t.Fatalf("stats = %+v", s)
+	}
+	if err := w.Enqueue(telemetryReq("late")); !errors.Is(err, ErrTelemetryClosed) {
+		t.Fatalf("Enqueue after Close = %v", err)
+	}
+}
+
+func TestTelemetryWriterRateLimit(t *testing.T) {
+	app := &recordingAppender{}
+	w := NewTelemetryWriter(app, WithTelemetryFlushInterval(5*time.Millisecond), WithTelemetryRate(20))
+	defer func() { _ = w.Close(context.Background()) }()
+
+	for i := 0; i < 4; i++ {
+		_ = w.Enqueue(telemetryReq("x"))
+	}
+	waitFor(t, func() bool { return w.Stats().Sent == 4 })
+
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	for i := 1; i < len(app.times); i++ {
+		if gap := app.times[i].Sub(app.times[i-1]); gap < 45*time.Millisecond {
+			t.Fatalf("append %d came %v after the previous one, want >= 50ms", i, gap)
+		}
+	}
+}
+
+func TestTelemetryWriterBandwidthLimit(t *testing.T) {
+	app := &recordingAppender{}
+	w := NewTelemetryWriter(app,
+		WithTelemetryFlushInterval(5*time.Millisecond),
+		WithTelemetryRate(1000),
+		WithTelemetryBandwidth(1000))
+	defer func() { _ = w.Close(context.Background()) }()
+
+	big := string(make([]byte, 100))
+	_ = w.Enqueue(telemetryReq(big))
+	_ = w.Enqueue(telemetryReq(big))
+	waitFor(t, func() bool { return w.Stats().Sent == 2 })
+
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	if gap := app.times[1].Sub(app.times[0]); gap < 90*time.Millisecond {
+		t.Fatalf("100-byte appends at 1000 B/s came %v apart, want >= 100ms", gap)
+	}
+}
+
+func TestTelemetryWriterQueueFullDropsOldest(t *testing.T) {
+	app := &recordingAppender{}
+	w := NewTelemetryWriter(app, WithTelemetryFlushInterval(time.Hour), WithTelemetryQueueSize(2))
+
+	_ = w.EnqueueCoalesced("a", telemetryReq("a"))
+	_ = w.Enqueue(telemetryReq("b"))
+	_ = w.Enqueue(telemetryReq("c"))
+	// "a" was evicted, so this starts a new item instead of coalescing.
+	_ = w.EnqueueCoalesced("a", telemetryReq("a2"))
+
+	if err := w.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := app.payloads(); len(got) != 2 || got[0] != "c" || got[1] != "a2" {
+		t.Fatalf("appended %v, want [c a2]", got)
+	}
+	if s := w.Stats(); s.Dropped != 2 || s.Coalesced != 0 {
+		t.Fatalf("stats = %+v", s)
+	}
+}
+
+func TestTelemetryWriterCloseDeadline(t *testing.T) {
+	app := &recordingAppender{}
+	w := NewTelemetryWriter(app, WithTelemetryFlushInterval(time.Hour))
+	_ = w.Enqueue(telemetryReq("a"))
+	_ = w.Enqueue(telemetryReq("b"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := w.Close(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Close = %v, want context.Canceled", err)
+	}
+	if s := w.Stats(); s.Dropped != 2 || s.Sent != 0 || s.Pending != 0 {
+		t.Fatalf("stats = %+v", s)
+	}
+}
+
+// blockingAppender blocks every append until its context is done.
+type blockingAppender struct{ started chan struct{} }
+
+func (a *blockingAppender) AppendTurn(ctx context.Context, _ *AppendRequest) (*AppendResult, error) {
+	a.started <- struct{}{}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestTelemetryWriterCloseCancelsPacedSend(t *testing.T) {
+	app := &blockingAppender{started: make(chan struct{}, 1)}
+	w := NewTelemetryWriter(app, WithTelemetryFlushInterval(time.Millisecond))
+	_ = w.Enqueue(telemetryReq("a"))
+	<-app.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_ = w.Close(ctx)
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("Close took %v with a 50ms deadline", d)
+	}
+	if s := w.Stats(); s.Failed != 1 {
+		t.Fatalf("stats = %+v", s)
+	}
+}
+
+func TestTelemetryWriterCountsFailures(t *testing.T) {
+	app := &recordingAppender{err: errors.New("boom")}
+	w := NewTelemetryWriter(app, WithTelemetryFlushInterval(time.Hour))
+	_ = w.Enqueue(telemetryReq("a"))
+	if err := w.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if s := w.Stats(); s.Failed != 1 || s.Sent != 0 {
+		t.Fatalf("stats = %+v", s)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
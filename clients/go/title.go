@@ -0,0 +1,130 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/strongdm/ai-cxdb/clients/go/types"
+)
+
+// maxHeuristicTitle bounds titles from HeuristicTitle, in runes.
+const maxHeuristicTitle = 80
+
+// TitleGenerator derives a context title from the first user input of a
+// context, for example by asking a small model for a summary. It should
+// honor ctx, which carries the WithTitleGenerator timeout.
+type TitleGenerator func(ctx context.Context, input *types.UserInput) (string, error)
+
+// WithTitleGenerator makes AppendConversationItem title new contexts. When
+// a user input is appended as the first turn of a context and carries no
+// ContextMetadata.Title, gen is called and its result is stored as the
+// title in that turn's ContextMetadata, where the server indexes it for
+// ListContexts and search.
+//
+// The title has to be known before the append: the server only reads
+// context metadata from the first turn, so it cannot be filled in later.
+// gen therefore runs synchronously, bounded by timeout (0 for no bound);
+// if it fails or times out, HeuristicTitle is used instead. Deciding that a
+// turn is the first costs a GetHead round trip per untitled user input
+// appended at the head.
+func WithTitleGenerator(gen TitleGenerator, timeout time.Duration) Option {
+	return func(o *clientOptions) {
+		o.titleGenerator = gen
+		o.titleTimeout = timeout
+	}
+}
+
+// HeuristicTitle is a TitleGenerator that needs no model: the first
+// non-empty line of the input with whitespace collapsed, cut at a word
+// boundary to at most 80 characters. Inputs with only attached files are
+// titled after the first file.
+func HeuristicTitle(_ context.Context, input *types.UserInput) (string, error) {
+	line := ""
+	for _, l := range strings.Split(input.Text, "\n") {
+		if line = strings.Join(strings.Fields(l), " "); line != "" {
+			break
+		}
+	}
+	if line == "" && len(input.Files) > 0 {
+		line = input.Files[0]
+	}
+	if utf8.RuneCountInString(line) <= maxHeuristicTitle {
+		return line, nil
+	}
+	cut := string([]rune(line)[:maxHeuristicTitle-1])
+	if i := strings.LastIndexByte(cut, ' '); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return cut + "…", nil
+}
+
+// withGeneratedTitle returns item with a generated title if it is the
+// untitled first user input of contextID. item itself is not modified.
+func (c *Client) withGeneratedTitle(ctx context.Context, contextID, parentTurnID uint64, item *types.ConversationItem) *types.ConversationItem {
+	if c.titleGenerator == nil || item.ItemType != types.ItemTypeUserInput || item.UserInput == nil || parentTurnID != 0 {
+		return item
+	}
+	if item.ContextMetadata != nil && item.ContextMetadata.Title != "" {
+		return item
+	}
+	head, err := c.GetHead(ctx, contextID)
+	if err != nil || head.HeadTurnID != 0 {
+		return item // the append reports a real failure
+	}
+
+	title := c.generateTitle(ctx, item.UserInput)
+	if title == "" {
+		return item
+	}
+	var meta types.ContextMetadata
+	if item.ContextMetadata != nil {
+		meta = *item.ContextMetadata
+	}
+	meta.Title = title
+	titled := *item
+	titled.ContextMetadata = &meta
+	return &titled
+}
+
+// generateTitle runs the title generator within the title timeout, falling
+// back to HeuristicTitle.
+func (c *Client) generateTitle(ctx context.Context, input *types.UserInput) string {
+	genCtx := ctx
+	if c.titleTimeout > 0 {
+		var cancel context.CancelFunc
+		genCtx, cancel = context.WithTimeout(ctx, c.titleTimeout)
+		defer cancel()
+	}
+
+	type generated struct {
+		title string
+		err   error
+	}
+	done := make(chan generated, 1) // the generator may outlive the timeout
+	go func() {
+		title, err := c.titleGenerator(genCtx, input)
+		done <- generated{title, err}
+	}()
+
+	var err error
+	select {
+	case g := <-done:
+		if title := strings.TrimSpace(g.title); g.err == nil && title != "" {
+			return title
+		}
+		err = g.err
+	case <-genCtx.Done():
+		err = genCtx.Err()
+	}
+	if err != nil {
+		slog.Warn("[cxdb] title generation failed, using heuristic title", "error", err)
+	}
+	title, _ := HeuristicTitle(ctx, input)
+	return title
+}
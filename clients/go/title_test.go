@@ -0,0 +1,102 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/strongdm/ai-cxdb/clients/go/types"
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
+)
+
+func TestHeuristicTitle(t *testing.T) {
+	long := strings.Repeat("word ", 30)
+	tests := []struct {
+		input types.UserInput
+		want  string
+	}{
+		{types.UserInput{Text: "\n  Fix the   flaky test\nin CI"}, "Fix the flaky test"},
+		{types.UserInput{Files: []string{"trace.log"}}, "trace.log"},
+		{types.UserInput{Text: long}, strings.TrimSpace(long[:75]) + "…"},
+	}
+	for _, tt := range tests {
+		if got, _ := HeuristicTitle(context.Background(), &tt.input); got != tt.want {
+			t.Errorf("HeuristicTitle(%q) = %q, want %q", tt.input.Text, got, tt.want)
+		}
+	}
+}
+
+func TestAppendConversationItemGeneratesTitle(t *testing.T) {
+	var headTurn uint64
+	var appended [][]byte
+	c := pipeClient(t, func(msgType uint16, p []byte) (uint16, uint16, []byte) {
+		switch msgType {
+		case wire.MsgGetHead:
+			head := make([]byte, 20)
+			copy(head, p[:8])
+			binary.LittleEndian.PutUint64(head[8:], headTurn)
+			return msgType, 0, head
+		case wire.MsgAppend:
+			appended = append(appended, p)
+			return msgType, 0, make([]byte, 52)
+		}
+		return wire.MsgError, 0, wire.AppendError(nil, wire.CodeInvalidInput, "unexpected message")
+	})
+
+	var calls atomic.Int32
+	c.titleGenerator = func(ctx context.Context, in *types.UserInput) (string, error) {
+		calls.Add(1)
+		switch in.Text {
+		case "slow":
+			<-ctx.Done()
+			return "", ctx.Err()
+		case "broken":
+			return "", errors.New("model unavailable")
+		}
+		return "Summary: " + in.Text, nil
+	}
+	c.titleTimeout = 20 * time.Millisecond
+
+	contains := func(i int, title string) bool {
+		enc, err := EncodeMsgpack(title)
+		return err == nil && bytes.Contains(appended[i], enc)
+	}
+	ctx := context.Background()
+
+	item := types.NewUserInput("hello")
+	if _, err := c.AppendConversationItem(ctx, 1, 0, item); err != nil {
+		t.Fatal(err)
+	}
+	if !contains(0, "Summary: hello") || item.ContextMetadata != nil {
+		t.Error("first user input not titled, or the caller's item was modified")
+	}
+
+	for _, text := range []string{"slow", "broken"} {
+		if _, err := c.AppendConversationItem(ctx, 1, 0, types.NewUserInput(text)); err != nil {
+			t.Fatal(err)
+		}
+		if !contains(len(appended)-1, text) {
+			t.Errorf("%s generator: heuristic title not used", text)
+		}
+	}
+
+	// Titled items, later turns and other item types are left alone.
+	calls.Store(0)
+	titled := types.NewUserInput("x").WithContextMetadata(&types.ContextMetadata{Title: "Mine"})
+	_, _ = c.AppendConversationItem(ctx, 1, 0, titled)
+	_, _ = c.AppendConversationItem(ctx, 1, 0, types.NewAssistantTurn("hi"))
+	_, _ = c.AppendConversationItem(ctx, 1, 7, types.NewUserInput("reply"))
+	headTurn = 9
+	_, _ = c.AppendConversationItem(ctx, 1, 0, types.NewUserInput("again"))
+	if n := calls.Load(); n != 0 {
+		t.Errorf("generator called %d times for turns that need no title", n)
+	}
+}
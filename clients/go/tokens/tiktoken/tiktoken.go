@@ -0,0 +1,219 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+// Package tiktoken is a dependency-free BPE tokenizer compatible with
+// OpenAI's tiktoken encodings.
+//
+// Rank files are not bundled; download the one for your model (for example
+// cl100k_base.tiktoken or o200k_base.tiktoken from
+// openaipublic.blob.core.windows.net/encodings) and load it with the
+// matching pre-tokenization pattern. Special tokens are treated as ordinary
+// text.
+package tiktoken
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Pre-tokenization patterns. They are the upstream patterns with the
+// trailing `\s+(?!\S)` alternative removed, since Go's regexp has no
+// lookahead; Encoding applies that rule itself.
+const (
+	PatternCL100K = `(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+`
+
+	PatternO200K = `[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+		`|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+		`|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|\s+`
+)
+
+// ErrInvalidRankFile is returned when a rank file cannot be parsed.
+var ErrInvalidRankFile = errors.New("tiktoken: invalid rank file")
+
+// Encoding tokenizes text with a fixed BPE rank table.
+type Encoding struct {
+	ranks   map[string]int
+	decoder map[int]string
+	pattern *regexp.Regexp
+}
+
+// New builds an encoding from a rank table and a pre-tokenization pattern.
+func New(ranks map[string]int, pattern string) (*Encoding, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("tiktoken: compile pattern: %w", err)
+	}
+	decoder := make(map[int]string, len(ranks))
+	for tok, rank := range ranks {
+		decoder[rank] = tok
+	}
+	return &Encoding{ranks: ranks, decoder: decoder, pattern: re}, nil
+}
+
+// Load reads a .tiktoken rank file ("<base64 token> <rank>" per line).
+func Load(r io.Reader, pattern string) (*Encoding, error) {
+	ranks := make(map[string]int)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		tokB64, rankStr, ok := strings.Cut(text, " ")
+		if !ok {
+			return nil, fmt.Errorf("%w: line %d", ErrInvalidRankFile, line)
+		}
+		tok, err := base64.StdEncoding.DecodeString(tokB64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidRankFile, line, err)
+		}
+		rank, err := strconv.Atoi(rankStr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidRankFile, line, err)
+		}
+		ranks[string(tok)] = rank
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("tiktoken: read ranks: %w", err)
+	}
+	return New(ranks, pattern)
+}
+
+// LoadFile reads a .tiktoken rank file from disk.
+func LoadFile(path string, pattern string) (*Encoding, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("tiktoken: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	return Load(f, pattern)
+}
+
+// Encode returns the token ranks for text.
+func (e *Encoding) Encode(text string) []int {
+	var out []int
+	e.each(text, func(piece string) {
+		out = append(out, e.encodePiece(piece)...)
+	})
+	return out
+}
+
+// CountTokens returns len(e.Encode(text)) without allocating the result.
+func (e *Encoding) CountTokens(text string) int {
+	n := 0
+	e.each(text, func(piece string) {
+		if _, ok := e.ranks[piece]; ok {
+			n++
+			return
+		}
+		n += len(e.encodePiece(piece))
+	})
+	return n
+}
+
+// Decode maps token ranks back to text. Unknown ranks are skipped.
+func (e *Encoding) Decode(tokens []int) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteString(e.decoder[t])
+	}
+	return b.String()
+}
+
+// each splits text into pre-tokenization pieces.
+func (e *Encoding) each(text string, fn func(piece string)) {
+	for pos := 0; pos < len(text); {
+		loc := e.pattern.FindStringIndex(text[pos:])
+		if loc == nil || loc[1] == 0 {
+			// The patterns cover every input; guard against custom ones.
+			_, size := utf8.DecodeRuneInString(text[pos:])
+			fn(text[pos : pos+size])
+			pos += size
+			continue
+		}
+		start, end := pos+loc[0], pos+loc[1]
+		if start > pos {
+			fn(text[pos:start])
+		}
+		end = trimTrailingSpace(text, start, end)
+		fn(text[start:end])
+		pos = end
+	}
+}
+
+// trimTrailingSpace emulates the upstream `\s+(?!\S)` alternative: a run of
+// non-newline whitespace followed by a non-space keeps its last character
+// for the next piece (so " word" stays one token).
+func trimTrailingSpace(text string, start, end int) int {
+	if end >= len(text) {
+		return end
+	}
+	next, _ := utf8.DecodeRuneInString(text[end:])
+	if unicode.IsSpace(next) {
+		return end
+	}
+	piece := text[start:end]
+	if strings.ContainsAny(piece, "\r\n") {
+		return end
+	}
+	for _, r := range piece {
+		if !unicode.IsSpace(r) {
+			return end
+		}
+	}
+	_, size := utf8.DecodeLastRuneInString(piece)
+	if size == len(piece) {
+		return end
+	}
+	return end - size
+}
+
+// encodePiece applies byte-pair merges to a single piece.
+func (e *Encoding) encodePiece(piece string) []int {
+	if rank, ok := e.ranks[piece]; ok {
+		return []int{rank}
+	}
+
+	// bounds[i] is the start of part i; the final entry is len(piece).
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+	for len(bounds) > 2 {
+		best, bestRank := -1, math.MaxInt
+		for i := 0; i+2 < len(bounds); i++ {
+			if rank, ok := e.ranks[piece[bounds[i]:bounds[i+2]]]; ok && rank < bestRank {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		bounds = append(bounds[:best+1], bounds[best+2:]...)
+	}
+
+	out := make([]int, 0, len(bounds)-1)
+	for i := 0; i+1 < len(bounds); i++ {
+		part := piece[bounds[i]:bounds[i+1]]
+		if rank, ok := e.ranks[part]; ok {
+			out = append(out, rank)
+		} else {
+			// Rank tables cover every byte; count strays as one token each.
+			out = append(out, -1)
+		}
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package tiktoken
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testRanks builds a .tiktoken file with every single byte plus a few merges.
+func testRanks(merges ...string) string {
+	var b strings.Builder
+	for i := 0; i < 256; i++ {
+		fmt.Fprintf(&b, "%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(i)}), i)
+	}
+	for i, m := range merges {
+		fmt.Fprintf(&b, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(m)), 256+i)
+	}
+	return b.String()
+}
+
+func load(t *testing.T, merges ...string) *Encoding {
+	t.Helper()
+	enc, err := Load(strings.NewReader(testRanks(merges...)), PatternCL100K)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return enc
+}
+
+func TestEncodeMergesByRank(t *testing.T) {
+	enc := load(t, "he", "ll", "hell", " w", " wo")
+
+	got := enc.Encode("hello")
+	want := []int{258, 'o'}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Encode(hello) = %v, want %v", got, want)
+	}
+	if s := enc.Decode(got); s != "hello" {
+		t.Errorf("Decode = %q", s)
+	}
+}
+
+func TestPreTokenizationSplits(t *testing.T) {
+	enc := load(t)
+	var pieces []string
+	enc.each("Hello  world's 12345\n\n  x", func(p string) { pieces = append(pieces, p) })
+	want := []string{"Hello", " ", " world", "'s", " ", "123", "45", "\n\n", " ", " x"}
+	if !reflect.DeepEqual(pieces, want) {
+		t.Errorf("pieces = %q, want %q", pieces, want)
+	}
+}
+
+func TestCountTokensMatchesEncode(t *testing.T) {
+	enc := load(t, "he", "ll", "hell", " w", "or", "ld")
+	for _, s := range []string{"", "hello world", "  trailing  ", "tabs\tand\nnewlines\r\n", "ünïcödé 🙂"} {
+		if got, want := enc.CountTokens(s), len(enc.Encode(s)); got != want {
+			t.Errorf("CountTokens(%q) = %d, want %d", s, got, want)
+		}
+		if got := enc.Decode(enc.Encode(s)); got != s {
+			t.Errorf("round trip %q -> %q", s, got)
+		}
+	}
+}
+
+func TestLoadRejectsBadLines(t *testing.T) {
+	if _, err := Load(strings.NewReader("not-a-rank-line\n"), PatternCL100K); err == nil {
+		t.Error("expected error for malformed line")
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+// Package tokens defines the token counting interface shared by the window
+// assembly helper and TurnMetrics validation.
+//
+// The default Heuristic counter is dependency-free and approximate. For
+// budget decisions that must match a model's real tokenizer, load an
+// encoding from the tiktoken subpackage:
+//
+//	enc, err := tiktoken.LoadFile("cl100k_base.tiktoken", tiktoken.PatternCL100K)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	w, err := window.Assemble(ctx, client, contextID,
+//	    window.WithTokenBudget(100_000),
+//	    window.WithTokenCounter(enc),
+//	)
+package tokens
+
+// Counter counts the tokens a model would see for a piece of text.
+type Counter interface {
+	CountTokens(text string) int
+}
+
+// CounterFunc adapts a function to the Counter interface.
+type CounterFunc func(text string) int
+
+// CountTokens calls f(text).
+func (f CounterFunc) CountTokens(text string) int {
+	return f(text)
+}
+
+// Heuristic estimates tokens from the byte length of the text.
+type Heuristic struct {
+	// BytesPerToken defaults to 4, a reasonable average for English text
+	// and code with BPE tokenizers.
+	BytesPerToken int
+}
+
+// CountTokens returns ceil(len(text) / BytesPerToken).
+func (h Heuristic) CountTokens(text string) int {
+	per := h.BytesPerToken
+	if per <= 0 {
+		per = 4
+	}
+	return (len(text) + per - 1) / per
+}
+
+// Approx is the default heuristic counter.
+var Approx Counter = Heuristic{}
@@ -0,0 +1,123 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
+)
+
+// Transport carries whole protocol frames between the client and a server.
+// The client's request and response handling only sees frames, so new
+// transports (WebSocket for browsers and the gateway tunnel, QUIC for lossy
+// networks) plug in without touching it.
+//
+// The client serializes calls: at most one WriteFrame/ReadFrame exchange is
+// in flight at a time.
+type Transport interface {
+	// WriteFrame sends one frame. h.Length is the payload length.
+	WriteFrame(h wire.Header, payload []byte) error
+
+	// ReadFrame receives the next frame.
+	ReadFrame() (wire.Header, []byte, error)
+
+	// SetDeadline bounds the current exchange; the zero time clears it.
+	SetDeadline(t time.Time) error
+
+	// Close releases the underlying connection and unblocks pending calls.
+	Close() error
+}
+
+// NewStreamTransport frames messages over a byte stream, as the TCP and TLS
+// transports used by Dial and DialTLS do.
+func NewStreamTransport(conn net.Conn) Transport {
+	return &streamTransport{conn: conn}
+}
+
+type streamTransport struct {
+	conn net.Conn
+}
+
+func (t *streamTransport) WriteFrame(h wire.Header, payload []byte) error {
+	_, err := t.conn.Write(append(wire.AppendHeader(make([]byte, 0, wire.HeaderSize+len(payload)), h), payload...))
+	return err
+}
+
+func (t *streamTransport) ReadFrame() (wire.Header, []byte, error) {
+	var buf [wire.HeaderSize]byte
+	if _, err := io.ReadFull(t.conn, buf[:]); err != nil {
+		return wire.Header{}, nil, fmt.Errorf("read header: %w", err)
+	}
+	h, _ := wire.ParseHeader(buf[:])
+
+	payload := make([]byte, h.Length)
+	if _, err := io.ReadFull(t.conn, payload); err != nil {
+		return wire.Header{}, nil, fmt.Errorf("read payload: %w", err)
+	}
+	return h, payload, nil
+}
+
+func (t *streamTransport) SetDeadline(d time.Time) error {
+	return t.conn.SetDeadline(d)
+}
+
+func (t *streamTransport) Close() error {
+	return t.conn.Close()
+}
+
+// DialTransport establishes a session over an already connected transport:
+// it sends HELLO and returns a Client that sends every request through t.
+// Dial, DialTLS and DialWebSocket are shorthands for the built-in
+// transports. On error t is closed.
+func DialTransport(t Transport, opts ...Option) (*Client, error) {
+	options := defaultClientOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return newClient(t, options)
+}
+
+func defaultClientOptions() clientOptions {
+	return clientOptions{
+		dialTimeout:    DefaultDialTimeout,
+		requestTimeout: DefaultRequestTimeout,
+	}
+}
+
+// newClient wraps t and performs the HELLO handshake.
+func newClient(t Transport, options clientOptions) (*Client, error) {
+	client := &Client{
+		transport: t,
+		timeout:   options.requestTimeout,
+		clientTag: options.clientTag,
+
+		payloadBlobThreshold: options.payloadBlobThreshold,
+		verifyFsAttach:       options.verifyFsAttach,
+		hashFirstMin:         options.hashFirstMin,
+		serverProvenance:     options.serverProvenance,
+		titleGenerator:       options.titleGenerator,
+		titleTimeout:         options.titleTimeout,
+		routingKey:           options.routingKey,
+		schemaPolicy:         options.schemaPolicy,
+		spill:                spiller{budget: options.memoryBudget, dir: options.spillDir},
+		usage:                newUsageTracker(),
+		leases:               newLeaseSet(options.leaseMode),
+
+		onConnect:    options.onConnect,
+		onDisconnect: options.onDisconnect,
+	}
+
+	// Send HELLO to establish session
+	if err := client.sendHello(options.clientTag); err != nil {
+		_ = t.Close()
+		return nil, fmt.Errorf("cxdb hello: %w", err)
+	}
+	client.connected()
+
+	return client, nil
+}
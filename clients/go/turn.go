@@ -7,8 +7,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
 	"github.com/zeebo/blake3"
 )
 
@@ -51,6 +53,14 @@ type TurnRecord struct {
 	Compression uint32
 	PayloadHash [32]byte
 	Payload     []byte // Only populated if requested
+
+	// PayloadRef is set when Payload was resolved from an EncodingBlobRef
+	// stub; PayloadHash is then the hash of the stub.
+	PayloadRef *PayloadRef
+
+	// PayloadReader holds the payload instead of Payload when it was read
+	// with GetLastOptions.PayloadReaders.
+	PayloadReader *PayloadReader
 }
 
 // AppendResult contains the result of an append operation.
@@ -59,10 +69,31 @@ type AppendResult struct {
 	TurnID      uint64
 	Depth       uint32
 	PayloadHash [32]byte
+
+	// Flags are the response frame flags.
+	Flags ResponseFlags
 }
 
 // AppendTurn appends a new turn to a context.
 func (c *Client) AppendTurn(ctx context.Context, req *AppendRequest) (*AppendResult, error) {
+	if err := c.leases.check(req.ContextID, req.TypeID); err != nil {
+		return nil, fmt.Errorf("append turn: %w", err)
+	}
+	sent, err := c.externalizePayload(ctx, req, false)
+	if err != nil {
+		return nil, fmt.Errorf("append turn: %w", err)
+	}
+	result, err := c.appendTurn(ctx, sent)
+	if retry, rerr := c.blobFallback(ctx, req, sent, err); rerr != nil {
+		return nil, rerr
+	} else if retry != nil {
+		result, err = c.appendTurn(ctx, retry)
+	}
+	return result, err
+}
+
+// appendTurn sends one APPEND_TURN.
+func (c *Client) appendTurn(ctx context.Context, req *AppendRequest) (*AppendResult, error) {
 	encoding := req.Encoding
 	if encoding == 0 {
 		encoding = EncodingMsgpack
@@ -93,10 +124,11 @@ func (c *Client) AppendTurn(ctx context.Context, req *AppendRequest) (*AppendRes
 		payload.WriteString(req.IdempotencyKey)
 	}
 
-	resp, err := c.sendRequest(ctx, msgAppend, payload.Bytes())
+	resp, err := c.sendRequest(ctx, wire.MsgAppend, payload.Bytes())
 	if err != nil {
 		return nil, fmt.Errorf("append turn: %w", err)
 	}
+	c.usage.record(req.ContextID, ContextUsage{Appends: 1, BytesAppended: uint64(frameHeaderSize + payload.Len())})
 
 	if len(resp.payload) < 52 {
 		return nil, fmt.Errorf("%w: append response too short (%d bytes)", ErrInvalidResponse, len(resp.payload))
@@ -106,6 +138,7 @@ func (c *Client) AppendTurn(ctx context.Context, req *AppendRequest) (*AppendRes
 		ContextID: binary.LittleEndian.Uint64(resp.payload[0:8]),
 		TurnID:    binary.LittleEndian.Uint64(resp.payload[8:16]),
 		Depth:     binary.LittleEndian.Uint32(resp.payload[16:20]),
+		Flags:     ResponseFlags(resp.flags),
 	}
 	copy(result.PayloadHash[:], resp.payload[20:52])
 
@@ -119,30 +152,102 @@ type GetLastOptions struct {
 
 	// IncludePayload controls whether to include turn payloads.
 	IncludePayload bool
+
+	// KeepPayloadRefs returns EncodingBlobRef stubs as stored instead of
+	// fetching the referenced blobs.
+	KeepPayloadRefs bool
+
+	// IfNoneMatch makes the read conditional, like an HTTP If-None-Match
+	// with the head turn ID as the ETag. Pass the TurnID of the newest turn
+	// of an earlier read: if the head has not moved, the server sends no
+	// turns and GetLast returns ErrNotModified. Zero reads unconditionally.
+	IfNoneMatch uint64
+
+	// PayloadReaders returns payloads as TurnRecord.PayloadReader instead
+	// of Payload, held within the client's memory budget and spilled to
+	// temporary files beyond it (see WithMemoryBudget). Close the readers
+	// with TurnPage.Close or CloseTurns.
+	PayloadReaders bool
+}
+
+// TurnPage is a GetLastPage result.
+type TurnPage struct {
+	// Turns are ordered oldest to newest.
+	Turns []TurnRecord
+
+	// Flags are the response frame flags. Flags.Truncated() means the
+	// server returned fewer turns than requested because of its own limits.
+	Flags ResponseFlags
 }
 
 // GetLast retrieves the last N turns from a context, walking back from the head.
+// With opts.IfNoneMatch it returns ErrNotModified if the head is unchanged.
 func (c *Client) GetLast(ctx context.Context, contextID uint64, opts GetLastOptions) ([]TurnRecord, error) {
+	page, err := c.GetLastPage(ctx, contextID, opts)
+	if err != nil {
+		return nil, err
+	}
+	return page.Turns, nil
+}
+
+// GetLastPage is GetLast with the response flags.
+func (c *Client) GetLastPage(ctx context.Context, contextID uint64, opts GetLastOptions) (*TurnPage, error) {
 	limit := opts.Limit
 	if limit == 0 {
 		limit = 10
 	}
 
-	payload := &bytes.Buffer{}
-	_ = binary.Write(payload, binary.LittleEndian, contextID)
-	_ = binary.Write(payload, binary.LittleEndian, limit)
-	var includePayload uint32
-	if opts.IncludePayload {
-		includePayload = 1
+	send := func(conditional bool) (*frame, error) {
+		payload := &bytes.Buffer{}
+		_ = binary.Write(payload, binary.LittleEndian, contextID)
+		_ = binary.Write(payload, binary.LittleEndian, limit)
+		var includePayload uint32
+		if opts.IncludePayload {
+			includePayload = 1
+		}
+		_ = binary.Write(payload, binary.LittleEndian, includePayload)
+		var flags uint16
+		if conditional {
+			flags = RequestFlagIfHeadChanged
+			_ = binary.Write(payload, binary.LittleEndian, opts.IfNoneMatch)
+		}
+		return c.sendRequestWithFlags(ctx, wire.MsgGetLast, flags, payload.Bytes())
 	}
-	_ = binary.Write(payload, binary.LittleEndian, includePayload)
 
-	resp, err := c.sendRequest(ctx, msgGetLast, payload.Bytes())
+	// Servers that reject the flag get plain reads, compared below.
+	conditional := opts.IfNoneMatch != 0 && !c.flagRejected(RequestFlagIfHeadChanged)
+	resp, err := send(conditional)
+	if conditional && errors.Is(err, ErrUnsupportedFlags) {
+		resp, err = send(false)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("get last: %w", err)
 	}
+	c.usage.record(contextID, ContextUsage{Reads: 1, BytesRead: uint64(frameHeaderSize + len(resp.payload))})
+	if ResponseFlags(resp.flags).NotModified() {
+		return nil, ErrNotModified
+	}
 
-	return parseTurnRecords(resp.payload)
+	turns, err := parseTurnRecords(resp.payload)
+	if err != nil {
+		return nil, err
+	}
+	// Servers without conditional reads ignore or reject the flag and send
+	// the turns; an unchanged head is still reported the same way.
+	if opts.IfNoneMatch != 0 && len(turns) > 0 && turns[len(turns)-1].TurnID == opts.IfNoneMatch {
+		return nil, ErrNotModified
+	}
+	if opts.IncludePayload && !opts.KeepPayloadRefs {
+		if err := c.resolvePayloads(WithUsageContext(ctx, contextID), turns); err != nil {
+			return nil, fmt.Errorf("get last: %w", err)
+		}
+	}
+	if opts.IncludePayload && opts.PayloadReaders {
+		if err := c.spill.holdPayloads(turns); err != nil {
+			return nil, fmt.Errorf("get last: %w", err)
+		}
+	}
+	return &TurnPage{Turns: turns, Flags: ResponseFlags(resp.flags)}, nil
 }
 
 func parseTurnRecords(data []byte) ([]TurnRecord, error) {
@@ -0,0 +1,149 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// =============================================================================
+// Annotation
+// =============================================================================
+
+// Annotation attaches derived data to an earlier turn, identified by
+// TargetTurnID. Annotations may live in the same context as their target or
+// in a separate sidecar context.
+type Annotation struct {
+	// TargetTurnID is the turn this annotation describes.
+	TargetTurnID uint64 `msgpack:"1" json:"target_turn_id"`
+
+	// TargetContextID is the target's context when the annotation is stored
+	// in a different context (0 means the annotation's own context).
+	TargetContextID uint64 `msgpack:"2" json:"target_context_id,omitempty"`
+
+	// Note is free-form text (e.g., a reviewer comment).
+	Note string `msgpack:"3" json:"note,omitempty"`
+
+	// Labels are arbitrary tags for organization/filtering.
+	Labels []string `msgpack:"4" json:"labels,omitempty"`
+
+	// Embeddings are vector representations of the target turn's text.
+	Embeddings []Embedding `msgpack:"5" json:"embeddings,omitempty"`
+}
+
+// VectorEncodingF32LE is the only supported blob encoding for embedding
+// vectors: little-endian IEEE-754 float32 values, packed.
+const VectorEncodingF32LE = "f32le"
+
+// Embedding is one embedding vector for a turn. Small vectors are stored
+// inline in Vector; large ones can be uploaded as a blob and referenced by
+// BlobHash instead.
+type Embedding struct {
+	// Model identifies the embedding model (e.g., "text-embedding-3-small").
+	Model string `msgpack:"1" json:"model"`
+
+	// Dims is the vector dimensionality.
+	Dims int `msgpack:"2" json:"dims"`
+
+	// Vector holds the values inline. Empty when BlobHash is set.
+	Vector []float32 `msgpack:"3" json:"vector,omitempty"`
+
+	// BlobHash is the hex BLAKE3-256 hash of the vector stored as a blob.
+	BlobHash string `msgpack:"4" json:"blob_hash,omitempty"`
+
+	// Encoding describes the blob layout; always VectorEncodingF32LE.
+	Encoding string `msgpack:"5" json:"encoding,omitempty"`
+
+	// SourceHash is the hex BLAKE3-256 hash of the embedded text, so stale
+	// embeddings can be detected if the extraction rules change.
+	SourceHash string `msgpack:"6" json:"source_hash,omitempty"`
+}
+
+// EncodeVector packs v as VectorEncodingF32LE bytes.
+func EncodeVector(v []float32) []byte {
+	out := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(out[4*i:], math.Float32bits(f))
+	}
+	return out
+}
+
+// DecodeVector unpacks VectorEncodingF32LE bytes.
+func DecodeVector(data []byte) ([]float32, error) {
+	if len(data)%4 != 0 {
+		return nil, fmt.Errorf("types: vector blob length %d is not a multiple of 4", len(data))
+	}
+	out := make([]float32, len(data)/4)
+	for i := range out {
+		out[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+	}
+	return out, nil
+}
+
+// =============================================================================
+// Annotation Builders
+// =============================================================================
+
+// NewEmbeddingAnnotation creates an annotation item carrying one inline embedding.
+func NewEmbeddingAnnotation(targetTurnID uint64, model string, vector []float32) *ConversationItem {
+	return BuildAnnotation(targetTurnID).
+		WithEmbedding(Embedding{Model: model, Dims: len(vector), Vector: vector}).
+		Build()
+}
+
+// AnnotationBuilder provides fluent configuration for annotation items.
+type AnnotationBuilder struct {
+	item *ConversationItem
+}
+
+// BuildAnnotation starts building an annotation for the given turn.
+func BuildAnnotation(targetTurnID uint64) *AnnotationBuilder {
+	return &AnnotationBuilder{
+		item: &ConversationItem{
+			ItemType:  ItemTypeAnnotation,
+			Status:    ItemStatusComplete,
+			Timestamp: Now(),
+			Annotation: &Annotation{
+				TargetTurnID: targetTurnID,
+			},
+		},
+	}
+}
+
+// WithTargetContext records the target's context for sidecar annotations.
+func (b *AnnotationBuilder) WithTargetContext(contextID uint64) *AnnotationBuilder {
+	b.item.Annotation.TargetContextID = contextID
+	return b
+}
+
+// WithNote sets the annotation note.
+func (b *AnnotationBuilder) WithNote(note string) *AnnotationBuilder {
+	b.item.Annotation.Note = note
+	return b
+}
+
+// WithLabels appends labels.
+func (b *AnnotationBuilder) WithLabels(labels ...string) *AnnotationBuilder {
+	b.item.Annotation.Labels = append(b.item.Annotation.Labels, labels...)
+	return b
+}
+
+// WithEmbedding appends an embedding.
+func (b *AnnotationBuilder) WithEmbedding(e Embedding) *AnnotationBuilder {
+	b.item.Annotation.Embeddings = append(b.item.Annotation.Embeddings, e)
+	return b
+}
+
+// WithID sets the item ID.
+func (b *AnnotationBuilder) WithID(id string) *AnnotationBuilder {
+	b.item.ID = id
+	return b
+}
+
+// Build returns the configured conversation item.
+func (b *AnnotationBuilder) Build() *ConversationItem {
+	return b.item
+}
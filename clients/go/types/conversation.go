@@ -50,6 +50,10 @@ const (
 	// ItemTypeHandoff indicates an agent-to-agent handoff event.
 	ItemTypeHandoff ItemType = "handoff"
 
+	// ItemTypeAnnotation attaches derived data (notes, labels, embeddings) to
+	// an earlier turn. Annotations are not part of the dialogue itself.
+	ItemTypeAnnotation ItemType = "annotation"
+
 	// Legacy types - kept for backward compatibility
 	// New code should use ItemTypeAssistantTurn instead of these flat types.
 
@@ -151,10 +155,11 @@ type ConversationItem struct {
 	ID string `msgpack:"4" json:"id,omitempty"`
 
 	// Primary variants (v2 schema)
-	UserInput *UserInput      `msgpack:"10" json:"user_input,omitempty"`
-	Turn      *AssistantTurn  `msgpack:"11" json:"turn,omitempty"`
-	System    *SystemMessage  `msgpack:"12" json:"system,omitempty"`
-	Handoff   *HandoffInfo    `msgpack:"13" json:"handoff,omitempty"`
+	UserInput  *UserInput     `msgpack:"10" json:"user_input,omitempty"`
+	Turn       *AssistantTurn `msgpack:"11" json:"turn,omitempty"`
+	System     *SystemMessage `msgpack:"12" json:"system,omitempty"`
+	Handoff    *HandoffInfo   `msgpack:"13" json:"handoff,omitempty"`
+	Annotation *Annotation    `msgpack:"14" json:"annotation,omitempty"`
 
 	// Legacy variants (v1 schema - kept for backward compatibility)
 	Assistant  *Assistant  `msgpack:"20" json:"assistant,omitempty"`
@@ -0,0 +1,41 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"bytes"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/zeebo/blake3"
+)
+
+// CanonicalEncode encodes item as the msgpack payload AppendConversationItem
+// sends: numeric field tags, omitted empty fields, and map keys in sorted
+// order, so equal items always encode to the same bytes.
+func CanonicalEncode(item *ConversationItem) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetSortMapKeys(true)
+	if err := enc.Encode(item); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// CanonicalHash returns the BLAKE3-256 hash of CanonicalEncode(item): the
+// PayloadHash the server reports when item is appended as is. Producers can
+// use it as an idempotency key, to dedupe items locally, or to check a
+// returned PayloadHash.
+//
+// The client changes some items before encoding them, and the hash then
+// differs: titles added by WithTitleGenerator, provenance enriched by
+// WithServerProvenance, and payloads over WithPayloadBlobThreshold, which
+// are appended as blob references.
+func CanonicalHash(item *ConversationItem) ([32]byte, error) {
+	data, err := CanonicalEncode(item)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return blake3.Sum256(data), nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestCanonicalHashGolden pins the canonical encoding of goldenItem. Other
+// SDKs can check their encoders against the same hash.
+func TestCanonicalHashGolden(t *testing.T) {
+	sum, err := CanonicalHash(goldenItem())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []byte(hex.EncodeToString(sum[:]))
+	path := filepath.Join("testdata", "conversation_item.blake3")
+	if *updateGolden {
+		if err := os.WriteFile(path, append(got, '\n'), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, bytes.TrimSpace(want)) {
+		t.Errorf("CanonicalHash drifted from %s (run with -update if intended): %s", path, got)
+	}
+}
+
+func TestCanonicalHashIgnoresMapOrder(t *testing.T) {
+	a, b := goldenItem(), goldenItem()
+	a.ContextMetadata.Custom = map[string]string{"a": "1", "b": "2", "c": "3", "d": "4"}
+	b.ContextMetadata.Custom = map[string]string{"d": "4", "c": "3", "b": "2", "a": "1"}
+	first, err := CanonicalHash(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		if sum, _ := CanonicalHash(b); sum != first {
+			t.Fatal("equal items hash differently")
+		}
+	}
+
+	b.ContextMetadata.Custom["a"] = "changed"
+	if sum, _ := CanonicalHash(b); sum == first {
+		t.Error("different items hash the same")
+	}
+}
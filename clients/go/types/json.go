@@ -0,0 +1,191 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// =============================================================================
+// JSON
+// =============================================================================
+
+// EncodeJSON encodes v in the documented JSON schema: the snake_case keys
+// of the json struct tags in field order, empty optional fields omitted,
+// and strings without HTML escaping, as other SDKs write them. There is no
+// trailing newline. The golden files in testdata pin the output.
+func EncodeJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// DecodeJSON decodes JSON into v, a pointer to one of this package's types,
+// accepting the key conventions frontends and other SDKs send:
+//
+//   - snake_case, as documented ("item_type")
+//   - camelCase or PascalCase ("itemType", "ItemType")
+//   - msgpack tag numbers ("1"), as in numeric-tag maps
+//
+// Conventions may be mixed, also across nesting levels. When a field is
+// given under several keys the documented one wins. Unknown keys are
+// ignored, as with encoding/json.
+func DecodeJSON(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("types: DecodeJSON needs a non-nil pointer, got %T", v)
+	}
+	normalized, err := normalizeJSON(data, rv.Type().Elem())
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(normalized, v)
+}
+
+// conversationItemJSON has ConversationItem's fields without its methods.
+type conversationItemJSON ConversationItem
+
+// MarshalJSON encodes item in the documented schema. json.Marshal escapes
+// <, > and & in the result again; EncodeJSON gives the exact bytes.
+func (item ConversationItem) MarshalJSON() ([]byte, error) {
+	return EncodeJSON((*conversationItemJSON)(&item))
+}
+
+// UnmarshalJSON decodes item with the key tolerance of DecodeJSON.
+func (item *ConversationItem) UnmarshalJSON(data []byte) error {
+	normalized, err := normalizeJSON(data, reflect.TypeOf(*item))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(normalized, (*conversationItemJSON)(item))
+}
+
+// normalizeJSON rewrites the object keys in data to the documented keys of
+// t, recursively.
+func normalizeJSON(data []byte, t reflect.Type) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw any
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+	return json.Marshal(normalizeValue(raw, t))
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+func normalizeValue(v any, t reflect.Type) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	// Types with their own decoding (Millis, Bytes) see their input as is.
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) && t != reflect.TypeOf(ConversationItem{}) {
+		return v
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return v
+		}
+		fields := jsonFieldsOf(t)
+		out := make(map[string]any, len(obj))
+		for key, val := range obj {
+			f, ok := fields.lookup(key)
+			if !ok {
+				if _, taken := out[key]; !taken {
+					out[key] = val
+				}
+				continue
+			}
+			if _, taken := out[f.name]; taken && key != f.name {
+				continue
+			}
+			out[f.name] = normalizeValue(val, f.typ)
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		arr, ok := v.([]any)
+		if !ok {
+			return v
+		}
+		for i := range arr {
+			arr[i] = normalizeValue(arr[i], t.Elem())
+		}
+		return arr
+	case reflect.Map:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return v
+		}
+		for key, val := range obj {
+			obj[key] = normalizeValue(val, t.Elem())
+		}
+		return obj
+	}
+	return v
+}
+
+type jsonField struct {
+	name string // documented key
+	typ  reflect.Type
+}
+
+// jsonFields indexes a struct's fields by folded name and by msgpack tag.
+type jsonFields struct {
+	byFold map[string]jsonField
+	byTag  map[string]jsonField
+}
+
+func (f *jsonFields) lookup(key string) (jsonField, bool) {
+	if field, ok := f.byTag[key]; ok {
+		return field, true
+	}
+	field, ok := f.byFold[foldJSONKey(key)]
+	return field, ok
+}
+
+// foldJSONKey maps snake_case, camelCase and PascalCase spellings of a key
+// to the same string.
+func foldJSONKey(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", ""))
+}
+
+var jsonFieldCache sync.Map // reflect.Type -> *jsonFields
+
+func jsonFieldsOf(t reflect.Type) *jsonFields {
+	if cached, ok := jsonFieldCache.Load(t); ok {
+		return cached.(*jsonFields)
+	}
+	fields := &jsonFields{byFold: make(map[string]jsonField), byTag: make(map[string]jsonField)}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() || sf.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		field := jsonField{name: name, typ: sf.Type}
+		fields.byFold[foldJSONKey(name)] = field
+		if tag, _, _ := strings.Cut(sf.Tag.Get("msgpack"), ","); tag != "" && tag != "-" {
+			fields.byTag[tag] = field
+		}
+	}
+	jsonFieldCache.Store(t, fields)
+	return fields
+}
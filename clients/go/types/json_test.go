@@ -0,0 +1,105 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite testdata golden files")
+
+// goldenItem exercises nesting, pointers, slices, maps and characters that
+// encoding/json would HTML-escape.
+func goldenItem() *ConversationItem {
+	exit := 0
+	item := BuildAssistantTurn("Listing <files> & dirs").
+		WithID("turn-7").
+		WithReasoning("need ls").
+		WithMetrics(120, 40).
+		WithToolCall(BuildToolCallItem("call-1", "shell", `{"cmd":"ls"}`).
+			WithStatus(ToolCallStatusComplete).
+			WithResult("a.txt\nb.txt", &exit).
+			WithDuration(15).
+			Build()).
+		WithContextMetadata(&ContextMetadata{
+			ClientTag:  "golden",
+			Title:      "Golden",
+			Labels:     []string{"demo"},
+			Custom:     map[string]string{"team": "core"},
+			Provenance: &Provenance{ServiceName: "agent", OnBehalfOf: "jay"},
+		}).
+		Build()
+	item.Timestamp = 1700000000000
+	return item
+}
+
+func TestConversationItemJSONGolden(t *testing.T) {
+	got, err := EncodeJSON(goldenItem())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join("testdata", "conversation_item.json")
+	if *updateGolden {
+		if err := os.WriteFile(path, append(got, '\n'), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, bytes.TrimSuffix(want, []byte("\n"))) {
+		t.Errorf("EncodeJSON drifted from %s (run with -update if intended):\n%s", path, got)
+	}
+}
+
+func TestConversationItemJSONTolerance(t *testing.T) {
+	want := goldenItem()
+	for _, name := range []string{"conversation_item.json", "conversation_item_camel.json", "conversation_item_tags.json"} {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var viaUnmarshal ConversationItem
+		if err := json.Unmarshal(data, &viaUnmarshal); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var viaDecode ConversationItem
+		if err := DecodeJSON(data, &viaDecode); err != nil {
+			t.Fatalf("%s: DecodeJSON: %v", name, err)
+		}
+		if !reflect.DeepEqual(&viaUnmarshal, want) || !reflect.DeepEqual(&viaDecode, want) {
+			t.Errorf("%s decoded to\n%+v\nwant\n%+v", name, viaUnmarshal, *want)
+		}
+	}
+}
+
+func TestDecodeJSONPrefersDocumentedKey(t *testing.T) {
+	var item ConversationItem
+	data := []byte(`{"itemType":"system","item_type":"user_input","1":"handoff","userInput":{"2":["a.go"],"text":"hi"}}`)
+	if err := json.Unmarshal(data, &item); err != nil {
+		t.Fatal(err)
+	}
+	if item.ItemType != ItemTypeUserInput || item.UserInput == nil ||
+		item.UserInput.Text != "hi" || len(item.UserInput.Files) != 1 {
+		t.Errorf("decoded %+v", item)
+	}
+
+	var p Provenance
+	if err := DecodeJSON([]byte(`{"serviceName":"agent","ProcessPID":42}`), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.ServiceName != "agent" || p.ProcessPID != 42 {
+		t.Errorf("provenance %+v", p)
+	}
+	if err := DecodeJSON([]byte(`{}`), p); err == nil {
+		t.Error("DecodeJSON accepted a non-pointer")
+	}
+}
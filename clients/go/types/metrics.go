@@ -0,0 +1,54 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/strongdm/ai-cxdb/clients/go/tokens"
+)
+
+// ErrInvalidMetrics is returned by TurnMetrics.Validate.
+var ErrInvalidMetrics = errors.New("types: invalid turn metrics")
+
+// DefaultMetricsTolerance is the fraction by which a reported output token
+// count may fall short of an independent count before Validate rejects it.
+// Tokenizers differ between models, so the check is deliberately loose.
+const DefaultMetricsTolerance = 0.25
+
+// Validate checks that the metrics are internally consistent: counts are
+// non-negative and TotalTokens, when set, equals InputTokens + OutputTokens.
+//
+// If counter is non-nil, OutputTokens is also compared against counter's
+// count of the turn's generated content (text, reasoning, and tool call
+// arguments); a report more than DefaultMetricsTolerance below that count
+// is an error. Pass a tiktoken encoding for the turn's model to get a
+// meaningful comparison.
+func (m *TurnMetrics) Validate(turn *AssistantTurn, counter tokens.Counter) error {
+	if m.InputTokens < 0 || m.OutputTokens < 0 || m.TotalTokens < 0 {
+		return fmt.Errorf("%w: negative token count", ErrInvalidMetrics)
+	}
+	if m.CachedTokens != nil && (*m.CachedTokens < 0 || *m.CachedTokens > m.InputTokens) {
+		return fmt.Errorf("%w: cached_tokens %d outside [0, input_tokens=%d]", ErrInvalidMetrics, *m.CachedTokens, m.InputTokens)
+	}
+	if m.TotalTokens != 0 && m.TotalTokens != m.InputTokens+m.OutputTokens {
+		return fmt.Errorf("%w: total_tokens %d != input_tokens %d + output_tokens %d",
+			ErrInvalidMetrics, m.TotalTokens, m.InputTokens, m.OutputTokens)
+	}
+	if counter == nil || turn == nil {
+		return nil
+	}
+
+	counted := counter.CountTokens(turn.Text) + counter.CountTokens(turn.Reasoning)
+	for _, tc := range turn.ToolCalls {
+		counted += counter.CountTokens(tc.Name) + counter.CountTokens(tc.Args)
+	}
+	minimum := int64(float64(counted) * (1 - DefaultMetricsTolerance))
+	if m.OutputTokens < minimum {
+		return fmt.Errorf("%w: output_tokens %d below %d counted from turn content",
+			ErrInvalidMetrics, m.OutputTokens, counted)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/strongdm/ai-cxdb/clients/go/tokens"
+)
+
+func TestTurnMetricsValidate(t *testing.T) {
+	words := tokens.CounterFunc(func(s string) int { return len(strings.Fields(s)) })
+	turn := &AssistantTurn{Text: "one two three four", Reasoning: "five six seven eight"}
+	cached := int64(50)
+	tooMuchCache := int64(500)
+
+	tests := []struct {
+		name    string
+		metrics TurnMetrics
+		counter tokens.Counter
+		wantErr bool
+	}{
+		{"consistent", TurnMetrics{InputTokens: 100, OutputTokens: 8, TotalTokens: 108}, nil, false},
+		{"total unset", TurnMetrics{InputTokens: 100, OutputTokens: 8}, nil, false},
+		{"bad total", TurnMetrics{InputTokens: 100, OutputTokens: 8, TotalTokens: 100}, nil, true},
+		{"negative", TurnMetrics{InputTokens: -1}, nil, true},
+		{"cached ok", TurnMetrics{InputTokens: 100, CachedTokens: &cached}, nil, false},
+		{"cached exceeds input", TurnMetrics{InputTokens: 100, CachedTokens: &tooMuchCache}, nil, true},
+		{"matches counter", TurnMetrics{InputTokens: 10, OutputTokens: 8}, words, false},
+		{"within tolerance", TurnMetrics{InputTokens: 10, OutputTokens: 6}, words, false},
+		{"undercounted", TurnMetrics{InputTokens: 10, OutputTokens: 2}, words, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.metrics.Validate(turn, tt.counter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidMetrics) {
+				t.Errorf("error %v does not wrap ErrInvalidMetrics", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import "strconv"
+
+// MissingFields returns the tag paths of required fields that are unset
+// for the item's variant, e.g. "12.1" for a system message without a kind.
+// It implements cxdb.RequiredFieldChecker for strict decoding.
+func (c *ConversationItem) MissingFields() []string {
+	if c.ItemType == "" {
+		return []string{"1"}
+	}
+
+	var missing []string
+	require := func(ok bool, path string) {
+		if !ok {
+			missing = append(missing, path)
+		}
+	}
+
+	switch c.ItemType {
+	case ItemTypeUserInput:
+		require(c.UserInput != nil, "10")
+	case ItemTypeAssistantTurn:
+		require(c.Turn != nil, "11")
+		if c.Turn != nil {
+			for i, tc := range c.Turn.ToolCalls {
+				prefix := "11.2[" + strconv.Itoa(i) + "]"
+				require(tc.ID != "", prefix+".1")
+				require(tc.Name != "", prefix+".2")
+			}
+		}
+	case ItemTypeSystem:
+		require(c.System != nil, "12")
+		if c.System != nil {
+			require(c.System.Kind != "", "12.1")
+		}
+	case ItemTypeHandoff:
+		require(c.Handoff != nil, "13")
+		if c.Handoff != nil {
+			require(c.Handoff.FromAgent != "", "13.1")
+			require(c.Handoff.ToAgent != "", "13.2")
+		}
+	case ItemTypeAnnotation:
+		require(c.Annotation != nil, "14")
+		if c.Annotation != nil {
+			require(c.Annotation.TargetTurnID != 0, "14.1")
+		}
+	case ItemTypeAssistant:
+		require(c.Assistant != nil, "20")
+	case ItemTypeToolCall:
+		require(c.ToolCall != nil, "21")
+		if c.ToolCall != nil {
+			require(c.ToolCall.CallID != "", "21.1")
+			require(c.ToolCall.Name != "", "21.2")
+		}
+	case ItemTypeToolResult:
+		require(c.ToolResult != nil, "22")
+		if c.ToolResult != nil {
+			require(c.ToolResult.CallID != "", "22.1")
+		}
+	}
+	return missing
+}
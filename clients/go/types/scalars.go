@@ -0,0 +1,229 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+)
+
+// =============================================================================
+// Millis
+// =============================================================================
+
+// Millis is a timestamp in Unix milliseconds, the canonical time
+// representation in CXDB payloads (registry semantic "unix_ms").
+//
+// It encodes as a msgpack integer in the smallest width that holds it, as
+// the Rust client does, and as a JSON number. Decoding also accepts msgpack
+// timestamp extensions, floats, and RFC 3339 strings so that payloads from
+// less careful writers still convert.
+type Millis int64
+
+// MillisOf converts t to Millis. The zero time converts to 0.
+func MillisOf(t time.Time) Millis {
+	if t.IsZero() {
+		return 0
+	}
+	return Millis(t.UnixMilli())
+}
+
+// NowMillis returns the current time as Millis.
+func NowMillis() Millis {
+	return Millis(time.Now().UnixMilli())
+}
+
+// Time converts m to a UTC time. 0 converts to the zero time.
+func (m Millis) Time() time.Time {
+	if m == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(int64(m)).UTC()
+}
+
+// IsZero reports whether m is unset.
+func (m Millis) IsZero() bool {
+	return m == 0
+}
+
+// String formats m as RFC 3339 with millisecond precision, matching the
+// gateway's ISO rendering of unix_ms fields.
+func (m Millis) String() string {
+	return m.Time().Format("2006-01-02T15:04:05.000Z07:00")
+}
+
+// EncodeMsgpack implements msgpack.CustomEncoder.
+func (m Millis) EncodeMsgpack(enc *msgpack.Encoder) error {
+	return enc.EncodeInt(int64(m))
+}
+
+// DecodeMsgpack implements msgpack.CustomDecoder.
+func (m *Millis) DecodeMsgpack(dec *msgpack.Decoder) error {
+	c, err := dec.PeekCode()
+	if err != nil {
+		return err
+	}
+	switch {
+	case msgpcode.IsExt(c) || msgpcode.IsString(c):
+		t, err := dec.DecodeTime()
+		if err != nil {
+			return fmt.Errorf("types: decode millis: %w", err)
+		}
+		*m = MillisOf(t)
+	case c == msgpcode.Float || c == msgpcode.Double:
+		f, err := dec.DecodeFloat64()
+		if err != nil {
+			return err
+		}
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Errorf("types: decode millis: %v is not a timestamp", f)
+		}
+		*m = Millis(f)
+	default:
+		n, err := dec.DecodeInt64()
+		if err != nil {
+			return fmt.Errorf("types: decode millis: %w", err)
+		}
+		*m = Millis(n)
+	}
+	return nil
+}
+
+// MarshalJSON encodes m as a JSON number.
+func (m Millis) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, int64(m), 10), nil
+}
+
+// UnmarshalJSON accepts a number, a numeric string, or an RFC 3339 string.
+func (m *Millis) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			*m = Millis(n)
+			return nil
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return fmt.Errorf("types: millis %q is neither unix milliseconds nor RFC 3339", s)
+		}
+		*m = MillisOf(t)
+		return nil
+	}
+	var f json.Number
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	if n, err := f.Int64(); err == nil {
+		*m = Millis(n)
+		return nil
+	}
+	v, err := f.Float64()
+	if err != nil {
+		return fmt.Errorf("types: millis %s is not a number", data)
+	}
+	*m = Millis(v)
+	return nil
+}
+
+// =============================================================================
+// Bytes
+// =============================================================================
+
+// Bytes is binary data, encoded as msgpack bin and as a standard base64
+// JSON string (the gateway's default bytes rendering).
+//
+// Decoding also accepts msgpack str and arrays of integers 0-255, which is
+// how serializers without a native bytes type (including serde without
+// serde_bytes) write byte slices.
+type Bytes []byte
+
+// String returns the standard base64 encoding of b.
+func (b Bytes) String() string {
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// Equal reports whether b and other hold the same bytes.
+func (b Bytes) Equal(other Bytes) bool {
+	return bytes.Equal(b, other)
+}
+
+// EncodeMsgpack implements msgpack.CustomEncoder.
+func (b Bytes) EncodeMsgpack(enc *msgpack.Encoder) error {
+	return enc.EncodeBytes(b)
+}
+
+// DecodeMsgpack implements msgpack.CustomDecoder.
+func (b *Bytes) DecodeMsgpack(dec *msgpack.Decoder) error {
+	c, err := dec.PeekCode()
+	if err != nil {
+		return err
+	}
+	if !msgpcode.IsFixedArray(c) && c != msgpcode.Array16 && c != msgpcode.Array32 {
+		data, err := dec.DecodeBytes()
+		if err != nil {
+			return fmt.Errorf("types: decode bytes: %w", err)
+		}
+		*b = data
+		return nil
+	}
+
+	n, err := dec.DecodeArrayLen()
+	if err != nil {
+		return err
+	}
+	out := make([]byte, n)
+	for i := range out {
+		v, err := dec.DecodeInt64()
+		if err != nil {
+			return fmt.Errorf("types: decode bytes: element %d: %w", i, err)
+		}
+		if v < 0 || v > math.MaxUint8 {
+			return fmt.Errorf("types: decode bytes: element %d is %d, not a byte", i, v)
+		}
+		out[i] = byte(v)
+	}
+	*b = out
+	return nil
+}
+
+// MarshalJSON encodes b as a standard base64 string, or null if b is nil.
+func (b Bytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]byte(b))
+}
+
+// UnmarshalJSON accepts a standard base64 string (padded or not) or null.
+func (b *Bytes) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*b = nil
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("types: bytes must be a base64 string: %w", err)
+	}
+	enc := base64.StdEncoding
+	if len(s)%4 != 0 {
+		enc = base64.RawStdEncoding
+	}
+	decoded, err := enc.DecodeString(s)
+	if err != nil {
+		return fmt.Errorf("types: bytes: %w", err)
+	}
+	*b = decoded
+	return nil
+}
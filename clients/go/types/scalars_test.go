@@ -0,0 +1,170 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type scalarPayload struct {
+	At   Millis `msgpack:"1" json:"at"`
+	Data Bytes  `msgpack:"2" json:"data"`
+}
+
+func TestMillisConversions(t *testing.T) {
+	ts := time.Date(2025, 1, 30, 11, 43, 20, 0, time.UTC)
+	m := MillisOf(ts)
+	if m != 1738237400000 {
+		t.Fatalf("MillisOf = %d", m)
+	}
+	if !m.Time().Equal(ts) {
+		t.Fatalf("Time() = %v, want %v", m.Time(), ts)
+	}
+	if got := m.String(); got != "2025-01-30T11:43:20.000Z" {
+		t.Fatalf("String() = %q", got)
+	}
+	if MillisOf(time.Time{}) != 0 || !Millis(0).Time().IsZero() {
+		t.Fatal("zero time must round-trip through 0")
+	}
+}
+
+func TestMillisMsgpackEncoding(t *testing.T) {
+	tests := []struct {
+		m    Millis
+		want string
+	}{
+		{0, "00"},
+		{100, "64"},
+		{1706615000000, "cf0000018d5a2e4bc0"},
+		{-1, "ff"},
+	}
+	for _, tt := range tests {
+		data, err := msgpack.Marshal(tt.m)
+		if err != nil {
+			t.Fatalf("marshal %d: %v", tt.m, err)
+		}
+		if got := hex.EncodeToString(data); got != tt.want {
+			t.Errorf("Millis(%d) = %s, want %s", tt.m, got, tt.want)
+		}
+		var back Millis
+		if err := msgpack.Unmarshal(data, &back); err != nil || back != tt.m {
+			t.Errorf("round trip %d = %d, %v", tt.m, back, err)
+		}
+	}
+}
+
+func TestMillisMsgpackTolerance(t *testing.T) {
+	want := Millis(1706615000000)
+	inputs := map[string]any{
+		"int64":     int64(1706615000000),
+		"float":     float64(1706615000000),
+		"timestamp": want.Time(),
+		"rfc3339":   "2024-01-30T11:43:20Z",
+	}
+	for name, in := range inputs {
+		data, err := msgpack.Marshal(in)
+		if err != nil {
+			t.Fatalf("%s: marshal: %v", name, err)
+		}
+		var got Millis
+		if err := msgpack.Unmarshal(data, &got); err != nil {
+			t.Fatalf("%s: unmarshal: %v", name, err)
+		}
+		if got != want {
+			t.Errorf("%s: got %d, want %d", name, got, want)
+		}
+	}
+}
+
+func TestMillisJSON(t *testing.T) {
+	data, err := json.Marshal(Millis(1706615000000))
+	if err != nil || string(data) != "1706615000000" {
+		t.Fatalf("marshal = %s, %v", data, err)
+	}
+	for _, in := range []string{`1706615000000`, `"1706615000000"`, `"2024-01-30T11:43:20.000Z"`, `1.706615e12`} {
+		var m Millis
+		if err := json.Unmarshal([]byte(in), &m); err != nil {
+			t.Fatalf("unmarshal %s: %v", in, err)
+		}
+		if m != 1706615000000 {
+			t.Errorf("unmarshal %s = %d", in, m)
+		}
+	}
+	var m Millis
+	if err := json.Unmarshal([]byte(`"yesterday"`), &m); err == nil {
+		t.Fatal("expected error for non-timestamp string")
+	}
+}
+
+func TestBytesMsgpack(t *testing.T) {
+	png := Bytes{0x89, 'P', 'N', 'G'}
+	data, err := msgpack.Marshal(png)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(data); got != "c404"+"89504e47" {
+		t.Fatalf("encoded as %s, want bin8", got)
+	}
+
+	// bin, str, and integer arrays all decode to the same bytes.
+	for name, in := range map[string]any{
+		"bin":   []byte(png),
+		"str":   string(png),
+		"array": []any{0x89, 'P', 'N', 'G'},
+	} {
+		data, err := msgpack.Marshal(in)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var got Bytes
+		if err := msgpack.Unmarshal(data, &got); err != nil {
+			t.Fatalf("%s: unmarshal: %v", name, err)
+		}
+		if !got.Equal(png) {
+			t.Errorf("%s: got %x", name, got)
+		}
+	}
+
+	data, _ = msgpack.Marshal([]any{1, 300})
+	var got Bytes
+	if err := msgpack.Unmarshal(data, &got); err == nil {
+		t.Fatal("expected error for out-of-range array element")
+	}
+}
+
+func TestBytesJSON(t *testing.T) {
+	data, err := json.Marshal(scalarPayload{At: 1, Data: Bytes{0x89, 'P', 'N', 'G'}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"at":1,"data":"iVBORw=="}` {
+		t.Fatalf("marshal = %s", data)
+	}
+	for _, in := range []string{`"iVBORw=="`, `"iVBORw"`} {
+		var b Bytes
+		if err := json.Unmarshal([]byte(in), &b); err != nil || b.String() != "iVBORw==" {
+			t.Errorf("unmarshal %s = %x, %v", in, b, err)
+		}
+	}
+}
+
+func TestScalarPayloadRoundTrip(t *testing.T) {
+	in := scalarPayload{At: 1706615000000, Data: Bytes("hello")}
+	data, err := msgpack.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out scalarPayload
+	if err := msgpack.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.At != in.At || !out.Data.Equal(in.Data) {
+		t.Fatalf("round trip = %+v", out)
+	}
+}
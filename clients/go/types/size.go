@@ -0,0 +1,170 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// =============================================================================
+// Size Limits
+// =============================================================================
+
+// ErrItemTooLarge is returned by TruncateForLimit when an item stays over
+// the limit with every truncatable field cut down, for example because the
+// user input alone is too large.
+var ErrItemTooLarge = errors.New("types: item too large")
+
+// truncationMarker replaces the middle of a truncated string.
+const truncationMarker = "\n…[%d bytes truncated]…\n"
+
+// EstimateEncodedSize returns the size of item encoded as msgpack, as
+// cxdb.EncodeMsgpack encodes it for AppendTurn. The server may compress the
+// payload further; limits apply to this uncompressed size.
+func (item *ConversationItem) EstimateEncodedSize() int {
+	var w countingWriter
+	enc := msgpack.NewEncoder(&w)
+	enc.SetSortMapKeys(true)
+	_ = enc.Encode(item) // cannot fail for these types
+	return int(w)
+}
+
+type countingWriter int
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	*w += countingWriter(len(p))
+	return len(p), nil
+}
+
+// TruncateForLimit shortens the bulky fields of item until its encoded size
+// is at most maxBytes: tool call streaming output and results, legacy tool
+// results, and reasoning. Text written by the user or the assistant is
+// never touched.
+//
+// The longest fields are cut first, down to a common length, so a single
+// huge tool output absorbs the cut before smaller fields are shortened.
+// Each cut string keeps its head and tail around a marker giving the
+// number of bytes removed, and the field's truncation flag is set where the
+// schema has one (StreamingOutputTruncated, ContentTruncated,
+// OutputTruncated).
+//
+// It returns an error wrapping ErrItemTooLarge if the item cannot be brought
+// under the limit; the item is then left as truncated as possible.
+func (item *ConversationItem) TruncateForLimit(maxBytes int) error {
+	size := item.EstimateEncodedSize()
+	if size <= maxBytes {
+		return nil
+	}
+
+	fields := item.truncatableFields()
+	originals := make([]string, len(fields))
+	for i, f := range fields {
+		originals[i] = *f.s
+	}
+
+	// Encoding overhead and markers make the first cut inexact, so cut
+	// again from the originals, deeper each time, until the item fits.
+	need := size - maxBytes
+	for attempt := 0; attempt < 8; attempt++ {
+		limit := truncationLevel(originals, need)
+		for i, f := range fields {
+			if cut, ok := truncateMiddle(originals[i], limit); ok {
+				*f.s = cut
+				if f.flag != nil {
+					*f.flag = true
+				}
+			}
+		}
+		size = item.EstimateEncodedSize()
+		if size <= maxBytes || limit == 0 {
+			break
+		}
+		need += size - maxBytes
+	}
+	if size > maxBytes {
+		return fmt.Errorf("%w: %d bytes after truncation, limit %d", ErrItemTooLarge, size, maxBytes)
+	}
+	return nil
+}
+
+// truncatableField is a string TruncateForLimit may shorten, with the flag
+// recording that it did.
+type truncatableField struct {
+	s    *string
+	flag *bool
+}
+
+func (item *ConversationItem) truncatableFields() []truncatableField {
+	var fields []truncatableField
+	if t := item.Turn; t != nil {
+		for i := range t.ToolCalls {
+			tc := &t.ToolCalls[i]
+			fields = append(fields, truncatableField{&tc.StreamingOutput, &tc.StreamingOutputTruncated})
+			if tc.Result != nil {
+				fields = append(fields, truncatableField{&tc.Result.Content, &tc.Result.ContentTruncated})
+			}
+		}
+		fields = append(fields, truncatableField{&t.Reasoning, nil})
+	}
+	if r := item.ToolResult; r != nil {
+		fields = append(fields,
+			truncatableField{&r.Content, &r.OutputTruncated},
+			truncatableField{&r.StreamingOutput, &r.OutputTruncated})
+	}
+	if a := item.Assistant; a != nil {
+		fields = append(fields, truncatableField{&a.Reasoning, nil})
+	}
+	return fields
+}
+
+// truncationLevel returns the largest length that, with every longer string
+// cut down to it, removes at least need bytes; 0 if that is not possible.
+func truncationLevel(strs []string, need int) int {
+	lens := make([]int, len(strs))
+	for i, s := range strs {
+		lens[i] = len(s)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(lens)))
+
+	removed := 0
+	for i := range lens {
+		next := 0
+		if i+1 < len(lens) {
+			next = lens[i+1]
+		}
+		// Lowering the level from lens[i] to next shortens i+1 strings.
+		if removed+(i+1)*(lens[i]-next) >= need {
+			return lens[i] - (need-removed+i)/(i+1)
+		}
+		removed += (i + 1) * (lens[i] - next)
+	}
+	return 0
+}
+
+// truncateMiddle shortens s to about n bytes, keeping its head and tail
+// around a marker. It reports false if that would not make s shorter.
+func truncateMiddle(s string, n int) (string, bool) {
+	if len(s) <= n {
+		return s, false
+	}
+	keep := max(n-len(truncationMarker), 0)
+	head := keep / 2
+	tail := len(s) - (keep - head)
+	for head > 0 && !utf8.RuneStart(s[head]) {
+		head--
+	}
+	for tail < len(s) && !utf8.RuneStart(s[tail]) {
+		tail++
+	}
+	cut := s[:head] + fmt.Sprintf(truncationMarker, tail-head) + s[tail:]
+	if len(cut) >= len(s) {
+		return s, false
+	}
+	return cut, true
+}
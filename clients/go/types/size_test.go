@@ -0,0 +1,71 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestEstimateEncodedSize(t *testing.T) {
+	item := goldenItem()
+	data, err := msgpack.Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := item.EstimateEncodedSize(); got != len(data) {
+		t.Errorf("EstimateEncodedSize = %d, encoded %d bytes", got, len(data))
+	}
+}
+
+func TestTruncateForLimit(t *testing.T) {
+	huge := "BEGIN " + strings.Repeat("é", 50_000) + " END"
+	item := BuildAssistantTurn("answer").
+		WithReasoning("short reasoning").
+		WithToolCall(BuildToolCallItem("call-1", "shell", "{}").
+			WithStreamingOutput(huge, false).
+			WithResult(strings.Repeat("r", 5_000), nil).
+			Build()).
+		Build()
+
+	if err := item.TruncateForLimit(1 << 20); err != nil || item.Turn.ToolCalls[0].StreamingOutputTruncated {
+		t.Fatalf("item under the limit was changed: %v", err)
+	}
+
+	const limit = 8 << 10
+	if err := item.TruncateForLimit(limit); err != nil {
+		t.Fatal(err)
+	}
+	if size := item.EstimateEncodedSize(); size > limit || size < limit-512 {
+		t.Errorf("size after truncation = %d, limit %d", size, limit)
+	}
+	tc := item.Turn.ToolCalls[0]
+	out := tc.StreamingOutput
+	if !tc.StreamingOutputTruncated || !strings.HasPrefix(out, "BEGIN ") || !strings.HasSuffix(out, " END") ||
+		!strings.Contains(out, "bytes truncated") || !utf8.ValidString(out) {
+		t.Errorf("streaming output not cut around a marker: %.60q…", out)
+	}
+	// The smaller fields absorb the cut only after the largest one.
+	if tc.Result.ContentTruncated && len(tc.Result.Content) < len(out)-len(truncationMarker) {
+		t.Errorf("result cut below the streaming output: %d < %d", len(tc.Result.Content), len(out))
+	}
+	if item.Turn.Reasoning != "short reasoning" || item.Turn.Text != "answer" {
+		t.Error("short fields were changed")
+	}
+}
+
+func TestTruncateForLimitTooLarge(t *testing.T) {
+	item := NewUserInput(strings.Repeat("u", 4_000))
+	err := item.TruncateForLimit(1_000)
+	if !errors.Is(err, ErrItemTooLarge) {
+		t.Errorf("err = %v, want ErrItemTooLarge", err)
+	}
+	if len(item.UserInput.Text) != 4_000 {
+		t.Error("user input was truncated")
+	}
+}
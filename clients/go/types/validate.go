@@ -0,0 +1,112 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidItem is returned by ConversationItem.Validate.
+var ErrInvalidItem = errors.New("types: invalid conversation item")
+
+var (
+	itemTypes = map[ItemType]bool{
+		ItemTypeUserInput: true, ItemTypeAssistantTurn: true, ItemTypeSystem: true,
+		ItemTypeHandoff: true, ItemTypeAnnotation: true,
+		ItemTypeAssistant: true, ItemTypeToolCall: true, ItemTypeToolResult: true,
+	}
+	itemStatuses = map[ItemStatus]bool{
+		ItemStatusPending: true, ItemStatusStreaming: true, ItemStatusComplete: true,
+		ItemStatusError: true, ItemStatusCancelled: true,
+	}
+	toolCallStatuses = map[ToolCallStatus]bool{
+		ToolCallStatusPending: true, ToolCallStatusExecuting: true, ToolCallStatusComplete: true,
+		ToolCallStatusError: true, ToolCallStatusSkipped: true,
+	}
+	systemKinds = map[SystemKind]bool{
+		SystemKindInfo: true, SystemKindWarning: true, SystemKindError: true,
+		SystemKindGuardrail: true, SystemKindRateLimit: true, SystemKindRewind: true,
+	}
+)
+
+// Validate checks the item against the canonical schema: a known item
+// type, its required fields (see MissingFields) and no other variant set,
+// known status, tool call status and system kind values, a tool call with
+// a result or an error but not both, consistent metrics (see
+// TurnMetrics.Validate), and embeddings with a vector or a blob hash.
+//
+// All problems are reported in one error wrapping ErrInvalidItem.
+func (c *ConversationItem) Validate() error {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.ItemType != "" && !itemTypes[c.ItemType] {
+		add("unknown item_type %q", c.ItemType)
+	}
+	for _, path := range c.MissingFields() {
+		add("missing required field %s", path)
+	}
+	for _, v := range []struct {
+		itemType ItemType
+		set      bool
+		name     string
+	}{
+		{ItemTypeUserInput, c.UserInput != nil, "user_input"},
+		{ItemTypeAssistantTurn, c.Turn != nil, "turn"},
+		{ItemTypeSystem, c.System != nil, "system"},
+		{ItemTypeHandoff, c.Handoff != nil, "handoff"},
+		{ItemTypeAnnotation, c.Annotation != nil, "annotation"},
+		{ItemTypeAssistant, c.Assistant != nil, "assistant"},
+		{ItemTypeToolCall, c.ToolCall != nil, "tool_call"},
+		{ItemTypeToolResult, c.ToolResult != nil, "tool_result"},
+	} {
+		if v.set && v.itemType != c.ItemType {
+			add("%s set on a %q item", v.name, c.ItemType)
+		}
+	}
+	if c.Status != "" && !itemStatuses[c.Status] {
+		add("unknown status %q", c.Status)
+	}
+	if c.Timestamp < 0 {
+		add("negative timestamp")
+	}
+
+	if c.Turn != nil {
+		for i, tc := range c.Turn.ToolCalls {
+			if tc.Status != "" && !toolCallStatuses[tc.Status] {
+				add("tool call %d: unknown status %q", i, tc.Status)
+			}
+			if tc.Result != nil && tc.Error != nil {
+				add("tool call %d: both result and error set", i)
+			}
+		}
+		if c.Turn.Metrics != nil {
+			if err := c.Turn.Metrics.Validate(c.Turn, nil); err != nil {
+				add("%v", err)
+			}
+		}
+	}
+	if c.System != nil && c.System.Kind != "" && !systemKinds[c.System.Kind] {
+		add("unknown system kind %q", c.System.Kind)
+	}
+	if c.Annotation != nil {
+		for i, e := range c.Annotation.Embeddings {
+			switch {
+			case len(e.Vector) == 0 && e.BlobHash == "":
+				add("embedding %d: neither vector nor blob_hash set", i)
+			case len(e.Vector) > 0 && e.Dims != len(e.Vector):
+				add("embedding %d: dims %d but %d values", i, e.Dims, len(e.Vector))
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidItem, strings.Join(problems, "; "))
+}
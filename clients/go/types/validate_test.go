@@ -0,0 +1,61 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConversationItemValidate(t *testing.T) {
+	withMetrics := BuildAssistantTurn("done").WithMetrics(100, 20).Build()
+	badMetrics := NewAssistantTurn("done")
+	badMetrics.Turn.Metrics = &TurnMetrics{InputTokens: 1, OutputTokens: 1, TotalTokens: 5}
+	bothOutcomes := BuildToolCallItem("call-1", "ls", "{}").WithResult("ok", nil).Build()
+	bothOutcomes.Error = &ToolCallError{Message: "failed"}
+	twoVariants := NewUserInput("hi")
+	twoVariants.Turn = &AssistantTurn{Text: "hello"}
+	badStatus := NewSystemInfo("note")
+	badStatus.Status = "finished"
+	badStatus.System.Kind = "notice"
+
+	tests := []struct {
+		name string
+		item *ConversationItem
+		want []string // substrings of the error; nil for a valid item
+	}{
+		{"user input", NewUserInput("hi"), nil},
+		{"assistant turn", withMetrics, nil},
+		{"handoff", NewHandoff("planner", "coder"), nil},
+		{"embedding", NewEmbeddingAnnotation(7, "m", []float32{1, 2}), nil},
+		{"no type", &ConversationItem{}, []string{"missing required field 1"}},
+		{"unknown type", &ConversationItem{ItemType: "thought"}, []string{`unknown item_type "thought"`}},
+		{"missing variant", &ConversationItem{ItemType: ItemTypeSystem}, []string{"missing required field 12"}},
+		{"two variants", twoVariants, []string{`turn set on a "user_input" item`}},
+		{"bad enums", badStatus, []string{`unknown status "finished"`, `unknown system kind "notice"`}},
+		{"bad metrics", badMetrics, []string{"total_tokens"}},
+		{"result and error", BuildAssistantTurn("x").WithToolCall(bothOutcomes).Build(), []string{"both result and error"}},
+		{"empty embedding", BuildAnnotation(7).WithEmbedding(Embedding{Model: "m"}).Build(), []string{"neither vector nor blob_hash"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.item.Validate()
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Validate() = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidItem) {
+				t.Fatalf("Validate() = %v, want ErrInvalidItem", err)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(err.Error(), w) {
+					t.Errorf("Validate() = %v, want %q", err, w)
+				}
+			}
+		})
+	}
+}
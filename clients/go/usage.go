@@ -0,0 +1,129 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"sync"
+
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
+)
+
+// frameHeaderSize is the size of a binary protocol frame header.
+const frameHeaderSize = wire.HeaderSize
+
+// ContextUsage counts wire traffic attributed to one context. Byte counts
+// include frame headers.
+type ContextUsage struct {
+	// Appends is the number of turns appended.
+	Appends uint64
+
+	// BytesAppended is the request bytes sent for appends.
+	BytesAppended uint64
+
+	// Reads is the number of GetLast calls.
+	Reads uint64
+
+	// BytesRead is the response bytes received for GetLast and GetBlob.
+	BytesRead uint64
+
+	// BlobsUploaded is the number of PutBlob calls, including ones for
+	// blobs the server already had.
+	BlobsUploaded uint64
+
+	// BlobBytesUploaded is the request bytes sent for PutBlob.
+	BlobBytesUploaded uint64
+}
+
+func (u *ContextUsage) add(o ContextUsage) {
+	u.Appends += o.Appends
+	u.BytesAppended += o.BytesAppended
+	u.Reads += o.Reads
+	u.BytesRead += o.BytesRead
+	u.BlobsUploaded += o.BlobsUploaded
+	u.BlobBytesUploaded += o.BlobBytesUploaded
+}
+
+// UsageStats is a snapshot of a client's per-context traffic counters.
+type UsageStats struct {
+	// Contexts maps context ID to usage. Blob traffic that carries no
+	// context (see WithUsageContext) is recorded under ID 0.
+	Contexts map[uint64]ContextUsage
+}
+
+// Total sums usage across all contexts.
+func (s UsageStats) Total() ContextUsage {
+	var total ContextUsage
+	for _, u := range s.Contexts {
+		total.add(u)
+	}
+	return total
+}
+
+type usageContextKey struct{}
+
+// WithUsageContext attributes blob traffic made with the returned context to
+// contextID. Blob requests carry no context ID on the wire, so without this
+// they are counted under ID 0. Appends and reads are always attributed to
+// the context they name.
+func WithUsageContext(ctx context.Context, contextID uint64) context.Context {
+	return context.WithValue(ctx, usageContextKey{}, contextID)
+}
+
+func usageContextID(ctx context.Context) uint64 {
+	id, _ := ctx.Value(usageContextKey{}).(uint64)
+	return id
+}
+
+// usageTracker holds the counters behind UsageStats. A nil tracker records
+// nothing.
+type usageTracker struct {
+	mu       sync.Mutex
+	contexts map[uint64]*ContextUsage
+}
+
+func newUsageTracker() *usageTracker {
+	return &usageTracker{contexts: make(map[uint64]*ContextUsage)}
+}
+
+func (t *usageTracker) record(contextID uint64, delta ContextUsage) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	u := t.contexts[contextID]
+	if u == nil {
+		u = &ContextUsage{}
+		t.contexts[contextID] = u
+	}
+	u.add(delta)
+}
+
+func (t *usageTracker) snapshot(reset bool) UsageStats {
+	stats := UsageStats{Contexts: make(map[uint64]ContextUsage)}
+	if t == nil {
+		return stats
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for id, u := range t.contexts {
+		stats.Contexts[id] = *u
+	}
+	if reset {
+		t.contexts = make(map[uint64]*ContextUsage)
+	}
+	return stats
+}
+
+// UsageStats returns a snapshot of the client's per-context traffic counters.
+func (c *Client) UsageStats() UsageStats {
+	return c.usage.snapshot(false)
+}
+
+// ResetUsageStats returns the counters and zeroes them atomically, so
+// periodic collectors never lose or double-count traffic.
+func (c *Client) ResetUsageStats() UsageStats {
+	return c.usage.snapshot(true)
+}
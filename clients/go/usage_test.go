@@ -0,0 +1,56 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestUsageStats(t *testing.T) {
+	store := &memStore{blobs: make(map[[32]byte][]byte)}
+	c := helloClient(t, store.handle)
+	c.payloadBlobThreshold = 64
+	ctx := context.Background()
+
+	small := []byte("hello")
+	big := bytes.Repeat([]byte("y"), 500)
+	for _, p := range [][]byte{small, big} {
+		if _, err := c.AppendTurn(ctx, &AppendRequest{ContextID: 1, TypeID: "t", TypeVersion: 1, Payload: p}); err != nil {
+			t.Fatalf("AppendTurn: %v", err)
+		}
+	}
+	if _, err := c.PutBlob(ctx, &PutBlobRequest{Data: []byte("loose")}); err != nil {
+		t.Fatalf("PutBlob: %v", err)
+	}
+	if _, err := c.GetLast(ctx, 1, GetLastOptions{IncludePayload: true}); err != nil {
+		t.Fatalf("GetLast: %v", err)
+	}
+
+	stats := c.UsageStats()
+	u := stats.Contexts[1]
+	if u.Appends != 2 || u.Reads != 1 || u.BlobsUploaded != 1 {
+		t.Errorf("context 1 usage = %+v", u)
+	}
+	if u.BlobBytesUploaded < uint64(len(big)) || u.BytesRead < uint64(len(big)) {
+		t.Errorf("externalized payload not counted: %+v", u)
+	}
+	if u.BytesAppended >= uint64(len(big)) {
+		t.Errorf("BytesAppended = %d includes the externalized payload", u.BytesAppended)
+	}
+	if loose := stats.Contexts[0]; loose.BlobsUploaded != 1 {
+		t.Errorf("unattributed usage = %+v", loose)
+	}
+	if total := stats.Total(); total.BlobsUploaded != 2 {
+		t.Errorf("total = %+v", total)
+	}
+
+	if got := c.ResetUsageStats(); got.Contexts[1] != u {
+		t.Errorf("ResetUsageStats returned %+v, want %+v", got.Contexts[1], u)
+	}
+	if n := len(c.UsageStats().Contexts); n != 0 {
+		t.Errorf("%d contexts after reset", n)
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Watch defaults.
+const (
+	DefaultWatchMaxIntervalFactor = 8 // idle polling slows to this multiple of the interval
+	watchBackoffFactor            = 1.5
+	watchJitter                   = 0.1 // ±10% of each delay
+)
+
+// HeadEvent reports a context head change.
+type HeadEvent struct {
+	Head ContextHead
+
+	// Previous is the head of the last event; zero on the first event,
+	// which carries the head at the time the watch started.
+	Previous ContextHead
+}
+
+// HeadWatch delivers head changes for one context. WatchHead implements it
+// by polling GetHead; a server push subscription will implement the same
+// interface, so consumers need not know which mechanism is in use.
+type HeadWatch interface {
+	// Events delivers head changes in order. Changes that happen while
+	// the consumer is not receiving are coalesced into one event. The
+	// channel is closed when the watch stops.
+	Events() <-chan HeadEvent
+
+	// Err returns why the watch stopped once Events is closed: nil after
+	// Close, the context's error on cancellation, or the server error that
+	// ended polling.
+	Err() error
+
+	// Close stops the watch and waits for it to finish.
+	Close() error
+}
+
+// WatchOption configures WatchHead.
+type WatchOption func(*watchOptions)
+
+type watchOptions struct {
+	maxInterval time.Duration
+}
+
+// WithMaxWatchInterval caps how far polling slows down while the head is
+// idle (default: DefaultWatchMaxIntervalFactor times the interval).
+func WithMaxWatchInterval(d time.Duration) WatchOption {
+	return func(o *watchOptions) {
+		o.maxInterval = d
+	}
+}
+
+// WatchHead watches a context's head by polling. Polls start every interval
+// and slow down while the head does not move, up to the maximum interval;
+// any change snaps back to interval. Delays are jittered so a fleet of
+// watchers does not poll in lockstep.
+//
+// The first event carries the current head. Server errors such as an
+// unknown context end the watch; other errors are logged and retried.
+func (c *Client) WatchHead(ctx context.Context, contextID uint64, interval time.Duration, opts ...WatchOption) (HeadWatch, error) {
+	return watchHead(ctx, c.GetHead, contextID, interval, opts)
+}
+
+// headPoller reads a context head; Client.GetHead and
+// ReconnectingClient.GetHead both fit.
+type headPoller func(ctx context.Context, contextID uint64) (*ContextHead, error)
+
+func watchHead(ctx context.Context, get headPoller, contextID uint64, interval time.Duration, opts []WatchOption) (HeadWatch, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("watch head: interval must be positive, got %v", interval)
+	}
+	o := watchOptions{maxInterval: interval * DefaultWatchMaxIntervalFactor}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.maxInterval = max(o.maxInterval, interval)
+
+	head, err := get(ctx, contextID)
+	if err != nil {
+		return nil, fmt.Errorf("watch head: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	w := &pollWatch{
+		get:       get,
+		contextID: contextID,
+		interval:  interval,
+		max:       o.maxInterval,
+		events:    make(chan HeadEvent, 1),
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+	w.events <- HeadEvent{Head: *head}
+	go w.run(ctx, *head)
+	return w, nil
+}
+
+// pollWatch is the polling HeadWatch.
+type pollWatch struct {
+	get       headPoller
+	contextID uint64
+	interval  time.Duration
+	max       time.Duration
+
+	events chan HeadEvent
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu     sync.Mutex
+	err    error
+	closed bool
+}
+
+func (w *pollWatch) Events() <-chan HeadEvent {
+	return w.events
+}
+
+func (w *pollWatch) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+func (w *pollWatch) Close() error {
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+	w.cancel()
+	<-w.done
+	return nil
+}
+
+func (w *pollWatch) run(ctx context.Context, last ContextHead) {
+	defer close(w.done)
+	defer close(w.events)
+
+	delay := w.interval
+	for {
+		select {
+		case <-ctx.Done():
+			w.stop(ctx.Err())
+			return
+		case <-time.After(jitter(delay)):
+		}
+
+		head, err := w.get(ctx, w.contextID)
+		switch {
+		case err == nil && head.HeadTurnID == last.HeadTurnID:
+			delay = min(time.Duration(float64(delay)*watchBackoffFactor), w.max)
+			continue
+		case err == nil:
+			select {
+			case w.events <- HeadEvent{Head: *head, Previous: last}:
+			case <-ctx.Done():
+				w.stop(ctx.Err())
+				return
+			}
+			last = *head
+			delay = w.interval
+			continue
+		case ctx.Err() != nil:
+			w.stop(ctx.Err())
+			return
+		}
+
+		var se *ServerError
+		if errors.As(err, &se) || errors.Is(err, ErrClientClosed) {
+			w.stop(fmt.Errorf("watch head: %w", err))
+			return
+		}
+		slog.Warn("[cxdb] watch head poll failed, retrying",
+			"context_id", w.contextID,
+			"error", err)
+		delay = min(time.Duration(float64(delay)*watchBackoffFactor), w.max)
+	}
+}
+
+// stop records why the watch ended. Errors caused by Close are dropped.
+func (w *pollWatch) stop(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.closed {
+		w.err = err
+	}
+}
+
+// jitter spreads d by ±watchJitter.
+func jitter(d time.Duration) time.Duration {
+	return time.Duration(float64(d) * (1 + watchJitter*(2*rand.Float64()-1)))
+}
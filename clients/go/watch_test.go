@@ -0,0 +1,118 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeHeads is a headPoller over a mutable head.
+type fakeHeads struct {
+	mu    sync.Mutex
+	head  ContextHead
+	err   error
+	polls []time.Time
+}
+
+func (f *fakeHeads) get(_ context.Context, contextID uint64) (*ContextHead, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.polls = append(f.polls, time.Now())
+	if f.err != nil {
+		return nil, f.err
+	}
+	h := f.head
+	h.ContextID = contextID
+	return &h, nil
+}
+
+func (f *fakeHeads) set(turnID uint64, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.head.HeadTurnID, f.head.HeadDepth = turnID, uint32(turnID)
+	f.err = err
+}
+
+func nextEvent(t *testing.T, w HeadWatch) (HeadEvent, bool) {
+	t.Helper()
+	select {
+	case ev, ok := <-w.Events():
+		return ev, ok
+	case <-time.After(2 * time.Second):
+		t.Fatal("no head event")
+		return HeadEvent{}, false
+	}
+}
+
+func TestWatchHead(t *testing.T) {
+	heads := &fakeHeads{}
+	heads.set(3, nil)
+	w, err := watchHead(context.Background(), heads.get, 9, 2*time.Millisecond, []WatchOption{WithMaxWatchInterval(10 * time.Millisecond)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ev, _ := nextEvent(t, w); ev.Head.HeadTurnID != 3 || ev.Head.ContextID != 9 || ev.Previous.HeadTurnID != 0 {
+		t.Errorf("initial event = %+v", ev)
+	}
+
+	// Idle polling backs off to the maximum interval.
+	time.Sleep(60 * time.Millisecond)
+	heads.mu.Lock()
+	idlePolls := len(heads.polls)
+	heads.mu.Unlock()
+	if idlePolls > 20 {
+		t.Errorf("%d polls in 60ms while idle; backoff not applied", idlePolls)
+	}
+
+	heads.set(5, nil)
+	if ev, _ := nextEvent(t, w); ev.Head.HeadTurnID != 5 || ev.Previous.HeadTurnID != 3 {
+		t.Errorf("change event = %+v", ev)
+	}
+
+	// A server error ends the watch.
+	heads.set(5, &ServerError{Code: 404, Detail: "context not found"})
+	if ev, ok := nextEvent(t, w); ok {
+		t.Fatalf("event after server error: %+v", ev)
+	}
+	if !IsServerError(w.Err(), 404) {
+		t.Errorf("Err() = %v", w.Err())
+	}
+}
+
+func TestWatchHeadCloseAndTransientErrors(t *testing.T) {
+	heads := &fakeHeads{}
+	heads.set(1, nil)
+	w, err := watchHead(context.Background(), heads.get, 9, time.Millisecond, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nextEvent(t, w)
+
+	// Non-server errors are retried.
+	heads.set(1, errors.New("i/o timeout"))
+	time.Sleep(10 * time.Millisecond)
+	heads.set(2, nil)
+	if ev, _ := nextEvent(t, w); ev.Head.HeadTurnID != 2 {
+		t.Errorf("event after retry = %+v", ev)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-w.Events(); ok {
+		t.Error("Events still open after Close")
+	}
+	if w.Err() != nil {
+		t.Errorf("Err() after Close = %v", w.Err())
+	}
+
+	if _, err := watchHead(context.Background(), heads.get, 9, 0, nil); err == nil {
+		t.Error("zero interval accepted")
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cxdb
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// WithWebSocketHeader adds a header to the WebSocket handshake sent by
+// DialWebSocket, typically Authorization for the gateway:
+//
+//	cxdb.WithWebSocketHeader("Authorization", "Bearer "+token)
+func WithWebSocketHeader(key, value string) Option {
+	return func(o *clientOptions) {
+		if o.wsHeader == nil {
+			o.wsHeader = http.Header{}
+		}
+		o.wsHeader.Add(key, value)
+	}
+}
+
+// DialWebSocket connects through a WebSocket endpoint such as the gateway's
+// binary tunnel (wss://your-domain.com/v1/binary). Each binary message
+// carries one protocol frame; the session behaves exactly like one opened
+// with Dial. Use wss:// in production.
+//
+// In js/wasm builds the browser's WebSocket is used: the handshake carries
+// the page's cookies, and WithWebSocketHeader is not supported.
+func DialWebSocket(rawURL string, opts ...Option) (*Client, error) {
+	options := defaultClientOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	t, err := dialWebSocket(rawURL, options)
+	if err != nil {
+		return nil, fmt.Errorf("cxdb dial websocket: %w", err)
+	}
+	return newClient(t, options)
+}
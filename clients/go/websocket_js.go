@@ -0,0 +1,191 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build js && wasm
+
+package cxdb
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"syscall/js"
+	"time"
+
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
+)
+
+// dialWebSocket opens a browser WebSocket. Browsers send the page's cookies
+// with the handshake but do not allow custom headers, so WithWebSocketHeader
+// is rejected rather than silently dropped.
+func dialWebSocket(rawURL string, options clientOptions) (Transport, error) {
+	if len(options.wsHeader) > 0 {
+		return nil, errors.New("custom handshake headers are not supported by browser WebSockets")
+	}
+	ctor := js.Global().Get("WebSocket")
+	if ctor.IsUndefined() {
+		return nil, errors.New("WebSocket is not available in this JavaScript environment")
+	}
+
+	t := &jsTransport{ready: make(chan struct{}, 1)}
+	ws, err := newJSWebSocket(ctor, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	t.ws = ws
+	t.ws.Set("binaryType", "arraybuffer")
+
+	open := make(chan struct{})
+	var openOnce sync.Once
+	t.funcs = []js.Func{
+		js.FuncOf(func(js.Value, []js.Value) any {
+			openOnce.Do(func() { close(open) })
+			return nil
+		}),
+		js.FuncOf(func(_ js.Value, args []js.Value) any {
+			data := args[0].Get("data")
+			buf := make([]byte, data.Get("byteLength").Int())
+			js.CopyBytesToGo(buf, js.Global().Get("Uint8Array").New(data))
+			t.push(buf, nil)
+			return nil
+		}),
+		js.FuncOf(func(_ js.Value, args []js.Value) any {
+			code := args[0].Get("code").Int()
+			t.push(nil, fmt.Errorf("websocket closed by server (code %d): %w", code, io.EOF))
+			openOnce.Do(func() { close(open) })
+			return nil
+		}),
+	}
+	t.ws.Set("onopen", t.funcs[0])
+	t.ws.Set("onmessage", t.funcs[1])
+	t.ws.Set("onclose", t.funcs[2])
+
+	select {
+	case <-open:
+	case <-time.After(options.dialTimeout):
+		_ = t.Close()
+		return nil, fmt.Errorf("dial %s: %w", rawURL, os.ErrDeadlineExceeded)
+	}
+	if t.ws.Get("readyState").Int() != 1 { // OPEN
+		_ = t.Close()
+		return nil, fmt.Errorf("dial %s: connection refused", rawURL)
+	}
+	return t, nil
+}
+
+// newJSWebSocket calls the WebSocket constructor, turning a thrown
+// exception (for example a malformed URL) into an error.
+func newJSWebSocket(ctor js.Value, rawURL string) (ws js.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("dial %s: %v", rawURL, r)
+		}
+	}()
+	return ctor.New(rawURL, wire.WebSocketSubprotocol), nil
+}
+
+// jsTransport carries frames over a browser WebSocket. Messages arrive on
+// the JavaScript event loop and are queued until ReadFrame takes them;
+// callbacks must not block, so the queue is unbounded.
+type jsTransport struct {
+	ws    js.Value
+	funcs []js.Func
+
+	mu       sync.Mutex
+	queue    [][]byte
+	err      error // set once the socket closes
+	deadline time.Time
+	ready    chan struct{} // signalled when queue or err changes
+}
+
+func (t *jsTransport) push(msg []byte, err error) {
+	t.mu.Lock()
+	if err != nil {
+		if t.err == nil {
+			t.err = err
+		}
+	} else {
+		t.queue = append(t.queue, msg)
+	}
+	t.mu.Unlock()
+	select {
+	case t.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (t *jsTransport) WriteFrame(h wire.Header, payload []byte) error {
+	t.mu.Lock()
+	err := t.err
+	t.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	msg := append(wire.AppendHeader(make([]byte, 0, wire.HeaderSize+len(payload)), h), payload...)
+	arr := js.Global().Get("Uint8Array").New(len(msg))
+	js.CopyBytesToJS(arr, msg)
+	t.ws.Call("send", arr)
+	return nil
+}
+
+func (t *jsTransport) ReadFrame() (wire.Header, []byte, error) {
+	for {
+		t.mu.Lock()
+		var msg []byte
+		if len(t.queue) > 0 {
+			msg, t.queue = t.queue[0], t.queue[1:]
+		}
+		err, deadline := t.err, t.deadline
+		t.mu.Unlock()
+
+		switch {
+		case msg != nil:
+			h, err := wire.ParseHeader(msg)
+			if err != nil {
+				return wire.Header{}, nil, fmt.Errorf("read frame: %w", err)
+			}
+			if uint64(h.Length) != uint64(len(msg)-wire.HeaderSize) {
+				return wire.Header{}, nil, fmt.Errorf("read frame: message holds %d payload bytes, header says %d",
+					len(msg)-wire.HeaderSize, h.Length)
+			}
+			return h, msg[wire.HeaderSize:], nil
+		case err != nil:
+			return wire.Header{}, nil, fmt.Errorf("read frame: %w", err)
+		}
+
+		if deadline.IsZero() {
+			<-t.ready
+			continue
+		}
+		timer := time.NewTimer(time.Until(deadline))
+		select {
+		case <-t.ready:
+			timer.Stop()
+		case <-timer.C:
+			return wire.Header{}, nil, fmt.Errorf("read frame: %w", os.ErrDeadlineExceeded)
+		}
+	}
+}
+
+// SetDeadline bounds reads only; browser sends are queued and never block.
+func (t *jsTransport) SetDeadline(d time.Time) error {
+	t.mu.Lock()
+	t.deadline = d
+	t.mu.Unlock()
+	return nil
+}
+
+func (t *jsTransport) Close() error {
+	t.push(nil, net.ErrClosed)
+	t.ws.Call("close", 1000)
+	t.ws.Set("onopen", js.Null())
+	t.ws.Set("onmessage", js.Null())
+	t.ws.Set("onclose", js.Null())
+	for _, f := range t.funcs {
+		f.Release()
+	}
+	return nil
+}
@@ -0,0 +1,287 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !js
+
+package cxdb
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
+)
+
+// WebSocket framing (RFC 6455). Only what the tunnel needs is implemented:
+// binary messages, fragmentation, ping/pong and close.
+const (
+	wsOpContinuation = 0x0
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+
+	wsAcceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	// wsMaxMessage bounds a message and each of its frames: one protocol
+	// frame, header included.
+	wsMaxMessage = wire.HeaderSize + wire.MaxFrameSize
+)
+
+// dialWebSocket opens a TCP or TLS connection and performs the WebSocket
+// handshake on it.
+func dialWebSocket(rawURL string, options clientOptions) (Transport, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		switch u.Scheme {
+		case "ws":
+			addr = net.JoinHostPort(u.Hostname(), "80")
+		case "wss":
+			addr = net.JoinHostPort(u.Hostname(), "443")
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: options.dialTimeout}
+	var conn net.Conn
+	switch u.Scheme {
+	case "ws":
+		conn, err = dialer.Dial("tcp", addr)
+	case "wss":
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{})
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q, want ws or wss", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := wsHandshake(conn, u, options.wsHeader, time.Now().Add(options.dialTimeout))
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// wsHandshake upgrades conn to a WebSocket speaking wire.WebSocketSubprotocol.
+func wsHandshake(conn net.Conn, u *url.URL, header http.Header, deadline time.Time) (*wsTransport, error) {
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("set deadline: %w", err)
+	}
+
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        &url.URL{Path: u.Path, RawPath: u.RawPath, RawQuery: u.RawQuery},
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Host:       u.Host,
+		Header:     header.Clone(),
+	}
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Protocol", wire.WebSocketSubprotocol)
+	if err := req.Write(conn); err != nil {
+		return nil, fmt.Errorf("write handshake: %w", err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, fmt.Errorf("read handshake: %w", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("handshake rejected: %s: %s", resp.Status, body)
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != wsAcceptKey(key) {
+		return nil, errors.New("handshake rejected: bad Sec-WebSocket-Accept")
+	}
+	if p := resp.Header.Get("Sec-WebSocket-Protocol"); p != wire.WebSocketSubprotocol {
+		return nil, fmt.Errorf("handshake rejected: subprotocol %q, want %q", p, wire.WebSocketSubprotocol)
+	}
+
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		return nil, fmt.Errorf("set deadline: %w", err)
+	}
+	return &wsTransport{conn: conn, br: br}, nil
+}
+
+func wsAcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + wsAcceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// wsTransport is the client side of a WebSocket carrying one protocol
+// frame per binary message.
+type wsTransport struct {
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+func (t *wsTransport) WriteFrame(h wire.Header, payload []byte) error {
+	msg := append(wire.AppendHeader(make([]byte, 0, wire.HeaderSize+len(payload)), h), payload...)
+	return t.writeMessage(wsOpBinary, msg)
+}
+
+func (t *wsTransport) ReadFrame() (wire.Header, []byte, error) {
+	msg, err := t.readMessage()
+	if err != nil {
+		return wire.Header{}, nil, fmt.Errorf("read frame: %w", err)
+	}
+	h, err := wire.ParseHeader(msg)
+	if err != nil {
+		return wire.Header{}, nil, fmt.Errorf("read frame: %w", err)
+	}
+	if uint64(h.Length) != uint64(len(msg)-wire.HeaderSize) {
+		return wire.Header{}, nil, fmt.Errorf("read frame: message holds %d payload bytes, header says %d",
+			len(msg)-wire.HeaderSize, h.Length)
+	}
+	return h, msg[wire.HeaderSize:], nil
+}
+
+func (t *wsTransport) SetDeadline(d time.Time) error {
+	return t.conn.SetDeadline(d)
+}
+
+// Close sends a close frame on a best-effort basis and closes the
+// connection without waiting for the peer's reply.
+func (t *wsTransport) Close() error {
+	_ = t.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	_ = t.writeMessage(wsOpClose, binary.BigEndian.AppendUint16(nil, 1000))
+	return t.conn.Close()
+}
+
+// writeMessage sends data as a single masked frame, as clients must.
+func (t *wsTransport) writeMessage(op byte, data []byte) error {
+	buf := make([]byte, 0, 14+len(data))
+	buf = append(buf, 0x80|op)
+	switch n := len(data); {
+	case n < 126:
+		buf = append(buf, 0x80|byte(n))
+	case n <= 0xFFFF:
+		buf = append(buf, 0x80|126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+	default:
+		buf = append(buf, 0x80|127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(n))
+	}
+	var mask [4]byte
+	if _, err := rand.Read(mask[:]); err != nil {
+		return err
+	}
+	buf = append(buf, mask[:]...)
+	start := len(buf)
+	buf = append(buf, data...)
+	for i := range data {
+		buf[start+i] ^= mask[i%4]
+	}
+	_, err := t.conn.Write(buf)
+	return err
+}
+
+// readMessage returns the next binary message, reassembling fragments and
+// answering pings on the way. A close frame reads as io.EOF.
+func (t *wsTransport) readMessage() ([]byte, error) {
+	var msg []byte
+	for {
+		fin, op, data, err := t.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case wsOpPing:
+			if err := t.writeMessage(wsOpPong, data); err != nil {
+				return nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			return nil, fmt.Errorf("websocket closed by server: %w", io.EOF)
+		case wsOpBinary, wsOpContinuation:
+			if len(msg)+len(data) > wsMaxMessage {
+				return nil, fmt.Errorf("websocket message exceeds the protocol limit of %d bytes", wsMaxMessage)
+			}
+			msg = append(msg, data...)
+		default:
+			return nil, fmt.Errorf("unexpected websocket opcode %#x", op)
+		}
+		if fin {
+			return msg, nil
+		}
+	}
+}
+
+func (t *wsTransport) readFrame() (fin bool, op byte, data []byte, err error) {
+	var head [2]byte
+	if _, err := io.ReadFull(t.br, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin, op = head[0]&0x80 != 0, head[0]&0x0F
+	masked := head[1]&0x80 != 0
+
+	n := uint64(head[1] & 0x7F)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(t.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(t.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	// One protocol frame per message bounds every websocket frame.
+	if n > wsMaxMessage {
+		return false, 0, nil, fmt.Errorf("websocket frame of %d bytes exceeds the protocol limit", n)
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(t.br, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	data = make([]byte, n)
+	if _, err := io.ReadFull(t.br, data); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range data {
+			data[i] ^= mask[i%4]
+		}
+	}
+	return fin, op, data, nil
+}
@@ -0,0 +1,165 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !js
+
+package cxdb
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/strongdm/ai-cxdb/clients/go/wire"
+)
+
+// wsServer upgrades requests carrying the expected token and answers each
+// frame with handler. Responses are preceded by a ping and split into two
+// fragments to exercise the client's reassembly.
+func wsServer(t *testing.T, token string, handler fakeHandler) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("Sec-WebSocket-Protocol") != wire.WebSocketSubprotocol {
+			http.Error(w, "bad subprotocol", http.StatusBadRequest)
+			return
+		}
+		conn, brw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		_, _ = brw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+			"Upgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + wsAcceptKey(r.Header.Get("Sec-WebSocket-Key")) + "\r\n" +
+			"Sec-WebSocket-Protocol: " + wire.WebSocketSubprotocol + "\r\n\r\n")
+		if err := brw.Flush(); err != nil {
+			return
+		}
+
+		peer := &wsTransport{conn: conn, br: brw.Reader}
+		for {
+			msg, err := peer.readMessage()
+			if err != nil {
+				return
+			}
+			h, _ := wire.ParseHeader(msg)
+			respType, flags, payload := handler(h.MsgType, msg[wire.HeaderSize:])
+			resp := wire.AppendFrame(nil, respType, flags, h.ReqID, payload)
+			half := len(resp) / 2
+			out := wsServerFrame(nil, false, wsOpPing, []byte("hi"))
+			out = wsServerFrame(out, false, wsOpBinary, resp[:half])
+			out = wsServerFrame(out, true, wsOpContinuation, resp[half:])
+			if _, err := conn.Write(out); err != nil {
+				return
+			}
+			// The client answers the ping before reading on.
+			if _, op, _, err := peer.readFrame(); err != nil || op != wsOpPong {
+				t.Errorf("expected pong, got op %#x, %v", op, err)
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// wsServerFrame appends an unmasked frame, as servers send them. Control
+// frames are always final.
+func wsServerFrame(b []byte, fin bool, op byte, data []byte) []byte {
+	if fin || op >= wsOpClose {
+		op |= 0x80
+	}
+	b = append(b, op)
+	if len(data) < 126 {
+		b = append(b, byte(len(data)))
+	} else {
+		b = append(b, 126)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(data)))
+	}
+	return append(b, data...)
+}
+
+func TestDialWebSocket(t *testing.T) {
+	srv := wsServer(t, "secret", func(msgType uint16, p []byte) (uint16, uint16, []byte) {
+		switch msgType {
+		case wire.MsgHello:
+			hello := make([]byte, 10)
+			binary.LittleEndian.PutUint64(hello, 77)
+			return msgType, 0, hello
+		case wire.MsgPutBlob:
+			return msgType, 0, append(append([]byte(nil), p[:32]...), 1)
+		}
+		return wire.MsgError, 0, wire.AppendError(nil, wire.CodeInvalidInput, "unexpected message")
+	})
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/v1/binary"
+
+	c, err := DialWebSocket(url, WithWebSocketHeader("Authorization", "Bearer secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = c.Close() }()
+	if c.SessionID() != 77 {
+		t.Errorf("session = %d", c.SessionID())
+	}
+
+	// Large enough for a 16-bit websocket length.
+	data := []byte(strings.Repeat("blob", 100))
+	res, err := c.PutBlob(context.Background(), &PutBlobRequest{Data: data})
+	if err != nil || !res.WasNew {
+		t.Fatalf("PutBlob = %+v, %v", res, err)
+	}
+	var se *ServerError
+	if _, err := c.GetHead(context.Background(), 1); !errors.As(err, &se) || se.Code != 422 {
+		t.Errorf("GetHead err = %v, want server error 422", err)
+	}
+
+	if _, err := DialWebSocket(url); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("dial without token: %v", err)
+	}
+	if _, err := DialWebSocket(srv.URL); err == nil {
+		t.Error("http:// URL accepted")
+	}
+}
+
+func TestWebSocketCloseIsConnectionError(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer func() { _ = serverConn.Close() }()
+	go func() {
+		_, _ = serverConn.Write(wsServerFrame(nil, true, wsOpClose, nil))
+		_, _ = io.Copy(io.Discard, serverConn)
+	}()
+	tr := &wsTransport{conn: clientConn, br: bufio.NewReader(clientConn)}
+	defer func() { _ = tr.Close() }()
+
+	if _, _, err := tr.ReadFrame(); !isConnectionError(err) {
+		t.Errorf("ReadFrame after close frame = %v, want a connection error", err)
+	}
+}
+
+func TestWebSocketRejectsOversizeFrames(t *testing.T) {
+	for _, n := range []uint64{wsMaxMessage + 1, ^uint64(0)} {
+		clientConn, serverConn := net.Pipe()
+		go func() {
+			head := binary.BigEndian.AppendUint64([]byte{0x80 | wsOpBinary, 127}, n)
+			_, _ = serverConn.Write(head)
+			_, _ = io.Copy(io.Discard, serverConn)
+		}()
+		tr := &wsTransport{conn: clientConn, br: bufio.NewReader(clientConn)}
+		if _, err := tr.readMessage(); err == nil || !strings.Contains(err.Error(), "exceeds the protocol limit") {
+			t.Errorf("length %d: err = %v, want a protocol limit error", n, err)
+		}
+		_ = tr.Close()
+		_ = serverConn.Close()
+	}
+}
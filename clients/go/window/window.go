@@ -0,0 +1,510 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+// Package window rebuilds a provider-ready prompt window from a CXDB context.
+//
+// It walks a context backward from its head, decodes canonical
+// ConversationItem turns, and fits them into a token budget:
+//
+//  1. System items are pinned and always kept.
+//  2. The newest messages (WithKeepLast) are always kept.
+//  3. Old tool outputs are replaced with a short placeholder, oldest first.
+//  4. Whole exchanges (an assistant message plus its tool results) are
+//     dropped, oldest first, until the window fits.
+//
+// Tool results are never separated from the assistant message that issued the
+// call, so the output can be sent to providers that validate tool pairing.
+// Results whose call is not in the history read are left out.
+//
+// Assemble reads back to the context's first turn, where the system prompt
+// usually is, before applying the budget; WithMaxTurns bounds that read.
+//
+// # Basic Usage
+//
+//	w, err := window.Assemble(ctx, client, contextID,
+//	    window.WithTokenBudget(100_000),
+//	)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, m := range w.Messages {
+//	    fmt.Println(m.Role, m.Content)
+//	}
+package window
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	cxdb "github.com/strongdm/ai-cxdb/clients/go"
+	"github.com/strongdm/ai-cxdb/clients/go/tokens"
+	"github.com/strongdm/ai-cxdb/clients/go/types"
+)
+
+// Role is the provider-neutral speaker of a message.
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+	RoleTool      Role = "tool"
+)
+
+// DefaultToolOutputPlaceholder replaces tool output dropped to fit the budget.
+const DefaultToolOutputPlaceholder = "[tool output omitted to fit context window]"
+
+// ToolCall is a tool invocation requested by the assistant.
+type ToolCall struct {
+	ID   string
+	Name string
+	Args string // JSON-encoded arguments
+}
+
+// Message is one entry of the assembled window.
+type Message struct {
+	Role    Role
+	Content string
+
+	// ToolCalls is set on assistant messages that invoked tools.
+	ToolCalls []ToolCall
+
+	// ToolCallID and Name identify the call a tool message answers.
+	ToolCallID string
+	Name       string
+
+	// TurnID and Depth locate the source turn in the context.
+	TurnID uint64
+	Depth  uint32
+}
+
+// Entry is a decoded canonical turn.
+type Entry struct {
+	TurnID uint64
+	Depth  uint32
+	Item   *types.ConversationItem
+}
+
+// Window is the result of fitting a context into a token budget.
+type Window struct {
+	// Messages in chronological order.
+	Messages []Message
+
+	// Tokens is the estimated size of Messages.
+	Tokens int
+
+	// TurnsScanned is the number of turns read from the context.
+	TurnsScanned int
+
+	// ToolOutputsElided counts tool outputs replaced by the placeholder.
+	ToolOutputsElided int
+
+	// MessagesDropped counts messages removed entirely.
+	MessagesDropped int
+
+	// OverBudget is set when pinned and kept messages alone exceed the budget.
+	OverBudget bool
+
+	// Partial is set when WithMaxTurns stopped Assemble before the first
+	// turn of the context, so older system items may be missing.
+	Partial bool
+}
+
+// Truncated reports whether any history was elided or dropped.
+func (w *Window) Truncated() bool {
+	return w.ToolOutputsElided > 0 || w.MessagesDropped > 0
+}
+
+// Option configures window assembly.
+type Option func(*options)
+
+type options struct {
+	budget          int
+	estimate        func(Message) int
+	keepLast        int
+	pageSize        uint32
+	maxTurns        uint32
+	placeholder     string
+	includeHandoffs bool
+}
+
+func defaultOptions() options {
+	return options{
+		estimate:        EstimateTokens,
+		keepLast:        2,
+		pageSize:        64,
+		maxTurns:        4096,
+		placeholder:     DefaultToolOutputPlaceholder,
+		includeHandoffs: true,
+	}
+}
+
+// WithTokenBudget sets the maximum estimated tokens in the window.
+// Zero (the default) keeps everything.
+func WithTokenBudget(tokens int) Option {
+	return func(o *options) {
+		o.budget = tokens
+	}
+}
+
+// WithTokenEstimator replaces the default per-message token estimate.
+func WithTokenEstimator(fn func(Message) int) Option {
+	return func(o *options) {
+		if fn != nil {
+			o.estimate = fn
+		}
+	}
+}
+
+// WithKeepLast sets how many of the newest messages are kept regardless of
+// the budget (default 2). Tool results attached to a kept assistant message
+// are kept with it.
+func WithKeepLast(n int) Option {
+	return func(o *options) {
+		o.keepLast = n
+	}
+}
+
+// WithPageSize sets how many turns Assemble fetches first (default 64). The
+// fetch doubles until it reaches the first turn or WithMaxTurns.
+func WithPageSize(n uint32) Option {
+	return func(o *options) {
+		o.pageSize = n
+	}
+}
+
+// WithMaxTurns bounds how many turns Assemble will read (default 4096).
+// Windows of longer contexts are marked Partial.
+func WithMaxTurns(n uint32) Option {
+	return func(o *options) {
+		o.maxTurns = n
+	}
+}
+
+// WithToolOutputPlaceholder sets the text that replaces elided tool output.
+func WithToolOutputPlaceholder(s string) Option {
+	return func(o *options) {
+		o.placeholder = s
+	}
+}
+
+// WithHandoffs controls whether agent handoffs appear as system notes (default true).
+func WithHandoffs(include bool) Option {
+	return func(o *options) {
+		o.includeHandoffs = include
+	}
+}
+
+// WithTokenCounter counts message tokens with c, e.g. a tiktoken encoding,
+// instead of the default heuristic.
+func WithTokenCounter(c tokens.Counter) Option {
+	return func(o *options) {
+		if c != nil {
+			o.estimate = CounterEstimator(c)
+		}
+	}
+}
+
+// messageOverhead approximates the role and separator tokens providers add
+// around each message.
+const messageOverhead = 4
+
+// CounterEstimator returns an estimator that counts a message's content,
+// name, and tool calls with c, plus a fixed per-message overhead.
+func CounterEstimator(c tokens.Counter) func(Message) int {
+	return func(m Message) int {
+		n := messageOverhead + c.CountTokens(m.Content)
+		if m.Name != "" {
+			n += c.CountTokens(m.Name)
+		}
+		for _, tc := range m.ToolCalls {
+			n += c.CountTokens(tc.Name) + c.CountTokens(tc.Args)
+		}
+		return n
+	}
+}
+
+// EstimateTokens is the default estimator, based on tokens.Approx.
+func EstimateTokens(m Message) int {
+	return CounterEstimator(tokens.Approx)(m)
+}
+
+// TurnReader is the read side of cxdb.Client and cxdb.ReconnectingClient.
+type TurnReader interface {
+	GetLast(ctx context.Context, contextID uint64, opts cxdb.GetLastOptions) ([]cxdb.TurnRecord, error)
+}
+
+// Assemble reads a context back to its first turn and fits it into a
+// window. System items anywhere in the history are kept, so the read does
+// not stop early once the budget is filled.
+func Assemble(ctx context.Context, client TurnReader, contextID uint64, opts ...Option) (*Window, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	limit := o.pageSize
+	if limit == 0 {
+		limit = defaultOptions().pageSize
+	}
+	for {
+		if o.maxTurns > 0 && limit > o.maxTurns {
+			limit = o.maxTurns
+		}
+		turns, err := client.GetLast(ctx, contextID, cxdb.GetLastOptions{Limit: limit, IncludePayload: true})
+		if err != nil {
+			return nil, fmt.Errorf("assemble window: %w", err)
+		}
+		entries, err := Decode(turns)
+		if err != nil {
+			return nil, fmt.Errorf("assemble window: %w", err)
+		}
+		// The first turn of a context is at depth 0.
+		atRoot := uint32(len(turns)) < limit || turns[0].Depth == 0
+		atMax := o.maxTurns > 0 && limit >= o.maxTurns
+		if atRoot || atMax {
+			w := build(entries, o)
+			w.TurnsScanned = len(turns)
+			w.Partial = !atRoot
+			return w, nil
+		}
+		limit *= 2
+	}
+}
+
+// Decode converts turn records into canonical entries. Turns with other
+// type IDs are skipped; undecodable ConversationItem payloads are an error.
+func Decode(turns []cxdb.TurnRecord) ([]Entry, error) {
+	entries := make([]Entry, 0, len(turns))
+	for _, t := range turns {
+		if t.TypeID != types.TypeIDConversationItem && t.TypeID != types.TypeIDConversationItemLegacy {
+			continue
+		}
+		var item types.ConversationItem
+		if err := cxdb.DecodeMsgpackInto(t.Payload, &item); err != nil {
+			return nil, fmt.Errorf("decode turn %d: %w", t.TurnID, err)
+		}
+		entries = append(entries, Entry{TurnID: t.TurnID, Depth: t.Depth, Item: &item})
+	}
+	return entries, nil
+}
+
+// Build fits already-decoded entries (oldest first) into a window.
+func Build(entries []Entry, opts ...Option) *Window {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return build(entries, o)
+}
+
+// group is the unit of dropping: a message plus any tool results that
+// answer its tool calls.
+type group struct {
+	msgs   []Message
+	pinned bool
+	kept   bool
+}
+
+func build(entries []Entry, o options) *Window {
+	var groups []*group
+	// Results join the group of the call they answer, wherever it is:
+	// parallel legacy ToolCall items put other calls between a call and
+	// its result.
+	callers := make(map[string]*group)
+	for _, e := range entries {
+		for _, m := range toMessages(e, o) {
+			if m.Role == RoleTool {
+				// A result whose call is not in entries cannot be paired,
+				// and providers reject it on its own.
+				if g := callers[m.ToolCallID]; g != nil {
+					g.msgs = append(g.msgs, m)
+				}
+				continue
+			}
+			g := &group{msgs: []Message{m}, pinned: m.Role == RoleSystem}
+			for _, tc := range m.ToolCalls {
+				if tc.ID != "" {
+					callers[tc.ID] = g
+				}
+			}
+			groups = append(groups, g)
+		}
+	}
+
+	// Mark the newest groups as kept until keepLast messages are covered.
+	covered := 0
+	for i := len(groups) - 1; i >= 0 && covered < o.keepLast; i-- {
+		groups[i].kept = true
+		covered += len(groups[i].msgs)
+	}
+
+	w := &Window{}
+	total := 0
+	for _, g := range groups {
+		for _, m := range g.msgs {
+			total += o.estimate(m)
+		}
+	}
+
+	if o.budget > 0 && total > o.budget {
+		// Pass 1: elide tool outputs, oldest first.
+		for _, g := range groups {
+			if total <= o.budget {
+				break
+			}
+			if g.kept {
+				continue
+			}
+			for i := range g.msgs {
+				m := &g.msgs[i]
+				if m.Role != RoleTool || m.Content == o.placeholder {
+					continue
+				}
+				before := o.estimate(*m)
+				m.Content = o.placeholder
+				total -= before - o.estimate(*m)
+				w.ToolOutputsElided++
+				if total <= o.budget {
+					break
+				}
+			}
+		}
+		// Pass 2: drop whole groups, oldest first.
+		for i, g := range groups {
+			if total <= o.budget {
+				break
+			}
+			if g.kept || g.pinned {
+				continue
+			}
+			for _, m := range g.msgs {
+				total -= o.estimate(m)
+			}
+			w.MessagesDropped += len(g.msgs)
+			groups[i] = nil
+		}
+		w.OverBudget = total > o.budget
+	}
+
+	for _, g := range groups {
+		if g != nil {
+			w.Messages = append(w.Messages, g.msgs...)
+		}
+	}
+	w.Tokens = total
+	return w
+}
+
+// toMessages maps a canonical item to zero or more messages.
+func toMessages(e Entry, o options) []Message {
+	item := e.Item
+	base := Message{TurnID: e.TurnID, Depth: e.Depth}
+	with := func(role Role, content string) Message {
+		m := base
+		m.Role = role
+		m.Content = content
+		return m
+	}
+
+	switch item.ItemType {
+	case types.ItemTypeUserInput:
+		if item.UserInput == nil {
+			return nil
+		}
+		content := item.UserInput.Text
+		if len(item.UserInput.Files) > 0 {
+			content += "\n\nFiles: " + strings.Join(item.UserInput.Files, ", ")
+		}
+		return []Message{with(RoleUser, content)}
+
+	case types.ItemTypeAssistantTurn:
+		if item.Turn == nil {
+			return nil
+		}
+		m := with(RoleAssistant, item.Turn.Text)
+		var results []Message
+		for _, tc := range item.Turn.ToolCalls {
+			m.ToolCalls = append(m.ToolCalls, ToolCall{ID: tc.ID, Name: tc.Name, Args: tc.Args})
+			if out, ok := toolCallOutput(tc); ok {
+				r := with(RoleTool, out)
+				r.ToolCallID = tc.ID
+				r.Name = tc.Name
+				results = append(results, r)
+			}
+		}
+		if m.Content == "" && len(m.ToolCalls) == 0 {
+			return nil
+		}
+		return append([]Message{m}, results...)
+
+	case types.ItemTypeSystem:
+		if item.System == nil {
+			return nil
+		}
+		content := item.System.Content
+		if item.System.Title != "" {
+			content = item.System.Title + "\n\n" + content
+		}
+		return []Message{with(RoleSystem, content)}
+
+	case types.ItemTypeHandoff:
+		if item.Handoff == nil || !o.includeHandoffs {
+			return nil
+		}
+		h := item.Handoff
+		content := fmt.Sprintf("Handoff from %s to %s.", h.FromAgent, h.ToAgent)
+		if h.Reason != "" {
+			content += " Reason: " + h.Reason
+		}
+		if h.Input != "" {
+			content += "\n\n" + h.Input
+		}
+		return []Message{with(RoleSystem, content)}
+
+	case types.ItemTypeAssistant:
+		if item.Assistant == nil {
+			return nil
+		}
+		return []Message{with(RoleAssistant, item.Assistant.Text)}
+
+	case types.ItemTypeToolCall:
+		if item.ToolCall == nil {
+			return nil
+		}
+		m := with(RoleAssistant, "")
+		m.ToolCalls = []ToolCall{{ID: item.ToolCall.CallID, Name: item.ToolCall.Name, Args: item.ToolCall.Args}}
+		return []Message{m}
+
+	case types.ItemTypeToolResult:
+		if item.ToolResult == nil {
+			return nil
+		}
+		content := item.ToolResult.Content
+		if content == "" {
+			content = item.ToolResult.StreamingOutput
+		}
+		if item.ToolResult.IsError {
+			content = "error: " + content
+		}
+		m := with(RoleTool, content)
+		m.ToolCallID = item.ToolResult.CallID
+		return []Message{m}
+	}
+	return nil
+}
+
+// toolCallOutput returns the content a provider should see for a tool call,
+// or false if the call has not produced anything yet.
+func toolCallOutput(tc types.ToolCallItem) (string, bool) {
+	switch {
+	case tc.Result != nil:
+		return tc.Result.Content, true
+	case tc.Error != nil:
+		return "error: " + tc.Error.Message, true
+	case tc.StreamingOutput != "":
+		return tc.StreamingOutput, true
+	}
+	return "", false
+}
@@ -0,0 +1,292 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package window
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	cxdb "github.com/strongdm/ai-cxdb/clients/go"
+	"github.com/strongdm/ai-cxdb/clients/go/tokens"
+	"github.com/strongdm/ai-cxdb/clients/go/types"
+)
+
+func entries(items ...*types.ConversationItem) []Entry {
+	out := make([]Entry, len(items))
+	for i, item := range items {
+		out[i] = Entry{TurnID: uint64(i + 1), Depth: uint32(i + 1), Item: item}
+	}
+	return out
+}
+
+func toolTurn(text, callID, output string) *types.ConversationItem {
+	return types.BuildAssistantTurn(text).
+		WithToolCall(types.BuildToolCallItem(callID, "shell", `{"cmd":"ls"}`).WithResult(output, nil).Build()).
+		Build()
+}
+
+func roles(msgs []Message) string {
+	parts := make([]string, len(msgs))
+	for i, m := range msgs {
+		parts[i] = string(m.Role)
+	}
+	return strings.Join(parts, ",")
+}
+
+// =============================================================================
+// Conversion
+// =============================================================================
+
+func TestBuildConvertsItems(t *testing.T) {
+	w := Build(entries(
+		types.NewSystemInfo("be brief"),
+		types.NewUserInput("list files"),
+		toolTurn("checking", "call-1", "a.txt\nb.txt"),
+		types.NewAssistantTurn("two files"),
+	))
+
+	if got, want := roles(w.Messages), "system,user,assistant,tool,assistant"; got != want {
+		t.Fatalf("roles = %s, want %s", got, want)
+	}
+	call := w.Messages[2]
+	if len(call.ToolCalls) != 1 || call.ToolCalls[0].ID != "call-1" {
+		t.Errorf("tool calls = %+v", call.ToolCalls)
+	}
+	result := w.Messages[3]
+	if result.ToolCallID != "call-1" || result.Content != "a.txt\nb.txt" || result.TurnID != 3 {
+		t.Errorf("tool result = %+v", result)
+	}
+	if w.Truncated() {
+		t.Error("unbudgeted window should not be truncated")
+	}
+}
+
+// =============================================================================
+// Budget policy
+// =============================================================================
+
+func TestBuildElidesToolOutputFirst(t *testing.T) {
+	big := strings.Repeat("x", 4000)
+	es := entries(
+		types.NewUserInput("go"),
+		toolTurn("", "call-1", big),
+		types.NewAssistantTurn("done"),
+	)
+	full := Build(es)
+
+	w := Build(es, WithTokenBudget(full.Tokens-500), WithKeepLast(1))
+	if w.ToolOutputsElided != 1 || w.MessagesDropped != 0 {
+		t.Fatalf("elided=%d dropped=%d, want 1/0", w.ToolOutputsElided, w.MessagesDropped)
+	}
+	if got := w.Messages[2].Content; got != DefaultToolOutputPlaceholder {
+		t.Errorf("tool content = %q", got)
+	}
+	if w.Tokens > full.Tokens-500 {
+		t.Errorf("tokens %d exceed budget", w.Tokens)
+	}
+}
+
+func TestBuildDropsOldestExchangesKeepingPairs(t *testing.T) {
+	es := entries(
+		types.NewSystemInfo("system prompt"),
+		types.NewUserInput(strings.Repeat("old ", 200)),
+		toolTurn("", "call-1", "out"),
+		types.NewUserInput("latest question"),
+		types.NewAssistantTurn("latest answer"),
+	)
+
+	w := Build(es, WithTokenBudget(40), WithKeepLast(2))
+	if got, want := roles(w.Messages), "system,user,assistant"; got != want {
+		t.Fatalf("roles = %s, want %s", got, want)
+	}
+	if w.Messages[1].Content != "latest question" {
+		t.Errorf("kept %q", w.Messages[1].Content)
+	}
+	if w.MessagesDropped != 3 {
+		t.Errorf("dropped = %d, want 3", w.MessagesDropped)
+	}
+	for _, m := range w.Messages {
+		if m.Role == RoleTool {
+			t.Errorf("orphan tool message survived: %+v", m)
+		}
+	}
+}
+
+func TestBuildOverBudgetKeepsPinned(t *testing.T) {
+	w := Build(entries(
+		types.NewSystemInfo(strings.Repeat("rules ", 100)),
+		types.NewUserInput("hi"),
+	), WithTokenBudget(10))
+	if !w.OverBudget {
+		t.Error("expected OverBudget")
+	}
+	if got, want := roles(w.Messages), "system,user"; got != want {
+		t.Errorf("roles = %s, want %s", got, want)
+	}
+}
+
+func TestBuildCustomEstimator(t *testing.T) {
+	perMessage := func(Message) int { return 1 }
+	w := Build(entries(
+		types.NewUserInput("a"),
+		types.NewAssistantTurn("b"),
+		types.NewUserInput("c"),
+		types.NewAssistantTurn("d"),
+	), WithTokenBudget(2), WithTokenEstimator(perMessage), WithKeepLast(1))
+	if w.Tokens != 2 || len(w.Messages) != 2 || w.Messages[0].Content != "c" {
+		t.Errorf("unexpected window %+v", w)
+	}
+}
+
+func TestBuildTokenCounter(t *testing.T) {
+	words := tokens.CounterFunc(func(s string) int { return len(strings.Fields(s)) })
+	w := Build(entries(
+		types.NewUserInput("one two three"),
+		types.NewAssistantTurn("four five"),
+	), WithTokenCounter(words))
+	if want := (messageOverhead + 3) + (messageOverhead + 2); w.Tokens != want {
+		t.Errorf("tokens = %d, want %d", w.Tokens, want)
+	}
+}
+
+// =============================================================================
+// Decode
+// =============================================================================
+
+func TestDecodeSkipsForeignTypes(t *testing.T) {
+	payload, err := cxdb.EncodeMsgpack(types.NewUserInput("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decode([]cxdb.TurnRecord{
+		{TurnID: 1, TypeID: "com.example.Other", Payload: []byte{0x80}},
+		{TurnID: 2, Depth: 2, TypeID: types.TypeIDConversationItem, Payload: payload},
+	})
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(got) != 1 || got[0].TurnID != 2 || got[0].Item.UserInput.Text != "hello" {
+		t.Errorf("unexpected entries %+v", got)
+	}
+}
+
+func TestBuildPairsParallelToolCalls(t *testing.T) {
+	call := func(id string) *types.ConversationItem {
+		return types.NewToolCall(id, "shell", `{"cmd":"ls"}`)
+	}
+	result := func(id, out string) *types.ConversationItem {
+		return types.NewToolResult(id, out, false)
+	}
+	es := entries(
+		types.NewUserInput("check both"),
+		call("call-a"),
+		call("call-b"),
+		result("call-b", strings.Repeat("b ", 400)),
+		result("call-a", strings.Repeat("a ", 400)),
+		types.NewUserInput("latest question"),
+		types.NewAssistantTurn("latest answer"),
+	)
+
+	// Each result follows its own call.
+	w := Build(es)
+	if got, want := roles(w.Messages), "user,assistant,tool,assistant,tool,user,assistant"; got != want {
+		t.Fatalf("roles = %s, want %s", got, want)
+	}
+	for _, i := range []int{1, 3} {
+		if w.Messages[i].ToolCalls[0].ID != w.Messages[i+1].ToolCallID {
+			t.Errorf("message %d: call %+v followed by result for %q", i, w.Messages[i].ToolCalls, w.Messages[i+1].ToolCallID)
+		}
+	}
+
+	// Trimming drops calls and results together.
+	w = Build(es, WithTokenBudget(30), WithKeepLast(2))
+	if got, want := roles(w.Messages), "user,assistant"; got != want {
+		t.Fatalf("trimmed roles = %s, want %s", got, want)
+	}
+}
+
+func TestBuildDropsOrphanToolResults(t *testing.T) {
+	// The call for this result is older than the history that was read.
+	es := entries(
+		types.NewToolResult("call-old", "stale output", false),
+		types.NewUserInput("next"),
+		types.NewAssistantTurn("done"),
+	)
+	w := Build(es)
+	if got, want := roles(w.Messages), "user,assistant"; got != want {
+		t.Fatalf("roles = %s, want %s", got, want)
+	}
+}
+
+// =============================================================================
+// Assemble
+// =============================================================================
+
+// history is a TurnReader over one in-memory context.
+type history struct {
+	turns []cxdb.TurnRecord
+	reads int
+}
+
+func newHistory(t *testing.T, items ...*types.ConversationItem) *history {
+	t.Helper()
+	h := &history{}
+	for i, item := range items {
+		payload, err := cxdb.EncodeMsgpack(item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.turns = append(h.turns, cxdb.TurnRecord{
+			TurnID:      uint64(i + 1),
+			Depth:       uint32(i), // the server puts the first turn at depth 0
+			TypeID:      types.TypeIDConversationItem,
+			TypeVersion: types.TypeVersionConversationItem,
+			Encoding:    cxdb.EncodingMsgpack,
+			Payload:     payload,
+		})
+	}
+	return h
+}
+
+func (h *history) GetLast(_ context.Context, _ uint64, opts cxdb.GetLastOptions) ([]cxdb.TurnRecord, error) {
+	h.reads++
+	turns := h.turns
+	if n := int(opts.Limit); n < len(turns) {
+		turns = turns[len(turns)-n:]
+	}
+	return turns, nil
+}
+
+func TestAssembleReadsToSystemPrompt(t *testing.T) {
+	items := []*types.ConversationItem{types.NewSystemInfo("you are a helpful assistant")}
+	for i := 0; i < 20; i++ {
+		items = append(items, types.NewUserInput(strings.Repeat("question ", 50)), types.NewAssistantTurn(strings.Repeat("answer ", 50)))
+	}
+	h := newHistory(t, items...)
+
+	// The first page alone overflows the budget; the system prompt is older.
+	w, err := Assemble(context.Background(), h, 1, WithTokenBudget(300), WithKeepLast(2), WithPageSize(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.TurnsScanned != len(items) || w.Partial || h.reads < 2 {
+		t.Fatalf("scanned %d of %d turns in %d reads, partial %v", w.TurnsScanned, len(items), h.reads, w.Partial)
+	}
+	if len(w.Messages) == 0 || w.Messages[0].Role != RoleSystem {
+		t.Fatalf("roles = %s, want the system prompt first", roles(w.Messages))
+	}
+	if !w.Truncated() {
+		t.Error("window was not trimmed to the budget")
+	}
+
+	// WithMaxTurns stops short of the first turn and says so.
+	w, err = Assemble(context.Background(), h, 1, WithPageSize(4), WithMaxTurns(8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.TurnsScanned != 8 || !w.Partial {
+		t.Fatalf("scanned %d turns, partial %v", w.TurnsScanned, w.Partial)
+	}
+}
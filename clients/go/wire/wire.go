@@ -0,0 +1,217 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+// Package wire defines the constants and frame layout of the CXDB binary
+// protocol. The client, the fixture generators, and the conformance tests
+// all take their numbers from here, so the golden fixtures consumed by other
+// client implementations cannot drift from what the Go client sends.
+//
+// A frame is a 16-byte little-endian header followed by the payload:
+//
+//	len:u32  msg_type:u16  flags:u16  req_id:u64  payload:[len]byte
+//
+// All integers in headers and payloads are little-endian.
+package wire
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Message types. Responses reuse the request's type, except MsgError.
+const (
+	MsgHello     uint16 = 1
+	MsgCtxCreate uint16 = 2
+	MsgCtxFork   uint16 = 3
+	MsgGetHead   uint16 = 4
+	MsgAppend    uint16 = 5
+	MsgGetLast   uint16 = 6
+	MsgGetBlob   uint16 = 9
+	MsgAttachFs  uint16 = 10
+	MsgPutBlob   uint16 = 11
+	MsgError     uint16 = 255
+)
+
+// ProtocolVersion is the version sent in HELLO.
+const ProtocolVersion uint16 = 1
+
+// Request flags. Requests and responses use separate bit assignments; new
+// bits are appended, never reused, so older peers can ignore unknown bits.
+const (
+	// FlagHasFsRoot marks an append payload that ends with a 32-byte
+	// filesystem root hash.
+	FlagHasFsRoot uint16 = 1 << 0
+
+	// FlagBlobRange marks a GET_BLOB payload that carries a byte range
+	// after the hash.
+	FlagBlobRange uint16 = 1 << 1
+
+	// FlagBlobProbe marks a PUT_BLOB payload that carries only the hash and
+	// length. The server acknowledges it if it has the blob and answers
+	// CodeNotFound otherwise.
+	FlagBlobProbe uint16 = 1 << 2
+
+	// FlagIfHeadChanged marks a GET_LAST payload that ends with the head
+	// turn ID the client last saw. If the head is still that turn, the
+	// server answers with no turns and FlagNotModified.
+	FlagIfHeadChanged uint16 = 1 << 3
+
+	// FlagTreeFormat marks an ATTACH_FS payload that ends with the format
+	// version (u8) of the snapshot's tree objects. Clients set it only for
+	// servers that accept it, see CapTreeFormat.
+	FlagTreeFormat uint16 = 1 << 4
+
+	// FlagRoutingKey marks a request payload that starts with a routing
+	// key (key_len:u16, key) for a sharded or proxied server tier. Clients
+	// set it only for servers that accept it, see CapRoutingKeys.
+	FlagRoutingKey uint16 = 1 << 5
+)
+
+// Response flags.
+const (
+	FlagTruncated   uint16 = 1 << 0
+	FlagInheritedFs uint16 = 1 << 1
+	FlagDeprecated  uint16 = 1 << 2
+	FlagNotModified uint16 = 1 << 3
+)
+
+// Payload encodings and compressions of an append.
+const (
+	EncodingMsgpack uint32 = 1
+	EncodingBlobRef uint32 = 2 // payload is a PayloadRef stub
+
+	CompressionNone uint32 = 0
+	CompressionZstd uint32 = 1
+)
+
+// Filesystem tree object formats. A v1 tree is a bare msgpack array of
+// entries; later formats start with their version byte, which can never
+// begin a msgpack array.
+const (
+	TreeFormatV1 uint8 = 1
+	TreeFormatV2 uint8 = 2
+)
+
+// MaxRoutingKeyLen bounds the routing key of a HELLO or a request.
+const MaxRoutingKeyLen = 255
+
+// Capabilities a server may list in the capabilities section of its HELLO
+// response.
+const (
+	// CapRoutingKeys means the server accepts FlagRoutingKey requests.
+	CapRoutingKeys = "routing_keys"
+
+	// CapBlobRanges means the server accepts FlagBlobRange GET_BLOB
+	// requests.
+	CapBlobRanges = "blob_ranges"
+
+	// CapBlobProbe means the server accepts FlagBlobProbe PUT_BLOB
+	// requests.
+	CapBlobProbe = "blob_probe"
+
+	// CapBlobRefPayloads means the server resolves EncodingBlobRef turn
+	// payloads wherever it decodes turns, so clients may append them.
+	CapBlobRefPayloads = "blob_ref_payloads"
+
+	// CapTreeFormat means the server accepts FlagTreeFormat ATTACH_FS
+	// requests.
+	CapTreeFormat = "tree_format"
+)
+
+// Error codes carried by MsgError. They follow HTTP status semantics so the
+// binary and HTTP APIs report the same code for the same failure.
+const (
+	CodeForbidden       uint32 = 403 // gateway tunnel refused a write
+	CodeNotFound        uint32 = 404
+	CodePayloadTooLarge uint32 = 413
+	CodeInvalidInput    uint32 = 422
+	CodeInternal        uint32 = 500
+)
+
+// WebSocketSubprotocol is negotiated by WebSocket transports. Each binary
+// message carries exactly one frame, header included.
+const WebSocketSubprotocol = "cxdb.binary.v1"
+
+// HeaderSize is the length of a frame header.
+const HeaderSize = 16
+
+// MaxFrameSize is the largest payload the server accepts or sends in one
+// frame.
+const MaxFrameSize = 64 << 20
+
+// ErrShortHeader is returned by ParseHeader for fewer than HeaderSize bytes.
+var ErrShortHeader = errors.New("wire: short frame header")
+
+// Header is a decoded frame header.
+type Header struct {
+	Length  uint32 // payload length
+	MsgType uint16
+	Flags   uint16
+	ReqID   uint64
+}
+
+// AppendHeader appends the encoding of h to b.
+func AppendHeader(b []byte, h Header) []byte {
+	b = binary.LittleEndian.AppendUint32(b, h.Length)
+	b = binary.LittleEndian.AppendUint16(b, h.MsgType)
+	b = binary.LittleEndian.AppendUint16(b, h.Flags)
+	return binary.LittleEndian.AppendUint64(b, h.ReqID)
+}
+
+// ParseHeader decodes the first HeaderSize bytes of b.
+func ParseHeader(b []byte) (Header, error) {
+	if len(b) < HeaderSize {
+		return Header{}, ErrShortHeader
+	}
+	return Header{
+		Length:  binary.LittleEndian.Uint32(b[0:4]),
+		MsgType: binary.LittleEndian.Uint16(b[4:6]),
+		Flags:   binary.LittleEndian.Uint16(b[6:8]),
+		ReqID:   binary.LittleEndian.Uint64(b[8:16]),
+	}, nil
+}
+
+// AppendFrame appends a complete frame with the given header fields and
+// payload to b.
+func AppendFrame(b []byte, msgType, flags uint16, reqID uint64, payload []byte) []byte {
+	b = AppendHeader(b, Header{Length: uint32(len(payload)), MsgType: msgType, Flags: flags, ReqID: reqID})
+	return append(b, payload...)
+}
+
+// AppendError appends a MsgError payload: code:u32, detail_len:u32, detail.
+func AppendError(b []byte, code uint32, detail string) []byte {
+	b = binary.LittleEndian.AppendUint32(b, code)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(detail)))
+	return append(b, detail...)
+}
+
+// ParseError decodes a MsgError payload. ok is false if the payload is too
+// short to hold a code; a truncated detail is dropped.
+func ParseError(payload []byte) (code uint32, detail string, ok bool) {
+	if len(payload) < 8 {
+		return 0, "", false
+	}
+	code = binary.LittleEndian.Uint32(payload[0:4])
+	n := binary.LittleEndian.Uint32(payload[4:8])
+	if uint64(n) <= uint64(len(payload)-8) {
+		detail = string(payload[8 : 8+n])
+	}
+	return code, detail, true
+}
+
+// AppendHello appends a HELLO request payload: protocol_version:u16,
+// tag_len:u16, tag, meta_len:u32, meta.
+func AppendHello(b []byte, clientTag string, meta []byte) []byte {
+	b = binary.LittleEndian.AppendUint16(b, ProtocolVersion)
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(clientTag)))
+	b = append(b, clientTag...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(meta)))
+	return append(b, meta...)
+}
+
+// AppendRoutingKey appends the routing key prefix of a FlagRoutingKey
+// request: key_len:u16, key.
+func AppendRoutingKey(b []byte, key string) []byte {
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(key)))
+	return append(b, key...)
+}
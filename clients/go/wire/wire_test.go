@@ -0,0 +1,40 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package wire
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestFrameLayout(t *testing.T) {
+	frame := AppendFrame(nil, MsgAppend, FlagHasFsRoot, 0x0102030405060708, []byte{0xAA, 0xBB})
+	if got, want := hex.EncodeToString(frame), "02000000"+"0500"+"0100"+"0807060504030201"+"aabb"; got != want {
+		t.Fatalf("frame = %s, want %s", got, want)
+	}
+
+	h, err := ParseHeader(frame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h != (Header{Length: 2, MsgType: MsgAppend, Flags: FlagHasFsRoot, ReqID: 0x0102030405060708}) {
+		t.Errorf("header = %+v", h)
+	}
+	if _, err := ParseHeader(frame[:HeaderSize-1]); err != ErrShortHeader {
+		t.Errorf("short header: %v", err)
+	}
+}
+
+func TestErrorPayload(t *testing.T) {
+	payload := AppendError(nil, CodeNotFound, "context not found")
+	if code, detail, ok := ParseError(payload); !ok || code != CodeNotFound || detail != "context not found" {
+		t.Errorf("ParseError = %d %q %v", code, detail, ok)
+	}
+	if code, detail, ok := ParseError(payload[:12]); !ok || code != CodeNotFound || detail != "" {
+		t.Errorf("truncated detail: %d %q %v", code, detail, ok)
+	}
+	if _, _, ok := ParseError(payload[:7]); ok {
+		t.Error("ParseError accepted a 7-byte payload")
+	}
+}
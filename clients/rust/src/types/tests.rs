@@ -183,3 +183,18 @@ fn provenance_env_vars_deep_copy() {
     }
     std::env::remove_var("PATH");
 }
+
+#[test]
+fn msgpack_scalars_match_fixture() {
+    let fixture = load_msgpack_fixture("msgpack_scalars");
+    let fixture_bytes = decode_hex(&fixture.payload_hex);
+    let decoded = decode_msgpack(&fixture_bytes).unwrap();
+    assert_eq!(decoded.get(&1).and_then(|v| v.as_str()), Some("user"));
+    // Millis: unix milliseconds as a plain integer.
+    assert_eq!(decoded.get(&3).and_then(|v| v.as_u64()), Some(1_706_615_000_000));
+    // Bytes: msgpack bin, not an array of integers.
+    assert_eq!(
+        decoded.get(&4).and_then(|v| v.as_slice()),
+        Some(&[0x89u8, 0x50, 0x4e, 0x47][..])
+    );
+}
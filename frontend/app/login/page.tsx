@@ -1,12 +1,29 @@
 'use client';
 
 import { useSearchParams } from 'next/navigation';
-import { Suspense } from 'react';
+import { Suspense, useEffect, useState } from 'react';
 import { Database, AlertCircle } from '@/components/icons';
 
+interface Branding {
+  title: string;
+  org_name?: string;
+  logo_url?: string;
+  help_url?: string;
+}
+
 function LoginContent() {
   const searchParams = useSearchParams();
   const error = searchParams.get('error');
+  const [branding, setBranding] = useState<Branding>({ title: 'CXDB' });
+
+  useEffect(() => {
+    fetch('/auth/branding')
+      .then((res) => (res.ok ? res.json() : null))
+      .then((data: Branding | null) => {
+        if (data) setBranding(data);
+      })
+      .catch(() => {});
+  }, []);
 
   const errorMessages: Record<string, string> = {
     access_denied: 'Access was denied. Please try again.',
@@ -21,11 +38,16 @@ function LoginContent() {
   return (
     <div className="min-h-screen flex items-center justify-center bg-theme-bg">
       <div className="text-center p-10 bg-theme-bg-secondary/50 border border-theme-border-dim rounded-2xl max-w-md w-full mx-4">
-        <div className="w-16 h-16 rounded-2xl bg-purple-600/20 border border-purple-500/30 flex items-center justify-center mx-auto mb-6">
-          <Database className="w-8 h-8 text-purple-400" />
-        </div>
+        {branding.logo_url ? (
+          // eslint-disable-next-line @next/next/no-img-element
+          <img src={branding.logo_url} alt={branding.title} className="max-h-16 max-w-48 mx-auto mb-6" />
+        ) : (
+          <div className="w-16 h-16 rounded-2xl bg-purple-600/20 border border-purple-500/30 flex items-center justify-center mx-auto mb-6">
+            <Database className="w-8 h-8 text-purple-400" />
+          </div>
+        )}
 
-        <h1 className="text-2xl font-semibold text-theme-text mb-2">CXDB</h1>
+        <h1 className="text-2xl font-semibold text-theme-text mb-2">{branding.title}</h1>
         <p className="text-theme-text-dim mb-8">AI Context Store - Authenticated Access</p>
 
         {errorMessage && (
@@ -62,6 +84,14 @@ function LoginContent() {
 
         <p className="text-xs text-theme-text-faint mt-8">
           Access restricted to authorized users only.
+          {branding.help_url && (
+            <>
+              {' '}
+              <a href={branding.help_url} className="text-purple-400 hover:underline">
+                Request access
+              </a>
+            </>
+          )}
         </p>
       </div>
     </div>
@@ -0,0 +1,49 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/strongdm/cxdb/gateway/pkg/openapi"
+)
+
+// openapi-gen renders the gateway's OpenAPI document and the generated Go
+// HTTP client. Run it via `go generate ./pkg/openapi` or `make openapi`.
+func main() {
+	yamlPath := flag.String("yaml", "", "write the OpenAPI document as YAML to this path")
+	jsonPath := flag.String("json", "", "write the OpenAPI document as JSON to this path")
+	clientPath := flag.String("client", "", "write the generated Go client to this path")
+	clientPkg := flag.String("client-package", "httpclient", "package name for the generated Go client")
+	flag.Parse()
+
+	if *yamlPath == "" && *jsonPath == "" && *clientPath == "" {
+		fmt.Fprintln(os.Stderr, "at least one of -yaml, -json, or -client is required")
+		os.Exit(2)
+	}
+
+	if *yamlPath != "" {
+		write(*yamlPath, openapi.YAML)
+	}
+	if *jsonPath != "" {
+		write(*jsonPath, openapi.JSON)
+	}
+	if *clientPath != "" {
+		write(*clientPath, func() ([]byte, error) { return openapi.GenerateClient(*clientPkg) })
+	}
+}
+
+func write(path string, render func() ([]byte, error)) {
+	data, err := render()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "render %s: %v\n", path, err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "write %s: %v\n", path, err)
+		os.Exit(1)
+	}
+}
@@ -11,10 +11,13 @@ import (
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/strongdm/cxdb/gateway/internal/config"
 	"github.com/strongdm/cxdb/gateway/pkg/auth"
 	"github.com/strongdm/cxdb/gateway/pkg/proxy"
+	"github.com/strongdm/cxdb/gateway/pkg/tracing"
+	"github.com/strongdm/cxdb/gateway/pkg/webhook"
 )
 
 // Entry point for the cxdb Gateway server.
@@ -32,6 +35,19 @@ func main() {
 		os.Exit(1)
 	}
 
+	tracer := tracing.New(cfg.Tracing, logger)
+	tracing.SetDefault(tracer)
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := tracer.Shutdown(shutdownCtx); err != nil {
+			logger.Warn("trace_export_shutdown_failed", "err", err)
+		}
+	}()
+	if tracer.Enabled() {
+		logger.Info("tracing_enabled", "endpoint", cfg.Tracing.Endpoint, "service", cfg.Tracing.ServiceName, "sample_ratio", cfg.Tracing.SampleRatio)
+	}
+
 	cookieSecure := strings.HasPrefix(cfg.PublicBaseURL, "https://")
 
 	sessionStore, err := auth.NewSessionStore(
@@ -57,7 +73,10 @@ func main() {
 		sessionStore,
 	)
 
-	reverseProxy, err := proxy.NewReverseProxy(cfg.CXDBBackendURL, logger)
+	reverseProxy, err := proxy.NewReverseProxy(cfg.CXDBBackendURL, logger,
+		proxy.WithFlushInterval(cfg.ProxyFlushInterval),
+		proxy.WithRouteTimeouts(cfg.ProxyTimeouts),
+	)
 	if err != nil {
 		logger.Error("reverse proxy init failed", "err", err)
 		os.Exit(1)
@@ -79,6 +98,18 @@ func main() {
 		os.Exit(1)
 	}
 
+	webhooksDone := make(chan struct{})
+	if len(cfg.Webhooks.Hooks) > 0 {
+		dispatcher := webhook.New(cfg.Webhooks, cfg.CXDBBackendURL, logger)
+		go func() {
+			defer close(webhooksDone)
+			dispatcher.Run(ctx)
+		}()
+		logger.Info("webhooks_enabled", "hooks", len(cfg.Webhooks.Hooks), "dead_letter_path", cfg.Webhooks.DeadLetterPath)
+	} else {
+		close(webhooksDone)
+	}
+
 	logger.Info("cxdb gateway starting",
 		"port", cfg.Port,
 		"backend", cfg.CXDBBackendURL,
@@ -89,4 +120,6 @@ func main() {
 		logger.Error("server exited", "err", err)
 		os.Exit(1)
 	}
+	// Queued webhook deliveries go to the dead-letter log on shutdown.
+	<-webhooksDone
 }
@@ -4,11 +4,14 @@
 package config
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -31,6 +34,14 @@ type Config struct {
 	DatabasePath  string
 	SessionTTL    time.Duration
 
+	// AccessLogRetention is how long reads of each context are kept for
+	// GET /v1/contexts/{id}/access. Zero disables the access log.
+	AccessLogRetention time.Duration
+
+	// AccessLogAdmins are the emails that may list the readers of any
+	// context; everyone else only sees the contexts they own.
+	AccessLogAdmins []string
+
 	Port         string
 	CookieName   string
 	CookieDomain string
@@ -38,12 +49,35 @@ type Config struct {
 	// Backend configuration
 	CXDBBackendURL string
 
+	// CXDBBinaryAddr is the backend's binary protocol listener (host:port).
+	// When set, GET /v1/binary tunnels the protocol over a WebSocket.
+	CXDBBinaryAddr string
+
+	// BinaryTunnelWriters are the emails or token subjects that may send
+	// writes through GET /v1/binary; "*" admits every signed-in caller.
+	// Everyone else gets a read-only tunnel.
+	BinaryTunnelWriters []string
+
+	// ProxyFlushInterval is how often proxied response bodies are flushed.
+	// Zero flushes at the end of the response, negative after every write.
+	// Event streams are always flushed immediately.
+	ProxyFlushInterval time.Duration
+
+	// ProxyTimeouts bounds proxied backend requests by kind of route.
+	ProxyTimeouts RouteTimeouts
+
 	// DevMode relaxes auth in local development by allowing the gateway
 	// to inject a synthetic session when no cookie is present. It is
 	// only enabled when DEV_MODE=true and PUBLIC_BASE_URL points at
 	// localhost. Never enable this in production.
 	DevMode bool
 
+	// PublicReadMode lets anonymous visitors obtain short-lived read-only
+	// tokens for contexts carrying one of PublicReadLabels, so a public
+	// demo can show live transcripts without opening the whole store.
+	PublicReadMode   bool
+	PublicReadLabels []string
+
 	// K8s OIDC authentication for in-cluster service accounts
 	K8sOIDCEnabled           bool
 	K8sOIDCIssuerURL         string
@@ -59,17 +93,101 @@ type Config struct {
 	// Renderer CSP configuration
 	// List of allowed origins for loading external renderer ESM modules
 	AllowedRendererOrigins []string
+
+	// Login and sign-in error page branding
+	BrandOrgName string
+	BrandLogoURL string
+	BrandHelpURL string
+
+	// Tracing configures OpenTelemetry trace export.
+	Tracing TracingConfig
+
+	// Webhooks configures outbound webhook delivery of backend events.
+	Webhooks WebhooksConfig
+}
+
+// WebhooksConfig configures outbound webhooks. Hooks are read from the JSON
+// file named by WEBHOOKS_FILE; none are configured without it.
+type WebhooksConfig struct {
+	Hooks []Webhook
+	// DeadLetterPath is the JSON Lines file that records deliveries which
+	// failed every attempt.
+	DeadLetterPath string
+	// MaxAttempts bounds deliveries of one event to one hook.
+	MaxAttempts int
+	// Timeout bounds each delivery request.
+	Timeout time.Duration
+}
+
+// Webhook is one receiver of signed event deliveries. A hook with labels or
+// client tags only receives events for contexts that carry one of each.
+type Webhook struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Secret keys the HMAC-SHA256 signature of each delivery. SecretEnv
+	// names an environment variable to read it from instead, so the file
+	// holds no secrets.
+	Secret    string `json:"secret,omitempty"`
+	SecretEnv string `json:"secret_env,omitempty"`
+	// Events are the backend event types delivered; empty means all of
+	// WebhookEvents.
+	Events     []string `json:"events,omitempty"`
+	Labels     []string `json:"labels,omitempty"`
+	ClientTags []string `json:"client_tags,omitempty"`
+}
+
+// WebhookEvents are the backend event types webhooks can receive.
+var WebhookEvents = []string{"context_created", "context_metadata_updated", "turn_appended"}
+
+// TracingConfig configures export of request traces over OTLP/HTTP. Incoming
+// traceparent headers are honored and forwarded to the backend whether or
+// not an endpoint is set; without one, spans are not exported.
+type TracingConfig struct {
+	// Endpoint is the OTLP/HTTP traces URL, e.g.
+	// http://otel-collector:4318/v1/traces. Empty disables export.
+	Endpoint string
+	// Headers are sent with each export, e.g. collector API keys.
+	Headers map[string]string
+	// ServiceName is reported as the service.name resource attribute.
+	ServiceName string
+	// SampleRatio is the fraction of new traces recorded. Requests that
+	// carry a sampled traceparent are always recorded.
+	SampleRatio float64
+}
+
+// RouteTimeouts bounds proxied backend requests by kind of route, so a slow
+// blob download is not held to the budget of a head lookup. Zero disables a
+// limit. Event streams and upgraded connections are never limited.
+type RouteTimeouts struct {
+	// Metadata bounds contexts, search, provenance and registry requests.
+	Metadata time.Duration
+	// Turns bounds turn listings, which decode payloads and can be large.
+	Turns time.Duration
+	// StreamIdle bounds blob and filesystem downloads. They have no total
+	// limit and fail only when the backend sends nothing for this long.
+	StreamIdle time.Duration
 }
 
 const (
 	defaultPort            = "8080"
 	defaultCookieName      = "cxdb_session"
 	defaultSessionTTL      = 24 * time.Hour
+	defaultAccessLogDays   = 90
 	defaultBaseURL         = "http://localhost:8080"
 	defaultDBPath          = "./data/sessions.db"
 	defaultCXDBBackendURL  = "http://127.0.0.1:9010"
 	defaultAWSIAMTokenTTL  = 1 * time.Hour
 	defaultK8sOIDCAudience = "cxdb.local"
+
+	defaultProxyMetadataTimeout   = 5 * time.Second
+	defaultProxyTurnsTimeout      = 15 * time.Second
+	defaultProxyStreamIdleTimeout = 60 * time.Second
+
+	defaultOTELServiceName = "cxdb-gateway"
+
+	defaultWebhookDeadLetterPath = "./data/webhook-dead-letters.jsonl"
+	defaultWebhookMaxAttempts    = 6
+	defaultWebhookTimeout        = 10 * time.Second
 )
 
 // Load reads configuration from environment variables and validates
@@ -92,7 +210,11 @@ func Load() (Config, error) {
 		CookieDomain:        strings.TrimSpace(os.Getenv("SESSION_COOKIE_DOMAIN")),
 		GoogleAllowedDomain: strings.ToLower(strings.TrimSpace(os.Getenv("GOOGLE_ALLOWED_DOMAIN"))),
 		SessionTTL:          defaultSessionTTL,
+		AccessLogRetention:  defaultAccessLogDays * 24 * time.Hour,
+		AccessLogAdmins:     splitAndTrim(os.Getenv("ACCESS_LOG_ADMINS")),
 		CXDBBackendURL:      firstNonEmpty(os.Getenv("CXDB_BACKEND_URL"), defaultCXDBBackendURL),
+		CXDBBinaryAddr:      strings.TrimSpace(os.Getenv("CXDB_BINARY_ADDR")),
+		BinaryTunnelWriters: splitAndTrim(os.Getenv("BINARY_TUNNEL_WRITERS")),
 	}
 
 	if ttlStr := strings.TrimSpace(os.Getenv("SESSION_TTL_HOURS")); ttlStr != "" {
@@ -102,6 +224,13 @@ func Load() (Config, error) {
 			return Config{}, fmt.Errorf("invalid SESSION_TTL_HOURS: %w", err)
 		}
 	}
+	if v := strings.TrimSpace(os.Getenv("ACCESS_LOG_RETENTION_DAYS")); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 0 {
+			return Config{}, errors.New("invalid ACCESS_LOG_RETENTION_DAYS: must be a non-negative number of days")
+		}
+		cfg.AccessLogRetention = time.Duration(days) * 24 * time.Hour
+	}
 
 	if len(cfg.PublicAllowedHosts) == 0 {
 		if host := hostnameFromURL(cfg.PublicBaseURL); host != "" {
@@ -144,6 +273,92 @@ func Load() (Config, error) {
 		}
 	}
 
+	if v := strings.TrimSpace(os.Getenv("PROXY_FLUSH_INTERVAL")); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid PROXY_FLUSH_INTERVAL: %w", err)
+		}
+		cfg.ProxyFlushInterval = d
+	}
+
+	cfg.ProxyTimeouts = RouteTimeouts{
+		Metadata:   defaultProxyMetadataTimeout,
+		Turns:      defaultProxyTurnsTimeout,
+		StreamIdle: defaultProxyStreamIdleTimeout,
+	}
+	for key, dst := range map[string]*time.Duration{
+		"PROXY_METADATA_TIMEOUT":    &cfg.ProxyTimeouts.Metadata,
+		"PROXY_TURNS_TIMEOUT":       &cfg.ProxyTimeouts.Turns,
+		"PROXY_STREAM_IDLE_TIMEOUT": &cfg.ProxyTimeouts.StreamIdle,
+	} {
+		if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return Config{}, fmt.Errorf("invalid %s: %w", key, err)
+			}
+			*dst = d
+		}
+	}
+
+	cfg.PublicReadMode = parseBoolEnv("PUBLIC_READ_MODE")
+	cfg.PublicReadLabels = splitAndTrimPreserveCase(os.Getenv("PUBLIC_READ_LABELS"))
+
+	// Login page branding
+	cfg.BrandOrgName = strings.TrimSpace(os.Getenv("BRAND_ORG_NAME"))
+	cfg.BrandLogoURL = strings.TrimSpace(os.Getenv("BRAND_LOGO_URL"))
+	cfg.BrandHelpURL = strings.TrimSpace(os.Getenv("BRAND_HELP_URL"))
+
+	// Trace export, using the standard OpenTelemetry variables
+	cfg.Tracing = TracingConfig{
+		Endpoint:    strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")),
+		ServiceName: firstNonEmpty(os.Getenv("OTEL_SERVICE_NAME"), defaultOTELServiceName),
+		SampleRatio: 1,
+	}
+	if cfg.Tracing.Endpoint == "" {
+		if base := strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")); base != "" {
+			cfg.Tracing.Endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+		}
+	}
+	headers, err := parseOTELHeaders(firstNonEmpty(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_HEADERS"), os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_HEADERS: %w", err)
+	}
+	cfg.Tracing.Headers = headers
+	if v := strings.TrimSpace(os.Getenv("OTEL_TRACES_SAMPLER_ARG")); v != "" {
+		ratio, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid OTEL_TRACES_SAMPLER_ARG: %w", err)
+		}
+		cfg.Tracing.SampleRatio = ratio
+	}
+
+	cfg.Webhooks = WebhooksConfig{
+		DeadLetterPath: firstNonEmpty(os.Getenv("WEBHOOK_DEAD_LETTER_PATH"), defaultWebhookDeadLetterPath),
+		MaxAttempts:    defaultWebhookMaxAttempts,
+		Timeout:        defaultWebhookTimeout,
+	}
+	if path := strings.TrimSpace(os.Getenv("WEBHOOKS_FILE")); path != "" {
+		hooks, err := loadWebhooks(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid WEBHOOKS_FILE: %w", err)
+		}
+		cfg.Webhooks.Hooks = hooks
+	}
+	if v := strings.TrimSpace(os.Getenv("WEBHOOK_MAX_ATTEMPTS")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid WEBHOOK_MAX_ATTEMPTS: %w", err)
+		}
+		cfg.Webhooks.MaxAttempts = n
+	}
+	if v := strings.TrimSpace(os.Getenv("WEBHOOK_TIMEOUT")); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid WEBHOOK_TIMEOUT: %w", err)
+		}
+		cfg.Webhooks.Timeout = d
+	}
+
 	if err := cfg.validate(); err != nil {
 		return Config{}, err
 	}
@@ -151,9 +366,34 @@ func Load() (Config, error) {
 	if abs, err := filepath.Abs(cfg.DatabasePath); err == nil {
 		cfg.DatabasePath = abs
 	}
+	if abs, err := filepath.Abs(cfg.Webhooks.DeadLetterPath); err == nil {
+		cfg.Webhooks.DeadLetterPath = abs
+	}
 	return cfg, nil
 }
 
+// loadWebhooks reads a JSON array of hooks and resolves their secrets.
+func loadWebhooks(path string) ([]Webhook, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var hooks []Webhook
+	if err := json.Unmarshal(data, &hooks); err != nil {
+		return nil, err
+	}
+	for i := range hooks {
+		h := &hooks[i]
+		if h.SecretEnv != "" {
+			h.Secret = strings.TrimSpace(os.Getenv(h.SecretEnv))
+		}
+		if len(h.Events) == 0 {
+			h.Events = WebhookEvents
+		}
+	}
+	return hooks, nil
+}
+
 func (c Config) validate() error {
 	var missing []string
 	if c.GoogleClientID == "" {
@@ -183,15 +423,96 @@ func (c Config) validate() error {
 		}
 	}
 
+	// Conditional validation for public read mode: it only ever exposes
+	// explicitly labelled contexts
+	if c.PublicReadMode {
+		if len(c.PublicReadLabels) == 0 {
+			missing = append(missing, "PUBLIC_READ_LABELS (required when PUBLIC_READ_MODE=true)")
+		}
+	}
+
 	if len(missing) > 0 {
 		return fmt.Errorf("missing required env vars: %s", strings.Join(missing, ", "))
 	}
 	if _, err := url.Parse(c.CXDBBackendURL); err != nil {
 		return errors.New("invalid CXDB_BACKEND_URL")
 	}
+	if c.CXDBBinaryAddr != "" {
+		if _, _, err := net.SplitHostPort(c.CXDBBinaryAddr); err != nil {
+			return errors.New("invalid CXDB_BINARY_ADDR: must be host:port")
+		}
+	}
+	if c.ProxyTimeouts.Metadata < 0 || c.ProxyTimeouts.Turns < 0 || c.ProxyTimeouts.StreamIdle < 0 {
+		return errors.New("invalid proxy timeout: PROXY_METADATA_TIMEOUT, PROXY_TURNS_TIMEOUT and PROXY_STREAM_IDLE_TIMEOUT must not be negative")
+	}
+	if c.BrandLogoURL != "" && !isHTTPURL(c.BrandLogoURL) {
+		return errors.New("invalid BRAND_LOGO_URL: must be an http(s) URL")
+	}
+	if c.BrandHelpURL != "" && !isHTTPURL(c.BrandHelpURL) && !strings.HasPrefix(c.BrandHelpURL, "mailto:") {
+		return errors.New("invalid BRAND_HELP_URL: must be an http(s) or mailto: URL")
+	}
+	if c.Tracing.Endpoint != "" && !isHTTPURL(c.Tracing.Endpoint) {
+		return errors.New("invalid OTEL_EXPORTER_OTLP_ENDPOINT: must be an http(s) URL")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		return errors.New("invalid OTEL_TRACES_SAMPLER_ARG: must be between 0 and 1")
+	}
+	return c.Webhooks.validate()
+}
+
+func (w WebhooksConfig) validate() error {
+	if w.MaxAttempts < 1 {
+		return errors.New("invalid WEBHOOK_MAX_ATTEMPTS: must be at least 1")
+	}
+	if w.Timeout <= 0 {
+		return errors.New("invalid WEBHOOK_TIMEOUT: must be positive")
+	}
+	names := map[string]bool{}
+	for i, h := range w.Hooks {
+		switch {
+		case h.Name == "":
+			return fmt.Errorf("invalid WEBHOOKS_FILE: hook %d has no name", i)
+		case names[h.Name]:
+			return fmt.Errorf("invalid WEBHOOKS_FILE: duplicate hook name %q", h.Name)
+		case !isHTTPURL(h.URL):
+			return fmt.Errorf("invalid WEBHOOKS_FILE: hook %q url must be an http(s) URL", h.Name)
+		case h.Secret == "" && h.SecretEnv != "":
+			return fmt.Errorf("invalid WEBHOOKS_FILE: hook %q secret_env %s is empty", h.Name, h.SecretEnv)
+		case h.Secret == "":
+			return fmt.Errorf("invalid WEBHOOKS_FILE: hook %q needs a secret or secret_env", h.Name)
+		}
+		names[h.Name] = true
+		for _, e := range h.Events {
+			if !slices.Contains(WebhookEvents, e) {
+				return fmt.Errorf("invalid WEBHOOKS_FILE: hook %q event %q is not one of %s", h.Name, e, strings.Join(WebhookEvents, ", "))
+			}
+		}
+	}
 	return nil
 }
 
+// parseOTELHeaders parses the OTEL_EXPORTER_OTLP_HEADERS format:
+// comma-separated key=value pairs with URL-encoded values.
+func parseOTELHeaders(raw string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(raw, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("%q is not key=value", pair)
+		}
+		v, err := url.QueryUnescape(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("header %s: %w", k, err)
+		}
+		headers[k] = v
+	}
+	return headers, nil
+}
+
 func splitAndTrim(raw string) []string {
 	parts := strings.Split(raw, ",")
 	out := make([]string, 0, len(parts))
@@ -249,3 +570,11 @@ func hostnameFromURL(raw string) string {
 	}
 	return strings.ToLower(strings.TrimSpace(u.Hostname()))
 }
+
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return u.Scheme == "https" || u.Scheme == "http"
+}
@@ -0,0 +1,187 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+// Package accesslog is the gateway's audit log of context reads: who read
+// which context, and when. It shares the session SQLite database and never
+// touches the cxdb backend.
+package accesslog
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/strongdm/cxdb/gateway/pkg/contextid"
+)
+
+// Reader kinds.
+const (
+	// KindUser is a signed-in user or a service with a bearer token; Reader
+	// is its email or token subject.
+	KindUser = "user"
+	// KindPublic is an anonymous public read token; Reader is "anonymous".
+	KindPublic = "public"
+)
+
+// AnonymousReader is the reader recorded for public read tokens.
+const AnonymousReader = "anonymous"
+
+// DefaultLimit and MaxLimit bound Access listings.
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// coalesceWindow merges repeated reads by one reader into one entry while
+// they are less than this far apart, so paging and polling do not flood
+// the log.
+const coalesceWindow = 15 * time.Minute
+
+// pruneInterval is how often expired entries are deleted.
+const pruneInterval = time.Hour
+
+// Access is one reader's run of reads of a context.
+type Access struct {
+	Reader      string    `json:"reader"`
+	Kind        string    `json:"kind"`
+	FirstReadAt time.Time `json:"first_read_at"`
+	LastReadAt  time.Time `json:"last_read_at"`
+	Reads       int64     `json:"reads"`
+}
+
+// Store persists context reads.
+type Store struct {
+	db        *sql.DB
+	retention time.Duration
+
+	mu         sync.Mutex
+	lastPruned time.Time
+}
+
+// NewStore creates the access log table in db if needed. Entries whose last
+// read is older than retention are deleted.
+func NewStore(db *sql.DB, retention time.Duration) (*Store, error) {
+	s := &Store{db: db, retention: retention}
+	if err := s.ensureSchema(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) ensureSchema() error {
+	const schema = `
+	CREATE TABLE IF NOT EXISTS context_access_log (
+		context_id TEXT NOT NULL,
+		reader TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		first_read_at TIMESTAMP NOT NULL,
+		last_read_at TIMESTAMP NOT NULL,
+		reads INTEGER NOT NULL DEFAULT 1
+	);
+	CREATE INDEX IF NOT EXISTS idx_context_access_last ON context_access_log(context_id, last_read_at);
+	CREATE INDEX IF NOT EXISTS idx_context_access_expiry ON context_access_log(last_read_at);
+	`
+	if _, err := s.db.Exec(schema); err != nil {
+		return fmt.Errorf("init access log schema: %w", err)
+	}
+	return nil
+}
+
+// Record logs a read of each of contextIDs by reader now. A read within 15
+// minutes of the reader's previous one of the same context extends that
+// entry.
+func (s *Store) Record(ctx context.Context, reader, kind string, contextIDs ...string) error {
+	ids := make([]string, len(contextIDs))
+	for i, raw := range contextIDs {
+		id, err := contextid.Normalize(raw)
+		if err != nil {
+			return err
+		}
+		ids[i] = id
+	}
+	now := time.Now().UTC()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin access: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	for _, id := range ids {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE context_access_log
+			SET last_read_at = ?, reads = reads + 1
+			WHERE rowid = (
+				SELECT rowid FROM context_access_log
+				WHERE context_id = ? AND reader = ? AND kind = ? AND last_read_at >= ?
+				ORDER BY last_read_at DESC
+				LIMIT 1
+			)
+		`, now, id, reader, kind, now.Add(-coalesceWindow))
+		if err != nil {
+			return fmt.Errorf("update access: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO context_access_log (context_id, reader, kind, first_read_at, last_read_at, reads)
+				VALUES (?, ?, ?, ?, ?, 1)
+			`, id, reader, kind, now, now); err != nil {
+				return fmt.Errorf("insert access: %w", err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit access: %w", err)
+	}
+	return s.maybePrune(ctx, now)
+}
+
+// maybePrune deletes expired entries at most once per pruneInterval.
+func (s *Store) maybePrune(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	if now.Sub(s.lastPruned) < pruneInterval {
+		s.mu.Unlock()
+		return nil
+	}
+	s.lastPruned = now
+	s.mu.Unlock()
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM context_access_log WHERE last_read_at < ?`, now.Add(-s.retention)); err != nil {
+		return fmt.Errorf("prune access log: %w", err)
+	}
+	return nil
+}
+
+// Access returns the reads of contextID, most recent first.
+func (s *Store) Access(ctx context.Context, contextID string, limit int) ([]Access, error) {
+	id, err := contextid.Normalize(contextID)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, MaxLimit)
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT reader, kind, first_read_at, last_read_at, reads
+		FROM context_access_log
+		WHERE context_id = ? AND last_read_at >= ?
+		ORDER BY last_read_at DESC
+		LIMIT ?
+	`, id, time.Now().UTC().Add(-s.retention), limit)
+	if err != nil {
+		return nil, fmt.Errorf("select access: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := []Access{}
+	for rows.Next() {
+		var a Access
+		if err := rows.Scan(&a.Reader, &a.Kind, &a.FirstReadAt, &a.LastReadAt, &a.Reads); err != nil {
+			return nil, fmt.Errorf("scan access: %w", err)
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,148 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package accesslog
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/strongdm/cxdb/gateway/pkg/contextid"
+)
+
+func newTestStore(t *testing.T, retention time.Duration) *Store {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "gateway.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	s, err := NewStore(db, retention)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// age moves every entry of contextID back by d.
+func age(t *testing.T, s *Store, contextID string, d time.Duration) {
+	t.Helper()
+	rows, err := s.db.Query(`SELECT rowid, first_read_at, last_read_at FROM context_access_log WHERE context_id = ?`, contextID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type entry struct {
+		rowid       int64
+		first, last time.Time
+	}
+	var entries []entry
+	for rows.Next() {
+		var e entry
+		if err := rows.Scan(&e.rowid, &e.first, &e.last); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+	_ = rows.Close()
+	for _, e := range entries {
+		if _, err := s.db.Exec(`UPDATE context_access_log SET first_read_at = ?, last_read_at = ? WHERE rowid = ?`,
+			e.first.Add(-d), e.last.Add(-d), e.rowid); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRecordCoalesces(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t, 24*time.Hour)
+
+	for i := 0; i < 3; i++ {
+		if err := s.Record(ctx, "a@example.com", KindUser, "042"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Record(ctx, AnonymousReader, KindPublic, "42", "43"); err != nil {
+		t.Fatal(err)
+	}
+
+	access, err := s.Access(ctx, "42", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(access) != 2 {
+		t.Fatalf("access = %+v, want 2 readers", access)
+	}
+	if a := access[0]; a.Reader != AnonymousReader || a.Kind != KindPublic || a.Reads != 1 {
+		t.Errorf("most recent = %+v", a)
+	}
+	if a := access[1]; a.Reader != "a@example.com" || a.Kind != KindUser || a.Reads != 3 || a.LastReadAt.Before(a.FirstReadAt) {
+		t.Errorf("user entry = %+v", a)
+	}
+	if access, _ := s.Access(ctx, "43", 0); len(access) != 1 {
+		t.Errorf("context 43 access = %+v", access)
+	}
+	if access, _ := s.Access(ctx, "42", 1); len(access) != 1 {
+		t.Errorf("limit 1 returned %d entries", len(access))
+	}
+
+	// A read after the coalesce window starts a new entry.
+	age(t, s, "42", coalesceWindow+time.Minute)
+	if err := s.Record(ctx, "a@example.com", KindUser, "42"); err != nil {
+		t.Fatal(err)
+	}
+	access, _ = s.Access(ctx, "42", 0)
+	if len(access) != 3 || access[0].Reader != "a@example.com" || access[0].Reads != 1 {
+		t.Fatalf("after window: %+v", access)
+	}
+}
+
+func TestRecordInvalidContextID(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t, time.Hour)
+
+	// One bad ID fails the whole batch.
+	if err := s.Record(ctx, "a@example.com", KindUser, "1", "x"); !errors.Is(err, contextid.ErrInvalid) {
+		t.Fatalf("Record err = %v", err)
+	}
+	if access, _ := s.Access(ctx, "1", 0); len(access) != 0 {
+		t.Fatalf("partial batch recorded: %+v", access)
+	}
+	if _, err := s.Access(ctx, "-1", 0); !errors.Is(err, contextid.ErrInvalid) {
+		t.Fatalf("Access err = %v", err)
+	}
+}
+
+func TestRetention(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t, time.Hour)
+
+	if err := s.Record(ctx, "a@example.com", KindUser, "1", "2"); err != nil {
+		t.Fatal(err)
+	}
+	age(t, s, "1", 2*time.Hour)
+	if access, _ := s.Access(ctx, "1", 0); len(access) != 0 {
+		t.Fatalf("expired entries listed: %+v", access)
+	}
+
+	// The next prune deletes them.
+	s.lastPruned = time.Time{}
+	if err := s.Record(ctx, "b@example.com", KindUser, "2"); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM context_access_log WHERE context_id = '1'`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Fatalf("%d expired entries kept", n)
+	}
+	if access, _ := s.Access(ctx, "2", 0); len(access) != 2 {
+		t.Fatalf("context 2 access = %+v", access)
+	}
+}
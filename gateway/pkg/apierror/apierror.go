@@ -0,0 +1,121 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+// Package apierror writes the gateway's JSON error responses and assigns
+// the request IDs they carry.
+//
+// Every error the gateway returns, including backend errors passed through
+// the reverse proxy, has the backend's shape plus the request ID:
+//
+//	{"error": {"code": 404, "message": "context not found", "request_id": "4f0c2a9e7d1b3c58"}}
+//
+// code is the HTTP status, matching the HTTP-style codes of the binary
+// protocol. The same request ID is sent in the X-Request-Id response header,
+// forwarded to the backend, and logged with the request.
+package apierror
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+)
+
+// Header carries the request ID on requests and responses.
+const Header = "X-Request-Id"
+
+// maxRequestIDLen bounds caller-supplied request IDs.
+const maxRequestIDLen = 64
+
+// Body is a JSON error response.
+type Body struct {
+	Error Detail `json:"error"`
+}
+
+// Detail describes an error.
+type Detail struct {
+	// Code is the HTTP status.
+	Code int `json:"code"`
+
+	// Message is a human-readable description.
+	Message string `json:"message"`
+
+	// RequestID identifies the request in gateway and backend logs.
+	RequestID string `json:"request_id,omitempty"`
+
+	// Reason is a stable machine-readable cause, e.g. role_not_allowed.
+	Reason string `json:"reason,omitempty"`
+
+	// Detail adds context to Message, such as the rejected identity.
+	Detail string `json:"detail,omitempty"`
+
+	// HelpURL is where to request access or get support.
+	HelpURL string `json:"help_url,omitempty"`
+}
+
+// Write sends a JSON error with the given status and message.
+func Write(w http.ResponseWriter, r *http.Request, status int, message string) {
+	WriteDetail(w, r, Detail{Code: status, Message: message})
+}
+
+// WriteDetail sends d as a JSON error with status d.Code, filling in the
+// request ID.
+func WriteDetail(w http.ResponseWriter, r *http.Request, d Detail) {
+	if d.RequestID == "" {
+		d.RequestID = RequestID(r.Context())
+	}
+	if d.Message == "" {
+		d.Message = http.StatusText(d.Code)
+	}
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Type", "application/json")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(d.Code)
+	_ = json.NewEncoder(w).Encode(Body{Error: d})
+}
+
+type requestIDKey struct{}
+
+// RequestID returns the request ID assigned by RequestIDs, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestIDs assigns each request an ID: the caller's X-Request-Id if it is
+// a short token, otherwise a random one. The ID is stored in the request
+// context, set on the request header so the reverse proxy forwards it, and
+// echoed in the response header.
+func RequestIDs(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(Header)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		r.Header.Set(Header, id)
+		w.Header().Set(Header, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,58 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestIDsAndWrite(t *testing.T) {
+	var seen string
+	h := RequestIDs(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Header.Get(Header)
+		if RequestID(r.Context()) != seen {
+			t.Errorf("context ID %q, header %q", RequestID(r.Context()), seen)
+		}
+		Write(w, r, http.StatusNotFound, "context not found")
+	}))
+
+	for _, tc := range []struct {
+		incoming string
+		keep     bool
+	}{
+		{"", false},
+		{"trace-42.a_b", true},
+		{"bad id\n", false},
+		{string(make([]byte, 65)), false},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/contexts/9", nil)
+		if tc.incoming != "" {
+			req.Header.Set(Header, tc.incoming)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if tc.keep && seen != tc.incoming {
+			t.Errorf("incoming %q replaced by %q", tc.incoming, seen)
+		}
+		if !tc.keep && (seen == tc.incoming || len(seen) != 16) {
+			t.Errorf("incoming %q: assigned %q", tc.incoming, seen)
+		}
+		if got := rec.Header().Get(Header); got != seen {
+			t.Errorf("response header %q, want %q", got, seen)
+		}
+
+		var body Body
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		want := Detail{Code: 404, Message: "context not found", RequestID: seen}
+		if rec.Code != http.StatusNotFound || body.Error != want {
+			t.Errorf("response %d %+v", rec.Code, body.Error)
+		}
+	}
+}
@@ -4,6 +4,7 @@
 package auth
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -17,6 +18,8 @@ import (
 
 	"github.com/lestrrat-go/jwx/v2/jwa"
 	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/strongdm/cxdb/gateway/pkg/apierror"
+	"github.com/strongdm/cxdb/gateway/pkg/tracing"
 )
 
 // AWSTokenExchanger handles token exchange for AWS IAM authentication.
@@ -29,6 +32,7 @@ type AWSTokenExchanger struct {
 	issuer              string
 	audience            string
 	debug               bool
+	branding            Branding
 }
 
 // NewAWSTokenExchanger creates a new AWS IAM token exchanger.
@@ -63,27 +67,32 @@ type TokenExchangeResponse struct {
 	TokenType string    `json:"token_type"`
 }
 
+// SetBranding sets the branding used in role-not-allowed errors.
+func (e *AWSTokenExchanger) SetBranding(b Branding) {
+	e.branding = b
+}
+
 // TokenHandler handles POST /auth/aws/token requests.
 // The client provides a presigned STS GetCallerIdentity URL in the X-AWS-Auth header.
 func (e *AWSTokenExchanger) TokenHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		apierror.Write(w, r, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
 	presignedURL := r.Header.Get("X-AWS-Auth")
 	if presignedURL == "" {
-		http.Error(w, "missing X-AWS-Auth header", http.StatusBadRequest)
+		apierror.Write(w, r, http.StatusBadRequest, "missing X-AWS-Auth header")
 		return
 	}
 
 	// Execute the presigned GetCallerIdentity request
-	identity, err := e.verifyPresignedURL(presignedURL)
+	identity, err := e.verifyPresignedURL(r.Context(), presignedURL)
 	if err != nil {
 		if e.debug {
 			log.Printf("[aws-iam] presigned URL verification failed: %v", err)
 		}
-		http.Error(w, "invalid AWS credentials", http.StatusUnauthorized)
+		apierror.Write(w, r, http.StatusUnauthorized, "invalid AWS credentials")
 		return
 	}
 
@@ -92,7 +101,7 @@ func (e *AWSTokenExchanger) TokenHandler(w http.ResponseWriter, r *http.Request)
 		if e.debug {
 			log.Printf("[aws-iam] ARN %s not in allowlist", identity.Arn)
 		}
-		http.Error(w, "ARN not authorized", http.StatusForbidden)
+		WriteAuthError(w, r, e.branding, roleNotAllowedError(identity.Arn))
 		return
 	}
 
@@ -102,7 +111,7 @@ func (e *AWSTokenExchanger) TokenHandler(w http.ResponseWriter, r *http.Request)
 		if e.debug {
 			log.Printf("[aws-iam] token generation failed: %v", err)
 		}
-		http.Error(w, "token generation failed", http.StatusInternalServerError)
+		apierror.Write(w, r, http.StatusInternalServerError, "token generation failed")
 		return
 	}
 
@@ -158,18 +167,27 @@ type STSIdentity struct {
 	UserId  string `json:"UserId"`
 }
 
-// verifyPresignedURL executes a presigned GetCallerIdentity request.
-func (e *AWSTokenExchanger) verifyPresignedURL(presignedURL string) (*STSIdentity, error) {
-	req, err := http.NewRequest(http.MethodGet, presignedURL, nil)
+// verifyPresignedURL executes a presigned GetCallerIdentity request. The
+// call is traced, but the trace context is not sent to AWS.
+func (e *AWSTokenExchanger) verifyPresignedURL(ctx context.Context, presignedURL string) (_ *STSIdentity, err error) {
+	ctx, span := tracing.Start(ctx, "aws.sts.GetCallerIdentity", tracing.KindClient)
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, presignedURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
+	span.SetAttr("server.address", req.URL.Host)
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("execute request: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
+	span.SetAttr("http.response.status_code", resp.StatusCode)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
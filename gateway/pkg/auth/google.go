@@ -19,6 +19,9 @@ import (
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
+
+	"github.com/strongdm/cxdb/gateway/pkg/apierror"
+	"github.com/strongdm/cxdb/gateway/pkg/tracing"
 )
 
 // GoogleAuth wires Google OAuth2 handlers with the session store.
@@ -29,6 +32,7 @@ type GoogleAuth struct {
 	allowedHosts  map[string]bool
 	sessions      *SessionStore
 	publicURL     string
+	branding      Branding
 }
 
 func NewGoogleAuth(publicBaseURL string, clientID, clientSecret string, allowedDomain string, allowedHosts []string, sessions *SessionStore) *GoogleAuth {
@@ -59,11 +63,18 @@ func NewGoogleAuth(publicBaseURL string, clientID, clientSecret string, allowedD
 	}
 }
 
-// LoginHandler redirects users to Google's consent screen.
+// SetBranding sets the branding used on sign-in error pages.
+func (g *GoogleAuth) SetBranding(b Branding) {
+	g.branding = b
+}
+
+// LoginHandler redirects users to Google's consent screen. With
+// ?switch_account=1 Google shows the account chooser even when the user is
+// already signed in.
 func (g *GoogleAuth) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	state, err := randomState()
 	if err != nil {
-		http.Error(w, "unable to create state", http.StatusInternalServerError)
+		apierror.Write(w, r, http.StatusInternalServerError, "unable to create state")
 		return
 	}
 	g.setPostAuthRedirectCookie(w, r)
@@ -77,7 +88,11 @@ func (g *GoogleAuth) LoginHandler(w http.ResponseWriter, r *http.Request) {
 		Secure:   g.sessions.Secure(),
 		SameSite: http.SameSiteLaxMode,
 	})
-	authURL := g.cfg.AuthCodeURL(state, oauth2.AccessTypeOnline)
+	opts := []oauth2.AuthCodeOption{oauth2.AccessTypeOnline}
+	if r.URL.Query().Get("switch_account") != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("prompt", "select_account"))
+	}
+	authURL := g.cfg.AuthCodeURL(state, opts...)
 	http.Redirect(w, r, authURL, http.StatusFound)
 }
 
@@ -88,21 +103,39 @@ func (g *GoogleAuth) CallbackHandler(w http.ResponseWriter, r *http.Request) {
 	state := r.URL.Query().Get("state")
 	code := r.URL.Query().Get("code")
 	if errParam := r.URL.Query().Get("error"); errParam != "" {
-		http.Redirect(w, r, "/login?error=access_denied", http.StatusFound)
+		g.fail(w, r, signInAgain(AuthError{
+			Status:  http.StatusForbidden,
+			Code:    ErrCodeAccessDenied,
+			Title:   "Sign-in cancelled",
+			Message: "Google did not grant access. Sign in again and approve the requested permissions.",
+		}))
 		return
 	}
 
 	if !g.validState(r, state) {
-		http.Redirect(w, r, "/login?error=state", http.StatusFound)
+		g.fail(w, r, signInAgain(AuthError{
+			Status:  http.StatusBadRequest,
+			Code:    ErrCodeInvalidState,
+			Title:   "Sign-in expired",
+			Message: "The sign-in request expired or was started in another browser. Start again from this browser.",
+		}))
 		return
 	}
 
-	token, err := g.cfg.Exchange(ctx, code)
+	exchangeCtx, span := tracing.Start(ctx, "google.oauth2.exchange", tracing.KindClient)
+	token, err := g.cfg.Exchange(exchangeCtx, code)
+	span.SetError(err)
+	span.End()
 	if err != nil {
 		if g.sessions.Debug() {
 			log.Printf("[auth] exchange error: %v", err)
 		}
-		http.Redirect(w, r, "/login?error=exchange", http.StatusFound)
+		g.fail(w, r, signInAgain(AuthError{
+			Status:  http.StatusBadGateway,
+			Code:    ErrCodeExchangeFailed,
+			Title:   "Sign-in failed",
+			Message: "Google rejected the sign-in code. This is usually transient; sign in again.",
+		}))
 		return
 	}
 
@@ -111,7 +144,12 @@ func (g *GoogleAuth) CallbackHandler(w http.ResponseWriter, r *http.Request) {
 		if g.sessions.Debug() {
 			log.Printf("[auth] userinfo error: %v", err)
 		}
-		http.Redirect(w, r, "/login?error=profile", http.StatusFound)
+		g.fail(w, r, signInAgain(AuthError{
+			Status:  http.StatusBadGateway,
+			Code:    ErrCodeProfileFailed,
+			Title:   "Sign-in failed",
+			Message: "Your Google profile could not be read. Sign in again; if it keeps failing, check that your account has an email address.",
+		}))
 		return
 	}
 	email := strings.ToLower(user.Email)
@@ -121,7 +159,7 @@ func (g *GoogleAuth) CallbackHandler(w http.ResponseWriter, r *http.Request) {
 		if g.sessions.Debug() {
 			log.Printf("[auth] unauthorized email %s (not from allowed domain %s)", email, g.allowedDomain)
 		}
-		http.Redirect(w, r, "/login?error=unauthorized", http.StatusFound)
+		g.fail(w, r, domainNotAllowedError(email, g.allowedDomain))
 		return
 	}
 
@@ -135,7 +173,12 @@ func (g *GoogleAuth) CallbackHandler(w http.ResponseWriter, r *http.Request) {
 		if g.sessions.Debug() {
 			log.Printf("[auth] create session error: %v", err)
 		}
-		http.Error(w, "unable to create session", http.StatusInternalServerError)
+		g.fail(w, r, signInAgain(AuthError{
+			Status:  http.StatusInternalServerError,
+			Code:    ErrCodeSessionFailed,
+			Title:   "Sign-in failed",
+			Message: "Your identity was verified but a session could not be created. Try again in a moment.",
+		}))
 		return
 	}
 	g.sessions.SetCookie(w, sessionID)
@@ -147,6 +190,12 @@ func (g *GoogleAuth) CallbackHandler(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/", http.StatusFound)
 }
 
+// fail ends the OAuth flow with an error page.
+func (g *GoogleAuth) fail(w http.ResponseWriter, r *http.Request, e AuthError) {
+	g.clearStateCookie(w)
+	WriteAuthError(w, r, g.branding, e)
+}
+
 // LogoutHandler clears the session and redirects to login.
 func (g *GoogleAuth) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -163,7 +212,13 @@ type googleUser struct {
 	Picture string `json:"picture"`
 }
 
-func (g *GoogleAuth) fetchUser(ctx context.Context, token *oauth2.Token) (googleUser, error) {
+func (g *GoogleAuth) fetchUser(ctx context.Context, token *oauth2.Token) (_ googleUser, err error) {
+	ctx, span := tracing.Start(ctx, "google.userinfo", tracing.KindClient)
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+
 	client := g.cfg.Client(ctx, token)
 	resp, err := client.Get("https://www.googleapis.com/oauth2/v2/userinfo")
 	if err != nil {
@@ -4,12 +4,17 @@
 package auth
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"net"
 	"net/http"
 	"os"
 	"strings"
 	"time"
+
+	"github.com/strongdm/cxdb/gateway/pkg/apierror"
+	"github.com/strongdm/cxdb/gateway/pkg/tracing"
 )
 
 // BearerTokenVerifier validates bearer tokens and returns a session.
@@ -87,6 +92,7 @@ type AuthMiddlewareOptions struct {
 	Store          *SessionStore
 	DevBypass      bool
 	TokenVerifiers []BearerTokenVerifier // Optional: K8s OIDC, AWS IAM, etc.
+	PublicRead     *PublicReader         // Optional: anonymous demo read tokens
 }
 
 // RequireAuthForReads is an HTTP middleware that enforces a valid session for
@@ -111,6 +117,13 @@ func RequireAuthForReadsWithOptions(opts AuthMiddlewareOptions, next http.Handle
 			if store.Debug() {
 				log.Printf("[auth] allowing write method %s %s", r.Method, path)
 			}
+			// Per-user endpoints still need to know who is writing; the
+			// handlers reject requests without a user.
+			if isUserScopedPath(path) {
+				if sess := resolveSession(opts, r); sess != nil {
+					r = r.WithContext(WithUser(r.Context(), sess))
+				}
+			}
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -124,50 +137,25 @@ func RequireAuthForReadsWithOptions(opts AuthMiddlewareOptions, next http.Handle
 			return
 		}
 
-		sess, _ := store.SessionFromRequest(r.Context(), r)
+		sess := resolveSession(opts, r)
 
-		// Try bearer token authentication (K8s OIDC, AWS IAM, etc.)
-		if sess == nil {
-			if token := extractBearerToken(r); token != "" {
-				for _, verifier := range opts.TokenVerifiers {
-					if s, err := verifier.Verify(token); err == nil && s != nil {
-						sess = s
-						if store.Debug() {
-							log.Printf("[auth] bearer token verified: %s", s.Email)
-						}
-						break
+		// Anonymous read tokens reach allowlisted contexts only, without a
+		// user in the request context.
+		if sess == nil && opts.PublicRead != nil {
+			if token := extractBearerToken(r); token != "" && opts.PublicRead.verify(token) == nil {
+				ctx, span := tracing.Start(r.Context(), "auth.public_read", tracing.KindInternal)
+				status := opts.PublicRead.authorize(r.WithContext(ctx))
+				span.SetAttr("auth.allowed", status == 0)
+				span.End()
+				if status != 0 {
+					if store.Debug() {
+						log.Printf("[auth] public read of %s denied with %d", path, status)
 					}
+					apierror.Write(w, r, status, strings.ToLower(http.StatusText(status)))
+					return
 				}
-			}
-		}
-
-		// Check for debug auth bypass (static token from allowed IP)
-		if sess == nil {
-			sess = checkDebugAuth(r)
-		}
-
-		// In DEV_MODE, allow requests without a browser session by
-		// injecting a synthetic user. This is only enabled when the
-		// server is started with DEV_MODE=true and PublicBaseURL is
-		// pointing at localhost.
-		if sess == nil && opts.DevBypass {
-			if store.Debug() {
-				log.Printf("[auth] DEV_MODE enabled, injecting dev session for %s", path)
-			}
-			email := strings.TrimSpace(os.Getenv("DEV_EMAIL"))
-			if email == "" {
-				email = "dev@localhost"
-			}
-			name := strings.TrimSpace(os.Getenv("DEV_NAME"))
-			if name == "" {
-				name = "Dev Mode User"
-			}
-			sess = &Session{
-				ID:        "dev-mode-session",
-				Email:     email,
-				Name:      name,
-				CreatedAt: time.Now().UTC(),
-				ExpiresAt: time.Now().Add(store.TTL()).UTC(),
+				next.ServeHTTP(w, r)
+				return
 			}
 		}
 
@@ -177,7 +165,7 @@ func RequireAuthForReadsWithOptions(opts AuthMiddlewareOptions, next http.Handle
 				if store.Debug() {
 					log.Printf("[auth] returning 401 for API request %s", path)
 				}
-				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				apierror.Write(w, r, http.StatusUnauthorized, "unauthorized")
 				return
 			}
 			if store.Debug() {
@@ -195,6 +183,85 @@ func RequireAuthForReadsWithOptions(opts AuthMiddlewareOptions, next http.Handle
 	})
 }
 
+// resolveSession identifies the caller from the session cookie, a bearer
+// token, the debug auth bypass, or DEV_MODE, in that order. It returns nil
+// for anonymous requests.
+func resolveSession(opts AuthMiddlewareOptions, r *http.Request) *Session {
+	ctx, span := tracing.Start(r.Context(), "auth.resolve_session", tracing.KindInternal)
+	defer span.End()
+
+	store := opts.Store
+	sess, _ := store.SessionFromRequest(ctx, r)
+	method := "cookie"
+
+	// Try bearer token authentication (K8s OIDC, AWS IAM, etc.)
+	if sess == nil {
+		if token := extractBearerToken(r); token != "" {
+			for _, verifier := range opts.TokenVerifiers {
+				if s := verifyBearer(ctx, verifier, token); s != nil {
+					sess = s
+					method = "bearer"
+					if store.Debug() {
+						log.Printf("[auth] bearer token verified: %s", s.Email)
+					}
+					break
+				}
+			}
+		}
+	}
+
+	// Check for debug auth bypass (static token from allowed IP)
+	if sess == nil {
+		sess = checkDebugAuth(r)
+		method = "debug"
+	}
+
+	// In DEV_MODE, allow requests without a browser session by
+	// injecting a synthetic user. This is only enabled when the
+	// server is started with DEV_MODE=true and PublicBaseURL is
+	// pointing at localhost.
+	if sess == nil && opts.DevBypass {
+		if store.Debug() {
+			log.Printf("[auth] DEV_MODE enabled, injecting dev session for %s", r.URL.Path)
+		}
+		email := strings.TrimSpace(os.Getenv("DEV_EMAIL"))
+		if email == "" {
+			email = "dev@localhost"
+		}
+		name := strings.TrimSpace(os.Getenv("DEV_NAME"))
+		if name == "" {
+			name = "Dev Mode User"
+		}
+		sess = &Session{
+			ID:        "dev-mode-session",
+			Email:     email,
+			Name:      name,
+			CreatedAt: time.Now().UTC(),
+			ExpiresAt: time.Now().Add(store.TTL()).UTC(),
+		}
+		method = "dev"
+	}
+	if sess == nil {
+		method = "anonymous"
+	}
+	span.SetAttr("auth.method", method)
+	return sess
+}
+
+// verifyBearer checks token with one verifier, as a span of ctx so slow
+// key fetches show up in the request's trace.
+func verifyBearer(ctx context.Context, verifier BearerTokenVerifier, token string) *Session {
+	_, span := tracing.Start(ctx, "auth.verify_token", tracing.KindInternal)
+	defer span.End()
+	span.SetAttr("auth.verifier", fmt.Sprintf("%T", verifier))
+	s, err := verifier.Verify(token)
+	span.SetAttr("auth.verified", err == nil && s != nil)
+	if err != nil {
+		return nil
+	}
+	return s
+}
+
 // extractBearerToken extracts a bearer token from the Authorization header.
 func extractBearerToken(r *http.Request) string {
 	auth := r.Header.Get("Authorization")
@@ -204,6 +271,11 @@ func extractBearerToken(r *http.Request) string {
 	return ""
 }
 
+// isUserScopedPath reports whether path serves per-user gateway state.
+func isUserScopedPath(path string) bool {
+	return strings.HasPrefix(strings.ToLower(path), "/v1/me/")
+}
+
 // isAPIRequest returns true if the request appears to be an API request
 // (should get 401 instead of redirect on auth failure).
 func isAPIRequest(r *http.Request) bool {
@@ -252,6 +324,10 @@ func isPublicPath(path string) bool {
 	if path == "/v1/metrics" {
 		return true
 	}
+	// OpenAPI document - describes the API surface, no data
+	if path == "/v1/openapi.json" {
+		return true
+	}
 	// SSE events endpoint - notifications about context/turn changes
 	// No sensitive data, just IDs and timestamps
 	if path == "/v1/events" {
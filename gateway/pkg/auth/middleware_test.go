@@ -0,0 +1,134 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type staticVerifier map[string]string // token -> email
+
+func (v staticVerifier) Verify(token string) (*Session, error) {
+	if email, ok := v[token]; ok {
+		return &Session{ID: "bearer", Email: email}, nil
+	}
+	return nil, errors.New("unknown token")
+}
+
+func newTestSessionStore(t *testing.T) *SessionStore {
+	t.Helper()
+	store, err := NewSessionStore(filepath.Join(t.TempDir(), "sessions.db"), "cxdb_session", time.Hour, "", false, "test-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+// sessionCookie creates a session for email and returns its signed cookie.
+func sessionCookie(t *testing.T, store *SessionStore, email string) *http.Cookie {
+	t.Helper()
+	id, err := store.Create(context.Background(), email, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	store.SetCookie(rec, id)
+	return rec.Result().Cookies()[0]
+}
+
+func TestResolveSession(t *testing.T) {
+	store := newTestSessionStore(t)
+	opts := AuthMiddlewareOptions{Store: store, TokenVerifiers: []BearerTokenVerifier{staticVerifier{"svc-token": "svc@example.com"}}}
+	cookie := sessionCookie(t, store, "alice@example.com")
+
+	for _, tc := range []struct {
+		name  string
+		setup func(*http.Request)
+		dev   bool
+		want  string
+	}{
+		{"cookie", func(r *http.Request) { r.AddCookie(cookie) }, false, "alice@example.com"},
+		{"cookie wins over bearer", func(r *http.Request) {
+			r.AddCookie(cookie)
+			r.Header.Set("Authorization", "Bearer svc-token")
+		}, false, "alice@example.com"},
+		{"bearer", func(r *http.Request) { r.Header.Set("Authorization", "Bearer svc-token") }, false, "svc@example.com"},
+		{"unknown bearer", func(r *http.Request) { r.Header.Set("Authorization", "Bearer nope") }, false, ""},
+		{"forged cookie", func(r *http.Request) {
+			r.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value + "x"})
+		}, false, ""},
+		{"anonymous", func(*http.Request) {}, false, ""},
+		{"dev mode", func(*http.Request) {}, true, "dev@localhost"},
+	} {
+		req := httptest.NewRequest("GET", "/v1/me/recent", nil)
+		tc.setup(req)
+		o := opts
+		o.DevBypass = tc.dev
+		got := ""
+		if sess := resolveSession(o, req); sess != nil {
+			got = sess.Email
+		}
+		if got != tc.want {
+			t.Errorf("%s: session %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestIsUserScopedPath(t *testing.T) {
+	for path, want := range map[string]bool{
+		"/v1/me/recent":       true,
+		"/v1/me/bookmarks/42": true,
+		"/V1/ME/bookmarks":    true,
+		"/v1/me":              false,
+		"/v1/metrics":         false,
+		"/api/v1/me":          false,
+		"/v1/contexts/1":      false,
+	} {
+		if got := isUserScopedPath(path); got != want {
+			t.Errorf("isUserScopedPath(%q) = %t, want %t", path, got, want)
+		}
+	}
+}
+
+func TestWritesCarryUserOnlyOnUserScopedPaths(t *testing.T) {
+	store := newTestSessionStore(t)
+	cookie := sessionCookie(t, store, "alice@example.com")
+	var user *Session
+	h := RequireAuthForReadsWithOptions(AuthMiddlewareOptions{Store: store}, http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		user = UserFromContext(r.Context())
+	}))
+
+	for _, tc := range []struct {
+		method, path string
+		cookie       bool
+		want         string
+	}{
+		{"POST", "/v1/me/recent", true, "alice@example.com"},
+		{"DELETE", "/v1/me/bookmarks/7", true, "alice@example.com"},
+		{"POST", "/v1/me/recent", false, ""},
+		{"POST", "/v1/contexts/create", true, ""},
+		{"GET", "/v1/me/recent", true, "alice@example.com"},
+	} {
+		user = nil
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		if tc.cookie {
+			req.AddCookie(cookie)
+		}
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		got := ""
+		if user != nil {
+			got = user.Email
+		}
+		if got != tc.want {
+			t.Errorf("%s %s cookie=%t: user %q, want %q", tc.method, tc.path, tc.cookie, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/strongdm/cxdb/gateway/pkg/apierror"
+)
+
+// Branding customizes the login page and the auth error pages.
+type Branding struct {
+	// OrgName replaces "CXDB" in page titles and headings.
+	OrgName string `json:"org_name,omitempty"`
+
+	// LogoURL is an image shown above the heading.
+	LogoURL string `json:"logo_url,omitempty"`
+
+	// HelpURL is where users are sent to request access or get support.
+	HelpURL string `json:"help_url,omitempty"`
+}
+
+// Title returns the product name shown to users.
+func (b Branding) Title() string {
+	if b.OrgName != "" {
+		return b.OrgName + " CXDB"
+	}
+	return "CXDB"
+}
+
+// BrandingHandler serves GET /auth/branding for the login page.
+func BrandingHandler(b Branding) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		_ = json.NewEncoder(w).Encode(struct {
+			Branding
+			Title string `json:"title"`
+		}{b, b.Title()})
+	}
+}
+
+// Auth error codes. They are stable and appear in JSON error bodies and in
+// the gateway logs.
+const (
+	ErrCodeDomainNotAllowed = "domain_not_allowed"
+	ErrCodeRoleNotAllowed   = "role_not_allowed"
+	ErrCodeAccessDenied     = "access_denied"
+	ErrCodeInvalidState     = "invalid_state"
+	ErrCodeExchangeFailed   = "exchange_failed"
+	ErrCodeProfileFailed    = "profile_failed"
+	ErrCodeSessionFailed    = "session_failed"
+)
+
+// AuthError describes a failed sign-in for the error page.
+type AuthError struct {
+	Status  int
+	Code    string
+	Title   string
+	Message string
+
+	// Detail is shown beneath the message, e.g. the rejected identity.
+	Detail string
+
+	// ActionLabel and ActionURL render the primary button.
+	ActionLabel string
+	ActionURL   string
+}
+
+// authErrorPage is a self-contained page: no scripts and only inline
+// styles, so it renders under the gateway CSP without the frontend bundle.
+var authErrorPage = template.Must(template.New("auth-error").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Error.Title}} - {{.Branding.Title}}</title>
+<style>
+body{margin:0;min-height:100vh;display:flex;align-items:center;justify-content:center;background:#0b0b0f;color:#e5e5ea;font-family:system-ui,-apple-system,sans-serif}
+main{max-width:28rem;margin:1rem;padding:2.5rem;text-align:center;background:#15151c;border:1px solid #2a2a35;border-radius:1rem}
+img{max-height:4rem;max-width:12rem;margin-bottom:1.5rem}
+h1{font-size:1.25rem;font-weight:600;margin:0 0 .25rem}
+h2{font-size:1rem;font-weight:500;color:#f87171;margin:0 0 1rem}
+p{color:#a1a1aa;line-height:1.5;margin:0 0 1rem}
+code{font-size:.875rem;color:#e5e5ea;background:#22222b;padding:.125rem .375rem;border-radius:.25rem}
+a.button{display:inline-block;margin-top:.5rem;padding:.625rem 1.25rem;background:#fff;color:#111;border-radius:.5rem;text-decoration:none;font-weight:500}
+.help{margin-top:1.5rem;font-size:.875rem}
+.help a{color:#a78bfa}
+.code{margin-top:1.5rem;font-size:.75rem;color:#71717a}
+</style>
+</head>
+<body>
+<main>
+{{if .Branding.LogoURL}}<img src="{{.Branding.LogoURL}}" alt="{{.Branding.Title}}">{{end}}
+<h1>{{.Branding.Title}}</h1>
+<h2>{{.Error.Title}}</h2>
+<p>{{.Error.Message}}</p>
+{{if .Error.Detail}}<p><code>{{.Error.Detail}}</code></p>{{end}}
+{{if .Error.ActionURL}}<a class="button" href="{{.Error.ActionURL}}">{{.Error.ActionLabel}}</a>{{end}}
+{{if .Branding.HelpURL}}<p class="help">Need access? <a href="{{.Branding.HelpURL}}">Contact your administrator</a>.</p>{{end}}
+<p class="code">Error code: {{.Error.Code}}</p>
+</main>
+</body>
+</html>
+`))
+
+// WriteAuthError renders e as an HTML page for browsers and as the standard
+// JSON error for API clients, with e.Code as the reason:
+//
+//	{"error": {"code": 403, "message": "...", "reason": "role_not_allowed", "help_url": "...", ...}}
+func WriteAuthError(w http.ResponseWriter, r *http.Request, b Branding, e AuthError) {
+	w.Header().Set("Cache-Control", "no-store")
+	if !wantsHTML(r) {
+		apierror.WriteDetail(w, r, apierror.Detail{
+			Code:    e.Status,
+			Message: e.Message,
+			Reason:  e.Code,
+			Detail:  e.Detail,
+			HelpURL: b.HelpURL,
+		})
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(e.Status)
+	_ = authErrorPage.Execute(w, struct {
+		Branding Branding
+		Error    AuthError
+	}{b, e})
+}
+
+func wantsHTML(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// signInAgain is the action offered when retrying the Google flow may help.
+func signInAgain(e AuthError) AuthError {
+	e.ActionLabel = "Sign in again"
+	e.ActionURL = "/auth/google/login"
+	return e
+}
+
+func domainNotAllowedError(email, allowedDomain string) AuthError {
+	return AuthError{
+		Status:      http.StatusForbidden,
+		Code:        ErrCodeDomainNotAllowed,
+		Title:       "Account not allowed",
+		Message:     "This instance only accepts Google accounts from @" + allowedDomain + ". Sign in with your work account instead.",
+		Detail:      email,
+		ActionLabel: "Use a different account",
+		ActionURL:   "/auth/google/login?switch_account=1",
+	}
+}
+
+func roleNotAllowedError(arn string) AuthError {
+	return AuthError{
+		Status:  http.StatusForbidden,
+		Code:    ErrCodeRoleNotAllowed,
+		Title:   "Role not allowed",
+		Message: "Your AWS identity is valid but its role is not in AWS_IAM_ALLOWED_ROLES. Ask an administrator to add it, or assume an allowed role.",
+		Detail:  arn,
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/strongdm/cxdb/gateway/pkg/apierror"
+)
+
+func TestBrandingHandler(t *testing.T) {
+	for _, tc := range []struct {
+		b         Branding
+		wantTitle string
+	}{
+		{Branding{}, "CXDB"},
+		{Branding{OrgName: "Acme", LogoURL: "https://cdn.example.com/logo.png", HelpURL: "https://help.example.com"}, "Acme CXDB"},
+	} {
+		rec := httptest.NewRecorder()
+		BrandingHandler(tc.b)(rec, httptest.NewRequest("GET", "/auth/branding", nil))
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		var got struct {
+			Branding
+			Title string `json:"title"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("decode %s: %v", rec.Body, err)
+		}
+		if got.Branding != tc.b || got.Title != tc.wantTitle {
+			t.Errorf("branding = %+v, want %+v titled %q", got, tc.b, tc.wantTitle)
+		}
+	}
+}
+
+func TestWriteAuthErrorNegotiates(t *testing.T) {
+	b := Branding{OrgName: "Acme", HelpURL: "https://help.example.com"}
+	e := roleNotAllowedError("arn:aws:sts::123:assumed-role/dev/alice")
+
+	// API clients get the standard JSON error.
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/auth/aws/token", nil)
+	req.Header.Set("Accept", "application/json")
+	WriteAuthError(rec, req, b, e)
+	if rec.Code != http.StatusForbidden || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("JSON: status %d, Content-Type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var body apierror.Body
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body, err)
+	}
+	if got := body.Error; got.Reason != ErrCodeRoleNotAllowed || got.Detail != e.Detail || got.HelpURL != b.HelpURL || got.Message != e.Message {
+		t.Errorf("JSON error = %+v", got)
+	}
+
+	// Browsers get the page.
+	rec = httptest.NewRecorder()
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+	WriteAuthError(rec, req, b, e)
+	if rec.Code != http.StatusForbidden || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("HTML: status %d, Content-Type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if rec.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("Cache-Control = %q", rec.Header().Get("Cache-Control"))
+	}
+	for _, want := range []string{"<title>Role not allowed - Acme CXDB</title>", e.Detail, `href="https://help.example.com"`, "Error code: " + ErrCodeRoleNotAllowed} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("page lacks %q", want)
+		}
+	}
+}
+
+func TestAuthErrorPageEscapes(t *testing.T) {
+	b := Branding{OrgName: `<script>alert("org")</script>`, LogoURL: `javascript:alert(1)`}
+	e := domainNotAllowedError(`eve@evil.example"><img src=x onerror=alert(1)>`, "example.com")
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/auth/google/callback", nil)
+	req.Header.Set("Accept", "text/html")
+	WriteAuthError(rec, req, b, e)
+	page := rec.Body.String()
+
+	for _, bad := range []string{"<script>", "<img src=x", `src="javascript:`} {
+		if strings.Contains(page, bad) {
+			t.Errorf("page contains unescaped %q", bad)
+		}
+	}
+	for _, want := range []string{"&lt;script&gt;", "&lt;img src=x onerror=alert(1)&gt;", `href="/auth/google/login?switch_account=1"`, "@example.com"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page lacks %q", want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/strongdm/cxdb/gateway/pkg/apierror"
+)
+
+// PublicReadTokenTTL is how long an anonymous read token stays valid.
+// Demo pages fetch a new one when it expires.
+const PublicReadTokenTTL = 1 * time.Hour
+
+// ContextFilter reports whether a context carries one of the public read
+// labels.
+type ContextFilter func(ctx context.Context, contextID uint64) (bool, error)
+
+// PublicReader issues anonymous read-only tokens for public demo
+// deployments. A token only grants GETs of the turns and provenance of
+// contexts the ContextFilter accepts; everything else still requires a real
+// session.
+type PublicReader struct {
+	signingKey []byte
+	issuer     string
+	labels     []string
+	allowed    ContextFilter
+	debug      bool
+}
+
+// NewPublicReader creates a PublicReader for contexts labelled with any of
+// labels. allowed performs the label check against the backend.
+func NewPublicReader(labels []string, signingKey []byte, issuer string, allowed ContextFilter) *PublicReader {
+	return &PublicReader{
+		signingKey: signingKey,
+		issuer:     issuer,
+		labels:     labels,
+		allowed:    allowed,
+		debug:      strings.Contains(os.Getenv("DEBUG"), "auth") || strings.Contains(os.Getenv("DEBUG"), "all"),
+	}
+}
+
+// TokenHandler handles POST /auth/public/token. It needs no credentials;
+// the returned token carries the public labels so the page can show them.
+func (p *PublicReader) TokenHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		apierror.Write(w, r, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	token, expiresAt, err := p.generateToken()
+	if err != nil {
+		if p.debug {
+			log.Printf("[public-read] token generation failed: %v", err)
+		}
+		apierror.Write(w, r, http.StatusInternalServerError, "token generation failed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(TokenExchangeResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		TokenType: "Bearer",
+	})
+}
+
+// generateToken creates a signed JWT scoped to the public labels.
+func (p *PublicReader) generateToken() (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(PublicReadTokenTTL)
+
+	token, err := jwt.NewBuilder().
+		Issuer(p.issuer).
+		Subject("public").
+		Audience([]string{p.issuer}).
+		IssuedAt(now).
+		Expiration(expiresAt).
+		Claim("cxdb:type", "public_read").
+		Claim("cxdb:labels", p.labels).
+		Build()
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("build token: %w", err)
+	}
+
+	signed, err := jwt.Sign(token, jwt.WithKey(jwa.HS256, p.signingKey))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("sign token: %w", err)
+	}
+
+	return string(signed), expiresAt, nil
+}
+
+// verify validates a token issued by TokenHandler.
+func (p *PublicReader) verify(tokenString string) error {
+	token, err := jwt.Parse([]byte(tokenString),
+		jwt.WithKey(jwa.HS256, p.signingKey),
+		jwt.WithValidate(true),
+		jwt.WithIssuer(p.issuer),
+		jwt.WithAudience(p.issuer),
+	)
+	if err != nil {
+		return fmt.Errorf("invalid token: %w", err)
+	}
+	if tokenType, _ := token.Get("cxdb:type"); tokenType != "public_read" {
+		return fmt.Errorf("wrong token type: %v", tokenType)
+	}
+	return nil
+}
+
+// authorize checks that a public reader may GET r: only
+// /v1/contexts/{id}/turns and /v1/contexts/{id}/provenance of allowlisted
+// contexts are readable. It returns 0 when the request may proceed,
+// otherwise the status to reject it with.
+func (p *PublicReader) authorize(r *http.Request) int {
+	rest, ok := strings.CutPrefix(r.URL.Path, "/v1/contexts/")
+	if !ok {
+		return http.StatusForbidden
+	}
+	parts := strings.Split(rest, "/")
+	if len(parts) != 2 || (parts[1] != "turns" && parts[1] != "provenance") {
+		return http.StatusForbidden
+	}
+	id, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return http.StatusForbidden
+	}
+
+	allowed, err := p.allowed(r.Context(), id)
+	if err != nil {
+		log.Printf("[public-read] label check for context %d failed: %v", id, err)
+		return http.StatusBadGateway
+	}
+	if !allowed {
+		// Indistinguishable from a missing context, so labels cannot be
+		// probed.
+		return http.StatusNotFound
+	}
+	return 0
+}
@@ -0,0 +1,141 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+const testPublicIssuer = "cxdb.example.com"
+
+// newPublicReadGateway serves everything behind the auth middleware with
+// public reads of contexts 1 and 2 allowed; context 13's label check fails.
+func newPublicReadGateway(t *testing.T) (*PublicReader, http.Handler, *bool) {
+	t.Helper()
+	filter := func(_ context.Context, id uint64) (bool, error) {
+		if id == 13 {
+			return false, errors.New("backend down")
+		}
+		return id == 1 || id == 2, nil
+	}
+	p := NewPublicReader([]string{"demo"}, []byte("test-secret"), testPublicIssuer, filter)
+	served := new(bool)
+	next := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		*served = true
+		if UserFromContext(r.Context()) != nil {
+			t.Errorf("%s: public reader got a user", r.URL.Path)
+		}
+	})
+	h := RequireAuthForReadsWithOptions(AuthMiddlewareOptions{Store: newTestSessionStore(t), PublicRead: p}, next)
+	return p, h, served
+}
+
+func publicToken(t *testing.T, p *PublicReader) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	p.TokenHandler(rec, httptest.NewRequest("POST", "/auth/public/token", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("token: status %d", rec.Code)
+	}
+	var resp TokenExchangeResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp.Token
+}
+
+func TestPublicReadScope(t *testing.T) {
+	p, h, served := newPublicReadGateway(t)
+	token := publicToken(t, p)
+
+	for _, tc := range []struct {
+		path string
+		want int // 0: served
+	}{
+		{"/v1/contexts/1/turns", 0},
+		{"/v1/contexts/2/provenance", 0},
+		{"/v1/contexts/3/turns", http.StatusNotFound},
+		{"/v1/contexts/3/provenance", http.StatusNotFound},
+		{"/v1/contexts/13/turns", http.StatusBadGateway},
+
+		{"/v1/contexts/1", http.StatusForbidden},
+		{"/v1/contexts/1/turns/", http.StatusForbidden},
+		{"/v1/contexts/1/turns/5", http.StatusForbidden},
+		{"/v1/contexts/1/access", http.StatusForbidden},
+		{"/v1/contexts/1/labels", http.StatusForbidden},
+		{"/v1/contexts/01x/turns", http.StatusForbidden},
+		{"/v1/contexts/search", http.StatusForbidden},
+		{"/v1/contexts/tree", http.StatusForbidden},
+		{"/v1/turns/5/fs", http.StatusForbidden},
+		{"/v1/turns/5/fs.tar.gz", http.StatusForbidden},
+		{"/v1/fsdiff", http.StatusForbidden},
+		{"/v1/blobs/abc", http.StatusForbidden},
+		{"/v1/binary", http.StatusForbidden},
+		{"/v1/me/recent", http.StatusForbidden},
+		{"/api/v1/me", http.StatusForbidden},
+
+		// Public paths need no token at all.
+		{"/v1/contexts", 0},
+	} {
+		*served = false
+		req := httptest.NewRequest("GET", tc.path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		switch {
+		case tc.want == 0 && !*served:
+			t.Errorf("%s: refused with %d, want served", tc.path, rec.Code)
+		case tc.want != 0 && (*served || rec.Code != tc.want):
+			t.Errorf("%s: served=%t status %d, want %d", tc.path, *served, rec.Code, tc.want)
+		}
+	}
+}
+
+func TestPublicReadRejectsOtherTokens(t *testing.T) {
+	p, h, served := newPublicReadGateway(t)
+
+	sign := func(key string, typ string, exp time.Time) string {
+		tok, err := jwt.NewBuilder().Issuer(testPublicIssuer).Audience([]string{testPublicIssuer}).
+			IssuedAt(time.Now().Add(-2*time.Hour)).Expiration(exp).Claim("cxdb:type", typ).Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		signed, err := jwt.Sign(tok, jwt.WithKey(jwa.HS256, []byte(key)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(signed)
+	}
+	future := time.Now().Add(time.Hour)
+	for name, token := range map[string]string{
+		"wrong key":  sign("other-secret", "public_read", future),
+		"wrong type": sign("test-secret", "session", future),
+		"expired":    sign("test-secret", "public_read", time.Now().Add(-time.Hour)),
+		"garbage":    "not-a-jwt",
+	} {
+		*served = false
+		req := httptest.NewRequest("GET", "/v1/contexts/1/turns", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if *served || rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: served=%t status %d, want 401", name, *served, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	p.TokenHandler(rec, httptest.NewRequest("GET", "/auth/public/token", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET token: status %d", rec.Code)
+	}
+}
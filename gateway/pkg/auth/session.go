@@ -146,6 +146,12 @@ func (s *SessionStore) Close() error {
 	return s.db.Close()
 }
 
+// DB returns the underlying database handle so other gateway-owned state
+// can share the session database.
+func (s *SessionStore) DB() *sql.DB {
+	return s.db
+}
+
 // Ping verifies the underlying SQLite database is reachable.
 func (s *SessionStore) Ping(ctx context.Context) error {
 	return s.db.PingContext(ctx)
@@ -0,0 +1,26 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+// Package contextid validates the decimal context IDs the gateway keys its
+// own SQLite state by, so every store spells an ID the same way.
+package contextid
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalid is returned for context IDs that are not unsigned integers.
+var ErrInvalid = errors.New("invalid context id")
+
+// Normalize validates a decimal context ID and strips surrounding space and
+// leading zeros.
+func Normalize(raw string) (string, error) {
+	id, err := strconv.ParseUint(strings.TrimSpace(raw), 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalid, raw)
+	}
+	return strconv.FormatUint(id, 10), nil
+}
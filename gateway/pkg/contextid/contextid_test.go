@@ -0,0 +1,28 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package contextid
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	for raw, want := range map[string]string{
+		"42":                   "42",
+		" 007 ":                "7",
+		"0":                    "0",
+		"18446744073709551615": "18446744073709551615",
+	} {
+		got, err := Normalize(raw)
+		if err != nil || got != want {
+			t.Errorf("Normalize(%q) = %q, %v; want %q", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{"", "x", "-1", "1.5", "18446744073709551616"} {
+		if _, err := Normalize(raw); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Normalize(%q) err = %v, want ErrInvalid", raw, err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+// Package cqlfilter decides whether a context matches a CQL condition by
+// asking the backend's context search, e.g.
+//
+//	id = 42 AND label IN ("prod")
+//
+// Verdicts are cached per context for 30 seconds, so a label change takes
+// up to that long to be noticed. Failed searches are not cached.
+package cqlfilter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// verdictTTL is how long a context's verdict is cached.
+	verdictTTL = 30 * time.Second
+	// maxVerdicts bounds the verdict cache; it is emptied when full.
+	maxVerdicts = 10000
+	// searchTimeout bounds one backend search.
+	searchTimeout = 5 * time.Second
+)
+
+// GetFunc sends a GET for path, including its query, to the backend.
+type GetFunc func(ctx context.Context, path string) (*http.Response, error)
+
+// Filter matches contexts against one CQL condition.
+type Filter struct {
+	cond string
+	get  GetFunc
+
+	mu       sync.Mutex
+	verdicts map[uint64]verdict
+}
+
+type verdict struct {
+	matches bool
+	at      time.Time
+}
+
+// New returns a filter for cond that searches through get.
+func New(cond string, get GetFunc) *Filter {
+	return &Filter{cond: cond, get: get, verdicts: make(map[uint64]verdict)}
+}
+
+// Matches reports whether contextID satisfies the filter's condition.
+func (f *Filter) Matches(ctx context.Context, contextID uint64) (bool, error) {
+	f.mu.Lock()
+	v, ok := f.verdicts[contextID]
+	f.mu.Unlock()
+	if ok && time.Since(v.at) < verdictTTL {
+		return v.matches, nil
+	}
+
+	matches, err := Search(ctx, f.get, "id = "+strconv.FormatUint(contextID, 10)+" AND "+f.cond)
+	if err != nil {
+		return false, err
+	}
+
+	f.mu.Lock()
+	if len(f.verdicts) >= maxVerdicts {
+		f.verdicts = make(map[uint64]verdict)
+	}
+	f.verdicts[contextID] = verdict{matches: matches, at: time.Now()}
+	f.mu.Unlock()
+	return matches, nil
+}
+
+// Search runs a backend context search and reports whether it matched
+// anything.
+func Search(ctx context.Context, get GetFunc, query string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, searchTimeout)
+	defer cancel()
+
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("limit", "1")
+	resp, err := get(ctx, "/v1/contexts/search?"+params.Encode())
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("backend returned %d", resp.StatusCode)
+	}
+	var result struct {
+		Contexts []json.RawMessage `json:"contexts"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, err
+	}
+	return len(result.Contexts) > 0, nil
+}
+
+// In renders a CQL condition that field is one of values, e.g.
+// label IN ("a", "b").
+func In(field string, values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = Quote(v)
+	}
+	return field + " IN (" + strings.Join(quoted, ", ") + ")"
+}
+
+// Quote renders s as a double-quoted CQL string literal.
+func Quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
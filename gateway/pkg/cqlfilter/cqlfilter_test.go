@@ -0,0 +1,62 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package cqlfilter
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIn(t *testing.T) {
+	got := In("label", []string{"demo", `say "hi"`, `C:\tmp`})
+	if want := `label IN ("demo", "say \"hi\"", "C:\\tmp")`; got != want {
+		t.Errorf("In = %s, want %s", got, want)
+	}
+}
+
+func TestFilterCachesVerdicts(t *testing.T) {
+	var searches []string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query().Get("q")
+		searches = append(searches, q)
+		switch {
+		case r.URL.Query().Get("limit") != "1":
+			http.Error(w, "unbounded search", http.StatusBadRequest)
+		case strings.HasPrefix(q, "id = 7 "):
+			_, _ = io.WriteString(w, `{"contexts":[{"context_id":"7"}]}`)
+		case strings.HasPrefix(q, "id = 9 "):
+			http.Error(w, "storage offline", http.StatusServiceUnavailable)
+		default:
+			_, _ = io.WriteString(w, `{"contexts":[]}`)
+		}
+	}))
+	defer backend.Close()
+
+	f := New(`tag IN ("agent")`, func(ctx context.Context, path string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, backend.URL+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		return http.DefaultClient.Do(req)
+	})
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if ok, err := f.Matches(ctx, 7); !ok || err != nil {
+			t.Errorf("context 7: %t, %v", ok, err)
+		}
+		if ok, err := f.Matches(ctx, 8); ok || err != nil {
+			t.Errorf("context 8: %t, %v", ok, err)
+		}
+		if _, err := f.Matches(ctx, 9); err == nil {
+			t.Error("backend failure not reported")
+		}
+	}
+	if len(searches) != 4 || searches[0] != `id = 7 AND tag IN ("agent")` {
+		t.Errorf("searches = %q", searches)
+	}
+}
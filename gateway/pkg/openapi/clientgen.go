@@ -0,0 +1,368 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+)
+
+// GenerateClient renders typed request methods and response structs for
+// every client-visible route. The output relies on the hand-written Client
+// and its do helper in the target package.
+func GenerateClient(pkg string) ([]byte, error) {
+	g := &clientGen{}
+	for _, s := range Schemas {
+		g.genSchema(s)
+	}
+	for _, r := range Routes {
+		if r.SkipClient {
+			continue
+		}
+		if err := g.genRoute(r); err != nil {
+			return nil, err
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by cmd/openapi-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", pkg)
+	out.WriteString("import (\n")
+	body := g.buf.String()
+	for _, imp := range []struct{ path, ident string }{
+		{"context", "context."},
+		{"encoding/json", "json."},
+		{"net/http", "http."},
+		{"net/url", "url."},
+		{"strconv", "strconv."},
+		{"time", "time."},
+	} {
+		if strings.Contains(body, imp.ident) {
+			fmt.Fprintf(&out, "%q\n", imp.path)
+		}
+	}
+	out.WriteString(")\n\n")
+	out.WriteString(body)
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated client: %w", err)
+	}
+	return src, nil
+}
+
+type clientGen struct {
+	buf bytes.Buffer
+}
+
+func (g *clientGen) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *clientGen) genSchema(s Schema) {
+	g.printf("// %s is the %s schema.\n", s.Name, s.Name)
+	if s.Description != "" {
+		g.printf("//\n// %s\n", s.Description)
+	}
+	g.printf("type %s struct {\n", s.Name)
+	for _, f := range s.Fields {
+		if f.Description != "" {
+			g.printf("// %s\n", f.Description)
+		}
+		typ := goType(f.Type)
+		tag := f.Name
+		if f.Optional {
+			tag += ",omitempty"
+			if isSchemaRef(f.Type) {
+				typ = "*" + typ
+			}
+		}
+		g.printf("%s %s `json:%q`\n", goName(f.Name), typ, tag)
+	}
+	g.printf("}\n\n")
+}
+
+func (g *clientGen) genRoute(r Route) error {
+	name := goName(r.OperationID)
+
+	var args, optional []Param
+	for _, p := range r.Params {
+		if p.In == "path" || p.Required {
+			args = append(args, p)
+		} else {
+			optional = append(optional, p)
+		}
+	}
+
+	paramsType := name + "Params"
+	if len(optional) > 0 {
+		g.printf("// %s holds the optional parameters for %s. Zero values are omitted.\n", paramsType, name)
+		g.printf("type %s struct {\n", paramsType)
+		for _, p := range optional {
+			if p.Description != "" {
+				g.printf("// %s\n", p.Description)
+			}
+			g.printf("%s %s\n", goName(p.Name), goType(p.Type))
+		}
+		g.printf("}\n\n")
+	}
+
+	sig := []string{"ctx context.Context"}
+	for _, p := range args {
+		sig = append(sig, fmt.Sprintf("%s %s", argName(p.Name), goType(p.Type)))
+	}
+	if r.Body != "" {
+		sig = append(sig, "body "+goType(r.Body))
+	}
+	if len(optional) > 0 {
+		sig = append(sig, "params *"+paramsType)
+	}
+
+	var ret, zero, outExpr string
+	switch {
+	case r.Response == "any":
+		ret, zero, outExpr = "(json.RawMessage, error)", "nil", "&out"
+	case r.Response != "":
+		ret, zero, outExpr = fmt.Sprintf("(*%s, error)", r.Response), "nil", "out"
+	case r.binary():
+		ret, zero, outExpr = "([]byte, error)", "nil", "&out"
+	default:
+		ret = "error"
+	}
+	g.printf("// %s calls %s %s.\n//\n// %s\n", name, r.Method, r.Path, r.Summary)
+	g.printf("func (c *Client) %s(%s) %s {\n", name, strings.Join(sig, ", "), ret)
+
+	pathExpr, err := pathExpression(r.Path, r.Params)
+	if err != nil {
+		return err
+	}
+	g.printf("reqPath := %s\n", pathExpr)
+
+	hasQuery := false
+	hasHeader := false
+	for _, p := range r.Params {
+		switch p.In {
+		case "query":
+			hasQuery = true
+		case "header":
+			hasHeader = true
+		}
+	}
+	queryVar, headerVar := "nil", "nil"
+	if hasQuery {
+		queryVar = "query"
+		g.printf("query := url.Values{}\n")
+	}
+	if hasHeader {
+		headerVar = "header"
+		g.printf("header := http.Header{}\n")
+	}
+	for _, p := range args {
+		switch p.In {
+		case "query":
+			g.printf("query.Set(%q, %s)\n", p.Name, formatValue(p.Type, argName(p.Name)))
+		case "header":
+			g.printf("header.Set(%q, %s)\n", p.Name, formatValue(p.Type, argName(p.Name)))
+		}
+	}
+	if len(optional) > 0 {
+		g.printf("if params != nil {\n")
+		for _, p := range optional {
+			field := "params." + goName(p.Name)
+			target := "query"
+			if p.In == "header" {
+				target = "header"
+			}
+			g.printf("if %s {\n%s.Set(%q, %s)\n}\n", nonZero(p.Type, field), target, p.Name, formatValue(p.Type, field))
+		}
+		g.printf("}\n")
+	}
+
+	bodyVar := "nil"
+	if r.Body != "" {
+		bodyVar = "body"
+	}
+
+	switch {
+	case ret == "error":
+		g.printf("return c.do(ctx, %q, reqPath, %s, %s, %s, nil)\n", r.Method, queryVar, headerVar, bodyVar)
+	case outExpr == "out":
+		g.printf("out := new(%s)\n", r.Response)
+		g.printf("if err := c.do(ctx, %q, reqPath, %s, %s, %s, out); err != nil {\nreturn %s, err\n}\n", r.Method, queryVar, headerVar, bodyVar, zero)
+		g.printf("return out, nil\n")
+	default:
+		outType := "json.RawMessage"
+		if r.binary() {
+			outType = "[]byte"
+		}
+		g.printf("var out %s\n", outType)
+		g.printf("if err := c.do(ctx, %q, reqPath, %s, %s, %s, &out); err != nil {\nreturn %s, err\n}\n", r.Method, queryVar, headerVar, bodyVar, zero)
+		g.printf("return out, nil\n")
+	}
+	g.printf("}\n\n")
+	return nil
+}
+
+// pathExpression turns /a/{b}/c into a Go string concatenation.
+func pathExpression(path string, params []Param) (string, error) {
+	byName := map[string]Param{}
+	for _, p := range params {
+		if p.In == "path" {
+			byName[p.Name] = p
+		}
+	}
+	var parts []string
+	rest := path
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(rest, '}')
+		if end < start {
+			return "", fmt.Errorf("openapi: malformed path %q", path)
+		}
+		if start > 0 {
+			parts = append(parts, fmt.Sprintf("%q", rest[:start]))
+		}
+		name := rest[start+1 : end]
+		p, ok := byName[name]
+		if !ok {
+			return "", fmt.Errorf("openapi: path %q references undeclared parameter %q", path, name)
+		}
+		switch {
+		case p.Rest:
+			parts = append(parts, "escapePathRest("+argName(name)+")")
+		case p.Type == "string":
+			parts = append(parts, "url.PathEscape("+argName(name)+")")
+		default:
+			parts = append(parts, formatValue(p.Type, argName(name)))
+		}
+		rest = rest[end+1:]
+	}
+	if rest != "" {
+		parts = append(parts, fmt.Sprintf("%q", rest))
+	}
+	return strings.Join(parts, " + "), nil
+}
+
+func formatValue(typ, expr string) string {
+	switch typ {
+	case "int32":
+		return "strconv.Itoa(" + expr + ")"
+	case "int64":
+		return "strconv.FormatInt(" + expr + ", 10)"
+	case "uint64":
+		return "strconv.FormatUint(" + expr + ", 10)"
+	case "bool":
+		// The backend treats "1" as true for flag parameters.
+		return "formatBool(" + expr + ")"
+	default:
+		return expr
+	}
+}
+
+func nonZero(typ, expr string) string {
+	switch typ {
+	case "bool":
+		return expr
+	case "string":
+		return expr + ` != ""`
+	default:
+		return expr + " != 0"
+	}
+}
+
+func goType(t string) string {
+	if elem, ok := strings.CutPrefix(t, "[]"); ok {
+		return "[]" + goType(elem)
+	}
+	switch t {
+	case "string":
+		return "string"
+	case "int32":
+		return "int"
+	case "int64":
+		return "int64"
+	case "uint64":
+		return "uint64"
+	case "bool":
+		return "bool"
+	case "time":
+		return "time.Time"
+	case "any":
+		return "json.RawMessage"
+	default:
+		return t
+	}
+}
+
+func isSchemaRef(t string) bool {
+	switch t {
+	case "string", "int32", "int64", "uint64", "bool", "time", "any":
+		return false
+	}
+	return !strings.HasPrefix(t, "[]")
+}
+
+// initialisms are upper-cased whole when they form a word of a Go name.
+var initialisms = map[string]bool{
+	"api": true, "aws": true, "id": true, "ip": true, "json": true,
+	"http": true, "ttl": true, "url": true, "uri": true,
+}
+
+// goName converts snake_case, kebab-case, and camelCase identifiers to an
+// exported Go name.
+func goName(s string) string {
+	var b strings.Builder
+	for _, w := range splitWords(s) {
+		b.WriteString(exportWord(w))
+	}
+	return b.String()
+}
+
+// argName is goName with its first word lower-cased.
+func argName(s string) string {
+	words := splitWords(s)
+	if len(words) == 0 {
+		return s
+	}
+	var b strings.Builder
+	b.WriteString(strings.ToLower(words[0]))
+	for _, w := range words[1:] {
+		b.WriteString(exportWord(w))
+	}
+	return b.String()
+}
+
+func exportWord(w string) string {
+	lw := strings.ToLower(w)
+	if initialisms[lw] {
+		return strings.ToUpper(lw)
+	}
+	if w == strings.ToUpper(w) && len(w) > 1 {
+		// Already an initialism in the source (e.g. "AWS").
+		return w
+	}
+	return strings.ToUpper(w[:1]) + w[1:]
+}
+
+func splitWords(s string) []string {
+	var words []string
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == '_' || r == '-' }) {
+		start := 0
+		for i := 1; i < len(part); i++ {
+			prevUpper := part[i-1] >= 'A' && part[i-1] <= 'Z'
+			curUpper := part[i] >= 'A' && part[i] <= 'Z'
+			if curUpper && !prevUpper {
+				words = append(words, part[start:i])
+				start = i
+			}
+		}
+		words = append(words, part[start:])
+	}
+	return words
+}
@@ -0,0 +1,618 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package openapi
+
+// Route describes one HTTP endpoint reachable through the gateway, either
+// served by the gateway itself or proxied to the cxdb backend.
+//
+// The route table is the single source of truth for the published OpenAPI
+// document and for the generated Go HTTP client in clients/go/httpclient.
+type Route struct {
+	Method string
+
+	// Path is the OpenAPI path template, e.g. /v1/contexts/{context_id}/turns.
+	Path string
+
+	// OperationID is the camelCase operation name. The generated client
+	// method is the same name with the first letter upper-cased.
+	OperationID string
+
+	Summary string
+	Tag     string
+
+	// Public routes are served without a session or bearer token.
+	Public bool
+
+	Params []Param
+
+	// Body names the schema of the JSON request body, if any.
+	Body string
+
+	// Response names the schema of a JSON 200 response. Use "any" for
+	// free-form JSON and leave empty when the route returns no JSON body.
+	Response string
+
+	// ContentType overrides the response media type for non-JSON routes.
+	ContentType string
+
+	// SkipClient omits the route from the generated client (browser
+	// redirects and streaming endpoints).
+	SkipClient bool
+}
+
+// binary reports whether the route returns raw bytes.
+func (r Route) binary() bool {
+	return r.ContentType == contentTypeOctetStream || r.ContentType == contentTypeGzip
+}
+
+// Param describes a path, query, or header parameter.
+type Param struct {
+	Name        string
+	In          string // "path", "query", or "header"
+	Type        string // field type, see Field.Type
+	Required    bool
+	Description string
+	Enum        []string
+
+	// Rest marks a trailing path parameter that may contain slashes.
+	Rest bool
+}
+
+// Schema is a named JSON object.
+type Schema struct {
+	Name        string
+	Description string
+	Fields      []Field
+}
+
+// Field is one property of a Schema.
+//
+// Type is one of "string", "int32", "int64", "uint64", "bool", "time", "any"
+// (free-form JSON), the name of another schema, or any of those prefixed
+// with "[]".
+type Field struct {
+	Name        string
+	Type        string
+	Description string
+	Optional    bool
+}
+
+const (
+	contentTypeJSON        = "application/json"
+	contentTypeOctetStream = "application/octet-stream"
+	contentTypeGzip        = "application/gzip"
+	contentTypeEventStream = "text/event-stream"
+	contentTypeText        = "text/plain"
+)
+
+var contextIDParam = Param{Name: "context_id", In: "path", Type: "uint64", Required: true, Description: "Context ID."}
+
+var turnIDParam = Param{Name: "turn_id", In: "path", Type: "uint64", Required: true, Description: "Turn ID."}
+
+// Routes lists every documented endpoint in display order.
+var Routes = []Route{
+	// --- System ---
+	{
+		Method: "GET", Path: "/healthz", OperationID: "healthz", Tag: "system", Public: true,
+		Summary: "Gateway liveness probe.", ContentType: contentTypeText,
+	},
+	{
+		Method: "GET", Path: "/readyz", OperationID: "readyz", Tag: "system", Public: true,
+		Summary: "Gateway readiness probe (checks the session database).", ContentType: contentTypeText,
+	},
+	{
+		Method: "GET", Path: "/v1/metrics", OperationID: "getMetrics", Tag: "system", Public: true,
+		Summary: "Aggregate server metrics.", Response: "any",
+	},
+	{
+		Method: "GET", Path: "/v1/openapi.json", OperationID: "getOpenAPI", Tag: "system", Public: true,
+		Summary: "This OpenAPI document.", Response: "any",
+	},
+
+	// --- Auth ---
+	{
+		Method: "GET", Path: "/auth/google/login", OperationID: "googleLogin", Tag: "auth", Public: true,
+		Summary: "Redirect to the Google consent screen.", SkipClient: true,
+	},
+	{
+		Method: "GET", Path: "/auth/google/callback", OperationID: "googleCallback", Tag: "auth", Public: true,
+		Summary: "OAuth callback; issues a session cookie.", SkipClient: true,
+		Params: []Param{
+			{Name: "code", In: "query", Type: "string"},
+			{Name: "state", In: "query", Type: "string"},
+			{Name: "error", In: "query", Type: "string"},
+		},
+	},
+	{
+		Method: "GET", Path: "/auth/google/logout", OperationID: "googleLogout", Tag: "auth", Public: true,
+		Summary: "Clear the session and redirect to the login page.", SkipClient: true,
+	},
+	{
+		Method: "GET", Path: "/auth/branding", OperationID: "getBranding", Tag: "auth", Public: true,
+		Summary: "Login page branding.", Response: "Branding",
+	},
+	{
+		Method: "POST", Path: "/auth/aws/token", OperationID: "exchangeAWSToken", Tag: "auth", Public: true,
+		Summary: "Exchange a presigned STS GetCallerIdentity URL for a bearer token.",
+		Params: []Param{
+			{Name: "X-AWS-Auth", In: "header", Type: "string", Required: true, Description: "Presigned STS GetCallerIdentity URL."},
+		},
+		Response: "TokenExchangeResponse",
+	},
+	{
+		Method: "POST", Path: "/auth/public/token", OperationID: "issuePublicReadToken", Tag: "auth", Public: true,
+		Summary:  "Anonymous read-only token for contexts with a public label (PUBLIC_READ_MODE only).",
+		Response: "TokenExchangeResponse",
+	},
+	{
+		Method: "GET", Path: "/api/v1/me", OperationID: "getMe", Tag: "auth",
+		Summary: "The authenticated user.", Response: "User",
+	},
+
+	// --- Per-user state ---
+	{
+		Method: "GET", Path: "/v1/me/recent", OperationID: "listRecentContexts", Tag: "me",
+		Summary: "Contexts the authenticated user viewed recently, newest first.",
+		Params: []Param{
+			{Name: "limit", In: "query", Type: "int32", Description: "Maximum entries to return (default and max 50)."},
+		},
+		Response: "RecentContextList",
+	},
+	{
+		Method: "POST", Path: "/v1/me/recent", OperationID: "recordContextView", Tag: "me",
+		Summary: "Record that the authenticated user viewed a context.", Body: "ContextRef",
+	},
+	{
+		Method: "DELETE", Path: "/v1/me/recent", OperationID: "clearRecentContexts", Tag: "me",
+		Summary: "Clear the authenticated user's recently viewed contexts.",
+	},
+	{
+		Method: "GET", Path: "/v1/me/bookmarks", OperationID: "listBookmarks", Tag: "me",
+		Summary: "The authenticated user's bookmarked contexts, newest first.", Response: "BookmarkList",
+	},
+	{
+		Method: "POST", Path: "/v1/me/bookmarks", OperationID: "addBookmark", Tag: "me",
+		Summary: "Bookmark a context, or update the label of an existing bookmark.",
+		Body:    "ContextRef", Response: "Bookmark",
+	},
+	{
+		Method: "PUT", Path: "/v1/me/bookmarks/{context_id}", OperationID: "putBookmark", Tag: "me",
+		Summary: "Bookmark a context, or update the label of an existing bookmark.",
+		Params:  []Param{contextIDParam}, Body: "BookmarkLabel", Response: "Bookmark",
+	},
+	{
+		Method: "DELETE", Path: "/v1/me/bookmarks/{context_id}", OperationID: "deleteBookmark", Tag: "me",
+		Summary: "Remove a bookmark.",
+		Params:  []Param{contextIDParam},
+	},
+
+	// --- Contexts ---
+	{
+		Method: "GET", Path: "/v1/contexts", OperationID: "listContexts", Tag: "contexts", Public: true,
+		Summary: "List recently active contexts.",
+		Params: []Param{
+			{Name: "limit", In: "query", Type: "int32", Description: "Maximum contexts to return (default 20)."},
+			{Name: "tag", In: "query", Type: "string", Description: "Only return contexts with this client tag."},
+			{Name: "include_provenance", In: "query", Type: "bool", Description: "Include stored provenance."},
+		},
+		Response: "ContextList",
+	},
+	{
+		Method: "GET", Path: "/v1/contexts/search", OperationID: "searchContexts", Tag: "contexts",
+		Summary: "Search contexts with a CQL query.",
+		Params: []Param{
+			{Name: "q", In: "query", Type: "string", Required: true, Description: "CQL query."},
+			{Name: "limit", In: "query", Type: "int32"},
+		},
+		Response: "ContextSearchResult",
+	},
+	{
+		Method: "GET", Path: "/v1/contexts/{context_id}/provenance", OperationID: "getContextProvenance", Tag: "contexts",
+		Summary:  "Provenance recorded for a context.",
+		Params:   []Param{contextIDParam},
+		Response: "ContextProvenance",
+	},
+	{
+		Method: "GET", Path: "/v1/contexts/{context_id}/access", OperationID: "getContextAccess", Tag: "contexts",
+		Summary: "Who read a context recently, most recent first, from the gateway's access log.",
+		Params: []Param{
+			contextIDParam,
+			{Name: "limit", In: "query", Type: "int32", Description: "Maximum entries to return (default 100, max 1000)."},
+		},
+		Response: "ContextAccessList",
+	},
+	{
+		Method: "GET", Path: "/v1/contexts/tree", OperationID: "getContextTree", Tag: "contexts",
+		Summary: "Lineage forest of recently active contexts, built from provenance.",
+		Params: []Param{
+			{Name: "limit", In: "query", Type: "int32", Description: "Maximum contexts to consider (default 500, max 5000)."},
+			{Name: "tag", In: "query", Type: "string", Description: "Only include contexts with this client tag."},
+			{Name: "root", In: "query", Type: "string", Description: "Only return the tree containing this context."},
+		},
+		Response: "ContextTree",
+	},
+
+	// --- Turns ---
+	{
+		Method: "GET", Path: "/v1/contexts/{context_id}/turns", OperationID: "listTurns", Tag: "turns",
+		Summary: "Page backwards through a context's turns.",
+		Params: []Param{
+			contextIDParam,
+			{Name: "limit", In: "query", Type: "int32", Description: "Maximum turns to return (default 64)."},
+			{Name: "before_turn_id", In: "query", Type: "uint64", Description: "Return turns older than this turn."},
+			{Name: "view", In: "query", Type: "string", Enum: []string{"typed", "raw", "both"}},
+			{Name: "type_hint_mode", In: "query", Type: "string", Enum: []string{"inherit", "latest", "explicit"}},
+			{Name: "as_type_id", In: "query", Type: "string"},
+			{Name: "as_type_version", In: "query", Type: "int32"},
+			{Name: "include_unknown", In: "query", Type: "bool"},
+			{Name: "bytes_render", In: "query", Type: "string", Enum: []string{"base64", "hex", "len_only"}},
+			{Name: "u64_format", In: "query", Type: "string", Enum: []string{"string", "number"}},
+			{Name: "enum_render", In: "query", Type: "string", Enum: []string{"label", "number", "both"}},
+			{Name: "time_render", In: "query", Type: "string", Enum: []string{"iso", "unix_ms"}},
+			{Name: "If-None-Match", In: "header", Type: "string", Description: "ETag of an earlier listing (its head turn ID); answers 304 while the head is unchanged."},
+		},
+		Response: "TurnList",
+	},
+
+	// --- Filesystem snapshots ---
+	{
+		Method: "GET", Path: "/v1/turns/{turn_id}/fs", OperationID: "listFs", Tag: "fs",
+		Summary: "List a directory in the turn's filesystem snapshot.",
+		Params: []Param{
+			turnIDParam,
+			{Name: "path", In: "query", Type: "string", Description: "Directory path relative to the snapshot root."},
+		},
+		Response: "FsListing",
+	},
+	{
+		Method: "GET", Path: "/v1/turns/{turn_id}/fs/{path}", OperationID: "getFsFile", Tag: "fs",
+		Summary: "Raw content of a file in the turn's filesystem snapshot.",
+		Params: []Param{
+			turnIDParam,
+			{Name: "path", In: "path", Type: "string", Required: true, Rest: true, Description: "File path; may contain slashes."},
+		},
+		ContentType: contentTypeOctetStream,
+	},
+	{
+		Method: "GET", Path: "/v1/turns/{turn_id}/fs.tar.gz", OperationID: "exportFs", Tag: "fs",
+		Summary: "The turn's whole filesystem snapshot as a gzipped tar archive, assembled by the gateway.",
+		Params: []Param{
+			turnIDParam,
+			{Name: "context_id", In: "query", Type: "uint64", Description: "Context the turn belongs to, logged as read in the access log."},
+		},
+		ContentType: contentTypeGzip,
+	},
+	{
+		Method: "GET", Path: "/v1/fsdiff", OperationID: "getFsDiff", Tag: "fs",
+		Summary: "Files and symlinks added, removed, or modified between two turns' filesystem snapshots.",
+		Params: []Param{
+			{Name: "from", In: "query", Type: "uint64", Required: true, Description: "Turn with the old snapshot."},
+			{Name: "to", In: "query", Type: "uint64", Required: true, Description: "Turn with the new snapshot."},
+			{Name: "text", In: "query", Type: "bool", Description: "Include unified diffs of small text files."},
+			{Name: "max_text_bytes", In: "query", Type: "int32", Description: "Size limit per side for text diffs (default 65536, max 1048576)."},
+			{Name: "context_id", In: "query", Type: "uint64", Description: "Context the turns belong to, logged as read in the access log."},
+		},
+		Response: "FsDiff",
+	},
+
+	// --- Blobs ---
+	{
+		Method: "GET", Path: "/v1/blobs/{hash}", OperationID: "getBlob", Tag: "blobs",
+		Summary: "Raw blob content by BLAKE3 hash.",
+		Params: []Param{
+			{Name: "hash", In: "path", Type: "string", Required: true, Description: "Hex-encoded BLAKE3-256 hash."},
+		},
+		ContentType: contentTypeOctetStream,
+	},
+
+	// --- Events ---
+	{
+		Method: "GET", Path: "/v1/events", OperationID: "streamEvents", Tag: "events", Public: true,
+		Summary:     "Server-sent events for context creation and turn appends.",
+		ContentType: contentTypeEventStream, SkipClient: true,
+	},
+
+	// --- Registry ---
+	{
+		Method: "PUT", Path: "/v1/registry/bundles/{bundle_id}", OperationID: "putRegistryBundle", Tag: "registry",
+		Summary: "Publish a type registry bundle.",
+		Params: []Param{
+			{Name: "bundle_id", In: "path", Type: "string", Required: true},
+		},
+		Body: "any",
+	},
+	{
+		Method: "GET", Path: "/v1/registry/bundles/{bundle_id}", OperationID: "getRegistryBundle", Tag: "registry",
+		Summary: "Fetch a type registry bundle.",
+		Params: []Param{
+			{Name: "bundle_id", In: "path", Type: "string", Required: true},
+		},
+		Response: "any",
+	},
+	{
+		Method: "GET", Path: "/v1/registry/types/{type_id}/versions/{type_version}", OperationID: "getTypeVersion", Tag: "registry",
+		Summary: "Descriptor for one version of a registered type.",
+		Params: []Param{
+			{Name: "type_id", In: "path", Type: "string", Required: true},
+			{Name: "type_version", In: "path", Type: "int32", Required: true},
+		},
+		Response: "any",
+	},
+	{
+		Method: "GET", Path: "/v1/registry/renderers", OperationID: "getRendererManifest", Tag: "registry",
+		Summary: "Renderer manifest for registered types.", Response: "any",
+	},
+}
+
+// Schemas lists the named JSON objects referenced by Routes.
+var Schemas = []Schema{
+	{
+		Name: "Error", Description: "Error body returned by the gateway, including backend errors it proxies.",
+		Fields: []Field{
+			{Name: "error", Type: "ErrorDetail"},
+		},
+	},
+	{
+		Name: "ErrorDetail",
+		Fields: []Field{
+			{Name: "code", Type: "int32", Description: "HTTP status."},
+			{Name: "message", Type: "string"},
+			{Name: "request_id", Type: "string", Optional: true, Description: "Also sent as X-Request-Id; quote it when reporting a problem."},
+			{Name: "reason", Type: "string", Optional: true, Description: "Stable cause for sign-in and token exchange failures, e.g. domain_not_allowed or role_not_allowed."},
+			{Name: "detail", Type: "string", Optional: true},
+			{Name: "help_url", Type: "string", Optional: true, Description: "Where to request access or get support."},
+		},
+	},
+	{
+		Name: "User",
+		Fields: []Field{
+			{Name: "email", Type: "string"},
+			{Name: "name", Type: "string"},
+			{Name: "picture", Type: "string", Optional: true},
+		},
+	},
+	{
+		Name: "Branding",
+		Fields: []Field{
+			{Name: "title", Type: "string", Description: "Product name to display."},
+			{Name: "org_name", Type: "string", Optional: true},
+			{Name: "logo_url", Type: "string", Optional: true},
+			{Name: "help_url", Type: "string", Optional: true, Description: "Where to request access or get support."},
+		},
+	},
+	{
+		Name: "TokenExchangeResponse",
+		Fields: []Field{
+			{Name: "token", Type: "string"},
+			{Name: "expires_at", Type: "time"},
+			{Name: "token_type", Type: "string"},
+		},
+	},
+	{
+		Name: "ContextRef",
+		Fields: []Field{
+			{Name: "context_id", Type: "string"},
+			{Name: "label", Type: "string", Optional: true, Description: "Bookmark label; ignored when recording views."},
+		},
+	},
+	{
+		Name: "BookmarkLabel",
+		Fields: []Field{
+			{Name: "label", Type: "string", Optional: true},
+		},
+	},
+	{
+		Name: "RecentContext",
+		Fields: []Field{
+			{Name: "context_id", Type: "string"},
+			{Name: "viewed_at", Type: "time"},
+			{Name: "view_count", Type: "int64"},
+		},
+	},
+	{
+		Name:        "ContextAccess",
+		Description: "One reader's run of reads of a context; reads less than 15 minutes apart share an entry.",
+		Fields: []Field{
+			{Name: "reader", Type: "string", Description: "Email or token subject, or \"anonymous\" for public read tokens."},
+			{Name: "kind", Type: "string", Description: "\"user\" or \"public\"."},
+			{Name: "first_read_at", Type: "time"},
+			{Name: "last_read_at", Type: "time"},
+			{Name: "reads", Type: "int64"},
+		},
+	},
+	{
+		Name: "ContextAccessList",
+		Fields: []Field{
+			{Name: "context_id", Type: "string"},
+			{Name: "access", Type: "[]ContextAccess"},
+		},
+	},
+	{
+		Name: "RecentContextList",
+		Fields: []Field{
+			{Name: "recent", Type: "[]RecentContext"},
+		},
+	},
+	{
+		Name: "Bookmark",
+		Fields: []Field{
+			{Name: "context_id", Type: "string"},
+			{Name: "label", Type: "string", Optional: true},
+			{Name: "created_at", Type: "time"},
+		},
+	},
+	{
+		Name: "BookmarkList",
+		Fields: []Field{
+			{Name: "bookmarks", Type: "[]Bookmark"},
+		},
+	},
+	{
+		Name: "ContextSummary",
+		Fields: []Field{
+			{Name: "context_id", Type: "string"},
+			{Name: "head_turn_id", Type: "string"},
+			{Name: "head_depth", Type: "int32"},
+			{Name: "created_at_unix_ms", Type: "int64"},
+			{Name: "is_live", Type: "bool"},
+			{Name: "client_tag", Type: "string", Optional: true},
+			{Name: "title", Type: "string", Optional: true},
+			{Name: "session_id", Type: "string", Optional: true},
+			{Name: "last_activity_at", Type: "int64", Optional: true},
+			{Name: "provenance", Type: "any", Optional: true},
+		},
+	},
+	{
+		Name: "SessionSummary",
+		Fields: []Field{
+			{Name: "session_id", Type: "string"},
+			{Name: "client_tag", Type: "string"},
+			{Name: "connected_at", Type: "int64"},
+			{Name: "last_activity_at", Type: "int64"},
+			{Name: "context_count", Type: "int32"},
+			{Name: "peer_addr", Type: "string", Optional: true},
+		},
+	},
+	{
+		Name: "ContextList",
+		Fields: []Field{
+			{Name: "contexts", Type: "[]ContextSummary"},
+			{Name: "count", Type: "int32"},
+			{Name: "active_sessions", Type: "[]SessionSummary"},
+			{Name: "active_tags", Type: "[]string"},
+		},
+	},
+	{
+		Name: "ContextSearchResult",
+		Fields: []Field{
+			{Name: "contexts", Type: "[]ContextSummary"},
+			{Name: "total_count", Type: "int32"},
+			{Name: "elapsed_ms", Type: "int64"},
+			{Name: "query", Type: "string"},
+		},
+	},
+	{
+		Name: "ContextProvenance",
+		Fields: []Field{
+			{Name: "context_id", Type: "string"},
+			{Name: "provenance", Type: "any", Description: "Null when the context has no provenance."},
+		},
+	},
+	{
+		Name: "ContextTreeNode",
+		Fields: []Field{
+			{Name: "context_id", Type: "string"},
+			{Name: "parent_context_id", Type: "string", Optional: true},
+			{Name: "kind", Type: "string", Description: "root, or the provenance spawn_reason (fork, quest, delegation, sub_agent)."},
+			{Name: "title", Type: "string", Optional: true},
+			{Name: "client_tag", Type: "string", Optional: true},
+			{Name: "on_behalf_of", Type: "string", Optional: true},
+			{Name: "is_live", Type: "bool"},
+			{Name: "head_depth", Type: "int32"},
+			{Name: "created_at_unix_ms", Type: "int64"},
+			{Name: "last_activity_at", Type: "int64", Optional: true},
+			{Name: "depth", Type: "int32", Description: "Distance from the node's root."},
+			{Name: "descendants", Type: "int32"},
+			{Name: "detached", Type: "bool", Optional: true, Description: "The parent is outside the listing or part of a cycle."},
+			{Name: "children", Type: "[]ContextTreeNode"},
+		},
+	},
+	{
+		Name: "ContextTree",
+		Fields: []Field{
+			{Name: "roots", Type: "[]ContextTreeNode"},
+			{Name: "count", Type: "int32"},
+			{Name: "by_kind", Type: "any", Description: "Node count per kind."},
+		},
+	},
+	{
+		Name: "TypeRef",
+		Fields: []Field{
+			{Name: "type_id", Type: "string"},
+			{Name: "type_version", Type: "int32"},
+		},
+	},
+	{
+		Name: "Turn",
+		Fields: []Field{
+			{Name: "turn_id", Type: "string"},
+			{Name: "parent_turn_id", Type: "string"},
+			{Name: "depth", Type: "int32"},
+			{Name: "declared_type", Type: "TypeRef"},
+			{Name: "decoded_as", Type: "TypeRef", Optional: true},
+			{Name: "data", Type: "any", Optional: true, Description: "Typed projection (view=typed|both)."},
+			{Name: "unknown", Type: "any", Optional: true},
+			{Name: "content_hash_b3", Type: "string", Optional: true},
+			{Name: "encoding", Type: "int32", Optional: true},
+			{Name: "compression", Type: "int32", Optional: true},
+			{Name: "uncompressed_len", Type: "int32", Optional: true},
+			{Name: "bytes_b64", Type: "string", Optional: true},
+			{Name: "bytes_hex", Type: "string", Optional: true},
+			{Name: "bytes_len", Type: "int64", Optional: true},
+		},
+	},
+	{
+		Name: "TurnListMeta",
+		Fields: []Field{
+			{Name: "context_id", Type: "string"},
+			{Name: "head_turn_id", Type: "string"},
+			{Name: "head_depth", Type: "int32"},
+			{Name: "registry_bundle_id", Type: "string", Optional: true},
+		},
+	},
+	{
+		Name: "TurnList",
+		Fields: []Field{
+			{Name: "meta", Type: "TurnListMeta"},
+			{Name: "turns", Type: "[]Turn"},
+			{Name: "next_before_turn_id", Type: "string", Optional: true},
+		},
+	},
+	{
+		Name: "FsEntry",
+		Fields: []Field{
+			{Name: "name", Type: "string"},
+			{Name: "kind", Type: "string", Description: "file, dir, or symlink."},
+			{Name: "mode", Type: "string", Description: "Octal permission bits."},
+			{Name: "size", Type: "int64"},
+			{Name: "hash", Type: "string"},
+		},
+	},
+	{
+		Name: "FsListing",
+		Fields: []Field{
+			{Name: "turn_id", Type: "string"},
+			{Name: "path", Type: "string"},
+			{Name: "fs_root_hash", Type: "string"},
+			{Name: "entries", Type: "[]FsEntry"},
+		},
+	},
+	{
+		Name: "FsDiffEntry",
+		Fields: []Field{
+			{Name: "path", Type: "string"},
+			{Name: "kind", Type: "string", Description: "file or symlink; for removed entries, the old kind."},
+			{Name: "size", Type: "int64"},
+			{Name: "hash", Type: "string"},
+			{Name: "old_kind", Type: "string", Optional: true, Description: "Set when a modified entry changed kind."},
+			{Name: "old_size", Type: "int64", Optional: true},
+			{Name: "old_hash", Type: "string", Optional: true},
+			{Name: "diff", Type: "string", Optional: true, Description: "Unified diff, with text=1."},
+			{Name: "diff_skipped", Type: "string", Optional: true, Description: "Why no diff was produced: binary, too_large, too_complex, or limit."},
+		},
+	},
+	{
+		Name: "FsDiff",
+		Fields: []Field{
+			{Name: "from_turn_id", Type: "string"},
+			{Name: "to_turn_id", Type: "string"},
+			{Name: "from_root_hash", Type: "string"},
+			{Name: "to_root_hash", Type: "string"},
+			{Name: "added", Type: "[]FsDiffEntry"},
+			{Name: "removed", Type: "[]FsDiffEntry"},
+			{Name: "modified", Type: "[]FsDiffEntry"},
+			{Name: "truncated", Type: "bool", Optional: true, Description: "More than 10000 paths changed."},
+		},
+	},
+}
@@ -0,0 +1,289 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+// Package openapi describes the gateway's HTTP API as an OpenAPI 3 document.
+//
+// The document is built from the Routes and Schemas tables, served at
+// /v1/openapi.json, and rendered to api/openapi.yaml together with the Go
+// client in clients/go/httpclient by cmd/openapi-gen.
+package openapi
+
+//go:generate go run ../../cmd/openapi-gen -yaml ../../api/openapi.yaml -client ../../../clients/go/httpclient/zz_generated.go
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/strongdm/cxdb/gateway/pkg/apierror"
+)
+
+// Version is the API version advertised in the document's info block.
+const Version = "1.0.0"
+
+// kv is one member of an ordered JSON object.
+type kv struct {
+	Key   string
+	Value any
+}
+
+// object is a JSON object that keeps insertion order, so the rendered
+// document is stable and diffs cleanly.
+type object []kv
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(m.Key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(m.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Document builds the OpenAPI 3.0 document for the gateway.
+func Document() any {
+	return object{
+		{"openapi", "3.0.3"},
+		{"info", object{
+			{"title", "CXDB Gateway API"},
+			{"version", Version},
+			{"description", "HTTP API exposed by the cxdb gateway. Routes under /v1/ are proxied to the cxdb server; " +
+				"/auth/ and /api/ routes are served by the gateway itself."},
+		}},
+		{"tags", buildTags()},
+		{"paths", buildPaths()},
+		{"components", object{
+			{"schemas", buildSchemas()},
+			{"securitySchemes", object{
+				{"sessionCookie", object{
+					{"type", "apiKey"},
+					{"in", "cookie"},
+					{"name", "cxdb_session"},
+				}},
+				{"bearerToken", object{
+					{"type", "http"},
+					{"scheme", "bearer"},
+				}},
+			}},
+		}},
+		{"security", []any{
+			object{{"sessionCookie", []string{}}},
+			object{{"bearerToken", []string{}}},
+		}},
+	}
+}
+
+// JSON renders the document as indented JSON.
+func JSON() ([]byte, error) {
+	raw, err := json.Marshal(Document())
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, raw, "", "  "); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// Handler serves the document as JSON.
+func Handler() http.Handler {
+	var (
+		once sync.Once
+		body []byte
+		err  error
+	)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() { body, err = JSON() })
+		if err != nil {
+			apierror.Write(w, r, http.StatusInternalServerError, "openapi document unavailable")
+			return
+		}
+		w.Header().Set("Content-Type", contentTypeJSON)
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		_, _ = w.Write(body)
+	})
+}
+
+func buildTags() []any {
+	var tags []any
+	seen := map[string]bool{}
+	for _, r := range Routes {
+		if seen[r.Tag] {
+			continue
+		}
+		seen[r.Tag] = true
+		tags = append(tags, object{{"name", r.Tag}})
+	}
+	return tags
+}
+
+func buildPaths() object {
+	var paths object
+	index := map[string]int{}
+	for _, r := range Routes {
+		i, ok := index[r.Path]
+		if !ok {
+			i = len(paths)
+			index[r.Path] = i
+			paths = append(paths, kv{r.Path, object{}})
+		}
+		ops := paths[i].Value.(object)
+		paths[i].Value = append(ops, kv{strings.ToLower(r.Method), buildOperation(r)})
+	}
+	return paths
+}
+
+func buildOperation(r Route) object {
+	op := object{
+		{"operationId", r.OperationID},
+		{"summary", r.Summary},
+		{"tags", []string{r.Tag}},
+	}
+	if r.Public {
+		op = append(op, kv{"security", []any{}})
+	}
+	if len(r.Params) > 0 {
+		var params []any
+		for _, p := range r.Params {
+			params = append(params, buildParam(p))
+		}
+		op = append(op, kv{"parameters", params})
+	}
+	if r.Body != "" {
+		op = append(op, kv{"requestBody", object{
+			{"required", true},
+			{"content", object{{contentTypeJSON, object{{"schema", typeSchema(r.Body)}}}}},
+		}})
+	}
+	op = append(op, kv{"responses", buildResponses(r)})
+	return op
+}
+
+func buildParam(p Param) object {
+	schema := typeSchema(p.Type)
+	if len(p.Enum) > 0 {
+		schema = append(schema, kv{"enum", p.Enum})
+	}
+	out := object{
+		{"name", p.Name},
+		{"in", p.In},
+	}
+	if p.Description != "" {
+		out = append(out, kv{"description", p.Description})
+	}
+	if p.Required || p.In == "path" {
+		out = append(out, kv{"required", true})
+	}
+	if p.Rest {
+		out = append(out, kv{"allowReserved", true})
+	}
+	return append(out, kv{"schema", schema})
+}
+
+func buildResponses(r Route) object {
+	var ok object
+	switch {
+	case r.SkipClient && r.ContentType == "":
+		return object{
+			{"302", object{{"description", "Redirect."}}},
+		}
+	case r.Response != "":
+		ok = object{
+			{"description", "OK"},
+			{"content", object{{contentTypeJSON, object{{"schema", typeSchema(r.Response)}}}}},
+		}
+	case r.ContentType != "":
+		schema := object{{"type", "string"}}
+		if r.binary() {
+			schema = append(schema, kv{"format", "binary"})
+		}
+		ok = object{
+			{"description", "OK"},
+			{"content", object{{r.ContentType, object{{"schema", schema}}}}},
+		}
+	default:
+		ok = object{{"description", "OK"}}
+	}
+	errResp := object{
+		{"description", "Error"},
+		{"content", object{{contentTypeJSON, object{{"schema", typeSchema("Error")}}}}},
+	}
+	out := object{{"200", ok}}
+	for _, p := range r.Params {
+		if p.In == "header" && p.Name == "If-None-Match" {
+			out = append(out, kv{"304", object{{"description", "Not Modified."}}})
+		}
+	}
+	return append(out, kv{"default", errResp})
+}
+
+func buildSchemas() object {
+	var out object
+	for _, s := range Schemas {
+		props := object{}
+		var required []string
+		for _, f := range s.Fields {
+			fs := typeSchema(f.Type)
+			if f.Description != "" {
+				fs = append(fs, kv{"description", f.Description})
+			}
+			props = append(props, kv{f.Name, fs})
+			if !f.Optional {
+				required = append(required, f.Name)
+			}
+		}
+		schema := object{{"type", "object"}}
+		if s.Description != "" {
+			schema = append(schema, kv{"description", s.Description})
+		}
+		schema = append(schema, kv{"properties", props})
+		if len(required) > 0 {
+			schema = append(schema, kv{"required", required})
+		}
+		out = append(out, kv{s.Name, schema})
+	}
+	return out
+}
+
+// typeSchema maps a field type (see Field.Type) to a JSON schema.
+func typeSchema(t string) object {
+	if elem, ok := strings.CutPrefix(t, "[]"); ok {
+		return object{{"type", "array"}, {"items", typeSchema(elem)}}
+	}
+	switch t {
+	case "string":
+		return object{{"type", "string"}}
+	case "int32":
+		return object{{"type", "integer"}, {"format", "int32"}}
+	case "int64":
+		return object{{"type", "integer"}, {"format", "int64"}}
+	case "uint64":
+		return object{{"type", "integer"}, {"format", "uint64"}}
+	case "bool":
+		return object{{"type", "boolean"}}
+	case "time":
+		return object{{"type", "string"}, {"format", "date-time"}}
+	case "any":
+		return object{}
+	default:
+		return object{{"$ref", "#/components/schemas/" + t}}
+	}
+}
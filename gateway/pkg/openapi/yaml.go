@@ -0,0 +1,135 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// YAML renders the document as YAML. The emitter only understands the value
+// shapes Document produces, which keeps the gateway free of a YAML dependency.
+func YAML() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("# Code generated by cmd/openapi-gen. DO NOT EDIT.\n")
+	if err := writeYAML(&buf, Document(), 0); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeYAML(buf *bytes.Buffer, v any, indent int) error {
+	pad := strings.Repeat("  ", indent)
+	switch val := v.(type) {
+	case object:
+		for _, m := range val {
+			buf.WriteString(pad + yamlString(m.Key) + ":")
+			if err := writeYAMLValue(buf, m.Value, indent+1); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range val {
+			buf.WriteString(pad + "-")
+			if err := writeYAMLListItem(buf, item, indent+1); err != nil {
+				return err
+			}
+		}
+	case []string:
+		for _, item := range val {
+			buf.WriteString(pad + "- " + yamlString(item) + "\n")
+		}
+	default:
+		return fmt.Errorf("openapi: unsupported yaml value %T", v)
+	}
+	return nil
+}
+
+// writeYAMLValue writes the remainder of a "key:" line.
+func writeYAMLValue(buf *bytes.Buffer, v any, indent int) error {
+	switch val := v.(type) {
+	case object:
+		if len(val) == 0 {
+			buf.WriteString(" {}\n")
+			return nil
+		}
+		buf.WriteString("\n")
+		return writeYAML(buf, val, indent)
+	case []any:
+		if len(val) == 0 {
+			buf.WriteString(" []\n")
+			return nil
+		}
+		buf.WriteString("\n")
+		return writeYAML(buf, val, indent)
+	case []string:
+		if len(val) == 0 {
+			buf.WriteString(" []\n")
+			return nil
+		}
+		buf.WriteString("\n")
+		return writeYAML(buf, val, indent)
+	default:
+		s, err := yamlScalar(v)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(" " + s + "\n")
+		return nil
+	}
+}
+
+// writeYAMLListItem writes the remainder of a "-" line. Objects start on the
+// same line as the dash.
+func writeYAMLListItem(buf *bytes.Buffer, v any, indent int) error {
+	obj, ok := v.(object)
+	if !ok || len(obj) == 0 {
+		return writeYAMLValue(buf, v, indent)
+	}
+	var nested bytes.Buffer
+	if err := writeYAML(&nested, obj, indent); err != nil {
+		return err
+	}
+	buf.WriteString(" ")
+	buf.WriteString(strings.TrimPrefix(nested.String(), strings.Repeat("  ", indent)))
+	return nil
+}
+
+func yamlScalar(v any) (string, error) {
+	switch val := v.(type) {
+	case string:
+		return yamlString(val), nil
+	case bool:
+		return strconv.FormatBool(val), nil
+	case int:
+		return strconv.Itoa(val), nil
+	default:
+		return "", fmt.Errorf("openapi: unsupported yaml scalar %T", v)
+	}
+}
+
+// yamlString quotes s unless it is unambiguously a plain string.
+func yamlString(s string) string {
+	if s == "" || needsQuote(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func needsQuote(s string) bool {
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~":
+		return true
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return true
+	}
+	if strings.ContainsAny(s[:1], "!&*-?[]{}|>'\"%@`#,$ ") {
+		return true
+	}
+	return strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") ||
+		strings.HasSuffix(s, " ")
+}
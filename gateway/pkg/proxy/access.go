@@ -0,0 +1,225 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/strongdm/cxdb/gateway/pkg/accesslog"
+	"github.com/strongdm/cxdb/gateway/pkg/apierror"
+	"github.com/strongdm/cxdb/gateway/pkg/auth"
+	"github.com/strongdm/cxdb/gateway/pkg/contextid"
+)
+
+// contextAccess serves GET /v1/contexts/{id}/access: who read the context
+// recently, most recent first (?limit=N). Only access log admins and the
+// context's owner, the provenance on_behalf_of_email or writer_subject, may
+// list its readers.
+func (s *Server) contextAccess(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		apierror.Write(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if s.accessLog == nil {
+		apierror.Write(w, r, http.StatusNotFound, "access log disabled")
+		return
+	}
+
+	contextID, err := contextid.Normalize(r.PathValue("id"))
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "invalid context_id")
+		return
+	}
+	if !s.isAccessLogAdmin(user) {
+		owner, err := s.ownsContext(r.Context(), user, contextID)
+		if err != nil {
+			s.logger.Error("context_owner_lookup_failed", "context_id", contextID, "err", err)
+			apierror.Write(w, r, http.StatusBadGateway, "Bad Gateway")
+			return
+		}
+		if !owner {
+			apierror.Write(w, r, http.StatusForbidden, "only the context owner or an access log admin may list readers")
+			return
+		}
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	access, err := s.accessLog.Access(r.Context(), contextID, limit)
+	if err != nil {
+		s.logger.Error("context_access_list_failed", "context_id", contextID, "err", err)
+		apierror.Write(w, r, http.StatusInternalServerError, "internal error")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"context_id": contextID, "access": access})
+}
+
+// isAccessLogAdmin reports whether user is listed in ACCESS_LOG_ADMINS.
+func (s *Server) isAccessLogAdmin(user *auth.Session) bool {
+	for _, admin := range s.cfg.AccessLogAdmins {
+		if strings.EqualFold(admin, user.Email) {
+			return true
+		}
+	}
+	return false
+}
+
+// ownsContext reports whether the context was created on behalf of user or
+// written by them, according to its provenance. Contexts without
+// provenance have no owner.
+func (s *Server) ownsContext(ctx context.Context, user *auth.Session, contextID string) (bool, error) {
+	resp, err := s.backendGet(ctx, "/v1/contexts/"+contextID+"/provenance")
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("backend returned %d", resp.StatusCode)
+	}
+	var body struct {
+		Provenance *struct {
+			OnBehalfOfEmail string `json:"on_behalf_of_email"`
+			WriterSubject   string `json:"writer_subject"`
+		} `json:"provenance"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return false, err
+	}
+	if body.Provenance == nil || user.Email == "" {
+		return false, nil
+	}
+	return strings.EqualFold(body.Provenance.OnBehalfOfEmail, user.Email) ||
+		strings.EqualFold(body.Provenance.WriterSubject, user.Email), nil
+}
+
+// logContextAccess records successful reads of /v1/contexts/{id} and its
+// subresources in the access log.
+func (s *Server) logContextAccess(next http.Handler) http.Handler {
+	if s.accessLog == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contextID, ok := readContextID(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		if sw.status == http.StatusOK {
+			s.recordAccess(r, contextID)
+		}
+	})
+}
+
+// accessRecordInterval throttles recording of one reader's reads of one
+// context, so polling dashboards do not write to the database on every
+// request. The store coalesces entries over a longer window anyway.
+const accessRecordInterval = time.Minute
+
+// accessThrottle remembers when each reader's read of each context was
+// last recorded. The zero value is ready to use.
+type accessThrottle struct {
+	mu    sync.Mutex
+	last  map[accessKey]time.Time
+	swept time.Time
+}
+
+type accessKey struct{ reader, contextID string }
+
+// admit returns the contextIDs whose reads by reader are due to be
+// recorded at now, and marks them recorded.
+func (t *accessThrottle) admit(reader string, contextIDs []string, now time.Time) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.last == nil {
+		t.last = make(map[accessKey]time.Time)
+	}
+	if now.Sub(t.swept) >= accessRecordInterval {
+		for k, at := range t.last {
+			if now.Sub(at) >= accessRecordInterval {
+				delete(t.last, k)
+			}
+		}
+		t.swept = now
+	}
+
+	var due []string
+	for _, id := range contextIDs {
+		k := accessKey{reader, id}
+		if at, ok := t.last[k]; ok && now.Sub(at) < accessRecordInterval {
+			continue
+		}
+		t.last[k] = now
+		due = append(due, id)
+	}
+	return due
+}
+
+// recordAccess logs reads of contextIDs by the caller of r in the
+// background, at most once per accessRecordInterval for each reader and
+// context. Requests without a user got through on a public read token.
+func (s *Server) recordAccess(r *http.Request, contextIDs ...string) {
+	if s.accessLog == nil || len(contextIDs) == 0 {
+		return
+	}
+	reader, kind := accesslog.AnonymousReader, accesslog.KindPublic
+	if user := auth.UserFromContext(r.Context()); user != nil {
+		reader, kind = user.Email, accesslog.KindUser
+	}
+	if contextIDs = s.accessSeen.admit(reader, contextIDs, time.Now()); len(contextIDs) == 0 {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := s.accessLog.Record(ctx, reader, kind, contextIDs...); err != nil {
+			s.logger.Warn("context_access_record_failed", "reader", reader, "contexts", len(contextIDs), "err", err)
+		}
+	}()
+}
+
+// accessContextIDs returns the optional ?context_id= that turn-addressed
+// reads name their context with. It writes a 400 and reports false when
+// the ID is invalid.
+func accessContextIDs(w http.ResponseWriter, r *http.Request) ([]string, bool) {
+	raw := r.URL.Query().Get("context_id")
+	if raw == "" {
+		return nil, true
+	}
+	id, err := contextid.Normalize(raw)
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "invalid context_id")
+		return nil, false
+	}
+	return []string{id}, true
+}
+
+// readContextID matches GET /v1/contexts/{id} and /v1/contexts/{id}/...
+// with a numeric ID.
+func readContextID(r *http.Request) (string, bool) {
+	if r.Method != http.MethodGet {
+		return "", false
+	}
+	rest, ok := strings.CutPrefix(r.URL.Path, "/v1/contexts/")
+	if !ok {
+		return "", false
+	}
+	id, _, _ := strings.Cut(rest, "/")
+	if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+		return "", false
+	}
+	return id, true
+}
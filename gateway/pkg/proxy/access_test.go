@@ -0,0 +1,278 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/strongdm/cxdb/gateway/internal/config"
+	"github.com/strongdm/cxdb/gateway/pkg/accesslog"
+	"github.com/strongdm/cxdb/gateway/pkg/auth"
+)
+
+func newTestAccessLog(t *testing.T) *accesslog.Store {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "gateway.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	store, err := accesslog.NewStore(db, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+// waitForReaders polls until contextID has n readers in the log, since
+// reads are recorded in the background.
+func waitForReaders(t *testing.T, store *accesslog.Store, contextID string, n int) []accesslog.Access {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		access, err := store.Access(context.Background(), contextID, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(access) >= n || time.Now().After(deadline) {
+			if len(access) != n {
+				t.Fatalf("context %s readers = %+v, want %d", contextID, access, n)
+			}
+			return access
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestReadContextID(t *testing.T) {
+	for _, tc := range []struct {
+		method, path string
+		want         string
+	}{
+		{"GET", "/v1/contexts/42", "42"},
+		{"GET", "/v1/contexts/42/turns", "42"},
+		{"GET", "/v1/contexts/42/provenance", "42"},
+		{"POST", "/v1/contexts/42/turns", ""},
+		{"GET", "/v1/contexts/search", ""},
+		{"GET", "/v1/contexts", ""},
+		{"GET", "/v1/turns/42/fs", ""},
+	} {
+		got, ok := readContextID(httptest.NewRequest(tc.method, tc.path, nil))
+		if got != tc.want || ok != (tc.want != "") {
+			t.Errorf("%s %s = %q, %t; want %q", tc.method, tc.path, got, ok, tc.want)
+		}
+	}
+}
+
+func TestContextAccessWithoutLog(t *testing.T) {
+	s := &Server{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/contexts/{id}/access", s.contextAccess)
+
+	// Reads pass through untouched when the log is disabled.
+	var served bool
+	h := s.logContextAccess(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { served = true }))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/contexts/1/turns", nil))
+	if !served {
+		t.Error("read not served")
+	}
+
+	for _, tc := range []struct {
+		user bool
+		want int
+	}{
+		{false, http.StatusUnauthorized},
+		{true, http.StatusNotFound},
+	} {
+		req := httptest.NewRequest("GET", "/v1/contexts/1/access", nil)
+		if tc.user {
+			req = req.WithContext(auth.WithUser(req.Context(), &auth.Session{Email: "a@example.com"}))
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("user=%t: status %d, want %d", tc.user, rec.Code, tc.want)
+		}
+	}
+}
+
+func TestContextAccessOwners(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/contexts/1/provenance":
+			_, _ = io.WriteString(w, `{"context_id":"1","provenance":{"on_behalf_of_email":"Owner@example.com"}}`)
+		case "/v1/contexts/2/provenance":
+			_, _ = io.WriteString(w, `{"context_id":"2","provenance":{"writer_subject":"system:serviceaccount:agents:runner"}}`)
+		case "/v1/contexts/3/provenance":
+			_, _ = io.WriteString(w, `{"context_id":"3","provenance":null}`)
+		default:
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}
+	}))
+	defer backend.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	rp, err := NewReverseProxy(backend.URL, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{
+		cfg:       config.Config{AccessLogAdmins: []string{"admin@example.com"}},
+		proxy:     rp,
+		logger:    logger,
+		accessLog: newTestAccessLog(t),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/contexts/{id}/access", s.contextAccess)
+
+	for _, tc := range []struct {
+		user, id string
+		want     int
+	}{
+		{"owner@example.com", "1", http.StatusOK},
+		{"other@example.com", "1", http.StatusForbidden},
+		{"system:serviceaccount:agents:runner", "2", http.StatusOK},
+		{"owner@example.com", "2", http.StatusForbidden},
+		{"owner@example.com", "3", http.StatusForbidden},
+		{"Admin@example.com", "3", http.StatusOK},
+		{"admin@example.com", "4", http.StatusOK},
+		{"owner@example.com", "4", http.StatusBadGateway},
+		{"owner@example.com", "x", http.StatusBadRequest},
+	} {
+		req := httptest.NewRequest("GET", "/v1/contexts/"+tc.id+"/access", nil)
+		req = req.WithContext(auth.WithUser(req.Context(), &auth.Session{Email: tc.user}))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s on %s: status %d, want %d", tc.user, tc.id, rec.Code, tc.want)
+		}
+	}
+}
+
+func TestRecordAccessFromHandlers(t *testing.T) {
+	store := newTestAccessLog(t)
+
+	// A tree focused on a context logs one read of it; an unfocused tree
+	// logs none.
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"contexts":[
+			{"context_id":"1","created_at_unix_ms":1},
+			{"context_id":"2","created_at_unix_ms":2,"provenance":{"parent_context_id":1,"spawn_reason":"fork"}}
+		]}`)
+	}))
+	defer backend.Close()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	rp, err := NewReverseProxy(backend.URL, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{proxy: rp, logger: logger, accessLog: store}
+	for _, path := range []string{"/v1/contexts/tree", "/v1/contexts/tree?root=2"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req = req.WithContext(auth.WithUser(req.Context(), &auth.Session{Email: "a@example.com"}))
+		s.contextTree(httptest.NewRecorder(), req)
+	}
+	if access := waitForReaders(t, store, "2", 1); access[0].Reader != "a@example.com" || access[0].Kind != accesslog.KindUser {
+		t.Errorf("context 2 access = %+v", access)
+	}
+	waitForReaders(t, store, "1", 0)
+
+	// Turn-addressed reads log the context they name.
+	rec := httptest.NewRecorder()
+	s.fsDiff(rec, httptest.NewRequest("GET", "/v1/fsdiff?from=1&to=2&context_id=x", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid context_id: status %d", rec.Code)
+	}
+	_, fsBackend := newFsBackend(t, time.Time{})
+	rp, err = NewReverseProxy(fsBackend.URL, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.proxy = rp
+	rec = httptest.NewRecorder()
+	s.fsDiff(rec, httptest.NewRequest("GET", "/v1/fsdiff?from=7&to=7&context_id=0009", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("fsdiff: status %d: %s", rec.Code, rec.Body)
+	}
+	if access := waitForReaders(t, store, "9", 1); access[0].Reader != accesslog.AnonymousReader || access[0].Kind != accesslog.KindPublic {
+		t.Errorf("fsdiff access = %+v", access)
+	}
+}
+
+func TestTunnelReads(t *testing.T) {
+	var recorded []string
+	reads := newTunnelReads(func(contextID string) { recorded = append(recorded, contextID) })
+	header := func(frame []byte) []byte { return frame[:tunnelFrameHeader] }
+	ctxPayload := func(id uint64) []byte { return binary.LittleEndian.AppendUint64(nil, id) }
+
+	// Answered reads are logged once per interval; refused ones and other
+	// messages are not.
+	reads.request(protocolFrame(tunnelMsgGetLast, 1, ctxPayload(7)))
+	reads.response(header(protocolFrame(tunnelMsgGetLast, 1, nil)))
+	reads.request(protocolFrame(tunnelMsgGetHead, 2, ctxPayload(7)))
+	reads.response(header(protocolFrame(tunnelMsgGetHead, 2, nil)))
+	reads.request(protocolFrame(tunnelMsgGetHead, 3, ctxPayload(8)))
+	reads.response(header(protocolFrame(tunnelMsgError, 3, nil)))
+	reads.request(protocolFrame(5, 4, ctxPayload(9)))
+	reads.response(header(protocolFrame(5, 4, nil)))
+	reads.request(protocolFrame(tunnelMsgGetLast, 5, []byte{1}))
+	reads.response(header(protocolFrame(tunnelMsgGetLast, 5, nil)))
+	if len(recorded) != 1 || recorded[0] != "7" {
+		t.Fatalf("recorded = %v, want [7]", recorded)
+	}
+	if len(reads.pending) != 0 {
+		t.Fatalf("pending = %v", reads.pending)
+	}
+
+	// A routing key ahead of the context ID is skipped.
+	routed := protocolFrame(tunnelMsgGetHead, 7, append(binary.LittleEndian.AppendUint16(nil, 5), append([]byte("shard"), ctxPayload(12)...)...))
+	binary.LittleEndian.PutUint16(routed[6:8], tunnelFlagRoutingKey)
+	reads.request(routed)
+	reads.response(header(protocolFrame(tunnelMsgGetHead, 7, nil)))
+	if len(recorded) != 2 || recorded[1] != "12" {
+		t.Fatalf("recorded = %v, want the routed read of 12", recorded)
+	}
+
+	reads.logged["7"] = time.Now().Add(-tunnelReadLogInterval)
+	reads.request(protocolFrame(tunnelMsgGetLast, 6, ctxPayload(7)))
+	reads.response(header(protocolFrame(tunnelMsgGetLast, 6, nil)))
+	if len(recorded) != 3 {
+		t.Fatalf("recorded = %v after the interval", recorded)
+	}
+}
+
+func TestAccessThrottle(t *testing.T) {
+	var th accessThrottle
+	now := time.Now()
+	if due := th.admit("a@example.com", []string{"1", "2"}, now); len(due) != 2 {
+		t.Fatalf("first reads: due %v", due)
+	}
+
+	// Repeats within the interval are dropped per reader and context.
+	if due := th.admit("a@example.com", []string{"1", "3"}, now.Add(time.Second)); len(due) != 1 || due[0] != "3" {
+		t.Errorf("repeat read: due %v, want [3]", due)
+	}
+	if due := th.admit("b@example.com", []string{"1"}, now.Add(time.Second)); len(due) != 1 {
+		t.Errorf("other reader: due %v", due)
+	}
+
+	// Once the interval has passed reads are recorded again, and stale
+	// entries are swept.
+	later := now.Add(accessRecordInterval + 2*time.Second)
+	if due := th.admit("a@example.com", []string{"1"}, later); len(due) != 1 {
+		t.Errorf("after interval: due %v", due)
+	}
+	if len(th.last) != 1 {
+		t.Errorf("%d entries after sweep, want 1", len(th.last))
+	}
+}
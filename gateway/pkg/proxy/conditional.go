@@ -0,0 +1,129 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// headProbeQuery asks the backend for the smallest turn listing that still
+// carries the context head in its meta.
+const headProbeQuery = "?limit=1&view=raw&bytes_render=len_only"
+
+// conditionalTurns answers GET /v1/contexts/{id}/turns with 304 Not
+// Modified when If-None-Match names the context's current head turn, so
+// dashboards polling a context skip the listing while nothing is appended.
+// The ETag is the head turn ID, set on listings by setHeadETag. Paging
+// requests with before_turn_id are not conditional.
+func (s *Server) conditionalTurns(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contextID, ok := viewedContextID(r)
+		ifNoneMatch := r.Header.Get("If-None-Match")
+		if !ok || ifNoneMatch == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		head, err := s.backendHead(r.Context(), contextID)
+		if err != nil {
+			// The listing reports backend failures in the usual shape.
+			s.logger.Warn("head_probe_failed", "context_id", contextID, "err", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		etag := headETag(head)
+		if !etagMatches(ifNoneMatch, etag) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusNotModified)
+	})
+}
+
+// backendHead returns the head turn ID of a context.
+func (s *Server) backendHead(ctx context.Context, contextID string) (string, error) {
+	if limit := s.cfg.ProxyTimeouts.Metadata; limit > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limit)
+		defer cancel()
+	}
+	resp, err := s.backendGet(ctx, "/v1/contexts/"+contextID+"/turns"+headProbeQuery)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("backend returned %d", resp.StatusCode)
+	}
+	head, err := peekHeadTurnID(resp.Body)
+	if err == nil && head == "" {
+		err = fmt.Errorf("no head_turn_id in turn listing")
+	}
+	return head, err
+}
+
+// setHeadETag sets the ETag of a head turn listing to its head turn ID.
+// The body is read only as far as the meta object, which the backend
+// writes before the turns, and then passed on unchanged.
+func setHeadETag(resp *http.Response) {
+	if resp.StatusCode != http.StatusOK || resp.Request == nil ||
+		resp.Header.Get("ETag") != "" || resp.Header.Get("Content-Encoding") != "" {
+		return
+	}
+	if _, ok := viewedContextID(resp.Request); !ok {
+		return
+	}
+	var peeked bytes.Buffer
+	head, err := peekHeadTurnID(io.TeeReader(resp.Body, &peeked))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(&peeked, resp.Body), resp.Body}
+	if err == nil && head != "" {
+		resp.Header.Set("ETag", headETag(head))
+	}
+}
+
+// peekHeadTurnID reads meta.head_turn_id from the start of a turn listing.
+// It returns "" if the listing does not start with meta.
+func peekHeadTurnID(r io.Reader) (string, error) {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return "", err
+	}
+	if key, err := dec.Token(); err != nil || key != "meta" {
+		return "", err
+	}
+	var meta struct {
+		HeadTurnID json.RawMessage `json:"head_turn_id"`
+	}
+	if err := dec.Decode(&meta); err != nil {
+		return "", err
+	}
+	return strings.Trim(string(meta.HeadTurnID), `"`), nil
+}
+
+func headETag(head string) string {
+	return `"` + head + `"`
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, compared
+// weakly as RFC 9110 requires. Bare turn IDs are accepted too.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag || headETag(candidate) == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestConditionalTurns(t *testing.T) {
+	var head atomic.Value
+	head.Store("7")
+	var listings atomic.Int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("limit") != "1" {
+			listings.Add(1)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"meta":{"context_id":"3","head_turn_id":"`+head.Load().(string)+`"},"turns":[]}`)
+	}))
+	defer backend.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	rp, err := NewReverseProxy(backend.URL, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{proxy: rp, logger: logger}
+	gw := httptest.NewServer(s.conditionalTurns(rp))
+	defer gw.Close()
+
+	get := func(path, ifNoneMatch string) (int, string, string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, gw.URL+path, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, resp.Header.Get("ETag"), string(body)
+	}
+
+	status, etag, body := get("/v1/contexts/3/turns", "")
+	if status != http.StatusOK || etag != `"7"` || body == "" {
+		t.Fatalf("listing: %d etag %q body %q", status, etag, body)
+	}
+	for _, inm := range []string{`"7"`, `W/"7"`, `"5", "7"`, "7"} {
+		if status, etag, _ := get("/v1/contexts/3/turns?limit=20", inm); status != http.StatusNotModified || etag != `"7"` {
+			t.Errorf("If-None-Match %s: %d etag %q, want 304", inm, status, etag)
+		}
+	}
+	if n := listings.Load(); n != 1 {
+		t.Errorf("backend served %d full listings, want 1", n)
+	}
+
+	head.Store("8")
+	if status, etag, _ := get("/v1/contexts/3/turns", `"7"`); status != http.StatusOK || etag != `"8"` {
+		t.Errorf("moved head: %d etag %q, want 200 with the new ETag", status, etag)
+	}
+	if status, etag, _ := get("/v1/contexts/3/turns?before_turn_id=5", `"8"`); status != http.StatusOK || etag != "" {
+		t.Errorf("paging request: %d etag %q, want an unconditional 200", status, etag)
+	}
+}
+
+func TestPeekHeadTurnID(t *testing.T) {
+	for body, want := range map[string]string{
+		`{"meta":{"head_turn_id":"42","head_depth":3},"turns":[]}`: "42",
+		`{"meta":{"head_turn_id":42},"turns":[]}`:                  "42",
+		`{"turns":[],"meta":{"head_turn_id":"42"}}`:                "",
+		`[]`: "",
+	} {
+		if got, _ := peekHeadTurnID(strings.NewReader(body)); got != want {
+			t.Errorf("peekHeadTurnID(%s) = %q, want %q", body, got, want)
+		}
+	}
+}
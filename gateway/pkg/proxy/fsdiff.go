@@ -0,0 +1,294 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/strongdm/cxdb/gateway/pkg/apierror"
+)
+
+// Limits for GET /v1/fsdiff.
+const (
+	fsDiffTimeout          = 60 * time.Second
+	maxFsDiffEntries       = 10000
+	maxFsTextDiffs         = 100
+	defaultFsTextDiffBytes = 64 << 10
+	maxFsTextDiffBytes     = 1 << 20
+)
+
+// Reasons a text diff was not produced.
+const (
+	diffSkippedBinary   = "binary"
+	diffSkippedTooLarge = "too_large"
+	diffSkippedComplex  = "too_complex"
+	diffSkippedLimit    = "limit"
+)
+
+// fsDiff is the GET /v1/fsdiff response.
+type fsDiff struct {
+	FromTurnID   string        `json:"from_turn_id"`
+	ToTurnID     string        `json:"to_turn_id"`
+	FromRootHash string        `json:"from_root_hash"`
+	ToRootHash   string        `json:"to_root_hash"`
+	Added        []fsDiffEntry `json:"added"`
+	Removed      []fsDiffEntry `json:"removed"`
+	Modified     []fsDiffEntry `json:"modified"`
+	Truncated    bool          `json:"truncated,omitempty"`
+}
+
+// fsDiffEntry is one changed file or symlink. Kind, Size, and Hash describe
+// the entry in the "to" snapshot, or in "from" for removed entries.
+type fsDiffEntry struct {
+	Path        string  `json:"path"`
+	Kind        string  `json:"kind"`
+	Size        uint64  `json:"size"`
+	Hash        string  `json:"hash"`
+	OldKind     string  `json:"old_kind,omitempty"`
+	OldSize     *uint64 `json:"old_size,omitempty"`
+	OldHash     string  `json:"old_hash,omitempty"`
+	Diff        string  `json:"diff,omitempty"`
+	DiffSkipped string  `json:"diff_skipped,omitempty"`
+
+	oldEntry, newEntry *fsTreeEntry
+}
+
+// fsDiff serves GET /v1/fsdiff: the files and symlinks added, removed, or
+// modified between two turns' filesystem snapshots, matching fstree's
+// Snapshot.Diff. Directories whose tree hashes match are not descended.
+//
+//	?from=ID            - turn with the old snapshot (required)
+//	?to=ID              - turn with the new snapshot (required)
+//	?text=1             - include unified diffs of small text files
+//	?max_text_bytes=N   - size limit per side for text diffs (default 64 KiB, max 1 MiB)
+//	?context_id=ID      - context the turns belong to, for the access log
+func (s *Server) fsDiff(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.Write(w, r, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	q := r.URL.Query()
+	from, to := q.Get("from"), q.Get("to")
+	for _, name := range []string{"from", "to"} {
+		if _, err := strconv.ParseUint(q.Get(name), 10, 64); err != nil {
+			apierror.Write(w, r, http.StatusBadRequest, "invalid "+name)
+			return
+		}
+	}
+	withText, _ := strconv.ParseBool(q.Get("text"))
+	maxText := defaultFsTextDiffBytes
+	if v := q.Get("max_text_bytes"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			apierror.Write(w, r, http.StatusBadRequest, "invalid max_text_bytes")
+			return
+		}
+		maxText = min(n, maxFsTextDiffBytes)
+	}
+	contextIDs, ok := accessContextIDs(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), fsDiffTimeout)
+	defer cancel()
+
+	diff := &fsDiff{FromTurnID: from, ToTurnID: to, Added: []fsDiffEntry{}, Removed: []fsDiffEntry{}, Modified: []fsDiffEntry{}}
+	var err error
+	diff.FromRootHash, err = s.fetchFsRoot(ctx, from)
+	if err == nil {
+		diff.ToRootHash, err = s.fetchFsRoot(ctx, to)
+	}
+	if errors.Is(err, errNoFsSnapshot) {
+		apierror.Write(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	if err == nil {
+		err = s.diffTrees(ctx, diff, diff.FromRootHash, diff.ToRootHash, "")
+	}
+	if err == nil && withText {
+		err = s.addTextDiffs(ctx, diff, maxText)
+	}
+	if err != nil {
+		s.logger.Error("fs_diff_failed", "from", from, "to", to, "err", err)
+		apierror.Write(w, r, http.StatusBadGateway, "Bad Gateway")
+		return
+	}
+	writeJSON(w, http.StatusOK, diff)
+	s.recordAccess(r, contextIDs...)
+}
+
+// diffTrees records the differences between two tree objects under dir.
+// An empty hash stands for a missing directory.
+func (s *Server) diffTrees(ctx context.Context, diff *fsDiff, oldHash, newHash, dir string) error {
+	if oldHash == newHash || diff.Truncated {
+		return nil
+	}
+	oldEntries, err := s.fetchTreeEntries(ctx, oldHash, dir)
+	if err != nil {
+		return err
+	}
+	newEntries, err := s.fetchTreeEntries(ctx, newHash, dir)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(oldEntries)+len(newEntries))
+	for name := range oldEntries {
+		names = append(names, name)
+	}
+	for name := range newEntries {
+		if _, ok := oldEntries[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p := path.Join(dir, name)
+		o, n := oldEntries[name], newEntries[name]
+		oldDir, newDir := o != nil && o.Kind == fsKindDir, n != nil && n.Kind == fsKindDir
+
+		// Descend into directories on either side; a file replaced by a
+		// directory is removed and its contents added, and vice versa.
+		var oldTree, newTree string
+		if oldDir {
+			oldTree = hex.EncodeToString(o.Hash)
+		}
+		if newDir {
+			newTree = hex.EncodeToString(n.Hash)
+		}
+		if oldDir || newDir {
+			if err := s.diffTrees(ctx, diff, oldTree, newTree, p); err != nil {
+				return err
+			}
+		}
+
+		switch {
+		case o != nil && !oldDir && n != nil && !newDir:
+			if !bytes.Equal(o.Hash, n.Hash) || o.Kind != n.Kind {
+				diff.add(&diff.Modified, p, o, n)
+			}
+		case o != nil && !oldDir:
+			diff.add(&diff.Removed, p, o, nil)
+		case n != nil && !newDir:
+			diff.add(&diff.Added, p, nil, n)
+		}
+	}
+	return nil
+}
+
+func (s *Server) fetchTreeEntries(ctx context.Context, hash, dir string) (map[string]*fsTreeEntry, error) {
+	if hash == "" {
+		return nil, nil
+	}
+	data, err := s.fetchBlob(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("fetch tree %s: %w", dir, err)
+	}
+	entries, err := decodeFsTree(data)
+	if err != nil {
+		return nil, fmt.Errorf("decode tree %s: %w", dir, err)
+	}
+	byName := make(map[string]*fsTreeEntry, len(entries))
+	for i := range entries {
+		byName[entries[i].Name] = &entries[i]
+	}
+	return byName, nil
+}
+
+func (d *fsDiff) add(list *[]fsDiffEntry, p string, o, n *fsTreeEntry) {
+	if len(d.Added)+len(d.Removed)+len(d.Modified) >= maxFsDiffEntries {
+		d.Truncated = true
+		return
+	}
+	e := fsDiffEntry{Path: p, oldEntry: o, newEntry: n}
+	cur := n
+	if n == nil {
+		cur = o
+	}
+	e.Kind, e.Size, e.Hash = fsKindName(cur.Kind), cur.Size, hex.EncodeToString(cur.Hash)
+	if o != nil && n != nil {
+		size := o.Size
+		e.OldSize, e.OldHash = &size, hex.EncodeToString(o.Hash)
+		if o.Kind != n.Kind {
+			e.OldKind = fsKindName(o.Kind)
+		}
+	}
+	*list = append(*list, e)
+}
+
+// addTextDiffs fills in Diff for changed regular files whose sides are all
+// at most maxBytes of UTF-8 text, up to maxFsTextDiffs files.
+func (s *Server) addTextDiffs(ctx context.Context, diff *fsDiff, maxBytes int) error {
+	done := 0
+	for _, list := range [][]fsDiffEntry{diff.Modified, diff.Added, diff.Removed} {
+		for i := range list {
+			e := &list[i]
+			if (e.oldEntry != nil && e.oldEntry.Kind != fsKindFile) || (e.newEntry != nil && e.newEntry.Kind != fsKindFile) {
+				continue
+			}
+			if (e.oldEntry != nil && e.oldEntry.Size > uint64(maxBytes)) || (e.newEntry != nil && e.newEntry.Size > uint64(maxBytes)) {
+				e.DiffSkipped = diffSkippedTooLarge
+				continue
+			}
+			if done == maxFsTextDiffs {
+				e.DiffSkipped = diffSkippedLimit
+				continue
+			}
+			done++
+
+			oldName, newName := "/dev/null", "/dev/null"
+			var oldText, newText []byte
+			var err error
+			if e.oldEntry != nil {
+				oldName = "a/" + e.Path
+				if oldText, err = s.fetchBlob(ctx, hex.EncodeToString(e.oldEntry.Hash)); err != nil {
+					return fmt.Errorf("fetch %s: %w", e.Path, err)
+				}
+			}
+			if e.newEntry != nil {
+				newName = "b/" + e.Path
+				if newText, err = s.fetchBlob(ctx, hex.EncodeToString(e.newEntry.Hash)); err != nil {
+					return fmt.Errorf("fetch %s: %w", e.Path, err)
+				}
+			}
+			if !isText(oldText) || !isText(newText) {
+				e.DiffSkipped = diffSkippedBinary
+				continue
+			}
+			e.Diff, err = unifiedDiff(oldName, newName, string(oldText), string(newText))
+			if errors.Is(err, errDiffTooComplex) {
+				e.DiffSkipped = diffSkippedComplex
+			}
+		}
+	}
+	return nil
+}
+
+func isText(b []byte) bool {
+	return utf8.Valid(b) && bytes.IndexByte(b, 0) < 0
+}
+
+func fsKindName(kind uint64) string {
+	switch kind {
+	case fsKindFile:
+		return "file"
+	case fsKindDir:
+		return "dir"
+	case fsKindSymlink:
+		return "symlink"
+	}
+	return strconv.FormatUint(kind, 10)
+}
@@ -0,0 +1,101 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func getFsDiff(t *testing.T, url string) *fsDiff {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s = %d", url, resp.StatusCode)
+	}
+	var diff fsDiff
+	if err := json.NewDecoder(resp.Body).Decode(&diff); err != nil {
+		t.Fatal(err)
+	}
+	return &diff
+}
+
+func TestFsDiff(t *testing.T) {
+	b, backend := newFsBackend(t, time.Time{})
+	gw := newFsGateway(t, backend)
+
+	// Turn 9 edits README.md, replaces the link with a file, adds a binary,
+	// and drops the empty directory. src/ is untouched.
+	readme := []byte("# Workspace\n\nUpdated.\n")
+	notLink := []byte("not a link\n")
+	tool := []byte("\x7fELF\x00\x01")
+	mainGo := []byte("package main\n")
+	src := b.put(treeObject(
+		treeEntry("main.go", fsKindFile, 0o600, uint64(len(mainGo)), b.put(mainGo), 0, true),
+	))
+	bin := b.put(treeObject(
+		treeEntry("tool", fsKindFile, 0o755, uint64(len(tool)), b.put(tool), 0, false),
+	))
+	b.roots["9"] = hex.EncodeToString(b.put(treeObject(
+		treeEntry("README.md", fsKindFile, 0o644, uint64(len(readme)), b.put(readme), 0, false),
+		treeEntry("bin", fsKindDir, 0o755, 0, bin, 0, false),
+		treeEntry("link", fsKindFile, 0o644, uint64(len(notLink)), b.put(notLink), 0, false),
+		treeEntry("src", fsKindDir, 0o755, 0, src, 0, false),
+	)))
+
+	diff := getFsDiff(t, gw.URL+"/v1/fsdiff?from=7&to=9&text=1")
+	if diff.FromRootHash != b.roots["7"] || diff.ToRootHash != b.roots["9"] {
+		t.Errorf("root hashes = %s, %s", diff.FromRootHash, diff.ToRootHash)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].Path != "bin/tool" || diff.Added[0].DiffSkipped != diffSkippedBinary {
+		t.Errorf("Added = %+v", diff.Added)
+	}
+	if len(diff.Removed) != 0 {
+		t.Errorf("Removed = %+v", diff.Removed)
+	}
+	if len(diff.Modified) != 2 {
+		t.Fatalf("Modified = %+v", diff.Modified)
+	}
+	readmeDiff := "--- a/README.md\n+++ b/README.md\n@@ -1 +1,3 @@\n # Workspace\n+\n+Updated.\n"
+	if m := diff.Modified[0]; m.Path != "README.md" || m.Diff != readmeDiff || m.OldSize == nil || *m.OldSize != 12 {
+		t.Errorf("Modified[0] = %+v", m)
+	}
+	if m := diff.Modified[1]; m.Path != "link" || m.Kind != "file" || m.OldKind != "symlink" || m.Diff != "" {
+		t.Errorf("Modified[1] = %+v", m)
+	}
+	b.mu.Lock()
+	if b.fetched[hex.EncodeToString(src)] {
+		t.Error("descended into a directory whose tree hash did not change")
+	}
+	b.mu.Unlock()
+
+	// The other way round, the binary is removed and diffs are opt-in.
+	back := getFsDiff(t, gw.URL+"/v1/fsdiff?from=9&to=7")
+	if len(back.Removed) != 1 || back.Removed[0].Path != "bin/tool" || back.Modified[0].Diff != "" {
+		t.Errorf("reverse diff = %+v", back)
+	}
+
+	for url, want := range map[string]int{
+		"/v1/fsdiff?from=7":                              http.StatusBadRequest,
+		"/v1/fsdiff?from=7&to=9&max_text_bytes=-1":       http.StatusBadRequest,
+		"/v1/fsdiff?from=7&to=8":                         http.StatusNotFound,
+		"/v1/fsdiff?from=7&to=9&text=1&max_text_bytes=4": http.StatusOK,
+	} {
+		resp, err := http.Get(gw.URL + url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("GET %s = %d, want %d", url, resp.StatusCode, want)
+		}
+	}
+}
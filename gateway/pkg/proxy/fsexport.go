@@ -0,0 +1,435 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/strongdm/cxdb/gateway/pkg/apierror"
+)
+
+// Limits for GET /v1/turns/{id}/fs.tar.gz.
+const (
+	fsExportTimeout     = 30 * time.Minute // whole archive, including the write to the client
+	fsExportBlobTimeout = 2 * time.Minute  // each tree object or file
+)
+
+// Tree entry kinds, as stored in fs tree objects.
+const (
+	fsKindFile    = 0
+	fsKindDir     = 1
+	fsKindSymlink = 2
+)
+
+var errNoFsSnapshot = errors.New("no fs snapshot for turn")
+
+// fsTreeEntry is one entry of a tree object.
+type fsTreeEntry struct {
+	Name    string
+	Kind    uint64
+	Mode    uint64
+	Size    uint64
+	Hash    []byte
+	ModTime int64 // unix ns, 0 if not recorded
+}
+
+// fsExport serves GET /v1/turns/{turn_id}/fs.tar.gz: the turn's whole
+// filesystem snapshot as a gzipped tar. The gateway walks the tree objects
+// and streams file contents from the backend's blob endpoint, so nothing is
+// buffered beyond one tree object.
+//
+// Turns do not record which context they belong to, so the export is only
+// logged as a read of a context when the caller names it with ?context_id=.
+//
+// Errors after the response has started abort the connection, so clients
+// see a truncated download rather than a valid but incomplete archive.
+func (s *Server) fsExport(w http.ResponseWriter, r *http.Request) {
+	turnID := r.PathValue("turn_id")
+	if _, err := strconv.ParseUint(turnID, 10, 64); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "invalid turn_id")
+		return
+	}
+	contextIDs, ok := accessContextIDs(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), fsExportTimeout)
+	defer cancel()
+
+	root, err := s.fetchFsRoot(ctx, turnID)
+	if errors.Is(err, errNoFsSnapshot) {
+		apierror.Write(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		s.logger.Error("fs_export_root_failed", "turn_id", turnID, "err", err)
+		apierror.Write(w, r, http.StatusBadGateway, "Bad Gateway")
+		return
+	}
+
+	// Archives of large workspaces outlast the server's WriteTimeout.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(fsExportTimeout))
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="turn-%s-fs.tar.gz"`, turnID))
+	w.Header().Set("X-Fs-Root-Hash", root)
+	w.WriteHeader(http.StatusOK)
+	s.recordAccess(r, contextIDs...)
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	err = s.exportTree(ctx, tw, root, "")
+	if err == nil {
+		err = tw.Close()
+	}
+	if err == nil {
+		err = gz.Close()
+	}
+	if err != nil {
+		s.logger.Error("fs_export_failed", "turn_id", turnID, "err", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// fetchFsRoot returns the hex root tree hash of the turn's snapshot.
+func (s *Server) fetchFsRoot(ctx context.Context, turnID string) (string, error) {
+	resp, err := s.backendGet(ctx, "/v1/turns/"+turnID+"/fs")
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("%w %s", errNoFsSnapshot, turnID)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("backend returned %d", resp.StatusCode)
+	}
+	var listing struct {
+		FsRootHash string `json:"fs_root_hash"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&listing); err != nil {
+		return "", err
+	}
+	if _, err := hex.DecodeString(listing.FsRootHash); err != nil || len(listing.FsRootHash) != 64 {
+		return "", fmt.Errorf("invalid fs_root_hash %q", listing.FsRootHash)
+	}
+	return listing.FsRootHash, nil
+}
+
+// exportTree writes the entries of a tree object, and everything below
+// them, under dir.
+func (s *Server) exportTree(ctx context.Context, tw *tar.Writer, hash, dir string) error {
+	data, err := s.fetchBlob(ctx, hash)
+	if err != nil {
+		return fmt.Errorf("fetch tree %s: %w", dir, err)
+	}
+	entries, err := decodeFsTree(data)
+	if err != nil {
+		return fmt.Errorf("decode tree %s: %w", dir, err)
+	}
+
+	for _, e := range entries {
+		if e.Name == "" || e.Name == "." || e.Name == ".." || strings.ContainsAny(e.Name, `/\`) {
+			return fmt.Errorf("unsafe entry name %q in %s", e.Name, dir)
+		}
+		name := path.Join(dir, e.Name)
+		hdr := &tar.Header{
+			Name:  name,
+			Mode:  int64(e.Mode & 0o7777),
+			Uname: "cxdb",
+			Gname: "cxdb",
+		}
+		if e.ModTime != 0 {
+			hdr.ModTime = time.Unix(0, e.ModTime)
+		}
+		entryHash := hex.EncodeToString(e.Hash)
+
+		switch e.Kind {
+		case fsKindDir:
+			hdr.Typeflag, hdr.Name = tar.TypeDir, name+"/"
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			if err := s.exportTree(ctx, tw, entryHash, name); err != nil {
+				return err
+			}
+		case fsKindFile:
+			hdr.Typeflag, hdr.Size = tar.TypeReg, int64(e.Size)
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			if err := s.copyBlob(ctx, tw, entryHash, int64(e.Size)); err != nil {
+				return fmt.Errorf("copy file %s: %w", name, err)
+			}
+		case fsKindSymlink:
+			target, err := s.fetchBlob(ctx, entryHash)
+			if err != nil {
+				return fmt.Errorf("fetch symlink %s: %w", name, err)
+			}
+			hdr.Typeflag, hdr.Linkname, hdr.Mode = tar.TypeSymlink, string(target), 0o777
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown entry kind %d for %s", e.Kind, name)
+		}
+	}
+	return nil
+}
+
+// fetchBlob reads a whole blob, for tree objects and symlink targets.
+func (s *Server) fetchBlob(ctx context.Context, hash string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, fsExportBlobTimeout)
+	defer cancel()
+	resp, err := s.backendGet(ctx, "/v1/blobs/"+hash)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("blob %s: backend returned %d", hash, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// copyBlob streams a file's contents into w, which expects exactly size
+// bytes.
+func (s *Server) copyBlob(ctx context.Context, w io.Writer, hash string, size int64) error {
+	ctx, cancel := context.WithTimeout(ctx, fsExportBlobTimeout)
+	defer cancel()
+	resp, err := s.backendGet(ctx, "/v1/blobs/"+hash)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("blob %s: backend returned %d", hash, resp.StatusCode)
+	}
+	n, err := io.Copy(w, io.LimitReader(resp.Body, size+1))
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return fmt.Errorf("blob %s: got %d bytes, want %d", hash, n, size)
+	}
+	return nil
+}
+
+// treeFormatV2 is the version byte that starts a v2 tree object; v1 trees
+// start directly with the msgpack array.
+const treeFormatV2 = 0x02
+
+// decodeFsTree decodes a tree object: a msgpack array of maps keyed by
+// field number, preceded by a version byte in format v2. Keys may be
+// integers or their decimal strings, as written by the Go client. Unknown
+// fields are ignored.
+func decodeFsTree(data []byte) ([]fsTreeEntry, error) {
+	if len(data) > 0 && data[0] == treeFormatV2 {
+		data = data[1:]
+	}
+	d := &msgpackDecoder{buf: data}
+	v, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+	items, ok := v.([]any)
+	if !ok && v != nil { // an empty directory may encode as nil
+		return nil, errors.New("tree object is not an array")
+	}
+	entries := make([]fsTreeEntry, 0, len(items))
+	for _, item := range items {
+		fields, ok := item.(map[string]any)
+		if !ok {
+			return nil, errors.New("tree entry is not a map")
+		}
+		var e fsTreeEntry
+		e.Name, _ = fields["1"].(string)
+		e.Kind, _ = msgpackUint(fields["2"])
+		e.Mode, _ = msgpackUint(fields["3"])
+		e.Size, _ = msgpackUint(fields["4"])
+		e.Hash, _ = fields["5"].([]byte)
+		if mt, ok := msgpackUint(fields["6"]); ok {
+			e.ModTime = int64(mt)
+		}
+		if len(e.Hash) != 32 {
+			return nil, fmt.Errorf("entry %q: hash is %d bytes", e.Name, len(e.Hash))
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func msgpackUint(v any) (uint64, bool) {
+	switch n := v.(type) {
+	case uint64:
+		return n, true
+	case int64:
+		return uint64(n), true
+	}
+	return 0, false
+}
+
+// msgpackDecoder decodes the subset of msgpack used by tree objects into
+// nil, bool, int64, uint64, float64, string, []byte, []any and
+// map[string]any (integer keys are formatted in decimal).
+type msgpackDecoder struct {
+	buf []byte
+	off int
+}
+
+var errMsgpackShort = errors.New("msgpack: unexpected end of data")
+
+func (d *msgpackDecoder) next(n int) ([]byte, error) {
+	if n < 0 || len(d.buf)-d.off < n {
+		return nil, errMsgpackShort
+	}
+	b := d.buf[d.off : d.off+n]
+	d.off += n
+	return b, nil
+}
+
+func (d *msgpackDecoder) uint(n int) (uint64, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
+
+func (d *msgpackDecoder) value() (any, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	c := b[0]
+	switch {
+	case c <= 0x7f:
+		return uint64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return d.mapOf(int(c & 0x0f))
+	case c&0xf0 == 0x90:
+		return d.arrayOf(int(c & 0x0f))
+	case c&0xe0 == 0xa0:
+		return d.str(int(c & 0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.uint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return d.next(int(n))
+	case 0xca:
+		u, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 0xcb:
+		u, err := d.uint(8)
+		return math.Float64frombits(u), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return d.uint(1 << (c - 0xcc))
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		u, err := d.uint(size)
+		if err != nil {
+			return nil, err
+		}
+		shift := 64 - 8*size
+		return int64(u<<shift) >> shift, nil
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.uint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(int(n))
+	case 0xdc, 0xdd:
+		n, err := d.uint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.arrayOf(int(n))
+	case 0xde, 0xdf:
+		n, err := d.uint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.mapOf(int(n))
+	}
+	return nil, fmt.Errorf("msgpack: unsupported type 0x%02x", c)
+}
+
+func (d *msgpackDecoder) str(n int) (string, error) {
+	b, err := d.next(n)
+	return string(b), err
+}
+
+func (d *msgpackDecoder) arrayOf(n int) ([]any, error) {
+	if n > len(d.buf)-d.off {
+		return nil, errMsgpackShort
+	}
+	out := make([]any, 0, n)
+	for i := 0; i < n; i++ {
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func (d *msgpackDecoder) mapOf(n int) (map[string]any, error) {
+	if n > len(d.buf)-d.off {
+		return nil, errMsgpackShort
+	}
+	out := make(map[string]any, n)
+	for i := 0; i < n; i++ {
+		k, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		switch k := k.(type) {
+		case string:
+			out[k] = v
+		case uint64:
+			out[strconv.FormatUint(k, 10)] = v
+		case int64:
+			out[strconv.FormatInt(k, 10)] = v
+		}
+	}
+	return out, nil
+}
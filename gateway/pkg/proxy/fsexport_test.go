@@ -0,0 +1,269 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fsBackend serves turns' fs listings and the blobs their trees refer to.
+type fsBackend struct {
+	blobs map[string][]byte
+	roots map[string]string // turn ID -> root tree hash
+
+	mu      sync.Mutex
+	fetched map[string]bool
+}
+
+func (b *fsBackend) put(data []byte) []byte {
+	sum := sha256.Sum256(data) // any 32-byte ID works; the gateway does not verify
+	b.blobs[hex.EncodeToString(sum[:])] = data
+	return sum[:]
+}
+
+// treeEntry encodes one tree entry the way the Go client does: a map keyed
+// by the field numbers as strings. intKeys uses integer keys instead.
+func treeEntry(name string, kind, mode, size uint64, hash []byte, mtime int64, intKeys bool) []byte {
+	fields := 5
+	if mtime != 0 {
+		fields++
+	}
+	out := []byte{0x80 | byte(fields)}
+	key := func(k int) {
+		if intKeys {
+			out = append(out, byte(k))
+		} else {
+			out = append(out, 0xa1, byte('0'+k))
+		}
+	}
+	u64 := func(v uint64) {
+		out = append(out, 0xcf)
+		for i := 7; i >= 0; i-- {
+			out = append(out, byte(v>>(8*i)))
+		}
+	}
+	key(1)
+	out = append(out, 0xd9, byte(len(name)))
+	out = append(out, name...)
+	key(2)
+	out = append(out, byte(kind))
+	key(3)
+	out = append(out, 0xcd, byte(mode>>8), byte(mode))
+	key(4)
+	u64(size)
+	key(5)
+	out = append(out, 0xc4, byte(len(hash)))
+	out = append(out, hash...)
+	if mtime != 0 {
+		key(6)
+		out = append(out, 0xd3)
+		for i := 7; i >= 0; i-- {
+			out = append(out, byte(mtime>>(8*i)))
+		}
+	}
+	return out
+}
+
+func treeObject(entries ...[]byte) []byte {
+	if len(entries) == 0 {
+		return []byte{0xc0} // nil slice
+	}
+	return append([]byte{0x90 | byte(len(entries))}, bytes.Join(entries, nil)...)
+}
+
+func newFsBackend(t *testing.T, mtime time.Time) (*fsBackend, *httptest.Server) {
+	t.Helper()
+	b := &fsBackend{blobs: map[string][]byte{}, roots: map[string]string{}, fetched: map[string]bool{}}
+	readme := []byte("# Workspace\n")
+	mainGo := []byte("package main\n")
+	src := treeObject(
+		treeEntry("main.go", fsKindFile, 0o600, uint64(len(mainGo)), b.put(mainGo), 0, true),
+	)
+	root := treeObject(
+		treeEntry("README.md", fsKindFile, 0o644, uint64(len(readme)), b.put(readme), mtime.UnixNano(), false),
+		treeEntry("empty", fsKindDir, 0o750, 0, b.put(treeObject()), 0, false),
+		treeEntry("link", fsKindSymlink, 0o777, 0, b.put([]byte("README.md")), 0, false),
+		treeEntry("src", fsKindDir, 0o755, 0, b.put(src), mtime.UnixNano(), false),
+	)
+	b.roots["7"] = hex.EncodeToString(b.put(root))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		turnID, _ := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/v1/turns/"), "/fs")
+		switch {
+		case b.roots[turnID] != "":
+			fmt.Fprintf(w, `{"turn_id":%q,"path":"","fs_root_hash":%q,"entries":[]}`, turnID, b.roots[turnID])
+		case strings.HasPrefix(r.URL.Path, "/v1/blobs/"):
+			hash := strings.TrimPrefix(r.URL.Path, "/v1/blobs/")
+			b.mu.Lock()
+			b.fetched[hash] = true
+			b.mu.Unlock()
+			data, ok := b.blobs[hash]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write(data)
+		default:
+			http.Error(w, `{"error":"no fs snapshot for turn"}`, http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return b, srv
+}
+
+func newFsGateway(t *testing.T, backend *httptest.Server) *httptest.Server {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	rp, err := NewReverseProxy(backend.URL, logger)
+	if err != nil {
+		t.Fatalf("NewReverseProxy: %v", err)
+	}
+	s := &Server{proxy: rp, logger: logger}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/turns/{turn_id}/fs.tar.gz", s.fsExport)
+	mux.HandleFunc("/v1/fsdiff", s.fsDiff)
+	gw := httptest.NewServer(mux)
+	t.Cleanup(gw.Close)
+	return gw
+}
+
+func TestFsExport_Archive(t *testing.T) {
+	mtime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	b, backend := newFsBackend(t, mtime)
+	gw := newFsGateway(t, backend)
+
+	resp, err := http.Get(gw.URL + "/v1/turns/7/fs.tar.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Content-Disposition"); got != `attachment; filename="turn-7-fs.tar.gz"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	if got := resp.Header.Get("X-Fs-Root-Hash"); got != b.roots["7"] {
+		t.Errorf("X-Fs-Root-Hash = %q, want %q", got, b.roots["7"])
+	}
+
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var got []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read archive: %v", err)
+		}
+		body, _ := io.ReadAll(tr)
+		line := fmt.Sprintf("%c %s %o", hdr.Typeflag, hdr.Name, hdr.Mode)
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			line += " " + string(body)
+		case tar.TypeSymlink:
+			line += " -> " + hdr.Linkname
+		}
+		got = append(got, strings.TrimSpace(line))
+
+		if hdr.Name == "README.md" || hdr.Name == "src/" {
+			if !hdr.ModTime.Equal(mtime) {
+				t.Errorf("%s ModTime = %v, want %v", hdr.Name, hdr.ModTime, mtime)
+			}
+		}
+	}
+
+	want := []string{
+		"0 README.md 644 # Workspace",
+		"5 empty/ 750",
+		"2 link 777 -> README.md",
+		"5 src/ 755",
+		"0 src/main.go 600 package main",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("archive:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestFsExport_Errors(t *testing.T) {
+	b, backend := newFsBackend(t, time.Time{})
+	gw := newFsGateway(t, backend)
+
+	for path, want := range map[string]int{
+		"/v1/turns/8/fs.tar.gz":   http.StatusNotFound,
+		"/v1/turns/abc/fs.tar.gz": http.StatusBadRequest,
+	} {
+		resp, err := http.Get(gw.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("GET %s = %d, want %d", path, resp.StatusCode, want)
+		}
+	}
+
+	// A blob missing mid-archive cuts the download short instead of
+	// completing a valid archive.
+	for hash, data := range b.blobs {
+		if string(data) == "package main\n" {
+			delete(b.blobs, hash)
+		}
+	}
+	if err := readFsArchive(gw.URL + "/v1/turns/7/fs.tar.gz"); err == nil {
+		t.Fatal("truncated archive read cleanly")
+	}
+}
+
+func TestDecodeFsTreeFormats(t *testing.T) {
+	v1 := treeObject(treeEntry("a.txt", fsKindFile, 0o644, 1, make([]byte, 32), 0, false))
+	for name, data := range map[string][]byte{"v1": v1, "v2": append([]byte{treeFormatV2}, v1...)} {
+		entries, err := decodeFsTree(data)
+		if err != nil || len(entries) != 1 || entries[0].Name != "a.txt" {
+			t.Errorf("%s: %+v, %v", name, entries, err)
+		}
+	}
+}
+
+// readFsArchive downloads and reads a whole archive.
+func readFsArchive(url string) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		if _, err := tr.Next(); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if _, err := io.Copy(io.Discard, tr); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,307 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/strongdm/cxdb/gateway/pkg/apierror"
+)
+
+// Context tree limits for GET /v1/contexts/tree.
+const (
+	defaultTreeContexts = 500
+	maxTreeContexts     = 5000
+)
+
+// Spawn kinds reported on tree nodes. They mirror Provenance.SpawnReason,
+// with "root" for contexts that have no parent.
+const (
+	spawnKindRoot = "root"
+	spawnKindFork = "fork"
+)
+
+// treeNode is one context in the lineage tree.
+type treeNode struct {
+	ContextID       string      `json:"context_id"`
+	ParentContextID string      `json:"parent_context_id,omitempty"`
+	Kind            string      `json:"kind"`
+	Title           string      `json:"title,omitempty"`
+	ClientTag       string      `json:"client_tag,omitempty"`
+	OnBehalfOf      string      `json:"on_behalf_of,omitempty"`
+	IsLive          bool        `json:"is_live"`
+	HeadDepth       int         `json:"head_depth"`
+	CreatedAtUnixMs int64       `json:"created_at_unix_ms"`
+	LastActivityAt  int64       `json:"last_activity_at,omitempty"`
+	Depth           int         `json:"depth"`
+	Descendants     int         `json:"descendants"`
+	Detached        bool        `json:"detached,omitempty"`
+	Children        []*treeNode `json:"children"`
+
+	id       uint64
+	parentID uint64
+}
+
+// contextTree is the GET /v1/contexts/tree response.
+type contextTree struct {
+	Roots  []*treeNode    `json:"roots"`
+	Count  int            `json:"count"`
+	ByKind map[string]int `json:"by_kind"`
+}
+
+// treeSource is the subset of the backend context listing the tree needs.
+type treeSource struct {
+	Contexts []struct {
+		ContextID       string          `json:"context_id"`
+		HeadDepth       int             `json:"head_depth"`
+		CreatedAtUnixMs int64           `json:"created_at_unix_ms"`
+		IsLive          bool            `json:"is_live"`
+		ClientTag       string          `json:"client_tag"`
+		Title           string          `json:"title"`
+		LastActivityAt  int64           `json:"last_activity_at"`
+		Provenance      json.RawMessage `json:"provenance"`
+	} `json:"contexts"`
+}
+
+// treeProvenance holds the lineage fields of a stored provenance. IDs may
+// be encoded as numbers or strings.
+type treeProvenance struct {
+	ParentContextID json.Number `json:"parent_context_id"`
+	SpawnReason     string      `json:"spawn_reason"`
+	OnBehalfOf      string      `json:"on_behalf_of"`
+}
+
+// contextTree serves GET /v1/contexts/tree: the lineage forest of recently
+// active contexts, built from their provenance.
+//
+//	?limit=N  - contexts to consider (default 500, max 5000)
+//	?tag=T    - only contexts with this client tag
+//	?root=ID  - only the tree containing this context
+func (s *Server) contextTree(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.Write(w, r, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit <= 0 {
+		limit = defaultTreeContexts
+	}
+	limit = min(limit, maxTreeContexts)
+
+	var rootFilter uint64
+	if v := q.Get("root"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			apierror.Write(w, r, http.StatusBadRequest, "invalid root")
+			return
+		}
+		rootFilter = id
+	}
+
+	src, err := s.fetchTreeSource(r.Context(), limit, q.Get("tag"))
+	if err != nil {
+		s.logger.Error("context_tree_fetch_failed", "err", err)
+		apierror.Write(w, r, http.StatusBadGateway, "Bad Gateway")
+		return
+	}
+
+	tree := buildContextTree(src)
+	if rootFilter != 0 {
+		tree = tree.containing(rootFilter)
+		if tree == nil {
+			apierror.Write(w, r, http.StatusNotFound, "context not found")
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, tree)
+
+	// A tree is a listing, not a read of every node: one focused with
+	// ?root= is one read of that context, and an unfocused one, like the
+	// context list, is not logged.
+	if rootFilter != 0 {
+		s.recordAccess(r, strconv.FormatUint(rootFilter, 10))
+	}
+}
+
+func (s *Server) fetchTreeSource(ctx context.Context, limit int, tag string) (*treeSource, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(limit))
+	params.Set("include_provenance", "1")
+	if tag != "" {
+		params.Set("tag", tag)
+	}
+	resp, err := s.backendGet(ctx, "/v1/contexts?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("backend returned %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var src treeSource
+	if err := json.Unmarshal(body, &src); err != nil {
+		return nil, err
+	}
+	return &src, nil
+}
+
+// buildContextTree links contexts to their parents. Contexts whose parent
+// is outside the listing become detached roots; cycles are broken at the
+// lowest context ID.
+func buildContextTree(src *treeSource) *contextTree {
+	nodes := make(map[uint64]*treeNode, len(src.Contexts))
+	order := make([]*treeNode, 0, len(src.Contexts))
+	for _, c := range src.Contexts {
+		id, err := strconv.ParseUint(c.ContextID, 10, 64)
+		if err != nil || nodes[id] != nil {
+			continue
+		}
+		n := &treeNode{
+			ContextID:       c.ContextID,
+			Kind:            spawnKindRoot,
+			Title:           c.Title,
+			ClientTag:       c.ClientTag,
+			IsLive:          c.IsLive,
+			HeadDepth:       c.HeadDepth,
+			CreatedAtUnixMs: c.CreatedAtUnixMs,
+			LastActivityAt:  c.LastActivityAt,
+			Children:        []*treeNode{},
+			id:              id,
+		}
+		var prov treeProvenance
+		if len(c.Provenance) > 0 && json.Unmarshal(c.Provenance, &prov) == nil {
+			n.OnBehalfOf = prov.OnBehalfOf
+			if parent, err := strconv.ParseUint(prov.ParentContextID.String(), 10, 64); err == nil && parent != 0 && parent != id {
+				n.parentID = parent
+				n.ParentContextID = strconv.FormatUint(parent, 10)
+				n.Kind = prov.SpawnReason
+				if n.Kind == "" {
+					n.Kind = spawnKindFork
+				}
+			}
+		}
+		nodes[id] = n
+		order = append(order, n)
+	}
+
+	tree := &contextTree{Roots: []*treeNode{}, Count: len(order), ByKind: make(map[string]int)}
+	for _, n := range order {
+		tree.ByKind[n.Kind]++
+		if parent := nodes[n.parentID]; n.parentID != 0 && parent != nil {
+			parent.Children = append(parent.Children, n)
+			continue
+		}
+		n.Detached = n.parentID != 0
+		tree.Roots = append(tree.Roots, n)
+	}
+
+	visited := make(map[uint64]bool, len(order))
+	for _, root := range tree.Roots {
+		root.finish(0, visited)
+	}
+
+	// Whatever was not reached hangs off a parent cycle.
+	sort.Slice(order, func(i, j int) bool { return order[i].id < order[j].id })
+	for _, n := range order {
+		if visited[n.id] {
+			continue
+		}
+		if parent := nodes[n.parentID]; parent != nil {
+			parent.Children = removeNode(parent.Children, n)
+		}
+		n.Detached = true
+		tree.Roots = append(tree.Roots, n)
+		n.finish(0, visited)
+	}
+
+	sortNodes(tree.Roots)
+	return tree
+}
+
+// finish assigns depths and descendant counts and sorts children.
+func (n *treeNode) finish(depth int, visited map[uint64]bool) int {
+	visited[n.id] = true
+	n.Depth = depth
+	n.Descendants = 0
+	kept := n.Children[:0]
+	for _, c := range n.Children {
+		if visited[c.id] {
+			continue
+		}
+		n.Descendants += 1 + c.finish(depth+1, visited)
+		kept = append(kept, c)
+	}
+	n.Children = kept
+	sortNodes(n.Children)
+	return n.Descendants
+}
+
+// containing returns the tree holding the given context, or nil.
+func (t *contextTree) containing(id uint64) *contextTree {
+	for _, root := range t.Roots {
+		if root.find(id) {
+			sub := &contextTree{Roots: []*treeNode{root}, ByKind: make(map[string]int)}
+			root.walk(func(n *treeNode) {
+				sub.Count++
+				sub.ByKind[n.Kind]++
+			})
+			return sub
+		}
+	}
+	return nil
+}
+
+func (n *treeNode) find(id uint64) bool {
+	if n.id == id {
+		return true
+	}
+	for _, c := range n.Children {
+		if c.find(id) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *treeNode) walk(fn func(*treeNode)) {
+	fn(n)
+	for _, c := range n.Children {
+		c.walk(fn)
+	}
+}
+
+func removeNode(nodes []*treeNode, target *treeNode) []*treeNode {
+	for i, n := range nodes {
+		if n == target {
+			return append(nodes[:i], nodes[i+1:]...)
+		}
+	}
+	return nodes
+}
+
+// sortNodes orders siblings oldest first.
+func sortNodes(nodes []*treeNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].CreatedAtUnixMs != nodes[j].CreatedAtUnixMs {
+			return nodes[i].CreatedAtUnixMs < nodes[j].CreatedAtUnixMs
+		}
+		return nodes[i].id < nodes[j].id
+	})
+}
@@ -0,0 +1,181 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// treeListing builds a backend /v1/contexts response from
+// id|created_at|provenance lines.
+func treeListing(t *testing.T, lines ...string) *treeSource {
+	t.Helper()
+	var b strings.Builder
+	b.WriteString(`{"contexts":[`)
+	for i, line := range lines {
+		id, rest, _ := strings.Cut(line, "|")
+		created, prov, _ := strings.Cut(rest, "|")
+		if prov == "" {
+			prov = "null"
+		}
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(`{"context_id":"` + id + `","created_at_unix_ms":` + created + `,"provenance":` + prov + `}`)
+	}
+	b.WriteString(`]}`)
+	var src treeSource
+	if err := json.Unmarshal([]byte(b.String()), &src); err != nil {
+		t.Fatal(err)
+	}
+	return &src
+}
+
+func childIDs(n *treeNode) []string {
+	ids := make([]string, len(n.Children))
+	for i, c := range n.Children {
+		ids[i] = c.ContextID
+	}
+	return ids
+}
+
+func findNode(tree *contextTree, id string) *treeNode {
+	var found *treeNode
+	for _, root := range tree.Roots {
+		root.walk(func(n *treeNode) {
+			if n.ContextID == id {
+				found = n
+			}
+		})
+	}
+	return found
+}
+
+func TestBuildContextTree(t *testing.T) {
+	tree := buildContextTree(treeListing(t,
+		`4|40|{"parent_context_id":1,"spawn_reason":"sub_agent"}`,
+		`1|10|{"on_behalf_of":"alice"}`,
+		`2|20|{"parent_context_id":"1","spawn_reason":"fork"}`,
+		`3|30|{"parent_context_id":2,"spawn_reason":"quest"}`,
+		`5|5|{"parent_context_id":1}`,
+	))
+	if len(tree.Roots) != 1 || tree.Count != 5 {
+		t.Fatalf("roots=%d count=%d, want 1 root and 5 nodes", len(tree.Roots), tree.Count)
+	}
+	root := tree.Roots[0]
+	if root.ContextID != "1" || root.Kind != spawnKindRoot || root.OnBehalfOf != "alice" || root.Descendants != 4 {
+		t.Fatalf("root = %+v", root)
+	}
+	if got := strings.Join(childIDs(root), ","); got != "5,2,4" {
+		t.Fatalf("root children = %s, want 5,2,4", got)
+	}
+	if quest := findNode(tree, "3"); quest.Kind != "quest" || quest.Depth != 2 || quest.ParentContextID != "2" {
+		t.Fatalf("quest node = %+v", quest)
+	}
+	if n := findNode(tree, "5"); n.Kind != spawnKindFork {
+		t.Fatalf("missing spawn reason kind = %q, want %q", n.Kind, spawnKindFork)
+	}
+	if tree.ByKind[spawnKindFork] != 2 || tree.ByKind["sub_agent"] != 1 || tree.ByKind[spawnKindRoot] != 1 {
+		t.Fatalf("by kind = %v", tree.ByKind)
+	}
+}
+
+func TestBuildContextTreeDetachedAndCycles(t *testing.T) {
+	tree := buildContextTree(treeListing(t,
+		`7|70|{"parent_context_id":99,"spawn_reason":"delegation"}`,
+		`8|80|{"parent_context_id":7,"spawn_reason":"sub_agent"}`,
+		`10|1|{"parent_context_id":12}`,
+		`11|2|{"parent_context_id":10}`,
+		`12|3|{"parent_context_id":11}`,
+		`bogus|0|`,
+	))
+	if len(tree.Roots) != 2 || tree.Count != 5 {
+		t.Fatalf("roots=%d count=%d, want 2 and 5", len(tree.Roots), tree.Count)
+	}
+	cycle, detached := tree.Roots[0], tree.Roots[1]
+	if cycle.ContextID != "10" || !cycle.Detached || cycle.Descendants != 2 {
+		t.Fatalf("cycle root = %+v", cycle)
+	}
+	if detached.ContextID != "7" || !detached.Detached || detached.Kind != "delegation" || detached.Descendants != 1 {
+		t.Fatalf("detached root = %+v", detached)
+	}
+}
+
+func TestContextTreeHandler(t *testing.T) {
+	var query string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/contexts" {
+			http.NotFound(w, r)
+			return
+		}
+		query = r.URL.RawQuery
+		if r.URL.Query().Get("tag") == "broken" {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		_, _ = io.WriteString(w, `{"contexts":[
+			{"context_id":"1","created_at_unix_ms":1},
+			{"context_id":"2","created_at_unix_ms":2,"provenance":{"parent_context_id":1,"spawn_reason":"quest"}},
+			{"context_id":"3","created_at_unix_ms":3}
+		]}`)
+	}))
+	defer backend.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	rp, err := NewReverseProxy(backend.URL, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{proxy: rp, logger: logger}
+
+	get := func(target string) (int, *contextTree) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		s.contextTree(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		var tree contextTree
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &tree); err != nil {
+				t.Fatalf("%s: %v", target, err)
+			}
+		}
+		return rec.Code, &tree
+	}
+
+	status, tree := get("/v1/contexts/tree?limit=99999&tag=agents")
+	if status != http.StatusOK || tree.Count != 3 || len(tree.Roots) != 2 {
+		t.Fatalf("tree: %d %+v", status, tree)
+	}
+	if query != "include_provenance=1&limit=5000&tag=agents" {
+		t.Errorf("backend query = %q", query)
+	}
+
+	status, tree = get("/v1/contexts/tree?root=2")
+	if status != http.StatusOK || tree.Count != 2 || len(tree.Roots) != 1 || tree.Roots[0].ContextID != "1" {
+		t.Fatalf("root=2: %d %+v", status, tree)
+	}
+	if query != "include_provenance=1&limit=500" {
+		t.Errorf("backend query = %q", query)
+	}
+
+	for target, want := range map[string]int{
+		"/v1/contexts/tree?root=x":     http.StatusBadRequest,
+		"/v1/contexts/tree?root=42":    http.StatusNotFound,
+		"/v1/contexts/tree?tag=broken": http.StatusBadGateway,
+	} {
+		if status, _ := get(target); status != want {
+			t.Errorf("%s: status %d, want %d", target, status, want)
+		}
+	}
+	rec := httptest.NewRecorder()
+	s.contextTree(rec, httptest.NewRequest(http.MethodPost, "/v1/contexts/tree", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status %d", rec.Code)
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/strongdm/cxdb/gateway/pkg/apierror"
+	"github.com/strongdm/cxdb/gateway/pkg/auth"
+	"github.com/strongdm/cxdb/gateway/pkg/contextid"
+)
+
+// maxMeBodyBytes bounds request bodies for the /v1/me endpoints.
+const maxMeBodyBytes = 4 << 10
+
+type contextRefRequest struct {
+	ContextID string `json:"context_id"`
+	Label     string `json:"label,omitempty"`
+}
+
+// meRecent serves /v1/me/recent.
+//
+//	GET    - list recently viewed contexts (?limit=N)
+//	POST   - record a view: {"context_id": "123"}
+//	DELETE - clear history
+func (s *Server) meRecent(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		apierror.Write(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		recent, err := s.userState.Recent(r.Context(), user.Email, limit)
+		if err != nil {
+			s.logger.Error("user_recent_list_failed", "user", user.Email, "err", err)
+			apierror.Write(w, r, http.StatusInternalServerError, "internal error")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"recent": recent})
+	case http.MethodPost:
+		var req contextRefRequest
+		if !decodeMeBody(w, r, &req) {
+			return
+		}
+		if err := s.userState.RecordView(r.Context(), user.Email, req.ContextID); err != nil {
+			s.writeUserStateError(w, r, user.Email, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		if err := s.userState.ClearRecent(r.Context(), user.Email); err != nil {
+			s.writeUserStateError(w, r, user.Email, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		apierror.Write(w, r, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// meBookmarks serves /v1/me/bookmarks.
+//
+//	GET  - list bookmarks
+//	POST - add or relabel a bookmark: {"context_id": "123", "label": "..."}
+func (s *Server) meBookmarks(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		apierror.Write(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		bookmarks, err := s.userState.Bookmarks(r.Context(), user.Email)
+		if err != nil {
+			s.logger.Error("user_bookmarks_list_failed", "user", user.Email, "err", err)
+			apierror.Write(w, r, http.StatusInternalServerError, "internal error")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"bookmarks": bookmarks})
+	case http.MethodPost:
+		var req contextRefRequest
+		if !decodeMeBody(w, r, &req) {
+			return
+		}
+		b, err := s.userState.AddBookmark(r.Context(), user.Email, req.ContextID, req.Label)
+		if err != nil {
+			s.writeUserStateError(w, r, user.Email, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, b)
+	default:
+		apierror.Write(w, r, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// meBookmark serves /v1/me/bookmarks/{context_id}.
+//
+//	PUT    - add or relabel a bookmark: optional {"label": "..."}
+//	DELETE - remove the bookmark
+func (s *Server) meBookmark(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		apierror.Write(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	contextID := strings.TrimPrefix(r.URL.Path, "/v1/me/bookmarks/")
+
+	switch r.Method {
+	case http.MethodPut:
+		var req contextRefRequest
+		if r.ContentLength != 0 && !decodeMeBody(w, r, &req) {
+			return
+		}
+		b, err := s.userState.AddBookmark(r.Context(), user.Email, contextID, req.Label)
+		if err != nil {
+			s.writeUserStateError(w, r, user.Email, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, b)
+	case http.MethodDelete:
+		removed, err := s.userState.RemoveBookmark(r.Context(), user.Email, contextID)
+		if err != nil {
+			s.writeUserStateError(w, r, user.Email, err)
+			return
+		}
+		if !removed {
+			apierror.Write(w, r, http.StatusNotFound, "bookmark not found")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		apierror.Write(w, r, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) writeUserStateError(w http.ResponseWriter, r *http.Request, email string, err error) {
+	if errors.Is(err, contextid.ErrInvalid) {
+		apierror.Write(w, r, http.StatusBadRequest, "invalid context_id")
+		return
+	}
+	s.logger.Error("user_state_write_failed", "user", email, "err", err)
+	apierror.Write(w, r, http.StatusInternalServerError, "internal error")
+}
+
+// trackContextViews records a recent-context view when an authenticated
+// user successfully loads the first page of a context's turns.
+func (s *Server) trackContextViews(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contextID, ok := viewedContextID(r)
+		user := auth.UserFromContext(r.Context())
+		if !ok || user == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		if sw.status != http.StatusOK {
+			return
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			if err := s.userState.RecordView(ctx, user.Email, contextID); err != nil {
+				s.logger.Warn("user_recent_record_failed", "user", user.Email, "context_id", contextID, "err", err)
+			}
+		}()
+	})
+}
+
+// viewedContextID matches GET /v1/contexts/{id}/turns without a paging
+// cursor, so scrolling back through history does not count as a new view.
+func viewedContextID(r *http.Request) (string, bool) {
+	if r.Method != http.MethodGet || r.URL.Query().Get("before_turn_id") != "" {
+		return "", false
+	}
+	rest, ok := strings.CutPrefix(r.URL.Path, "/v1/contexts/")
+	if !ok {
+		return "", false
+	}
+	id, ok := strings.CutSuffix(rest, "/turns")
+	if !ok || id == "" || strings.Contains(id, "/") {
+		return "", false
+	}
+	return id, true
+}
+
+func decodeMeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxMeBodyBytes+1))
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "read body")
+		return false
+	}
+	if len(body) > maxMeBodyBytes {
+		apierror.Write(w, r, http.StatusRequestEntityTooLarge, "request body too large")
+		return false
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "invalid JSON body")
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
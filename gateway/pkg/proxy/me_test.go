@@ -0,0 +1,200 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/strongdm/cxdb/gateway/pkg/auth"
+	"github.com/strongdm/cxdb/gateway/pkg/userstate"
+)
+
+func newMeServer(t *testing.T) (*Server, *http.ServeMux) {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "gateway.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	state, err := userstate.NewStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{userState: state, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/me/recent", s.meRecent)
+	mux.HandleFunc("/v1/me/bookmarks", s.meBookmarks)
+	mux.HandleFunc("/v1/me/bookmarks/", s.meBookmark)
+	return s, mux
+}
+
+// meRequest sends a request as email, or anonymously when email is empty,
+// and returns the status and body.
+func meRequest(t *testing.T, h http.Handler, email, method, target, body string) (int, string) {
+	t.Helper()
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, target, r)
+	if email != "" {
+		req = req.WithContext(auth.WithUser(req.Context(), &auth.Session{Email: email}))
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code, rec.Body.String()
+}
+
+func TestMeRecent(t *testing.T) {
+	_, mux := newMeServer(t)
+	const alice = "alice@example.com"
+
+	for _, body := range []string{`{"context_id":"5"}`, `{"context_id":"0009"}`} {
+		if status, resp := meRequest(t, mux, alice, "POST", "/v1/me/recent", body); status != http.StatusNoContent {
+			t.Fatalf("POST %s: %d %s", body, status, resp)
+		}
+	}
+	status, body := meRequest(t, mux, alice, "GET", "/v1/me/recent?limit=1", "")
+	var listing struct {
+		Recent []userstate.RecentContext `json:"recent"`
+	}
+	if err := json.Unmarshal([]byte(body), &listing); err != nil || status != http.StatusOK {
+		t.Fatalf("GET: %d %s", status, body)
+	}
+	if len(listing.Recent) != 1 || listing.Recent[0].ContextID != "9" {
+		t.Fatalf("recent = %+v, want only context 9", listing.Recent)
+	}
+	if _, body := meRequest(t, mux, "bob@example.com", "GET", "/v1/me/recent", ""); body != "{\"recent\":[]}\n" {
+		t.Fatalf("bob sees %s", body)
+	}
+
+	for _, tc := range []struct {
+		email, method, body string
+		want                int
+	}{
+		{"", "GET", "", http.StatusUnauthorized},
+		{"", "POST", `{"context_id":"5"}`, http.StatusUnauthorized},
+		{alice, "POST", `{"context_id":"five"}`, http.StatusBadRequest},
+		{alice, "POST", `not json`, http.StatusBadRequest},
+		{alice, "POST", `{"context_id":"` + strings.Repeat("1", maxMeBodyBytes) + `"}`, http.StatusRequestEntityTooLarge},
+		{alice, "PATCH", "", http.StatusMethodNotAllowed},
+		{alice, "DELETE", "", http.StatusNoContent},
+	} {
+		if status, body := meRequest(t, mux, tc.email, tc.method, "/v1/me/recent", tc.body); status != tc.want {
+			t.Errorf("%s as %q: %d %s, want %d", tc.method, tc.email, status, body, tc.want)
+		}
+	}
+	if _, body := meRequest(t, mux, alice, "GET", "/v1/me/recent", ""); body != "{\"recent\":[]}\n" {
+		t.Fatalf("after DELETE: %s", body)
+	}
+}
+
+func TestMeBookmarks(t *testing.T) {
+	_, mux := newMeServer(t)
+	const alice = "alice@example.com"
+
+	if status, body := meRequest(t, mux, alice, "POST", "/v1/me/bookmarks", `{"context_id":"12","label":"incident"}`); status != http.StatusCreated {
+		t.Fatalf("POST: %d %s", status, body)
+	}
+	status, body := meRequest(t, mux, alice, "PUT", "/v1/me/bookmarks/012", "")
+	var b userstate.Bookmark
+	if err := json.Unmarshal([]byte(body), &b); err != nil || status != http.StatusOK {
+		t.Fatalf("PUT: %d %s", status, body)
+	}
+	if b.ContextID != "12" || b.Label != "" {
+		t.Fatalf("PUT without a body = %+v, want the label cleared", b)
+	}
+	if status, _ := meRequest(t, mux, alice, "PUT", "/v1/me/bookmarks/13", `{"label":"later"}`); status != http.StatusOK {
+		t.Fatalf("PUT 13: %d", status)
+	}
+
+	status, body = meRequest(t, mux, alice, "GET", "/v1/me/bookmarks", "")
+	var listing struct {
+		Bookmarks []userstate.Bookmark `json:"bookmarks"`
+	}
+	if err := json.Unmarshal([]byte(body), &listing); err != nil || status != http.StatusOK {
+		t.Fatalf("GET: %d %s", status, body)
+	}
+	if len(listing.Bookmarks) != 2 {
+		t.Fatalf("bookmarks = %+v", listing.Bookmarks)
+	}
+
+	for _, tc := range []struct {
+		email, method, target string
+		want                  int
+	}{
+		{"", "GET", "/v1/me/bookmarks", http.StatusUnauthorized},
+		{"", "DELETE", "/v1/me/bookmarks/12", http.StatusUnauthorized},
+		{"bob@example.com", "DELETE", "/v1/me/bookmarks/12", http.StatusNotFound},
+		{alice, "DELETE", "/v1/me/bookmarks/x", http.StatusBadRequest},
+		{alice, "PUT", "/v1/me/bookmarks/", http.StatusBadRequest},
+		{alice, "GET", "/v1/me/bookmarks/12", http.StatusMethodNotAllowed},
+		{alice, "DELETE", "/v1/me/bookmarks/12", http.StatusNoContent},
+		{alice, "DELETE", "/v1/me/bookmarks/12", http.StatusNotFound},
+		{alice, "PUT", "/v1/me/bookmarks", http.StatusMethodNotAllowed},
+	} {
+		if status, body := meRequest(t, mux, tc.email, tc.method, tc.target, ""); status != tc.want {
+			t.Errorf("%s %s as %q: %d %s, want %d", tc.method, tc.target, tc.email, status, body, tc.want)
+		}
+	}
+}
+
+func TestTrackContextViews(t *testing.T) {
+	s, _ := newMeServer(t)
+	status := http.StatusOK
+	h := s.trackContextViews(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(status) }))
+
+	meRequest(t, h, "alice@example.com", "GET", "/v1/contexts/3/turns?before_turn_id=9", "")
+	meRequest(t, h, "alice@example.com", "GET", "/v1/contexts/3/provenance", "")
+	meRequest(t, h, "", "GET", "/v1/contexts/3/turns", "")
+	status = http.StatusNotFound
+	meRequest(t, h, "alice@example.com", "GET", "/v1/contexts/4/turns", "")
+	status = http.StatusOK
+	meRequest(t, h, "alice@example.com", "GET", "/v1/contexts/5/turns?limit=10", "")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		recent, err := s.userState.Recent(context.Background(), "alice@example.com", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(recent) > 0 || time.Now().After(deadline) {
+			if len(recent) != 1 || recent[0].ContextID != "5" {
+				t.Fatalf("recent = %+v, want only context 5", recent)
+			}
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestViewedContextID(t *testing.T) {
+	for _, tc := range []struct {
+		method, target string
+		want           string
+	}{
+		{"GET", "/v1/contexts/42/turns", "42"},
+		{"GET", "/v1/contexts/42/turns?limit=5", "42"},
+		{"GET", "/v1/contexts/42/turns?before_turn_id=7", ""},
+		{"POST", "/v1/contexts/42/turns", ""},
+		{"GET", "/v1/contexts/42", ""},
+		{"GET", "/v1/contexts//turns", ""},
+		{"GET", "/v1/contexts/42/x/turns", ""},
+	} {
+		got, ok := viewedContextID(httptest.NewRequest(tc.method, tc.target, nil))
+		if got != tc.want || ok != (tc.want != "") {
+			t.Errorf("%s %s = %q, %t; want %q", tc.method, tc.target, got, ok, tc.want)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"github.com/strongdm/cxdb/gateway/pkg/cqlfilter"
+)
+
+// newPublicContexts decides which contexts public read tokens may see: those
+// carrying one of the public labels. Removing a label takes up to the
+// filter's cache TTL to hide a context.
+func newPublicContexts(labels []string, s *Server) *cqlfilter.Filter {
+	return cqlfilter.New(cqlfilter.In("label", labels), s.backendGet)
+}
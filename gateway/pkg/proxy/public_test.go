@@ -0,0 +1,62 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPublicContexts(t *testing.T) {
+	var searches []string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/contexts/search" {
+			http.NotFound(w, r)
+			return
+		}
+		q := r.URL.Query().Get("q")
+		searches = append(searches, q)
+		switch {
+		case strings.HasPrefix(q, "id = 7 "):
+			_, _ = io.WriteString(w, `{"contexts":[{"context_id":"7"}]}`)
+		case strings.HasPrefix(q, "id = 9 "):
+			http.Error(w, "storage offline", http.StatusServiceUnavailable)
+		default:
+			_, _ = io.WriteString(w, `{"contexts":[]}`)
+		}
+	}))
+	defer backend.Close()
+
+	rp, err := NewReverseProxy(backend.URL, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := newPublicContexts([]string{"demo", `say "hi"`}, &Server{proxy: rp})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if ok, err := p.Matches(ctx, 7); !ok || err != nil {
+			t.Errorf("context 7: %t, %v", ok, err)
+		}
+		if ok, err := p.Matches(ctx, 8); ok || err != nil {
+			t.Errorf("context 8: %t, %v", ok, err)
+		}
+	}
+	if _, err := p.Matches(ctx, 9); err == nil {
+		t.Error("backend failure not reported")
+	}
+
+	// Verdicts are cached; failures are not.
+	if len(searches) != 3 {
+		t.Errorf("backend searched %d times: %q", len(searches), searches)
+	}
+	if want := `id = 7 AND label IN ("demo", "say \"hi\"")`; searches[0] != want {
+		t.Errorf("search query = %s, want %s", searches[0], want)
+	}
+}
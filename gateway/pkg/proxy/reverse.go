@@ -4,24 +4,58 @@
 package proxy
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
 	"log/slog"
 	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/strongdm/cxdb/gateway/internal/config"
+	"github.com/strongdm/cxdb/gateway/pkg/apierror"
+	"github.com/strongdm/cxdb/gateway/pkg/tracing"
 )
 
 // ReverseProxy wraps httputil.ReverseProxy with additional configuration.
 type ReverseProxy struct {
-	proxy  *httputil.ReverseProxy
-	target *url.URL
-	logger *slog.Logger
+	proxy    *httputil.ReverseProxy
+	target   *url.URL
+	logger   *slog.Logger
+	timeouts config.RouteTimeouts
+}
+
+// ReverseProxyOption configures NewReverseProxy.
+type ReverseProxyOption func(*ReverseProxy)
+
+// WithFlushInterval sets how often buffered response bodies are flushed to
+// the client. Zero flushes only at the end of the response; a negative
+// value flushes after every write. Event streams and responses of unknown
+// length are always flushed after every write.
+func WithFlushInterval(d time.Duration) ReverseProxyOption {
+	return func(rp *ReverseProxy) {
+		rp.proxy.FlushInterval = d
+	}
+}
+
+// WithRouteTimeouts bounds backend requests by route: metadata and turn
+// listings get a total deadline, blob and filesystem downloads an idle
+// timeout. Without it, proxied requests are only bounded by the server's
+// write timeout.
+func WithRouteTimeouts(t config.RouteTimeouts) ReverseProxyOption {
+	return func(rp *ReverseProxy) {
+		rp.timeouts = t
+	}
 }
 
 // NewReverseProxy creates a reverse proxy to the specified backend URL.
-func NewReverseProxy(backendURL string, logger *slog.Logger) (*ReverseProxy, error) {
+func NewReverseProxy(backendURL string, logger *slog.Logger, opts ...ReverseProxyOption) (*ReverseProxy, error) {
 	target, err := url.Parse(backendURL)
 	if err != nil {
 		return nil, err
@@ -60,14 +94,38 @@ func NewReverseProxy(backendURL string, logger *slog.Logger) (*ReverseProxy, err
 		}
 	}
 
+	// Event streams must not be buffered by anything between the gateway
+	// and the browser (nginx, ALB ingress controllers).
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		if isEventStream(resp.Header) {
+			resp.Header.Set("X-Accel-Buffering", "no")
+			if resp.Header.Get("Cache-Control") == "" {
+				resp.Header.Set("Cache-Control", "no-cache")
+			}
+			return nil
+		}
+		setHeadETag(resp)
+		return standardizeError(resp)
+	}
+
 	// Custom error handler
 	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
-		logger.Error("proxy error", "path", r.URL.Path, "method", r.Method, "err", err)
-		http.Error(w, "Bad Gateway", http.StatusBadGateway)
+		cause := context.Cause(r.Context())
+		if errors.Is(cause, context.DeadlineExceeded) || errors.Is(cause, errUpstreamIdle) {
+			logger.Warn("proxy timeout", "path", r.URL.Path, "method", r.Method, "request_id", apierror.RequestID(r.Context()), "err", cause)
+			apierror.Write(w, r, http.StatusGatewayTimeout, "Gateway Timeout")
+			return
+		}
+		logger.Error("proxy error", "path", r.URL.Path, "method", r.Method, "request_id", apierror.RequestID(r.Context()), "err", err)
+		apierror.Write(w, r, http.StatusBadGateway, "Bad Gateway")
 	}
 
-	// Custom transport with reasonable timeouts
-	proxy.Transport = &http.Transport{
+	// Custom transport with reasonable timeouts. There is deliberately no
+	// response or idle-read timeout here: per-route limits are applied by
+	// ServeHTTP, and event streams and upgraded connections stay open for
+	// as long as the client does. Each backend request is a client span
+	// carrying the trace context to the backend.
+	proxy.Transport = tracing.Transport(&http.Transport{
 		DialContext: (&net.Dialer{
 			Timeout:   30 * time.Second,
 			KeepAlive: 30 * time.Second,
@@ -76,17 +134,45 @@ func NewReverseProxy(backendURL string, logger *slog.Logger) (*ReverseProxy, err
 		IdleConnTimeout:       90 * time.Second,
 		TLSHandshakeTimeout:   10 * time.Second,
 		ExpectContinueTimeout: 1 * time.Second,
-	}
+	})
 
-	return &ReverseProxy{
+	rp := &ReverseProxy{
 		proxy:  proxy,
 		target: target,
 		logger: logger,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(rp)
+	}
+	return rp, nil
 }
 
 // ServeHTTP implements http.Handler.
 func (rp *ReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var limit time.Duration
+	switch classifyRoute(r) {
+	case routeMetadata:
+		limit = rp.timeouts.Metadata
+	case routeTurns:
+		limit = rp.timeouts.Turns
+	case routeStream:
+		// Downloads may take as long as they keep making progress, so the
+		// server's write timeout is lifted in favour of the idle timeout.
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+		if idle := rp.timeouts.StreamIdle; idle > 0 {
+			ctx, cancel := context.WithCancelCause(r.Context())
+			defer cancel(nil)
+			iw := &idleWriter{ResponseWriter: w, idle: idle}
+			iw.timer = time.AfterFunc(idle, func() { cancel(errUpstreamIdle) })
+			defer iw.timer.Stop()
+			w, r = iw, r.WithContext(ctx)
+		}
+	}
+	if limit > 0 {
+		ctx, cancel := context.WithTimeout(r.Context(), limit)
+		defer cancel()
+		r = r.WithContext(ctx)
+	}
 	rp.proxy.ServeHTTP(w, r)
 }
 
@@ -95,6 +181,107 @@ func (rp *ReverseProxy) Target() string {
 	return rp.target.String()
 }
 
+// errUpstreamIdle cancels a streamed download whose backend stopped sending.
+var errUpstreamIdle = errors.New("upstream idle timeout")
+
+// routeClass selects which of the RouteTimeouts applies to a request.
+type routeClass int
+
+const (
+	routeMetadata  routeClass = iota // contexts, search, provenance, registry
+	routeTurns                       // GET /v1/contexts/{id}/turns
+	routeStream                      // blob and filesystem downloads
+	routeUnbounded                   // event streams and upgrades
+)
+
+func classifyRoute(r *http.Request) routeClass {
+	if r.Header.Get("Upgrade") != "" || r.URL.Path == "/v1/events" ||
+		strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		return routeUnbounded
+	}
+	path := r.URL.Path
+	if strings.HasPrefix(path, "/v1/blobs/") {
+		return routeStream
+	}
+	if rest, ok := strings.CutPrefix(path, "/v1/turns/"); ok {
+		if parts := strings.SplitN(rest, "/", 3); len(parts) >= 2 && parts[1] == "fs" {
+			return routeStream
+		}
+	}
+	if strings.HasPrefix(path, "/v1/contexts/") && strings.HasSuffix(path, "/turns") {
+		return routeTurns
+	}
+	return routeMetadata
+}
+
+// idleWriter restarts the idle timer whenever the proxy copies backend bytes
+// to the client.
+type idleWriter struct {
+	http.ResponseWriter
+	idle  time.Duration
+	timer *time.Timer
+}
+
+func (w *idleWriter) Write(b []byte) (int, error) {
+	w.timer.Reset(w.idle)
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController flush the underlying writer.
+func (w *idleWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// maxBackendErrorBytes bounds the backend error bodies standardizeError
+// reads; longer bodies are passed through untouched.
+const maxBackendErrorBytes = 64 << 10
+
+// standardizeError rewrites a backend error response into the gateway's
+// JSON error shape and adds the request ID. Bodies that are not a backend
+// JSON error become the message.
+func standardizeError(resp *http.Response) error {
+	if resp.StatusCode < 400 || resp.Header.Get("Content-Encoding") != "" {
+		return nil
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBackendErrorBytes+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > maxBackendErrorBytes {
+		resp.Body = readCloser{io.MultiReader(bytes.NewReader(data), resp.Body), resp.Body}
+		return nil
+	}
+	_ = resp.Body.Close()
+
+	var body apierror.Body
+	if json.Unmarshal(data, &body) != nil || body.Error.Message == "" {
+		body = apierror.Body{Error: apierror.Detail{Message: strings.TrimSpace(string(data))}}
+	}
+	if body.Error.Code == 0 {
+		body.Error.Code = resp.StatusCode
+	}
+	if body.Error.Message == "" {
+		body.Error.Message = http.StatusText(resp.StatusCode)
+	}
+	body.Error.RequestID = apierror.RequestID(resp.Request.Context())
+
+	out, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	out = append(out, '\n')
+	resp.Body = io.NopCloser(bytes.NewReader(out))
+	resp.ContentLength, resp.TransferEncoding = int64(len(out)), nil
+	resp.Header.Set("Content-Length", strconv.Itoa(len(out)))
+	resp.Header.Set("Content-Type", "application/json")
+	return nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
 func extractClientIP(r *http.Request) string {
 	// Check X-Forwarded-For first (in case we're behind another proxy)
 	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
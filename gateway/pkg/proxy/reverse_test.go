@@ -0,0 +1,164 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/strongdm/cxdb/gateway/internal/config"
+	"github.com/strongdm/cxdb/gateway/pkg/apierror"
+)
+
+func TestReverseProxy_StandardizesBackendErrors(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get(apierror.Header); got != "req-7" {
+			t.Errorf("backend saw request ID %q", got)
+		}
+		switch r.URL.Path {
+		case "/v1/contexts/1":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"error":{"code":404,"message":"context not found"}}`)
+		case "/v1/contexts/2":
+			http.Error(w, "storage offline", http.StatusServiceUnavailable)
+		default:
+			_, _ = io.WriteString(w, `{"ok":true}`)
+		}
+	}))
+	defer backend.Close()
+
+	rp, err := NewReverseProxy(backend.URL, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := httptest.NewServer(apierror.RequestIDs(rp))
+	defer gw.Close()
+
+	get := func(path string) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, gw.URL+path, nil)
+		req.Header.Set(apierror.Header, "req-7")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	for path, want := range map[string]apierror.Detail{
+		"/v1/contexts/1": {Code: 404, Message: "context not found", RequestID: "req-7"},
+		"/v1/contexts/2": {Code: 503, Message: "storage offline", RequestID: "req-7"},
+	} {
+		status, raw := get(path)
+		var body apierror.Body
+		if err := json.Unmarshal([]byte(raw), &body); err != nil {
+			t.Fatalf("%s: %v in %q", path, err, raw)
+		}
+		if status != want.Code || body.Error != want {
+			t.Errorf("%s: %d %+v, want %+v", path, status, body.Error, want)
+		}
+	}
+
+	if status, raw := get("/v1/contexts"); status != http.StatusOK || raw != `{"ok":true}` {
+		t.Errorf("success response changed: %d %q", status, raw)
+	}
+}
+
+func TestClassifyRoute(t *testing.T) {
+	for path, want := range map[string]routeClass{
+		"/v1/contexts":               routeMetadata,
+		"/v1/contexts/search":        routeMetadata,
+		"/v1/contexts/7/provenance":  routeMetadata,
+		"/v1/registry/renderers":     routeMetadata,
+		"/v1/contexts/7/turns":       routeTurns,
+		"/v1/blobs/abc":              routeStream,
+		"/v1/turns/9/fs":             routeStream,
+		"/v1/turns/9/fs/src/main.go": routeStream,
+		"/v1/events":                 routeUnbounded,
+	} {
+		if got := classifyRoute(httptest.NewRequest(http.MethodGet, path, nil)); got != want {
+			t.Errorf("%s: class %d, want %d", path, got, want)
+		}
+	}
+	r := httptest.NewRequest(http.MethodGet, "/v1/contexts/7/stream", nil)
+	r.Header.Set("Accept", "text/event-stream")
+	if got := classifyRoute(r); got != routeUnbounded {
+		t.Errorf("event stream: class %d", got)
+	}
+}
+
+func TestReverseProxy_RouteTimeouts(t *testing.T) {
+	const tick = 30 * time.Millisecond
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rc := http.NewResponseController(w)
+		switch r.URL.Path {
+		case "/v1/contexts/1/provenance":
+			time.Sleep(5 * tick)
+		case "/v1/blobs/trickle":
+			// Longer in total than any limit, but never idle for long.
+			for i := 0; i < 8; i++ {
+				_, _ = io.WriteString(w, "x")
+				_ = rc.Flush()
+				time.Sleep(tick)
+			}
+			return
+		case "/v1/blobs/stall":
+			_, _ = io.WriteString(w, "x")
+			_ = rc.Flush()
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		_, _ = io.WriteString(w, `{"ok":true}`)
+	}))
+	defer backend.Close()
+
+	rp, err := NewReverseProxy(backend.URL, slog.New(slog.NewTextHandler(io.Discard, nil)),
+		WithFlushInterval(-1),
+		WithRouteTimeouts(config.RouteTimeouts{Metadata: 2 * tick, Turns: 2 * tick, StreamIdle: 3 * tick}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := httptest.NewServer(apierror.RequestIDs(rp))
+	defer gw.Close()
+
+	get := func(path string) (int, string, error) {
+		t.Helper()
+		resp, err := http.Get(gw.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body), err
+	}
+
+	if status, _, _ := get("/v1/contexts/1/provenance"); status != http.StatusGatewayTimeout {
+		t.Errorf("slow metadata: status %d, want 504", status)
+	}
+	if status, body, err := get("/v1/contexts/2/provenance"); status != http.StatusOK || err != nil || body != `{"ok":true}` {
+		t.Errorf("fast metadata: %d %q %v", status, body, err)
+	}
+	if status, body, err := get("/v1/blobs/trickle"); status != http.StatusOK || err != nil || body != "xxxxxxxx" {
+		t.Errorf("trickling blob: %d %q %v", status, body, err)
+	}
+	start := time.Now()
+	if _, _, err := get("/v1/blobs/stall"); err == nil {
+		t.Error("stalled blob completed, want the response cut off")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("stalled blob took %v to fail", elapsed)
+	}
+}
@@ -10,12 +10,18 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/strongdm/cxdb/gateway/internal/config"
+	"github.com/strongdm/cxdb/gateway/pkg/accesslog"
+	"github.com/strongdm/cxdb/gateway/pkg/apierror"
 	"github.com/strongdm/cxdb/gateway/pkg/auth"
+	"github.com/strongdm/cxdb/gateway/pkg/openapi"
+	"github.com/strongdm/cxdb/gateway/pkg/tracing"
+	"github.com/strongdm/cxdb/gateway/pkg/userstate"
 	"golang.org/x/time/rate"
 )
 
@@ -30,6 +36,13 @@ type Server struct {
 	logger   *slog.Logger
 	staticFS fs.FS
 
+	// Per-user recent contexts and bookmarks, stored beside sessions
+	userState *userstate.Store
+
+	// Audit log of context reads; nil when ACCESS_LOG_RETENTION_DAYS=0
+	accessLog  *accesslog.Store
+	accessSeen accessThrottle
+
 	cspHeader   string
 	hstsEnabled bool
 	limiters    *ipRateLimiter
@@ -37,6 +50,9 @@ type Server struct {
 	// Service-to-service auth verifiers (optional)
 	tokenVerifiers []auth.BearerTokenVerifier
 	awsExchanger   *auth.AWSTokenExchanger
+
+	// Anonymous read tokens for public demos (optional)
+	publicRead *auth.PublicReader
 }
 
 // New constructs the HTTP server and registers all routes.
@@ -51,6 +67,16 @@ func New(cfg config.Config, sessions *auth.SessionStore, google *auth.GoogleAuth
 	for _, origin := range cfg.AllowedRendererOrigins {
 		scriptSrc += " " + origin
 	}
+	imgSrc := "'self' data: https://lh3.googleusercontent.com"
+	if origin := urlOrigin(cfg.BrandLogoURL); origin != "" {
+		imgSrc += " " + origin
+	}
+	branding := auth.Branding{
+		OrgName: cfg.BrandOrgName,
+		LogoURL: cfg.BrandLogoURL,
+		HelpURL: cfg.BrandHelpURL,
+	}
+	google.SetBranding(branding)
 
 	s := &Server{
 		cfg:      cfg,
@@ -63,7 +89,7 @@ func New(cfg config.Config, sessions *auth.SessionStore, google *auth.GoogleAuth
 		staticFS: staticFS,
 		cspHeader: strings.Join([]string{
 			"default-src 'self'",
-			"img-src 'self' data: https://lh3.googleusercontent.com",
+			"img-src " + imgSrc,
 			"script-src " + scriptSrc,
 			"style-src 'self' 'unsafe-inline'",
 			"connect-src 'self'",
@@ -75,6 +101,20 @@ func New(cfg config.Config, sessions *auth.SessionStore, google *auth.GoogleAuth
 		limiters:    newIPRateLimiter(rate.Limit(5), 10),
 	}
 
+	userState, err := userstate.NewStore(sessions.DB())
+	if err != nil {
+		return nil, fmt.Errorf("init user state: %w", err)
+	}
+	s.userState = userState
+
+	if cfg.AccessLogRetention > 0 {
+		accessLog, err := accesslog.NewStore(sessions.DB(), cfg.AccessLogRetention)
+		if err != nil {
+			return nil, fmt.Errorf("init access log: %w", err)
+		}
+		s.accessLog = accessLog
+	}
+
 	// Initialize K8s OIDC verifier if enabled
 	if cfg.K8sOIDCEnabled {
 		k8sVerifier, err := auth.NewK8sOIDCVerifier(
@@ -89,13 +129,14 @@ func New(cfg config.Config, sessions *auth.SessionStore, google *auth.GoogleAuth
 		logger.Info("k8s_oidc_enabled", "issuer", cfg.K8sOIDCIssuerURL, "audience", cfg.K8sOIDCAudience)
 	}
 
+	// Issuer for gateway-minted tokens, from PublicBaseURL
+	// (e.g., "https://your-domain.com" -> "your-domain.com")
+	issuer := strings.TrimPrefix(cfg.PublicBaseURL, "https://")
+	issuer = strings.TrimPrefix(issuer, "http://")
+	issuer = strings.TrimSuffix(issuer, "/")
+
 	// Initialize AWS IAM token exchanger if enabled
 	if cfg.AWSIAMEnabled {
-		// Extract issuer from PublicBaseURL (e.g., "https://your-domain.com" -> "your-domain.com")
-		issuer := strings.TrimPrefix(cfg.PublicBaseURL, "https://")
-		issuer = strings.TrimPrefix(issuer, "http://")
-		issuer = strings.TrimSuffix(issuer, "/")
-
 		awsExchanger, err := auth.NewAWSTokenExchanger(
 			cfg.AWSIAMAllowedRoles,
 			cfg.AWSIAMTokenTTL,
@@ -105,11 +146,19 @@ func New(cfg config.Config, sessions *auth.SessionStore, google *auth.GoogleAuth
 		if err != nil {
 			return nil, fmt.Errorf("init aws iam exchanger: %w", err)
 		}
+		awsExchanger.SetBranding(branding)
 		s.awsExchanger = awsExchanger
 		s.tokenVerifiers = append(s.tokenVerifiers, awsExchanger)
 		logger.Info("aws_iam_enabled", "allowed_roles", len(cfg.AWSIAMAllowedRoles), "token_ttl", cfg.AWSIAMTokenTTL)
 	}
 
+	// Initialize public read tokens if enabled
+	if cfg.PublicReadMode {
+		filter := newPublicContexts(cfg.PublicReadLabels, s)
+		s.publicRead = auth.NewPublicReader(cfg.PublicReadLabels, []byte(cfg.SessionSecret), issuer, filter.Matches)
+		logger.Info("public_read_enabled", "labels", cfg.PublicReadLabels)
+	}
+
 	// Health check endpoints (public)
 	mux.HandleFunc("/healthz", s.healthz)
 	mux.HandleFunc("/readyz", s.readyz)
@@ -118,20 +167,58 @@ func New(cfg config.Config, sessions *auth.SessionStore, google *auth.GoogleAuth
 	mux.HandleFunc("/auth/google/login", google.LoginHandler)
 	mux.HandleFunc("/auth/google/callback", google.CallbackHandler)
 	mux.HandleFunc("/auth/google/logout", google.LogoutHandler)
+	mux.HandleFunc("/auth/branding", auth.BrandingHandler(branding))
 
 	// AWS IAM token exchange endpoint (public - uses AWS creds for auth)
 	if s.awsExchanger != nil {
 		mux.HandleFunc("/auth/aws/token", s.awsExchanger.TokenHandler)
 	}
 
+	// Anonymous read token endpoint for public demos (public)
+	if s.publicRead != nil {
+		mux.HandleFunc("/auth/public/token", s.publicRead.TokenHandler)
+	}
+
 	// API info endpoint
 	mux.HandleFunc("/api/v1/me", s.me)
 
+	// OpenAPI document for the HTTP API (must be before /v1/ catch-all)
+	mux.Handle("/v1/openapi.json", openapi.Handler())
+
+	// Per-user navigation state owned by the gateway (must be before /v1/ catch-all)
+	mux.HandleFunc("/v1/me/recent", s.meRecent)
+	mux.HandleFunc("/v1/me/bookmarks", s.meBookmarks)
+	mux.HandleFunc("/v1/me/bookmarks/", s.meBookmark)
+
+	// Context lineage tree built from provenance (must be before /v1/ catch-all)
+	mux.HandleFunc("/v1/contexts/tree", s.contextTree)
+
+	// Who read a context, from the gateway's access log (must be before /v1/ catch-all)
+	mux.HandleFunc("GET /v1/contexts/{id}/access", s.contextAccess)
+
+	// Filesystem snapshot as a tar.gz assembled from blobs (must be before /v1/ catch-all)
+	mux.HandleFunc("GET /v1/turns/{turn_id}/fs.tar.gz", s.fsExport)
+
+	// Changes between two turns' filesystem snapshots (must be before /v1/ catch-all)
+	mux.HandleFunc("/v1/fsdiff", s.fsDiff)
+
+	// Binary protocol over WebSocket (must be before /v1/ catch-all)
+	if cfg.CXDBBinaryAddr != "" {
+		tunnel := NewBinaryTunnel(cfg.CXDBBinaryAddr, logger)
+		tunnel.origin = urlOrigin(cfg.PublicBaseURL)
+		tunnel.writers = cfg.BinaryTunnelWriters
+		if s.accessLog != nil {
+			tunnel.recordRead = s.recordAccess
+		}
+		mux.Handle("GET /v1/binary", tunnel)
+	}
+
 	// SSE endpoint for live events (must be before /v1/ catch-all)
 	mux.Handle("/v1/events", sseBroker)
 
-	// Reverse proxy for all /v1/* endpoints
-	mux.Handle("/v1/", proxy)
+	// Reverse proxy for all /v1/* endpoints; head turn listings answer
+	// If-None-Match with 304 while the head is unchanged
+	mux.Handle("/v1/", s.logContextAccess(s.trackContextViews(s.conditionalTurns(proxy))))
 
 	// Serve embedded React frontend for all other routes
 	mux.Handle("/", s.staticHandler())
@@ -149,16 +236,20 @@ func (s *Server) ListenAndServe(ctx context.Context) error {
 		Store:          s.sessions,
 		DevBypass:      s.cfg.DevMode,
 		TokenVerifiers: s.tokenVerifiers,
+		PublicRead:     s.publicRead,
 	}, s.mux)
+	handler = streamDeadlines(handler)
 	handler = s.rateLimitMiddleware(handler)
 	handler = s.securityHeaders(handler)
 	handler = s.loggingMiddleware(handler)
+	handler = tracing.Middleware(handler)
+	handler = apierror.RequestIDs(handler)
 
 	srv := &http.Server{
 		Addr:         addr,
 		Handler:      handler,
 		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 120 * time.Second, // Longer for SSE connections
+		WriteTimeout: 120 * time.Second, // lifted for event streams, see streamDeadlines
 		IdleTimeout:  120 * time.Second,
 	}
 
@@ -189,7 +280,7 @@ func (s *Server) readyz(w http.ResponseWriter, r *http.Request) {
 
 	if err := s.sessions.Ping(ctx); err != nil {
 		s.logger.Error("readyz database ping failed", "err", err)
-		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		apierror.Write(w, r, http.StatusServiceUnavailable, "not ready")
 		return
 	}
 	w.WriteHeader(http.StatusOK)
@@ -199,13 +290,30 @@ func (s *Server) readyz(w http.ResponseWriter, r *http.Request) {
 func (s *Server) me(w http.ResponseWriter, r *http.Request) {
 	user := auth.UserFromContext(r.Context())
 	if user == nil {
-		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		apierror.Write(w, r, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	_, _ = fmt.Fprintf(w, `{"email":%q,"name":%q,"picture":%q}`, user.Email, user.Name, user.Picture)
 }
 
+// backendClient makes the gateway's own backend requests, such as
+// visibility checks, as spans of the request that needs them.
+var backendClient = &http.Client{Transport: tracing.Transport(nil)}
+
+// backendGet issues a GET to the backend, forwarding the request ID and
+// trace context.
+func (s *Server) backendGet(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.proxy.Target()+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if id := apierror.RequestID(ctx); id != "" {
+		req.Header.Set(apierror.Header, id)
+	}
+	return backendClient.Do(req)
+}
+
 // staticHandler serves the embedded React frontend with smart routing for Next.js static export.
 func (s *Server) staticHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -282,7 +390,7 @@ func (s *Server) rateLimitMiddleware(next http.Handler) http.Handler {
 		limiter := s.limiters.get(ip)
 		if !limiter.Allow() {
 			s.logger.Warn("rate_limit_exceeded", "ip", ip, "path", r.URL.Path)
-			http.Error(w, "too many requests", http.StatusTooManyRequests)
+			apierror.Write(w, r, http.StatusTooManyRequests, "too many requests")
 			return
 		}
 		next.ServeHTTP(w, r)
@@ -294,7 +402,7 @@ func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Skip wrapping for SSE endpoint - the wrapper can interfere with HTTP/2 streaming
 		if r.URL.Path == "/v1/events" {
-			s.logger.Info("http_sse_start", "method", r.Method, "path", r.URL.Path, "ip", clientIP(r))
+			s.logger.Info("http_sse_start", "method", r.Method, "path", r.URL.Path, "ip", clientIP(r), "request_id", apierror.RequestID(r.Context()))
 			next.ServeHTTP(w, r)
 			s.logger.Info("http_sse_end", "method", r.Method, "path", r.URL.Path)
 			return
@@ -317,6 +425,7 @@ func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 			"size_bytes", sw.bytes,
 			"ip", clientIP(r),
 			"user", user,
+			"request_id", apierror.RequestID(r.Context()),
 		)
 	})
 }
@@ -345,6 +454,21 @@ func (w *statusWriter) Flush() {
 	}
 }
 
+// Unwrap exposes the underlying writer to http.ResponseController, which
+// hijacks upgraded connections and adjusts stream deadlines through it.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// urlOrigin returns scheme://host for an absolute URL, or "".
+func urlOrigin(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
+
 func clientIP(r *http.Request) string {
 	xff := r.Header.Get("X-Forwarded-For")
 	if xff != "" {
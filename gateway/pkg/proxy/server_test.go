@@ -0,0 +1,48 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/strongdm/cxdb/gateway/internal/config"
+	"github.com/strongdm/cxdb/gateway/pkg/auth"
+)
+
+func TestCSPAllowsBrandLogo(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.db"), "cxdb_session", time.Hour, "", false, "test-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = sessions.Close() })
+	rp, err := NewReverseProxy("http://127.0.0.1:1", logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	google := auth.NewGoogleAuth("http://localhost", "id", "secret", "", nil, sessions)
+
+	for logo, wantImg := range map[string]string{
+		"": "img-src 'self' data: https://lh3.googleusercontent.com;",
+		"https://cdn.example.com:8443/brand/logo.png?v=2": "img-src 'self' data: https://lh3.googleusercontent.com https://cdn.example.com:8443;",
+	} {
+		s, err := New(config.Config{BrandLogoURL: logo}, sessions, google, rp, fstest.MapFS{}, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		s.securityHeaders(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		if csp := rec.Header().Get("Content-Security-Policy"); !strings.Contains(csp, wantImg) {
+			t.Errorf("logo %q: CSP %q lacks %q", logo, csp, wantImg)
+		}
+	}
+}
@@ -12,6 +12,9 @@ import (
 	"net/http"
 	"sync"
 	"time"
+
+	"github.com/strongdm/cxdb/gateway/pkg/apierror"
+	"github.com/strongdm/cxdb/gateway/pkg/tracing"
 )
 
 // SSEBroker manages SSE connections and broadcasts events to all connected clients.
@@ -78,13 +81,24 @@ func (b *SSEBroker) poll() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	// Each poll is its own trace; the subscriber streams it feeds are
+	// long-lived and would otherwise collect every poll as a child.
+	ctx, span := tracing.Start(ctx, "sse.poll", tracing.KindInternal)
+	events := 0
+	defer func() {
+		span.SetAttr("sse.events", events)
+		span.SetAttr("sse.subscribers", b.ClientCount())
+		span.SetError(b.lastPollError)
+		span.End()
+	}()
+
 	req, err := http.NewRequestWithContext(ctx, "GET", b.backend+"/v1/contexts?limit=50", nil)
 	if err != nil {
 		b.lastPollError = err
 		return
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := backendClient.Do(req)
 	if err != nil {
 		b.lastPollError = err
 		return
@@ -121,6 +135,7 @@ func (b *SSEBroker) poll() {
 		oldState, exists := b.lastContexts[ctx.ContextID]
 		if !exists {
 			// New context
+			events++
 			b.broadcast(Event{
 				Type: "context_created",
 				Data: map[string]interface{}{
@@ -130,6 +145,7 @@ func (b *SSEBroker) poll() {
 			})
 		} else if oldState.HeadTurnID != ctx.HeadTurnID {
 			// Turn appended
+			events++
 			b.broadcast(Event{
 				Type: "turn_appended",
 				Data: map[string]interface{}{
@@ -197,7 +213,7 @@ func (b *SSEBroker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Check if client supports SSE
 	flusher, ok := w.(http.Flusher)
 	if !ok {
-		http.Error(w, "SSE not supported", http.StatusInternalServerError)
+		apierror.Write(w, r, http.StatusInternalServerError, "SSE not supported")
 		return
 	}
 
@@ -0,0 +1,69 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"mime"
+	"net/http"
+	"time"
+)
+
+// streamDeadlines lifts the server read and write timeouts for event-stream
+// responses. http.Server applies WriteTimeout to the whole response and
+// ReadTimeout to the connection, so without this every SSE stream, proxied
+// or served by the broker, is cut off when they expire. Ordinary responses
+// keep both timeouts.
+//
+// Upgraded connections (WebSocket) need nothing here: hijacking clears the
+// connection deadlines.
+func streamDeadlines(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&streamWriter{ResponseWriter: w}, r)
+	})
+}
+
+// streamWriter clears the connection deadlines once it sees an event-stream
+// response header.
+type streamWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *streamWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if statusCode == http.StatusOK && isEventStream(w.Header()) {
+			rc := http.NewResponseController(w.ResponseWriter)
+			_ = rc.SetReadDeadline(time.Time{})
+			_ = rc.SetWriteDeadline(time.Time{})
+		}
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *streamWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher for handlers that assert it directly.
+func (w *streamWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, which
+// the reverse proxy needs to hijack upgraded connections.
+func (w *streamWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func isEventStream(h http.Header) bool {
+	mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	return err == nil && mediaType == "text/event-stream"
+}
@@ -0,0 +1,228 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The production server uses a 10s read and 120s write timeout. These tests
+// scale everything down so a "multi-minute" stream is a few times longer
+// than both timeouts.
+const (
+	testReadTimeout  = 200 * time.Millisecond
+	testWriteTimeout = 300 * time.Millisecond
+	testEventEvery   = 150 * time.Millisecond
+	testEventCount   = 10 // ~1.5s, five times the write timeout
+)
+
+// newTestGateway fronts backend with the same writer wrappers the gateway
+// uses (streamDeadlines inside a statusWriter) and short server timeouts.
+func newTestGateway(t *testing.T, backend *httptest.Server) *httptest.Server {
+	t.Helper()
+	rp, err := NewReverseProxy(backend.URL, slog.New(slog.NewTextHandler(io.Discard, nil)),
+		WithFlushInterval(-1),
+	)
+	if err != nil {
+		t.Fatalf("NewReverseProxy: %v", err)
+	}
+	inner := streamDeadlines(rp)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inner.ServeHTTP(&statusWriter{ResponseWriter: w, status: http.StatusOK}, r)
+	})
+
+	gw := httptest.NewUnstartedServer(handler)
+	gw.Config.ReadTimeout = testReadTimeout
+	gw.Config.WriteTimeout = testWriteTimeout
+	gw.Start()
+	t.Cleanup(gw.Close)
+	return gw
+}
+
+func sseBackend(t *testing.T) *httptest.Server {
+	t.Helper()
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		rc := http.NewResponseController(w)
+		for i := 0; i < testEventCount; i++ {
+			fmt.Fprintf(w, "data: %d\n\n", i)
+			if err := rc.Flush(); err != nil {
+				return
+			}
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(testEventEvery):
+			}
+		}
+	}))
+	t.Cleanup(backend.Close)
+	return backend
+}
+
+func TestProxiedEventStreamOutlivesServerTimeouts(t *testing.T) {
+	gw := newTestGateway(t, sseBackend(t))
+
+	start := time.Now()
+	resp, err := http.Get(gw.URL + "/v1/events")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if got := resp.Header.Get("X-Accel-Buffering"); got != "no" {
+		t.Errorf("X-Accel-Buffering = %q, want %q", got, "no")
+	}
+	if got := resp.Header.Get("Cache-Control"); got != "no-cache" {
+		t.Errorf("Cache-Control = %q, want %q", got, "no-cache")
+	}
+
+	var events []string
+	var firstAt time.Duration
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		if data, ok := strings.CutPrefix(sc.Text(), "data: "); ok {
+			if len(events) == 0 {
+				firstAt = time.Since(start)
+			}
+			events = append(events, data)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatalf("stream ended early after %d events: %v", len(events), err)
+	}
+	if len(events) != testEventCount {
+		t.Fatalf("got %d events, want %d", len(events), testEventCount)
+	}
+	for i, data := range events {
+		if data != fmt.Sprint(i) {
+			t.Fatalf("event %d = %q", i, data)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 3*testWriteTimeout {
+		t.Fatalf("stream took %v, expected it to outlast the write timeout", elapsed)
+	}
+	// Events must not be held back until the stream ends.
+	if firstAt > testWriteTimeout {
+		t.Errorf("first event arrived after %v, want it flushed immediately", firstAt)
+	}
+}
+
+func TestOrdinaryResponsesKeepWriteTimeout(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		rc := http.NewResponseController(w)
+		for i := 0; i < testEventCount; i++ {
+			fmt.Fprintf(w, "line %d\n", i)
+			if err := rc.Flush(); err != nil {
+				return
+			}
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(testEventEvery):
+			}
+		}
+	}))
+	t.Cleanup(backend.Close)
+	gw := newTestGateway(t, backend)
+
+	resp, err := http.Get(gw.URL + "/v1/contexts")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err == nil && strings.Count(string(body), "\n") == testEventCount {
+		t.Fatal("non-stream response was not cut off by the write timeout")
+	}
+}
+
+func TestProxiedUpgradeOutlivesServerTimeouts(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			http.Error(w, "upgrade required", http.StatusUpgradeRequired)
+			return
+		}
+		conn, brw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Errorf("backend hijack: %v", err)
+			return
+		}
+		defer conn.Close()
+		fmt.Fprint(brw, "HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+		_ = brw.Flush()
+		_, _ = io.Copy(conn, brw)
+	}))
+	t.Cleanup(backend.Close)
+	gw := newTestGateway(t, backend)
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(gw.URL, "http://"))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	fmt.Fprint(conn, "GET /v1/ws HTTP/1.1\r\nHost: gateway\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatalf("read upgrade response: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status = %d, want 101", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Upgrade"); !strings.EqualFold(got, "websocket") {
+		t.Fatalf("Upgrade = %q, want websocket", got)
+	}
+
+	// Echo a few frames spread past both server timeouts.
+	for i := 0; i < 4; i++ {
+		time.Sleep(testWriteTimeout / 2)
+		msg := fmt.Sprintf("ping %d\n", i)
+		if _, err := io.WriteString(conn, msg); err != nil {
+			t.Fatalf("write %d: %v", i, err)
+		}
+		got, err := br.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read %d: %v", i, err)
+		}
+		if got != msg {
+			t.Fatalf("echo %d = %q, want %q", i, got, msg)
+		}
+	}
+}
+
+func TestIsEventStream(t *testing.T) {
+	cases := map[string]bool{
+		"text/event-stream":                true,
+		"Text/Event-Stream; charset=utf-8": true,
+		"text/plain":                       false,
+		"":                                 false,
+		"text/event-stream-ish":            false,
+	}
+	for ct, want := range cases {
+		h := http.Header{}
+		h.Set("Content-Type", ct)
+		if got := isEventStream(h); got != want {
+			t.Errorf("isEventStream(%q) = %v, want %v", ct, got, want)
+		}
+	}
+}
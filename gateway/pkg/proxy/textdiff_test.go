@@ -0,0 +1,61 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	numbered := func(n int, edit map[int]string) string {
+		var sb strings.Builder
+		for i := 1; i <= n; i++ {
+			if l, ok := edit[i]; ok {
+				sb.WriteString(l)
+				continue
+			}
+			fmt.Fprintf(&sb, "line %d\n", i)
+		}
+		return sb.String()
+	}
+
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{"added file", "", "a\nb\n", "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+a\n+b\n"},
+		{"removed file", "a\n", "", "--- a\n+++ b\n@@ -1 +0,0 @@\n-a\n"},
+		{
+			"no trailing newline", "a\nb", "a\nc",
+			"--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n",
+		},
+		{
+			"two hunks",
+			numbered(20, nil),
+			numbered(20, map[int]string{2: "changed 2\n", 18: ""}),
+			"--- a\n+++ b\n" +
+				"@@ -1,5 +1,5 @@\n line 1\n-line 2\n+changed 2\n line 3\n line 4\n line 5\n" +
+				"@@ -15,6 +15,5 @@\n line 15\n line 16\n line 17\n-line 18\n line 19\n line 20\n",
+		},
+		{
+			"merged hunk",
+			numbered(12, nil),
+			numbered(12, map[int]string{3: "", 8: "changed 8\n"}),
+			"--- a\n+++ b\n@@ -1,11 +1,10 @@\n line 1\n line 2\n-line 3\n line 4\n line 5\n line 6\n line 7\n-line 8\n+changed 8\n line 9\n line 10\n line 11\n",
+		},
+	}
+	for _, tt := range tests {
+		got, err := unifiedDiff("a", "b", tt.a, tt.b)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s:\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+	}
+}
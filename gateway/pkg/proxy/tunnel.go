@@ -0,0 +1,438 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/strongdm/cxdb/gateway/pkg/apierror"
+	"github.com/strongdm/cxdb/gateway/pkg/auth"
+)
+
+// Binary tunnel limits and protocol identifiers.
+const (
+	tunnelSubprotocol = "cxdb.binary.v1" // wire.WebSocketSubprotocol in the Go client
+	tunnelDialTimeout = 5 * time.Second
+	tunnelFrameHeader = 16
+	tunnelMaxPayload  = 64 << 20 // the server's MAX_FRAME_SIZE
+
+	// Message types the tunnel looks into. Everything but HELLO and the
+	// reads is a write.
+	tunnelMsgHello   = 1
+	tunnelMsgGetHead = 4
+	tunnelMsgGetLast = 6
+	tunnelMsgGetBlob = 9
+	tunnelMsgError   = 255
+	tunnelForbidden  = 403 // wire.CodeForbidden
+	// tunnelFlagRoutingKey marks a request payload that starts with a
+	// routing key (key_len:u16, key); wire.FlagRoutingKey in the Go client.
+	tunnelFlagRoutingKey = 1 << 5
+	// tunnelReadLogInterval throttles logging of one context's reads per
+	// connection; clients poll GET_LAST.
+	tunnelReadLogInterval = time.Minute
+
+	wsAcceptGUID     = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	wsOpContinuation = 0x0
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+// BinaryTunnel serves GET /v1/binary: a WebSocket carrying the binary
+// protocol to the backend, for clients that cannot open raw TCP connections
+// (browsers, WASM builds, networks that only pass HTTPS). Each binary
+// message holds exactly one protocol frame in either direction; the tunnel
+// checks frame lengths but otherwise passes frames through unchanged.
+//
+// The handshake is an ordinary GET, so the auth middleware has already
+// admitted the caller by the time the upgrade happens. Browsers may only
+// open the tunnel from the gateway's own origin, and writes are refused
+// with a 403 error frame unless the caller is one of the writers.
+type BinaryTunnel struct {
+	addr   string
+	logger *slog.Logger
+
+	// origin is the gateway's public origin; handshakes carrying any other
+	// Origin header are refused.
+	origin string
+	// writers are the emails or token subjects that may send writes; "*"
+	// admits every signed-in caller.
+	writers []string
+
+	// recordRead logs successful GET_HEAD and GET_LAST requests in the
+	// access log; nil when the log is disabled.
+	recordRead func(r *http.Request, contextIDs ...string)
+}
+
+// NewBinaryTunnel returns a tunnel to the binary protocol listener at addr.
+func NewBinaryTunnel(addr string, logger *slog.Logger) *BinaryTunnel {
+	return &BinaryTunnel{addr: addr, logger: logger}
+}
+
+func (t *BinaryTunnel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !headerHasToken(r.Header, "Connection", "upgrade") || !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		w.Header().Set("Upgrade", "websocket")
+		apierror.Write(w, r, http.StatusUpgradeRequired, "websocket upgrade required")
+		return
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" || r.Header.Get("Sec-WebSocket-Version") != "13" {
+		apierror.Write(w, r, http.StatusBadRequest, "invalid websocket handshake")
+		return
+	}
+	if !headerHasToken(r.Header, "Sec-WebSocket-Protocol", tunnelSubprotocol) {
+		apierror.Write(w, r, http.StatusBadRequest, "websocket subprotocol "+tunnelSubprotocol+" required")
+		return
+	}
+	// Browsers send cookies with cross-site WebSocket handshakes; other
+	// clients send no Origin.
+	if origin := r.Header.Get("Origin"); origin != "" && !strings.EqualFold(origin, t.origin) {
+		apierror.Write(w, r, http.StatusForbidden, "websocket origin not allowed")
+		return
+	}
+
+	backend, err := net.DialTimeout("tcp", t.addr, tunnelDialTimeout)
+	if err != nil {
+		t.logger.Error("binary_tunnel_dial_failed", "addr", t.addr, "err", err)
+		apierror.Write(w, r, http.StatusBadGateway, "binary backend unavailable")
+		return
+	}
+	defer func() { _ = backend.Close() }()
+
+	conn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		t.logger.Error("binary_tunnel_hijack_failed", "err", err)
+		apierror.Write(w, r, http.StatusInternalServerError, "websocket not supported")
+		return
+	}
+	defer func() { _ = conn.Close() }()
+	// Hijacking leaves any server deadlines in place; the tunnel is long-lived.
+	_ = conn.SetDeadline(time.Time{})
+
+	_, _ = brw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + wsAcceptKey(key) + "\r\n" +
+		"Sec-WebSocket-Protocol: " + tunnelSubprotocol + "\r\n\r\n")
+	if err := brw.Flush(); err != nil {
+		return
+	}
+
+	ws := &wsServerConn{conn: conn, br: brw.Reader, canWrite: t.canWrite(auth.UserFromContext(r.Context()))}
+	if t.recordRead != nil {
+		ws.reads = newTunnelReads(func(contextID string) { t.recordRead(r, contextID) })
+	}
+	start := time.Now()
+	errc := make(chan error, 2)
+	go func() { errc <- ws.pumpToBackend(backend) }()
+	go func() { errc <- ws.pumpFromBackend(backend) }()
+	err = <-errc
+
+	// Unblock the other direction.
+	ws.close(err)
+	_ = backend.Close()
+	<-errc
+
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+		t.logger.Warn("binary_tunnel_closed", "err", err, "duration", time.Since(start))
+	}
+}
+
+// canWrite reports whether user may send writes through the tunnel.
+func (t *BinaryTunnel) canWrite(user *auth.Session) bool {
+	if user == nil {
+		return false
+	}
+	for _, w := range t.writers {
+		if w == "*" || strings.EqualFold(w, user.Email) {
+			return true
+		}
+	}
+	return false
+}
+
+// wsServerConn is the server side of a tunnel WebSocket.
+type wsServerConn struct {
+	conn net.Conn
+	br   *bufio.Reader
+
+	wmu    sync.Mutex // pongs and close frames race with forwarded frames
+	closed bool
+
+	canWrite bool
+	reads    *tunnelReads // nil unless reads are logged
+}
+
+// tunnelReads matches context reads sent through a tunnel with their
+// responses, so only reads the backend answered are logged.
+type tunnelReads struct {
+	record func(contextID string)
+
+	mu      sync.Mutex
+	pending map[uint64]string    // req_id -> context ID
+	logged  map[string]time.Time // context ID -> last logged
+}
+
+func newTunnelReads(record func(contextID string)) *tunnelReads {
+	return &tunnelReads{record: record, pending: make(map[uint64]string), logged: make(map[string]time.Time)}
+}
+
+// request notes a client frame that reads a context.
+func (t *tunnelReads) request(frame []byte) {
+	msgType := binary.LittleEndian.Uint16(frame[4:6])
+	if msgType != tunnelMsgGetHead && msgType != tunnelMsgGetLast {
+		return
+	}
+	payload := frame[tunnelFrameHeader:]
+	if binary.LittleEndian.Uint16(frame[6:8])&tunnelFlagRoutingKey != 0 {
+		if len(payload) < 2 {
+			return
+		}
+		n := 2 + int(binary.LittleEndian.Uint16(payload))
+		if len(payload) < n {
+			return
+		}
+		payload = payload[n:]
+	}
+	if len(payload) < 8 {
+		return
+	}
+	reqID := binary.LittleEndian.Uint64(frame[8:16])
+	contextID := strconv.FormatUint(binary.LittleEndian.Uint64(payload), 10)
+	t.mu.Lock()
+	t.pending[reqID] = contextID
+	t.mu.Unlock()
+}
+
+// response logs the read answered by a backend frame header, unless the
+// backend refused it.
+func (t *tunnelReads) response(header []byte) {
+	reqID := binary.LittleEndian.Uint64(header[8:16])
+	t.mu.Lock()
+	contextID, ok := t.pending[reqID]
+	delete(t.pending, reqID)
+	if !ok || binary.LittleEndian.Uint16(header[4:6]) == tunnelMsgError || time.Since(t.logged[contextID]) < tunnelReadLogInterval {
+		t.mu.Unlock()
+		return
+	}
+	t.logged[contextID] = time.Now()
+	t.mu.Unlock()
+	t.record(contextID)
+}
+
+// pumpToBackend forwards client messages to the backend until either side
+// closes. A clean close frame ends it with io.EOF.
+func (c *wsServerConn) pumpToBackend(backend net.Conn) error {
+	for {
+		msg, err := c.readMessage()
+		if err != nil {
+			return err
+		}
+		if len(msg) < tunnelFrameHeader || int(binary.LittleEndian.Uint32(msg[0:4])) != len(msg)-tunnelFrameHeader {
+			return errors.New("websocket message is not a single protocol frame")
+		}
+		if !c.canWrite && isTunnelWrite(binary.LittleEndian.Uint16(msg[4:6])) {
+			detail := "binary tunnel: writes are not permitted for this caller"
+			payload := binary.LittleEndian.AppendUint32(nil, tunnelForbidden)
+			payload = binary.LittleEndian.AppendUint32(payload, uint32(len(detail)))
+			if err := c.write(wsOpBinary, tunnelFrame(tunnelMsgError, binary.LittleEndian.Uint64(msg[8:16]), append(payload, detail...))); err != nil {
+				return err
+			}
+			continue
+		}
+		if c.reads != nil {
+			c.reads.request(msg)
+		}
+		if _, err := backend.Write(msg); err != nil {
+			return fmt.Errorf("write backend: %w", err)
+		}
+	}
+}
+
+// pumpFromBackend forwards backend frames to the client, one per message.
+func (c *wsServerConn) pumpFromBackend(backend net.Conn) error {
+	br := bufio.NewReader(backend)
+	for {
+		header := make([]byte, tunnelFrameHeader)
+		if _, err := io.ReadFull(br, header); err != nil {
+			return err
+		}
+		n := binary.LittleEndian.Uint32(header[0:4])
+		if n > tunnelMaxPayload {
+			return fmt.Errorf("backend frame of %d bytes exceeds limit", n)
+		}
+		if c.reads != nil {
+			c.reads.response(header)
+		}
+		msg := make([]byte, tunnelFrameHeader+int(n))
+		copy(msg, header)
+		if _, err := io.ReadFull(br, msg[tunnelFrameHeader:]); err != nil {
+			return err
+		}
+		if err := c.write(wsOpBinary, msg); err != nil {
+			return err
+		}
+	}
+}
+
+// readMessage returns the next binary message, answering pings on the way.
+func (c *wsServerConn) readMessage() ([]byte, error) {
+	var msg []byte
+	for {
+		head := make([]byte, 2)
+		if _, err := io.ReadFull(c.br, head); err != nil {
+			return nil, err
+		}
+		fin, op := head[0]&0x80 != 0, head[0]&0x0F
+		if head[1]&0x80 == 0 {
+			return nil, errors.New("unmasked client frame")
+		}
+		n := uint64(head[1] & 0x7F)
+		switch n {
+		case 126:
+			ext := make([]byte, 2)
+			if _, err := io.ReadFull(c.br, ext); err != nil {
+				return nil, err
+			}
+			n = uint64(binary.BigEndian.Uint16(ext))
+		case 127:
+			ext := make([]byte, 8)
+			if _, err := io.ReadFull(c.br, ext); err != nil {
+				return nil, err
+			}
+			n = binary.BigEndian.Uint64(ext)
+			if n>>63 != 0 {
+				return nil, errors.New("invalid websocket frame length")
+			}
+		}
+		// Compare without adding to n so hostile lengths cannot wrap.
+		if n > tunnelFrameHeader+tunnelMaxPayload-uint64(len(msg)) {
+			return nil, errors.New("websocket message exceeds frame limit")
+		}
+
+		frame := make([]byte, 4+n)
+		if _, err := io.ReadFull(c.br, frame); err != nil {
+			return nil, err
+		}
+		mask, data := frame[:4], frame[4:]
+		for i := range data {
+			data[i] ^= mask[i%4]
+		}
+
+		switch op {
+		case wsOpPing:
+			if err := c.write(wsOpPong, data); err != nil {
+				return nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			return nil, io.EOF
+		case wsOpBinary, wsOpContinuation:
+			msg = append(msg, data...)
+		default:
+			return nil, fmt.Errorf("unexpected websocket opcode %#x", op)
+		}
+		if fin {
+			return msg, nil
+		}
+	}
+}
+
+// write sends one unmasked frame.
+func (c *wsServerConn) write(op byte, data []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closed {
+		return net.ErrClosed
+	}
+	return c.writeLocked(op, data)
+}
+
+func (c *wsServerConn) writeLocked(op byte, data []byte) error {
+	buf := make([]byte, 0, 10+len(data))
+	buf = append(buf, 0x80|op)
+	switch n := len(data); {
+	case n < 126:
+		buf = append(buf, byte(n))
+	case n <= 0xFFFF:
+		buf = append(buf, 126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+	default:
+		buf = append(buf, 127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(n))
+	}
+	_, err := c.conn.Write(append(buf, data...))
+	return err
+}
+
+// close sends a close frame whose status reflects err and closes the
+// connection.
+func (c *wsServerConn) close(err error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+
+	code := uint16(1000) // normal closure
+	if err != nil && !errors.Is(err, io.EOF) {
+		code = 1011 // unexpected condition
+	}
+	_ = c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	_ = c.writeLocked(wsOpClose, binary.BigEndian.AppendUint16(nil, code))
+	_ = c.conn.Close()
+}
+
+// isTunnelWrite reports whether msgType changes the backend's state.
+func isTunnelWrite(msgType uint16) bool {
+	switch msgType {
+	case tunnelMsgHello, tunnelMsgGetHead, tunnelMsgGetLast, tunnelMsgGetBlob:
+		return false
+	}
+	return true
+}
+
+// tunnelFrame builds a protocol frame with no flags.
+func tunnelFrame(msgType uint16, reqID uint64, payload []byte) []byte {
+	b := binary.LittleEndian.AppendUint32(nil, uint32(len(payload)))
+	b = binary.LittleEndian.AppendUint16(b, msgType)
+	b = binary.LittleEndian.AppendUint16(b, 0)
+	b = binary.LittleEndian.AppendUint64(b, reqID)
+	return append(b, payload...)
+}
+
+func wsAcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + wsAcceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// headerHasToken reports whether the comma-separated header contains token,
+// ignoring case.
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,300 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/strongdm/cxdb/gateway/pkg/auth"
+)
+
+// echoBinaryBackend answers every protocol frame with the same header and
+// payload.
+func echoBinaryBackend(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				for {
+					header := make([]byte, tunnelFrameHeader)
+					if _, err := io.ReadFull(conn, header); err != nil {
+						return
+					}
+					payload := make([]byte, binary.LittleEndian.Uint32(header[0:4]))
+					if _, err := io.ReadFull(conn, payload); err != nil {
+						return
+					}
+					if _, err := conn.Write(append(header, payload...)); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// dialTunnel performs a client handshake against gw, with optional extra
+// "Name: value" headers, and returns the connection and the response status.
+func dialTunnel(t *testing.T, gw *httptest.Server, subprotocol string, headers ...string) (net.Conn, *bufio.Reader, int) {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(gw.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	const key = "dGhlIHNhbXBsZSBub25jZQ=="
+	req := "GET /v1/binary HTTP/1.1\r\nHost: gw\r\n" +
+		"Upgrade: websocket\r\nConnection: keep-alive, Upgrade\r\n" +
+		"Sec-WebSocket-Key: " + key + "\r\nSec-WebSocket-Version: 13\r\n" +
+		"Sec-WebSocket-Protocol: " + subprotocol + "\r\n"
+	for _, h := range headers {
+		req += h + "\r\n"
+	}
+	req += "\r\n"
+	if _, err := conn.Write([]byte(req)); err != nil {
+		t.Fatal(err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode == http.StatusSwitchingProtocols {
+		if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+			t.Errorf("Sec-WebSocket-Accept = %q", got)
+		}
+	}
+	return conn, br, resp.StatusCode
+}
+
+// writeClientFrame sends a masked frame, as clients must.
+func writeClientFrame(t *testing.T, conn net.Conn, fin bool, op byte, data []byte) {
+	t.Helper()
+	if fin {
+		op |= 0x80
+	}
+	buf := []byte{op}
+	if len(data) < 126 {
+		buf = append(buf, 0x80|byte(len(data)))
+	} else {
+		buf = append(buf, 0x80|126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(len(data)))
+	}
+	mask := []byte{1, 2, 3, 4}
+	buf = append(buf, mask...)
+	for i, b := range data {
+		buf = append(buf, b^mask[i%4])
+	}
+	if _, err := conn.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readServerFrame reads one unmasked frame.
+func readServerFrame(t *testing.T, br *bufio.Reader) (byte, []byte) {
+	t.Helper()
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(br, head); err != nil {
+		t.Fatal(err)
+	}
+	n := int(head[1] & 0x7F)
+	if n == 126 {
+		ext := make([]byte, 2)
+		if _, err := io.ReadFull(br, ext); err != nil {
+			t.Fatal(err)
+		}
+		n = int(binary.BigEndian.Uint16(ext))
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(br, data); err != nil {
+		t.Fatal(err)
+	}
+	return head[0] & 0x0F, data
+}
+
+func protocolFrame(msgType uint16, reqID uint64, payload []byte) []byte {
+	b := binary.LittleEndian.AppendUint32(nil, uint32(len(payload)))
+	b = binary.LittleEndian.AppendUint16(b, msgType)
+	b = binary.LittleEndian.AppendUint16(b, 0)
+	b = binary.LittleEndian.AppendUint64(b, reqID)
+	return append(b, payload...)
+}
+
+// Tunnel gateways in tests serve https://cxdb.example.com, sign callers in
+// from an X-Test-User header, and let writer@example.com write.
+const (
+	testTunnelOrigin = "https://cxdb.example.com"
+	testTunnelWriter = "X-Test-User: writer@example.com"
+)
+
+func newTunnelGateway(t *testing.T, backendAddr string) *httptest.Server {
+	t.Helper()
+	tunnel := NewBinaryTunnel(backendAddr, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	tunnel.origin = testTunnelOrigin
+	tunnel.writers = []string{"writer@example.com"}
+	gw := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if email := r.Header.Get("X-Test-User"); email != "" {
+			r = r.WithContext(auth.WithUser(r.Context(), &auth.Session{Email: email}))
+		}
+		tunnel.ServeHTTP(w, r)
+	}))
+	gw.Config.ReadTimeout = testReadTimeout
+	gw.Config.WriteTimeout = testWriteTimeout
+	gw.Start()
+	t.Cleanup(gw.Close)
+	return gw
+}
+
+func TestBinaryTunnelForwardsFrames(t *testing.T) {
+	gw := newTunnelGateway(t, echoBinaryBackend(t))
+	conn, br, status := dialTunnel(t, gw, "other, "+tunnelSubprotocol, testTunnelWriter)
+	if status != http.StatusSwitchingProtocols {
+		t.Fatalf("status = %d, want 101", status)
+	}
+
+	// A fragmented request with a ping in the middle.
+	req := protocolFrame(4, 1, bytes.Repeat([]byte{7}, 200))
+	writeClientFrame(t, conn, false, wsOpBinary, req[:50])
+	writeClientFrame(t, conn, true, wsOpPing, []byte("p"))
+	writeClientFrame(t, conn, true, wsOpContinuation, req[50:])
+
+	if op, data := readServerFrame(t, br); op != wsOpPong || string(data) != "p" {
+		t.Fatalf("got op %#x %q, want pong", op, data)
+	}
+	if op, data := readServerFrame(t, br); op != wsOpBinary || !bytes.Equal(data, req) {
+		t.Fatalf("got op %#x with %d bytes, want the echoed frame", op, len(data))
+	}
+
+	// The tunnel outlives the server's read and write timeouts.
+	time.Sleep(2 * testWriteTimeout)
+	req = protocolFrame(2, 2, nil)
+	writeClientFrame(t, conn, true, wsOpBinary, req)
+	if op, data := readServerFrame(t, br); op != wsOpBinary || !bytes.Equal(data, req) {
+		t.Fatalf("after idle: op %#x, %x", op, data)
+	}
+
+	// A message that is not exactly one frame ends the tunnel.
+	writeClientFrame(t, conn, true, wsOpBinary, []byte("short"))
+	if op, data := readServerFrame(t, br); op != wsOpClose || binary.BigEndian.Uint16(data) != 1011 {
+		t.Fatalf("got op %#x %x, want close 1011", op, data)
+	}
+}
+
+func TestBinaryTunnelRejectsBadHandshakes(t *testing.T) {
+	gw := newTunnelGateway(t, echoBinaryBackend(t))
+
+	resp, err := http.Get(gw.URL + "/v1/binary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUpgradeRequired {
+		t.Errorf("plain GET status = %d, want 426", resp.StatusCode)
+	}
+
+	if _, _, status := dialTunnel(t, gw, "chat"); status != http.StatusBadRequest {
+		t.Errorf("wrong subprotocol status = %d, want 400", status)
+	}
+
+	down := newTunnelGateway(t, "127.0.0.1:1")
+	if _, _, status := dialTunnel(t, down, tunnelSubprotocol); status != http.StatusBadGateway {
+		t.Errorf("unreachable backend status = %d, want 502", status)
+	}
+}
+
+func TestBinaryTunnelOrigin(t *testing.T) {
+	gw := newTunnelGateway(t, echoBinaryBackend(t))
+	for origin, want := range map[string]int{
+		"":                        http.StatusSwitchingProtocols,
+		testTunnelOrigin:          http.StatusSwitchingProtocols,
+		"https://evil.example":    http.StatusForbidden,
+		"http://cxdb.example.com": http.StatusForbidden,
+		"null":                    http.StatusForbidden,
+	} {
+		var headers []string
+		if origin != "" {
+			headers = append(headers, "Origin: "+origin)
+		}
+		if _, _, status := dialTunnel(t, gw, tunnelSubprotocol, headers...); status != want {
+			t.Errorf("Origin %q: status %d, want %d", origin, status, want)
+		}
+	}
+}
+
+func TestBinaryTunnelRefusesWrites(t *testing.T) {
+	gw := newTunnelGateway(t, echoBinaryBackend(t))
+	for _, user := range []string{"", "X-Test-User: reader@example.com"} {
+		var headers []string
+		if user != "" {
+			headers = append(headers, user)
+		}
+		conn, br, status := dialTunnel(t, gw, tunnelSubprotocol, headers...)
+		if status != http.StatusSwitchingProtocols {
+			t.Fatalf("%q: status %d", user, status)
+		}
+
+		// Writes get a 403 error frame and never reach the backend; reads
+		// still do, on the same tunnel.
+		for _, msgType := range []uint16{2, 3, 5, 10, 11, 42} {
+			writeClientFrame(t, conn, true, wsOpBinary, protocolFrame(msgType, 7, []byte{1, 2, 3}))
+			op, data := readServerFrame(t, br)
+			if op != wsOpBinary || len(data) < tunnelFrameHeader+8 {
+				t.Fatalf("%q type %d: op %#x, %x", user, msgType, op, data)
+			}
+			if got := binary.LittleEndian.Uint16(data[4:6]); got != tunnelMsgError {
+				t.Fatalf("%q type %d: forwarded as %d", user, msgType, got)
+			}
+			if reqID, code := binary.LittleEndian.Uint64(data[8:16]), binary.LittleEndian.Uint32(data[16:20]); reqID != 7 || code != 403 {
+				t.Fatalf("%q type %d: req %d code %d", user, msgType, reqID, code)
+			}
+		}
+		req := protocolFrame(tunnelMsgGetLast, 8, make([]byte, 16))
+		writeClientFrame(t, conn, true, wsOpBinary, req)
+		if op, data := readServerFrame(t, br); op != wsOpBinary || !bytes.Equal(data, req) {
+			t.Fatalf("%q read: op %#x, %x", user, op, data)
+		}
+	}
+}
+
+func TestBinaryTunnelRejectsHugeFragments(t *testing.T) {
+	gw := newTunnelGateway(t, echoBinaryBackend(t))
+	for _, n := range []uint64{^uint64(0) - 2, 1<<63 - 1, tunnelFrameHeader + tunnelMaxPayload} {
+		conn, br, status := dialTunnel(t, gw, tunnelSubprotocol, testTunnelWriter)
+		if status != http.StatusSwitchingProtocols {
+			t.Fatalf("status = %d, want 101", status)
+		}
+
+		// A short first fragment, then a continuation whose 64-bit length
+		// would wrap the size check. Its header is all that is sent.
+		writeClientFrame(t, conn, false, wsOpBinary, []byte{1, 2, 3})
+		head := binary.BigEndian.AppendUint64([]byte{0x80 | wsOpContinuation, 0x80 | 127}, n)
+		if _, err := conn.Write(append(head, 1, 2, 3, 4)); err != nil {
+			t.Fatal(err)
+		}
+		if op, data := readServerFrame(t, br); op != wsOpClose || binary.BigEndian.Uint16(data) != 1011 {
+			t.Fatalf("length %d: got op %#x %x, want close 1011", n, op, data)
+		}
+	}
+}
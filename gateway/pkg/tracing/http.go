@@ -0,0 +1,121 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package tracing
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/strongdm/cxdb/gateway/pkg/apierror"
+)
+
+// Middleware starts a server span for each request, continuing the trace
+// in the caller's traceparent header if it has one. Handlers reach the span
+// through the request context, and outgoing requests made with Transport
+// become its children.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := Extract(r.Context(), r.Header)
+		ctx, span := Start(ctx, r.Method+" "+Route(r.URL.Path), KindServer)
+		defer span.End()
+		span.SetAttr("http.request.method", r.Method)
+		span.SetAttr("url.path", r.URL.Path)
+		if id := apierror.RequestID(ctx); id != "" {
+			span.SetAttr("cxdb.request_id", id)
+		}
+		r = r.WithContext(ctx)
+
+		// Event streams are served unwrapped; the span covers the stream's
+		// lifetime but not its status.
+		if r.URL.Path == "/v1/events" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		span.SetHTTPStatus(sw.status)
+	})
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(statusCode int) {
+	w.status = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Flush implements http.Flusher for streamed responses.
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Transport wraps base so each request is a client span whose trace
+// context is sent in the traceparent header. A nil base means
+// http.DefaultTransport.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := Start(req.Context(), req.Method+" "+Route(req.URL.Path), KindClient)
+	span.SetAttr("http.request.method", req.Method)
+	span.SetAttr("server.address", req.URL.Host)
+	span.SetAttr("url.path", req.URL.Path)
+
+	// RoundTrippers must not modify the caller's request.
+	req = req.Clone(ctx)
+	Inject(ctx, req.Header)
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		span.SetError(err)
+		span.End()
+		return nil, err
+	}
+	span.SetHTTPStatus(resp.StatusCode)
+	span.End()
+	return resp, nil
+}
+
+// Route returns path with numeric IDs and content hashes replaced by
+// placeholders, so span names group requests by endpoint:
+// /v1/contexts/42/turns becomes /v1/contexts/{id}/turns.
+func Route(path string) string {
+	segs := strings.Split(path, "/")
+	for i, seg := range segs {
+		switch {
+		case seg == "":
+		case isDigits(seg):
+			segs[i] = "{id}"
+		case len(seg) == 64 && isLowerHex(seg):
+			segs[i] = "{hash}"
+		}
+	}
+	return strings.Join(segs, "/")
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,377 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+// Package tracing records request traces and exports them to an
+// OpenTelemetry collector over OTLP/HTTP.
+//
+// Trace context travels in W3C traceparent and tracestate headers. The
+// gateway continues traces started by SDK clients, starts spans for its own
+// work (authentication, proxied backend calls, event polling), and forwards
+// the trace context to the backend, so one trace covers SDK → gateway →
+// backend:
+//
+//	ctx, span := tracing.Start(ctx, "auth.verify", tracing.KindInternal)
+//	defer span.End()
+//
+// Spans are exported only when a Tracer with an endpoint is installed with
+// SetDefault. Without one, trace context is still propagated.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/strongdm/cxdb/gateway/internal/config"
+)
+
+// W3C Trace Context headers.
+const (
+	TraceparentHeader = "Traceparent"
+	TracestateHeader  = "Tracestate"
+)
+
+// Kind is the role of a span in a trace, with OTLP's numbering.
+type Kind int
+
+const (
+	KindInternal Kind = 1
+	KindServer   Kind = 2
+	KindClient   Kind = 3
+)
+
+// SpanContext identifies a span across process boundaries.
+type SpanContext struct {
+	TraceID    [16]byte
+	SpanID     [8]byte
+	Sampled    bool
+	TraceState string
+}
+
+// IsValid reports whether the trace and span IDs are set.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Traceparent formats sc as a version 00 traceparent header value.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" + hex.EncodeToString(sc.SpanID[:]) + "-" + flags
+}
+
+// ParseTraceparent parses a traceparent header value. Unknown future
+// versions are read by their version 00 prefix, as the specification asks.
+func ParseTraceparent(v string) (SpanContext, bool) {
+	var sc SpanContext
+	v = strings.TrimSpace(v)
+	if len(v) < 55 || v[2] != '-' || v[35] != '-' || v[52] != '-' {
+		return sc, false
+	}
+	version, traceID, spanID, flags := v[:2], v[3:35], v[36:52], v[53:55]
+	if !isLowerHex(version) || version == "ff" || (version == "00" && len(v) != 55) || (len(v) > 55 && v[55] != '-') {
+		return sc, false
+	}
+	if !isLowerHex(traceID) || !isLowerHex(spanID) || !isLowerHex(flags) {
+		return sc, false
+	}
+	_, _ = hex.Decode(sc.TraceID[:], []byte(traceID))
+	_, _ = hex.Decode(sc.SpanID[:], []byte(spanID))
+	var f [1]byte
+	_, _ = hex.Decode(f[:], []byte(flags))
+	sc.Sampled = f[0]&1 == 1
+	return sc, sc.IsValid()
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// Extract returns ctx carrying the remote span context in h, if any.
+func Extract(ctx context.Context, h http.Header) context.Context {
+	sc, ok := ParseTraceparent(h.Get(TraceparentHeader))
+	if !ok {
+		return ctx
+	}
+	sc.TraceState = h.Get(TracestateHeader)
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// Inject sets the traceparent and tracestate headers for the current span
+// in ctx, or the remote span it continues. It leaves h alone if ctx carries
+// no trace.
+func Inject(ctx context.Context, h http.Header) {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	h.Set(TraceparentHeader, sc.Traceparent())
+	if sc.TraceState != "" {
+		h.Set(TracestateHeader, sc.TraceState)
+	} else {
+		h.Del(TracestateHeader)
+	}
+}
+
+type spanKey struct{}
+
+type remoteKey struct{}
+
+// SpanFromContext returns the current span, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// SpanContextFromContext returns the context of the current span, or of
+// the remote span extracted from an incoming request.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if s := SpanFromContext(ctx); s != nil {
+		return s.sc
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
+
+// Tracer starts spans and hands finished, sampled ones to its exporter.
+type Tracer struct {
+	ratio    float64
+	exporter *exporter
+}
+
+// New returns a Tracer for cfg. It exports nothing if cfg.Endpoint is
+// empty; call Shutdown to flush buffered spans before exiting.
+func New(cfg config.TracingConfig, logger *slog.Logger) *Tracer {
+	t := &Tracer{ratio: cfg.SampleRatio}
+	if cfg.Endpoint != "" {
+		t.exporter = newExporter(cfg, logger)
+	}
+	return t
+}
+
+// Enabled reports whether spans are exported.
+func (t *Tracer) Enabled() bool {
+	return t.exporter != nil
+}
+
+// Shutdown exports buffered spans and stops the exporter.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t.exporter == nil {
+		return nil
+	}
+	return t.exporter.shutdown(ctx)
+}
+
+var defaultTracer atomic.Pointer[Tracer]
+
+// SetDefault installs t as the Tracer used by Start.
+func SetDefault(t *Tracer) {
+	defaultTracer.Store(t)
+}
+
+// Default returns the installed Tracer. Until SetDefault is called it is a
+// Tracer that samples everything and exports nothing.
+func Default() *Tracer {
+	if t := defaultTracer.Load(); t != nil {
+		return t
+	}
+	return &Tracer{ratio: 1}
+}
+
+// Start starts a span with the default Tracer. See Tracer.Start.
+func Start(ctx context.Context, name string, kind Kind) (context.Context, *Span) {
+	return Default().Start(ctx, name, kind)
+}
+
+// Start starts a span as a child of the current span in ctx, or of the
+// remote span extracted from an incoming request, or as the root of a new
+// trace. Children inherit their parent's sampling decision; new traces are
+// sampled at the configured ratio. The returned context carries the span.
+func (t *Tracer) Start(ctx context.Context, name string, kind Kind) (context.Context, *Span) {
+	parent := SpanContextFromContext(ctx)
+	s := &Span{tracer: t, name: name, kind: kind, start: time.Now()}
+	if parent.IsValid() {
+		s.sc.TraceID = parent.TraceID
+		s.sc.Sampled = parent.Sampled
+		s.sc.TraceState = parent.TraceState
+		s.parent = parent.SpanID
+	} else {
+		s.sc.TraceID = newTraceID()
+		s.sc.Sampled = t.sample(s.sc.TraceID)
+	}
+	s.sc.SpanID = newSpanID()
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// sample applies the ratio to the random low half of the trace ID, like
+// OpenTelemetry's TraceIDRatioBased sampler.
+func (t *Tracer) sample(id [16]byte) bool {
+	if t.ratio >= 1 {
+		return true
+	}
+	if t.ratio <= 0 {
+		return false
+	}
+	bound := uint64(t.ratio * (1 << 63))
+	return binary.BigEndian.Uint64(id[8:])>>1 < bound
+}
+
+func newTraceID() [16]byte {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return id
+}
+
+func newSpanID() [8]byte {
+	var id [8]byte
+	_, _ = rand.Read(id[:])
+	return id
+}
+
+// statusError is OTLP's STATUS_CODE_ERROR.
+const statusError = 2
+
+// Span is one timed operation in a trace. Its methods are safe for
+// concurrent use and do nothing after End; a nil *Span is also a no-op.
+type Span struct {
+	tracer *Tracer
+	sc     SpanContext
+	parent [8]byte
+	name   string
+	kind   Kind
+	start  time.Time
+
+	mu        sync.Mutex
+	ended     bool
+	attrs     []keyValue
+	status    int
+	statusMsg string
+}
+
+// Context returns the span's identity for propagation.
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// SetAttr records an attribute. Strings, bools, integers and floats keep
+// their type; other values are formatted with fmt.
+func (s *Span) SetAttr(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.attrs = append(s.attrs, keyValue{Key: key, Value: attrValue(value)})
+}
+
+// SetError marks the span failed. A nil error is ignored.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.status = statusError
+	s.statusMsg = err.Error()
+}
+
+// SetHTTPStatus records an HTTP response status. Server spans fail on 5xx
+// responses, client spans on any 4xx or 5xx, following the OpenTelemetry
+// HTTP conventions.
+func (s *Span) SetHTTPStatus(code int) {
+	if s == nil {
+		return
+	}
+	s.SetAttr("http.response.status_code", code)
+	if code >= 500 || (s.kind == KindClient && code >= 400) {
+		s.SetError(fmt.Errorf("%d %s", code, http.StatusText(code)))
+	}
+}
+
+// End finishes the span and queues it for export if it is sampled.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.mu.Unlock()
+	if s.sc.Sampled && s.tracer.exporter != nil {
+		s.tracer.exporter.enqueue(s.export(time.Now()))
+	}
+}
+
+func (s *Span) export(end time.Time) otlpSpan {
+	out := otlpSpan{
+		TraceID:           hex.EncodeToString(s.sc.TraceID[:]),
+		SpanID:            hex.EncodeToString(s.sc.SpanID[:]),
+		Name:              s.name,
+		Kind:              int(s.kind),
+		StartTimeUnixNano: fmt.Sprint(s.start.UnixNano()),
+		EndTimeUnixNano:   fmt.Sprint(end.UnixNano()),
+		Attributes:        s.attrs,
+		Status:            otlpStatus{Code: s.status, Message: s.statusMsg},
+	}
+	if s.parent != [8]byte{} {
+		out.ParentSpanID = hex.EncodeToString(s.parent[:])
+	}
+	return out
+}
+
+func attrValue(v any) anyValue {
+	switch v := v.(type) {
+	case string:
+		return anyValue{StringValue: &v}
+	case bool:
+		return anyValue{BoolValue: &v}
+	case int:
+		return intValue(int64(v))
+	case int64:
+		return intValue(v)
+	case uint64:
+		if v > math.MaxInt64 {
+			s := fmt.Sprint(v)
+			return anyValue{StringValue: &s}
+		}
+		return intValue(int64(v))
+	case float64:
+		return anyValue{DoubleValue: &v}
+	default:
+		s := fmt.Sprint(v)
+		return anyValue{StringValue: &s}
+	}
+}
+
+func intValue(v int64) anyValue {
+	s := fmt.Sprint(v)
+	return anyValue{IntValue: &s}
+}
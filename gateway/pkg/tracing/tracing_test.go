@@ -0,0 +1,184 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/strongdm/cxdb/gateway/internal/config"
+	"github.com/strongdm/cxdb/gateway/pkg/apierror"
+)
+
+const sdkTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceparent(t *testing.T) {
+	sc, ok := ParseTraceparent(sdkTraceparent)
+	if !ok || !sc.Sampled || sc.Traceparent() != sdkTraceparent {
+		t.Fatalf("ParseTraceparent = %+v, %v", sc, ok)
+	}
+	if sc, ok := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future"); !ok || sc.Sampled {
+		t.Errorf("future version = %+v, %v", sc, ok)
+	}
+	for _, bad := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+	} {
+		if _, ok := ParseTraceparent(bad); ok {
+			t.Errorf("ParseTraceparent(%q) accepted", bad)
+		}
+	}
+}
+
+func TestSampling(t *testing.T) {
+	never := &Tracer{ratio: 0}
+	_, root := never.Start(context.Background(), "root", KindInternal)
+	if root.Context().Sampled {
+		t.Error("ratio 0 sampled a new trace")
+	}
+
+	// A caller's sampling decision wins over the ratio.
+	h := http.Header{}
+	h.Set(TraceparentHeader, sdkTraceparent)
+	h.Set(TracestateHeader, "vendor=1")
+	_, child := never.Start(Extract(context.Background(), h), "child", KindServer)
+	sc := child.Context()
+	if !sc.Sampled || sc.TraceState != "vendor=1" || sc.Traceparent()[3:35] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("child = %+v", sc)
+	}
+}
+
+type collector struct {
+	mu    sync.Mutex
+	spans []otlpSpan
+	auth  string
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req otlpRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.auth = r.Header.Get("Authorization")
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			c.spans = append(c.spans, ss.Spans...)
+		}
+	}
+}
+
+func TestEndToEnd(t *testing.T) {
+	coll := &collector{}
+	otlp := httptest.NewServer(coll)
+	defer otlp.Close()
+
+	tracer := New(config.TracingConfig{
+		Endpoint:    otlp.URL + "/v1/traces",
+		Headers:     map[string]string{"Authorization": "Bearer collector"},
+		ServiceName: "cxdb-gateway",
+		SampleRatio: 1,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	SetDefault(tracer)
+	defer SetDefault(nil)
+
+	var backendTraceparent string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		backendTraceparent = r.Header.Get(TraceparentHeader)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer backend.Close()
+
+	client := &http.Client{Transport: Transport(nil)}
+	gateway := httptest.NewServer(apierror.RequestIDs(Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, backend.URL+r.URL.Path, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		_ = resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+	}))))
+	defer gateway.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, gateway.URL+"/v1/contexts/42/turns", nil)
+	req.Header.Set(TraceparentHeader, sdkTraceparent)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var serverSpan, clientSpan otlpSpan
+	for _, s := range coll.spans {
+		switch Kind(s.Kind) {
+		case KindServer:
+			serverSpan = s
+		case KindClient:
+			clientSpan = s
+		}
+	}
+	if serverSpan.Name != "GET /v1/contexts/{id}/turns" || clientSpan.Name != serverSpan.Name {
+		t.Errorf("span names = %q, %q", serverSpan.Name, clientSpan.Name)
+	}
+	if serverSpan.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || serverSpan.ParentSpanID != "00f067aa0ba902b7" {
+		t.Errorf("server span = %+v, want a child of the SDK span", serverSpan)
+	}
+	if clientSpan.TraceID != serverSpan.TraceID || clientSpan.ParentSpanID != serverSpan.SpanID {
+		t.Errorf("client span = %+v, want a child of the server span", clientSpan)
+	}
+	if want := "00-" + clientSpan.TraceID + "-" + clientSpan.SpanID + "-01"; backendTraceparent != want {
+		t.Errorf("backend traceparent = %q, want %q", backendTraceparent, want)
+	}
+	if clientSpan.Status.Code != statusError || serverSpan.Status.Code != 0 {
+		t.Errorf("status: client %+v, server %+v; a 404 fails only the client span", clientSpan.Status, serverSpan.Status)
+	}
+	if coll.auth != "Bearer collector" {
+		t.Errorf("collector Authorization = %q", coll.auth)
+	}
+}
+
+func TestRoute(t *testing.T) {
+	hash := "a3f5b8c2a3f5b8c2a3f5b8c2a3f5b8c2a3f5b8c2a3f5b8c2a3f5b8c2a3f5b8c2"
+	for path, want := range map[string]string{
+		"/v1/contexts/42/turns":  "/v1/contexts/{id}/turns",
+		"/v1/blobs/" + hash:      "/v1/blobs/{hash}",
+		"/v1/turns/7/fs/src/123": "/v1/turns/{id}/fs/src/{id}",
+		"/healthz":               "/healthz",
+	} {
+		if got := Route(path); got != want {
+			t.Errorf("Route(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+// Event streams reach the handler with the server's own writer, so SSE
+// flushing and deadline control are not hidden behind a wrapper.
+func TestMiddlewareLeavesEventStreamsUnwrapped(t *testing.T) {
+	for path, wantRaw := range map[string]bool{"/v1/events": true, "/v1/contexts": false} {
+		rec := httptest.NewRecorder()
+		Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, raw := w.(*httptest.ResponseRecorder); raw != wantRaw {
+				t.Errorf("%s: handler got %T", path, w)
+			}
+		})).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+}
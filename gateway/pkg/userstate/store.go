@@ -0,0 +1,216 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+// Package userstate keeps small per-user navigation state owned by the
+// gateway: recently viewed contexts and explicit bookmarks. It shares the
+// session SQLite database and never touches the cxdb backend.
+package userstate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/strongdm/cxdb/gateway/pkg/contextid"
+)
+
+// DefaultMaxRecent is how many recently viewed contexts are kept per user.
+const DefaultMaxRecent = 50
+
+// MaxLabelLength bounds bookmark labels, in bytes.
+const MaxLabelLength = 200
+
+// RecentContext is one entry in a user's recently viewed list.
+type RecentContext struct {
+	ContextID string    `json:"context_id"`
+	ViewedAt  time.Time `json:"viewed_at"`
+	ViewCount int64     `json:"view_count"`
+}
+
+// Bookmark is a context the user explicitly saved.
+type Bookmark struct {
+	ContextID string    `json:"context_id"`
+	Label     string    `json:"label,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store persists per-user state keyed by the user's email.
+type Store struct {
+	db        *sql.DB
+	maxRecent int
+}
+
+// NewStore creates the userstate tables in db if needed.
+func NewStore(db *sql.DB) (*Store, error) {
+	s := &Store{db: db, maxRecent: DefaultMaxRecent}
+	if err := s.ensureSchema(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) ensureSchema() error {
+	const schema = `
+	CREATE TABLE IF NOT EXISTS user_recent_contexts (
+		email TEXT NOT NULL,
+		context_id TEXT NOT NULL,
+		viewed_at TIMESTAMP NOT NULL,
+		view_count INTEGER NOT NULL DEFAULT 1,
+		PRIMARY KEY (email, context_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_user_recent_viewed ON user_recent_contexts(email, viewed_at);
+	CREATE TABLE IF NOT EXISTS user_bookmarks (
+		email TEXT NOT NULL,
+		context_id TEXT NOT NULL,
+		label TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (email, context_id)
+	);
+	`
+	if _, err := s.db.Exec(schema); err != nil {
+		return fmt.Errorf("init userstate schema: %w", err)
+	}
+	return nil
+}
+
+// RecordView marks contextID as viewed now and trims the user's list to the
+// most recent entries.
+func (s *Store) RecordView(ctx context.Context, email, contextID string) error {
+	id, err := contextid.Normalize(contextID)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_recent_contexts (email, context_id, viewed_at, view_count)
+		VALUES (?, ?, ?, 1)
+		ON CONFLICT(email, context_id) DO UPDATE SET
+			viewed_at = excluded.viewed_at,
+			view_count = view_count + 1
+	`, email, id, now); err != nil {
+		return fmt.Errorf("record view: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		DELETE FROM user_recent_contexts
+		WHERE email = ? AND context_id NOT IN (
+			SELECT context_id FROM user_recent_contexts
+			WHERE email = ?
+			ORDER BY viewed_at DESC
+			LIMIT ?
+		)
+	`, email, email, s.maxRecent); err != nil {
+		return fmt.Errorf("trim recent: %w", err)
+	}
+	return nil
+}
+
+// Recent returns the user's recently viewed contexts, newest first.
+func (s *Store) Recent(ctx context.Context, email string, limit int) ([]RecentContext, error) {
+	if limit <= 0 || limit > s.maxRecent {
+		limit = s.maxRecent
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT context_id, viewed_at, view_count
+		FROM user_recent_contexts
+		WHERE email = ?
+		ORDER BY viewed_at DESC
+		LIMIT ?
+	`, email, limit)
+	if err != nil {
+		return nil, fmt.Errorf("select recent: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := []RecentContext{}
+	for rows.Next() {
+		var rc RecentContext
+		if err := rows.Scan(&rc.ContextID, &rc.ViewedAt, &rc.ViewCount); err != nil {
+			return nil, fmt.Errorf("scan recent: %w", err)
+		}
+		out = append(out, rc)
+	}
+	return out, rows.Err()
+}
+
+// ClearRecent removes the user's recently viewed history.
+func (s *Store) ClearRecent(ctx context.Context, email string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM user_recent_contexts WHERE email = ?`, email); err != nil {
+		return fmt.Errorf("clear recent: %w", err)
+	}
+	return nil
+}
+
+// Bookmarks returns the user's bookmarks, newest first.
+func (s *Store) Bookmarks(ctx context.Context, email string) ([]Bookmark, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT context_id, label, created_at
+		FROM user_bookmarks
+		WHERE email = ?
+		ORDER BY created_at DESC
+	`, email)
+	if err != nil {
+		return nil, fmt.Errorf("select bookmarks: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := []Bookmark{}
+	for rows.Next() {
+		var b Bookmark
+		if err := rows.Scan(&b.ContextID, &b.Label, &b.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan bookmark: %w", err)
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+// AddBookmark saves contextID for the user. Re-adding an existing bookmark
+// updates its label and keeps the original creation time.
+func (s *Store) AddBookmark(ctx context.Context, email, contextID, label string) (*Bookmark, error) {
+	id, err := contextid.Normalize(contextID)
+	if err != nil {
+		return nil, err
+	}
+	label = strings.TrimSpace(label)
+	if len(label) > MaxLabelLength {
+		// Cut on a rune boundary so the stored label stays valid UTF-8.
+		n := MaxLabelLength
+		for n > 0 && !utf8.RuneStart(label[n]) {
+			n--
+		}
+		label = label[:n]
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_bookmarks (email, context_id, label, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(email, context_id) DO UPDATE SET label = excluded.label
+	`, email, id, label, time.Now().UTC()); err != nil {
+		return nil, fmt.Errorf("insert bookmark: %w", err)
+	}
+
+	b := &Bookmark{ContextID: id}
+	row := s.db.QueryRowContext(ctx, `
+		SELECT label, created_at FROM user_bookmarks WHERE email = ? AND context_id = ?
+	`, email, id)
+	if err := row.Scan(&b.Label, &b.CreatedAt); err != nil {
+		return nil, fmt.Errorf("select bookmark: %w", err)
+	}
+	return b, nil
+}
+
+// RemoveBookmark deletes a bookmark. It reports whether one existed.
+func (s *Store) RemoveBookmark(ctx context.Context, email, contextID string) (bool, error) {
+	id, err := contextid.Normalize(contextID)
+	if err != nil {
+		return false, err
+	}
+	res, err := s.db.ExecContext(ctx, `DELETE FROM user_bookmarks WHERE email = ? AND context_id = ?`, email, id)
+	if err != nil {
+		return false, fmt.Errorf("delete bookmark: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
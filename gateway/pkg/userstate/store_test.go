@@ -0,0 +1,145 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package userstate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/strongdm/cxdb/gateway/pkg/contextid"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "gateway.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	s, err := NewStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func recentIDs(t *testing.T, s *Store, email string, limit int) string {
+	t.Helper()
+	recent, err := s.Recent(context.Background(), email, limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]string, len(recent))
+	for i, rc := range recent {
+		ids[i] = rc.ContextID
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestRecent(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+	s.maxRecent = 3
+
+	for _, id := range []string{"1", "2", "01", "3", "4"} {
+		if err := s.RecordView(ctx, "alice@example.com", id); err != nil {
+			t.Fatalf("RecordView(%s): %v", id, err)
+		}
+	}
+	if err := s.RecordView(ctx, "bob@example.com", "2"); err != nil {
+		t.Fatal(err)
+	}
+
+	// "01" is context 1 again, so 2 fell off the end; bob's view is his own.
+	if got := recentIDs(t, s, "alice@example.com", 0); got != "4,3,1" {
+		t.Fatalf("alice recent = %s, want 4,3,1", got)
+	}
+	if got := recentIDs(t, s, "alice@example.com", 2); got != "4,3" {
+		t.Fatalf("alice recent limit 2 = %s", got)
+	}
+	if got := recentIDs(t, s, "bob@example.com", 0); got != "2" {
+		t.Fatalf("bob recent = %s", got)
+	}
+	recent, _ := s.Recent(ctx, "alice@example.com", 0)
+	if recent[2].ViewCount != 2 {
+		t.Fatalf("context 1 view count = %d, want 2", recent[2].ViewCount)
+	}
+
+	if err := s.RecordView(ctx, "alice@example.com", "abc"); !errors.Is(err, contextid.ErrInvalid) {
+		t.Fatalf("RecordView(abc) err = %v", err)
+	}
+
+	if err := s.ClearRecent(ctx, "alice@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if got := recentIDs(t, s, "alice@example.com", 0); got != "" {
+		t.Fatalf("alice recent after clear = %s", got)
+	}
+	if got := recentIDs(t, s, "bob@example.com", 0); got != "2" {
+		t.Fatalf("clearing alice touched bob: %s", got)
+	}
+}
+
+func TestBookmarks(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	first, err := s.AddBookmark(ctx, "alice@example.com", "007", "  triage  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.ContextID != "7" || first.Label != "triage" {
+		t.Fatalf("bookmark = %+v", first)
+	}
+	relabeled, err := s.AddBookmark(ctx, "alice@example.com", "7", strings.Repeat("x", MaxLabelLength+10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(relabeled.Label) != MaxLabelLength || !relabeled.CreatedAt.Equal(first.CreatedAt) {
+		t.Fatalf("relabeled = %+v, want a truncated label and the original time", relabeled)
+	}
+	// Multi-byte labels are cut before the rune that crosses the limit.
+	wide, err := s.AddBookmark(ctx, "carol@example.com", "9", "x"+strings.Repeat("é", MaxLabelLength))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "x" + strings.Repeat("é", (MaxLabelLength-1)/2); wide.Label != want || !utf8.ValidString(wide.Label) {
+		t.Fatalf("label = %q (%d bytes), want %d bytes of valid UTF-8", wide.Label, len(wide.Label), len(want))
+	}
+	if _, err := s.AddBookmark(ctx, "bob@example.com", "8", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	bookmarks, err := s.Bookmarks(ctx, "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bookmarks) != 1 || bookmarks[0].ContextID != "7" {
+		t.Fatalf("alice bookmarks = %+v", bookmarks)
+	}
+
+	// Bob cannot remove alice's bookmark.
+	if removed, err := s.RemoveBookmark(ctx, "bob@example.com", "7"); err != nil || removed {
+		t.Fatalf("bob removed alice's bookmark: %t, %v", removed, err)
+	}
+	if removed, err := s.RemoveBookmark(ctx, "alice@example.com", " 7"); err != nil || !removed {
+		t.Fatalf("RemoveBookmark = %t, %v", removed, err)
+	}
+	if removed, _ := s.RemoveBookmark(ctx, "alice@example.com", "7"); removed {
+		t.Fatal("removed a bookmark twice")
+	}
+	if _, err := s.AddBookmark(ctx, "alice@example.com", "-1", ""); !errors.Is(err, contextid.ErrInvalid) {
+		t.Fatalf("AddBookmark(-1) err = %v", err)
+	}
+	if _, err := s.RemoveBookmark(ctx, "alice@example.com", ""); !errors.Is(err, contextid.ErrInvalid) {
+		t.Fatalf("RemoveBookmark(\"\") err = %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/strongdm/cxdb/gateway/pkg/tracing"
+)
+
+// Headers set on every delivery.
+const (
+	HeaderEvent     = "X-CXDB-Event"
+	HeaderDelivery  = "X-CXDB-Delivery"
+	HeaderTimestamp = "X-CXDB-Timestamp"
+	HeaderSignature = "X-CXDB-Signature"
+)
+
+// Sign returns the X-CXDB-Signature value for a delivery body sent at
+// timestamp (Unix seconds, as in X-CXDB-Timestamp): "sha256=" and the hex
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the hook's secret.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a received delivery's signature, and that its timestamp is
+// within tolerance of now so a captured request cannot be replayed later.
+func Verify(secret string, h http.Header, body []byte, tolerance time.Duration) error {
+	ts, err := strconv.ParseInt(h.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return errors.New("webhook: missing or malformed timestamp")
+	}
+	if age := time.Since(time.Unix(ts, 0)); age > tolerance || age < -tolerance {
+		return errors.New("webhook: timestamp outside tolerance")
+	}
+	if !hmac.Equal([]byte(h.Get(HeaderSignature)), []byte(Sign(secret, ts, body))) {
+		return errors.New("webhook: signature mismatch")
+	}
+	return nil
+}
+
+// worker delivers h's queue in order until ctx is done.
+func (d *Dispatcher) worker(ctx context.Context, h *hook) {
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case delivery := <-h.queue:
+					d.deadLetter(h, delivery, 0, "shutdown")
+				default:
+					return
+				}
+			}
+		case delivery := <-h.queue:
+			if d.wants(ctx, h, delivery) {
+				d.deliver(ctx, h, delivery)
+			}
+		}
+	}
+}
+
+// deliver sends one delivery, retrying network errors, 408, 429 and 5xx
+// responses with backoff. Other responses count as permanent failures.
+func (d *Dispatcher) deliver(ctx context.Context, h *hook, delivery *Delivery) {
+	body, err := json.Marshal(delivery)
+	if err != nil {
+		d.deadLetter(h, delivery, 0, err.Error())
+		return
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= d.maxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				d.deadLetter(h, delivery, attempt-1, "shutdown after: "+lastErr.Error())
+				return
+			case <-time.After(d.backoff(attempt - 1)):
+			}
+		}
+
+		retry, err := d.post(ctx, h, delivery, body, attempt)
+		if err == nil {
+			return
+		}
+		lastErr = err
+		d.logger.Warn("webhook_delivery_failed",
+			"hook", h.Name, "delivery", delivery.ID, "type", delivery.Type,
+			"attempt", attempt, "err", err)
+		if !retry {
+			d.deadLetter(h, delivery, attempt, err.Error())
+			return
+		}
+	}
+	d.deadLetter(h, delivery, d.maxAttempts, lastErr.Error())
+}
+
+// post makes one delivery attempt. retry reports whether a failure is
+// worth retrying.
+func (d *Dispatcher) post(ctx context.Context, h *hook, delivery *Delivery, body []byte, attempt int) (retry bool, err error) {
+	ctx, span := tracing.Start(ctx, "webhook.deliver", tracing.KindInternal)
+	span.SetAttr("webhook.name", h.Name)
+	span.SetAttr("webhook.event", delivery.Type)
+	span.SetAttr("webhook.attempt", attempt)
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.Type)
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(h.Secret, ts, body))
+
+	resp, err := d.deliverC.Do(req)
+	if err != nil {
+		return true, err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry = resp.StatusCode >= 500 ||
+		resp.StatusCode == http.StatusRequestTimeout ||
+		resp.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("receiver returned %d", resp.StatusCode)
+}
+
+// deadLetter records a delivery that will not be retried.
+func (d *Dispatcher) deadLetter(h *hook, delivery *Delivery, attempts int, reason string) {
+	d.logger.Error("webhook_dead_letter",
+		"hook", h.Name, "delivery", delivery.ID, "type", delivery.Type,
+		"attempts", attempts, "err", reason)
+	if err := d.deadLetters.append(deadLetter{
+		Hook:     h.Name,
+		Attempts: attempts,
+		Error:    reason,
+		FailedAt: time.Now().UTC(),
+		Delivery: delivery,
+	}); err != nil {
+		d.logger.Error("webhook_dead_letter_write_failed", "path", d.deadLetters.path, "err", err)
+	}
+}
+
+// deadLetter is one line of the dead-letter log.
+type deadLetter struct {
+	Hook     string    `json:"hook"`
+	Attempts int       `json:"attempts"`
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
+	Delivery *Delivery `json:"delivery"`
+}
+
+// deadLetterLog appends dead letters to a JSON Lines file.
+type deadLetterLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+func (l *deadLetterLog) append(entry deadLetter) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o750); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,60 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package webhook
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+)
+
+// maxEventLine bounds one line of the backend event stream.
+const maxEventLine = 1 << 20
+
+// errStreamClosed reports that the backend ended the event stream.
+var errStreamClosed = errors.New("event stream closed")
+
+// readEvents parses a server-sent event stream, calling fn for each event,
+// until r fails or ends. Comments such as the backend's heartbeats are
+// skipped.
+func readEvents(r io.Reader, fn func(eventType string, data []byte)) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), maxEventLine)
+
+	var eventType string
+	var data bytes.Buffer
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case line == "":
+			if data.Len() > 0 {
+				if eventType == "" {
+					eventType = "message"
+				}
+				fn(eventType, bytes.Clone(data.Bytes()))
+			}
+			eventType = ""
+			data.Reset()
+		case strings.HasPrefix(line, ":"):
+		default:
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				eventType = value
+			case "data":
+				if data.Len() > 0 {
+					data.WriteByte('\n')
+				}
+				data.WriteString(value)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return errStreamClosed
+}
@@ -0,0 +1,250 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+// Package webhook delivers backend events to external HTTP receivers. The
+// dispatcher follows the backend's /v1/events stream and POSTs each event
+// to every hook that subscribes to its type and whose label and client tag
+// filters the event's context matches. Deliveries are signed with
+// HMAC-SHA256, retried with exponential backoff, and recorded in a
+// dead-letter log when every attempt fails.
+//
+// Events that occur while the gateway is disconnected from the backend are
+// not replayed.
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/strongdm/cxdb/gateway/internal/config"
+	"github.com/strongdm/cxdb/gateway/pkg/cqlfilter"
+	"github.com/strongdm/cxdb/gateway/pkg/tracing"
+)
+
+const (
+	// queueSize bounds the deliveries waiting for one hook. Events for a
+	// hook whose queue is full go straight to the dead-letter log.
+	queueSize = 1000
+	// maxStreamBackoff caps the wait between event stream reconnects.
+	maxStreamBackoff = 30 * time.Second
+	// maxRetryBackoff caps the wait between delivery attempts.
+	maxRetryBackoff = 5 * time.Minute
+)
+
+// Delivery is the JSON body of a webhook request.
+type Delivery struct {
+	// ID is unique per event and hook; retries reuse it, so receivers can
+	// drop duplicates.
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	// OccurredAt is when the gateway received the event, in Unix
+	// milliseconds.
+	OccurredAt int64 `json:"occurred_at_unix_ms"`
+	// Data is the backend event, e.g. context_id and turn_id for
+	// turn_appended.
+	Data json.RawMessage `json:"data"`
+}
+
+// Dispatcher follows the backend event stream and delivers webhooks.
+type Dispatcher struct {
+	backend     string
+	hooks       []*hook
+	maxAttempts int
+	logger      *slog.Logger
+	deadLetters *deadLetterLog
+
+	stream   *http.Client // backend event stream, never times out
+	backendC *http.Client // backend searches
+	deliverC *http.Client // webhook requests
+
+	// backoff is the wait before delivery attempt n+1.
+	backoff func(n int) time.Duration
+}
+
+type hook struct {
+	config.Webhook
+	events map[string]bool
+	filter *cqlfilter.Filter // label and client tag filters; nil matches all
+	queue  chan *Delivery
+}
+
+// New returns a dispatcher for cfg that reads events from the backend at
+// backendURL. Call Run to start it.
+func New(cfg config.WebhooksConfig, backendURL string, logger *slog.Logger) *Dispatcher {
+	d := &Dispatcher{
+		backend:     strings.TrimSuffix(backendURL, "/"),
+		maxAttempts: cfg.MaxAttempts,
+		logger:      logger,
+		deadLetters: &deadLetterLog{path: cfg.DeadLetterPath},
+		stream:      &http.Client{Transport: tracing.Transport(nil)},
+		backendC:    &http.Client{Transport: tracing.Transport(nil), Timeout: 5 * time.Second},
+		deliverC:    &http.Client{Transport: tracing.Transport(nil), Timeout: cfg.Timeout},
+		backoff:     retryBackoff,
+	}
+	for _, h := range cfg.Hooks {
+		events := make(map[string]bool, len(h.Events))
+		for _, e := range h.Events {
+			events[e] = true
+		}
+		var filter *cqlfilter.Filter
+		if cond := filterCondition(h); cond != "" {
+			filter = cqlfilter.New(cond, d.backendGet)
+		}
+		d.hooks = append(d.hooks, &hook{
+			Webhook: h,
+			events:  events,
+			filter:  filter,
+			queue:   make(chan *Delivery, queueSize),
+		})
+	}
+	return d
+}
+
+// Run delivers events until ctx is done. Deliveries still queued then are
+// written to the dead-letter log.
+func (d *Dispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, h := range d.hooks {
+		wg.Add(1)
+		go func(h *hook) {
+			defer wg.Done()
+			d.worker(ctx, h)
+		}(h)
+	}
+
+	wait := time.Second
+	for ctx.Err() == nil {
+		connected, err := d.follow(ctx)
+		if ctx.Err() != nil {
+			break
+		}
+		if connected {
+			wait = time.Second
+		}
+		d.logger.Warn("webhook_event_stream_failed", "err", err, "retry_in", wait)
+		select {
+		case <-ctx.Done():
+		case <-time.After(wait):
+		}
+		wait = min(2*wait, maxStreamBackoff)
+	}
+	wg.Wait()
+}
+
+// follow reads the backend event stream until it fails. connected reports
+// whether the stream was established, to reset the reconnect backoff.
+func (d *Dispatcher) follow(ctx context.Context) (connected bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.backend+"/v1/events", nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := d.stream.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("backend returned %d", resp.StatusCode)
+	}
+	d.logger.Info("webhook_event_stream_connected", "hooks", len(d.hooks))
+	return true, readEvents(resp.Body, d.dispatch)
+}
+
+// dispatch queues one event for every hook subscribed to its type. Context
+// filters are applied by the hook's worker, so a slow backend search holds
+// up only that hook and never the event stream.
+func (d *Dispatcher) dispatch(eventType string, data []byte) {
+	for _, h := range d.hooks {
+		if !h.events[eventType] {
+			continue
+		}
+		delivery := &Delivery{
+			ID:         newDeliveryID(),
+			Type:       eventType,
+			OccurredAt: time.Now().UnixMilli(),
+			Data:       json.RawMessage(data),
+		}
+		select {
+		case h.queue <- delivery:
+		default:
+			d.deadLetter(h, delivery, 0, "queue full")
+		}
+	}
+}
+
+// wants reports whether h's filters match the context of delivery. Events
+// without a context never match a filtered hook.
+func (d *Dispatcher) wants(ctx context.Context, h *hook, delivery *Delivery) bool {
+	if h.filter == nil {
+		return true
+	}
+	contextID, ok := eventContextID(delivery.Data)
+	if !ok {
+		d.logger.Warn("webhook_event_without_context", "hook", h.Name, "type", delivery.Type)
+		return false
+	}
+	matches, err := h.filter.Matches(ctx, contextID)
+	if err != nil {
+		d.logger.Warn("webhook_filter_failed", "hook", h.Name, "context_id", contextID, "err", err)
+		return false
+	}
+	return matches
+}
+
+// eventContextID extracts context_id, a number or a decimal string.
+func eventContextID(data []byte) (uint64, bool) {
+	var event struct {
+		ContextID json.RawMessage `json:"context_id"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil || len(event.ContextID) == 0 {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(strings.Trim(string(event.ContextID), `"`), 10, 64)
+	return id, err == nil
+}
+
+// filterCondition renders a hook's label and client tag filters as a CQL
+// condition.
+func filterCondition(h config.Webhook) string {
+	var conds []string
+	if len(h.Labels) > 0 {
+		conds = append(conds, cqlfilter.In("label", h.Labels))
+	}
+	if len(h.ClientTags) > 0 {
+		conds = append(conds, cqlfilter.In("tag", h.ClientTags))
+	}
+	return strings.Join(conds, " AND ")
+}
+
+// backendGet sends a GET for path to the backend.
+func (d *Dispatcher) backendGet(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.backend+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return d.backendC.Do(req)
+}
+
+func newDeliveryID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// retryBackoff doubles from one second up to maxRetryBackoff.
+func retryBackoff(n int) time.Duration {
+	if n > 20 {
+		return maxRetryBackoff
+	}
+	return min(time.Second<<(n-1), maxRetryBackoff)
+}
@@ -0,0 +1,313 @@
+// Copyright 2025 StrongDM Inc
+// SPDX-License-Identifier: Apache-2.0
+
+package webhook
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/strongdm/cxdb/gateway/internal/config"
+)
+
+func TestReadEvents(t *testing.T) {
+	stream := ":heartbeat\n\n" +
+		"event: turn_appended\ndata: {\"context_id\":1}\n\n" +
+		"data: a\ndata: b\n\n"
+	var got []string
+	err := readEvents(strings.NewReader(stream), func(eventType string, data []byte) {
+		got = append(got, eventType+" "+string(data))
+	})
+	if err != errStreamClosed {
+		t.Errorf("err = %v", err)
+	}
+	want := []string{`turn_appended {"context_id":1}`, "message a\nb"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"id":"x"}`)
+	now := time.Now().Unix()
+	h := http.Header{}
+	h.Set(HeaderTimestamp, fmt.Sprint(now))
+	h.Set(HeaderSignature, Sign("s3cret", now, body))
+	if err := Verify("s3cret", h, body, time.Minute); err != nil {
+		t.Errorf("valid signature rejected: %v", err)
+	}
+	if err := Verify("other", h, body, time.Minute); err == nil {
+		t.Error("wrong secret accepted")
+	}
+	if err := Verify("s3cret", h, []byte(`{"id":"y"}`), time.Minute); err == nil {
+		t.Error("tampered body accepted")
+	}
+	h.Set(HeaderTimestamp, fmt.Sprint(now-600))
+	h.Set(HeaderSignature, Sign("s3cret", now-600, body))
+	if err := Verify("s3cret", h, body, time.Minute); err == nil {
+		t.Error("stale timestamp accepted")
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	var searches atomic.Int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/events":
+			w.Header().Set("Content-Type", "text/event-stream")
+			for _, id := range []int{1, 2} {
+				_, _ = fmt.Fprintf(w, "event: turn_appended\ndata: {\"context_id\":%d,\"turn_id\":%d}\n\n", id, 10+id)
+			}
+			_, _ = io.WriteString(w, "event: client_connected\ndata: {}\n\n")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		case "/v1/contexts/search":
+			searches.Add(1)
+			if strings.HasPrefix(r.URL.Query().Get("q"), `id = 1 AND label IN ("prod")`) {
+				_, _ = io.WriteString(w, `{"contexts":[{"context_id":"1"}]}`)
+				return
+			}
+			_, _ = io.WriteString(w, `{"contexts":[]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer backend.Close()
+
+	type received struct {
+		hook string
+		body Delivery
+	}
+	got := make(chan received, 10)
+	var flaky atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hook := strings.TrimPrefix(r.URL.Path, "/")
+		body, _ := io.ReadAll(r.Body)
+		if err := Verify("secret-"+hook, r.Header, body, time.Minute); err != nil {
+			t.Errorf("%s: %v", hook, err)
+		}
+		switch {
+		case hook == "flaky" && flaky.Add(1) == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		case hook == "rejecting":
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		var d Delivery
+		if err := json.Unmarshal(body, &d); err != nil {
+			t.Errorf("%s: %v", hook, err)
+		}
+		if r.Header.Get(HeaderEvent) != d.Type || r.Header.Get(HeaderDelivery) != d.ID {
+			t.Errorf("%s: headers %v do not match %+v", hook, r.Header, d)
+		}
+		got <- received{hook: hook, body: d}
+	}))
+	defer receiver.Close()
+
+	hook := func(name string, labels ...string) config.Webhook {
+		return config.Webhook{
+			Name:   name,
+			URL:    receiver.URL + "/" + name,
+			Secret: "secret-" + name,
+			Events: []string{"turn_appended"},
+			Labels: labels,
+		}
+	}
+	deadLetters := filepath.Join(t.TempDir(), "dead.jsonl")
+	d := New(config.WebhooksConfig{
+		Hooks:          []config.Webhook{hook("all"), hook("flaky", "prod"), hook("rejecting")},
+		DeadLetterPath: deadLetters,
+		MaxAttempts:    3,
+		Timeout:        time.Second,
+	}, backend.URL, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	d.backoff = func(int) time.Duration { return 0 }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Run(ctx)
+	}()
+
+	counts := map[string]int{}
+	for i := 0; i < 3; i++ {
+		select {
+		case r := <-got:
+			counts[r.hook]++
+			if r.body.Type != "turn_appended" {
+				t.Errorf("%s received %+v", r.hook, r.body)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out; received %v", counts)
+		}
+	}
+	// The unfiltered hook gets both events; the labeled one only context 1,
+	// after a retry.
+	if counts["all"] != 2 || counts["flaky"] != 1 || flaky.Load() != 2 {
+		t.Errorf("received %v, flaky attempts %d", counts, flaky.Load())
+	}
+
+	// The rejecting hook gives up on the first 410.
+	var letters []deadLetter
+	deadline := time.Now().Add(5 * time.Second)
+	for (len(letters) < 2 || searches.Load() < 2) && time.Now().Before(deadline) {
+		letters = readDeadLetters(t, deadLetters)
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+	if n := searches.Load(); n != 2 {
+		t.Errorf("backend searched %d times, want 2", n)
+	}
+	if len(letters) != 2 {
+		t.Fatalf("dead letters = %+v", letters)
+	}
+	for _, l := range letters {
+		if l.Hook != "rejecting" || l.Attempts != 1 || !strings.Contains(l.Error, "410") || l.Delivery == nil {
+			t.Errorf("dead letter = %+v", l)
+		}
+	}
+}
+
+func TestDispatcherFilters(t *testing.T) {
+	release := make(chan struct{})
+	var searches atomic.Int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/events":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, "event: turn_appended\ndata: {\"context_id\":5}\n\n")
+			_, _ = io.WriteString(w, "event: turn_appended\ndata: {\"turn_id\":9}\n\n")
+			_, _ = io.WriteString(w, "event: turn_appended\ndata: {\"context_id\":\"1\"}\n\n")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		case "/v1/contexts/search":
+			// Searches stall until the unfiltered hook has had every event.
+			<-release
+			searches.Add(1)
+			if strings.HasPrefix(r.URL.Query().Get("q"), "id = 1 AND ") {
+				_, _ = io.WriteString(w, `{"contexts":[{"context_id":"1"}]}`)
+				return
+			}
+			_, _ = io.WriteString(w, `{"contexts":[]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer backend.Close()
+
+	type received struct {
+		hook string
+		data string
+	}
+	got := make(chan received, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var d Delivery
+		if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+			t.Error(err)
+		}
+		got <- received{hook: strings.TrimPrefix(r.URL.Path, "/"), data: string(d.Data)}
+	}))
+	defer receiver.Close()
+
+	hook := func(name string, tags ...string) config.Webhook {
+		return config.Webhook{Name: name, URL: receiver.URL + "/" + name, Secret: "s", Events: []string{"turn_appended"}, ClientTags: tags}
+	}
+	deadLetters := filepath.Join(t.TempDir(), "dead.jsonl")
+	d := New(config.WebhooksConfig{
+		// The filtered hook comes first: its verdicts must not affect the
+		// hooks after it.
+		Hooks:          []config.Webhook{hook("tagged", "agent"), hook("all")},
+		DeadLetterPath: deadLetters,
+		MaxAttempts:    1,
+		Timeout:        time.Second,
+	}, backend.URL, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	next := func() received {
+		t.Helper()
+		select {
+		case r := <-got:
+			return r
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a delivery")
+			return received{}
+		}
+	}
+
+	// The unfiltered hook gets every event while the filtered hook's search
+	// is stalled.
+	var all []string
+	for i := 0; i < 3; i++ {
+		r := next()
+		if r.hook != "all" {
+			t.Fatalf("%s received %s before searches were released", r.hook, r.data)
+		}
+		all = append(all, r.data)
+	}
+	if want := `[{"context_id":5} {"turn_id":9} {"context_id":"1"}]`; fmt.Sprint(all) != want {
+		t.Errorf("all received %v, want %s", all, want)
+	}
+
+	// The filtered hook skips the miss and the event without a context.
+	close(release)
+	if r := next(); r.hook != "tagged" || r.data != `{"context_id":"1"}` {
+		t.Errorf("tagged received %+v", r)
+	}
+	if n := searches.Load(); n != 2 {
+		t.Errorf("backend searched %d times, want 2", n)
+	}
+	select {
+	case r := <-got:
+		t.Errorf("unexpected delivery %+v", r)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if letters := readDeadLetters(t, deadLetters); len(letters) != 0 {
+		t.Errorf("dead letters = %+v", letters)
+	}
+}
+
+func readDeadLetters(t *testing.T, path string) []deadLetter {
+	t.Helper()
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	var letters []deadLetter
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var l deadLetter
+		if err := json.Unmarshal(sc.Bytes(), &l); err != nil {
+			t.Fatalf("dead letter %q: %v", sc.Text(), err)
+		}
+		letters = append(letters, l)
+	}
+	return letters
+}